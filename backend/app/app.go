@@ -1,14 +1,13 @@
 package app
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
-	"strconv"
 	"strings"
 	"time"
 	"unicode"
@@ -28,9 +27,13 @@ import (
 	"helpmeclean-backend/internal/graph"
 	"helpmeclean-backend/internal/graph/resolver"
 	dochandler "helpmeclean-backend/internal/handler"
+	"helpmeclean-backend/internal/jobs"
 	custommiddleware "helpmeclean-backend/internal/middleware"
+	"helpmeclean-backend/internal/service/accounting"
+	"helpmeclean-backend/internal/service/anaf"
 	"helpmeclean-backend/internal/service/email"
 	"helpmeclean-backend/internal/service/invoice"
+	"helpmeclean-backend/internal/service/ledger"
 	"helpmeclean-backend/internal/service/payment"
 	"helpmeclean-backend/internal/storage"
 	"helpmeclean-backend/internal/webhook"
@@ -81,8 +84,8 @@ func NewHandler(ctx context.Context) (http.Handler, func(), error) {
 
 	queries := db.New(pool)
 
-	paymentSvc := payment.NewService(queries)
-	invoiceSvc := invoice.NewService(queries)
+	ledgerSvc := ledger.NewService(pool, queries)
+	paymentSvc := payment.NewService(pool, queries, ledgerSvc, payment.NewPaymentProvider())
 	emailSvc := email.NewService()
 
 	// File storage — always GCS.
@@ -103,33 +106,77 @@ func NewHandler(ctx context.Context) (http.Handler, func(), error) {
 	store := gcsStore
 	log.Printf("Using Google Cloud Storage: bucket=%s, project=%s", gcsBucket, gcsProjectID)
 
+	anafRegistry := anaf.NewRegistry(anaf.DefaultRegistryURL)
+	invoiceSvc := invoice.NewService(pool, queries, ledgerSvc, store, anafRegistry, emailSvc)
+	accountingSvc := accounting.NewService(queries, store)
+
 	// Stripe webhook — must be registered BEFORE auth middleware.
 	stripeWebhook := webhook.NewStripeHandler(paymentSvc)
 	r.Post("/webhook/stripe", stripeWebhook.ServeHTTP)
 
 	// ANAF company lookup proxy — CORS-safe server-side relay for Romanian tax authority.
-	r.Get("/api/company-lookup", anafCompanyLookupHandler())
+	r.Get("/api/company-lookup", anafCompanyLookupHandler(anafRegistry))
 
 	// Document download — public proxy that streams private files from GCS.
 	// Security: document UUIDs (v4) are cryptographically unguessable.
 	r.Get("/api/documents/{id}", dochandler.NewDocumentHandler(queries, store))
 
+	// Invoice PDF download — rendered by the backend, authorized per invoice.
+	r.With(auth.AuthMiddleware).Get("/api/invoices/{id}/pdf", dochandler.NewInvoicePDFHandler(queries, invoiceSvc))
+
+	// Accounting export download — authorized per export.
+	r.With(auth.AuthMiddleware).Get("/api/accounting-exports/{id}", dochandler.NewAccountingExportHandler(queries, accountingSvc))
+
 	authzHelper := custommiddleware.NewAuthzHelper(queries)
 
 	res := &resolver.Resolver{
-		Pool:           pool,
-		Queries:        queries,
-		PaymentService: paymentSvc,
-		InvoiceService: invoiceSvc,
-		EmailService:   emailSvc,
-		Storage:        store,
-		AuthzHelper:    authzHelper,
+		Pool:              pool,
+		Queries:           queries,
+		PaymentService:    paymentSvc,
+		InvoiceService:    invoiceSvc,
+		LedgerService:     ledgerSvc,
+		AccountingService: accountingSvc,
+		EmailService:      emailSvc,
+		Storage:           store,
+		AuthzHelper:       authzHelper,
 	}
 
 	// Wire auto-confirm callback: when payment webhook succeeds, create chat room.
 	paymentSvc.OnBookingConfirmed = func(ctx context.Context, booking db.Booking) {
 		res.CreateBookingChatFromPayment(ctx, booking)
 	}
+	// Companies that invoice on payment get the client invoice issued once the
+	// payment succeeds.
+	paymentSvc.OnBookingPaid = func(ctx context.Context, booking db.Booking) {
+		invoiceSvc.AutoIssue(ctx, booking, invoice.TriggerPaymentSucceeded)
+	}
+	// Every refund, whether made by an admin or in Stripe, is credited on the
+	// booking's invoices.
+	paymentSvc.OnRefunded = invoiceSvc.IssueRefundCredit
+
+	// Background jobs — triggered by an external scheduler via POST /jobs/{name},
+	// or run in-process when SCHEDULER_ENABLED=true (long-lived server).
+	scheduler := jobs.NewScheduler()
+	scheduler.Register("recurring-occurrences", 6*time.Hour, res.GenerateRecurringOccurrences)
+	scheduler.Register("recurring-charges", time.Hour, res.ChargeRecurringOccurrences)
+	scheduler.Register("stripe-events", 5*time.Minute, paymentSvc.RetryWebhookEvents)
+	scheduler.Register("company-payouts", 6*time.Hour, res.RunCompanyPayouts)
+	scheduler.Register("payment-holds", 6*time.Hour, paymentSvc.RunPaymentHolds)
+	scheduler.Register("ledger-reconciliation", 24*time.Hour, paymentSvc.ReconcileLedger)
+	scheduler.Register("efactura-status", 15*time.Minute, invoiceSvc.PollEFacturaStatus)
+	scheduler.Register("invoice-pipeline", 15*time.Minute, invoiceSvc.RunInvoicePipelines)
+	scheduler.Register("commission-month", 24*time.Hour, res.RunCommissionMonth)
+	scheduler.Register("accounting-exports", 15*time.Minute, accountingSvc.RunPendingExports)
+	r.Post("/jobs/{name}", scheduler.Handler())
+	if os.Getenv("SCHEDULER_ENABLED") == "true" {
+		jobsCtx, stopJobs := context.WithCancel(context.Background())
+		scheduler.Start(jobsCtx)
+		closePool := shutdown
+		shutdown = func() {
+			stopJobs()
+			closePool()
+		}
+	}
 
 	srv := handler.New(graph.NewExecutableSchema(graph.Config{
 		Resolvers: res,
@@ -168,57 +215,35 @@ func NewHandler(ctx context.Context) (http.Handler, func(), error) {
 }
 
 // anafCompanyLookupHandler returns the ANAF company lookup proxy handler.
-func anafCompanyLookupHandler() http.HandlerFunc {
+func anafCompanyLookupHandler(registry *anaf.Registry) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
-		cuiStr := strings.TrimSpace(req.URL.Query().Get("cui"))
-		cuiStr = strings.TrimPrefix(strings.ToUpper(cuiStr), "RO")
-		cuiNum, err := strconv.Atoi(strings.TrimSpace(cuiStr))
-		if err != nil || cuiNum <= 0 {
-			w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Type", "application/json")
+		cui := req.URL.Query().Get("cui")
+		if _, err := anaf.ParseCUI(cui); err != nil {
 			http.Error(w, `{"error":"invalid cui"}`, http.StatusBadRequest)
 			return
 		}
-		today := time.Now().Format("2006-01-02")
-		payload, _ := json.Marshal([]map[string]interface{}{{"cui": cuiNum, "data": today}})
-		resp, err := http.Post(
-			"https://webservicesp.anaf.ro/api/PlatitorTvaRest/v9/tva",
-			"application/json",
-			bytes.NewReader(payload),
-		)
-		if err != nil {
-			w.Header().Set("Content-Type", "application/json")
-			http.Error(w, `{"error":"anaf unreachable"}`, http.StatusServiceUnavailable)
+		tp, err := registry.Lookup(req.Context(), cui, time.Now())
+		if errors.Is(err, anaf.ErrNotFound) {
+			w.Write([]byte(`{"found":false}`)) //nolint:errcheck
 			return
 		}
-		defer resp.Body.Close()
-		var anafResp struct {
-			Found []struct {
-				DateGenerale struct {
-					Denumire string `json:"denumire"`
-					Adresa   string `json:"adresa"`
-					Telefon  string `json:"telefon"`
-					NrRegCom string `json:"nrRegCom"`
-					CodCAEN  string `json:"cod_CAEN"`
-				} `json:"date_generale"`
-			} `json:"found"`
-		}
-		if err := json.NewDecoder(resp.Body).Decode(&anafResp); err != nil || len(anafResp.Found) == 0 {
-			w.Header().Set("Content-Type", "application/json")
-			w.Write([]byte(`{"found":false}`)) //nolint:errcheck
+		if err != nil {
+			log.Printf("anaf company lookup: %v", err)
+			http.Error(w, `{"error":"anaf unreachable"}`, http.StatusServiceUnavailable)
 			return
 		}
-		dg := anafResp.Found[0].DateGenerale
-		city, county, streetAddr := parseANAFAddress(dg.Adresa)
-		w.Header().Set("Content-Type", "application/json")
+		city, county, streetAddr := parseANAFAddress(tp.Address)
 		json.NewEncoder(w).Encode(map[string]interface{}{ //nolint:errcheck
 			"found":         true,
-			"companyName":   titleCaseRO(strings.TrimSpace(dg.Denumire)),
+			"companyName":   titleCaseRO(tp.Name),
 			"streetAddress": streetAddr,
 			"city":          city,
 			"county":        county,
-			"contactPhone":  strings.TrimSpace(dg.Telefon),
-			"nrRegCom":      strings.TrimSpace(dg.NrRegCom),
-			"codCaen":       strings.TrimSpace(dg.CodCAEN),
+			"contactPhone":  tp.Phone,
+			"nrRegCom":      tp.RegNumber,
+			"codCaen":       tp.CAEN,
+			"isVatPayer":    tp.VATPayer,
 		})
 	}
 }
@@ -0,0 +1,36 @@
+// Command anafstub serves an in-memory stand-in for the ANAF OAuth2 server
+// and the e-Factura SPV API, so invoices can be uploaded and polled locally.
+//
+// Usage:
+//
+//	anafstub [-addr :8090] [-polls 1] [-reject]
+//
+// Point ANAF_OAUTH_URL at http://localhost:8090/oauth and ANAF_EFACTURA_URL at
+// http://localhost:8090/api. With -reject every upload is rejected.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"helpmeclean-backend/internal/service/efactura/anafstub"
+)
+
+func main() {
+	addr := flag.String("addr", ":8090", "listen address")
+	polls := flag.Int("polls", 1, "status requests that report an upload as still being processed")
+	reject := flag.Bool("reject", false, "reject every upload")
+	flag.Parse()
+
+	stub := anafstub.New()
+	stub.ProcessingPolls = *polls
+	if *reject {
+		stub.Reject = func([]byte) []string {
+			return []string{"E: validari globale eroare: factura respinsa de anafstub"}
+		}
+	}
+
+	log.Printf("anafstub: listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, stub))
+}
@@ -0,0 +1,158 @@
+// Command matchsim replays a historical booking stream through the matching
+// algorithm under alternative MatchConfigs and reports utilisation, idle
+// gaps, fulfilment rate and workload fairness for each.
+//
+// Usage:
+//
+//	matchsim -from 2026-01-01 -to 2026-03-31 [-dump snapshot.json]
+//	matchsim -fixture snapshot.json -buffer 15,30 -weight 0,10,20
+//
+// Every comma-separated flag is one axis of the grid; all combinations are run.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/joho/godotenv"
+
+	internaldb "helpmeclean-backend/internal/db"
+	db "helpmeclean-backend/internal/db/generated"
+	"helpmeclean-backend/internal/service/matching"
+)
+
+func main() {
+	defaults := matching.DefaultMatchConfig()
+
+	fixture := flag.String("fixture", "", "load the snapshot from this JSON file instead of the database")
+	dump := flag.String("dump", "", "write the loaded snapshot to this JSON file")
+	from := flag.String("from", "", "first scheduled date of the booking stream (YYYY-MM-DD, database only)")
+	to := flag.String("to", "", "last scheduled date of the booking stream (YYYY-MM-DD, database only)")
+	buffers := flag.String("buffer", strconv.Itoa(defaults.BufferMinutes), "buffer minutes between jobs")
+	maxJobs := flag.String("max-jobs", strconv.Itoa(defaults.MaxJobsPerDay), "max jobs per cleaner per day")
+	weights := flag.String("weight", strconv.FormatFloat(defaults.LoadBalanceWeight, 'f', -1, 64), "load balance weight")
+	minAvail := flag.String("min-available", strconv.Itoa(defaults.MinAvailableCount), "min available cleaners before backfilling")
+	flag.Parse()
+
+	configs, err := configGrid(defaults, *buffers, *maxJobs, *weights, *minAvail)
+	if err != nil {
+		log.Fatalf("Invalid config grid: %v", err)
+	}
+
+	snapshot, err := load(*fixture, *from, *to)
+	if err != nil {
+		log.Fatalf("Failed to load snapshot: %v", err)
+	}
+	log.Printf("Loaded %d cleaners and %d requests", len(snapshot.Cleaners), len(snapshot.Requests))
+
+	if *dump != "" {
+		if err := writeFixture(*dump, snapshot); err != nil {
+			log.Fatalf("Failed to write snapshot: %v", err)
+		}
+		log.Printf("Snapshot written to %s", *dump)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "buffer\tmaxJobs\tweight\tminAvail\tfulfilled\trate\tbookedH\tutil\tidleH\tidleH/day\tgini\tbackfill\t")
+	for _, config := range configs {
+		r, err := matching.Simulate(snapshot, config)
+		if err != nil {
+			log.Fatalf("Simulation failed: %v", err)
+		}
+		fmt.Fprintf(w, "%d\t%d\t%.1f\t%d\t%d/%d\t%.1f%%\t%.1f\t%.1f%%\t%.1f\t%.2f\t%.3f\t%.1f%%\t\n",
+			config.BufferMinutes, config.MaxJobsPerDay, config.LoadBalanceWeight, config.MinAvailableCount,
+			r.Fulfilled, r.Requests, r.FulfilmentRate*100, r.BookedHours, r.Utilisation*100,
+			r.IdleGapHours, r.AvgIdleGapHours, r.Gini, r.BackfillRate*100)
+	}
+	w.Flush()
+}
+
+// load reads the snapshot from a fixture, or from DATABASE_URL for the given range.
+func load(fixture, from, to string) (matching.SimSnapshot, error) {
+	if fixture != "" {
+		return loadFixture(fixture)
+	}
+	if from == "" || to == "" {
+		return matching.SimSnapshot{}, fmt.Errorf("either -fixture or both -from and -to are required")
+	}
+	fromDate, err := time.Parse("2006-01-02", from)
+	if err != nil {
+		return matching.SimSnapshot{}, fmt.Errorf("invalid -from: %w", err)
+	}
+	toDate, err := time.Parse("2006-01-02", to)
+	if err != nil {
+		return matching.SimSnapshot{}, fmt.Errorf("invalid -to: %w", err)
+	}
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using environment variables")
+	}
+	ctx := context.Background()
+	pool, err := internaldb.NewPool(ctx)
+	if err != nil {
+		return matching.SimSnapshot{}, err
+	}
+	defer pool.Close()
+
+	return loadSnapshot(ctx, db.New(pool), fromDate, toDate)
+}
+
+// configGrid expands the comma-separated flag values into every combination.
+func configGrid(base matching.MatchConfig, buffers, maxJobs, weights, minAvail string) ([]matching.MatchConfig, error) {
+	bufferVals, err := parseInts(buffers)
+	if err != nil {
+		return nil, fmt.Errorf("-buffer: %w", err)
+	}
+	maxJobVals, err := parseInts(maxJobs)
+	if err != nil {
+		return nil, fmt.Errorf("-max-jobs: %w", err)
+	}
+	minAvailVals, err := parseInts(minAvail)
+	if err != nil {
+		return nil, fmt.Errorf("-min-available: %w", err)
+	}
+	var weightVals []float64
+	for _, s := range strings.Split(weights, ",") {
+		f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if err != nil {
+			return nil, fmt.Errorf("-weight: %w", err)
+		}
+		weightVals = append(weightVals, f)
+	}
+
+	var configs []matching.MatchConfig
+	for _, b := range bufferVals {
+		for _, m := range maxJobVals {
+			for _, w := range weightVals {
+				for _, a := range minAvailVals {
+					c := base
+					c.BufferMinutes = b
+					c.MaxJobsPerDay = m
+					c.LoadBalanceWeight = w
+					c.MinAvailableCount = a
+					configs = append(configs, c)
+				}
+			}
+		}
+	}
+	return configs, nil
+}
+
+func parseInts(s string) ([]int, error) {
+	var vals []int
+	for _, part := range strings.Split(s, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+		vals = append(vals, n)
+	}
+	return vals, nil
+}
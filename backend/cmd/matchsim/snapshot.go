@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	db "helpmeclean-backend/internal/db/generated"
+	"helpmeclean-backend/internal/service/matching"
+)
+
+// loadFixture reads a snapshot from a JSON file.
+func loadFixture(path string) (matching.SimSnapshot, error) {
+	var snapshot matching.SimSnapshot
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return snapshot, fmt.Errorf("failed to read fixture: %w", err)
+	}
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return snapshot, fmt.Errorf("failed to parse fixture: %w", err)
+	}
+	return snapshot, nil
+}
+
+// writeFixture saves a snapshot as indented JSON so it can be replayed later
+// without a database.
+func writeFixture(path string, snapshot matching.SimSnapshot) error {
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// loadSnapshot builds a snapshot from the database: every active cleaner of an
+// approved company with their weekly windows (company schedule applied) and
+// date overrides, plus the non-cancelled solo bookings scheduled between from
+// and to, in creation order. Bookings do not record a service area, so
+// requests and cleaners are matched by city.
+func loadSnapshot(ctx context.Context, queries *db.Queries, from, to time.Time) (matching.SimSnapshot, error) {
+	var snapshot matching.SimSnapshot
+	fromPG := pgtype.Date{Time: from, Valid: true}
+	toPG := pgtype.Date{Time: to, Valid: true}
+
+	cleaners, err := queries.ListCleanersForSimulation(ctx)
+	if err != nil {
+		return snapshot, fmt.Errorf("failed to list cleaners: %w", err)
+	}
+
+	companySchedules := map[pgtype.UUID][]db.CompanyWorkSchedule{}
+	for _, c := range cleaners {
+		schedule, ok := companySchedules[c.CompanyID]
+		if !ok {
+			schedule, err = queries.ListCompanyWorkSchedule(ctx, c.CompanyID)
+			if err != nil {
+				return snapshot, fmt.Errorf("failed to load company schedule: %w", err)
+			}
+			companySchedules[c.CompanyID] = schedule
+		}
+
+		areas, err := queries.ListCleanerServiceAreas(ctx, c.ID)
+		if err != nil {
+			return snapshot, fmt.Errorf("failed to load cleaner areas: %w", err)
+		}
+		weekly, err := queries.ListCleanerAvailability(ctx, c.ID)
+		if err != nil {
+			return snapshot, fmt.Errorf("failed to load cleaner availability: %w", err)
+		}
+		overrides, err := queries.ListCleanerDateOverrides(ctx, db.ListCleanerDateOverridesParams{
+			CleanerID:      c.ID,
+			OverrideDate:   fromPG,
+			OverrideDate_2: toPG,
+		})
+		if err != nil {
+			return snapshot, fmt.Errorf("failed to load cleaner overrides: %w", err)
+		}
+
+		sc := matching.SimCleaner{
+			ID:        uuidString(c.ID),
+			Weekly:    weeklyWindows(weekly, schedule),
+			Overrides: map[string]matching.SimOverride{},
+		}
+		if f, err := c.RatingAvg.Float64Value(); err == nil && f.Valid {
+			sc.RatingAvg = f.Float64
+		}
+		if c.TotalJobsCompleted.Valid {
+			sc.JobsDone = int(c.TotalJobsCompleted.Int32)
+		}
+		seenCity := map[string]bool{}
+		for _, a := range areas {
+			if !seenCity[a.CityName] {
+				seenCity[a.CityName] = true
+				sc.Areas = append(sc.Areas, a.CityName)
+			}
+		}
+		for _, o := range overrides {
+			// Company closed days win over cleaner overrides, as in suggestCleaners.
+			if isCompanyClosed(schedule, int32(o.OverrideDate.Time.Weekday())) {
+				continue
+			}
+			sc.Overrides[o.OverrideDate.Time.Format("2006-01-02")] = matching.SimOverride{
+				Available: o.IsAvailable,
+				Start:     matching.MicrosToHHMM(o.StartTime.Microseconds),
+				End:       matching.MicrosToHHMM(o.EndTime.Microseconds),
+			}
+		}
+		snapshot.Cleaners = append(snapshot.Cleaners, sc)
+	}
+
+	bookings, err := queries.ListBookingsForSimulation(ctx, db.ListBookingsForSimulationParams{
+		ScheduledDate:   fromPG,
+		ScheduledDate_2: toPG,
+	})
+	if err != nil {
+		return snapshot, fmt.Errorf("failed to list bookings: %w", err)
+	}
+	for _, b := range bookings {
+		hours := 0.0
+		if f, err := b.EstimatedDurationHours.Float64Value(); err == nil && f.Valid {
+			hours = f.Float64
+		}
+		req := matching.SimRequest{
+			ID:            uuidString(b.ID),
+			Area:          b.City,
+			DurationHours: hours,
+		}
+
+		slots, err := queries.ListBookingTimeSlots(ctx, b.ID)
+		if err != nil {
+			return snapshot, fmt.Errorf("failed to load booking time slots: %w", err)
+		}
+		for _, s := range slots {
+			req.Slots = append(req.Slots, matching.SimSlot{
+				Date:  s.SlotDate.Time.Format("2006-01-02"),
+				Start: matching.MicrosToHHMM(s.StartTime.Microseconds),
+				End:   matching.MicrosToHHMM(s.EndTime.Microseconds),
+			})
+		}
+		if len(req.Slots) == 0 {
+			// Legacy bookings only have a start time: the slot is the job itself.
+			start := b.ScheduledStartTime.Microseconds
+			req.Slots = []matching.SimSlot{{
+				Date:  b.ScheduledDate.Time.Format("2006-01-02"),
+				Start: matching.MicrosToHHMM(start),
+				End:   matching.MicrosToHHMM(start + int64(hours*float64(matching.HourMicros))),
+			}}
+		}
+		snapshot.Requests = append(snapshot.Requests, req)
+	}
+
+	return snapshot, nil
+}
+
+// weeklyWindows applies the availability cascade used by suggestCleaners for
+// each day of week: company closed, then the cleaner's weekly slot, then the
+// company schedule, then the default 08:00-17:00.
+func weeklyWindows(weekly []db.CleanerAvailability, schedule []db.CompanyWorkSchedule) map[int]matching.SimWindow {
+	windows := map[int]matching.SimWindow{}
+	for day := int32(0); day < 7; day++ {
+		var companyDay *db.CompanyWorkSchedule
+		for i := range schedule {
+			if schedule[i].DayOfWeek == day {
+				companyDay = &schedule[i]
+				break
+			}
+		}
+		if companyDay != nil && !companyDay.IsWorkDay {
+			continue
+		}
+
+		found := false
+		for _, slot := range weekly {
+			if slot.DayOfWeek == day && slot.IsAvailable.Valid && slot.IsAvailable.Bool {
+				windows[int(day)] = matching.SimWindow{
+					Start: matching.MicrosToHHMM(slot.StartTime.Microseconds),
+					End:   matching.MicrosToHHMM(slot.EndTime.Microseconds),
+				}
+				found = true
+				break
+			}
+		}
+		if found {
+			continue
+		}
+		if companyDay != nil {
+			windows[int(day)] = matching.SimWindow{
+				Start: matching.MicrosToHHMM(companyDay.StartTime.Microseconds),
+				End:   matching.MicrosToHHMM(companyDay.EndTime.Microseconds),
+			}
+		} else {
+			windows[int(day)] = matching.SimWindow{Start: "08:00", End: "17:00"}
+		}
+	}
+	return windows
+}
+
+func isCompanyClosed(schedule []db.CompanyWorkSchedule, day int32) bool {
+	for _, s := range schedule {
+		if s.DayOfWeek == day {
+			return !s.IsWorkDay
+		}
+	}
+	return false
+}
+
+func uuidString(u pgtype.UUID) string {
+	if !u.Valid {
+		return ""
+	}
+	b := u.Bytes
+	return fmt.Sprintf("%08x-%04x-%04x-%04x-%012x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
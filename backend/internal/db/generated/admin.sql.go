@@ -212,7 +212,7 @@ func (q *Queries) GetRevenueByMonth(ctx context.Context, limit int32) ([]GetReve
 }
 
 const listAllBookings = `-- name: ListAllBookings :many
-SELECT id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number FROM bookings ORDER BY created_at DESC LIMIT $1 OFFSET $2
+SELECT id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number, team_size, holiday_surcharge, pets_surcharge FROM bookings ORDER BY created_at DESC LIMIT $1 OFFSET $2
 `
 
 type ListAllBookingsParams struct {
@@ -263,6 +263,9 @@ func (q *Queries) ListAllBookings(ctx context.Context, arg ListAllBookingsParams
 			&i.UpdatedAt,
 			&i.RecurringGroupID,
 			&i.OccurrenceNumber,
+			&i.TeamSize,
+			&i.HolidaySurcharge,
+			&i.PetsSurcharge,
 		); err != nil {
 			return nil, err
 		}
@@ -12,11 +12,12 @@ import (
 )
 
 const getCleanerEarningsByDateRange = `-- name: GetCleanerEarningsByDateRange :many
-SELECT DATE(completed_at) AS date,
-    COALESCE(SUM(COALESCE(final_total, estimated_total)), 0)::numeric AS amount
-FROM bookings
-WHERE cleaner_id = $1 AND status = 'completed' AND completed_at >= $2 AND completed_at <= $3
-GROUP BY DATE(completed_at) ORDER BY date
+SELECT DATE(b.completed_at) AS date,
+    COALESCE(SUM(COALESCE(btm.pay_share, b.final_total, b.estimated_total)), 0)::numeric AS amount
+FROM bookings b
+LEFT JOIN booking_team_members btm ON btm.booking_id = b.id AND btm.cleaner_id = $1
+WHERE (b.cleaner_id = $1 OR btm.id IS NOT NULL) AND b.status = 'completed' AND b.completed_at >= $2 AND b.completed_at <= $3
+GROUP BY DATE(b.completed_at) ORDER BY date
 `
 
 type GetCleanerEarningsByDateRangeParams struct {
@@ -13,7 +13,7 @@ import (
 
 const assignCleanerToBooking = `-- name: AssignCleanerToBooking :one
 UPDATE bookings SET company_id = $2, cleaner_id = $3, status = 'assigned', updated_at = NOW()
-WHERE id = $1 RETURNING id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number
+WHERE id = $1 RETURNING id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number, team_size, holiday_surcharge, pets_surcharge
 `
 
 type AssignCleanerToBookingParams struct {
@@ -59,13 +59,16 @@ func (q *Queries) AssignCleanerToBooking(ctx context.Context, arg AssignCleanerT
 		&i.UpdatedAt,
 		&i.RecurringGroupID,
 		&i.OccurrenceNumber,
+		&i.TeamSize,
+		&i.HolidaySurcharge,
+		&i.PetsSurcharge,
 	)
 	return i, err
 }
 
 const cancelBookingWithReason = `-- name: CancelBookingWithReason :one
 UPDATE bookings SET status = $2, cancelled_at = NOW(), cancellation_reason = $3, updated_at = NOW()
-WHERE id = $1 RETURNING id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number
+WHERE id = $1 RETURNING id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number, team_size, holiday_surcharge, pets_surcharge
 `
 
 type CancelBookingWithReasonParams struct {
@@ -111,15 +114,20 @@ func (q *Queries) CancelBookingWithReason(ctx context.Context, arg CancelBooking
 		&i.UpdatedAt,
 		&i.RecurringGroupID,
 		&i.OccurrenceNumber,
+		&i.TeamSize,
+		&i.HolidaySurcharge,
+		&i.PetsSurcharge,
 	)
 	return i, err
 }
 
 const completeBooking = `-- name: CompleteBooking :one
 UPDATE bookings SET status = 'completed', completed_at = NOW(), updated_at = NOW()
-WHERE id = $1 RETURNING id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number
+WHERE id = $1 AND status = 'in_progress' RETURNING id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number, team_size, holiday_surcharge, pets_surcharge
 `
 
+// CompleteBooking returns no rows unless the booking is in progress, so a
+// booking completed concurrently is completed once.
 func (q *Queries) CompleteBooking(ctx context.Context, id pgtype.UUID) (Booking, error) {
 	row := q.db.QueryRow(ctx, completeBooking, id)
 	var i Booking
@@ -157,6 +165,9 @@ func (q *Queries) CompleteBooking(ctx context.Context, id pgtype.UUID) (Booking,
 		&i.UpdatedAt,
 		&i.RecurringGroupID,
 		&i.OccurrenceNumber,
+		&i.TeamSize,
+		&i.HolidaySurcharge,
+		&i.PetsSurcharge,
 	)
 	return i, err
 }
@@ -184,7 +195,7 @@ func (q *Queries) CountBookingsByStatus(ctx context.Context, status BookingStatu
 }
 
 const countCompletedJobsByCleaner = `-- name: CountCompletedJobsByCleaner :one
-SELECT COUNT(*) FROM bookings WHERE cleaner_id = $1 AND status = 'completed'
+SELECT COUNT(*) FROM bookings WHERE (cleaner_id = $1 OR id IN (SELECT booking_id FROM booking_team_members WHERE cleaner_id = $1)) AND status = 'completed'
 `
 
 func (q *Queries) CountCompletedJobsByCleaner(ctx context.Context, cleanerID pgtype.UUID) (int64, error) {
@@ -214,7 +225,7 @@ func (q *Queries) CountSearchBookings(ctx context.Context, arg CountSearchBookin
 
 const countSearchCleanerBookings = `-- name: CountSearchCleanerBookings :one
 SELECT COUNT(*) FROM bookings WHERE
-    cleaner_id = $1
+    (cleaner_id = $1 OR id IN (SELECT booking_id FROM booking_team_members WHERE cleaner_id = $1))
     AND ($2::text = '' OR reference_code ILIKE '%' || $2::text || '%')
     AND ($3::text = '' OR status::text = $3::text OR ($3::text = 'cancelled' AND status::text LIKE 'cancelled%'))
     AND ($4::date = '0001-01-01' OR scheduled_date >= $4::date)
@@ -274,7 +285,7 @@ func (q *Queries) CountSearchCompanyBookings(ctx context.Context, arg CountSearc
 
 const countThisMonthJobsByCleaner = `-- name: CountThisMonthJobsByCleaner :one
 SELECT COUNT(*) FROM bookings
-WHERE cleaner_id = $1
+WHERE (cleaner_id = $1 OR id IN (SELECT booking_id FROM booking_team_members WHERE cleaner_id = $1))
   AND scheduled_date >= date_trunc('month', CURRENT_DATE)::date
   AND status NOT IN ('cancelled_by_client', 'cancelled_by_company', 'cancelled_by_admin')
 `
@@ -291,9 +302,9 @@ INSERT INTO bookings (
     reference_code, client_user_id, address_id, service_type, scheduled_date,
     scheduled_start_time, estimated_duration_hours, property_type, num_rooms,
     num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total,
-    recurring_group_id, occurrence_number
-) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
-RETURNING id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number
+    recurring_group_id, occurrence_number, pets_surcharge
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+RETURNING id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number, team_size, holiday_surcharge, pets_surcharge
 `
 
 type CreateBookingParams struct {
@@ -314,6 +325,7 @@ type CreateBookingParams struct {
 	EstimatedTotal         pgtype.Numeric `json:"estimated_total"`
 	RecurringGroupID       pgtype.UUID    `json:"recurring_group_id"`
 	OccurrenceNumber       pgtype.Int4    `json:"occurrence_number"`
+	PetsSurcharge          pgtype.Numeric `json:"pets_surcharge"`
 }
 
 func (q *Queries) CreateBooking(ctx context.Context, arg CreateBookingParams) (Booking, error) {
@@ -335,6 +347,7 @@ func (q *Queries) CreateBooking(ctx context.Context, arg CreateBookingParams) (B
 		arg.EstimatedTotal,
 		arg.RecurringGroupID,
 		arg.OccurrenceNumber,
+		arg.PetsSurcharge,
 	)
 	var i Booking
 	err := row.Scan(
@@ -371,12 +384,15 @@ func (q *Queries) CreateBooking(ctx context.Context, arg CreateBookingParams) (B
 		&i.UpdatedAt,
 		&i.RecurringGroupID,
 		&i.OccurrenceNumber,
+		&i.TeamSize,
+		&i.HolidaySurcharge,
+		&i.PetsSurcharge,
 	)
 	return i, err
 }
 
 const getBookingByID = `-- name: GetBookingByID :one
-SELECT id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number FROM bookings WHERE id = $1
+SELECT id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number, team_size, holiday_surcharge, pets_surcharge FROM bookings WHERE id = $1
 `
 
 func (q *Queries) GetBookingByID(ctx context.Context, id pgtype.UUID) (Booking, error) {
@@ -416,12 +432,15 @@ func (q *Queries) GetBookingByID(ctx context.Context, id pgtype.UUID) (Booking,
 		&i.UpdatedAt,
 		&i.RecurringGroupID,
 		&i.OccurrenceNumber,
+		&i.TeamSize,
+		&i.HolidaySurcharge,
+		&i.PetsSurcharge,
 	)
 	return i, err
 }
 
 const getBookingByReferenceCode = `-- name: GetBookingByReferenceCode :one
-SELECT id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number FROM bookings WHERE reference_code = $1
+SELECT id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number, team_size, holiday_surcharge, pets_surcharge FROM bookings WHERE reference_code = $1
 `
 
 func (q *Queries) GetBookingByReferenceCode(ctx context.Context, referenceCode string) (Booking, error) {
@@ -461,6 +480,9 @@ func (q *Queries) GetBookingByReferenceCode(ctx context.Context, referenceCode s
 		&i.UpdatedAt,
 		&i.RecurringGroupID,
 		&i.OccurrenceNumber,
+		&i.TeamSize,
+		&i.HolidaySurcharge,
+		&i.PetsSurcharge,
 	)
 	return i, err
 }
@@ -543,8 +565,79 @@ func (q *Queries) ListBookingExtras(ctx context.Context, bookingID pgtype.UUID)
 	return items, nil
 }
 
+const listBookingsAwaitingInvoice = `-- name: ListBookingsAwaitingInvoice :many
+SELECT b.id, b.reference_code, b.client_user_id, b.company_id, b.cleaner_id, b.address_id, b.service_type, b.scheduled_date, b.scheduled_start_time, b.estimated_duration_hours, b.property_type, b.num_rooms, b.num_bathrooms, b.area_sqm, b.has_pets, b.special_instructions, b.hourly_rate, b.estimated_total, b.final_total, b.platform_commission_pct, b.platform_commission_amount, b.status, b.started_at, b.completed_at, b.cancelled_at, b.cancellation_reason, b.stripe_payment_intent_id, b.payment_status, b.paid_at, b.created_at, b.updated_at, b.recurring_group_id, b.occurrence_number, b.team_size, b.holiday_surcharge, b.pets_surcharge FROM bookings b
+JOIN company_invoice_settings s ON s.company_id = b.company_id
+WHERE ((s.auto_issue_trigger = 'job_completed' AND b.status = 'completed' AND b.completed_at >= s.auto_issue_since)
+    OR (s.auto_issue_trigger = 'payment_succeeded' AND b.payment_status = 'paid' AND b.paid_at >= s.auto_issue_since))
+  AND NOT EXISTS (
+    SELECT 1 FROM invoices i WHERE i.booking_id = b.id AND i.invoice_type = 'client_service'
+  )
+ORDER BY b.updated_at
+LIMIT $1
+`
+
+// ListBookingsAwaitingInvoice returns bookings that their company's settings
+// should have invoiced automatically but have no client invoice yet.
+func (q *Queries) ListBookingsAwaitingInvoice(ctx context.Context, limit int32) ([]Booking, error) {
+	rows, err := q.db.Query(ctx, listBookingsAwaitingInvoice, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Booking
+	for rows.Next() {
+		var i Booking
+		if err := rows.Scan(
+			&i.ID,
+			&i.ReferenceCode,
+			&i.ClientUserID,
+			&i.CompanyID,
+			&i.CleanerID,
+			&i.AddressID,
+			&i.ServiceType,
+			&i.ScheduledDate,
+			&i.ScheduledStartTime,
+			&i.EstimatedDurationHours,
+			&i.PropertyType,
+			&i.NumRooms,
+			&i.NumBathrooms,
+			&i.AreaSqm,
+			&i.HasPets,
+			&i.SpecialInstructions,
+			&i.HourlyRate,
+			&i.EstimatedTotal,
+			&i.FinalTotal,
+			&i.PlatformCommissionPct,
+			&i.PlatformCommissionAmount,
+			&i.Status,
+			&i.StartedAt,
+			&i.CompletedAt,
+			&i.CancelledAt,
+			&i.CancellationReason,
+			&i.StripePaymentIntentID,
+			&i.PaymentStatus,
+			&i.PaidAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.RecurringGroupID,
+			&i.OccurrenceNumber,
+			&i.TeamSize,
+			&i.HolidaySurcharge,
+			&i.PetsSurcharge,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listBookingsByCleaner = `-- name: ListBookingsByCleaner :many
-SELECT id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number FROM bookings WHERE cleaner_id = $1 ORDER BY scheduled_date DESC
+SELECT id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number, team_size, holiday_surcharge, pets_surcharge FROM bookings WHERE (cleaner_id = $1 OR id IN (SELECT booking_id FROM booking_team_members WHERE cleaner_id = $1)) ORDER BY scheduled_date DESC
 `
 
 func (q *Queries) ListBookingsByCleaner(ctx context.Context, cleanerID pgtype.UUID) ([]Booking, error) {
@@ -590,6 +683,9 @@ func (q *Queries) ListBookingsByCleaner(ctx context.Context, cleanerID pgtype.UU
 			&i.UpdatedAt,
 			&i.RecurringGroupID,
 			&i.OccurrenceNumber,
+			&i.TeamSize,
+			&i.HolidaySurcharge,
+			&i.PetsSurcharge,
 		); err != nil {
 			return nil, err
 		}
@@ -602,8 +698,8 @@ func (q *Queries) ListBookingsByCleaner(ctx context.Context, cleanerID pgtype.UU
 }
 
 const listBookingsByCleanerAndDateRange = `-- name: ListBookingsByCleanerAndDateRange :many
-SELECT id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number FROM bookings
-WHERE cleaner_id = $1
+SELECT id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number, team_size, holiday_surcharge, pets_surcharge FROM bookings
+WHERE (cleaner_id = $1 OR id IN (SELECT booking_id FROM booking_team_members WHERE cleaner_id = $1))
   AND scheduled_date >= $2::date
   AND scheduled_date <= $3::date
   AND status NOT IN ('cancelled_by_client', 'cancelled_by_company', 'cancelled_by_admin')
@@ -659,6 +755,9 @@ func (q *Queries) ListBookingsByCleanerAndDateRange(ctx context.Context, arg Lis
 			&i.UpdatedAt,
 			&i.RecurringGroupID,
 			&i.OccurrenceNumber,
+			&i.TeamSize,
+			&i.HolidaySurcharge,
+			&i.PetsSurcharge,
 		); err != nil {
 			return nil, err
 		}
@@ -671,7 +770,7 @@ func (q *Queries) ListBookingsByCleanerAndDateRange(ctx context.Context, arg Lis
 }
 
 const listBookingsByClient = `-- name: ListBookingsByClient :many
-SELECT id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number FROM bookings WHERE client_user_id = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3
+SELECT id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number, team_size, holiday_surcharge, pets_surcharge FROM bookings WHERE client_user_id = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3
 `
 
 type ListBookingsByClientParams struct {
@@ -723,6 +822,9 @@ func (q *Queries) ListBookingsByClient(ctx context.Context, arg ListBookingsByCl
 			&i.UpdatedAt,
 			&i.RecurringGroupID,
 			&i.OccurrenceNumber,
+			&i.TeamSize,
+			&i.HolidaySurcharge,
+			&i.PetsSurcharge,
 		); err != nil {
 			return nil, err
 		}
@@ -735,7 +837,7 @@ func (q *Queries) ListBookingsByClient(ctx context.Context, arg ListBookingsByCl
 }
 
 const listBookingsByClientAndStatus = `-- name: ListBookingsByClientAndStatus :many
-SELECT id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number FROM bookings WHERE client_user_id = $1 AND status = $2 ORDER BY created_at DESC LIMIT $3 OFFSET $4
+SELECT id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number, team_size, holiday_surcharge, pets_surcharge FROM bookings WHERE client_user_id = $1 AND status = $2 ORDER BY created_at DESC LIMIT $3 OFFSET $4
 `
 
 type ListBookingsByClientAndStatusParams struct {
@@ -793,6 +895,9 @@ func (q *Queries) ListBookingsByClientAndStatus(ctx context.Context, arg ListBoo
 			&i.UpdatedAt,
 			&i.RecurringGroupID,
 			&i.OccurrenceNumber,
+			&i.TeamSize,
+			&i.HolidaySurcharge,
+			&i.PetsSurcharge,
 		); err != nil {
 			return nil, err
 		}
@@ -805,7 +910,7 @@ func (q *Queries) ListBookingsByClientAndStatus(ctx context.Context, arg ListBoo
 }
 
 const listBookingsByCompany = `-- name: ListBookingsByCompany :many
-SELECT id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number FROM bookings WHERE company_id = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3
+SELECT id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number, team_size, holiday_surcharge, pets_surcharge FROM bookings WHERE company_id = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3
 `
 
 type ListBookingsByCompanyParams struct {
@@ -857,6 +962,9 @@ func (q *Queries) ListBookingsByCompany(ctx context.Context, arg ListBookingsByC
 			&i.UpdatedAt,
 			&i.RecurringGroupID,
 			&i.OccurrenceNumber,
+			&i.TeamSize,
+			&i.HolidaySurcharge,
+			&i.PetsSurcharge,
 		); err != nil {
 			return nil, err
 		}
@@ -869,7 +977,7 @@ func (q *Queries) ListBookingsByCompany(ctx context.Context, arg ListBookingsByC
 }
 
 const listBookingsByCompanyAndDateRange = `-- name: ListBookingsByCompanyAndDateRange :many
-SELECT id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number FROM bookings
+SELECT id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number, team_size, holiday_surcharge, pets_surcharge FROM bookings
 WHERE company_id = $1
   AND scheduled_date >= $2::date
   AND scheduled_date <= $3::date
@@ -926,6 +1034,9 @@ func (q *Queries) ListBookingsByCompanyAndDateRange(ctx context.Context, arg Lis
 			&i.UpdatedAt,
 			&i.RecurringGroupID,
 			&i.OccurrenceNumber,
+			&i.TeamSize,
+			&i.HolidaySurcharge,
+			&i.PetsSurcharge,
 		); err != nil {
 			return nil, err
 		}
@@ -938,7 +1049,7 @@ func (q *Queries) ListBookingsByCompanyAndDateRange(ctx context.Context, arg Lis
 }
 
 const listBookingsByCompanyAndStatus = `-- name: ListBookingsByCompanyAndStatus :many
-SELECT id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number FROM bookings WHERE company_id = $1 AND status = $2 ORDER BY created_at DESC LIMIT $3 OFFSET $4
+SELECT id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number, team_size, holiday_surcharge, pets_surcharge FROM bookings WHERE company_id = $1 AND status = $2 ORDER BY created_at DESC LIMIT $3 OFFSET $4
 `
 
 type ListBookingsByCompanyAndStatusParams struct {
@@ -996,6 +1107,9 @@ func (q *Queries) ListBookingsByCompanyAndStatus(ctx context.Context, arg ListBo
 			&i.UpdatedAt,
 			&i.RecurringGroupID,
 			&i.OccurrenceNumber,
+			&i.TeamSize,
+			&i.HolidaySurcharge,
+			&i.PetsSurcharge,
 		); err != nil {
 			return nil, err
 		}
@@ -1008,7 +1122,7 @@ func (q *Queries) ListBookingsByCompanyAndStatus(ctx context.Context, arg ListBo
 }
 
 const listBookingsByStatus = `-- name: ListBookingsByStatus :many
-SELECT id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number FROM bookings WHERE status = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3
+SELECT id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number, team_size, holiday_surcharge, pets_surcharge FROM bookings WHERE status = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3
 `
 
 type ListBookingsByStatusParams struct {
@@ -1060,6 +1174,9 @@ func (q *Queries) ListBookingsByStatus(ctx context.Context, arg ListBookingsBySt
 			&i.UpdatedAt,
 			&i.RecurringGroupID,
 			&i.OccurrenceNumber,
+			&i.TeamSize,
+			&i.HolidaySurcharge,
+			&i.PetsSurcharge,
 		); err != nil {
 			return nil, err
 		}
@@ -1072,7 +1189,7 @@ func (q *Queries) ListBookingsByStatus(ctx context.Context, arg ListBookingsBySt
 }
 
 const listTodaysJobsByCleaner = `-- name: ListTodaysJobsByCleaner :many
-SELECT id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number FROM bookings WHERE cleaner_id = $1 AND scheduled_date = CURRENT_DATE ORDER BY scheduled_start_time
+SELECT id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number, team_size, holiday_surcharge, pets_surcharge FROM bookings WHERE (cleaner_id = $1 OR id IN (SELECT booking_id FROM booking_team_members WHERE cleaner_id = $1)) AND scheduled_date = CURRENT_DATE ORDER BY scheduled_start_time
 `
 
 func (q *Queries) ListTodaysJobsByCleaner(ctx context.Context, cleanerID pgtype.UUID) ([]Booking, error) {
@@ -1118,6 +1235,9 @@ func (q *Queries) ListTodaysJobsByCleaner(ctx context.Context, cleanerID pgtype.
 			&i.UpdatedAt,
 			&i.RecurringGroupID,
 			&i.OccurrenceNumber,
+			&i.TeamSize,
+			&i.HolidaySurcharge,
+			&i.PetsSurcharge,
 		); err != nil {
 			return nil, err
 		}
@@ -1129,8 +1249,151 @@ func (q *Queries) ListTodaysJobsByCleaner(ctx context.Context, cleanerID pgtype.
 	return items, nil
 }
 
+const reassignBookingCleaner = `-- name: ReassignBookingCleaner :one
+UPDATE bookings
+SET cleaner_id = $1,
+    status = CASE WHEN status = 'pending' THEN 'assigned'::booking_status ELSE status END,
+    updated_at = NOW()
+WHERE id = $2
+  AND company_id = $3
+  AND cleaner_id IS NOT DISTINCT FROM $4
+  AND status IN ('pending', 'assigned', 'confirmed')
+  AND team_size = 1
+RETURNING id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number, team_size, holiday_surcharge, pets_surcharge
+`
+
+type ReassignBookingCleanerParams struct {
+	CleanerID         pgtype.UUID `json:"cleaner_id"`
+	ID                pgtype.UUID `json:"id"`
+	CompanyID         pgtype.UUID `json:"company_id"`
+	ExpectedCleanerID pgtype.UUID `json:"expected_cleaner_id"`
+}
+
+// ReassignBookingCleaner moves a booking to another cleaner of the same company,
+// failing with no rows if its cleaner changed since expected_cleaner_id was read
+// or the booking is a started or team job.
+func (q *Queries) ReassignBookingCleaner(ctx context.Context, arg ReassignBookingCleanerParams) (Booking, error) {
+	row := q.db.QueryRow(ctx, reassignBookingCleaner,
+		arg.CleanerID,
+		arg.ID,
+		arg.CompanyID,
+		arg.ExpectedCleanerID,
+	)
+	var i Booking
+	err := row.Scan(
+		&i.ID,
+		&i.ReferenceCode,
+		&i.ClientUserID,
+		&i.CompanyID,
+		&i.CleanerID,
+		&i.AddressID,
+		&i.ServiceType,
+		&i.ScheduledDate,
+		&i.ScheduledStartTime,
+		&i.EstimatedDurationHours,
+		&i.PropertyType,
+		&i.NumRooms,
+		&i.NumBathrooms,
+		&i.AreaSqm,
+		&i.HasPets,
+		&i.SpecialInstructions,
+		&i.HourlyRate,
+		&i.EstimatedTotal,
+		&i.FinalTotal,
+		&i.PlatformCommissionPct,
+		&i.PlatformCommissionAmount,
+		&i.Status,
+		&i.StartedAt,
+		&i.CompletedAt,
+		&i.CancelledAt,
+		&i.CancellationReason,
+		&i.StripePaymentIntentID,
+		&i.PaymentStatus,
+		&i.PaidAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.RecurringGroupID,
+		&i.OccurrenceNumber,
+		&i.TeamSize,
+		&i.HolidaySurcharge,
+		&i.PetsSurcharge,
+	)
+	return i, err
+}
+
+const rescheduleBooking = `-- name: RescheduleBooking :one
+UPDATE bookings
+SET scheduled_date = $1,
+    scheduled_start_time = $2,
+    cleaner_id = $3,
+    status = CASE WHEN $3::uuid IS NULL THEN 'pending'::booking_status ELSE status END,
+    updated_at = NOW()
+WHERE id = $4
+  AND status IN ('pending', 'assigned', 'confirmed')
+  AND started_at IS NULL
+RETURNING id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number, team_size, holiday_surcharge, pets_surcharge
+`
+
+type RescheduleBookingParams struct {
+	ScheduledDate      pgtype.Date `json:"scheduled_date"`
+	ScheduledStartTime pgtype.Time `json:"scheduled_start_time"`
+	CleanerID          pgtype.UUID `json:"cleaner_id"`
+	ID                 pgtype.UUID `json:"id"`
+}
+
+// RescheduleBooking moves a not-yet-started booking to a new date and time with
+// the cleaner who can do it; without a cleaner it goes back to pending.
+func (q *Queries) RescheduleBooking(ctx context.Context, arg RescheduleBookingParams) (Booking, error) {
+	row := q.db.QueryRow(ctx, rescheduleBooking,
+		arg.ScheduledDate,
+		arg.ScheduledStartTime,
+		arg.CleanerID,
+		arg.ID,
+	)
+	var i Booking
+	err := row.Scan(
+		&i.ID,
+		&i.ReferenceCode,
+		&i.ClientUserID,
+		&i.CompanyID,
+		&i.CleanerID,
+		&i.AddressID,
+		&i.ServiceType,
+		&i.ScheduledDate,
+		&i.ScheduledStartTime,
+		&i.EstimatedDurationHours,
+		&i.PropertyType,
+		&i.NumRooms,
+		&i.NumBathrooms,
+		&i.AreaSqm,
+		&i.HasPets,
+		&i.SpecialInstructions,
+		&i.HourlyRate,
+		&i.EstimatedTotal,
+		&i.FinalTotal,
+		&i.PlatformCommissionPct,
+		&i.PlatformCommissionAmount,
+		&i.Status,
+		&i.StartedAt,
+		&i.CompletedAt,
+		&i.CancelledAt,
+		&i.CancellationReason,
+		&i.StripePaymentIntentID,
+		&i.PaymentStatus,
+		&i.PaidAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.RecurringGroupID,
+		&i.OccurrenceNumber,
+		&i.TeamSize,
+		&i.HolidaySurcharge,
+		&i.PetsSurcharge,
+	)
+	return i, err
+}
+
 const searchBookings = `-- name: SearchBookings :many
-SELECT id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number FROM bookings WHERE
+SELECT id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number, team_size, holiday_surcharge, pets_surcharge FROM bookings WHERE
     ($3::text = '' OR reference_code ILIKE '%' || $3::text || '%')
     AND ($4::text = '' OR status::text = $4::text)
 ORDER BY created_at DESC LIMIT $1 OFFSET $2
@@ -1191,6 +1454,9 @@ func (q *Queries) SearchBookings(ctx context.Context, arg SearchBookingsParams)
 			&i.UpdatedAt,
 			&i.RecurringGroupID,
 			&i.OccurrenceNumber,
+			&i.TeamSize,
+			&i.HolidaySurcharge,
+			&i.PetsSurcharge,
 		); err != nil {
 			return nil, err
 		}
@@ -1203,8 +1469,8 @@ func (q *Queries) SearchBookings(ctx context.Context, arg SearchBookingsParams)
 }
 
 const searchCleanerBookings = `-- name: SearchCleanerBookings :many
-SELECT id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number FROM bookings WHERE
-    cleaner_id = $1
+SELECT id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number, team_size, holiday_surcharge, pets_surcharge FROM bookings WHERE
+    (cleaner_id = $1 OR id IN (SELECT booking_id FROM booking_team_members WHERE cleaner_id = $1))
     AND ($4::text = '' OR reference_code ILIKE '%' || $4::text || '%')
     AND ($5::text = '' OR status::text = $5::text OR ($5::text = 'cancelled' AND status::text LIKE 'cancelled%'))
     AND ($6::date = '0001-01-01' OR scheduled_date >= $6::date)
@@ -1273,6 +1539,9 @@ func (q *Queries) SearchCleanerBookings(ctx context.Context, arg SearchCleanerBo
 			&i.UpdatedAt,
 			&i.RecurringGroupID,
 			&i.OccurrenceNumber,
+			&i.TeamSize,
+			&i.HolidaySurcharge,
+			&i.PetsSurcharge,
 		); err != nil {
 			return nil, err
 		}
@@ -1285,7 +1554,7 @@ func (q *Queries) SearchCleanerBookings(ctx context.Context, arg SearchCleanerBo
 }
 
 const searchCompanyBookings = `-- name: SearchCompanyBookings :many
-SELECT id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number FROM bookings WHERE
+SELECT id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number, team_size, holiday_surcharge, pets_surcharge FROM bookings WHERE
     company_id = $1
     AND ($4::text = '' OR reference_code ILIKE '%' || $4::text || '%')
     AND ($5::text = '' OR status::text = $5::text OR ($5::text = 'cancelled' AND status::text LIKE 'cancelled%'))
@@ -1355,6 +1624,9 @@ func (q *Queries) SearchCompanyBookings(ctx context.Context, arg SearchCompanyBo
 			&i.UpdatedAt,
 			&i.RecurringGroupID,
 			&i.OccurrenceNumber,
+			&i.TeamSize,
+			&i.HolidaySurcharge,
+			&i.PetsSurcharge,
 		); err != nil {
 			return nil, err
 		}
@@ -1366,9 +1638,64 @@ func (q *Queries) SearchCompanyBookings(ctx context.Context, arg SearchCompanyBo
 	return items, nil
 }
 
+const setBookingCompany = `-- name: SetBookingCompany :one
+UPDATE bookings SET company_id = $2, updated_at = NOW()
+WHERE id = $1 RETURNING id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number, team_size, holiday_surcharge, pets_surcharge
+`
+
+type SetBookingCompanyParams struct {
+	ID        pgtype.UUID `json:"id"`
+	CompanyID pgtype.UUID `json:"company_id"`
+}
+
+// SetBookingCompany attaches a booking to a company without assigning a cleaner.
+func (q *Queries) SetBookingCompany(ctx context.Context, arg SetBookingCompanyParams) (Booking, error) {
+	row := q.db.QueryRow(ctx, setBookingCompany, arg.ID, arg.CompanyID)
+	var i Booking
+	err := row.Scan(
+		&i.ID,
+		&i.ReferenceCode,
+		&i.ClientUserID,
+		&i.CompanyID,
+		&i.CleanerID,
+		&i.AddressID,
+		&i.ServiceType,
+		&i.ScheduledDate,
+		&i.ScheduledStartTime,
+		&i.EstimatedDurationHours,
+		&i.PropertyType,
+		&i.NumRooms,
+		&i.NumBathrooms,
+		&i.AreaSqm,
+		&i.HasPets,
+		&i.SpecialInstructions,
+		&i.HourlyRate,
+		&i.EstimatedTotal,
+		&i.FinalTotal,
+		&i.PlatformCommissionPct,
+		&i.PlatformCommissionAmount,
+		&i.Status,
+		&i.StartedAt,
+		&i.CompletedAt,
+		&i.CancelledAt,
+		&i.CancellationReason,
+		&i.StripePaymentIntentID,
+		&i.PaymentStatus,
+		&i.PaidAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.RecurringGroupID,
+		&i.OccurrenceNumber,
+		&i.TeamSize,
+		&i.HolidaySurcharge,
+		&i.PetsSurcharge,
+	)
+	return i, err
+}
+
 const setBookingFinalTotal = `-- name: SetBookingFinalTotal :one
 UPDATE bookings SET final_total = $2, platform_commission_amount = $3, updated_at = NOW()
-WHERE id = $1 RETURNING id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number
+WHERE id = $1 RETURNING id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number, team_size, holiday_surcharge, pets_surcharge
 `
 
 type SetBookingFinalTotalParams struct {
@@ -1414,13 +1741,76 @@ func (q *Queries) SetBookingFinalTotal(ctx context.Context, arg SetBookingFinalT
 		&i.UpdatedAt,
 		&i.RecurringGroupID,
 		&i.OccurrenceNumber,
+		&i.TeamSize,
+		&i.HolidaySurcharge,
+		&i.PetsSurcharge,
+	)
+	return i, err
+}
+
+const setBookingHolidaySurcharge = `-- name: SetBookingHolidaySurcharge :one
+UPDATE bookings
+SET estimated_total = estimated_total - holiday_surcharge + $1,
+    holiday_surcharge = $1,
+    updated_at = NOW()
+WHERE id = $2
+RETURNING id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number, team_size, holiday_surcharge, pets_surcharge
+`
+
+type SetBookingHolidaySurchargeParams struct {
+	HolidaySurcharge pgtype.Numeric `json:"holiday_surcharge"`
+	ID               pgtype.UUID    `json:"id"`
+}
+
+// SetBookingHolidaySurcharge replaces the holiday surcharge included in the
+// booking's estimated total.
+func (q *Queries) SetBookingHolidaySurcharge(ctx context.Context, arg SetBookingHolidaySurchargeParams) (Booking, error) {
+	row := q.db.QueryRow(ctx, setBookingHolidaySurcharge, arg.HolidaySurcharge, arg.ID)
+	var i Booking
+	err := row.Scan(
+		&i.ID,
+		&i.ReferenceCode,
+		&i.ClientUserID,
+		&i.CompanyID,
+		&i.CleanerID,
+		&i.AddressID,
+		&i.ServiceType,
+		&i.ScheduledDate,
+		&i.ScheduledStartTime,
+		&i.EstimatedDurationHours,
+		&i.PropertyType,
+		&i.NumRooms,
+		&i.NumBathrooms,
+		&i.AreaSqm,
+		&i.HasPets,
+		&i.SpecialInstructions,
+		&i.HourlyRate,
+		&i.EstimatedTotal,
+		&i.FinalTotal,
+		&i.PlatformCommissionPct,
+		&i.PlatformCommissionAmount,
+		&i.Status,
+		&i.StartedAt,
+		&i.CompletedAt,
+		&i.CancelledAt,
+		&i.CancellationReason,
+		&i.StripePaymentIntentID,
+		&i.PaymentStatus,
+		&i.PaidAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.RecurringGroupID,
+		&i.OccurrenceNumber,
+		&i.TeamSize,
+		&i.HolidaySurcharge,
+		&i.PetsSurcharge,
 	)
 	return i, err
 }
 
 const setBookingPreferredCleaner = `-- name: SetBookingPreferredCleaner :one
 UPDATE bookings SET company_id = $2, cleaner_id = $3, status = 'confirmed', updated_at = NOW()
-WHERE id = $1 RETURNING id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number
+WHERE id = $1 RETURNING id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number, team_size, holiday_surcharge, pets_surcharge
 `
 
 type SetBookingPreferredCleanerParams struct {
@@ -1466,13 +1856,70 @@ func (q *Queries) SetBookingPreferredCleaner(ctx context.Context, arg SetBooking
 		&i.UpdatedAt,
 		&i.RecurringGroupID,
 		&i.OccurrenceNumber,
+		&i.TeamSize,
+		&i.HolidaySurcharge,
+		&i.PetsSurcharge,
+	)
+	return i, err
+}
+
+const setBookingTeamSize = `-- name: SetBookingTeamSize :one
+UPDATE bookings SET team_size = $2, estimated_duration_hours = $3, updated_at = NOW() WHERE id = $1 RETURNING id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number, team_size, holiday_surcharge, pets_surcharge
+`
+
+type SetBookingTeamSizeParams struct {
+	ID                     pgtype.UUID    `json:"id"`
+	TeamSize               int32          `json:"team_size"`
+	EstimatedDurationHours pgtype.Numeric `json:"estimated_duration_hours"`
+}
+
+func (q *Queries) SetBookingTeamSize(ctx context.Context, arg SetBookingTeamSizeParams) (Booking, error) {
+	row := q.db.QueryRow(ctx, setBookingTeamSize, arg.ID, arg.TeamSize, arg.EstimatedDurationHours)
+	var i Booking
+	err := row.Scan(
+		&i.ID,
+		&i.ReferenceCode,
+		&i.ClientUserID,
+		&i.CompanyID,
+		&i.CleanerID,
+		&i.AddressID,
+		&i.ServiceType,
+		&i.ScheduledDate,
+		&i.ScheduledStartTime,
+		&i.EstimatedDurationHours,
+		&i.PropertyType,
+		&i.NumRooms,
+		&i.NumBathrooms,
+		&i.AreaSqm,
+		&i.HasPets,
+		&i.SpecialInstructions,
+		&i.HourlyRate,
+		&i.EstimatedTotal,
+		&i.FinalTotal,
+		&i.PlatformCommissionPct,
+		&i.PlatformCommissionAmount,
+		&i.Status,
+		&i.StartedAt,
+		&i.CompletedAt,
+		&i.CancelledAt,
+		&i.CancellationReason,
+		&i.StripePaymentIntentID,
+		&i.PaymentStatus,
+		&i.PaidAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.RecurringGroupID,
+		&i.OccurrenceNumber,
+		&i.TeamSize,
+		&i.HolidaySurcharge,
+		&i.PetsSurcharge,
 	)
 	return i, err
 }
 
 const startBooking = `-- name: StartBooking :one
 UPDATE bookings SET status = 'in_progress', started_at = NOW(), updated_at = NOW()
-WHERE id = $1 RETURNING id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number
+WHERE id = $1 RETURNING id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number, team_size, holiday_surcharge, pets_surcharge
 `
 
 func (q *Queries) StartBooking(ctx context.Context, id pgtype.UUID) (Booking, error) {
@@ -1512,16 +1959,20 @@ func (q *Queries) StartBooking(ctx context.Context, id pgtype.UUID) (Booking, er
 		&i.UpdatedAt,
 		&i.RecurringGroupID,
 		&i.OccurrenceNumber,
+		&i.TeamSize,
+		&i.HolidaySurcharge,
+		&i.PetsSurcharge,
 	)
 	return i, err
 }
 
 const sumThisMonthEarningsByCleaner = `-- name: SumThisMonthEarningsByCleaner :one
-SELECT COALESCE(SUM(COALESCE(final_total, estimated_total)), 0)::numeric AS total
-FROM bookings
-WHERE cleaner_id = $1
-  AND status = 'completed'
-  AND completed_at >= date_trunc('month', CURRENT_DATE)
+SELECT COALESCE(SUM(COALESCE(btm.pay_share, b.final_total, b.estimated_total)), 0)::numeric AS total
+FROM bookings b
+LEFT JOIN booking_team_members btm ON btm.booking_id = b.id AND btm.cleaner_id = $1
+WHERE (b.cleaner_id = $1 OR btm.id IS NOT NULL)
+  AND b.status = 'completed'
+  AND b.completed_at >= date_trunc('month', CURRENT_DATE)
 `
 
 func (q *Queries) SumThisMonthEarningsByCleaner(ctx context.Context, cleanerID pgtype.UUID) (pgtype.Numeric, error) {
@@ -1533,7 +1984,7 @@ func (q *Queries) SumThisMonthEarningsByCleaner(ctx context.Context, cleanerID p
 
 const updateBookingSchedule = `-- name: UpdateBookingSchedule :one
 UPDATE bookings SET scheduled_date = $2, scheduled_start_time = $3, updated_at = NOW()
-WHERE id = $1 RETURNING id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number
+WHERE id = $1 RETURNING id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number, team_size, holiday_surcharge, pets_surcharge
 `
 
 type UpdateBookingScheduleParams struct {
@@ -1579,12 +2030,15 @@ func (q *Queries) UpdateBookingSchedule(ctx context.Context, arg UpdateBookingSc
 		&i.UpdatedAt,
 		&i.RecurringGroupID,
 		&i.OccurrenceNumber,
+		&i.TeamSize,
+		&i.HolidaySurcharge,
+		&i.PetsSurcharge,
 	)
 	return i, err
 }
 
 const updateBookingStatus = `-- name: UpdateBookingStatus :one
-UPDATE bookings SET status = $2, updated_at = NOW() WHERE id = $1 RETURNING id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number
+UPDATE bookings SET status = $2, updated_at = NOW() WHERE id = $1 RETURNING id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number, team_size, holiday_surcharge, pets_surcharge
 `
 
 type UpdateBookingStatusParams struct {
@@ -1629,6 +2083,9 @@ func (q *Queries) UpdateBookingStatus(ctx context.Context, arg UpdateBookingStat
 		&i.UpdatedAt,
 		&i.RecurringGroupID,
 		&i.OccurrenceNumber,
+		&i.TeamSize,
+		&i.HolidaySurcharge,
+		&i.PetsSurcharge,
 	)
 	return i, err
 }
@@ -13,7 +13,7 @@ import (
 
 const adminUpdateCompanyProfile = `-- name: AdminUpdateCompanyProfile :one
 UPDATE companies SET company_name = $2, cui = $3, address = $4, contact_phone = $5, contact_email = $6, updated_at = NOW()
-WHERE id = $1 RETURNING id, admin_user_id, company_name, cui, company_type, legal_representative, contact_email, contact_phone, address, city, county, description, logo_url, status, rejection_reason, max_service_radius_km, rating_avg, total_jobs_completed, approved_at, created_at, updated_at, claim_token, stripe_connect_account_id, stripe_connect_onboarding_complete, stripe_connect_charges_enabled, stripe_connect_payouts_enabled
+WHERE id = $1 RETURNING id, admin_user_id, company_name, cui, company_type, legal_representative, contact_email, contact_phone, address, city, county, description, logo_url, status, rejection_reason, max_service_radius_km, rating_avg, total_jobs_completed, approved_at, created_at, updated_at, claim_token, stripe_connect_account_id, stripe_connect_onboarding_complete, stripe_connect_charges_enabled, stripe_connect_payouts_enabled, works_on_holidays, holiday_surcharge_pct, is_vat_payer, vat_checked_at
 `
 
 type AdminUpdateCompanyProfileParams struct {
@@ -62,13 +62,17 @@ func (q *Queries) AdminUpdateCompanyProfile(ctx context.Context, arg AdminUpdate
 		&i.StripeConnectOnboardingComplete,
 		&i.StripeConnectChargesEnabled,
 		&i.StripeConnectPayoutsEnabled,
+		&i.WorksOnHolidays,
+		&i.HolidaySurchargePct,
+		&i.IsVatPayer,
+		&i.VatCheckedAt,
 	)
 	return i, err
 }
 
 const approveCompany = `-- name: ApproveCompany :one
 UPDATE companies SET status = 'approved', approved_at = NOW(), updated_at = NOW()
-WHERE id = $1 RETURNING id, admin_user_id, company_name, cui, company_type, legal_representative, contact_email, contact_phone, address, city, county, description, logo_url, status, rejection_reason, max_service_radius_km, rating_avg, total_jobs_completed, approved_at, created_at, updated_at, claim_token, stripe_connect_account_id, stripe_connect_onboarding_complete, stripe_connect_charges_enabled, stripe_connect_payouts_enabled
+WHERE id = $1 RETURNING id, admin_user_id, company_name, cui, company_type, legal_representative, contact_email, contact_phone, address, city, county, description, logo_url, status, rejection_reason, max_service_radius_km, rating_avg, total_jobs_completed, approved_at, created_at, updated_at, claim_token, stripe_connect_account_id, stripe_connect_onboarding_complete, stripe_connect_charges_enabled, stripe_connect_payouts_enabled, works_on_holidays, holiday_surcharge_pct, is_vat_payer, vat_checked_at
 `
 
 func (q *Queries) ApproveCompany(ctx context.Context, id pgtype.UUID) (Company, error) {
@@ -101,6 +105,10 @@ func (q *Queries) ApproveCompany(ctx context.Context, id pgtype.UUID) (Company,
 		&i.StripeConnectOnboardingComplete,
 		&i.StripeConnectChargesEnabled,
 		&i.StripeConnectPayoutsEnabled,
+		&i.WorksOnHolidays,
+		&i.HolidaySurchargePct,
+		&i.IsVatPayer,
+		&i.VatCheckedAt,
 	)
 	return i, err
 }
@@ -126,7 +134,7 @@ const claimCompanyByToken = `-- name: ClaimCompanyByToken :one
 UPDATE companies
 SET admin_user_id = $1, claim_token = NULL, updated_at = NOW()
 WHERE claim_token = $2 AND admin_user_id IS NULL
-RETURNING id, admin_user_id, company_name, cui, company_type, legal_representative, contact_email, contact_phone, address, city, county, description, logo_url, status, rejection_reason, max_service_radius_km, rating_avg, total_jobs_completed, approved_at, created_at, updated_at, claim_token, stripe_connect_account_id, stripe_connect_onboarding_complete, stripe_connect_charges_enabled, stripe_connect_payouts_enabled
+RETURNING id, admin_user_id, company_name, cui, company_type, legal_representative, contact_email, contact_phone, address, city, county, description, logo_url, status, rejection_reason, max_service_radius_km, rating_avg, total_jobs_completed, approved_at, created_at, updated_at, claim_token, stripe_connect_account_id, stripe_connect_onboarding_complete, stripe_connect_charges_enabled, stripe_connect_payouts_enabled, works_on_holidays, holiday_surcharge_pct, is_vat_payer, vat_checked_at
 `
 
 type ClaimCompanyByTokenParams struct {
@@ -164,6 +172,10 @@ func (q *Queries) ClaimCompanyByToken(ctx context.Context, arg ClaimCompanyByTok
 		&i.StripeConnectOnboardingComplete,
 		&i.StripeConnectChargesEnabled,
 		&i.StripeConnectPayoutsEnabled,
+		&i.WorksOnHolidays,
+		&i.HolidaySurchargePct,
+		&i.IsVatPayer,
+		&i.VatCheckedAt,
 	)
 	return i, err
 }
@@ -202,7 +214,7 @@ INSERT INTO companies (
     admin_user_id, company_name, cui, company_type, legal_representative,
     contact_email, contact_phone, address, city, county, description, claim_token
 ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
-RETURNING id, admin_user_id, company_name, cui, company_type, legal_representative, contact_email, contact_phone, address, city, county, description, logo_url, status, rejection_reason, max_service_radius_km, rating_avg, total_jobs_completed, approved_at, created_at, updated_at, claim_token, stripe_connect_account_id, stripe_connect_onboarding_complete, stripe_connect_charges_enabled, stripe_connect_payouts_enabled
+RETURNING id, admin_user_id, company_name, cui, company_type, legal_representative, contact_email, contact_phone, address, city, county, description, logo_url, status, rejection_reason, max_service_radius_km, rating_avg, total_jobs_completed, approved_at, created_at, updated_at, claim_token, stripe_connect_account_id, stripe_connect_onboarding_complete, stripe_connect_charges_enabled, stripe_connect_payouts_enabled, works_on_holidays, holiday_surcharge_pct, is_vat_payer, vat_checked_at
 `
 
 type CreateCompanyParams struct {
@@ -263,12 +275,16 @@ func (q *Queries) CreateCompany(ctx context.Context, arg CreateCompanyParams) (C
 		&i.StripeConnectOnboardingComplete,
 		&i.StripeConnectChargesEnabled,
 		&i.StripeConnectPayoutsEnabled,
+		&i.WorksOnHolidays,
+		&i.HolidaySurchargePct,
+		&i.IsVatPayer,
+		&i.VatCheckedAt,
 	)
 	return i, err
 }
 
 const getCompanyByAdminUserID = `-- name: GetCompanyByAdminUserID :one
-SELECT id, admin_user_id, company_name, cui, company_type, legal_representative, contact_email, contact_phone, address, city, county, description, logo_url, status, rejection_reason, max_service_radius_km, rating_avg, total_jobs_completed, approved_at, created_at, updated_at, claim_token, stripe_connect_account_id, stripe_connect_onboarding_complete, stripe_connect_charges_enabled, stripe_connect_payouts_enabled FROM companies WHERE admin_user_id = $1
+SELECT id, admin_user_id, company_name, cui, company_type, legal_representative, contact_email, contact_phone, address, city, county, description, logo_url, status, rejection_reason, max_service_radius_km, rating_avg, total_jobs_completed, approved_at, created_at, updated_at, claim_token, stripe_connect_account_id, stripe_connect_onboarding_complete, stripe_connect_charges_enabled, stripe_connect_payouts_enabled, works_on_holidays, holiday_surcharge_pct, is_vat_payer, vat_checked_at FROM companies WHERE admin_user_id = $1
 `
 
 func (q *Queries) GetCompanyByAdminUserID(ctx context.Context, adminUserID pgtype.UUID) (Company, error) {
@@ -301,12 +317,16 @@ func (q *Queries) GetCompanyByAdminUserID(ctx context.Context, adminUserID pgtyp
 		&i.StripeConnectOnboardingComplete,
 		&i.StripeConnectChargesEnabled,
 		&i.StripeConnectPayoutsEnabled,
+		&i.WorksOnHolidays,
+		&i.HolidaySurchargePct,
+		&i.IsVatPayer,
+		&i.VatCheckedAt,
 	)
 	return i, err
 }
 
 const getCompanyByCUI = `-- name: GetCompanyByCUI :one
-SELECT id, admin_user_id, company_name, cui, company_type, legal_representative, contact_email, contact_phone, address, city, county, description, logo_url, status, rejection_reason, max_service_radius_km, rating_avg, total_jobs_completed, approved_at, created_at, updated_at, claim_token, stripe_connect_account_id, stripe_connect_onboarding_complete, stripe_connect_charges_enabled, stripe_connect_payouts_enabled FROM companies WHERE cui = $1
+SELECT id, admin_user_id, company_name, cui, company_type, legal_representative, contact_email, contact_phone, address, city, county, description, logo_url, status, rejection_reason, max_service_radius_km, rating_avg, total_jobs_completed, approved_at, created_at, updated_at, claim_token, stripe_connect_account_id, stripe_connect_onboarding_complete, stripe_connect_charges_enabled, stripe_connect_payouts_enabled, works_on_holidays, holiday_surcharge_pct, is_vat_payer, vat_checked_at FROM companies WHERE cui = $1
 `
 
 func (q *Queries) GetCompanyByCUI(ctx context.Context, cui string) (Company, error) {
@@ -339,12 +359,16 @@ func (q *Queries) GetCompanyByCUI(ctx context.Context, cui string) (Company, err
 		&i.StripeConnectOnboardingComplete,
 		&i.StripeConnectChargesEnabled,
 		&i.StripeConnectPayoutsEnabled,
+		&i.WorksOnHolidays,
+		&i.HolidaySurchargePct,
+		&i.IsVatPayer,
+		&i.VatCheckedAt,
 	)
 	return i, err
 }
 
 const getCompanyByClaimToken = `-- name: GetCompanyByClaimToken :one
-SELECT id, admin_user_id, company_name, cui, company_type, legal_representative, contact_email, contact_phone, address, city, county, description, logo_url, status, rejection_reason, max_service_radius_km, rating_avg, total_jobs_completed, approved_at, created_at, updated_at, claim_token, stripe_connect_account_id, stripe_connect_onboarding_complete, stripe_connect_charges_enabled, stripe_connect_payouts_enabled FROM companies
+SELECT id, admin_user_id, company_name, cui, company_type, legal_representative, contact_email, contact_phone, address, city, county, description, logo_url, status, rejection_reason, max_service_radius_km, rating_avg, total_jobs_completed, approved_at, created_at, updated_at, claim_token, stripe_connect_account_id, stripe_connect_onboarding_complete, stripe_connect_charges_enabled, stripe_connect_payouts_enabled, works_on_holidays, holiday_surcharge_pct, is_vat_payer, vat_checked_at FROM companies
 WHERE claim_token = $1 AND admin_user_id IS NULL
 `
 
@@ -378,12 +402,16 @@ func (q *Queries) GetCompanyByClaimToken(ctx context.Context, claimToken pgtype.
 		&i.StripeConnectOnboardingComplete,
 		&i.StripeConnectChargesEnabled,
 		&i.StripeConnectPayoutsEnabled,
+		&i.WorksOnHolidays,
+		&i.HolidaySurchargePct,
+		&i.IsVatPayer,
+		&i.VatCheckedAt,
 	)
 	return i, err
 }
 
 const getCompanyByID = `-- name: GetCompanyByID :one
-SELECT id, admin_user_id, company_name, cui, company_type, legal_representative, contact_email, contact_phone, address, city, county, description, logo_url, status, rejection_reason, max_service_radius_km, rating_avg, total_jobs_completed, approved_at, created_at, updated_at, claim_token, stripe_connect_account_id, stripe_connect_onboarding_complete, stripe_connect_charges_enabled, stripe_connect_payouts_enabled FROM companies WHERE id = $1
+SELECT id, admin_user_id, company_name, cui, company_type, legal_representative, contact_email, contact_phone, address, city, county, description, logo_url, status, rejection_reason, max_service_radius_km, rating_avg, total_jobs_completed, approved_at, created_at, updated_at, claim_token, stripe_connect_account_id, stripe_connect_onboarding_complete, stripe_connect_charges_enabled, stripe_connect_payouts_enabled, works_on_holidays, holiday_surcharge_pct, is_vat_payer, vat_checked_at FROM companies WHERE id = $1
 `
 
 func (q *Queries) GetCompanyByID(ctx context.Context, id pgtype.UUID) (Company, error) {
@@ -416,6 +444,10 @@ func (q *Queries) GetCompanyByID(ctx context.Context, id pgtype.UUID) (Company,
 		&i.StripeConnectOnboardingComplete,
 		&i.StripeConnectChargesEnabled,
 		&i.StripeConnectPayoutsEnabled,
+		&i.WorksOnHolidays,
+		&i.HolidaySurchargePct,
+		&i.IsVatPayer,
+		&i.VatCheckedAt,
 	)
 	return i, err
 }
@@ -449,7 +481,7 @@ func (q *Queries) GetCompanyFinancialSummary(ctx context.Context, companyID pgty
 }
 
 const getUnclaimedCompanyByContactEmail = `-- name: GetUnclaimedCompanyByContactEmail :one
-SELECT id, admin_user_id, company_name, cui, company_type, legal_representative, contact_email, contact_phone, address, city, county, description, logo_url, status, rejection_reason, max_service_radius_km, rating_avg, total_jobs_completed, approved_at, created_at, updated_at, claim_token, stripe_connect_account_id, stripe_connect_onboarding_complete, stripe_connect_charges_enabled, stripe_connect_payouts_enabled FROM companies
+SELECT id, admin_user_id, company_name, cui, company_type, legal_representative, contact_email, contact_phone, address, city, county, description, logo_url, status, rejection_reason, max_service_radius_km, rating_avg, total_jobs_completed, approved_at, created_at, updated_at, claim_token, stripe_connect_account_id, stripe_connect_onboarding_complete, stripe_connect_charges_enabled, stripe_connect_payouts_enabled, works_on_holidays, holiday_surcharge_pct, is_vat_payer, vat_checked_at FROM companies
 WHERE contact_email = $1 AND admin_user_id IS NULL
 LIMIT 1
 `
@@ -484,12 +516,16 @@ func (q *Queries) GetUnclaimedCompanyByContactEmail(ctx context.Context, contact
 		&i.StripeConnectOnboardingComplete,
 		&i.StripeConnectChargesEnabled,
 		&i.StripeConnectPayoutsEnabled,
+		&i.WorksOnHolidays,
+		&i.HolidaySurchargePct,
+		&i.IsVatPayer,
+		&i.VatCheckedAt,
 	)
 	return i, err
 }
 
 const listAllCompanies = `-- name: ListAllCompanies :many
-SELECT id, admin_user_id, company_name, cui, company_type, legal_representative, contact_email, contact_phone, address, city, county, description, logo_url, status, rejection_reason, max_service_radius_km, rating_avg, total_jobs_completed, approved_at, created_at, updated_at, claim_token, stripe_connect_account_id, stripe_connect_onboarding_complete, stripe_connect_charges_enabled, stripe_connect_payouts_enabled FROM companies ORDER BY created_at DESC LIMIT $1 OFFSET $2
+SELECT id, admin_user_id, company_name, cui, company_type, legal_representative, contact_email, contact_phone, address, city, county, description, logo_url, status, rejection_reason, max_service_radius_km, rating_avg, total_jobs_completed, approved_at, created_at, updated_at, claim_token, stripe_connect_account_id, stripe_connect_onboarding_complete, stripe_connect_charges_enabled, stripe_connect_payouts_enabled, works_on_holidays, holiday_surcharge_pct, is_vat_payer, vat_checked_at FROM companies ORDER BY created_at DESC LIMIT $1 OFFSET $2
 `
 
 type ListAllCompaniesParams struct {
@@ -533,6 +569,10 @@ func (q *Queries) ListAllCompanies(ctx context.Context, arg ListAllCompaniesPara
 			&i.StripeConnectOnboardingComplete,
 			&i.StripeConnectChargesEnabled,
 			&i.StripeConnectPayoutsEnabled,
+			&i.WorksOnHolidays,
+			&i.HolidaySurchargePct,
+			&i.IsVatPayer,
+			&i.VatCheckedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -545,7 +585,7 @@ func (q *Queries) ListAllCompanies(ctx context.Context, arg ListAllCompaniesPara
 }
 
 const listCompaniesByStatus = `-- name: ListCompaniesByStatus :many
-SELECT id, admin_user_id, company_name, cui, company_type, legal_representative, contact_email, contact_phone, address, city, county, description, logo_url, status, rejection_reason, max_service_radius_km, rating_avg, total_jobs_completed, approved_at, created_at, updated_at, claim_token, stripe_connect_account_id, stripe_connect_onboarding_complete, stripe_connect_charges_enabled, stripe_connect_payouts_enabled FROM companies WHERE status = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3
+SELECT id, admin_user_id, company_name, cui, company_type, legal_representative, contact_email, contact_phone, address, city, county, description, logo_url, status, rejection_reason, max_service_radius_km, rating_avg, total_jobs_completed, approved_at, created_at, updated_at, claim_token, stripe_connect_account_id, stripe_connect_onboarding_complete, stripe_connect_charges_enabled, stripe_connect_payouts_enabled, works_on_holidays, holiday_surcharge_pct, is_vat_payer, vat_checked_at FROM companies WHERE status = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3
 `
 
 type ListCompaniesByStatusParams struct {
@@ -590,6 +630,10 @@ func (q *Queries) ListCompaniesByStatus(ctx context.Context, arg ListCompaniesBy
 			&i.StripeConnectOnboardingComplete,
 			&i.StripeConnectChargesEnabled,
 			&i.StripeConnectPayoutsEnabled,
+			&i.WorksOnHolidays,
+			&i.HolidaySurchargePct,
+			&i.IsVatPayer,
+			&i.VatCheckedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -603,7 +647,7 @@ func (q *Queries) ListCompaniesByStatus(ctx context.Context, arg ListCompaniesBy
 
 const rejectCompany = `-- name: RejectCompany :one
 UPDATE companies SET status = 'rejected', rejection_reason = $2, updated_at = NOW()
-WHERE id = $1 RETURNING id, admin_user_id, company_name, cui, company_type, legal_representative, contact_email, contact_phone, address, city, county, description, logo_url, status, rejection_reason, max_service_radius_km, rating_avg, total_jobs_completed, approved_at, created_at, updated_at, claim_token, stripe_connect_account_id, stripe_connect_onboarding_complete, stripe_connect_charges_enabled, stripe_connect_payouts_enabled
+WHERE id = $1 RETURNING id, admin_user_id, company_name, cui, company_type, legal_representative, contact_email, contact_phone, address, city, county, description, logo_url, status, rejection_reason, max_service_radius_km, rating_avg, total_jobs_completed, approved_at, created_at, updated_at, claim_token, stripe_connect_account_id, stripe_connect_onboarding_complete, stripe_connect_charges_enabled, stripe_connect_payouts_enabled, works_on_holidays, holiday_surcharge_pct, is_vat_payer, vat_checked_at
 `
 
 type RejectCompanyParams struct {
@@ -641,12 +685,16 @@ func (q *Queries) RejectCompany(ctx context.Context, arg RejectCompanyParams) (C
 		&i.StripeConnectOnboardingComplete,
 		&i.StripeConnectChargesEnabled,
 		&i.StripeConnectPayoutsEnabled,
+		&i.WorksOnHolidays,
+		&i.HolidaySurchargePct,
+		&i.IsVatPayer,
+		&i.VatCheckedAt,
 	)
 	return i, err
 }
 
 const searchCompanies = `-- name: SearchCompanies :many
-SELECT id, admin_user_id, company_name, cui, company_type, legal_representative, contact_email, contact_phone, address, city, county, description, logo_url, status, rejection_reason, max_service_radius_km, rating_avg, total_jobs_completed, approved_at, created_at, updated_at, claim_token, stripe_connect_account_id, stripe_connect_onboarding_complete, stripe_connect_charges_enabled, stripe_connect_payouts_enabled FROM companies WHERE
+SELECT id, admin_user_id, company_name, cui, company_type, legal_representative, contact_email, contact_phone, address, city, county, description, logo_url, status, rejection_reason, max_service_radius_km, rating_avg, total_jobs_completed, approved_at, created_at, updated_at, claim_token, stripe_connect_account_id, stripe_connect_onboarding_complete, stripe_connect_charges_enabled, stripe_connect_payouts_enabled, works_on_holidays, holiday_surcharge_pct, is_vat_payer, vat_checked_at FROM companies WHERE
     (company_name ILIKE '%' || $3::text || '%' OR cui ILIKE '%' || $3::text || '%')
     AND ($4::text = '' OR status::text = $4::text)
 ORDER BY created_at DESC LIMIT $1 OFFSET $2
@@ -700,6 +748,10 @@ func (q *Queries) SearchCompanies(ctx context.Context, arg SearchCompaniesParams
 			&i.StripeConnectOnboardingComplete,
 			&i.StripeConnectChargesEnabled,
 			&i.StripeConnectPayoutsEnabled,
+			&i.WorksOnHolidays,
+			&i.HolidaySurchargePct,
+			&i.IsVatPayer,
+			&i.VatCheckedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -714,7 +766,7 @@ func (q *Queries) SearchCompanies(ctx context.Context, arg SearchCompaniesParams
 const setCompanyAdminUser = `-- name: SetCompanyAdminUser :one
 UPDATE companies SET admin_user_id = $1, updated_at = NOW()
 WHERE id = $2
-RETURNING id, admin_user_id, company_name, cui, company_type, legal_representative, contact_email, contact_phone, address, city, county, description, logo_url, status, rejection_reason, max_service_radius_km, rating_avg, total_jobs_completed, approved_at, created_at, updated_at, claim_token, stripe_connect_account_id, stripe_connect_onboarding_complete, stripe_connect_charges_enabled, stripe_connect_payouts_enabled
+RETURNING id, admin_user_id, company_name, cui, company_type, legal_representative, contact_email, contact_phone, address, city, county, description, logo_url, status, rejection_reason, max_service_radius_km, rating_avg, total_jobs_completed, approved_at, created_at, updated_at, claim_token, stripe_connect_account_id, stripe_connect_onboarding_complete, stripe_connect_charges_enabled, stripe_connect_payouts_enabled, works_on_holidays, holiday_surcharge_pct, is_vat_payer, vat_checked_at
 `
 
 type SetCompanyAdminUserParams struct {
@@ -752,12 +804,82 @@ func (q *Queries) SetCompanyAdminUser(ctx context.Context, arg SetCompanyAdminUs
 		&i.StripeConnectOnboardingComplete,
 		&i.StripeConnectChargesEnabled,
 		&i.StripeConnectPayoutsEnabled,
+		&i.WorksOnHolidays,
+		&i.HolidaySurchargePct,
+		&i.IsVatPayer,
+		&i.VatCheckedAt,
+	)
+	return i, err
+}
+
+const setCompanyVATStatus = `-- name: SetCompanyVATStatus :exec
+UPDATE companies SET is_vat_payer = $2, vat_checked_at = NOW(), updated_at = NOW() WHERE id = $1
+`
+
+type SetCompanyVATStatusParams struct {
+	ID         pgtype.UUID `json:"id"`
+	IsVatPayer pgtype.Bool `json:"is_vat_payer"`
+}
+
+// SetCompanyVATStatus records the VAT registration of a company as checked now.
+func (q *Queries) SetCompanyVATStatus(ctx context.Context, arg SetCompanyVATStatusParams) error {
+	_, err := q.db.Exec(ctx, setCompanyVATStatus, arg.ID, arg.IsVatPayer)
+	return err
+}
+
+const updateCompanyHolidayPolicy = `-- name: UpdateCompanyHolidayPolicy :one
+UPDATE companies
+SET works_on_holidays = $2, holiday_surcharge_pct = $3, updated_at = NOW()
+WHERE id = $1
+RETURNING id, admin_user_id, company_name, cui, company_type, legal_representative, contact_email, contact_phone, address, city, county, description, logo_url, status, rejection_reason, max_service_radius_km, rating_avg, total_jobs_completed, approved_at, created_at, updated_at, claim_token, stripe_connect_account_id, stripe_connect_onboarding_complete, stripe_connect_charges_enabled, stripe_connect_payouts_enabled, works_on_holidays, holiday_surcharge_pct, is_vat_payer, vat_checked_at
+`
+
+type UpdateCompanyHolidayPolicyParams struct {
+	ID                  pgtype.UUID    `json:"id"`
+	WorksOnHolidays     bool           `json:"works_on_holidays"`
+	HolidaySurchargePct pgtype.Numeric `json:"holiday_surcharge_pct"`
+}
+
+func (q *Queries) UpdateCompanyHolidayPolicy(ctx context.Context, arg UpdateCompanyHolidayPolicyParams) (Company, error) {
+	row := q.db.QueryRow(ctx, updateCompanyHolidayPolicy, arg.ID, arg.WorksOnHolidays, arg.HolidaySurchargePct)
+	var i Company
+	err := row.Scan(
+		&i.ID,
+		&i.AdminUserID,
+		&i.CompanyName,
+		&i.Cui,
+		&i.CompanyType,
+		&i.LegalRepresentative,
+		&i.ContactEmail,
+		&i.ContactPhone,
+		&i.Address,
+		&i.City,
+		&i.County,
+		&i.Description,
+		&i.LogoUrl,
+		&i.Status,
+		&i.RejectionReason,
+		&i.MaxServiceRadiusKm,
+		&i.RatingAvg,
+		&i.TotalJobsCompleted,
+		&i.ApprovedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ClaimToken,
+		&i.StripeConnectAccountID,
+		&i.StripeConnectOnboardingComplete,
+		&i.StripeConnectChargesEnabled,
+		&i.StripeConnectPayoutsEnabled,
+		&i.WorksOnHolidays,
+		&i.HolidaySurchargePct,
+		&i.IsVatPayer,
+		&i.VatCheckedAt,
 	)
 	return i, err
 }
 
 const updateCompanyLogo = `-- name: UpdateCompanyLogo :one
-UPDATE companies SET logo_url = $2, updated_at = NOW() WHERE id = $1 RETURNING id, admin_user_id, company_name, cui, company_type, legal_representative, contact_email, contact_phone, address, city, county, description, logo_url, status, rejection_reason, max_service_radius_km, rating_avg, total_jobs_completed, approved_at, created_at, updated_at, claim_token, stripe_connect_account_id, stripe_connect_onboarding_complete, stripe_connect_charges_enabled, stripe_connect_payouts_enabled
+UPDATE companies SET logo_url = $2, updated_at = NOW() WHERE id = $1 RETURNING id, admin_user_id, company_name, cui, company_type, legal_representative, contact_email, contact_phone, address, city, county, description, logo_url, status, rejection_reason, max_service_radius_km, rating_avg, total_jobs_completed, approved_at, created_at, updated_at, claim_token, stripe_connect_account_id, stripe_connect_onboarding_complete, stripe_connect_charges_enabled, stripe_connect_payouts_enabled, works_on_holidays, holiday_surcharge_pct, is_vat_payer, vat_checked_at
 `
 
 type UpdateCompanyLogoParams struct {
@@ -795,6 +917,10 @@ func (q *Queries) UpdateCompanyLogo(ctx context.Context, arg UpdateCompanyLogoPa
 		&i.StripeConnectOnboardingComplete,
 		&i.StripeConnectChargesEnabled,
 		&i.StripeConnectPayoutsEnabled,
+		&i.WorksOnHolidays,
+		&i.HolidaySurchargePct,
+		&i.IsVatPayer,
+		&i.VatCheckedAt,
 	)
 	return i, err
 }
@@ -806,7 +932,7 @@ UPDATE companies SET
     contact_email = COALESCE(NULLIF($4::text, ''), contact_email),
     max_service_radius_km = CASE WHEN $5::int > 0 THEN $5::int ELSE max_service_radius_km END,
     updated_at = NOW()
-WHERE id = $1 RETURNING id, admin_user_id, company_name, cui, company_type, legal_representative, contact_email, contact_phone, address, city, county, description, logo_url, status, rejection_reason, max_service_radius_km, rating_avg, total_jobs_completed, approved_at, created_at, updated_at, claim_token, stripe_connect_account_id, stripe_connect_onboarding_complete, stripe_connect_charges_enabled, stripe_connect_payouts_enabled
+WHERE id = $1 RETURNING id, admin_user_id, company_name, cui, company_type, legal_representative, contact_email, contact_phone, address, city, county, description, logo_url, status, rejection_reason, max_service_radius_km, rating_avg, total_jobs_completed, approved_at, created_at, updated_at, claim_token, stripe_connect_account_id, stripe_connect_onboarding_complete, stripe_connect_charges_enabled, stripe_connect_payouts_enabled, works_on_holidays, holiday_surcharge_pct, is_vat_payer, vat_checked_at
 `
 
 type UpdateCompanyOwnProfileParams struct {
@@ -853,12 +979,16 @@ func (q *Queries) UpdateCompanyOwnProfile(ctx context.Context, arg UpdateCompany
 		&i.StripeConnectOnboardingComplete,
 		&i.StripeConnectChargesEnabled,
 		&i.StripeConnectPayoutsEnabled,
+		&i.WorksOnHolidays,
+		&i.HolidaySurchargePct,
+		&i.IsVatPayer,
+		&i.VatCheckedAt,
 	)
 	return i, err
 }
 
 const updateCompanyStatus = `-- name: UpdateCompanyStatus :one
-UPDATE companies SET status = $2, updated_at = NOW() WHERE id = $1 RETURNING id, admin_user_id, company_name, cui, company_type, legal_representative, contact_email, contact_phone, address, city, county, description, logo_url, status, rejection_reason, max_service_radius_km, rating_avg, total_jobs_completed, approved_at, created_at, updated_at, claim_token, stripe_connect_account_id, stripe_connect_onboarding_complete, stripe_connect_charges_enabled, stripe_connect_payouts_enabled
+UPDATE companies SET status = $2, updated_at = NOW() WHERE id = $1 RETURNING id, admin_user_id, company_name, cui, company_type, legal_representative, contact_email, contact_phone, address, city, county, description, logo_url, status, rejection_reason, max_service_radius_km, rating_avg, total_jobs_completed, approved_at, created_at, updated_at, claim_token, stripe_connect_account_id, stripe_connect_onboarding_complete, stripe_connect_charges_enabled, stripe_connect_payouts_enabled, works_on_holidays, holiday_surcharge_pct, is_vat_payer, vat_checked_at
 `
 
 type UpdateCompanyStatusParams struct {
@@ -896,6 +1026,10 @@ func (q *Queries) UpdateCompanyStatus(ctx context.Context, arg UpdateCompanyStat
 		&i.StripeConnectOnboardingComplete,
 		&i.StripeConnectChargesEnabled,
 		&i.StripeConnectPayoutsEnabled,
+		&i.WorksOnHolidays,
+		&i.HolidaySurchargePct,
+		&i.IsVatPayer,
+		&i.VatCheckedAt,
 	)
 	return i, err
 }
@@ -11,6 +11,83 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const claimInvoicePipeline = `-- name: ClaimInvoicePipeline :one
+UPDATE invoices
+SET pipeline_status = 'processing', pipeline_attempts = pipeline_attempts + 1, pipeline_updated_at = NOW()
+WHERE id = $1
+  AND (pipeline_status IN ('pending', 'failed')
+       OR (pipeline_status = 'processing' AND pipeline_updated_at < NOW() - INTERVAL '10 minutes'))
+RETURNING id, invoice_type, invoice_number, factureaza_id, factureaza_download_url, seller_company_name, seller_cui, seller_reg_number, seller_address, seller_city, seller_county, seller_is_vat_payer, seller_bank_name, seller_iban, buyer_name, buyer_cui, buyer_reg_number, buyer_address, buyer_city, buyer_county, buyer_is_vat_payer, buyer_email, subtotal_amount, vat_rate, vat_amount, total_amount, currency, booking_id, payment_transaction_id, company_id, client_user_id, efactura_status, efactura_index, status, issued_at, due_date, notes, created_at, updated_at, efactura_message, efactura_download_id, efactura_response_path, efactura_uploaded_at, efactura_checked_at, pdf_path, pipeline_status, pipeline_attempts, pipeline_error, pipeline_next_attempt_at, pipeline_updated_at, emailed_at, credited_invoice_id, credited_invoice_number, credited_invoice_date, stripe_refund_id, payout_id, period_from, period_to
+`
+
+// ClaimInvoicePipeline marks the pipeline of an invoice as running. It returns
+// no rows when the pipeline is done or another worker holds a fresh claim.
+func (q *Queries) ClaimInvoicePipeline(ctx context.Context, id pgtype.UUID) (Invoice, error) {
+	row := q.db.QueryRow(ctx, claimInvoicePipeline, id)
+	var i Invoice
+	err := row.Scan(
+		&i.ID,
+		&i.InvoiceType,
+		&i.InvoiceNumber,
+		&i.FactureazaID,
+		&i.FactureazaDownloadUrl,
+		&i.SellerCompanyName,
+		&i.SellerCui,
+		&i.SellerRegNumber,
+		&i.SellerAddress,
+		&i.SellerCity,
+		&i.SellerCounty,
+		&i.SellerIsVatPayer,
+		&i.SellerBankName,
+		&i.SellerIban,
+		&i.BuyerName,
+		&i.BuyerCui,
+		&i.BuyerRegNumber,
+		&i.BuyerAddress,
+		&i.BuyerCity,
+		&i.BuyerCounty,
+		&i.BuyerIsVatPayer,
+		&i.BuyerEmail,
+		&i.SubtotalAmount,
+		&i.VatRate,
+		&i.VatAmount,
+		&i.TotalAmount,
+		&i.Currency,
+		&i.BookingID,
+		&i.PaymentTransactionID,
+		&i.CompanyID,
+		&i.ClientUserID,
+		&i.EfacturaStatus,
+		&i.EfacturaIndex,
+		&i.Status,
+		&i.IssuedAt,
+		&i.DueDate,
+		&i.Notes,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.EfacturaMessage,
+		&i.EfacturaDownloadID,
+		&i.EfacturaResponsePath,
+		&i.EfacturaUploadedAt,
+		&i.EfacturaCheckedAt,
+		&i.PdfPath,
+		&i.PipelineStatus,
+		&i.PipelineAttempts,
+		&i.PipelineError,
+		&i.PipelineNextAttemptAt,
+		&i.PipelineUpdatedAt,
+		&i.EmailedAt,
+		&i.CreditedInvoiceID,
+		&i.CreditedInvoiceNumber,
+		&i.CreditedInvoiceDate,
+		&i.StripeRefundID,
+		&i.PayoutID,
+		&i.PeriodFrom,
+		&i.PeriodTo,
+	)
+	return i, err
+}
+
 const countInvoicesByClient = `-- name: CountInvoicesByClient :one
 SELECT COUNT(*) FROM invoices WHERE client_user_id = $1
 `
@@ -99,54 +176,67 @@ INSERT INTO invoices (
   buyer_is_vat_payer, buyer_email,
   subtotal_amount, vat_rate, vat_amount, total_amount, currency,
   booking_id, payment_transaction_id, company_id, client_user_id,
-  status, due_date, notes
+  status, due_date, notes,
+  credited_invoice_id, credited_invoice_number, credited_invoice_date, stripe_refund_id, payout_id,
+  period_from, period_to
 ) VALUES (
   $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11,
   $12, $13, $14, $15, $16, $17, $18, $19,
   $20, $21, $22, $23, $24,
   $25, $26, $27, $28,
-  $29, $30, $31
+  $29, $30, $31,
+  $32, $33, $34, $35, $36,
+  $37, $38
 )
-RETURNING id, invoice_type, invoice_number, factureaza_id, factureaza_download_url, seller_company_name, seller_cui, seller_reg_number, seller_address, seller_city, seller_county, seller_is_vat_payer, seller_bank_name, seller_iban, buyer_name, buyer_cui, buyer_reg_number, buyer_address, buyer_city, buyer_county, buyer_is_vat_payer, buyer_email, subtotal_amount, vat_rate, vat_amount, total_amount, currency, booking_id, payment_transaction_id, company_id, client_user_id, efactura_status, efactura_index, status, issued_at, due_date, notes, created_at, updated_at
+ON CONFLICT (booking_id) WHERE invoice_type = 'client_service' AND credited_invoice_id IS NULL DO NOTHING
+RETURNING id, invoice_type, invoice_number, factureaza_id, factureaza_download_url, seller_company_name, seller_cui, seller_reg_number, seller_address, seller_city, seller_county, seller_is_vat_payer, seller_bank_name, seller_iban, buyer_name, buyer_cui, buyer_reg_number, buyer_address, buyer_city, buyer_county, buyer_is_vat_payer, buyer_email, subtotal_amount, vat_rate, vat_amount, total_amount, currency, booking_id, payment_transaction_id, company_id, client_user_id, efactura_status, efactura_index, status, issued_at, due_date, notes, created_at, updated_at, efactura_message, efactura_download_id, efactura_response_path, efactura_uploaded_at, efactura_checked_at, pdf_path, pipeline_status, pipeline_attempts, pipeline_error, pipeline_next_attempt_at, pipeline_updated_at, emailed_at, credited_invoice_id, credited_invoice_number, credited_invoice_date, stripe_refund_id, payout_id, period_from, period_to
 `
 
 type CreateInvoiceParams struct {
-	InvoiceType          InvoiceType    `json:"invoice_type"`
-	InvoiceNumber        pgtype.Text    `json:"invoice_number"`
-	SellerCompanyName    string         `json:"seller_company_name"`
-	SellerCui            string         `json:"seller_cui"`
-	SellerRegNumber      pgtype.Text    `json:"seller_reg_number"`
-	SellerAddress        string         `json:"seller_address"`
-	SellerCity           string         `json:"seller_city"`
-	SellerCounty         string         `json:"seller_county"`
-	SellerIsVatPayer     bool           `json:"seller_is_vat_payer"`
-	SellerBankName       pgtype.Text    `json:"seller_bank_name"`
-	SellerIban           pgtype.Text    `json:"seller_iban"`
-	BuyerName            string         `json:"buyer_name"`
-	BuyerCui             pgtype.Text    `json:"buyer_cui"`
-	BuyerRegNumber       pgtype.Text    `json:"buyer_reg_number"`
-	BuyerAddress         pgtype.Text    `json:"buyer_address"`
-	BuyerCity            pgtype.Text    `json:"buyer_city"`
-	BuyerCounty          pgtype.Text    `json:"buyer_county"`
-	BuyerIsVatPayer      pgtype.Bool    `json:"buyer_is_vat_payer"`
-	BuyerEmail           pgtype.Text    `json:"buyer_email"`
-	SubtotalAmount       int32          `json:"subtotal_amount"`
-	VatRate              pgtype.Numeric `json:"vat_rate"`
-	VatAmount            int32          `json:"vat_amount"`
-	TotalAmount          int32          `json:"total_amount"`
-	Currency             string         `json:"currency"`
-	BookingID            pgtype.UUID    `json:"booking_id"`
-	PaymentTransactionID pgtype.UUID    `json:"payment_transaction_id"`
-	CompanyID            pgtype.UUID    `json:"company_id"`
-	ClientUserID         pgtype.UUID    `json:"client_user_id"`
-	Status               InvoiceStatus  `json:"status"`
-	DueDate              pgtype.Date    `json:"due_date"`
-	Notes                pgtype.Text    `json:"notes"`
+	InvoiceType           InvoiceType    `json:"invoice_type"`
+	InvoiceNumber         pgtype.Text    `json:"invoice_number"`
+	SellerCompanyName     string         `json:"seller_company_name"`
+	SellerCui             string         `json:"seller_cui"`
+	SellerRegNumber       pgtype.Text    `json:"seller_reg_number"`
+	SellerAddress         string         `json:"seller_address"`
+	SellerCity            string         `json:"seller_city"`
+	SellerCounty          string         `json:"seller_county"`
+	SellerIsVatPayer      bool           `json:"seller_is_vat_payer"`
+	SellerBankName        pgtype.Text    `json:"seller_bank_name"`
+	SellerIban            pgtype.Text    `json:"seller_iban"`
+	BuyerName             string         `json:"buyer_name"`
+	BuyerCui              pgtype.Text    `json:"buyer_cui"`
+	BuyerRegNumber        pgtype.Text    `json:"buyer_reg_number"`
+	BuyerAddress          pgtype.Text    `json:"buyer_address"`
+	BuyerCity             pgtype.Text    `json:"buyer_city"`
+	BuyerCounty           pgtype.Text    `json:"buyer_county"`
+	BuyerIsVatPayer       pgtype.Bool    `json:"buyer_is_vat_payer"`
+	BuyerEmail            pgtype.Text    `json:"buyer_email"`
+	SubtotalAmount        int32          `json:"subtotal_amount"`
+	VatRate               pgtype.Numeric `json:"vat_rate"`
+	VatAmount             int32          `json:"vat_amount"`
+	TotalAmount           int32          `json:"total_amount"`
+	Currency              string         `json:"currency"`
+	BookingID             pgtype.UUID    `json:"booking_id"`
+	PaymentTransactionID  pgtype.UUID    `json:"payment_transaction_id"`
+	CompanyID             pgtype.UUID    `json:"company_id"`
+	ClientUserID          pgtype.UUID    `json:"client_user_id"`
+	Status                InvoiceStatus  `json:"status"`
+	DueDate               pgtype.Date    `json:"due_date"`
+	Notes                 pgtype.Text    `json:"notes"`
+	CreditedInvoiceID     pgtype.UUID    `json:"credited_invoice_id"`
+	CreditedInvoiceNumber pgtype.Text    `json:"credited_invoice_number"`
+	CreditedInvoiceDate   pgtype.Date    `json:"credited_invoice_date"`
+	StripeRefundID        pgtype.Text    `json:"stripe_refund_id"`
+	PayoutID              pgtype.UUID    `json:"payout_id"`
+	PeriodFrom            pgtype.Date    `json:"period_from"`
+	PeriodTo              pgtype.Date    `json:"period_to"`
 }
 
 // ============================================
 // INVOICES
 // ============================================
+// CreateInvoice returns no rows for a second client invoice of a booking.
 func (q *Queries) CreateInvoice(ctx context.Context, arg CreateInvoiceParams) (Invoice, error) {
 	row := q.db.QueryRow(ctx, createInvoice,
 		arg.InvoiceType,
@@ -180,6 +270,13 @@ func (q *Queries) CreateInvoice(ctx context.Context, arg CreateInvoiceParams) (I
 		arg.Status,
 		arg.DueDate,
 		arg.Notes,
+		arg.CreditedInvoiceID,
+		arg.CreditedInvoiceNumber,
+		arg.CreditedInvoiceDate,
+		arg.StripeRefundID,
+		arg.PayoutID,
+		arg.PeriodFrom,
+		arg.PeriodTo,
 	)
 	var i Invoice
 	err := row.Scan(
@@ -222,6 +319,25 @@ func (q *Queries) CreateInvoice(ctx context.Context, arg CreateInvoiceParams) (I
 		&i.Notes,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.EfacturaMessage,
+		&i.EfacturaDownloadID,
+		&i.EfacturaResponsePath,
+		&i.EfacturaUploadedAt,
+		&i.EfacturaCheckedAt,
+		&i.PdfPath,
+		&i.PipelineStatus,
+		&i.PipelineAttempts,
+		&i.PipelineError,
+		&i.PipelineNextAttemptAt,
+		&i.PipelineUpdatedAt,
+		&i.EmailedAt,
+		&i.CreditedInvoiceID,
+		&i.CreditedInvoiceNumber,
+		&i.CreditedInvoiceDate,
+		&i.StripeRefundID,
+		&i.PayoutID,
+		&i.PeriodFrom,
+		&i.PeriodTo,
 	)
 	return i, err
 }
@@ -281,23 +397,6 @@ func (q *Queries) CreateInvoiceLineItem(ctx context.Context, arg CreateInvoiceLi
 	return i, err
 }
 
-const createInvoiceSequence = `-- name: CreateInvoiceSequence :exec
-INSERT INTO invoice_sequences (company_id, prefix, year, current_number)
-VALUES ($1, $2, $3, 0)
-ON CONFLICT (company_id, prefix, year) DO NOTHING
-`
-
-type CreateInvoiceSequenceParams struct {
-	CompanyID pgtype.UUID `json:"company_id"`
-	Prefix    string      `json:"prefix"`
-	Year      int32       `json:"year"`
-}
-
-func (q *Queries) CreateInvoiceSequence(ctx context.Context, arg CreateInvoiceSequenceParams) error {
-	_, err := q.db.Exec(ctx, createInvoiceSequence, arg.CompanyID, arg.Prefix, arg.Year)
-	return err
-}
-
 const deleteBillingProfile = `-- name: DeleteBillingProfile :exec
 DELETE FROM client_billing_profiles WHERE id = $1
 `
@@ -338,6 +437,241 @@ func (q *Queries) GetBillingProfileByUser(ctx context.Context, userID pgtype.UUI
 	return i, err
 }
 
+const getCommissionInvoiceByBooking = `-- name: GetCommissionInvoiceByBooking :one
+SELECT i.id, i.invoice_type, i.invoice_number, i.factureaza_id, i.factureaza_download_url, i.seller_company_name, i.seller_cui, i.seller_reg_number, i.seller_address, i.seller_city, i.seller_county, i.seller_is_vat_payer, i.seller_bank_name, i.seller_iban, i.buyer_name, i.buyer_cui, i.buyer_reg_number, i.buyer_address, i.buyer_city, i.buyer_county, i.buyer_is_vat_payer, i.buyer_email, i.subtotal_amount, i.vat_rate, i.vat_amount, i.total_amount, i.currency, i.booking_id, i.payment_transaction_id, i.company_id, i.client_user_id, i.efactura_status, i.efactura_index, i.status, i.issued_at, i.due_date, i.notes, i.created_at, i.updated_at, i.efactura_message, i.efactura_download_id, i.efactura_response_path, i.efactura_uploaded_at, i.efactura_checked_at, i.pdf_path, i.pipeline_status, i.pipeline_attempts, i.pipeline_error, i.pipeline_next_attempt_at, i.pipeline_updated_at, i.emailed_at, i.credited_invoice_id, i.credited_invoice_number, i.credited_invoice_date, i.stripe_refund_id, i.payout_id, i.period_from, i.period_to FROM invoices i
+WHERE i.invoice_type = 'platform_commission' AND i.status NOT IN ('cancelled', 'credit_note')
+  AND (
+    i.payout_id IN (SELECT pli.payout_id FROM payout_line_items pli WHERE pli.booking_id = $1)
+    OR EXISTS (
+      SELECT 1 FROM ledger_entries e
+      WHERE e.kind = 'payment' AND e.booking_id = $1 AND e.company_id = i.company_id
+        AND (e.occurred_at AT TIME ZONE 'Europe/Bucharest')::date BETWEEN i.period_from AND i.period_to
+    )
+  )
+ORDER BY i.created_at DESC LIMIT 1
+`
+
+// GetCommissionInvoiceByBooking returns the commission invoice that charged
+// the platform fee of a booking, if one was issued: the invoice of the month
+// the booking was paid in, or of the payout that included it.
+func (q *Queries) GetCommissionInvoiceByBooking(ctx context.Context, bookingID pgtype.UUID) (Invoice, error) {
+	row := q.db.QueryRow(ctx, getCommissionInvoiceByBooking, bookingID)
+	var i Invoice
+	err := row.Scan(
+		&i.ID,
+		&i.InvoiceType,
+		&i.InvoiceNumber,
+		&i.FactureazaID,
+		&i.FactureazaDownloadUrl,
+		&i.SellerCompanyName,
+		&i.SellerCui,
+		&i.SellerRegNumber,
+		&i.SellerAddress,
+		&i.SellerCity,
+		&i.SellerCounty,
+		&i.SellerIsVatPayer,
+		&i.SellerBankName,
+		&i.SellerIban,
+		&i.BuyerName,
+		&i.BuyerCui,
+		&i.BuyerRegNumber,
+		&i.BuyerAddress,
+		&i.BuyerCity,
+		&i.BuyerCounty,
+		&i.BuyerIsVatPayer,
+		&i.BuyerEmail,
+		&i.SubtotalAmount,
+		&i.VatRate,
+		&i.VatAmount,
+		&i.TotalAmount,
+		&i.Currency,
+		&i.BookingID,
+		&i.PaymentTransactionID,
+		&i.CompanyID,
+		&i.ClientUserID,
+		&i.EfacturaStatus,
+		&i.EfacturaIndex,
+		&i.Status,
+		&i.IssuedAt,
+		&i.DueDate,
+		&i.Notes,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.EfacturaMessage,
+		&i.EfacturaDownloadID,
+		&i.EfacturaResponsePath,
+		&i.EfacturaUploadedAt,
+		&i.EfacturaCheckedAt,
+		&i.PdfPath,
+		&i.PipelineStatus,
+		&i.PipelineAttempts,
+		&i.PipelineError,
+		&i.PipelineNextAttemptAt,
+		&i.PipelineUpdatedAt,
+		&i.EmailedAt,
+		&i.CreditedInvoiceID,
+		&i.CreditedInvoiceNumber,
+		&i.CreditedInvoiceDate,
+		&i.StripeRefundID,
+		&i.PayoutID,
+		&i.PeriodFrom,
+		&i.PeriodTo,
+	)
+	return i, err
+}
+
+const getCommissionInvoiceForPeriod = `-- name: GetCommissionInvoiceForPeriod :one
+SELECT id, invoice_type, invoice_number, factureaza_id, factureaza_download_url, seller_company_name, seller_cui, seller_reg_number, seller_address, seller_city, seller_county, seller_is_vat_payer, seller_bank_name, seller_iban, buyer_name, buyer_cui, buyer_reg_number, buyer_address, buyer_city, buyer_county, buyer_is_vat_payer, buyer_email, subtotal_amount, vat_rate, vat_amount, total_amount, currency, booking_id, payment_transaction_id, company_id, client_user_id, efactura_status, efactura_index, status, issued_at, due_date, notes, created_at, updated_at, efactura_message, efactura_download_id, efactura_response_path, efactura_uploaded_at, efactura_checked_at, pdf_path, pipeline_status, pipeline_attempts, pipeline_error, pipeline_next_attempt_at, pipeline_updated_at, emailed_at, credited_invoice_id, credited_invoice_number, credited_invoice_date, stripe_refund_id, payout_id, period_from, period_to FROM invoices
+WHERE company_id = $1 AND period_from = $2 AND invoice_type = 'platform_commission'
+  AND status NOT IN ('cancelled', 'credit_note')
+`
+
+type GetCommissionInvoiceForPeriodParams struct {
+	CompanyID  pgtype.UUID `json:"company_id"`
+	PeriodFrom pgtype.Date `json:"period_from"`
+}
+
+func (q *Queries) GetCommissionInvoiceForPeriod(ctx context.Context, arg GetCommissionInvoiceForPeriodParams) (Invoice, error) {
+	row := q.db.QueryRow(ctx, getCommissionInvoiceForPeriod, arg.CompanyID, arg.PeriodFrom)
+	var i Invoice
+	err := row.Scan(
+		&i.ID,
+		&i.InvoiceType,
+		&i.InvoiceNumber,
+		&i.FactureazaID,
+		&i.FactureazaDownloadUrl,
+		&i.SellerCompanyName,
+		&i.SellerCui,
+		&i.SellerRegNumber,
+		&i.SellerAddress,
+		&i.SellerCity,
+		&i.SellerCounty,
+		&i.SellerIsVatPayer,
+		&i.SellerBankName,
+		&i.SellerIban,
+		&i.BuyerName,
+		&i.BuyerCui,
+		&i.BuyerRegNumber,
+		&i.BuyerAddress,
+		&i.BuyerCity,
+		&i.BuyerCounty,
+		&i.BuyerIsVatPayer,
+		&i.BuyerEmail,
+		&i.SubtotalAmount,
+		&i.VatRate,
+		&i.VatAmount,
+		&i.TotalAmount,
+		&i.Currency,
+		&i.BookingID,
+		&i.PaymentTransactionID,
+		&i.CompanyID,
+		&i.ClientUserID,
+		&i.EfacturaStatus,
+		&i.EfacturaIndex,
+		&i.Status,
+		&i.IssuedAt,
+		&i.DueDate,
+		&i.Notes,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.EfacturaMessage,
+		&i.EfacturaDownloadID,
+		&i.EfacturaResponsePath,
+		&i.EfacturaUploadedAt,
+		&i.EfacturaCheckedAt,
+		&i.PdfPath,
+		&i.PipelineStatus,
+		&i.PipelineAttempts,
+		&i.PipelineError,
+		&i.PipelineNextAttemptAt,
+		&i.PipelineUpdatedAt,
+		&i.EmailedAt,
+		&i.CreditedInvoiceID,
+		&i.CreditedInvoiceNumber,
+		&i.CreditedInvoiceDate,
+		&i.StripeRefundID,
+		&i.PayoutID,
+		&i.PeriodFrom,
+		&i.PeriodTo,
+	)
+	return i, err
+}
+
+const getCreditNoteByRefund = `-- name: GetCreditNoteByRefund :one
+SELECT id, invoice_type, invoice_number, factureaza_id, factureaza_download_url, seller_company_name, seller_cui, seller_reg_number, seller_address, seller_city, seller_county, seller_is_vat_payer, seller_bank_name, seller_iban, buyer_name, buyer_cui, buyer_reg_number, buyer_address, buyer_city, buyer_county, buyer_is_vat_payer, buyer_email, subtotal_amount, vat_rate, vat_amount, total_amount, currency, booking_id, payment_transaction_id, company_id, client_user_id, efactura_status, efactura_index, status, issued_at, due_date, notes, created_at, updated_at, efactura_message, efactura_download_id, efactura_response_path, efactura_uploaded_at, efactura_checked_at, pdf_path, pipeline_status, pipeline_attempts, pipeline_error, pipeline_next_attempt_at, pipeline_updated_at, emailed_at, credited_invoice_id, credited_invoice_number, credited_invoice_date, stripe_refund_id, payout_id, period_from, period_to FROM invoices WHERE stripe_refund_id = $1 AND invoice_type = $2
+`
+
+type GetCreditNoteByRefundParams struct {
+	StripeRefundID pgtype.Text `json:"stripe_refund_id"`
+	InvoiceType    InvoiceType `json:"invoice_type"`
+}
+
+func (q *Queries) GetCreditNoteByRefund(ctx context.Context, arg GetCreditNoteByRefundParams) (Invoice, error) {
+	row := q.db.QueryRow(ctx, getCreditNoteByRefund, arg.StripeRefundID, arg.InvoiceType)
+	var i Invoice
+	err := row.Scan(
+		&i.ID,
+		&i.InvoiceType,
+		&i.InvoiceNumber,
+		&i.FactureazaID,
+		&i.FactureazaDownloadUrl,
+		&i.SellerCompanyName,
+		&i.SellerCui,
+		&i.SellerRegNumber,
+		&i.SellerAddress,
+		&i.SellerCity,
+		&i.SellerCounty,
+		&i.SellerIsVatPayer,
+		&i.SellerBankName,
+		&i.SellerIban,
+		&i.BuyerName,
+		&i.BuyerCui,
+		&i.BuyerRegNumber,
+		&i.BuyerAddress,
+		&i.BuyerCity,
+		&i.BuyerCounty,
+		&i.BuyerIsVatPayer,
+		&i.BuyerEmail,
+		&i.SubtotalAmount,
+		&i.VatRate,
+		&i.VatAmount,
+		&i.TotalAmount,
+		&i.Currency,
+		&i.BookingID,
+		&i.PaymentTransactionID,
+		&i.CompanyID,
+		&i.ClientUserID,
+		&i.EfacturaStatus,
+		&i.EfacturaIndex,
+		&i.Status,
+		&i.IssuedAt,
+		&i.DueDate,
+		&i.Notes,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.EfacturaMessage,
+		&i.EfacturaDownloadID,
+		&i.EfacturaResponsePath,
+		&i.EfacturaUploadedAt,
+		&i.EfacturaCheckedAt,
+		&i.PdfPath,
+		&i.PipelineStatus,
+		&i.PipelineAttempts,
+		&i.PipelineError,
+		&i.PipelineNextAttemptAt,
+		&i.PipelineUpdatedAt,
+		&i.EmailedAt,
+		&i.CreditedInvoiceID,
+		&i.CreditedInvoiceNumber,
+		&i.CreditedInvoiceDate,
+		&i.StripeRefundID,
+		&i.PayoutID,
+		&i.PeriodFrom,
+		&i.PeriodTo,
+	)
+	return i, err
+}
+
 const getInvoiceAnalytics = `-- name: GetInvoiceAnalytics :one
 
 SELECT
@@ -370,7 +704,8 @@ func (q *Queries) GetInvoiceAnalytics(ctx context.Context, arg GetInvoiceAnalyti
 }
 
 const getInvoiceByBookingAndType = `-- name: GetInvoiceByBookingAndType :one
-SELECT id, invoice_type, invoice_number, factureaza_id, factureaza_download_url, seller_company_name, seller_cui, seller_reg_number, seller_address, seller_city, seller_county, seller_is_vat_payer, seller_bank_name, seller_iban, buyer_name, buyer_cui, buyer_reg_number, buyer_address, buyer_city, buyer_county, buyer_is_vat_payer, buyer_email, subtotal_amount, vat_rate, vat_amount, total_amount, currency, booking_id, payment_transaction_id, company_id, client_user_id, efactura_status, efactura_index, status, issued_at, due_date, notes, created_at, updated_at FROM invoices WHERE booking_id = $1 AND invoice_type = $2 ORDER BY created_at DESC LIMIT 1
+SELECT id, invoice_type, invoice_number, factureaza_id, factureaza_download_url, seller_company_name, seller_cui, seller_reg_number, seller_address, seller_city, seller_county, seller_is_vat_payer, seller_bank_name, seller_iban, buyer_name, buyer_cui, buyer_reg_number, buyer_address, buyer_city, buyer_county, buyer_is_vat_payer, buyer_email, subtotal_amount, vat_rate, vat_amount, total_amount, currency, booking_id, payment_transaction_id, company_id, client_user_id, efactura_status, efactura_index, status, issued_at, due_date, notes, created_at, updated_at, efactura_message, efactura_download_id, efactura_response_path, efactura_uploaded_at, efactura_checked_at, pdf_path, pipeline_status, pipeline_attempts, pipeline_error, pipeline_next_attempt_at, pipeline_updated_at, emailed_at, credited_invoice_id, credited_invoice_number, credited_invoice_date, stripe_refund_id, payout_id, period_from, period_to FROM invoices WHERE booking_id = $1 AND invoice_type = $2 AND credited_invoice_id IS NULL
+ORDER BY created_at DESC LIMIT 1
 `
 
 type GetInvoiceByBookingAndTypeParams struct {
@@ -421,12 +756,31 @@ func (q *Queries) GetInvoiceByBookingAndType(ctx context.Context, arg GetInvoice
 		&i.Notes,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.EfacturaMessage,
+		&i.EfacturaDownloadID,
+		&i.EfacturaResponsePath,
+		&i.EfacturaUploadedAt,
+		&i.EfacturaCheckedAt,
+		&i.PdfPath,
+		&i.PipelineStatus,
+		&i.PipelineAttempts,
+		&i.PipelineError,
+		&i.PipelineNextAttemptAt,
+		&i.PipelineUpdatedAt,
+		&i.EmailedAt,
+		&i.CreditedInvoiceID,
+		&i.CreditedInvoiceNumber,
+		&i.CreditedInvoiceDate,
+		&i.StripeRefundID,
+		&i.PayoutID,
+		&i.PeriodFrom,
+		&i.PeriodTo,
 	)
 	return i, err
 }
 
 const getInvoiceByID = `-- name: GetInvoiceByID :one
-SELECT id, invoice_type, invoice_number, factureaza_id, factureaza_download_url, seller_company_name, seller_cui, seller_reg_number, seller_address, seller_city, seller_county, seller_is_vat_payer, seller_bank_name, seller_iban, buyer_name, buyer_cui, buyer_reg_number, buyer_address, buyer_city, buyer_county, buyer_is_vat_payer, buyer_email, subtotal_amount, vat_rate, vat_amount, total_amount, currency, booking_id, payment_transaction_id, company_id, client_user_id, efactura_status, efactura_index, status, issued_at, due_date, notes, created_at, updated_at FROM invoices WHERE id = $1
+SELECT id, invoice_type, invoice_number, factureaza_id, factureaza_download_url, seller_company_name, seller_cui, seller_reg_number, seller_address, seller_city, seller_county, seller_is_vat_payer, seller_bank_name, seller_iban, buyer_name, buyer_cui, buyer_reg_number, buyer_address, buyer_city, buyer_county, buyer_is_vat_payer, buyer_email, subtotal_amount, vat_rate, vat_amount, total_amount, currency, booking_id, payment_transaction_id, company_id, client_user_id, efactura_status, efactura_index, status, issued_at, due_date, notes, created_at, updated_at, efactura_message, efactura_download_id, efactura_response_path, efactura_uploaded_at, efactura_checked_at, pdf_path, pipeline_status, pipeline_attempts, pipeline_error, pipeline_next_attempt_at, pipeline_updated_at, emailed_at, credited_invoice_id, credited_invoice_number, credited_invoice_date, stripe_refund_id, payout_id, period_from, period_to FROM invoices WHERE id = $1
 `
 
 func (q *Queries) GetInvoiceByID(ctx context.Context, id pgtype.UUID) (Invoice, error) {
@@ -472,6 +826,25 @@ func (q *Queries) GetInvoiceByID(ctx context.Context, id pgtype.UUID) (Invoice,
 		&i.Notes,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.EfacturaMessage,
+		&i.EfacturaDownloadID,
+		&i.EfacturaResponsePath,
+		&i.EfacturaUploadedAt,
+		&i.EfacturaCheckedAt,
+		&i.PdfPath,
+		&i.PipelineStatus,
+		&i.PipelineAttempts,
+		&i.PipelineError,
+		&i.PipelineNextAttemptAt,
+		&i.PipelineUpdatedAt,
+		&i.EmailedAt,
+		&i.CreditedInvoiceID,
+		&i.CreditedInvoiceNumber,
+		&i.CreditedInvoiceDate,
+		&i.StripeRefundID,
+		&i.PayoutID,
+		&i.PeriodFrom,
+		&i.PeriodTo,
 	)
 	return i, err
 }
@@ -552,32 +925,88 @@ func (q *Queries) GetInvoiceCountByType(ctx context.Context, arg GetInvoiceCount
 	return items, nil
 }
 
-const getNextInvoiceNumber = `-- name: GetNextInvoiceNumber :one
-
-UPDATE invoice_sequences SET current_number = current_number + 1
-WHERE company_id IS NOT DISTINCT FROM $1 AND prefix = $2 AND year = $3
-RETURNING current_number
+const getIssuedInvoiceByBooking = `-- name: GetIssuedInvoiceByBooking :one
+SELECT id, invoice_type, invoice_number, factureaza_id, factureaza_download_url, seller_company_name, seller_cui, seller_reg_number, seller_address, seller_city, seller_county, seller_is_vat_payer, seller_bank_name, seller_iban, buyer_name, buyer_cui, buyer_reg_number, buyer_address, buyer_city, buyer_county, buyer_is_vat_payer, buyer_email, subtotal_amount, vat_rate, vat_amount, total_amount, currency, booking_id, payment_transaction_id, company_id, client_user_id, efactura_status, efactura_index, status, issued_at, due_date, notes, created_at, updated_at, efactura_message, efactura_download_id, efactura_response_path, efactura_uploaded_at, efactura_checked_at, pdf_path, pipeline_status, pipeline_attempts, pipeline_error, pipeline_next_attempt_at, pipeline_updated_at, emailed_at, credited_invoice_id, credited_invoice_number, credited_invoice_date, stripe_refund_id, payout_id, period_from, period_to FROM invoices
+WHERE booking_id = $1 AND invoice_type = $2 AND status NOT IN ('cancelled', 'credit_note')
+ORDER BY created_at DESC LIMIT 1
 `
 
-type GetNextInvoiceNumberParams struct {
-	CompanyID pgtype.UUID `json:"company_id"`
-	Prefix    string      `json:"prefix"`
-	Year      int32       `json:"year"`
+type GetIssuedInvoiceByBookingParams struct {
+	BookingID   pgtype.UUID `json:"booking_id"`
+	InvoiceType InvoiceType `json:"invoice_type"`
 }
 
-// ============================================
-// INVOICE SEQUENCES
-// ============================================
-func (q *Queries) GetNextInvoiceNumber(ctx context.Context, arg GetNextInvoiceNumberParams) (int32, error) {
-	row := q.db.QueryRow(ctx, getNextInvoiceNumber, arg.CompanyID, arg.Prefix, arg.Year)
-	var current_number int32
-	err := row.Scan(&current_number)
-	return current_number, err
+// GetIssuedInvoiceByBooking returns the invoice of a booking that credit notes
+// correct, skipping cancelled invoices and earlier credit notes.
+func (q *Queries) GetIssuedInvoiceByBooking(ctx context.Context, arg GetIssuedInvoiceByBookingParams) (Invoice, error) {
+	row := q.db.QueryRow(ctx, getIssuedInvoiceByBooking, arg.BookingID, arg.InvoiceType)
+	var i Invoice
+	err := row.Scan(
+		&i.ID,
+		&i.InvoiceType,
+		&i.InvoiceNumber,
+		&i.FactureazaID,
+		&i.FactureazaDownloadUrl,
+		&i.SellerCompanyName,
+		&i.SellerCui,
+		&i.SellerRegNumber,
+		&i.SellerAddress,
+		&i.SellerCity,
+		&i.SellerCounty,
+		&i.SellerIsVatPayer,
+		&i.SellerBankName,
+		&i.SellerIban,
+		&i.BuyerName,
+		&i.BuyerCui,
+		&i.BuyerRegNumber,
+		&i.BuyerAddress,
+		&i.BuyerCity,
+		&i.BuyerCounty,
+		&i.BuyerIsVatPayer,
+		&i.BuyerEmail,
+		&i.SubtotalAmount,
+		&i.VatRate,
+		&i.VatAmount,
+		&i.TotalAmount,
+		&i.Currency,
+		&i.BookingID,
+		&i.PaymentTransactionID,
+		&i.CompanyID,
+		&i.ClientUserID,
+		&i.EfacturaStatus,
+		&i.EfacturaIndex,
+		&i.Status,
+		&i.IssuedAt,
+		&i.DueDate,
+		&i.Notes,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.EfacturaMessage,
+		&i.EfacturaDownloadID,
+		&i.EfacturaResponsePath,
+		&i.EfacturaUploadedAt,
+		&i.EfacturaCheckedAt,
+		&i.PdfPath,
+		&i.PipelineStatus,
+		&i.PipelineAttempts,
+		&i.PipelineError,
+		&i.PipelineNextAttemptAt,
+		&i.PipelineUpdatedAt,
+		&i.EmailedAt,
+		&i.CreditedInvoiceID,
+		&i.CreditedInvoiceNumber,
+		&i.CreditedInvoiceDate,
+		&i.StripeRefundID,
+		&i.PayoutID,
+		&i.PeriodFrom,
+		&i.PeriodTo,
+	)
+	return i, err
 }
 
 const listAllInvoices = `-- name: ListAllInvoices :many
 
-SELECT id, invoice_type, invoice_number, factureaza_id, factureaza_download_url, seller_company_name, seller_cui, seller_reg_number, seller_address, seller_city, seller_county, seller_is_vat_payer, seller_bank_name, seller_iban, buyer_name, buyer_cui, buyer_reg_number, buyer_address, buyer_city, buyer_county, buyer_is_vat_payer, buyer_email, subtotal_amount, vat_rate, vat_amount, total_amount, currency, booking_id, payment_transaction_id, company_id, client_user_id, efactura_status, efactura_index, status, issued_at, due_date, notes, created_at, updated_at FROM invoices ORDER BY created_at DESC LIMIT $1 OFFSET $2
+SELECT id, invoice_type, invoice_number, factureaza_id, factureaza_download_url, seller_company_name, seller_cui, seller_reg_number, seller_address, seller_city, seller_county, seller_is_vat_payer, seller_bank_name, seller_iban, buyer_name, buyer_cui, buyer_reg_number, buyer_address, buyer_city, buyer_county, buyer_is_vat_payer, buyer_email, subtotal_amount, vat_rate, vat_amount, total_amount, currency, booking_id, payment_transaction_id, company_id, client_user_id, efactura_status, efactura_index, status, issued_at, due_date, notes, created_at, updated_at, efactura_message, efactura_download_id, efactura_response_path, efactura_uploaded_at, efactura_checked_at, pdf_path, pipeline_status, pipeline_attempts, pipeline_error, pipeline_next_attempt_at, pipeline_updated_at, emailed_at, credited_invoice_id, credited_invoice_number, credited_invoice_date, stripe_refund_id, payout_id, period_from, period_to FROM invoices ORDER BY created_at DESC LIMIT $1 OFFSET $2
 `
 
 type ListAllInvoicesParams struct {
@@ -637,6 +1066,25 @@ func (q *Queries) ListAllInvoices(ctx context.Context, arg ListAllInvoicesParams
 			&i.Notes,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.EfacturaMessage,
+			&i.EfacturaDownloadID,
+			&i.EfacturaResponsePath,
+			&i.EfacturaUploadedAt,
+			&i.EfacturaCheckedAt,
+			&i.PdfPath,
+			&i.PipelineStatus,
+			&i.PipelineAttempts,
+			&i.PipelineError,
+			&i.PipelineNextAttemptAt,
+			&i.PipelineUpdatedAt,
+			&i.EmailedAt,
+			&i.CreditedInvoiceID,
+			&i.CreditedInvoiceNumber,
+			&i.CreditedInvoiceDate,
+			&i.StripeRefundID,
+			&i.PayoutID,
+			&i.PeriodFrom,
+			&i.PeriodTo,
 		); err != nil {
 			return nil, err
 		}
@@ -648,13 +1096,172 @@ func (q *Queries) ListAllInvoices(ctx context.Context, arg ListAllInvoicesParams
 	return items, nil
 }
 
-const listInvoiceLineItems = `-- name: ListInvoiceLineItems :many
-SELECT id, invoice_id, description_ro, description_en, quantity, unit_price, vat_rate, vat_amount, line_total, line_total_with_vat, sort_order FROM invoice_line_items WHERE invoice_id = $1 ORDER BY sort_order
+const listCommissionMonth = `-- name: ListCommissionMonth :many
+WITH payments AS (
+  SELECT e.id, e.kind, e.booking_id, e.company_id FROM ledger_entries e
+  WHERE e.kind = 'payment' AND e.company_id IS NOT NULL
+    AND e.occurred_at >= $1 AND e.occurred_at < $2
+    AND NOT EXISTS (
+      SELECT 1 FROM payout_line_items pli
+      JOIN invoices i ON i.payout_id = pli.payout_id
+      WHERE pli.booking_id = e.booking_id AND i.invoice_type = 'platform_commission'
+        AND i.status NOT IN ('cancelled', 'credit_note')
+    )
+), refunds AS (
+  SELECT e.id, e.kind, e.booking_id, e.company_id FROM ledger_entries e
+  WHERE e.kind = 'refund' AND e.booking_id IN (SELECT booking_id FROM payments)
+    AND NOT EXISTS (
+      SELECT 1 FROM invoices i
+      WHERE i.invoice_type = 'platform_commission' AND i.stripe_refund_id IS NOT NULL
+        AND e.reference = 'refund:' || i.stripe_refund_id
+    )
+)
+SELECT x.company_id,
+       COUNT(DISTINCT x.booking_id) FILTER (WHERE x.kind = 'payment')::int AS booking_count,
+       COALESCE(SUM(-l.amount) FILTER (WHERE x.kind = 'payment'), 0)::bigint AS commission,
+       COALESCE(SUM(l.amount) FILTER (WHERE x.kind = 'refund'), 0)::bigint AS refunded
+FROM (SELECT * FROM payments UNION ALL SELECT * FROM refunds) x
+JOIN ledger_lines l ON l.entry_id = x.id
+JOIN ledger_accounts a ON a.id = l.account_id AND a.account_type = 'platform_fees'
+GROUP BY x.company_id
+ORDER BY x.company_id
 `
 
-func (q *Queries) ListInvoiceLineItems(ctx context.Context, invoiceID pgtype.UUID) ([]InvoiceLineItem, error) {
-	rows, err := q.db.Query(ctx, listInvoiceLineItems, invoiceID)
-	if err != nil {
+type ListCommissionMonthParams struct {
+	OccurredAt   pgtype.Timestamptz `json:"occurred_at"`
+	OccurredAt_2 pgtype.Timestamptz `json:"occurred_at_2"`
+}
+
+type ListCommissionMonthRow struct {
+	CompanyID    pgtype.UUID `json:"company_id"`
+	BookingCount int32       `json:"booking_count"`
+	Commission   int64       `json:"commission"`
+	Refunded     int64       `json:"refunded"`
+}
+
+// ListCommissionMonth sums, per company, the platform fees of the payments
+// received between two instants and the fees refunded since on those
+// bookings, leaving out refunds already credited on a commission invoice and
+// bookings invoiced per payout.
+func (q *Queries) ListCommissionMonth(ctx context.Context, arg ListCommissionMonthParams) ([]ListCommissionMonthRow, error) {
+	rows, err := q.db.Query(ctx, listCommissionMonth, arg.OccurredAt, arg.OccurredAt_2)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListCommissionMonthRow
+	for rows.Next() {
+		var i ListCommissionMonthRow
+		if err := rows.Scan(
+			&i.CompanyID,
+			&i.BookingCount,
+			&i.Commission,
+			&i.Refunded,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDueInvoicePipelines = `-- name: ListDueInvoicePipelines :many
+SELECT id, invoice_type, invoice_number, factureaza_id, factureaza_download_url, seller_company_name, seller_cui, seller_reg_number, seller_address, seller_city, seller_county, seller_is_vat_payer, seller_bank_name, seller_iban, buyer_name, buyer_cui, buyer_reg_number, buyer_address, buyer_city, buyer_county, buyer_is_vat_payer, buyer_email, subtotal_amount, vat_rate, vat_amount, total_amount, currency, booking_id, payment_transaction_id, company_id, client_user_id, efactura_status, efactura_index, status, issued_at, due_date, notes, created_at, updated_at, efactura_message, efactura_download_id, efactura_response_path, efactura_uploaded_at, efactura_checked_at, pdf_path, pipeline_status, pipeline_attempts, pipeline_error, pipeline_next_attempt_at, pipeline_updated_at, emailed_at, credited_invoice_id, credited_invoice_number, credited_invoice_date, stripe_refund_id, payout_id, period_from, period_to FROM invoices
+WHERE (pipeline_status IN ('pending', 'failed') AND pipeline_next_attempt_at <= NOW())
+   OR (pipeline_status = 'processing' AND pipeline_updated_at < NOW() - INTERVAL '10 minutes')
+ORDER BY created_at
+LIMIT $1
+`
+
+func (q *Queries) ListDueInvoicePipelines(ctx context.Context, limit int32) ([]Invoice, error) {
+	rows, err := q.db.Query(ctx, listDueInvoicePipelines, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Invoice
+	for rows.Next() {
+		var i Invoice
+		if err := rows.Scan(
+			&i.ID,
+			&i.InvoiceType,
+			&i.InvoiceNumber,
+			&i.FactureazaID,
+			&i.FactureazaDownloadUrl,
+			&i.SellerCompanyName,
+			&i.SellerCui,
+			&i.SellerRegNumber,
+			&i.SellerAddress,
+			&i.SellerCity,
+			&i.SellerCounty,
+			&i.SellerIsVatPayer,
+			&i.SellerBankName,
+			&i.SellerIban,
+			&i.BuyerName,
+			&i.BuyerCui,
+			&i.BuyerRegNumber,
+			&i.BuyerAddress,
+			&i.BuyerCity,
+			&i.BuyerCounty,
+			&i.BuyerIsVatPayer,
+			&i.BuyerEmail,
+			&i.SubtotalAmount,
+			&i.VatRate,
+			&i.VatAmount,
+			&i.TotalAmount,
+			&i.Currency,
+			&i.BookingID,
+			&i.PaymentTransactionID,
+			&i.CompanyID,
+			&i.ClientUserID,
+			&i.EfacturaStatus,
+			&i.EfacturaIndex,
+			&i.Status,
+			&i.IssuedAt,
+			&i.DueDate,
+			&i.Notes,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.EfacturaMessage,
+			&i.EfacturaDownloadID,
+			&i.EfacturaResponsePath,
+			&i.EfacturaUploadedAt,
+			&i.EfacturaCheckedAt,
+			&i.PdfPath,
+			&i.PipelineStatus,
+			&i.PipelineAttempts,
+			&i.PipelineError,
+			&i.PipelineNextAttemptAt,
+			&i.PipelineUpdatedAt,
+			&i.EmailedAt,
+			&i.CreditedInvoiceID,
+			&i.CreditedInvoiceNumber,
+			&i.CreditedInvoiceDate,
+			&i.StripeRefundID,
+			&i.PayoutID,
+			&i.PeriodFrom,
+			&i.PeriodTo,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listInvoiceLineItems = `-- name: ListInvoiceLineItems :many
+SELECT id, invoice_id, description_ro, description_en, quantity, unit_price, vat_rate, vat_amount, line_total, line_total_with_vat, sort_order FROM invoice_line_items WHERE invoice_id = $1 ORDER BY sort_order
+`
+
+func (q *Queries) ListInvoiceLineItems(ctx context.Context, invoiceID pgtype.UUID) ([]InvoiceLineItem, error) {
+	rows, err := q.db.Query(ctx, listInvoiceLineItems, invoiceID)
+	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
@@ -686,7 +1293,7 @@ func (q *Queries) ListInvoiceLineItems(ctx context.Context, invoiceID pgtype.UUI
 
 const listInvoicesByClient = `-- name: ListInvoicesByClient :many
 
-SELECT id, invoice_type, invoice_number, factureaza_id, factureaza_download_url, seller_company_name, seller_cui, seller_reg_number, seller_address, seller_city, seller_county, seller_is_vat_payer, seller_bank_name, seller_iban, buyer_name, buyer_cui, buyer_reg_number, buyer_address, buyer_city, buyer_county, buyer_is_vat_payer, buyer_email, subtotal_amount, vat_rate, vat_amount, total_amount, currency, booking_id, payment_transaction_id, company_id, client_user_id, efactura_status, efactura_index, status, issued_at, due_date, notes, created_at, updated_at FROM invoices WHERE client_user_id = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3
+SELECT id, invoice_type, invoice_number, factureaza_id, factureaza_download_url, seller_company_name, seller_cui, seller_reg_number, seller_address, seller_city, seller_county, seller_is_vat_payer, seller_bank_name, seller_iban, buyer_name, buyer_cui, buyer_reg_number, buyer_address, buyer_city, buyer_county, buyer_is_vat_payer, buyer_email, subtotal_amount, vat_rate, vat_amount, total_amount, currency, booking_id, payment_transaction_id, company_id, client_user_id, efactura_status, efactura_index, status, issued_at, due_date, notes, created_at, updated_at, efactura_message, efactura_download_id, efactura_response_path, efactura_uploaded_at, efactura_checked_at, pdf_path, pipeline_status, pipeline_attempts, pipeline_error, pipeline_next_attempt_at, pipeline_updated_at, emailed_at, credited_invoice_id, credited_invoice_number, credited_invoice_date, stripe_refund_id, payout_id, period_from, period_to FROM invoices WHERE client_user_id = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3
 `
 
 type ListInvoicesByClientParams struct {
@@ -747,6 +1354,25 @@ func (q *Queries) ListInvoicesByClient(ctx context.Context, arg ListInvoicesByCl
 			&i.Notes,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.EfacturaMessage,
+			&i.EfacturaDownloadID,
+			&i.EfacturaResponsePath,
+			&i.EfacturaUploadedAt,
+			&i.EfacturaCheckedAt,
+			&i.PdfPath,
+			&i.PipelineStatus,
+			&i.PipelineAttempts,
+			&i.PipelineError,
+			&i.PipelineNextAttemptAt,
+			&i.PipelineUpdatedAt,
+			&i.EmailedAt,
+			&i.CreditedInvoiceID,
+			&i.CreditedInvoiceNumber,
+			&i.CreditedInvoiceDate,
+			&i.StripeRefundID,
+			&i.PayoutID,
+			&i.PeriodFrom,
+			&i.PeriodTo,
 		); err != nil {
 			return nil, err
 		}
@@ -760,7 +1386,7 @@ func (q *Queries) ListInvoicesByClient(ctx context.Context, arg ListInvoicesByCl
 
 const listInvoicesByCompany = `-- name: ListInvoicesByCompany :many
 
-SELECT id, invoice_type, invoice_number, factureaza_id, factureaza_download_url, seller_company_name, seller_cui, seller_reg_number, seller_address, seller_city, seller_county, seller_is_vat_payer, seller_bank_name, seller_iban, buyer_name, buyer_cui, buyer_reg_number, buyer_address, buyer_city, buyer_county, buyer_is_vat_payer, buyer_email, subtotal_amount, vat_rate, vat_amount, total_amount, currency, booking_id, payment_transaction_id, company_id, client_user_id, efactura_status, efactura_index, status, issued_at, due_date, notes, created_at, updated_at FROM invoices WHERE company_id = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3
+SELECT id, invoice_type, invoice_number, factureaza_id, factureaza_download_url, seller_company_name, seller_cui, seller_reg_number, seller_address, seller_city, seller_county, seller_is_vat_payer, seller_bank_name, seller_iban, buyer_name, buyer_cui, buyer_reg_number, buyer_address, buyer_city, buyer_county, buyer_is_vat_payer, buyer_email, subtotal_amount, vat_rate, vat_amount, total_amount, currency, booking_id, payment_transaction_id, company_id, client_user_id, efactura_status, efactura_index, status, issued_at, due_date, notes, created_at, updated_at, efactura_message, efactura_download_id, efactura_response_path, efactura_uploaded_at, efactura_checked_at, pdf_path, pipeline_status, pipeline_attempts, pipeline_error, pipeline_next_attempt_at, pipeline_updated_at, emailed_at, credited_invoice_id, credited_invoice_number, credited_invoice_date, stripe_refund_id, payout_id, period_from, period_to FROM invoices WHERE company_id = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3
 `
 
 type ListInvoicesByCompanyParams struct {
@@ -821,6 +1447,25 @@ func (q *Queries) ListInvoicesByCompany(ctx context.Context, arg ListInvoicesByC
 			&i.Notes,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.EfacturaMessage,
+			&i.EfacturaDownloadID,
+			&i.EfacturaResponsePath,
+			&i.EfacturaUploadedAt,
+			&i.EfacturaCheckedAt,
+			&i.PdfPath,
+			&i.PipelineStatus,
+			&i.PipelineAttempts,
+			&i.PipelineError,
+			&i.PipelineNextAttemptAt,
+			&i.PipelineUpdatedAt,
+			&i.EmailedAt,
+			&i.CreditedInvoiceID,
+			&i.CreditedInvoiceNumber,
+			&i.CreditedInvoiceDate,
+			&i.StripeRefundID,
+			&i.PayoutID,
+			&i.PeriodFrom,
+			&i.PeriodTo,
 		); err != nil {
 			return nil, err
 		}
@@ -833,7 +1478,7 @@ func (q *Queries) ListInvoicesByCompany(ctx context.Context, arg ListInvoicesByC
 }
 
 const listInvoicesByCompanyAndStatus = `-- name: ListInvoicesByCompanyAndStatus :many
-SELECT id, invoice_type, invoice_number, factureaza_id, factureaza_download_url, seller_company_name, seller_cui, seller_reg_number, seller_address, seller_city, seller_county, seller_is_vat_payer, seller_bank_name, seller_iban, buyer_name, buyer_cui, buyer_reg_number, buyer_address, buyer_city, buyer_county, buyer_is_vat_payer, buyer_email, subtotal_amount, vat_rate, vat_amount, total_amount, currency, booking_id, payment_transaction_id, company_id, client_user_id, efactura_status, efactura_index, status, issued_at, due_date, notes, created_at, updated_at FROM invoices WHERE company_id = $1 AND status = $2 ORDER BY created_at DESC LIMIT $3 OFFSET $4
+SELECT id, invoice_type, invoice_number, factureaza_id, factureaza_download_url, seller_company_name, seller_cui, seller_reg_number, seller_address, seller_city, seller_county, seller_is_vat_payer, seller_bank_name, seller_iban, buyer_name, buyer_cui, buyer_reg_number, buyer_address, buyer_city, buyer_county, buyer_is_vat_payer, buyer_email, subtotal_amount, vat_rate, vat_amount, total_amount, currency, booking_id, payment_transaction_id, company_id, client_user_id, efactura_status, efactura_index, status, issued_at, due_date, notes, created_at, updated_at, efactura_message, efactura_download_id, efactura_response_path, efactura_uploaded_at, efactura_checked_at, pdf_path, pipeline_status, pipeline_attempts, pipeline_error, pipeline_next_attempt_at, pipeline_updated_at, emailed_at, credited_invoice_id, credited_invoice_number, credited_invoice_date, stripe_refund_id, payout_id, period_from, period_to FROM invoices WHERE company_id = $1 AND status = $2 ORDER BY created_at DESC LIMIT $3 OFFSET $4
 `
 
 type ListInvoicesByCompanyAndStatusParams struct {
@@ -897,6 +1542,25 @@ func (q *Queries) ListInvoicesByCompanyAndStatus(ctx context.Context, arg ListIn
 			&i.Notes,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.EfacturaMessage,
+			&i.EfacturaDownloadID,
+			&i.EfacturaResponsePath,
+			&i.EfacturaUploadedAt,
+			&i.EfacturaCheckedAt,
+			&i.PdfPath,
+			&i.PipelineStatus,
+			&i.PipelineAttempts,
+			&i.PipelineError,
+			&i.PipelineNextAttemptAt,
+			&i.PipelineUpdatedAt,
+			&i.EmailedAt,
+			&i.CreditedInvoiceID,
+			&i.CreditedInvoiceNumber,
+			&i.CreditedInvoiceDate,
+			&i.StripeRefundID,
+			&i.PayoutID,
+			&i.PeriodFrom,
+			&i.PeriodTo,
 		); err != nil {
 			return nil, err
 		}
@@ -909,7 +1573,7 @@ func (q *Queries) ListInvoicesByCompanyAndStatus(ctx context.Context, arg ListIn
 }
 
 const listInvoicesByCompanyID = `-- name: ListInvoicesByCompanyID :many
-SELECT id, invoice_type, invoice_number, factureaza_id, factureaza_download_url, seller_company_name, seller_cui, seller_reg_number, seller_address, seller_city, seller_county, seller_is_vat_payer, seller_bank_name, seller_iban, buyer_name, buyer_cui, buyer_reg_number, buyer_address, buyer_city, buyer_county, buyer_is_vat_payer, buyer_email, subtotal_amount, vat_rate, vat_amount, total_amount, currency, booking_id, payment_transaction_id, company_id, client_user_id, efactura_status, efactura_index, status, issued_at, due_date, notes, created_at, updated_at FROM invoices WHERE company_id = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3
+SELECT id, invoice_type, invoice_number, factureaza_id, factureaza_download_url, seller_company_name, seller_cui, seller_reg_number, seller_address, seller_city, seller_county, seller_is_vat_payer, seller_bank_name, seller_iban, buyer_name, buyer_cui, buyer_reg_number, buyer_address, buyer_city, buyer_county, buyer_is_vat_payer, buyer_email, subtotal_amount, vat_rate, vat_amount, total_amount, currency, booking_id, payment_transaction_id, company_id, client_user_id, efactura_status, efactura_index, status, issued_at, due_date, notes, created_at, updated_at, efactura_message, efactura_download_id, efactura_response_path, efactura_uploaded_at, efactura_checked_at, pdf_path, pipeline_status, pipeline_attempts, pipeline_error, pipeline_next_attempt_at, pipeline_updated_at, emailed_at, credited_invoice_id, credited_invoice_number, credited_invoice_date, stripe_refund_id, payout_id, period_from, period_to FROM invoices WHERE company_id = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3
 `
 
 type ListInvoicesByCompanyIDParams struct {
@@ -967,6 +1631,25 @@ func (q *Queries) ListInvoicesByCompanyID(ctx context.Context, arg ListInvoicesB
 			&i.Notes,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.EfacturaMessage,
+			&i.EfacturaDownloadID,
+			&i.EfacturaResponsePath,
+			&i.EfacturaUploadedAt,
+			&i.EfacturaCheckedAt,
+			&i.PdfPath,
+			&i.PipelineStatus,
+			&i.PipelineAttempts,
+			&i.PipelineError,
+			&i.PipelineNextAttemptAt,
+			&i.PipelineUpdatedAt,
+			&i.EmailedAt,
+			&i.CreditedInvoiceID,
+			&i.CreditedInvoiceNumber,
+			&i.CreditedInvoiceDate,
+			&i.StripeRefundID,
+			&i.PayoutID,
+			&i.PeriodFrom,
+			&i.PeriodTo,
 		); err != nil {
 			return nil, err
 		}
@@ -979,7 +1662,7 @@ func (q *Queries) ListInvoicesByCompanyID(ctx context.Context, arg ListInvoicesB
 }
 
 const listInvoicesByType = `-- name: ListInvoicesByType :many
-SELECT id, invoice_type, invoice_number, factureaza_id, factureaza_download_url, seller_company_name, seller_cui, seller_reg_number, seller_address, seller_city, seller_county, seller_is_vat_payer, seller_bank_name, seller_iban, buyer_name, buyer_cui, buyer_reg_number, buyer_address, buyer_city, buyer_county, buyer_is_vat_payer, buyer_email, subtotal_amount, vat_rate, vat_amount, total_amount, currency, booking_id, payment_transaction_id, company_id, client_user_id, efactura_status, efactura_index, status, issued_at, due_date, notes, created_at, updated_at FROM invoices WHERE invoice_type = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3
+SELECT id, invoice_type, invoice_number, factureaza_id, factureaza_download_url, seller_company_name, seller_cui, seller_reg_number, seller_address, seller_city, seller_county, seller_is_vat_payer, seller_bank_name, seller_iban, buyer_name, buyer_cui, buyer_reg_number, buyer_address, buyer_city, buyer_county, buyer_is_vat_payer, buyer_email, subtotal_amount, vat_rate, vat_amount, total_amount, currency, booking_id, payment_transaction_id, company_id, client_user_id, efactura_status, efactura_index, status, issued_at, due_date, notes, created_at, updated_at, efactura_message, efactura_download_id, efactura_response_path, efactura_uploaded_at, efactura_checked_at, pdf_path, pipeline_status, pipeline_attempts, pipeline_error, pipeline_next_attempt_at, pipeline_updated_at, emailed_at, credited_invoice_id, credited_invoice_number, credited_invoice_date, stripe_refund_id, payout_id, period_from, period_to FROM invoices WHERE invoice_type = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3
 `
 
 type ListInvoicesByTypeParams struct {
@@ -1037,6 +1720,25 @@ func (q *Queries) ListInvoicesByType(ctx context.Context, arg ListInvoicesByType
 			&i.Notes,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.EfacturaMessage,
+			&i.EfacturaDownloadID,
+			&i.EfacturaResponsePath,
+			&i.EfacturaUploadedAt,
+			&i.EfacturaCheckedAt,
+			&i.PdfPath,
+			&i.PipelineStatus,
+			&i.PipelineAttempts,
+			&i.PipelineError,
+			&i.PipelineNextAttemptAt,
+			&i.PipelineUpdatedAt,
+			&i.EmailedAt,
+			&i.CreditedInvoiceID,
+			&i.CreditedInvoiceNumber,
+			&i.CreditedInvoiceDate,
+			&i.StripeRefundID,
+			&i.PayoutID,
+			&i.PeriodFrom,
+			&i.PeriodTo,
 		); err != nil {
 			return nil, err
 		}
@@ -1049,7 +1751,7 @@ func (q *Queries) ListInvoicesByType(ctx context.Context, arg ListInvoicesByType
 }
 
 const listInvoicesByTypeAndStatus = `-- name: ListInvoicesByTypeAndStatus :many
-SELECT id, invoice_type, invoice_number, factureaza_id, factureaza_download_url, seller_company_name, seller_cui, seller_reg_number, seller_address, seller_city, seller_county, seller_is_vat_payer, seller_bank_name, seller_iban, buyer_name, buyer_cui, buyer_reg_number, buyer_address, buyer_city, buyer_county, buyer_is_vat_payer, buyer_email, subtotal_amount, vat_rate, vat_amount, total_amount, currency, booking_id, payment_transaction_id, company_id, client_user_id, efactura_status, efactura_index, status, issued_at, due_date, notes, created_at, updated_at FROM invoices WHERE invoice_type = $1 AND status = $2 ORDER BY created_at DESC LIMIT $3 OFFSET $4
+SELECT id, invoice_type, invoice_number, factureaza_id, factureaza_download_url, seller_company_name, seller_cui, seller_reg_number, seller_address, seller_city, seller_county, seller_is_vat_payer, seller_bank_name, seller_iban, buyer_name, buyer_cui, buyer_reg_number, buyer_address, buyer_city, buyer_county, buyer_is_vat_payer, buyer_email, subtotal_amount, vat_rate, vat_amount, total_amount, currency, booking_id, payment_transaction_id, company_id, client_user_id, efactura_status, efactura_index, status, issued_at, due_date, notes, created_at, updated_at, efactura_message, efactura_download_id, efactura_response_path, efactura_uploaded_at, efactura_checked_at, pdf_path, pipeline_status, pipeline_attempts, pipeline_error, pipeline_next_attempt_at, pipeline_updated_at, emailed_at, credited_invoice_id, credited_invoice_number, credited_invoice_date, stripe_refund_id, payout_id, period_from, period_to FROM invoices WHERE invoice_type = $1 AND status = $2 ORDER BY created_at DESC LIMIT $3 OFFSET $4
 `
 
 type ListInvoicesByTypeAndStatusParams struct {
@@ -1113,6 +1815,25 @@ func (q *Queries) ListInvoicesByTypeAndStatus(ctx context.Context, arg ListInvoi
 			&i.Notes,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.EfacturaMessage,
+			&i.EfacturaDownloadID,
+			&i.EfacturaResponsePath,
+			&i.EfacturaUploadedAt,
+			&i.EfacturaCheckedAt,
+			&i.PdfPath,
+			&i.PipelineStatus,
+			&i.PipelineAttempts,
+			&i.PipelineError,
+			&i.PipelineNextAttemptAt,
+			&i.PipelineUpdatedAt,
+			&i.EmailedAt,
+			&i.CreditedInvoiceID,
+			&i.CreditedInvoiceNumber,
+			&i.CreditedInvoiceDate,
+			&i.StripeRefundID,
+			&i.PayoutID,
+			&i.PeriodFrom,
+			&i.PeriodTo,
 		); err != nil {
 			return nil, err
 		}
@@ -1124,6 +1845,321 @@ func (q *Queries) ListInvoicesByTypeAndStatus(ctx context.Context, arg ListInvoi
 	return items, nil
 }
 
+const listInvoicesForExport = `-- name: ListInvoicesForExport :many
+SELECT id, invoice_type, invoice_number, factureaza_id, factureaza_download_url, seller_company_name, seller_cui, seller_reg_number, seller_address, seller_city, seller_county, seller_is_vat_payer, seller_bank_name, seller_iban, buyer_name, buyer_cui, buyer_reg_number, buyer_address, buyer_city, buyer_county, buyer_is_vat_payer, buyer_email, subtotal_amount, vat_rate, vat_amount, total_amount, currency, booking_id, payment_transaction_id, company_id, client_user_id, efactura_status, efactura_index, status, issued_at, due_date, notes, created_at, updated_at, efactura_message, efactura_download_id, efactura_response_path, efactura_uploaded_at, efactura_checked_at, pdf_path, pipeline_status, pipeline_attempts, pipeline_error, pipeline_next_attempt_at, pipeline_updated_at, emailed_at, credited_invoice_id, credited_invoice_number, credited_invoice_date, stripe_refund_id, payout_id, period_from, period_to FROM invoices
+WHERE invoice_type = $1
+  AND ($2::uuid IS NULL OR company_id = $2::uuid)
+  AND status NOT IN ('draft', 'cancelled')
+  AND (COALESCE(issued_at, created_at) AT TIME ZONE 'Europe/Bucharest')::date BETWEEN $3 AND $4
+ORDER BY COALESCE(issued_at, created_at), invoice_number
+`
+
+type ListInvoicesForExportParams struct {
+	InvoiceType InvoiceType `json:"invoice_type"`
+	CompanyID   pgtype.UUID `json:"company_id"`
+	PeriodFrom  pgtype.Date `json:"period_from"`
+	PeriodTo    pgtype.Date `json:"period_to"`
+}
+
+// ListInvoicesForExport returns the invoices and credit notes of a type issued
+// in a period, Romanian time, optionally for one company. Drafts and
+// cancelled invoices are left out.
+func (q *Queries) ListInvoicesForExport(ctx context.Context, arg ListInvoicesForExportParams) ([]Invoice, error) {
+	rows, err := q.db.Query(ctx, listInvoicesForExport,
+		arg.InvoiceType,
+		arg.CompanyID,
+		arg.PeriodFrom,
+		arg.PeriodTo,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Invoice
+	for rows.Next() {
+		var i Invoice
+		if err := rows.Scan(
+			&i.ID,
+			&i.InvoiceType,
+			&i.InvoiceNumber,
+			&i.FactureazaID,
+			&i.FactureazaDownloadUrl,
+			&i.SellerCompanyName,
+			&i.SellerCui,
+			&i.SellerRegNumber,
+			&i.SellerAddress,
+			&i.SellerCity,
+			&i.SellerCounty,
+			&i.SellerIsVatPayer,
+			&i.SellerBankName,
+			&i.SellerIban,
+			&i.BuyerName,
+			&i.BuyerCui,
+			&i.BuyerRegNumber,
+			&i.BuyerAddress,
+			&i.BuyerCity,
+			&i.BuyerCounty,
+			&i.BuyerIsVatPayer,
+			&i.BuyerEmail,
+			&i.SubtotalAmount,
+			&i.VatRate,
+			&i.VatAmount,
+			&i.TotalAmount,
+			&i.Currency,
+			&i.BookingID,
+			&i.PaymentTransactionID,
+			&i.CompanyID,
+			&i.ClientUserID,
+			&i.EfacturaStatus,
+			&i.EfacturaIndex,
+			&i.Status,
+			&i.IssuedAt,
+			&i.DueDate,
+			&i.Notes,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.EfacturaMessage,
+			&i.EfacturaDownloadID,
+			&i.EfacturaResponsePath,
+			&i.EfacturaUploadedAt,
+			&i.EfacturaCheckedAt,
+			&i.PdfPath,
+			&i.PipelineStatus,
+			&i.PipelineAttempts,
+			&i.PipelineError,
+			&i.PipelineNextAttemptAt,
+			&i.PipelineUpdatedAt,
+			&i.EmailedAt,
+			&i.CreditedInvoiceID,
+			&i.CreditedInvoiceNumber,
+			&i.CreditedInvoiceDate,
+			&i.StripeRefundID,
+			&i.PayoutID,
+			&i.PeriodFrom,
+			&i.PeriodTo,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPendingEFacturaInvoices = `-- name: ListPendingEFacturaInvoices :many
+SELECT id, invoice_type, invoice_number, factureaza_id, factureaza_download_url, seller_company_name, seller_cui, seller_reg_number, seller_address, seller_city, seller_county, seller_is_vat_payer, seller_bank_name, seller_iban, buyer_name, buyer_cui, buyer_reg_number, buyer_address, buyer_city, buyer_county, buyer_is_vat_payer, buyer_email, subtotal_amount, vat_rate, vat_amount, total_amount, currency, booking_id, payment_transaction_id, company_id, client_user_id, efactura_status, efactura_index, status, issued_at, due_date, notes, created_at, updated_at, efactura_message, efactura_download_id, efactura_response_path, efactura_uploaded_at, efactura_checked_at, pdf_path, pipeline_status, pipeline_attempts, pipeline_error, pipeline_next_attempt_at, pipeline_updated_at, emailed_at, credited_invoice_id, credited_invoice_number, credited_invoice_date, stripe_refund_id, payout_id, period_from, period_to FROM invoices
+WHERE efactura_status IN ('uploaded', 'processing')
+ORDER BY efactura_uploaded_at
+LIMIT $1
+`
+
+// ListPendingEFacturaInvoices returns the invoices uploaded to the ANAF SPV
+// that are still waiting for a result, oldest first.
+func (q *Queries) ListPendingEFacturaInvoices(ctx context.Context, limit int32) ([]Invoice, error) {
+	rows, err := q.db.Query(ctx, listPendingEFacturaInvoices, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Invoice
+	for rows.Next() {
+		var i Invoice
+		if err := rows.Scan(
+			&i.ID,
+			&i.InvoiceType,
+			&i.InvoiceNumber,
+			&i.FactureazaID,
+			&i.FactureazaDownloadUrl,
+			&i.SellerCompanyName,
+			&i.SellerCui,
+			&i.SellerRegNumber,
+			&i.SellerAddress,
+			&i.SellerCity,
+			&i.SellerCounty,
+			&i.SellerIsVatPayer,
+			&i.SellerBankName,
+			&i.SellerIban,
+			&i.BuyerName,
+			&i.BuyerCui,
+			&i.BuyerRegNumber,
+			&i.BuyerAddress,
+			&i.BuyerCity,
+			&i.BuyerCounty,
+			&i.BuyerIsVatPayer,
+			&i.BuyerEmail,
+			&i.SubtotalAmount,
+			&i.VatRate,
+			&i.VatAmount,
+			&i.TotalAmount,
+			&i.Currency,
+			&i.BookingID,
+			&i.PaymentTransactionID,
+			&i.CompanyID,
+			&i.ClientUserID,
+			&i.EfacturaStatus,
+			&i.EfacturaIndex,
+			&i.Status,
+			&i.IssuedAt,
+			&i.DueDate,
+			&i.Notes,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.EfacturaMessage,
+			&i.EfacturaDownloadID,
+			&i.EfacturaResponsePath,
+			&i.EfacturaUploadedAt,
+			&i.EfacturaCheckedAt,
+			&i.PdfPath,
+			&i.PipelineStatus,
+			&i.PipelineAttempts,
+			&i.PipelineError,
+			&i.PipelineNextAttemptAt,
+			&i.PipelineUpdatedAt,
+			&i.EmailedAt,
+			&i.CreditedInvoiceID,
+			&i.CreditedInvoiceNumber,
+			&i.CreditedInvoiceDate,
+			&i.StripeRefundID,
+			&i.PayoutID,
+			&i.PeriodFrom,
+			&i.PeriodTo,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markInvoiceEFacturaUploaded = `-- name: MarkInvoiceEFacturaUploaded :exec
+UPDATE invoices SET
+  efactura_status = 'uploaded', efactura_index = $2, efactura_message = NULL,
+  efactura_download_id = NULL, efactura_response_path = NULL,
+  efactura_uploaded_at = NOW(), efactura_checked_at = NULL, updated_at = NOW()
+WHERE id = $1
+`
+
+type MarkInvoiceEFacturaUploadedParams struct {
+	ID            pgtype.UUID `json:"id"`
+	EfacturaIndex pgtype.Text `json:"efactura_index"`
+}
+
+// MarkInvoiceEFacturaUploaded records an upload to the ANAF SPV and resets the
+// result of any earlier upload.
+func (q *Queries) MarkInvoiceEFacturaUploaded(ctx context.Context, arg MarkInvoiceEFacturaUploadedParams) error {
+	_, err := q.db.Exec(ctx, markInvoiceEFacturaUploaded, arg.ID, arg.EfacturaIndex)
+	return err
+}
+
+const markInvoiceEmailed = `-- name: MarkInvoiceEmailed :exec
+UPDATE invoices SET emailed_at = NOW(), updated_at = NOW() WHERE id = $1
+`
+
+func (q *Queries) MarkInvoiceEmailed(ctx context.Context, id pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, markInvoiceEmailed, id)
+	return err
+}
+
+const markInvoicePipelineDone = `-- name: MarkInvoicePipelineDone :exec
+UPDATE invoices
+SET pipeline_status = 'done', pipeline_error = NULL, pipeline_next_attempt_at = NULL, pipeline_updated_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) MarkInvoicePipelineDone(ctx context.Context, id pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, markInvoicePipelineDone, id)
+	return err
+}
+
+const markInvoicePipelineFailed = `-- name: MarkInvoicePipelineFailed :exec
+UPDATE invoices
+SET pipeline_status = 'failed', pipeline_error = $2, pipeline_next_attempt_at = $3, pipeline_updated_at = NOW()
+WHERE id = $1
+`
+
+type MarkInvoicePipelineFailedParams struct {
+	ID                    pgtype.UUID        `json:"id"`
+	PipelineError         pgtype.Text        `json:"pipeline_error"`
+	PipelineNextAttemptAt pgtype.Timestamptz `json:"pipeline_next_attempt_at"`
+}
+
+func (q *Queries) MarkInvoicePipelineFailed(ctx context.Context, arg MarkInvoicePipelineFailedParams) error {
+	_, err := q.db.Exec(ctx, markInvoicePipelineFailed, arg.ID, arg.PipelineError, arg.PipelineNextAttemptAt)
+	return err
+}
+
+const setInvoiceEFacturaResult = `-- name: SetInvoiceEFacturaResult :exec
+UPDATE invoices SET
+  efactura_status = $2, efactura_message = $3, efactura_download_id = $4,
+  efactura_response_path = $5, efactura_checked_at = NOW(), updated_at = NOW()
+WHERE id = $1
+`
+
+type SetInvoiceEFacturaResultParams struct {
+	ID                   pgtype.UUID `json:"id"`
+	EfacturaStatus       pgtype.Text `json:"efactura_status"`
+	EfacturaMessage      pgtype.Text `json:"efactura_message"`
+	EfacturaDownloadID   pgtype.Text `json:"efactura_download_id"`
+	EfacturaResponsePath pgtype.Text `json:"efactura_response_path"`
+}
+
+func (q *Queries) SetInvoiceEFacturaResult(ctx context.Context, arg SetInvoiceEFacturaResultParams) error {
+	_, err := q.db.Exec(ctx, setInvoiceEFacturaResult,
+		arg.ID,
+		arg.EfacturaStatus,
+		arg.EfacturaMessage,
+		arg.EfacturaDownloadID,
+		arg.EfacturaResponsePath,
+	)
+	return err
+}
+
+const setInvoicePDFPath = `-- name: SetInvoicePDFPath :exec
+UPDATE invoices SET pdf_path = $2, updated_at = NOW() WHERE id = $1
+`
+
+type SetInvoicePDFPathParams struct {
+	ID      pgtype.UUID `json:"id"`
+	PdfPath pgtype.Text `json:"pdf_path"`
+}
+
+// SetInvoicePDFPath records where the rendered PDF of an invoice is stored.
+func (q *Queries) SetInvoicePDFPath(ctx context.Context, arg SetInvoicePDFPathParams) error {
+	_, err := q.db.Exec(ctx, setInvoicePDFPath, arg.ID, arg.PdfPath)
+	return err
+}
+
+const startInvoicePipeline = `-- name: StartInvoicePipeline :exec
+UPDATE invoices
+SET pipeline_status = 'pending', pipeline_attempts = 0, pipeline_error = NULL,
+    pipeline_next_attempt_at = NOW(), pipeline_updated_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) StartInvoicePipeline(ctx context.Context, id pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, startInvoicePipeline, id)
+	return err
+}
+
+const sumCreditedAmount = `-- name: SumCreditedAmount :one
+SELECT COALESCE(SUM(total_amount), 0)::bigint AS credited FROM invoices
+WHERE credited_invoice_id = $1 AND status = 'credit_note'
+`
+
+// SumCreditedAmount returns the total of the credit notes issued against an
+// invoice, as a negative amount in bani.
+func (q *Queries) SumCreditedAmount(ctx context.Context, creditedInvoiceID pgtype.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, sumCreditedAmount, creditedInvoiceID)
+	var credited int64
+	err := row.Scan(&credited)
+	return credited, err
+}
+
 const updateBillingProfile = `-- name: UpdateBillingProfile :one
 UPDATE client_billing_profiles SET
   is_company = $2, company_name = $3, cui = $4, reg_number = $5,
@@ -1216,7 +2252,7 @@ func (q *Queries) UpdateInvoiceFactureaza(ctx context.Context, arg UpdateInvoice
 
 const updateInvoiceStatus = `-- name: UpdateInvoiceStatus :one
 UPDATE invoices SET status = $2, issued_at = CASE WHEN $2 = 'issued' THEN NOW() ELSE issued_at END, updated_at = NOW()
-WHERE id = $1 RETURNING id, invoice_type, invoice_number, factureaza_id, factureaza_download_url, seller_company_name, seller_cui, seller_reg_number, seller_address, seller_city, seller_county, seller_is_vat_payer, seller_bank_name, seller_iban, buyer_name, buyer_cui, buyer_reg_number, buyer_address, buyer_city, buyer_county, buyer_is_vat_payer, buyer_email, subtotal_amount, vat_rate, vat_amount, total_amount, currency, booking_id, payment_transaction_id, company_id, client_user_id, efactura_status, efactura_index, status, issued_at, due_date, notes, created_at, updated_at
+WHERE id = $1 RETURNING id, invoice_type, invoice_number, factureaza_id, factureaza_download_url, seller_company_name, seller_cui, seller_reg_number, seller_address, seller_city, seller_county, seller_is_vat_payer, seller_bank_name, seller_iban, buyer_name, buyer_cui, buyer_reg_number, buyer_address, buyer_city, buyer_county, buyer_is_vat_payer, buyer_email, subtotal_amount, vat_rate, vat_amount, total_amount, currency, booking_id, payment_transaction_id, company_id, client_user_id, efactura_status, efactura_index, status, issued_at, due_date, notes, created_at, updated_at, efactura_message, efactura_download_id, efactura_response_path, efactura_uploaded_at, efactura_checked_at, pdf_path, pipeline_status, pipeline_attempts, pipeline_error, pipeline_next_attempt_at, pipeline_updated_at, emailed_at, credited_invoice_id, credited_invoice_number, credited_invoice_date, stripe_refund_id, payout_id, period_from, period_to
 `
 
 type UpdateInvoiceStatusParams struct {
@@ -1267,6 +2303,25 @@ func (q *Queries) UpdateInvoiceStatus(ctx context.Context, arg UpdateInvoiceStat
 		&i.Notes,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.EfacturaMessage,
+		&i.EfacturaDownloadID,
+		&i.EfacturaResponsePath,
+		&i.EfacturaUploadedAt,
+		&i.EfacturaCheckedAt,
+		&i.PdfPath,
+		&i.PipelineStatus,
+		&i.PipelineAttempts,
+		&i.PipelineError,
+		&i.PipelineNextAttemptAt,
+		&i.PipelineUpdatedAt,
+		&i.EmailedAt,
+		&i.CreditedInvoiceID,
+		&i.CreditedInvoiceNumber,
+		&i.CreditedInvoiceDate,
+		&i.StripeRefundID,
+		&i.PayoutID,
+		&i.PeriodFrom,
+		&i.PeriodTo,
 	)
 	return i, err
 }
@@ -13,7 +13,7 @@ import (
 
 const countCleanerBookingsInDateRange = `-- name: CountCleanerBookingsInDateRange :one
 SELECT COUNT(*) FROM bookings
-WHERE cleaner_id = $1
+WHERE (cleaner_id = $1 OR id IN (SELECT booking_id FROM booking_team_members WHERE cleaner_id = $1))
   AND scheduled_date >= $2
   AND scheduled_date <= $3
   AND status NOT IN ('cancelled_by_client', 'cancelled_by_company', 'cancelled_by_admin')
@@ -81,10 +81,60 @@ func (q *Queries) FindMatchingCleaners(ctx context.Context, cityAreaID pgtype.UU
 	return items, nil
 }
 
+const listBookingsForSimulation = `-- name: ListBookingsForSimulation :many
+SELECT b.id, b.scheduled_date, b.scheduled_start_time, b.estimated_duration_hours, a.city
+FROM bookings b
+JOIN client_addresses a ON b.address_id = a.id
+WHERE b.scheduled_date >= $1
+  AND b.scheduled_date <= $2
+  AND b.team_size = 1
+  AND b.status NOT IN ('cancelled_by_client', 'cancelled_by_company', 'cancelled_by_admin')
+ORDER BY b.created_at
+`
+
+type ListBookingsForSimulationParams struct {
+	ScheduledDate   pgtype.Date `json:"scheduled_date"`
+	ScheduledDate_2 pgtype.Date `json:"scheduled_date_2"`
+}
+
+type ListBookingsForSimulationRow struct {
+	ID                     pgtype.UUID    `json:"id"`
+	ScheduledDate          pgtype.Date    `json:"scheduled_date"`
+	ScheduledStartTime     pgtype.Time    `json:"scheduled_start_time"`
+	EstimatedDurationHours pgtype.Numeric `json:"estimated_duration_hours"`
+	City                   string         `json:"city"`
+}
+
+func (q *Queries) ListBookingsForSimulation(ctx context.Context, arg ListBookingsForSimulationParams) ([]ListBookingsForSimulationRow, error) {
+	rows, err := q.db.Query(ctx, listBookingsForSimulation, arg.ScheduledDate, arg.ScheduledDate_2)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListBookingsForSimulationRow
+	for rows.Next() {
+		var i ListBookingsForSimulationRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ScheduledDate,
+			&i.ScheduledStartTime,
+			&i.EstimatedDurationHours,
+			&i.City,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listCleanerBookingsForDate = `-- name: ListCleanerBookingsForDate :many
 SELECT id, scheduled_start_time, estimated_duration_hours
 FROM bookings
-WHERE cleaner_id = $1
+WHERE (cleaner_id = $1 OR id IN (SELECT booking_id FROM booking_team_members WHERE cleaner_id = $1))
   AND scheduled_date = $2
   AND status NOT IN ('cancelled_by_client', 'cancelled_by_company', 'cancelled_by_admin')
 ORDER BY scheduled_start_time
@@ -120,3 +170,44 @@ func (q *Queries) ListCleanerBookingsForDate(ctx context.Context, arg ListCleane
 	}
 	return items, nil
 }
+
+const listCleanersForSimulation = `-- name: ListCleanersForSimulation :many
+SELECT c.id, c.company_id, c.rating_avg, c.total_jobs_completed
+FROM cleaners c
+JOIN companies co ON c.company_id = co.id
+WHERE c.status = 'active'
+  AND co.status = 'approved'
+ORDER BY c.id
+`
+
+type ListCleanersForSimulationRow struct {
+	ID                 pgtype.UUID    `json:"id"`
+	CompanyID          pgtype.UUID    `json:"company_id"`
+	RatingAvg          pgtype.Numeric `json:"rating_avg"`
+	TotalJobsCompleted pgtype.Int4    `json:"total_jobs_completed"`
+}
+
+func (q *Queries) ListCleanersForSimulation(ctx context.Context) ([]ListCleanersForSimulationRow, error) {
+	rows, err := q.db.Query(ctx, listCleanersForSimulation)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListCleanersForSimulationRow
+	for rows.Next() {
+		var i ListCleanersForSimulationRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CompanyID,
+			&i.RatingAvg,
+			&i.TotalJobsCompleted,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -191,6 +191,53 @@ func (ns NullCompanyType) Value() (driver.Value, error) {
 	return string(ns.CompanyType), nil
 }
 
+type DisputeStatus string
+
+const (
+	DisputeStatusWarningNeedsResponse DisputeStatus = "warning_needs_response"
+	DisputeStatusWarningUnderReview   DisputeStatus = "warning_under_review"
+	DisputeStatusWarningClosed        DisputeStatus = "warning_closed"
+	DisputeStatusNeedsResponse        DisputeStatus = "needs_response"
+	DisputeStatusUnderReview          DisputeStatus = "under_review"
+	DisputeStatusWon                  DisputeStatus = "won"
+	DisputeStatusLost                 DisputeStatus = "lost"
+)
+
+func (e *DisputeStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = DisputeStatus(s)
+	case string:
+		*e = DisputeStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for DisputeStatus: %T", src)
+	}
+	return nil
+}
+
+type NullDisputeStatus struct {
+	DisputeStatus DisputeStatus `json:"dispute_status"`
+	Valid         bool          `json:"valid"` // Valid is true if DisputeStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullDisputeStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.DisputeStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.DisputeStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullDisputeStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.DisputeStatus), nil
+}
+
 type InvoiceStatus string
 
 const (
@@ -280,6 +327,99 @@ func (ns NullInvoiceType) Value() (driver.Value, error) {
 	return string(ns.InvoiceType), nil
 }
 
+type LedgerAccountType string
+
+const (
+	LedgerAccountTypeStripeBalance LedgerAccountType = "stripe_balance"
+	LedgerAccountTypeClient        LedgerAccountType = "client"
+	LedgerAccountTypeCompany       LedgerAccountType = "company"
+	LedgerAccountTypePlatformFees  LedgerAccountType = "platform_fees"
+	LedgerAccountTypeStripeFees    LedgerAccountType = "stripe_fees"
+	LedgerAccountTypeVatPayable    LedgerAccountType = "vat_payable"
+)
+
+func (e *LedgerAccountType) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = LedgerAccountType(s)
+	case string:
+		*e = LedgerAccountType(s)
+	default:
+		return fmt.Errorf("unsupported scan type for LedgerAccountType: %T", src)
+	}
+	return nil
+}
+
+type NullLedgerAccountType struct {
+	LedgerAccountType LedgerAccountType `json:"ledger_account_type"`
+	Valid             bool              `json:"valid"` // Valid is true if LedgerAccountType is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullLedgerAccountType) Scan(value interface{}) error {
+	if value == nil {
+		ns.LedgerAccountType, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.LedgerAccountType.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullLedgerAccountType) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.LedgerAccountType), nil
+}
+
+type LedgerEntryKind string
+
+const (
+	LedgerEntryKindPayment           LedgerEntryKind = "payment"
+	LedgerEntryKindRefund            LedgerEntryKind = "refund"
+	LedgerEntryKindPayout            LedgerEntryKind = "payout"
+	LedgerEntryKindStripeFee         LedgerEntryKind = "stripe_fee"
+	LedgerEntryKindCommissionInvoice LedgerEntryKind = "commission_invoice"
+	LedgerEntryKindCreditNote        LedgerEntryKind = "credit_note"
+	LedgerEntryKindDispute           LedgerEntryKind = "dispute"
+)
+
+func (e *LedgerEntryKind) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = LedgerEntryKind(s)
+	case string:
+		*e = LedgerEntryKind(s)
+	default:
+		return fmt.Errorf("unsupported scan type for LedgerEntryKind: %T", src)
+	}
+	return nil
+}
+
+type NullLedgerEntryKind struct {
+	LedgerEntryKind LedgerEntryKind `json:"ledger_entry_kind"`
+	Valid           bool            `json:"valid"` // Valid is true if LedgerEntryKind is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullLedgerEntryKind) Scan(value interface{}) error {
+	if value == nil {
+		ns.LedgerEntryKind, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.LedgerEntryKind.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullLedgerEntryKind) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.LedgerEntryKind), nil
+}
+
 type NotificationType string
 
 const (
@@ -295,6 +435,8 @@ const (
 	NotificationTypeNewMessage       NotificationType = "new_message"
 	NotificationTypeReviewReceived   NotificationType = "review_received"
 	NotificationTypePaymentProcessed NotificationType = "payment_processed"
+	NotificationTypePaymentFailed    NotificationType = "payment_failed"
+	NotificationTypePaymentDisputed  NotificationType = "payment_disputed"
 )
 
 func (e *NotificationType) Scan(src interface{}) error {
@@ -332,12 +474,55 @@ func (ns NullNotificationType) Value() (driver.Value, error) {
 	return string(ns.NotificationType), nil
 }
 
+type OccurrenceExceptionType string
+
+const (
+	OccurrenceExceptionTypeSkipped     OccurrenceExceptionType = "skipped"
+	OccurrenceExceptionTypeRescheduled OccurrenceExceptionType = "rescheduled"
+)
+
+func (e *OccurrenceExceptionType) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = OccurrenceExceptionType(s)
+	case string:
+		*e = OccurrenceExceptionType(s)
+	default:
+		return fmt.Errorf("unsupported scan type for OccurrenceExceptionType: %T", src)
+	}
+	return nil
+}
+
+type NullOccurrenceExceptionType struct {
+	OccurrenceExceptionType OccurrenceExceptionType `json:"occurrence_exception_type"`
+	Valid                   bool                    `json:"valid"` // Valid is true if OccurrenceExceptionType is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullOccurrenceExceptionType) Scan(value interface{}) error {
+	if value == nil {
+		ns.OccurrenceExceptionType, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.OccurrenceExceptionType.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullOccurrenceExceptionType) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.OccurrenceExceptionType), nil
+}
+
 type PaymentTransactionStatus string
 
 const (
 	PaymentTransactionStatusPending           PaymentTransactionStatus = "pending"
 	PaymentTransactionStatusRequiresAction    PaymentTransactionStatus = "requires_action"
 	PaymentTransactionStatusProcessing        PaymentTransactionStatus = "processing"
+	PaymentTransactionStatusAuthorized        PaymentTransactionStatus = "authorized"
 	PaymentTransactionStatusSucceeded         PaymentTransactionStatus = "succeeded"
 	PaymentTransactionStatusFailed            PaymentTransactionStatus = "failed"
 	PaymentTransactionStatusRefunded          PaymentTransactionStatus = "refunded"
@@ -384,6 +569,7 @@ type PayoutStatus string
 
 const (
 	PayoutStatusPending    PayoutStatus = "pending"
+	PayoutStatusHeld       PayoutStatus = "held"
 	PayoutStatusProcessing PayoutStatus = "processing"
 	PayoutStatusPaid       PayoutStatus = "paid"
 	PayoutStatusFailed     PayoutStatus = "failed"
@@ -431,6 +617,7 @@ const (
 	RecurrenceTypeWeekly   RecurrenceType = "weekly"
 	RecurrenceTypeBiweekly RecurrenceType = "biweekly"
 	RecurrenceTypeMonthly  RecurrenceType = "monthly"
+	RecurrenceTypeCustom   RecurrenceType = "custom"
 )
 
 func (e *RecurrenceType) Scan(src interface{}) error {
@@ -558,6 +745,50 @@ func (ns NullServiceType) Value() (driver.Value, error) {
 	return string(ns.ServiceType), nil
 }
 
+type StripeEventStatus string
+
+const (
+	StripeEventStatusPending    StripeEventStatus = "pending"
+	StripeEventStatusProcessing StripeEventStatus = "processing"
+	StripeEventStatusProcessed  StripeEventStatus = "processed"
+	StripeEventStatusFailed     StripeEventStatus = "failed"
+)
+
+func (e *StripeEventStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = StripeEventStatus(s)
+	case string:
+		*e = StripeEventStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for StripeEventStatus: %T", src)
+	}
+	return nil
+}
+
+type NullStripeEventStatus struct {
+	StripeEventStatus StripeEventStatus `json:"stripe_event_status"`
+	Valid             bool              `json:"valid"` // Valid is true if StripeEventStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullStripeEventStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.StripeEventStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.StripeEventStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullStripeEventStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.StripeEventStatus), nil
+}
+
 type UserRole string
 
 const (
@@ -688,6 +919,36 @@ func (ns NullWaitlistLeadType) Value() (driver.Value, error) {
 	return string(ns.WaitlistLeadType), nil
 }
 
+type AccountingExport struct {
+	ID          pgtype.UUID        `json:"id"`
+	CompanyID   pgtype.UUID        `json:"company_id"`
+	RequestedBy pgtype.UUID        `json:"requested_by"`
+	Format      string             `json:"format"`
+	PeriodFrom  pgtype.Date        `json:"period_from"`
+	PeriodTo    pgtype.Date        `json:"period_to"`
+	Status      string             `json:"status"`
+	Attempts    int32              `json:"attempts"`
+	FilePath    pgtype.Text        `json:"file_path"`
+	FileName    pgtype.Text        `json:"file_name"`
+	RecordCount pgtype.Int4        `json:"record_count"`
+	Error       pgtype.Text        `json:"error"`
+	CompletedAt pgtype.Timestamptz `json:"completed_at"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+}
+
+type AnafToken struct {
+	ID                pgtype.UUID        `json:"id"`
+	Cif               string             `json:"cif"`
+	CompanyID         pgtype.UUID        `json:"company_id"`
+	AccessToken       string             `json:"access_token"`
+	RefreshToken      string             `json:"refresh_token"`
+	ExpiresAt         pgtype.Timestamptz `json:"expires_at"`
+	ConnectedByUserID pgtype.UUID        `json:"connected_by_user_id"`
+	CreatedAt         pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt         pgtype.Timestamptz `json:"updated_at"`
+}
+
 type Booking struct {
 	ID                       pgtype.UUID        `json:"id"`
 	ReferenceCode            string             `json:"reference_code"`
@@ -722,6 +983,9 @@ type Booking struct {
 	UpdatedAt                pgtype.Timestamptz `json:"updated_at"`
 	RecurringGroupID         pgtype.UUID        `json:"recurring_group_id"`
 	OccurrenceNumber         pgtype.Int4        `json:"occurrence_number"`
+	TeamSize                 int32              `json:"team_size"`
+	HolidaySurcharge         pgtype.Numeric     `json:"holiday_surcharge"`
+	PetsSurcharge            pgtype.Numeric     `json:"pets_surcharge"`
 }
 
 type BookingExtra struct {
@@ -732,6 +996,17 @@ type BookingExtra struct {
 	Quantity  pgtype.Int4    `json:"quantity"`
 }
 
+type BookingTeamMember struct {
+	ID          pgtype.UUID        `json:"id"`
+	BookingID   pgtype.UUID        `json:"booking_id"`
+	CleanerID   pgtype.UUID        `json:"cleaner_id"`
+	IsLead      bool               `json:"is_lead"`
+	PayShare    pgtype.Numeric     `json:"pay_share"`
+	CheckedInAt pgtype.Timestamptz `json:"checked_in_at"`
+	CompletedAt pgtype.Timestamptz `json:"completed_at"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+}
+
 type BookingTimeSlot struct {
 	ID         pgtype.UUID        `json:"id"`
 	BookingID  pgtype.UUID        `json:"booking_id"`
@@ -827,6 +1102,12 @@ type CleanerServiceArea struct {
 	CreatedAt  pgtype.Timestamptz `json:"created_at"`
 }
 
+type CleanerSkill struct {
+	CleanerID   pgtype.UUID        `json:"cleaner_id"`
+	ServiceType ServiceType        `json:"service_type"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+}
+
 type ClientAddress struct {
 	ID            pgtype.UUID        `json:"id"`
 	UserID        pgtype.UUID        `json:"user_id"`
@@ -902,6 +1183,18 @@ type Company struct {
 	StripeConnectOnboardingComplete pgtype.Bool        `json:"stripe_connect_onboarding_complete"`
 	StripeConnectChargesEnabled     pgtype.Bool        `json:"stripe_connect_charges_enabled"`
 	StripeConnectPayoutsEnabled     pgtype.Bool        `json:"stripe_connect_payouts_enabled"`
+	WorksOnHolidays                 bool               `json:"works_on_holidays"`
+	HolidaySurchargePct             pgtype.Numeric     `json:"holiday_surcharge_pct"`
+	IsVatPayer                      pgtype.Bool        `json:"is_vat_payer"`
+	VatCheckedAt                    pgtype.Timestamptz `json:"vat_checked_at"`
+}
+
+type CompanyClosure struct {
+	ID          pgtype.UUID        `json:"id"`
+	CompanyID   pgtype.UUID        `json:"company_id"`
+	ClosureDate pgtype.Date        `json:"closure_date"`
+	Reason      pgtype.Text        `json:"reason"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
 }
 
 type CompanyDocument struct {
@@ -917,6 +1210,16 @@ type CompanyDocument struct {
 	RejectionReason pgtype.Text        `json:"rejection_reason"`
 }
 
+type CompanyInvoiceSetting struct {
+	CompanyID        pgtype.UUID        `json:"company_id"`
+	AutoIssueTrigger string             `json:"auto_issue_trigger"`
+	AutoEmail        bool               `json:"auto_email"`
+	AutoEfactura     bool               `json:"auto_efactura"`
+	AutoIssueSince   pgtype.Timestamptz `json:"auto_issue_since"`
+	CreatedAt        pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt        pgtype.Timestamptz `json:"updated_at"`
+}
+
 type CompanyPayout struct {
 	ID               pgtype.UUID        `json:"id"`
 	CompanyID        pgtype.UUID        `json:"company_id"`
@@ -932,6 +1235,8 @@ type CompanyPayout struct {
 	FailureReason    pgtype.Text        `json:"failure_reason"`
 	CreatedAt        pgtype.Timestamptz `json:"created_at"`
 	UpdatedAt        pgtype.Timestamptz `json:"updated_at"`
+	Attempts         int32              `json:"attempts"`
+	NextAttemptAt    pgtype.Timestamptz `json:"next_attempt_at"`
 }
 
 type CompanyServiceArea struct {
@@ -1008,6 +1313,25 @@ type Invoice struct {
 	Notes                 pgtype.Text        `json:"notes"`
 	CreatedAt             pgtype.Timestamptz `json:"created_at"`
 	UpdatedAt             pgtype.Timestamptz `json:"updated_at"`
+	EfacturaMessage       pgtype.Text        `json:"efactura_message"`
+	EfacturaDownloadID    pgtype.Text        `json:"efactura_download_id"`
+	EfacturaResponsePath  pgtype.Text        `json:"efactura_response_path"`
+	EfacturaUploadedAt    pgtype.Timestamptz `json:"efactura_uploaded_at"`
+	EfacturaCheckedAt     pgtype.Timestamptz `json:"efactura_checked_at"`
+	PdfPath               pgtype.Text        `json:"pdf_path"`
+	PipelineStatus        pgtype.Text        `json:"pipeline_status"`
+	PipelineAttempts      int32              `json:"pipeline_attempts"`
+	PipelineError         pgtype.Text        `json:"pipeline_error"`
+	PipelineNextAttemptAt pgtype.Timestamptz `json:"pipeline_next_attempt_at"`
+	PipelineUpdatedAt     pgtype.Timestamptz `json:"pipeline_updated_at"`
+	EmailedAt             pgtype.Timestamptz `json:"emailed_at"`
+	CreditedInvoiceID     pgtype.UUID        `json:"credited_invoice_id"`
+	CreditedInvoiceNumber pgtype.Text        `json:"credited_invoice_number"`
+	CreditedInvoiceDate   pgtype.Date        `json:"credited_invoice_date"`
+	StripeRefundID        pgtype.Text        `json:"stripe_refund_id"`
+	PayoutID              pgtype.UUID        `json:"payout_id"`
+	PeriodFrom            pgtype.Date        `json:"period_from"`
+	PeriodTo              pgtype.Date        `json:"period_to"`
 }
 
 type InvoiceLineItem struct {
@@ -1024,12 +1348,54 @@ type InvoiceLineItem struct {
 	SortOrder        pgtype.Int4    `json:"sort_order"`
 }
 
-type InvoiceSequence struct {
-	ID            pgtype.UUID `json:"id"`
-	CompanyID     pgtype.UUID `json:"company_id"`
-	Prefix        string      `json:"prefix"`
-	CurrentNumber int32       `json:"current_number"`
-	Year          int32       `json:"year"`
+type InvoiceSeries struct {
+	ID           pgtype.UUID        `json:"id"`
+	CompanyID    pgtype.UUID        `json:"company_id"`
+	DocumentType string             `json:"document_type"`
+	Prefix       string             `json:"prefix"`
+	StartNumber  int32              `json:"start_number"`
+	NextNumber   int32              `json:"next_number"`
+	YearlyReset  bool               `json:"yearly_reset"`
+	CurrentYear  int32              `json:"current_year"`
+	Active       bool               `json:"active"`
+	RetiredAt    pgtype.Timestamptz `json:"retired_at"`
+	CreatedAt    pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt    pgtype.Timestamptz `json:"updated_at"`
+}
+
+type LedgerAccount struct {
+	ID          pgtype.UUID        `json:"id"`
+	AccountType LedgerAccountType  `json:"account_type"`
+	OwnerID     pgtype.UUID        `json:"owner_id"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+}
+
+type LedgerEntry struct {
+	ID          pgtype.UUID        `json:"id"`
+	Kind        LedgerEntryKind    `json:"kind"`
+	Reference   string             `json:"reference"`
+	Description string             `json:"description"`
+	BookingID   pgtype.UUID        `json:"booking_id"`
+	CompanyID   pgtype.UUID        `json:"company_id"`
+	OccurredAt  pgtype.Timestamptz `json:"occurred_at"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+}
+
+type LedgerLine struct {
+	ID        pgtype.UUID `json:"id"`
+	EntryID   pgtype.UUID `json:"entry_id"`
+	AccountID pgtype.UUID `json:"account_id"`
+	Amount    int64       `json:"amount"`
+}
+
+type LedgerReconciliation struct {
+	ID            pgtype.UUID        `json:"id"`
+	PeriodFrom    pgtype.Timestamptz `json:"period_from"`
+	PeriodTo      pgtype.Timestamptz `json:"period_to"`
+	CheckedCount  int32              `json:"checked_count"`
+	FeesPosted    int32              `json:"fees_posted"`
+	Discrepancies []byte             `json:"discrepancies"`
+	CreatedAt     pgtype.Timestamptz `json:"created_at"`
 }
 
 type Notification struct {
@@ -1044,6 +1410,26 @@ type Notification struct {
 	CreatedAt pgtype.Timestamptz `json:"created_at"`
 }
 
+type PaymentDispute struct {
+	ID                   pgtype.UUID        `json:"id"`
+	StripeDisputeID      string             `json:"stripe_dispute_id"`
+	StripeChargeID       string             `json:"stripe_charge_id"`
+	PaymentTransactionID pgtype.UUID        `json:"payment_transaction_id"`
+	BookingID            pgtype.UUID        `json:"booking_id"`
+	CompanyID            pgtype.UUID        `json:"company_id"`
+	Amount               int32              `json:"amount"`
+	Currency             string             `json:"currency"`
+	Reason               string             `json:"reason"`
+	Status               DisputeStatus      `json:"status"`
+	EvidenceDueBy        pgtype.Timestamptz `json:"evidence_due_by"`
+	Evidence             []byte             `json:"evidence"`
+	EvidenceSubmittedAt  pgtype.Timestamptz `json:"evidence_submitted_at"`
+	ClosedAt             pgtype.Timestamptz `json:"closed_at"`
+	CreatedAt            pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt            pgtype.Timestamptz `json:"updated_at"`
+	EventCreatedAt       pgtype.Timestamptz `json:"event_created_at"`
+}
+
 type PaymentTransaction struct {
 	ID                    pgtype.UUID              `json:"id"`
 	BookingID             pgtype.UUID              `json:"booking_id"`
@@ -1060,6 +1446,9 @@ type PaymentTransaction struct {
 	Metadata              []byte                   `json:"metadata"`
 	CreatedAt             pgtype.Timestamptz       `json:"created_at"`
 	UpdatedAt             pgtype.Timestamptz       `json:"updated_at"`
+	CaptureMethod         string                   `json:"capture_method"`
+	AmountAuthorized      pgtype.Int4              `json:"amount_authorized"`
+	AuthorizedAt          pgtype.Timestamptz       `json:"authorized_at"`
 }
 
 type PayoutLineItem struct {
@@ -1071,6 +1460,7 @@ type PayoutLineItem struct {
 	AmountCommission     int32              `json:"amount_commission"`
 	AmountNet            int32              `json:"amount_net"`
 	CreatedAt            pgtype.Timestamptz `json:"created_at"`
+	CleanerID            pgtype.UUID        `json:"cleaner_id"`
 }
 
 type PersonalityAssessment struct {
@@ -1171,6 +1561,11 @@ type RecurringBookingGroup struct {
 	CancellationReason          pgtype.Text        `json:"cancellation_reason"`
 	CreatedAt                   pgtype.Timestamptz `json:"created_at"`
 	UpdatedAt                   pgtype.Timestamptz `json:"updated_at"`
+	EstimatedDurationHours      pgtype.Numeric     `json:"estimated_duration_hours"`
+	GeneratedUntil              pgtype.Date        `json:"generated_until"`
+	AnchorDate                  pgtype.Date        `json:"anchor_date"`
+	Rrule                       pgtype.Text        `json:"rrule"`
+	PaymentFailures             int32              `json:"payment_failures"`
 }
 
 type RecurringGroupExtra struct {
@@ -1180,6 +1575,32 @@ type RecurringGroupExtra struct {
 	Quantity pgtype.Int4 `json:"quantity"`
 }
 
+type RecurringOccurrenceException struct {
+	GroupID       pgtype.UUID             `json:"group_id"`
+	OriginalDate  pgtype.Date             `json:"original_date"`
+	ExceptionType OccurrenceExceptionType `json:"exception_type"`
+	BookingID     pgtype.UUID             `json:"booking_id"`
+	CreatedAt     pgtype.Timestamptz      `json:"created_at"`
+	UpdatedAt     pgtype.Timestamptz      `json:"updated_at"`
+}
+
+type RefundCredit struct {
+	RefundID        string             `json:"refund_id"`
+	PaymentIntentID string             `json:"payment_intent_id"`
+	BookingID       pgtype.UUID        `json:"booking_id"`
+	CompanyID       pgtype.UUID        `json:"company_id"`
+	Amount          int64              `json:"amount"`
+	PaymentGross    int64              `json:"payment_gross"`
+	PaymentFee      int64              `json:"payment_fee"`
+	RefundedAt      pgtype.Timestamptz `json:"refunded_at"`
+	Status          string             `json:"status"`
+	Attempts        int32              `json:"attempts"`
+	LastError       pgtype.Text        `json:"last_error"`
+	NextAttemptAt   pgtype.Timestamptz `json:"next_attempt_at"`
+	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt       pgtype.Timestamptz `json:"updated_at"`
+}
+
 type RefundRequest struct {
 	ID                   pgtype.UUID        `json:"id"`
 	BookingID            pgtype.UUID        `json:"booking_id"`
@@ -1239,6 +1660,19 @@ type ServiceExtra struct {
 	UnitLabel       pgtype.Text    `json:"unit_label"`
 }
 
+type StripeEvent struct {
+	ID            string             `json:"id"`
+	EventType     string             `json:"event_type"`
+	Payload       []byte             `json:"payload"`
+	Status        StripeEventStatus  `json:"status"`
+	Attempts      int32              `json:"attempts"`
+	LastError     pgtype.Text        `json:"last_error"`
+	NextAttemptAt pgtype.Timestamptz `json:"next_attempt_at"`
+	ProcessedAt   pgtype.Timestamptz `json:"processed_at"`
+	CreatedAt     pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt     pgtype.Timestamptz `json:"updated_at"`
+}
+
 type User struct {
 	ID                pgtype.UUID        `json:"id"`
 	Email             string             `json:"email"`
@@ -1255,6 +1689,15 @@ type User struct {
 	StripeCustomerID  pgtype.Text        `json:"stripe_customer_id"`
 }
 
+type VatRate struct {
+	ID          pgtype.UUID        `json:"id"`
+	Category    string             `json:"category"`
+	RatePct     int32              `json:"rate_pct"`
+	ValidFrom   pgtype.Date        `json:"valid_from"`
+	Description pgtype.Text        `json:"description"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+}
+
 type WaitlistLead struct {
 	ID          pgtype.UUID        `json:"id"`
 	LeadType    WaitlistLeadType   `json:"lead_type"`
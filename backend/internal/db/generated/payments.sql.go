@@ -11,6 +11,91 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const cancelBookingForFailedPayment = `-- name: CancelBookingForFailedPayment :execrows
+UPDATE bookings SET status = 'cancelled_by_admin', updated_at = NOW()
+WHERE id = $1 AND stripe_payment_intent_id = $2
+  AND status IN ('pending', 'assigned', 'confirmed')
+`
+
+type CancelBookingForFailedPaymentParams struct {
+	ID                    pgtype.UUID `json:"id"`
+	StripePaymentIntentID pgtype.Text `json:"stripe_payment_intent_id"`
+}
+
+// CancelBookingForFailedPayment cancels a booking whose payment failed. It
+// leaves alone a booking that has started, is already cancelled or is now paid
+// with another PaymentIntent.
+func (q *Queries) CancelBookingForFailedPayment(ctx context.Context, arg CancelBookingForFailedPaymentParams) (int64, error) {
+	result, err := q.db.Exec(ctx, cancelBookingForFailedPayment, arg.ID, arg.StripePaymentIntentID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const cancelPayout = `-- name: CancelPayout :one
+UPDATE company_payouts SET status = 'cancelled', next_attempt_at = NULL, updated_at = NOW()
+WHERE id = $1 AND status IN ('pending', 'failed', 'held')
+RETURNING id, company_id, stripe_transfer_id, stripe_payout_id, amount, currency, period_from, period_to, booking_count, status, paid_at, failure_reason, created_at, updated_at, attempts, next_attempt_at
+`
+
+func (q *Queries) CancelPayout(ctx context.Context, id pgtype.UUID) (CompanyPayout, error) {
+	row := q.db.QueryRow(ctx, cancelPayout, id)
+	var i CompanyPayout
+	err := row.Scan(
+		&i.ID,
+		&i.CompanyID,
+		&i.StripeTransferID,
+		&i.StripePayoutID,
+		&i.Amount,
+		&i.Currency,
+		&i.PeriodFrom,
+		&i.PeriodTo,
+		&i.BookingCount,
+		&i.Status,
+		&i.PaidAt,
+		&i.FailureReason,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Attempts,
+		&i.NextAttemptAt,
+	)
+	return i, err
+}
+
+const claimPayoutForExecution = `-- name: ClaimPayoutForExecution :one
+UPDATE company_payouts
+SET status = 'processing', attempts = attempts + 1, failure_reason = NULL, next_attempt_at = NULL, updated_at = NOW()
+WHERE id = $1 AND status IN ('pending', 'failed')
+RETURNING id, company_id, stripe_transfer_id, stripe_payout_id, amount, currency, period_from, period_to, booking_count, status, paid_at, failure_reason, created_at, updated_at, attempts, next_attempt_at
+`
+
+// ClaimPayoutForExecution moves a pending or failed payout to processing. It
+// returns no rows when the payout is already processing, paid or cancelled.
+func (q *Queries) ClaimPayoutForExecution(ctx context.Context, id pgtype.UUID) (CompanyPayout, error) {
+	row := q.db.QueryRow(ctx, claimPayoutForExecution, id)
+	var i CompanyPayout
+	err := row.Scan(
+		&i.ID,
+		&i.CompanyID,
+		&i.StripeTransferID,
+		&i.StripePayoutID,
+		&i.Amount,
+		&i.Currency,
+		&i.PeriodFrom,
+		&i.PeriodTo,
+		&i.BookingCount,
+		&i.Status,
+		&i.PaidAt,
+		&i.FailureReason,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Attempts,
+		&i.NextAttemptAt,
+	)
+	return i, err
+}
+
 const countPaymentHistoryByUser = `-- name: CountPaymentHistoryByUser :one
 SELECT COUNT(*) FROM payment_transactions pt
 JOIN bookings b ON b.id = pt.booking_id
@@ -28,7 +113,7 @@ const createCompanyPayout = `-- name: CreateCompanyPayout :one
 
 INSERT INTO company_payouts (company_id, amount, currency, period_from, period_to, booking_count, status)
 VALUES ($1, $2, $3, $4, $5, $6, $7)
-RETURNING id, company_id, stripe_transfer_id, stripe_payout_id, amount, currency, period_from, period_to, booking_count, status, paid_at, failure_reason, created_at, updated_at
+RETURNING id, company_id, stripe_transfer_id, stripe_payout_id, amount, currency, period_from, period_to, booking_count, status, paid_at, failure_reason, created_at, updated_at, attempts, next_attempt_at
 `
 
 type CreateCompanyPayoutParams struct {
@@ -70,6 +155,8 @@ func (q *Queries) CreateCompanyPayout(ctx context.Context, arg CreateCompanyPayo
 		&i.FailureReason,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Attempts,
+		&i.NextAttemptAt,
 	)
 	return i, err
 }
@@ -119,9 +206,9 @@ const createPaymentTransaction = `-- name: CreatePaymentTransaction :one
 
 INSERT INTO payment_transactions (
   booking_id, stripe_payment_intent_id, amount_total, amount_company,
-  amount_platform_fee, currency, status, metadata
-) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-RETURNING id, booking_id, stripe_payment_intent_id, stripe_charge_id, amount_total, amount_company, amount_platform_fee, currency, status, failure_reason, refund_amount, stripe_refund_id, metadata, created_at, updated_at
+  amount_platform_fee, currency, status, metadata, capture_method, amount_authorized
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING id, booking_id, stripe_payment_intent_id, stripe_charge_id, amount_total, amount_company, amount_platform_fee, currency, status, failure_reason, refund_amount, stripe_refund_id, metadata, created_at, updated_at, capture_method, amount_authorized, authorized_at
 `
 
 type CreatePaymentTransactionParams struct {
@@ -133,6 +220,8 @@ type CreatePaymentTransactionParams struct {
 	Currency              string                   `json:"currency"`
 	Status                PaymentTransactionStatus `json:"status"`
 	Metadata              []byte                   `json:"metadata"`
+	CaptureMethod         string                   `json:"capture_method"`
+	AmountAuthorized      pgtype.Int4              `json:"amount_authorized"`
 }
 
 // ============================================
@@ -148,6 +237,8 @@ func (q *Queries) CreatePaymentTransaction(ctx context.Context, arg CreatePaymen
 		arg.Currency,
 		arg.Status,
 		arg.Metadata,
+		arg.CaptureMethod,
+		arg.AmountAuthorized,
 	)
 	var i PaymentTransaction
 	err := row.Scan(
@@ -166,14 +257,17 @@ func (q *Queries) CreatePaymentTransaction(ctx context.Context, arg CreatePaymen
 		&i.Metadata,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.CaptureMethod,
+		&i.AmountAuthorized,
+		&i.AuthorizedAt,
 	)
 	return i, err
 }
 
 const createPayoutLineItem = `-- name: CreatePayoutLineItem :one
 
-INSERT INTO payout_line_items (payout_id, payment_transaction_id, booking_id, amount_gross, amount_commission, amount_net)
-VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, payout_id, payment_transaction_id, booking_id, amount_gross, amount_commission, amount_net, created_at
+INSERT INTO payout_line_items (payout_id, payment_transaction_id, booking_id, amount_gross, amount_commission, amount_net, cleaner_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, payout_id, payment_transaction_id, booking_id, amount_gross, amount_commission, amount_net, created_at, cleaner_id
 `
 
 type CreatePayoutLineItemParams struct {
@@ -183,6 +277,7 @@ type CreatePayoutLineItemParams struct {
 	AmountGross          int32       `json:"amount_gross"`
 	AmountCommission     int32       `json:"amount_commission"`
 	AmountNet            int32       `json:"amount_net"`
+	CleanerID            pgtype.UUID `json:"cleaner_id"`
 }
 
 // ============================================
@@ -196,6 +291,7 @@ func (q *Queries) CreatePayoutLineItem(ctx context.Context, arg CreatePayoutLine
 		arg.AmountGross,
 		arg.AmountCommission,
 		arg.AmountNet,
+		arg.CleanerID,
 	)
 	var i PayoutLineItem
 	err := row.Scan(
@@ -207,6 +303,7 @@ func (q *Queries) CreatePayoutLineItem(ctx context.Context, arg CreatePayoutLine
 		&i.AmountCommission,
 		&i.AmountNet,
 		&i.CreatedAt,
+		&i.CleanerID,
 	)
 	return i, err
 }
@@ -265,6 +362,15 @@ func (q *Queries) DeletePaymentMethod(ctx context.Context, id pgtype.UUID) error
 	return err
 }
 
+const deletePayoutLineItems = `-- name: DeletePayoutLineItems :exec
+DELETE FROM payout_line_items WHERE payout_id = $1
+`
+
+func (q *Queries) DeletePayoutLineItems(ctx context.Context, payoutID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, deletePayoutLineItems, payoutID)
+	return err
+}
+
 const getCompanyStripeConnect = `-- name: GetCompanyStripeConnect :one
 
 SELECT stripe_connect_account_id, stripe_connect_onboarding_complete, stripe_connect_charges_enabled, stripe_connect_payouts_enabled
@@ -315,7 +421,7 @@ func (q *Queries) GetPaymentMethodByStripeID(ctx context.Context, stripePaymentM
 }
 
 const getPaymentTransactionByBookingID = `-- name: GetPaymentTransactionByBookingID :one
-SELECT id, booking_id, stripe_payment_intent_id, stripe_charge_id, amount_total, amount_company, amount_platform_fee, currency, status, failure_reason, refund_amount, stripe_refund_id, metadata, created_at, updated_at FROM payment_transactions WHERE booking_id = $1 ORDER BY created_at DESC LIMIT 1
+SELECT id, booking_id, stripe_payment_intent_id, stripe_charge_id, amount_total, amount_company, amount_platform_fee, currency, status, failure_reason, refund_amount, stripe_refund_id, metadata, created_at, updated_at, capture_method, amount_authorized, authorized_at FROM payment_transactions WHERE booking_id = $1 ORDER BY created_at DESC LIMIT 1
 `
 
 func (q *Queries) GetPaymentTransactionByBookingID(ctx context.Context, bookingID pgtype.UUID) (PaymentTransaction, error) {
@@ -337,12 +443,45 @@ func (q *Queries) GetPaymentTransactionByBookingID(ctx context.Context, bookingI
 		&i.Metadata,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.CaptureMethod,
+		&i.AmountAuthorized,
+		&i.AuthorizedAt,
+	)
+	return i, err
+}
+
+const getPaymentTransactionByID = `-- name: GetPaymentTransactionByID :one
+SELECT id, booking_id, stripe_payment_intent_id, stripe_charge_id, amount_total, amount_company, amount_platform_fee, currency, status, failure_reason, refund_amount, stripe_refund_id, metadata, created_at, updated_at, capture_method, amount_authorized, authorized_at FROM payment_transactions WHERE id = $1
+`
+
+func (q *Queries) GetPaymentTransactionByID(ctx context.Context, id pgtype.UUID) (PaymentTransaction, error) {
+	row := q.db.QueryRow(ctx, getPaymentTransactionByID, id)
+	var i PaymentTransaction
+	err := row.Scan(
+		&i.ID,
+		&i.BookingID,
+		&i.StripePaymentIntentID,
+		&i.StripeChargeID,
+		&i.AmountTotal,
+		&i.AmountCompany,
+		&i.AmountPlatformFee,
+		&i.Currency,
+		&i.Status,
+		&i.FailureReason,
+		&i.RefundAmount,
+		&i.StripeRefundID,
+		&i.Metadata,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CaptureMethod,
+		&i.AmountAuthorized,
+		&i.AuthorizedAt,
 	)
 	return i, err
 }
 
 const getPaymentTransactionByStripePI = `-- name: GetPaymentTransactionByStripePI :one
-SELECT id, booking_id, stripe_payment_intent_id, stripe_charge_id, amount_total, amount_company, amount_platform_fee, currency, status, failure_reason, refund_amount, stripe_refund_id, metadata, created_at, updated_at FROM payment_transactions WHERE stripe_payment_intent_id = $1
+SELECT id, booking_id, stripe_payment_intent_id, stripe_charge_id, amount_total, amount_company, amount_platform_fee, currency, status, failure_reason, refund_amount, stripe_refund_id, metadata, created_at, updated_at, capture_method, amount_authorized, authorized_at FROM payment_transactions WHERE stripe_payment_intent_id = $1
 `
 
 func (q *Queries) GetPaymentTransactionByStripePI(ctx context.Context, stripePaymentIntentID string) (PaymentTransaction, error) {
@@ -364,12 +503,15 @@ func (q *Queries) GetPaymentTransactionByStripePI(ctx context.Context, stripePay
 		&i.Metadata,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.CaptureMethod,
+		&i.AmountAuthorized,
+		&i.AuthorizedAt,
 	)
 	return i, err
 }
 
 const getPayoutByID = `-- name: GetPayoutByID :one
-SELECT id, company_id, stripe_transfer_id, stripe_payout_id, amount, currency, period_from, period_to, booking_count, status, paid_at, failure_reason, created_at, updated_at FROM company_payouts WHERE id = $1
+SELECT id, company_id, stripe_transfer_id, stripe_payout_id, amount, currency, period_from, period_to, booking_count, status, paid_at, failure_reason, created_at, updated_at, attempts, next_attempt_at FROM company_payouts WHERE id = $1
 `
 
 func (q *Queries) GetPayoutByID(ctx context.Context, id pgtype.UUID) (CompanyPayout, error) {
@@ -390,42 +532,12 @@ func (q *Queries) GetPayoutByID(ctx context.Context, id pgtype.UUID) (CompanyPay
 		&i.FailureReason,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Attempts,
+		&i.NextAttemptAt,
 	)
 	return i, err
 }
 
-const getPlatformRevenueReport = `-- name: GetPlatformRevenueReport :one
-
-SELECT
-  COALESCE(SUM(pt.amount_total), 0)::BIGINT as total_revenue,
-  COALESCE(SUM(pt.amount_platform_fee), 0)::BIGINT as total_commission,
-  COUNT(DISTINCT pt.id)::BIGINT as booking_count
-FROM payment_transactions pt
-WHERE pt.status = 'succeeded'
-  AND pt.created_at >= $1 AND pt.created_at <= $2
-`
-
-type GetPlatformRevenueReportParams struct {
-	CreatedAt   pgtype.Timestamptz `json:"created_at"`
-	CreatedAt_2 pgtype.Timestamptz `json:"created_at_2"`
-}
-
-type GetPlatformRevenueReportRow struct {
-	TotalRevenue    int64 `json:"total_revenue"`
-	TotalCommission int64 `json:"total_commission"`
-	BookingCount    int64 `json:"booking_count"`
-}
-
-// ============================================
-// PLATFORM REVENUE (Admin reporting)
-// ============================================
-func (q *Queries) GetPlatformRevenueReport(ctx context.Context, arg GetPlatformRevenueReportParams) (GetPlatformRevenueReportRow, error) {
-	row := q.db.QueryRow(ctx, getPlatformRevenueReport, arg.CreatedAt, arg.CreatedAt_2)
-	var i GetPlatformRevenueReportRow
-	err := row.Scan(&i.TotalRevenue, &i.TotalCommission, &i.BookingCount)
-	return i, err
-}
-
 const getRefundRequestByBookingID = `-- name: GetRefundRequestByBookingID :one
 SELECT id, booking_id, payment_transaction_id, requested_by_user_id, approved_by_user_id, amount, reason, status, stripe_refund_id, processed_at, created_at, updated_at FROM refund_requests WHERE booking_id = $1 ORDER BY created_at DESC LIMIT 1
 `
@@ -474,49 +586,30 @@ func (q *Queries) GetRefundRequestByID(ctx context.Context, id pgtype.UUID) (Ref
 	return i, err
 }
 
-const getTotalPayoutsInPeriod = `-- name: GetTotalPayoutsInPeriod :one
-SELECT
-  COALESCE(SUM(CASE WHEN status = 'paid' THEN amount ELSE 0 END), 0)::BIGINT as total_paid,
-  COALESCE(SUM(CASE WHEN status = 'pending' THEN amount ELSE 0 END), 0)::BIGINT as total_pending
-FROM company_payouts
-WHERE created_at >= $1 AND created_at <= $2
+const getRefundRequestByStripeRefundID = `-- name: GetRefundRequestByStripeRefundID :one
+SELECT id, booking_id, payment_transaction_id, requested_by_user_id, approved_by_user_id, amount, reason, status, stripe_refund_id, processed_at, created_at, updated_at FROM refund_requests WHERE stripe_refund_id = $1
 `
 
-type GetTotalPayoutsInPeriodParams struct {
-	CreatedAt   pgtype.Timestamptz `json:"created_at"`
-	CreatedAt_2 pgtype.Timestamptz `json:"created_at_2"`
-}
-
-type GetTotalPayoutsInPeriodRow struct {
-	TotalPaid    int64 `json:"total_paid"`
-	TotalPending int64 `json:"total_pending"`
-}
-
-func (q *Queries) GetTotalPayoutsInPeriod(ctx context.Context, arg GetTotalPayoutsInPeriodParams) (GetTotalPayoutsInPeriodRow, error) {
-	row := q.db.QueryRow(ctx, getTotalPayoutsInPeriod, arg.CreatedAt, arg.CreatedAt_2)
-	var i GetTotalPayoutsInPeriodRow
-	err := row.Scan(&i.TotalPaid, &i.TotalPending)
+func (q *Queries) GetRefundRequestByStripeRefundID(ctx context.Context, stripeRefundID pgtype.Text) (RefundRequest, error) {
+	row := q.db.QueryRow(ctx, getRefundRequestByStripeRefundID, stripeRefundID)
+	var i RefundRequest
+	err := row.Scan(
+		&i.ID,
+		&i.BookingID,
+		&i.PaymentTransactionID,
+		&i.RequestedByUserID,
+		&i.ApprovedByUserID,
+		&i.Amount,
+		&i.Reason,
+		&i.Status,
+		&i.StripeRefundID,
+		&i.ProcessedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
 	return i, err
 }
 
-const getTotalRefundsInPeriod = `-- name: GetTotalRefundsInPeriod :one
-SELECT COALESCE(SUM(amount), 0)::BIGINT as total_refunds
-FROM refund_requests
-WHERE status = 'processed' AND processed_at >= $1 AND processed_at <= $2
-`
-
-type GetTotalRefundsInPeriodParams struct {
-	ProcessedAt   pgtype.Timestamptz `json:"processed_at"`
-	ProcessedAt_2 pgtype.Timestamptz `json:"processed_at_2"`
-}
-
-func (q *Queries) GetTotalRefundsInPeriod(ctx context.Context, arg GetTotalRefundsInPeriodParams) (int64, error) {
-	row := q.db.QueryRow(ctx, getTotalRefundsInPeriod, arg.ProcessedAt, arg.ProcessedAt_2)
-	var total_refunds int64
-	err := row.Scan(&total_refunds)
-	return total_refunds, err
-}
-
 const getUserStripeCustomerID = `-- name: GetUserStripeCustomerID :one
 
 SELECT stripe_customer_id FROM users WHERE id = $1
@@ -532,8 +625,54 @@ func (q *Queries) GetUserStripeCustomerID(ctx context.Context, id pgtype.UUID) (
 	return stripe_customer_id, err
 }
 
+const holdPayoutsForTransaction = `-- name: HoldPayoutsForTransaction :many
+UPDATE company_payouts SET status = 'held', next_attempt_at = NULL, updated_at = NOW()
+WHERE status IN ('pending', 'failed')
+  AND id IN (SELECT payout_id FROM payout_line_items WHERE payment_transaction_id = $1)
+RETURNING id, company_id, stripe_transfer_id, stripe_payout_id, amount, currency, period_from, period_to, booking_count, status, paid_at, failure_reason, created_at, updated_at, attempts, next_attempt_at
+`
+
+// HoldPayoutsForTransaction puts the unexecuted payouts that contain a
+// disputed transaction on hold.
+func (q *Queries) HoldPayoutsForTransaction(ctx context.Context, paymentTransactionID pgtype.UUID) ([]CompanyPayout, error) {
+	rows, err := q.db.Query(ctx, holdPayoutsForTransaction, paymentTransactionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CompanyPayout
+	for rows.Next() {
+		var i CompanyPayout
+		if err := rows.Scan(
+			&i.ID,
+			&i.CompanyID,
+			&i.StripeTransferID,
+			&i.StripePayoutID,
+			&i.Amount,
+			&i.Currency,
+			&i.PeriodFrom,
+			&i.PeriodTo,
+			&i.BookingCount,
+			&i.Status,
+			&i.PaidAt,
+			&i.FailureReason,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Attempts,
+			&i.NextAttemptAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listAllPaymentTransactions = `-- name: ListAllPaymentTransactions :many
-SELECT id, booking_id, stripe_payment_intent_id, stripe_charge_id, amount_total, amount_company, amount_platform_fee, currency, status, failure_reason, refund_amount, stripe_refund_id, metadata, created_at, updated_at FROM payment_transactions ORDER BY created_at DESC LIMIT $1 OFFSET $2
+SELECT id, booking_id, stripe_payment_intent_id, stripe_charge_id, amount_total, amount_company, amount_platform_fee, currency, status, failure_reason, refund_amount, stripe_refund_id, metadata, created_at, updated_at, capture_method, amount_authorized, authorized_at FROM payment_transactions ORDER BY created_at DESC LIMIT $1 OFFSET $2
 `
 
 type ListAllPaymentTransactionsParams struct {
@@ -566,6 +705,9 @@ func (q *Queries) ListAllPaymentTransactions(ctx context.Context, arg ListAllPay
 			&i.Metadata,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.CaptureMethod,
+			&i.AmountAuthorized,
+			&i.AuthorizedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -578,7 +720,7 @@ func (q *Queries) ListAllPaymentTransactions(ctx context.Context, arg ListAllPay
 }
 
 const listAllPayouts = `-- name: ListAllPayouts :many
-SELECT id, company_id, stripe_transfer_id, stripe_payout_id, amount, currency, period_from, period_to, booking_count, status, paid_at, failure_reason, created_at, updated_at FROM company_payouts ORDER BY created_at DESC LIMIT $1 OFFSET $2
+SELECT id, company_id, stripe_transfer_id, stripe_payout_id, amount, currency, period_from, period_to, booking_count, status, paid_at, failure_reason, created_at, updated_at, attempts, next_attempt_at FROM company_payouts ORDER BY created_at DESC LIMIT $1 OFFSET $2
 `
 
 type ListAllPayoutsParams struct {
@@ -610,6 +752,8 @@ func (q *Queries) ListAllPayouts(ctx context.Context, arg ListAllPayoutsParams)
 			&i.FailureReason,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Attempts,
+			&i.NextAttemptAt,
 		); err != nil {
 			return nil, err
 		}
@@ -663,9 +807,121 @@ func (q *Queries) ListAllRefundRequests(ctx context.Context, arg ListAllRefundRe
 	return items, nil
 }
 
+const listCompaniesForPayout = `-- name: ListCompaniesForPayout :many
+SELECT id, admin_user_id, company_name, cui, company_type, legal_representative, contact_email, contact_phone, address, city, county, description, logo_url, status, rejection_reason, max_service_radius_km, rating_avg, total_jobs_completed, approved_at, created_at, updated_at, claim_token, stripe_connect_account_id, stripe_connect_onboarding_complete, stripe_connect_charges_enabled, stripe_connect_payouts_enabled, works_on_holidays, holiday_surcharge_pct, is_vat_payer, vat_checked_at FROM companies
+WHERE status = 'approved'
+  AND stripe_connect_account_id IS NOT NULL
+  AND stripe_connect_payouts_enabled = TRUE
+ORDER BY created_at
+`
+
+func (q *Queries) ListCompaniesForPayout(ctx context.Context) ([]Company, error) {
+	rows, err := q.db.Query(ctx, listCompaniesForPayout)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Company
+	for rows.Next() {
+		var i Company
+		if err := rows.Scan(
+			&i.ID,
+			&i.AdminUserID,
+			&i.CompanyName,
+			&i.Cui,
+			&i.CompanyType,
+			&i.LegalRepresentative,
+			&i.ContactEmail,
+			&i.ContactPhone,
+			&i.Address,
+			&i.City,
+			&i.County,
+			&i.Description,
+			&i.LogoUrl,
+			&i.Status,
+			&i.RejectionReason,
+			&i.MaxServiceRadiusKm,
+			&i.RatingAvg,
+			&i.TotalJobsCompleted,
+			&i.ApprovedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ClaimToken,
+			&i.StripeConnectAccountID,
+			&i.StripeConnectOnboardingComplete,
+			&i.StripeConnectChargesEnabled,
+			&i.StripeConnectPayoutsEnabled,
+			&i.WorksOnHolidays,
+			&i.HolidaySurchargePct,
+			&i.IsVatPayer,
+			&i.VatCheckedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listExpiringAuthorizations = `-- name: ListExpiringAuthorizations :many
+SELECT pt.id, pt.booking_id, pt.stripe_payment_intent_id, pt.stripe_charge_id, pt.amount_total, pt.amount_company, pt.amount_platform_fee, pt.currency, pt.status, pt.failure_reason, pt.refund_amount, pt.stripe_refund_id, pt.metadata, pt.created_at, pt.updated_at, pt.capture_method, pt.amount_authorized, pt.authorized_at FROM payment_transactions pt
+JOIN bookings b ON b.id = pt.booking_id AND b.stripe_payment_intent_id = pt.stripe_payment_intent_id
+WHERE pt.status = 'authorized'
+  AND pt.authorized_at < NOW() - INTERVAL '6 days'
+  AND b.scheduled_date >= (pt.authorized_at + INTERVAL '7 days')::date
+  AND b.status IN ('pending', 'assigned', 'confirmed')
+  AND b.payment_status = 'authorized'
+ORDER BY pt.authorized_at
+LIMIT $1
+`
+
+// ListExpiringAuthorizations returns the current authorization holds of active
+// bookings that will expire (after 7 days) before the booking takes place.
+func (q *Queries) ListExpiringAuthorizations(ctx context.Context, limit int32) ([]PaymentTransaction, error) {
+	rows, err := q.db.Query(ctx, listExpiringAuthorizations, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PaymentTransaction
+	for rows.Next() {
+		var i PaymentTransaction
+		if err := rows.Scan(
+			&i.ID,
+			&i.BookingID,
+			&i.StripePaymentIntentID,
+			&i.StripeChargeID,
+			&i.AmountTotal,
+			&i.AmountCompany,
+			&i.AmountPlatformFee,
+			&i.Currency,
+			&i.Status,
+			&i.FailureReason,
+			&i.RefundAmount,
+			&i.StripeRefundID,
+			&i.Metadata,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.CaptureMethod,
+			&i.AmountAuthorized,
+			&i.AuthorizedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listPaymentHistoryByUser = `-- name: ListPaymentHistoryByUser :many
 
-SELECT pt.id, pt.booking_id, pt.stripe_payment_intent_id, pt.stripe_charge_id, pt.amount_total, pt.amount_company, pt.amount_platform_fee, pt.currency, pt.status, pt.failure_reason, pt.refund_amount, pt.stripe_refund_id, pt.metadata, pt.created_at, pt.updated_at FROM payment_transactions pt
+SELECT pt.id, pt.booking_id, pt.stripe_payment_intent_id, pt.stripe_charge_id, pt.amount_total, pt.amount_company, pt.amount_platform_fee, pt.currency, pt.status, pt.failure_reason, pt.refund_amount, pt.stripe_refund_id, pt.metadata, pt.created_at, pt.updated_at, pt.capture_method, pt.amount_authorized, pt.authorized_at FROM payment_transactions pt
 JOIN bookings b ON b.id = pt.booking_id
 WHERE b.client_user_id = $1
 ORDER BY pt.created_at DESC
@@ -706,6 +962,9 @@ func (q *Queries) ListPaymentHistoryByUser(ctx context.Context, arg ListPaymentH
 			&i.Metadata,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.CaptureMethod,
+			&i.AmountAuthorized,
+			&i.AuthorizedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -756,7 +1015,7 @@ func (q *Queries) ListPaymentMethodsByUser(ctx context.Context, userID pgtype.UU
 }
 
 const listPaymentTransactionsByBooking = `-- name: ListPaymentTransactionsByBooking :many
-SELECT id, booking_id, stripe_payment_intent_id, stripe_charge_id, amount_total, amount_company, amount_platform_fee, currency, status, failure_reason, refund_amount, stripe_refund_id, metadata, created_at, updated_at FROM payment_transactions WHERE booking_id = $1 ORDER BY created_at DESC
+SELECT id, booking_id, stripe_payment_intent_id, stripe_charge_id, amount_total, amount_company, amount_platform_fee, currency, status, failure_reason, refund_amount, stripe_refund_id, metadata, created_at, updated_at, capture_method, amount_authorized, authorized_at FROM payment_transactions WHERE booking_id = $1 ORDER BY created_at DESC
 `
 
 func (q *Queries) ListPaymentTransactionsByBooking(ctx context.Context, bookingID pgtype.UUID) ([]PaymentTransaction, error) {
@@ -784,6 +1043,9 @@ func (q *Queries) ListPaymentTransactionsByBooking(ctx context.Context, bookingI
 			&i.Metadata,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.CaptureMethod,
+			&i.AmountAuthorized,
+			&i.AuthorizedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -796,7 +1058,7 @@ func (q *Queries) ListPaymentTransactionsByBooking(ctx context.Context, bookingI
 }
 
 const listPaymentTransactionsByStatus = `-- name: ListPaymentTransactionsByStatus :many
-SELECT id, booking_id, stripe_payment_intent_id, stripe_charge_id, amount_total, amount_company, amount_platform_fee, currency, status, failure_reason, refund_amount, stripe_refund_id, metadata, created_at, updated_at FROM payment_transactions WHERE status = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3
+SELECT id, booking_id, stripe_payment_intent_id, stripe_charge_id, amount_total, amount_company, amount_platform_fee, currency, status, failure_reason, refund_amount, stripe_refund_id, metadata, created_at, updated_at, capture_method, amount_authorized, authorized_at FROM payment_transactions WHERE status = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3
 `
 
 type ListPaymentTransactionsByStatusParams struct {
@@ -830,6 +1092,9 @@ func (q *Queries) ListPaymentTransactionsByStatus(ctx context.Context, arg ListP
 			&i.Metadata,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.CaptureMethod,
+			&i.AmountAuthorized,
+			&i.AuthorizedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -842,7 +1107,7 @@ func (q *Queries) ListPaymentTransactionsByStatus(ctx context.Context, arg ListP
 }
 
 const listPayoutLineItems = `-- name: ListPayoutLineItems :many
-SELECT id, payout_id, payment_transaction_id, booking_id, amount_gross, amount_commission, amount_net, created_at FROM payout_line_items WHERE payout_id = $1 ORDER BY created_at
+SELECT id, payout_id, payment_transaction_id, booking_id, amount_gross, amount_commission, amount_net, created_at, cleaner_id FROM payout_line_items WHERE payout_id = $1 ORDER BY created_at
 `
 
 func (q *Queries) ListPayoutLineItems(ctx context.Context, payoutID pgtype.UUID) ([]PayoutLineItem, error) {
@@ -863,6 +1128,7 @@ func (q *Queries) ListPayoutLineItems(ctx context.Context, payoutID pgtype.UUID)
 			&i.AmountCommission,
 			&i.AmountNet,
 			&i.CreatedAt,
+			&i.CleanerID,
 		); err != nil {
 			return nil, err
 		}
@@ -875,7 +1141,7 @@ func (q *Queries) ListPayoutLineItems(ctx context.Context, payoutID pgtype.UUID)
 }
 
 const listPayoutsByCompany = `-- name: ListPayoutsByCompany :many
-SELECT id, company_id, stripe_transfer_id, stripe_payout_id, amount, currency, period_from, period_to, booking_count, status, paid_at, failure_reason, created_at, updated_at FROM company_payouts WHERE company_id = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3
+SELECT id, company_id, stripe_transfer_id, stripe_payout_id, amount, currency, period_from, period_to, booking_count, status, paid_at, failure_reason, created_at, updated_at, attempts, next_attempt_at FROM company_payouts WHERE company_id = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3
 `
 
 type ListPayoutsByCompanyParams struct {
@@ -908,6 +1174,8 @@ func (q *Queries) ListPayoutsByCompany(ctx context.Context, arg ListPayoutsByCom
 			&i.FailureReason,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Attempts,
+			&i.NextAttemptAt,
 		); err != nil {
 			return nil, err
 		}
@@ -920,7 +1188,7 @@ func (q *Queries) ListPayoutsByCompany(ctx context.Context, arg ListPayoutsByCom
 }
 
 const listPayoutsByCompanyAndStatus = `-- name: ListPayoutsByCompanyAndStatus :many
-SELECT id, company_id, stripe_transfer_id, stripe_payout_id, amount, currency, period_from, period_to, booking_count, status, paid_at, failure_reason, created_at, updated_at FROM company_payouts WHERE company_id = $1 AND status = $2 ORDER BY created_at DESC LIMIT $3 OFFSET $4
+SELECT id, company_id, stripe_transfer_id, stripe_payout_id, amount, currency, period_from, period_to, booking_count, status, paid_at, failure_reason, created_at, updated_at, attempts, next_attempt_at FROM company_payouts WHERE company_id = $1 AND status = $2 ORDER BY created_at DESC LIMIT $3 OFFSET $4
 `
 
 type ListPayoutsByCompanyAndStatusParams struct {
@@ -959,6 +1227,8 @@ func (q *Queries) ListPayoutsByCompanyAndStatus(ctx context.Context, arg ListPay
 			&i.FailureReason,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Attempts,
+			&i.NextAttemptAt,
 		); err != nil {
 			return nil, err
 		}
@@ -971,7 +1241,7 @@ func (q *Queries) ListPayoutsByCompanyAndStatus(ctx context.Context, arg ListPay
 }
 
 const listPayoutsByStatus = `-- name: ListPayoutsByStatus :many
-SELECT id, company_id, stripe_transfer_id, stripe_payout_id, amount, currency, period_from, period_to, booking_count, status, paid_at, failure_reason, created_at, updated_at FROM company_payouts WHERE status = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3
+SELECT id, company_id, stripe_transfer_id, stripe_payout_id, amount, currency, period_from, period_to, booking_count, status, paid_at, failure_reason, created_at, updated_at, attempts, next_attempt_at FROM company_payouts WHERE status = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3
 `
 
 type ListPayoutsByStatusParams struct {
@@ -1004,6 +1274,8 @@ func (q *Queries) ListPayoutsByStatus(ctx context.Context, arg ListPayoutsByStat
 			&i.FailureReason,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Attempts,
+			&i.NextAttemptAt,
 		); err != nil {
 			return nil, err
 		}
@@ -1015,38 +1287,42 @@ func (q *Queries) ListPayoutsByStatus(ctx context.Context, arg ListPayoutsByStat
 	return items, nil
 }
 
-const listRefundRequestsByStatus = `-- name: ListRefundRequestsByStatus :many
-SELECT id, booking_id, payment_transaction_id, requested_by_user_id, approved_by_user_id, amount, reason, status, stripe_refund_id, processed_at, created_at, updated_at FROM refund_requests WHERE status = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3
+const listPayoutsDueForRetry = `-- name: ListPayoutsDueForRetry :many
+SELECT id, company_id, stripe_transfer_id, stripe_payout_id, amount, currency, period_from, period_to, booking_count, status, paid_at, failure_reason, created_at, updated_at, attempts, next_attempt_at FROM company_payouts
+WHERE (status = 'failed' AND next_attempt_at <= NOW())
+   OR (status = 'pending' AND created_at < NOW() - INTERVAL '10 minutes')
+ORDER BY created_at
+LIMIT $1
 `
 
-type ListRefundRequestsByStatusParams struct {
-	Status RefundStatus `json:"status"`
-	Limit  int32        `json:"limit"`
-	Offset int32        `json:"offset"`
-}
-
-func (q *Queries) ListRefundRequestsByStatus(ctx context.Context, arg ListRefundRequestsByStatusParams) ([]RefundRequest, error) {
-	rows, err := q.db.Query(ctx, listRefundRequestsByStatus, arg.Status, arg.Limit, arg.Offset)
+// ListPayoutsDueForRetry returns failed payouts whose next attempt is due and
+// pending payouts that were never executed.
+func (q *Queries) ListPayoutsDueForRetry(ctx context.Context, limit int32) ([]CompanyPayout, error) {
+	rows, err := q.db.Query(ctx, listPayoutsDueForRetry, limit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []RefundRequest
+	var items []CompanyPayout
 	for rows.Next() {
-		var i RefundRequest
+		var i CompanyPayout
 		if err := rows.Scan(
 			&i.ID,
-			&i.BookingID,
-			&i.PaymentTransactionID,
-			&i.RequestedByUserID,
-			&i.ApprovedByUserID,
+			&i.CompanyID,
+			&i.StripeTransferID,
+			&i.StripePayoutID,
 			&i.Amount,
-			&i.Reason,
+			&i.Currency,
+			&i.PeriodFrom,
+			&i.PeriodTo,
+			&i.BookingCount,
 			&i.Status,
-			&i.StripeRefundID,
-			&i.ProcessedAt,
+			&i.PaidAt,
+			&i.FailureReason,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Attempts,
+			&i.NextAttemptAt,
 		); err != nil {
 			return nil, err
 		}
@@ -1058,15 +1334,171 @@ func (q *Queries) ListRefundRequestsByStatus(ctx context.Context, arg ListRefund
 	return items, nil
 }
 
-const listUnpaidCompanyTransactions = `-- name: ListUnpaidCompanyTransactions :many
+const listPayoutsForReconciliation = `-- name: ListPayoutsForReconciliation :many
+SELECT id, company_id, stripe_transfer_id, stripe_payout_id, amount, currency, period_from, period_to, booking_count, status, paid_at, failure_reason, created_at, updated_at, attempts, next_attempt_at FROM company_payouts
+WHERE (status = 'failed' AND next_attempt_at IS NULL)
+   OR (status = 'processing' AND updated_at < NOW() - INTERVAL '3 days')
+ORDER BY updated_at
+`
 
-SELECT pt.id, pt.booking_id, pt.stripe_payment_intent_id, pt.stripe_charge_id, pt.amount_total, pt.amount_company, pt.amount_platform_fee, pt.currency, pt.status, pt.failure_reason, pt.refund_amount, pt.stripe_refund_id, pt.metadata, pt.created_at, pt.updated_at FROM payment_transactions pt
-JOIN bookings b ON b.id = pt.booking_id
-LEFT JOIN payout_line_items pli ON pli.payment_transaction_id = pt.id
-WHERE b.company_id = $1
-  AND pt.status = 'succeeded'
-  AND pli.id IS NULL
-  AND pt.created_at >= $2 AND pt.created_at <= $3
+// ListPayoutsForReconciliation returns payouts that need an admin: failed with
+// no retries left, or processing with no Stripe outcome for three days.
+func (q *Queries) ListPayoutsForReconciliation(ctx context.Context) ([]CompanyPayout, error) {
+	rows, err := q.db.Query(ctx, listPayoutsForReconciliation)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CompanyPayout
+	for rows.Next() {
+		var i CompanyPayout
+		if err := rows.Scan(
+			&i.ID,
+			&i.CompanyID,
+			&i.StripeTransferID,
+			&i.StripePayoutID,
+			&i.Amount,
+			&i.Currency,
+			&i.PeriodFrom,
+			&i.PeriodTo,
+			&i.BookingCount,
+			&i.Status,
+			&i.PaidAt,
+			&i.FailureReason,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Attempts,
+			&i.NextAttemptAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRefundRequestsByStatus = `-- name: ListRefundRequestsByStatus :many
+SELECT id, booking_id, payment_transaction_id, requested_by_user_id, approved_by_user_id, amount, reason, status, stripe_refund_id, processed_at, created_at, updated_at FROM refund_requests WHERE status = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3
+`
+
+type ListRefundRequestsByStatusParams struct {
+	Status RefundStatus `json:"status"`
+	Limit  int32        `json:"limit"`
+	Offset int32        `json:"offset"`
+}
+
+func (q *Queries) ListRefundRequestsByStatus(ctx context.Context, arg ListRefundRequestsByStatusParams) ([]RefundRequest, error) {
+	rows, err := q.db.Query(ctx, listRefundRequestsByStatus, arg.Status, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []RefundRequest
+	for rows.Next() {
+		var i RefundRequest
+		if err := rows.Scan(
+			&i.ID,
+			&i.BookingID,
+			&i.PaymentTransactionID,
+			&i.RequestedByUserID,
+			&i.ApprovedByUserID,
+			&i.Amount,
+			&i.Reason,
+			&i.Status,
+			&i.StripeRefundID,
+			&i.ProcessedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUncapturedCompletedBookings = `-- name: ListUncapturedCompletedBookings :many
+SELECT id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number, team_size, holiday_surcharge, pets_surcharge FROM bookings
+WHERE status = 'completed' AND payment_status = 'authorized'
+ORDER BY completed_at
+LIMIT $1
+`
+
+func (q *Queries) ListUncapturedCompletedBookings(ctx context.Context, limit int32) ([]Booking, error) {
+	rows, err := q.db.Query(ctx, listUncapturedCompletedBookings, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Booking
+	for rows.Next() {
+		var i Booking
+		if err := rows.Scan(
+			&i.ID,
+			&i.ReferenceCode,
+			&i.ClientUserID,
+			&i.CompanyID,
+			&i.CleanerID,
+			&i.AddressID,
+			&i.ServiceType,
+			&i.ScheduledDate,
+			&i.ScheduledStartTime,
+			&i.EstimatedDurationHours,
+			&i.PropertyType,
+			&i.NumRooms,
+			&i.NumBathrooms,
+			&i.AreaSqm,
+			&i.HasPets,
+			&i.SpecialInstructions,
+			&i.HourlyRate,
+			&i.EstimatedTotal,
+			&i.FinalTotal,
+			&i.PlatformCommissionPct,
+			&i.PlatformCommissionAmount,
+			&i.Status,
+			&i.StartedAt,
+			&i.CompletedAt,
+			&i.CancelledAt,
+			&i.CancellationReason,
+			&i.StripePaymentIntentID,
+			&i.PaymentStatus,
+			&i.PaidAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.RecurringGroupID,
+			&i.OccurrenceNumber,
+			&i.TeamSize,
+			&i.HolidaySurcharge,
+			&i.PetsSurcharge,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUnpaidCompanyTransactions = `-- name: ListUnpaidCompanyTransactions :many
+SELECT pt.id, pt.booking_id, pt.stripe_payment_intent_id, pt.stripe_charge_id, pt.amount_total, pt.amount_company, pt.amount_platform_fee, pt.currency, pt.status, pt.failure_reason, pt.refund_amount, pt.stripe_refund_id, pt.metadata, pt.created_at, pt.updated_at, pt.capture_method, pt.amount_authorized, pt.authorized_at FROM payment_transactions pt
+JOIN bookings b ON b.id = pt.booking_id
+LEFT JOIN payout_line_items pli ON pli.payment_transaction_id = pt.id
+WHERE b.company_id = $1
+  AND pt.status = 'succeeded'
+  AND pli.id IS NULL
+  AND pt.created_at >= $2 AND pt.created_at <= $3
+  AND NOT EXISTS (
+    SELECT 1 FROM payment_disputes d
+    WHERE d.payment_transaction_id = pt.id AND d.status NOT IN ('won', 'warning_closed')
+  )
 ORDER BY pt.created_at
 `
 
@@ -1104,6 +1536,9 @@ func (q *Queries) ListUnpaidCompanyTransactions(ctx context.Context, arg ListUnp
 			&i.Metadata,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.CaptureMethod,
+			&i.AmountAuthorized,
+			&i.AuthorizedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -1115,9 +1550,63 @@ func (q *Queries) ListUnpaidCompanyTransactions(ctx context.Context, arg ListUnp
 	return items, nil
 }
 
+const markBookingAuthorizedAndConfirmed = `-- name: MarkBookingAuthorizedAndConfirmed :one
+UPDATE bookings
+SET payment_status = 'authorized',
+    status = CASE WHEN status IN ('pending', 'assigned') THEN 'confirmed'::booking_status ELSE status END,
+    updated_at = NOW()
+WHERE id = $1 RETURNING id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number, team_size, holiday_surcharge, pets_surcharge
+`
+
+// MarkBookingAuthorizedAndConfirmed records the authorization hold on a booking
+// and auto-confirms it if still pending/assigned, like MarkBookingPaidAndConfirmed.
+func (q *Queries) MarkBookingAuthorizedAndConfirmed(ctx context.Context, id pgtype.UUID) (Booking, error) {
+	row := q.db.QueryRow(ctx, markBookingAuthorizedAndConfirmed, id)
+	var i Booking
+	err := row.Scan(
+		&i.ID,
+		&i.ReferenceCode,
+		&i.ClientUserID,
+		&i.CompanyID,
+		&i.CleanerID,
+		&i.AddressID,
+		&i.ServiceType,
+		&i.ScheduledDate,
+		&i.ScheduledStartTime,
+		&i.EstimatedDurationHours,
+		&i.PropertyType,
+		&i.NumRooms,
+		&i.NumBathrooms,
+		&i.AreaSqm,
+		&i.HasPets,
+		&i.SpecialInstructions,
+		&i.HourlyRate,
+		&i.EstimatedTotal,
+		&i.FinalTotal,
+		&i.PlatformCommissionPct,
+		&i.PlatformCommissionAmount,
+		&i.Status,
+		&i.StartedAt,
+		&i.CompletedAt,
+		&i.CancelledAt,
+		&i.CancellationReason,
+		&i.StripePaymentIntentID,
+		&i.PaymentStatus,
+		&i.PaidAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.RecurringGroupID,
+		&i.OccurrenceNumber,
+		&i.TeamSize,
+		&i.HolidaySurcharge,
+		&i.PetsSurcharge,
+	)
+	return i, err
+}
+
 const markBookingPaid = `-- name: MarkBookingPaid :one
 UPDATE bookings SET payment_status = 'paid', paid_at = NOW(), updated_at = NOW()
-WHERE id = $1 RETURNING id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number
+WHERE id = $1 RETURNING id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number, team_size, holiday_surcharge, pets_surcharge
 `
 
 func (q *Queries) MarkBookingPaid(ctx context.Context, id pgtype.UUID) (Booking, error) {
@@ -1157,20 +1646,24 @@ func (q *Queries) MarkBookingPaid(ctx context.Context, id pgtype.UUID) (Booking,
 		&i.UpdatedAt,
 		&i.RecurringGroupID,
 		&i.OccurrenceNumber,
+		&i.TeamSize,
+		&i.HolidaySurcharge,
+		&i.PetsSurcharge,
 	)
 	return i, err
 }
 
 const markBookingPaidAndConfirmed = `-- name: MarkBookingPaidAndConfirmed :one
 UPDATE bookings
-SET payment_status = 'paid', paid_at = NOW(),
+SET payment_status = 'paid', paid_at = COALESCE(paid_at, NOW()),
     status = CASE WHEN status IN ('pending', 'assigned') THEN 'confirmed'::booking_status ELSE status END,
     updated_at = NOW()
-WHERE id = $1 RETURNING id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number
+WHERE id = $1 RETURNING id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number, team_size, holiday_surcharge, pets_surcharge
 `
 
 // Atomically marks a booking as paid AND auto-confirms it if still pending/assigned.
-// Idempotent: if booking is already confirmed or later, status is left unchanged.
+// Idempotent: if booking is already confirmed or later, status is left unchanged,
+// and paid_at keeps the time of the first payment.
 func (q *Queries) MarkBookingPaidAndConfirmed(ctx context.Context, id pgtype.UUID) (Booking, error) {
 	row := q.db.QueryRow(ctx, markBookingPaidAndConfirmed, id)
 	var i Booking
@@ -1208,10 +1701,203 @@ func (q *Queries) MarkBookingPaidAndConfirmed(ctx context.Context, id pgtype.UUI
 		&i.UpdatedAt,
 		&i.RecurringGroupID,
 		&i.OccurrenceNumber,
+		&i.TeamSize,
+		&i.HolidaySurcharge,
+		&i.PetsSurcharge,
+	)
+	return i, err
+}
+
+const markPaymentTransactionAuthorized = `-- name: MarkPaymentTransactionAuthorized :one
+UPDATE payment_transactions
+SET status = 'authorized', amount_authorized = $2, stripe_charge_id = $3,
+    authorized_at = COALESCE(authorized_at, NOW()), updated_at = NOW()
+WHERE stripe_payment_intent_id = $1 AND status IN ('pending', 'requires_action', 'processing', 'authorized')
+RETURNING id, booking_id, stripe_payment_intent_id, stripe_charge_id, amount_total, amount_company, amount_platform_fee, currency, status, failure_reason, refund_amount, stripe_refund_id, metadata, created_at, updated_at, capture_method, amount_authorized, authorized_at
+`
+
+type MarkPaymentTransactionAuthorizedParams struct {
+	StripePaymentIntentID string      `json:"stripe_payment_intent_id"`
+	AmountAuthorized      pgtype.Int4 `json:"amount_authorized"`
+	StripeChargeID        pgtype.Text `json:"stripe_charge_id"`
+}
+
+// MarkPaymentTransactionAuthorized records an authorization hold. It returns no
+// rows once the hold has been captured or cancelled, so a late event cannot
+// reopen it.
+func (q *Queries) MarkPaymentTransactionAuthorized(ctx context.Context, arg MarkPaymentTransactionAuthorizedParams) (PaymentTransaction, error) {
+	row := q.db.QueryRow(ctx, markPaymentTransactionAuthorized, arg.StripePaymentIntentID, arg.AmountAuthorized, arg.StripeChargeID)
+	var i PaymentTransaction
+	err := row.Scan(
+		&i.ID,
+		&i.BookingID,
+		&i.StripePaymentIntentID,
+		&i.StripeChargeID,
+		&i.AmountTotal,
+		&i.AmountCompany,
+		&i.AmountPlatformFee,
+		&i.Currency,
+		&i.Status,
+		&i.FailureReason,
+		&i.RefundAmount,
+		&i.StripeRefundID,
+		&i.Metadata,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CaptureMethod,
+		&i.AmountAuthorized,
+		&i.AuthorizedAt,
 	)
 	return i, err
 }
 
+const markPaymentTransactionCancelled = `-- name: MarkPaymentTransactionCancelled :one
+UPDATE payment_transactions SET status = 'cancelled', updated_at = NOW()
+WHERE stripe_payment_intent_id = $1 AND status IN ('pending', 'requires_action', 'processing', 'authorized')
+RETURNING id, booking_id, stripe_payment_intent_id, stripe_charge_id, amount_total, amount_company, amount_platform_fee, currency, status, failure_reason, refund_amount, stripe_refund_id, metadata, created_at, updated_at, capture_method, amount_authorized, authorized_at
+`
+
+func (q *Queries) MarkPaymentTransactionCancelled(ctx context.Context, stripePaymentIntentID string) (PaymentTransaction, error) {
+	row := q.db.QueryRow(ctx, markPaymentTransactionCancelled, stripePaymentIntentID)
+	var i PaymentTransaction
+	err := row.Scan(
+		&i.ID,
+		&i.BookingID,
+		&i.StripePaymentIntentID,
+		&i.StripeChargeID,
+		&i.AmountTotal,
+		&i.AmountCompany,
+		&i.AmountPlatformFee,
+		&i.Currency,
+		&i.Status,
+		&i.FailureReason,
+		&i.RefundAmount,
+		&i.StripeRefundID,
+		&i.Metadata,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CaptureMethod,
+		&i.AmountAuthorized,
+		&i.AuthorizedAt,
+	)
+	return i, err
+}
+
+const markPayoutFailed = `-- name: MarkPayoutFailed :one
+UPDATE company_payouts SET status = 'failed', failure_reason = $2, next_attempt_at = $3, updated_at = NOW()
+WHERE id = $1 RETURNING id, company_id, stripe_transfer_id, stripe_payout_id, amount, currency, period_from, period_to, booking_count, status, paid_at, failure_reason, created_at, updated_at, attempts, next_attempt_at
+`
+
+type MarkPayoutFailedParams struct {
+	ID            pgtype.UUID        `json:"id"`
+	FailureReason pgtype.Text        `json:"failure_reason"`
+	NextAttemptAt pgtype.Timestamptz `json:"next_attempt_at"`
+}
+
+func (q *Queries) MarkPayoutFailed(ctx context.Context, arg MarkPayoutFailedParams) (CompanyPayout, error) {
+	row := q.db.QueryRow(ctx, markPayoutFailed, arg.ID, arg.FailureReason, arg.NextAttemptAt)
+	var i CompanyPayout
+	err := row.Scan(
+		&i.ID,
+		&i.CompanyID,
+		&i.StripeTransferID,
+		&i.StripePayoutID,
+		&i.Amount,
+		&i.Currency,
+		&i.PeriodFrom,
+		&i.PeriodTo,
+		&i.BookingCount,
+		&i.Status,
+		&i.PaidAt,
+		&i.FailureReason,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Attempts,
+		&i.NextAttemptAt,
+	)
+	return i, err
+}
+
+const markPayoutPaid = `-- name: MarkPayoutPaid :one
+UPDATE company_payouts SET status = 'paid', paid_at = NOW(), failure_reason = NULL, next_attempt_at = NULL, updated_at = NOW()
+WHERE id = $1 RETURNING id, company_id, stripe_transfer_id, stripe_payout_id, amount, currency, period_from, period_to, booking_count, status, paid_at, failure_reason, created_at, updated_at, attempts, next_attempt_at
+`
+
+func (q *Queries) MarkPayoutPaid(ctx context.Context, id pgtype.UUID) (CompanyPayout, error) {
+	row := q.db.QueryRow(ctx, markPayoutPaid, id)
+	var i CompanyPayout
+	err := row.Scan(
+		&i.ID,
+		&i.CompanyID,
+		&i.StripeTransferID,
+		&i.StripePayoutID,
+		&i.Amount,
+		&i.Currency,
+		&i.PeriodFrom,
+		&i.PeriodTo,
+		&i.BookingCount,
+		&i.Status,
+		&i.PaidAt,
+		&i.FailureReason,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Attempts,
+		&i.NextAttemptAt,
+	)
+	return i, err
+}
+
+const releaseHeldPayouts = `-- name: ReleaseHeldPayouts :many
+UPDATE company_payouts p SET status = 'pending', updated_at = NOW()
+WHERE p.status = 'held'
+  AND p.id IN (SELECT payout_id FROM payout_line_items WHERE payment_transaction_id = $1)
+  AND NOT EXISTS (
+    SELECT 1 FROM payout_line_items pli
+    JOIN payment_disputes d ON d.payment_transaction_id = pli.payment_transaction_id
+    WHERE pli.payout_id = p.id AND d.status NOT IN ('won', 'warning_closed')
+  )
+RETURNING id, company_id, stripe_transfer_id, stripe_payout_id, amount, currency, period_from, period_to, booking_count, status, paid_at, failure_reason, created_at, updated_at, attempts, next_attempt_at
+`
+
+// ReleaseHeldPayouts releases the held payouts that contain a transaction once
+// none of their transactions has a dispute that is open or lost.
+func (q *Queries) ReleaseHeldPayouts(ctx context.Context, paymentTransactionID pgtype.UUID) ([]CompanyPayout, error) {
+	rows, err := q.db.Query(ctx, releaseHeldPayouts, paymentTransactionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CompanyPayout
+	for rows.Next() {
+		var i CompanyPayout
+		if err := rows.Scan(
+			&i.ID,
+			&i.CompanyID,
+			&i.StripeTransferID,
+			&i.StripePayoutID,
+			&i.Amount,
+			&i.Currency,
+			&i.PeriodFrom,
+			&i.PeriodTo,
+			&i.BookingCount,
+			&i.Status,
+			&i.PaidAt,
+			&i.FailureReason,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Attempts,
+			&i.NextAttemptAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const setCompanyStripeConnect = `-- name: SetCompanyStripeConnect :exec
 UPDATE companies SET
   stripe_connect_account_id = $2,
@@ -1255,6 +1941,40 @@ func (q *Queries) SetDefaultPaymentMethod(ctx context.Context, arg SetDefaultPay
 	return err
 }
 
+const setPayoutStripePayoutID = `-- name: SetPayoutStripePayoutID :one
+UPDATE company_payouts SET stripe_payout_id = $2, updated_at = NOW()
+WHERE id = $1 RETURNING id, company_id, stripe_transfer_id, stripe_payout_id, amount, currency, period_from, period_to, booking_count, status, paid_at, failure_reason, created_at, updated_at, attempts, next_attempt_at
+`
+
+type SetPayoutStripePayoutIDParams struct {
+	ID             pgtype.UUID `json:"id"`
+	StripePayoutID pgtype.Text `json:"stripe_payout_id"`
+}
+
+func (q *Queries) SetPayoutStripePayoutID(ctx context.Context, arg SetPayoutStripePayoutIDParams) (CompanyPayout, error) {
+	row := q.db.QueryRow(ctx, setPayoutStripePayoutID, arg.ID, arg.StripePayoutID)
+	var i CompanyPayout
+	err := row.Scan(
+		&i.ID,
+		&i.CompanyID,
+		&i.StripeTransferID,
+		&i.StripePayoutID,
+		&i.Amount,
+		&i.Currency,
+		&i.PeriodFrom,
+		&i.PeriodTo,
+		&i.BookingCount,
+		&i.Status,
+		&i.PaidAt,
+		&i.FailureReason,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Attempts,
+		&i.NextAttemptAt,
+	)
+	return i, err
+}
+
 const setUserStripeCustomerID = `-- name: SetUserStripeCustomerID :exec
 UPDATE users SET stripe_customer_id = $2, updated_at = NOW() WHERE id = $1
 `
@@ -1269,51 +1989,10 @@ func (q *Queries) SetUserStripeCustomerID(ctx context.Context, arg SetUserStripe
 	return err
 }
 
-const sumCompanyEarnings = `-- name: SumCompanyEarnings :one
-
-SELECT
-  COALESCE(SUM(pt.amount_total), 0)::BIGINT as total_gross,
-  COALESCE(SUM(pt.amount_platform_fee), 0)::BIGINT as total_commission,
-  COALESCE(SUM(pt.amount_company), 0)::BIGINT as total_net,
-  COUNT(pt.id)::BIGINT as booking_count
-FROM payment_transactions pt
-JOIN bookings b ON b.id = pt.booking_id
-WHERE b.company_id = $1 AND pt.status = 'succeeded'
-  AND pt.created_at >= $2 AND pt.created_at <= $3
-`
-
-type SumCompanyEarningsParams struct {
-	CompanyID   pgtype.UUID        `json:"company_id"`
-	CreatedAt   pgtype.Timestamptz `json:"created_at"`
-	CreatedAt_2 pgtype.Timestamptz `json:"created_at_2"`
-}
-
-type SumCompanyEarningsRow struct {
-	TotalGross      int64 `json:"total_gross"`
-	TotalCommission int64 `json:"total_commission"`
-	TotalNet        int64 `json:"total_net"`
-	BookingCount    int64 `json:"booking_count"`
-}
-
-// ============================================
-// COMPANY EARNINGS (Reporting)
-// ============================================
-func (q *Queries) SumCompanyEarnings(ctx context.Context, arg SumCompanyEarningsParams) (SumCompanyEarningsRow, error) {
-	row := q.db.QueryRow(ctx, sumCompanyEarnings, arg.CompanyID, arg.CreatedAt, arg.CreatedAt_2)
-	var i SumCompanyEarningsRow
-	err := row.Scan(
-		&i.TotalGross,
-		&i.TotalCommission,
-		&i.TotalNet,
-		&i.BookingCount,
-	)
-	return i, err
-}
-
 const updateBookingPayment = `-- name: UpdateBookingPayment :one
 
 UPDATE bookings SET stripe_payment_intent_id = $2, payment_status = $3, updated_at = NOW()
-WHERE id = $1 RETURNING id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number
+WHERE id = $1 RETURNING id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number, team_size, holiday_surcharge, pets_surcharge
 `
 
 type UpdateBookingPaymentParams struct {
@@ -1362,13 +2041,62 @@ func (q *Queries) UpdateBookingPayment(ctx context.Context, arg UpdateBookingPay
 		&i.UpdatedAt,
 		&i.RecurringGroupID,
 		&i.OccurrenceNumber,
+		&i.TeamSize,
+		&i.HolidaySurcharge,
+		&i.PetsSurcharge,
+	)
+	return i, err
+}
+
+const updatePaymentTransactionCaptured = `-- name: UpdatePaymentTransactionCaptured :one
+UPDATE payment_transactions
+SET amount_total = $2, amount_company = $3, amount_platform_fee = $4, updated_at = NOW()
+WHERE stripe_payment_intent_id = $1 RETURNING id, booking_id, stripe_payment_intent_id, stripe_charge_id, amount_total, amount_company, amount_platform_fee, currency, status, failure_reason, refund_amount, stripe_refund_id, metadata, created_at, updated_at, capture_method, amount_authorized, authorized_at
+`
+
+type UpdatePaymentTransactionCapturedParams struct {
+	StripePaymentIntentID string `json:"stripe_payment_intent_id"`
+	AmountTotal           int32  `json:"amount_total"`
+	AmountCompany         int32  `json:"amount_company"`
+	AmountPlatformFee     int32  `json:"amount_platform_fee"`
+}
+
+func (q *Queries) UpdatePaymentTransactionCaptured(ctx context.Context, arg UpdatePaymentTransactionCapturedParams) (PaymentTransaction, error) {
+	row := q.db.QueryRow(ctx, updatePaymentTransactionCaptured,
+		arg.StripePaymentIntentID,
+		arg.AmountTotal,
+		arg.AmountCompany,
+		arg.AmountPlatformFee,
+	)
+	var i PaymentTransaction
+	err := row.Scan(
+		&i.ID,
+		&i.BookingID,
+		&i.StripePaymentIntentID,
+		&i.StripeChargeID,
+		&i.AmountTotal,
+		&i.AmountCompany,
+		&i.AmountPlatformFee,
+		&i.Currency,
+		&i.Status,
+		&i.FailureReason,
+		&i.RefundAmount,
+		&i.StripeRefundID,
+		&i.Metadata,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CaptureMethod,
+		&i.AmountAuthorized,
+		&i.AuthorizedAt,
 	)
 	return i, err
 }
 
 const updatePaymentTransactionFailed = `-- name: UpdatePaymentTransactionFailed :one
 UPDATE payment_transactions SET status = 'failed', failure_reason = $2, updated_at = NOW()
-WHERE stripe_payment_intent_id = $1 RETURNING id, booking_id, stripe_payment_intent_id, stripe_charge_id, amount_total, amount_company, amount_platform_fee, currency, status, failure_reason, refund_amount, stripe_refund_id, metadata, created_at, updated_at
+WHERE stripe_payment_intent_id = $1
+  AND status IN ('pending', 'requires_action', 'processing', 'failed')
+RETURNING id, booking_id, stripe_payment_intent_id, stripe_charge_id, amount_total, amount_company, amount_platform_fee, currency, status, failure_reason, refund_amount, stripe_refund_id, metadata, created_at, updated_at, capture_method, amount_authorized, authorized_at
 `
 
 type UpdatePaymentTransactionFailedParams struct {
@@ -1376,6 +2104,9 @@ type UpdatePaymentTransactionFailedParams struct {
 	FailureReason         pgtype.Text `json:"failure_reason"`
 }
 
+// UpdatePaymentTransactionFailed records a failed payment attempt. It returns
+// no rows once the payment has been authorized, has succeeded or has been
+// cancelled, so a late or replayed event cannot fail it.
 func (q *Queries) UpdatePaymentTransactionFailed(ctx context.Context, arg UpdatePaymentTransactionFailedParams) (PaymentTransaction, error) {
 	row := q.db.QueryRow(ctx, updatePaymentTransactionFailed, arg.StripePaymentIntentID, arg.FailureReason)
 	var i PaymentTransaction
@@ -1395,13 +2126,18 @@ func (q *Queries) UpdatePaymentTransactionFailed(ctx context.Context, arg Update
 		&i.Metadata,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.CaptureMethod,
+		&i.AmountAuthorized,
+		&i.AuthorizedAt,
 	)
 	return i, err
 }
 
 const updatePaymentTransactionRefund = `-- name: UpdatePaymentTransactionRefund :one
 UPDATE payment_transactions SET status = $2, refund_amount = $3, stripe_refund_id = $4, updated_at = NOW()
-WHERE stripe_payment_intent_id = $1 RETURNING id, booking_id, stripe_payment_intent_id, stripe_charge_id, amount_total, amount_company, amount_platform_fee, currency, status, failure_reason, refund_amount, stripe_refund_id, metadata, created_at, updated_at
+WHERE stripe_payment_intent_id = $1
+  AND (status IN ('succeeded', 'partially_refunded') OR (status = 'refunded' AND $2 = 'refunded'))
+RETURNING id, booking_id, stripe_payment_intent_id, stripe_charge_id, amount_total, amount_company, amount_platform_fee, currency, status, failure_reason, refund_amount, stripe_refund_id, metadata, created_at, updated_at, capture_method, amount_authorized, authorized_at
 `
 
 type UpdatePaymentTransactionRefundParams struct {
@@ -1411,6 +2147,9 @@ type UpdatePaymentTransactionRefundParams struct {
 	StripeRefundID        pgtype.Text              `json:"stripe_refund_id"`
 }
 
+// UpdatePaymentTransactionRefund records the latest refund of a payment. It
+// returns no rows for a partial refund of a fully refunded payment, so a
+// replayed event cannot move it back.
 func (q *Queries) UpdatePaymentTransactionRefund(ctx context.Context, arg UpdatePaymentTransactionRefundParams) (PaymentTransaction, error) {
 	row := q.db.QueryRow(ctx, updatePaymentTransactionRefund,
 		arg.StripePaymentIntentID,
@@ -1435,13 +2174,18 @@ func (q *Queries) UpdatePaymentTransactionRefund(ctx context.Context, arg Update
 		&i.Metadata,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.CaptureMethod,
+		&i.AmountAuthorized,
+		&i.AuthorizedAt,
 	)
 	return i, err
 }
 
 const updatePaymentTransactionStatus = `-- name: UpdatePaymentTransactionStatus :one
 UPDATE payment_transactions SET status = $2, stripe_charge_id = $3, updated_at = NOW()
-WHERE stripe_payment_intent_id = $1 RETURNING id, booking_id, stripe_payment_intent_id, stripe_charge_id, amount_total, amount_company, amount_platform_fee, currency, status, failure_reason, refund_amount, stripe_refund_id, metadata, created_at, updated_at
+WHERE stripe_payment_intent_id = $1
+  AND status IN ('pending', 'requires_action', 'processing', 'authorized', 'failed', 'succeeded')
+RETURNING id, booking_id, stripe_payment_intent_id, stripe_charge_id, amount_total, amount_company, amount_platform_fee, currency, status, failure_reason, refund_amount, stripe_refund_id, metadata, created_at, updated_at, capture_method, amount_authorized, authorized_at
 `
 
 type UpdatePaymentTransactionStatusParams struct {
@@ -1450,6 +2194,9 @@ type UpdatePaymentTransactionStatusParams struct {
 	StripeChargeID        pgtype.Text              `json:"stripe_charge_id"`
 }
 
+// UpdatePaymentTransactionStatus records a succeeded payment. It returns no
+// rows once the payment has been refunded or cancelled, so a replayed event
+// cannot move it back.
 func (q *Queries) UpdatePaymentTransactionStatus(ctx context.Context, arg UpdatePaymentTransactionStatusParams) (PaymentTransaction, error) {
 	row := q.db.QueryRow(ctx, updatePaymentTransactionStatus, arg.StripePaymentIntentID, arg.Status, arg.StripeChargeID)
 	var i PaymentTransaction
@@ -1469,13 +2216,16 @@ func (q *Queries) UpdatePaymentTransactionStatus(ctx context.Context, arg Update
 		&i.Metadata,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.CaptureMethod,
+		&i.AmountAuthorized,
+		&i.AuthorizedAt,
 	)
 	return i, err
 }
 
 const updatePayoutStatus = `-- name: UpdatePayoutStatus :one
 UPDATE company_payouts SET status = $2, stripe_transfer_id = $3, paid_at = CASE WHEN $2 = 'paid' THEN NOW() ELSE paid_at END, updated_at = NOW()
-WHERE id = $1 RETURNING id, company_id, stripe_transfer_id, stripe_payout_id, amount, currency, period_from, period_to, booking_count, status, paid_at, failure_reason, created_at, updated_at
+WHERE id = $1 RETURNING id, company_id, stripe_transfer_id, stripe_payout_id, amount, currency, period_from, period_to, booking_count, status, paid_at, failure_reason, created_at, updated_at, attempts, next_attempt_at
 `
 
 type UpdatePayoutStatusParams struct {
@@ -1502,6 +2252,8 @@ func (q *Queries) UpdatePayoutStatus(ctx context.Context, arg UpdatePayoutStatus
 		&i.FailureReason,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Attempts,
+		&i.NextAttemptAt,
 	)
 	return i, err
 }
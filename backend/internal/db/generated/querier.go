@@ -12,19 +12,55 @@ import (
 
 type Querier interface {
 	ActivateCleanerStatus(ctx context.Context, id pgtype.UUID) (Cleaner, error)
+	AddBookingTeamMember(ctx context.Context, arg AddBookingTeamMemberParams) (BookingTeamMember, error)
 	AddChatParticipant(ctx context.Context, arg AddChatParticipantParams) (ChatParticipant, error)
+	AddCleanerSkill(ctx context.Context, arg AddCleanerSkillParams) error
 	AdminUpdateCompanyProfile(ctx context.Context, arg AdminUpdateCompanyProfileParams) (Company, error)
 	AdminUpdateUserProfile(ctx context.Context, arg AdminUpdateUserProfileParams) (User, error)
+	// AllocateInvoiceNumber takes the next number of the active series of a
+	// document type, starting the series again in a new year when it resets
+	// yearly. Run it in the transaction that inserts the invoice: the series row
+	// stays locked until the transaction ends and a rollback returns the number.
+	AllocateInvoiceNumber(ctx context.Context, arg AllocateInvoiceNumberParams) (AllocateInvoiceNumberRow, error)
 	ApproveCompany(ctx context.Context, id pgtype.UUID) (Company, error)
 	AssignCleanerToBooking(ctx context.Context, arg AssignCleanerToBookingParams) (Booking, error)
+	// CancelBookingForFailedPayment cancels a booking whose payment failed. It
+	// leaves alone a booking that has started, is already cancelled or is now paid
+	// with another PaymentIntent.
+	CancelBookingForFailedPayment(ctx context.Context, arg CancelBookingForFailedPaymentParams) (int64, error)
 	CancelBookingWithReason(ctx context.Context, arg CancelBookingWithReasonParams) (Booking, error)
 	CancelFutureOccurrences(ctx context.Context, arg CancelFutureOccurrencesParams) error
+	CancelPayout(ctx context.Context, id pgtype.UUID) (CompanyPayout, error)
 	CancelRecurringGroup(ctx context.Context, arg CancelRecurringGroupParams) (RecurringBookingGroup, error)
+	// CancelRegenerableOccurrences cancels the not-yet-started occurrences of a
+	// series from apply_from on, keeping one-off exceptions, so they can be
+	// regenerated after a series edit.
+	CancelRegenerableOccurrences(ctx context.Context, arg CancelRegenerableOccurrencesParams) error
 	CheckChatParticipant(ctx context.Context, arg CheckChatParticipantParams) (int64, error)
 	// Returns true if all 3 required documents exist and are approved
 	CheckCompanyDocumentsReady(ctx context.Context, companyID pgtype.UUID) (pgtype.Bool, error)
+	CheckInBookingTeamMember(ctx context.Context, arg CheckInBookingTeamMemberParams) (BookingTeamMember, error)
+	// ClaimAccountingExport marks an export as being generated. It returns no
+	// rows when the export is finished or another worker holds a fresh claim.
+	ClaimAccountingExport(ctx context.Context, id pgtype.UUID) (AccountingExport, error)
 	ClaimCompanyByToken(ctx context.Context, arg ClaimCompanyByTokenParams) (Company, error)
+	// ClaimInvoicePipeline marks the pipeline of an invoice as running. It returns
+	// no rows when the pipeline is done or another worker holds a fresh claim.
+	ClaimInvoicePipeline(ctx context.Context, id pgtype.UUID) (Invoice, error)
+	// ClaimPayoutForExecution moves a pending or failed payout to processing. It
+	// returns no rows when the payout is already processing, paid or cancelled.
+	ClaimPayoutForExecution(ctx context.Context, id pgtype.UUID) (CompanyPayout, error)
+	// ClaimRefundCredit marks the credit of a refund as being issued. It returns no
+	// rows when the credit is done or another worker holds a fresh claim.
+	ClaimRefundCredit(ctx context.Context, refundID string) (RefundCredit, error)
+	// ClaimStripeEvent marks an event as being processed. It returns no rows when
+	// the event is already processed or another worker holds a fresh claim.
+	ClaimStripeEvent(ctx context.Context, id string) (StripeEvent, error)
+	CompleteAllBookingTeamMembers(ctx context.Context, bookingID pgtype.UUID) error
+	// CompleteBooking returns no rows unless the booking is in progress, so a
+	// booking completed concurrently is completed once.
 	CompleteBooking(ctx context.Context, id pgtype.UUID) (Booking, error)
+	CompleteBookingTeamMember(ctx context.Context, arg CompleteBookingTeamMemberParams) (BookingTeamMember, error)
 	CountActiveEmailOTPs(ctx context.Context, email string) (int64, error)
 	CountActiveRecurringGroups(ctx context.Context) (int64, error)
 	CountAllBookings(ctx context.Context) (int64, error)
@@ -36,6 +72,7 @@ type Querier interface {
 	CountInvoicesByClient(ctx context.Context, clientUserID pgtype.UUID) (int64, error)
 	CountInvoicesByCompany(ctx context.Context, companyID pgtype.UUID) (int64, error)
 	CountPaymentHistoryByUser(ctx context.Context, clientUserID pgtype.UUID) (int64, error)
+	CountPendingBookingTeamMembers(ctx context.Context, bookingID pgtype.UUID) (int64, error)
 	CountReviewsByCleanerID(ctx context.Context, reviewedCleanerID pgtype.UUID) (int64, error)
 	CountSearchBookings(ctx context.Context, arg CountSearchBookingsParams) (int64, error)
 	CountSearchCleanerBookings(ctx context.Context, arg CountSearchCleanerBookingsParams) (int64, error)
@@ -46,6 +83,7 @@ type Querier interface {
 	CountUnreadNotifications(ctx context.Context, userID pgtype.UUID) (int64, error)
 	CountUsersByRole(ctx context.Context, role UserRole) (int64, error)
 	CountWaitlistLeads(ctx context.Context) (CountWaitlistLeadsRow, error)
+	CreateAccountingExport(ctx context.Context, arg CreateAccountingExportParams) (AccountingExport, error)
 	CreateAddress(ctx context.Context, arg CreateAddressParams) (ClientAddress, error)
 	CreateArea(ctx context.Context, arg CreateAreaParams) (CityArea, error)
 	CreateBillingProfile(ctx context.Context, arg CreateBillingProfileParams) (ClientBillingProfile, error)
@@ -58,6 +96,7 @@ type Querier interface {
 	CreateCleanerProfile(ctx context.Context, arg CreateCleanerProfileParams) (Cleaner, error)
 	CreateCleanerUser(ctx context.Context, arg CreateCleanerUserParams) (User, error)
 	CreateCompany(ctx context.Context, arg CreateCompanyParams) (Company, error)
+	CreateCompanyClosure(ctx context.Context, arg CreateCompanyClosureParams) (CompanyClosure, error)
 	CreateCompanyDocument(ctx context.Context, arg CreateCompanyDocumentParams) (CompanyDocument, error)
 	// ============================================
 	// COMPANY PAYOUTS
@@ -67,12 +106,18 @@ type Querier interface {
 	// ============================================
 	// INVOICES
 	// ============================================
+	// CreateInvoice returns no rows for a second client invoice of a booking.
 	CreateInvoice(ctx context.Context, arg CreateInvoiceParams) (Invoice, error)
 	// ============================================
 	// INVOICE LINE ITEMS
 	// ============================================
 	CreateInvoiceLineItem(ctx context.Context, arg CreateInvoiceLineItemParams) (InvoiceLineItem, error)
-	CreateInvoiceSequence(ctx context.Context, arg CreateInvoiceSequenceParams) error
+	CreateInvoiceSeries(ctx context.Context, arg CreateInvoiceSeriesParams) (InvoiceSeries, error)
+	// CreateLedgerEntry returns no rows when an entry with the reference was
+	// already posted.
+	CreateLedgerEntry(ctx context.Context, arg CreateLedgerEntryParams) (LedgerEntry, error)
+	CreateLedgerLine(ctx context.Context, arg CreateLedgerLineParams) error
+	CreateLedgerReconciliation(ctx context.Context, arg CreateLedgerReconciliationParams) (LedgerReconciliation, error)
 	CreateNotification(ctx context.Context, arg CreateNotificationParams) (Notification, error)
 	CreatePaymentMethod(ctx context.Context, arg CreatePaymentMethodParams) (ClientPaymentMethod, error)
 	// ============================================
@@ -89,6 +134,9 @@ type Querier interface {
 	CreatePersonalityInsight(ctx context.Context, arg CreatePersonalityInsightParams) (PersonalityInsight, error)
 	CreatePlatformEvent(ctx context.Context, arg CreatePlatformEventParams) error
 	CreateRecurringGroup(ctx context.Context, arg CreateRecurringGroupParams) (RecurringBookingGroup, error)
+	// CreateRefundCredit records the credit notes owed for a refund. Refunds
+	// already recorded affect no rows.
+	CreateRefundCredit(ctx context.Context, arg CreateRefundCreditParams) (int64, error)
 	// ============================================
 	// REFUND REQUESTS
 	// ============================================
@@ -97,29 +145,42 @@ type Querier interface {
 	CreateServiceDefinition(ctx context.Context, arg CreateServiceDefinitionParams) (ServiceDefinition, error)
 	CreateServiceExtra(ctx context.Context, arg CreateServiceExtraParams) (ServiceExtra, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	CreateVatRate(ctx context.Context, arg CreateVatRateParams) (VatRate, error)
 	CreateWaitlistLead(ctx context.Context, arg CreateWaitlistLeadParams) (WaitlistLead, error)
 	DeleteAddress(ctx context.Context, id pgtype.UUID) error
 	DeleteAllCleanerServiceAreas(ctx context.Context, cleanerID pgtype.UUID) error
 	DeleteAllCompanyServiceAreas(ctx context.Context, companyID pgtype.UUID) error
+	DeleteAnafToken(ctx context.Context, cif string) error
 	DeleteArea(ctx context.Context, id pgtype.UUID) error
 	DeleteBillingProfile(ctx context.Context, id pgtype.UUID) error
+	DeleteBookingTeamMembers(ctx context.Context, bookingID pgtype.UUID) error
 	DeleteBookingTimeSlots(ctx context.Context, bookingID pgtype.UUID) error
 	DeleteCleanerAvailability(ctx context.Context, cleanerID pgtype.UUID) error
 	DeleteCleanerDateOverride(ctx context.Context, arg DeleteCleanerDateOverrideParams) error
 	DeleteCleanerDocument(ctx context.Context, id pgtype.UUID) error
+	DeleteCleanerSkills(ctx context.Context, cleanerID pgtype.UUID) error
+	DeleteCompanyClosure(ctx context.Context, arg DeleteCompanyClosureParams) error
 	DeleteCompanyDocument(ctx context.Context, id pgtype.UUID) error
 	DeleteCompanyWorkSchedule(ctx context.Context, companyID pgtype.UUID) error
 	DeleteExpiredEmailOTPs(ctx context.Context) error
 	DeletePaymentMethod(ctx context.Context, id pgtype.UUID) error
+	DeletePayoutLineItems(ctx context.Context, payoutID pgtype.UUID) error
 	// Delete personality insight (for regeneration)
 	DeletePersonalityInsight(ctx context.Context, assessmentID pgtype.UUID) error
+	DeleteRecurringGroupExtras(ctx context.Context, groupID pgtype.UUID) error
 	DeleteReview(ctx context.Context, id pgtype.UUID) error
 	DeleteUser(ctx context.Context, id pgtype.UUID) error
 	DeselectAllBookingTimeSlots(ctx context.Context, bookingID pgtype.UUID) error
+	// EnsureInvoiceSeries creates the default series of a document type for a
+	// company that never had one. Companies that retired all their series of
+	// the type must create a new one.
+	EnsureInvoiceSeries(ctx context.Context, arg EnsureInvoiceSeriesParams) error
 	FindChatRoomByExactParticipants(ctx context.Context, arg FindChatRoomByExactParticipantsParams) (ChatRoom, error)
 	FindDirectChatRoom(ctx context.Context, arg FindDirectChatRoomParams) (ChatRoom, error)
 	FindMatchingCleaners(ctx context.Context, cityAreaID pgtype.UUID) ([]FindMatchingCleanersRow, error)
+	GetAccountingExport(ctx context.Context, id pgtype.UUID) (AccountingExport, error)
 	GetAddressByID(ctx context.Context, id pgtype.UUID) (ClientAddress, error)
+	GetAnafTokenByCIF(ctx context.Context, cif string) (AnafToken, error)
 	GetAreaByID(ctx context.Context, id pgtype.UUID) (GetAreaByIDRow, error)
 	GetAverageCleanerRating(ctx context.Context, reviewedCleanerID pgtype.UUID) (pgtype.Numeric, error)
 	// ============================================
@@ -129,6 +190,7 @@ type Querier interface {
 	GetBookingByID(ctx context.Context, id pgtype.UUID) (Booking, error)
 	GetBookingByReferenceCode(ctx context.Context, referenceCode string) (Booking, error)
 	GetBookingCountByStatus(ctx context.Context) ([]GetBookingCountByStatusRow, error)
+	GetBookingTeamMember(ctx context.Context, arg GetBookingTeamMemberParams) (BookingTeamMember, error)
 	GetBookingsByRecurringGroup(ctx context.Context, recurringGroupID pgtype.UUID) ([]Booking, error)
 	GetChatRoomByBookingID(ctx context.Context, bookingID pgtype.UUID) (ChatRoom, error)
 	GetChatRoomByID(ctx context.Context, id pgtype.UUID) (ChatRoom, error)
@@ -140,19 +202,27 @@ type Querier interface {
 	GetCleanerDocument(ctx context.Context, id pgtype.UUID) (CleanerDocument, error)
 	GetCleanerEarningsByDateRange(ctx context.Context, arg GetCleanerEarningsByDateRangeParams) ([]GetCleanerEarningsByDateRangeRow, error)
 	GetCleanerPerformanceStats(ctx context.Context, id pgtype.UUID) (GetCleanerPerformanceStatsRow, error)
+	// GetCommissionInvoiceByBooking returns the commission invoice that charged
+	// the platform fee of a booking, if one was issued: the invoice of the month
+	// the booking was paid in, or of the payout that included it.
+	GetCommissionInvoiceByBooking(ctx context.Context, bookingID pgtype.UUID) (Invoice, error)
+	GetCommissionInvoiceForPeriod(ctx context.Context, arg GetCommissionInvoiceForPeriodParams) (Invoice, error)
 	GetCompanyByAdminUserID(ctx context.Context, adminUserID pgtype.UUID) (Company, error)
 	GetCompanyByCUI(ctx context.Context, cui string) (Company, error)
 	GetCompanyByClaimToken(ctx context.Context, claimToken pgtype.Text) (Company, error)
 	GetCompanyByID(ctx context.Context, id pgtype.UUID) (Company, error)
 	GetCompanyDocument(ctx context.Context, id pgtype.UUID) (CompanyDocument, error)
 	GetCompanyFinancialSummary(ctx context.Context, companyID pgtype.UUID) (GetCompanyFinancialSummaryRow, error)
+	GetCompanyInvoiceSettings(ctx context.Context, companyID pgtype.UUID) (CompanyInvoiceSetting, error)
 	GetCompanyPerformance(ctx context.Context, arg GetCompanyPerformanceParams) ([]GetCompanyPerformanceRow, error)
 	GetCompanyRevenueByDateRange(ctx context.Context, arg GetCompanyRevenueByDateRangeParams) ([]GetCompanyRevenueByDateRangeRow, error)
 	// ============================================
 	// STRIPE CONNECT (Companies)
 	// ============================================
 	GetCompanyStripeConnect(ctx context.Context, id pgtype.UUID) (GetCompanyStripeConnectRow, error)
+	GetCreditNoteByRefund(ctx context.Context, arg GetCreditNoteByRefundParams) (Invoice, error)
 	GetExtraByID(ctx context.Context, id pgtype.UUID) (ServiceExtra, error)
+	GetFirstRecurringOccurrence(ctx context.Context, recurringGroupID pgtype.UUID) (Booking, error)
 	// ============================================
 	// INVOICE ANALYTICS (Admin reporting)
 	// ============================================
@@ -161,13 +231,19 @@ type Querier interface {
 	GetInvoiceByID(ctx context.Context, id pgtype.UUID) (Invoice, error)
 	GetInvoiceCountByStatus(ctx context.Context, arg GetInvoiceCountByStatusParams) ([]GetInvoiceCountByStatusRow, error)
 	GetInvoiceCountByType(ctx context.Context, arg GetInvoiceCountByTypeParams) ([]GetInvoiceCountByTypeRow, error)
+	GetInvoiceSeries(ctx context.Context, id pgtype.UUID) (InvoiceSeries, error)
+	// GetIssuedInvoiceByBooking returns the invoice of a booking that credit notes
+	// correct, skipping cancelled invoices and earlier credit notes.
+	GetIssuedInvoiceByBooking(ctx context.Context, arg GetIssuedInvoiceByBookingParams) (Invoice, error)
 	GetLastChatMessage(ctx context.Context, roomID pgtype.UUID) (ChatMessage, error)
-	// ============================================
-	// INVOICE SEQUENCES
-	// ============================================
-	GetNextInvoiceNumber(ctx context.Context, arg GetNextInvoiceNumberParams) (int32, error)
+	GetLedgerAccountBalance(ctx context.Context, arg GetLedgerAccountBalanceParams) (int64, error)
+	GetMaxOccurrenceNumber(ctx context.Context, recurringGroupID pgtype.UUID) (int32, error)
+	GetOccurrenceExceptionByBooking(ctx context.Context, bookingID pgtype.UUID) (RecurringOccurrenceException, error)
+	GetPaymentDisputeByID(ctx context.Context, id pgtype.UUID) (PaymentDispute, error)
+	GetPaymentDisputeByStripeID(ctx context.Context, stripeDisputeID string) (PaymentDispute, error)
 	GetPaymentMethodByStripeID(ctx context.Context, stripePaymentMethodID pgtype.Text) (ClientPaymentMethod, error)
 	GetPaymentTransactionByBookingID(ctx context.Context, bookingID pgtype.UUID) (PaymentTransaction, error)
+	GetPaymentTransactionByID(ctx context.Context, id pgtype.UUID) (PaymentTransaction, error)
 	GetPaymentTransactionByStripePI(ctx context.Context, stripePaymentIntentID string) (PaymentTransaction, error)
 	GetPayoutByID(ctx context.Context, id pgtype.UUID) (CompanyPayout, error)
 	GetPersonalityAnswersByAssessmentID(ctx context.Context, assessmentID pgtype.UUID) ([]PersonalityAssessmentAnswer, error)
@@ -176,10 +252,6 @@ type Querier interface {
 	GetPersonalityInsightByAssessmentID(ctx context.Context, assessmentID pgtype.UUID) (PersonalityInsight, error)
 	// GetPlatformLegalEntity returns the single platform legal entity configuration.
 	GetPlatformLegalEntity(ctx context.Context) (PlatformLegalEntity, error)
-	// ============================================
-	// PLATFORM REVENUE (Admin reporting)
-	// ============================================
-	GetPlatformRevenueReport(ctx context.Context, arg GetPlatformRevenueReportParams) (GetPlatformRevenueReportRow, error)
 	GetPlatformSetting(ctx context.Context, key string) (PlatformSetting, error)
 	GetPlatformStats(ctx context.Context) (GetPlatformStatsRow, error)
 	GetPlatformTotals(ctx context.Context) (GetPlatformTotalsRow, error)
@@ -187,15 +259,15 @@ type Querier interface {
 	GetRecurringGroupExtras(ctx context.Context, groupID pgtype.UUID) ([]GetRecurringGroupExtrasRow, error)
 	GetRefundRequestByBookingID(ctx context.Context, bookingID pgtype.UUID) (RefundRequest, error)
 	GetRefundRequestByID(ctx context.Context, id pgtype.UUID) (RefundRequest, error)
+	GetRefundRequestByStripeRefundID(ctx context.Context, stripeRefundID pgtype.Text) (RefundRequest, error)
 	GetRevenueByDateRange(ctx context.Context, arg GetRevenueByDateRangeParams) ([]GetRevenueByDateRangeRow, error)
 	GetRevenueByMonth(ctx context.Context, limit int32) ([]GetRevenueByMonthRow, error)
 	GetRevenueByServiceType(ctx context.Context, arg GetRevenueByServiceTypeParams) ([]GetRevenueByServiceTypeRow, error)
 	GetReviewByBookingID(ctx context.Context, bookingID pgtype.UUID) (Review, error)
 	GetSelectedTimeSlot(ctx context.Context, bookingID pgtype.UUID) (BookingTimeSlot, error)
 	GetServiceByType(ctx context.Context, serviceType ServiceType) (ServiceDefinition, error)
+	GetStripeEvent(ctx context.Context, id string) (StripeEvent, error)
 	GetTopCompaniesByRevenue(ctx context.Context, arg GetTopCompaniesByRevenueParams) ([]GetTopCompaniesByRevenueRow, error)
-	GetTotalPayoutsInPeriod(ctx context.Context, arg GetTotalPayoutsInPeriodParams) (GetTotalPayoutsInPeriodRow, error)
-	GetTotalRefundsInPeriod(ctx context.Context, arg GetTotalRefundsInPeriodParams) (int64, error)
 	GetUnclaimedCompanyByContactEmail(ctx context.Context, contactEmail string) (Company, error)
 	GetUpcomingBookingsByRecurringGroup(ctx context.Context, recurringGroupID pgtype.UUID) ([]Booking, error)
 	GetUserByEmail(ctx context.Context, email string) (User, error)
@@ -206,12 +278,22 @@ type Querier interface {
 	// ============================================
 	GetUserStripeCustomerID(ctx context.Context, id pgtype.UUID) (pgtype.Text, error)
 	GetValidEmailOTP(ctx context.Context, arg GetValidEmailOTPParams) (EmailOtpCode, error)
+	// GetVatRate returns the rate of a category in force on a date: the one that
+	// started last on or before it.
+	GetVatRate(ctx context.Context, arg GetVatRateParams) (int32, error)
 	HasPersonalityAssessment(ctx context.Context, cleanerID pgtype.UUID) (bool, error)
+	// HoldPayoutsForTransaction puts the unexecuted payouts that contain a
+	// disputed transaction on hold.
+	HoldPayoutsForTransaction(ctx context.Context, paymentTransactionID pgtype.UUID) ([]CompanyPayout, error)
+	IncrementRecurringPaymentFailures(ctx context.Context, id pgtype.UUID) (int32, error)
 	InsertBookingExtra(ctx context.Context, arg InsertBookingExtraParams) error
 	InsertCleanerServiceArea(ctx context.Context, arg InsertCleanerServiceAreaParams) (CleanerServiceArea, error)
 	InsertCompanyServiceArea(ctx context.Context, arg InsertCompanyServiceAreaParams) (CompanyServiceArea, error)
 	InsertRecurringGroupExtra(ctx context.Context, arg InsertRecurringGroupExtraParams) error
 	LinkCleanerToUser(ctx context.Context, arg LinkCleanerToUserParams) (Cleaner, error)
+	// ListAccountingExports returns the latest exports of a company, or of the
+	// platform for a NULL company.
+	ListAccountingExports(ctx context.Context, arg ListAccountingExportsParams) ([]AccountingExport, error)
 	ListActiveCities(ctx context.Context) ([]EnabledCity, error)
 	ListActiveExtras(ctx context.Context) ([]ServiceExtra, error)
 	ListActiveRecurringGroupsByClient(ctx context.Context, clientUserID pgtype.UUID) ([]RecurringBookingGroup, error)
@@ -234,7 +316,11 @@ type Querier interface {
 	ListAllUsers(ctx context.Context) ([]User, error)
 	ListAreasByCity(ctx context.Context, cityID pgtype.UUID) ([]ListAreasByCityRow, error)
 	ListBookingExtras(ctx context.Context, bookingID pgtype.UUID) ([]ListBookingExtrasRow, error)
+	ListBookingTeamMembers(ctx context.Context, bookingID pgtype.UUID) ([]BookingTeamMember, error)
 	ListBookingTimeSlots(ctx context.Context, bookingID pgtype.UUID) ([]BookingTimeSlot, error)
+	// ListBookingsAwaitingInvoice returns bookings that their company's settings
+	// should have invoiced automatically but have no client invoice yet.
+	ListBookingsAwaitingInvoice(ctx context.Context, limit int32) ([]Booking, error)
 	ListBookingsByCleaner(ctx context.Context, cleanerID pgtype.UUID) ([]Booking, error)
 	ListBookingsByCleanerAndDateRange(ctx context.Context, arg ListBookingsByCleanerAndDateRangeParams) ([]Booking, error)
 	ListBookingsByClient(ctx context.Context, arg ListBookingsByClientParams) ([]Booking, error)
@@ -243,6 +329,7 @@ type Querier interface {
 	ListBookingsByCompanyAndDateRange(ctx context.Context, arg ListBookingsByCompanyAndDateRangeParams) ([]Booking, error)
 	ListBookingsByCompanyAndStatus(ctx context.Context, arg ListBookingsByCompanyAndStatusParams) ([]Booking, error)
 	ListBookingsByStatus(ctx context.Context, arg ListBookingsByStatusParams) ([]Booking, error)
+	ListBookingsForSimulation(ctx context.Context, arg ListBookingsForSimulationParams) ([]ListBookingsForSimulationRow, error)
 	ListChatMessages(ctx context.Context, arg ListChatMessagesParams) ([]ChatMessage, error)
 	ListChatParticipants(ctx context.Context, roomID pgtype.UUID) ([]ChatParticipant, error)
 	ListChatRoomsByCompanyCleaners(ctx context.Context, companyID pgtype.UUID) ([]ChatRoom, error)
@@ -252,13 +339,32 @@ type Querier interface {
 	ListCleanerDateOverrides(ctx context.Context, arg ListCleanerDateOverridesParams) ([]CleanerDateOverride, error)
 	ListCleanerDocuments(ctx context.Context, cleanerID pgtype.UUID) ([]CleanerDocument, error)
 	ListCleanerServiceAreas(ctx context.Context, cleanerID pgtype.UUID) ([]ListCleanerServiceAreasRow, error)
+	ListCleanerSkills(ctx context.Context, cleanerID pgtype.UUID) ([]ServiceType, error)
 	ListCleanersByCompany(ctx context.Context, companyID pgtype.UUID) ([]Cleaner, error)
+	ListCleanersForSimulation(ctx context.Context) ([]ListCleanersForSimulationRow, error)
+	// ListCommissionMonth sums, per company, the platform fees of the payments
+	// received between two instants and the fees refunded since on those
+	// bookings, leaving out refunds already credited on a commission invoice and
+	// bookings invoiced per payout.
+	ListCommissionMonth(ctx context.Context, arg ListCommissionMonthParams) ([]ListCommissionMonthRow, error)
 	ListCompaniesByStatus(ctx context.Context, arg ListCompaniesByStatusParams) ([]Company, error)
+	ListCompaniesForPayout(ctx context.Context) ([]Company, error)
+	ListCompanyClosures(ctx context.Context, arg ListCompanyClosuresParams) ([]CompanyClosure, error)
 	ListCompanyDocuments(ctx context.Context, companyID pgtype.UUID) ([]CompanyDocument, error)
 	ListCompanyServiceAreas(ctx context.Context, companyID pgtype.UUID) ([]ListCompanyServiceAreasRow, error)
 	ListCompanyWorkSchedule(ctx context.Context, companyID pgtype.UUID) ([]CompanyWorkSchedule, error)
+	ListDueAccountingExports(ctx context.Context, limit int32) ([]AccountingExport, error)
+	ListDueInvoicePipelines(ctx context.Context, limit int32) ([]Invoice, error)
+	ListDueRefundCredits(ctx context.Context, limit int32) ([]RefundCredit, error)
+	ListDueStripeEvents(ctx context.Context, limit int32) ([]StripeEvent, error)
 	ListEnabledCities(ctx context.Context) ([]EnabledCity, error)
+	// ListExpiringAuthorizations returns the current authorization holds of active
+	// bookings that will expire (after 7 days) before the booking takes place.
+	ListExpiringAuthorizations(ctx context.Context, limit int32) ([]PaymentTransaction, error)
 	ListInvoiceLineItems(ctx context.Context, invoiceID pgtype.UUID) ([]InvoiceLineItem, error)
+	// ListInvoiceSeries returns the invoice series of a company, or of the
+	// platform for a NULL company, active series first.
+	ListInvoiceSeries(ctx context.Context, companyID pgtype.UUID) ([]InvoiceSeries, error)
 	// ============================================
 	// INVOICE LISTING (Client)
 	// ============================================
@@ -271,7 +377,22 @@ type Querier interface {
 	ListInvoicesByCompanyID(ctx context.Context, arg ListInvoicesByCompanyIDParams) ([]Invoice, error)
 	ListInvoicesByType(ctx context.Context, arg ListInvoicesByTypeParams) ([]Invoice, error)
 	ListInvoicesByTypeAndStatus(ctx context.Context, arg ListInvoicesByTypeAndStatusParams) ([]Invoice, error)
+	// ListInvoicesForExport returns the invoices and credit notes of a type issued
+	// in a period, Romanian time, optionally for one company. Drafts and
+	// cancelled invoices are left out.
+	ListInvoicesForExport(ctx context.Context, arg ListInvoicesForExportParams) ([]Invoice, error)
+	// ListLedgerLinesForExport returns the lines of the ledger entries posted in
+	// a period, optionally of one company, debits before credits.
+	ListLedgerLinesForExport(ctx context.Context, arg ListLedgerLinesForExportParams) ([]ListLedgerLinesForExportRow, error)
+	ListLedgerReconciliations(ctx context.Context, limit int32) ([]LedgerReconciliation, error)
+	// ListLedgerStripeMovements returns what payments and refunds in a period
+	// moved on the Stripe balance, for reconciliation.
+	ListLedgerStripeMovements(ctx context.Context, arg ListLedgerStripeMovementsParams) ([]ListLedgerStripeMovementsRow, error)
 	ListNotificationsByUser(ctx context.Context, arg ListNotificationsByUserParams) ([]Notification, error)
+	ListOccurrenceExceptions(ctx context.Context, groupID pgtype.UUID) ([]RecurringOccurrenceException, error)
+	ListPaymentDisputes(ctx context.Context, arg ListPaymentDisputesParams) ([]PaymentDispute, error)
+	ListPaymentDisputesByBooking(ctx context.Context, bookingID pgtype.UUID) ([]PaymentDispute, error)
+	ListPaymentDisputesByStatus(ctx context.Context, arg ListPaymentDisputesByStatusParams) ([]PaymentDispute, error)
 	// ============================================
 	// PAYMENT HISTORY (Client-facing)
 	// ============================================
@@ -286,30 +407,89 @@ type Querier interface {
 	ListPayoutsByCompany(ctx context.Context, arg ListPayoutsByCompanyParams) ([]CompanyPayout, error)
 	ListPayoutsByCompanyAndStatus(ctx context.Context, arg ListPayoutsByCompanyAndStatusParams) ([]CompanyPayout, error)
 	ListPayoutsByStatus(ctx context.Context, arg ListPayoutsByStatusParams) ([]CompanyPayout, error)
+	// ListPayoutsDueForRetry returns failed payouts whose next attempt is due and
+	// pending payouts that were never executed.
+	ListPayoutsDueForRetry(ctx context.Context, limit int32) ([]CompanyPayout, error)
+	// ListPayoutsForReconciliation returns payouts that need an admin: failed with
+	// no retries left, or processing with no Stripe outcome for three days.
+	ListPayoutsForReconciliation(ctx context.Context) ([]CompanyPayout, error)
 	ListPendingCleanerDocuments(ctx context.Context) ([]CleanerDocument, error)
 	ListPendingCompanyDocuments(ctx context.Context) ([]CompanyDocument, error)
+	// ListPendingEFacturaInvoices returns the invoices uploaded to the ANAF SPV
+	// that are still waiting for a result, oldest first.
+	ListPendingEFacturaInvoices(ctx context.Context, limit int32) ([]Invoice, error)
 	ListPlatformSettings(ctx context.Context) ([]PlatformSetting, error)
 	ListRecurringGroupsByClient(ctx context.Context, clientUserID pgtype.UUID) ([]RecurringBookingGroup, error)
+	// ListRecurringGroupsToExtend returns active groups whose occurrences are not
+	// yet generated up to the horizon date.
+	ListRecurringGroupsToExtend(ctx context.Context, horizon pgtype.Date) ([]RecurringBookingGroup, error)
+	// ListRecurringOccurrencesToCharge returns unpaid occurrences of active series
+	// that start between now and charge_before and were never charged.
+	ListRecurringOccurrencesToCharge(ctx context.Context, arg ListRecurringOccurrencesToChargeParams) ([]Booking, error)
 	ListRefundRequestsByStatus(ctx context.Context, arg ListRefundRequestsByStatusParams) ([]RefundRequest, error)
 	ListReviewsByCleanerID(ctx context.Context, arg ListReviewsByCleanerIDParams) ([]Review, error)
+	ListStripeEvents(ctx context.Context, arg ListStripeEventsParams) ([]StripeEvent, error)
+	ListStripeEventsByStatus(ctx context.Context, arg ListStripeEventsByStatusParams) ([]StripeEvent, error)
 	ListTodaysJobsByCleaner(ctx context.Context, cleanerID pgtype.UUID) ([]Booking, error)
+	ListUncapturedCompletedBookings(ctx context.Context, limit int32) ([]Booking, error)
 	// ============================================
 	// UNPAID TRANSACTIONS (Payout calculation)
 	// ============================================
 	ListUnpaidCompanyTransactions(ctx context.Context, arg ListUnpaidCompanyTransactionsParams) ([]PaymentTransaction, error)
 	ListUsersByRole(ctx context.Context, role UserRole) ([]User, error)
+	ListVatRates(ctx context.Context) ([]VatRate, error)
 	ListWaitlistLeads(ctx context.Context, arg ListWaitlistLeadsParams) ([]WaitlistLead, error)
+	MarkAccountingExportFailed(ctx context.Context, arg MarkAccountingExportFailedParams) error
+	MarkAccountingExportReady(ctx context.Context, arg MarkAccountingExportReadyParams) (AccountingExport, error)
 	MarkAllNotificationsRead(ctx context.Context, userID pgtype.UUID) error
+	// MarkBookingAuthorizedAndConfirmed records the authorization hold on a booking
+	// and auto-confirms it if still pending/assigned, like MarkBookingPaidAndConfirmed.
+	MarkBookingAuthorizedAndConfirmed(ctx context.Context, id pgtype.UUID) (Booking, error)
 	MarkBookingPaid(ctx context.Context, id pgtype.UUID) (Booking, error)
 	// Atomically marks a booking as paid AND auto-confirms it if still pending/assigned.
-	// Idempotent: if booking is already confirmed or later, status is left unchanged.
+	// Idempotent: if booking is already confirmed or later, status is left unchanged,
+	// and paid_at keeps the time of the first payment.
 	MarkBookingPaidAndConfirmed(ctx context.Context, id pgtype.UUID) (Booking, error)
 	MarkEmailOTPUsed(ctx context.Context, id pgtype.UUID) error
+	// MarkInvoiceEFacturaUploaded records an upload to the ANAF SPV and resets the
+	// result of any earlier upload.
+	MarkInvoiceEFacturaUploaded(ctx context.Context, arg MarkInvoiceEFacturaUploadedParams) error
+	MarkInvoiceEmailed(ctx context.Context, id pgtype.UUID) error
+	MarkInvoicePipelineDone(ctx context.Context, id pgtype.UUID) error
+	MarkInvoicePipelineFailed(ctx context.Context, arg MarkInvoicePipelineFailedParams) error
 	MarkMessagesRead(ctx context.Context, arg MarkMessagesReadParams) error
 	MarkNotificationRead(ctx context.Context, id pgtype.UUID) error
+	MarkPaymentDisputeEvidenceSubmitted(ctx context.Context, id pgtype.UUID) (PaymentDispute, error)
+	// MarkPaymentTransactionAuthorized records an authorization hold. It returns no
+	// rows once the hold has been captured or cancelled, so a late event cannot
+	// reopen it.
+	MarkPaymentTransactionAuthorized(ctx context.Context, arg MarkPaymentTransactionAuthorizedParams) (PaymentTransaction, error)
+	MarkPaymentTransactionCancelled(ctx context.Context, stripePaymentIntentID string) (PaymentTransaction, error)
+	MarkPayoutFailed(ctx context.Context, arg MarkPayoutFailedParams) (CompanyPayout, error)
+	MarkPayoutPaid(ctx context.Context, id pgtype.UUID) (CompanyPayout, error)
+	MarkRefundCreditDone(ctx context.Context, refundID string) error
+	MarkRefundCreditFailed(ctx context.Context, arg MarkRefundCreditFailedParams) error
+	MarkStripeEventFailed(ctx context.Context, arg MarkStripeEventFailedParams) error
+	MarkStripeEventProcessed(ctx context.Context, id string) error
 	PauseRecurringGroup(ctx context.Context, id pgtype.UUID) (RecurringBookingGroup, error)
+	// ReassignBookingCleaner moves a booking to another cleaner of the same company,
+	// failing with no rows if its cleaner changed since expected_cleaner_id was read.
+	ReassignBookingCleaner(ctx context.Context, arg ReassignBookingCleanerParams) (Booking, error)
+	// RecordStripeEvent stores a verified webhook event. Redeliveries of an
+	// event already stored affect no rows.
+	RecordStripeEvent(ctx context.Context, arg RecordStripeEventParams) (int64, error)
 	RejectCompany(ctx context.Context, arg RejectCompanyParams) (Company, error)
+	// ReleaseHeldPayouts releases the held payouts that contain a transaction once
+	// none of their transactions has a dispute that is open or lost.
+	ReleaseHeldPayouts(ctx context.Context, paymentTransactionID pgtype.UUID) ([]CompanyPayout, error)
+	// RescheduleBooking moves a not-yet-started booking to a new date and time with
+	// the cleaner who can do it; without a cleaner it goes back to pending.
+	RescheduleBooking(ctx context.Context, arg RescheduleBookingParams) (Booking, error)
+	ResetRecurringPaymentFailures(ctx context.Context, id pgtype.UUID) error
+	ResetStripeEventForReplay(ctx context.Context, id string) (StripeEvent, error)
 	ResumeRecurringGroup(ctx context.Context, id pgtype.UUID) (RecurringBookingGroup, error)
+	RetireActiveInvoiceSeries(ctx context.Context, arg RetireActiveInvoiceSeriesParams) error
+	RetireInvoiceSeries(ctx context.Context, id pgtype.UUID) (InvoiceSeries, error)
 	SearchBookings(ctx context.Context, arg SearchBookingsParams) ([]Booking, error)
 	SearchCleanerBookings(ctx context.Context, arg SearchCleanerBookingsParams) ([]Booking, error)
 	SearchCompanies(ctx context.Context, arg SearchCompaniesParams) ([]Company, error)
@@ -317,21 +497,43 @@ type Querier interface {
 	SearchUsers(ctx context.Context, arg SearchUsersParams) ([]User, error)
 	SearchUsersByName(ctx context.Context, dollar_1 pgtype.Text) ([]User, error)
 	SelectBookingTimeSlot(ctx context.Context, id pgtype.UUID) (BookingTimeSlot, error)
+	// SetBookingCompany attaches a booking to a company without assigning a cleaner.
+	SetBookingCompany(ctx context.Context, arg SetBookingCompanyParams) (Booking, error)
 	SetBookingFinalTotal(ctx context.Context, arg SetBookingFinalTotalParams) (Booking, error)
+	// SetBookingHolidaySurcharge replaces the holiday surcharge included in the
+	// booking's estimated total.
+	SetBookingHolidaySurcharge(ctx context.Context, arg SetBookingHolidaySurchargeParams) (Booking, error)
 	SetBookingPreferredCleaner(ctx context.Context, arg SetBookingPreferredCleanerParams) (Booking, error)
+	SetBookingTeamMemberPayShare(ctx context.Context, arg SetBookingTeamMemberPayShareParams) error
+	SetBookingTeamSize(ctx context.Context, arg SetBookingTeamSizeParams) (Booking, error)
 	SetCleanerAvailability(ctx context.Context, arg SetCleanerAvailabilityParams) (CleanerAvailability, error)
 	SetCompanyAdminUser(ctx context.Context, arg SetCompanyAdminUserParams) (Company, error)
 	SetCompanyStripeConnect(ctx context.Context, arg SetCompanyStripeConnectParams) error
+	// SetCompanyVATStatus records the VAT registration of a company as checked now.
+	SetCompanyVATStatus(ctx context.Context, arg SetCompanyVATStatusParams) error
 	SetDefaultAddress(ctx context.Context, arg SetDefaultAddressParams) error
 	SetDefaultPaymentMethod(ctx context.Context, arg SetDefaultPaymentMethodParams) error
+	SetInvoiceEFacturaResult(ctx context.Context, arg SetInvoiceEFacturaResultParams) error
+	// SetInvoicePDFPath records where the rendered PDF of an invoice is stored.
+	SetInvoicePDFPath(ctx context.Context, arg SetInvoicePDFPathParams) error
+	// SetPaymentDisputeEvidence stores the evidence of a dispute. It returns no
+	// rows once the evidence was submitted.
+	SetPaymentDisputeEvidence(ctx context.Context, arg SetPaymentDisputeEvidenceParams) (PaymentDispute, error)
+	SetPayoutStripePayoutID(ctx context.Context, arg SetPayoutStripePayoutIDParams) (CompanyPayout, error)
+	SetRecurringGroupGeneratedUntil(ctx context.Context, arg SetRecurringGroupGeneratedUntilParams) error
 	SetUserStripeCustomerID(ctx context.Context, arg SetUserStripeCustomerIDParams) error
 	StartBooking(ctx context.Context, id pgtype.UUID) (Booking, error)
-	// ============================================
-	// COMPANY EARNINGS (Reporting)
-	// ============================================
-	SumCompanyEarnings(ctx context.Context, arg SumCompanyEarningsParams) (SumCompanyEarningsRow, error)
+	StartInvoicePipeline(ctx context.Context, id pgtype.UUID) error
+	// SumCreditedAmount returns the total of the credit notes issued against an
+	// invoice, as a negative amount in bani.
+	SumCreditedAmount(ctx context.Context, creditedInvoiceID pgtype.UUID) (int64, error)
+	SumLedgerBalancesByType(ctx context.Context) ([]SumLedgerBalancesByTypeRow, error)
+	// SumLedgerMovements totals the debits and credits posted in a period per
+	// entry kind and account type, optionally for one company.
+	SumLedgerMovements(ctx context.Context, arg SumLedgerMovementsParams) ([]SumLedgerMovementsRow, error)
 	SumThisMonthEarningsByCleaner(ctx context.Context, cleanerID pgtype.UUID) (pgtype.Numeric, error)
 	UpdateAddress(ctx context.Context, arg UpdateAddressParams) (ClientAddress, error)
+	UpdateAnafTokens(ctx context.Context, arg UpdateAnafTokensParams) (AnafToken, error)
 	UpdateBillingProfile(ctx context.Context, arg UpdateBillingProfileParams) (ClientBillingProfile, error)
 	// ============================================
 	// BOOKING PAYMENT STATUS
@@ -345,19 +547,31 @@ type Querier interface {
 	UpdateCleanerStatus(ctx context.Context, arg UpdateCleanerStatusParams) (Cleaner, error)
 	UpdateCleanerUserPhone(ctx context.Context, arg UpdateCleanerUserPhoneParams) error
 	UpdateCompanyDocumentStatus(ctx context.Context, arg UpdateCompanyDocumentStatusParams) (CompanyDocument, error)
+	UpdateCompanyHolidayPolicy(ctx context.Context, arg UpdateCompanyHolidayPolicyParams) (Company, error)
 	UpdateCompanyLogo(ctx context.Context, arg UpdateCompanyLogoParams) (Company, error)
 	UpdateCompanyOwnProfile(ctx context.Context, arg UpdateCompanyOwnProfileParams) (Company, error)
 	UpdateCompanyStatus(ctx context.Context, arg UpdateCompanyStatusParams) (Company, error)
 	UpdateInvoiceEFactura(ctx context.Context, arg UpdateInvoiceEFacturaParams) error
 	UpdateInvoiceFactureaza(ctx context.Context, arg UpdateInvoiceFactureazaParams) error
 	UpdateInvoiceStatus(ctx context.Context, arg UpdateInvoiceStatusParams) (Invoice, error)
+	UpdatePaymentTransactionCaptured(ctx context.Context, arg UpdatePaymentTransactionCapturedParams) (PaymentTransaction, error)
+	// UpdatePaymentTransactionFailed records a failed payment attempt. It returns
+	// no rows once the payment has been authorized, has succeeded or has been
+	// cancelled, so a late or replayed event cannot fail it.
 	UpdatePaymentTransactionFailed(ctx context.Context, arg UpdatePaymentTransactionFailedParams) (PaymentTransaction, error)
+	// UpdatePaymentTransactionRefund records the latest refund of a payment. It
+	// returns no rows for a partial refund of a fully refunded payment, so a
+	// replayed event cannot move it back.
 	UpdatePaymentTransactionRefund(ctx context.Context, arg UpdatePaymentTransactionRefundParams) (PaymentTransaction, error)
+	// UpdatePaymentTransactionStatus records a succeeded payment. It returns no
+	// rows once the payment has been refunded or cancelled, so a replayed event
+	// cannot move it back.
 	UpdatePaymentTransactionStatus(ctx context.Context, arg UpdatePaymentTransactionStatusParams) (PaymentTransaction, error)
 	UpdatePayoutStatus(ctx context.Context, arg UpdatePayoutStatusParams) (CompanyPayout, error)
 	// UpdatePlatformLegalEntity updates the platform legal entity details.
 	UpdatePlatformLegalEntity(ctx context.Context, arg UpdatePlatformLegalEntityParams) (PlatformLegalEntity, error)
 	UpdatePlatformSetting(ctx context.Context, arg UpdatePlatformSettingParams) (PlatformSetting, error)
+	UpdateRecurringGroupSeries(ctx context.Context, arg UpdateRecurringGroupSeriesParams) (RecurringBookingGroup, error)
 	UpdateRefundRequestStatus(ctx context.Context, arg UpdateRefundRequestStatusParams) (RefundRequest, error)
 	UpdateServiceDefinition(ctx context.Context, arg UpdateServiceDefinitionParams) (ServiceDefinition, error)
 	UpdateServiceExtra(ctx context.Context, arg UpdateServiceExtraParams) (ServiceExtra, error)
@@ -366,8 +580,23 @@ type Querier interface {
 	UpdateUserFCMToken(ctx context.Context, arg UpdateUserFCMTokenParams) error
 	UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (User, error)
 	UpdateUserStatus(ctx context.Context, arg UpdateUserStatusParams) (User, error)
+	// UpsertAnafToken stores the OAuth2 tokens of the ANAF account connected for a
+	// fiscal code, replacing an earlier connection.
+	UpsertAnafToken(ctx context.Context, arg UpsertAnafTokenParams) (AnafToken, error)
 	UpsertCleanerDateOverride(ctx context.Context, arg UpsertCleanerDateOverrideParams) (CleanerDateOverride, error)
+	// UpsertCompanyInvoiceSettings saves the invoicing settings of a company.
+	// Changing the trigger restarts auto_issue_since, so bookings completed or
+	// paid earlier are not invoiced retroactively.
+	UpsertCompanyInvoiceSettings(ctx context.Context, arg UpsertCompanyInvoiceSettingsParams) (CompanyInvoiceSetting, error)
 	UpsertCompanyWorkScheduleDay(ctx context.Context, arg UpsertCompanyWorkScheduleDayParams) (CompanyWorkSchedule, error)
+	// UpsertLedgerAccount returns the account of a type and owner, creating it
+	// the first time it is used.
+	UpsertLedgerAccount(ctx context.Context, arg UpsertLedgerAccountParams) (LedgerAccount, error)
+	UpsertOccurrenceException(ctx context.Context, arg UpsertOccurrenceExceptionParams) error
+	// UpsertPaymentDispute stores a dispute from a webhook. It returns no rows for
+	// an event older than the one the dispute was last stored from, and a closed
+	// dispute keeps its final status.
+	UpsertPaymentDispute(ctx context.Context, arg UpsertPaymentDisputeParams) (PaymentDispute, error)
 	// UpsertPlatformLegalEntity creates or updates the platform legal entity.
 	UpsertPlatformLegalEntity(ctx context.Context, arg UpsertPlatformLegalEntityParams) (PlatformLegalEntity, error)
 }
@@ -32,7 +32,7 @@ func (q *Queries) CancelFutureOccurrences(ctx context.Context, arg CancelFutureO
 const cancelRecurringGroup = `-- name: CancelRecurringGroup :one
 UPDATE recurring_booking_groups
 SET is_active = FALSE, cancelled_at = NOW(), cancellation_reason = $2, updated_at = NOW()
-WHERE id = $1 RETURNING id, client_user_id, company_id, preferred_cleaner_id, address_id, recurrence_type, day_of_week, preferred_time, service_type, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total_per_occurrence, is_active, cancelled_at, cancellation_reason, created_at, updated_at
+WHERE id = $1 RETURNING id, client_user_id, company_id, preferred_cleaner_id, address_id, recurrence_type, day_of_week, preferred_time, service_type, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total_per_occurrence, is_active, cancelled_at, cancellation_reason, created_at, updated_at, estimated_duration_hours, generated_until, anchor_date, rrule, payment_failures
 `
 
 type CancelRecurringGroupParams struct {
@@ -66,10 +66,42 @@ func (q *Queries) CancelRecurringGroup(ctx context.Context, arg CancelRecurringG
 		&i.CancellationReason,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.EstimatedDurationHours,
+		&i.GeneratedUntil,
+		&i.AnchorDate,
+		&i.Rrule,
+		&i.PaymentFailures,
 	)
 	return i, err
 }
 
+const cancelRegenerableOccurrences = `-- name: CancelRegenerableOccurrences :exec
+UPDATE bookings
+SET status = 'cancelled_by_client', cancelled_at = NOW(), cancellation_reason = $1, updated_at = NOW()
+WHERE recurring_group_id = $2
+  AND scheduled_date >= $3::date
+  AND status IN ('pending', 'assigned', 'confirmed')
+  AND started_at IS NULL
+  AND id NOT IN (
+      SELECT booking_id FROM recurring_occurrence_exceptions
+      WHERE group_id = $2 AND booking_id IS NOT NULL
+  )
+`
+
+type CancelRegenerableOccurrencesParams struct {
+	CancellationReason pgtype.Text `json:"cancellation_reason"`
+	RecurringGroupID   pgtype.UUID `json:"recurring_group_id"`
+	ApplyFrom          pgtype.Date `json:"apply_from"`
+}
+
+// CancelRegenerableOccurrences cancels the not-yet-started occurrences of a
+// series from apply_from on, keeping one-off exceptions, so they can be
+// regenerated after a series edit.
+func (q *Queries) CancelRegenerableOccurrences(ctx context.Context, arg CancelRegenerableOccurrencesParams) error {
+	_, err := q.db.Exec(ctx, cancelRegenerableOccurrences, arg.CancellationReason, arg.RecurringGroupID, arg.ApplyFrom)
+	return err
+}
+
 const countActiveRecurringGroups = `-- name: CountActiveRecurringGroups :one
 SELECT COUNT(*) FROM recurring_booking_groups WHERE is_active = TRUE
 `
@@ -86,9 +118,10 @@ INSERT INTO recurring_booking_groups (
     client_user_id, company_id, preferred_cleaner_id, address_id,
     recurrence_type, day_of_week, preferred_time, service_type,
     property_type, num_rooms, num_bathrooms, area_sqm, has_pets,
-    special_instructions, hourly_rate, estimated_total_per_occurrence
-) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
-RETURNING id, client_user_id, company_id, preferred_cleaner_id, address_id, recurrence_type, day_of_week, preferred_time, service_type, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total_per_occurrence, is_active, cancelled_at, cancellation_reason, created_at, updated_at
+    special_instructions, hourly_rate, estimated_total_per_occurrence,
+    estimated_duration_hours, generated_until, anchor_date, rrule
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
+RETURNING id, client_user_id, company_id, preferred_cleaner_id, address_id, recurrence_type, day_of_week, preferred_time, service_type, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total_per_occurrence, is_active, cancelled_at, cancellation_reason, created_at, updated_at, estimated_duration_hours, generated_until, anchor_date, rrule, payment_failures
 `
 
 type CreateRecurringGroupParams struct {
@@ -108,6 +141,10 @@ type CreateRecurringGroupParams struct {
 	SpecialInstructions         pgtype.Text    `json:"special_instructions"`
 	HourlyRate                  pgtype.Numeric `json:"hourly_rate"`
 	EstimatedTotalPerOccurrence pgtype.Numeric `json:"estimated_total_per_occurrence"`
+	EstimatedDurationHours      pgtype.Numeric `json:"estimated_duration_hours"`
+	GeneratedUntil              pgtype.Date    `json:"generated_until"`
+	AnchorDate                  pgtype.Date    `json:"anchor_date"`
+	Rrule                       pgtype.Text    `json:"rrule"`
 }
 
 func (q *Queries) CreateRecurringGroup(ctx context.Context, arg CreateRecurringGroupParams) (RecurringBookingGroup, error) {
@@ -128,6 +165,10 @@ func (q *Queries) CreateRecurringGroup(ctx context.Context, arg CreateRecurringG
 		arg.SpecialInstructions,
 		arg.HourlyRate,
 		arg.EstimatedTotalPerOccurrence,
+		arg.EstimatedDurationHours,
+		arg.GeneratedUntil,
+		arg.AnchorDate,
+		arg.Rrule,
 	)
 	var i RecurringBookingGroup
 	err := row.Scan(
@@ -153,12 +194,26 @@ func (q *Queries) CreateRecurringGroup(ctx context.Context, arg CreateRecurringG
 		&i.CancellationReason,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.EstimatedDurationHours,
+		&i.GeneratedUntil,
+		&i.AnchorDate,
+		&i.Rrule,
+		&i.PaymentFailures,
 	)
 	return i, err
 }
 
+const deleteRecurringGroupExtras = `-- name: DeleteRecurringGroupExtras :exec
+DELETE FROM recurring_group_extras WHERE group_id = $1
+`
+
+func (q *Queries) DeleteRecurringGroupExtras(ctx context.Context, groupID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, deleteRecurringGroupExtras, groupID)
+	return err
+}
+
 const getBookingsByRecurringGroup = `-- name: GetBookingsByRecurringGroup :many
-SELECT id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number FROM bookings
+SELECT id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number, team_size, holiday_surcharge, pets_surcharge FROM bookings
 WHERE recurring_group_id = $1
 ORDER BY scheduled_date, scheduled_start_time
 `
@@ -206,6 +261,9 @@ func (q *Queries) GetBookingsByRecurringGroup(ctx context.Context, recurringGrou
 			&i.UpdatedAt,
 			&i.RecurringGroupID,
 			&i.OccurrenceNumber,
+			&i.TeamSize,
+			&i.HolidaySurcharge,
+			&i.PetsSurcharge,
 		); err != nil {
 			return nil, err
 		}
@@ -217,8 +275,90 @@ func (q *Queries) GetBookingsByRecurringGroup(ctx context.Context, recurringGrou
 	return items, nil
 }
 
+const getFirstRecurringOccurrence = `-- name: GetFirstRecurringOccurrence :one
+SELECT id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number, team_size, holiday_surcharge, pets_surcharge FROM bookings
+WHERE recurring_group_id = $1
+ORDER BY occurrence_number
+LIMIT 1
+`
+
+func (q *Queries) GetFirstRecurringOccurrence(ctx context.Context, recurringGroupID pgtype.UUID) (Booking, error) {
+	row := q.db.QueryRow(ctx, getFirstRecurringOccurrence, recurringGroupID)
+	var i Booking
+	err := row.Scan(
+		&i.ID,
+		&i.ReferenceCode,
+		&i.ClientUserID,
+		&i.CompanyID,
+		&i.CleanerID,
+		&i.AddressID,
+		&i.ServiceType,
+		&i.ScheduledDate,
+		&i.ScheduledStartTime,
+		&i.EstimatedDurationHours,
+		&i.PropertyType,
+		&i.NumRooms,
+		&i.NumBathrooms,
+		&i.AreaSqm,
+		&i.HasPets,
+		&i.SpecialInstructions,
+		&i.HourlyRate,
+		&i.EstimatedTotal,
+		&i.FinalTotal,
+		&i.PlatformCommissionPct,
+		&i.PlatformCommissionAmount,
+		&i.Status,
+		&i.StartedAt,
+		&i.CompletedAt,
+		&i.CancelledAt,
+		&i.CancellationReason,
+		&i.StripePaymentIntentID,
+		&i.PaymentStatus,
+		&i.PaidAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.RecurringGroupID,
+		&i.OccurrenceNumber,
+		&i.TeamSize,
+		&i.HolidaySurcharge,
+		&i.PetsSurcharge,
+	)
+	return i, err
+}
+
+const getMaxOccurrenceNumber = `-- name: GetMaxOccurrenceNumber :one
+SELECT COALESCE(MAX(occurrence_number), 0)::int AS max_occurrence
+FROM bookings
+WHERE recurring_group_id = $1
+`
+
+func (q *Queries) GetMaxOccurrenceNumber(ctx context.Context, recurringGroupID pgtype.UUID) (int32, error) {
+	row := q.db.QueryRow(ctx, getMaxOccurrenceNumber, recurringGroupID)
+	var maxOccurrence int32
+	err := row.Scan(&maxOccurrence)
+	return maxOccurrence, err
+}
+
+const getOccurrenceExceptionByBooking = `-- name: GetOccurrenceExceptionByBooking :one
+SELECT group_id, original_date, exception_type, booking_id, created_at, updated_at FROM recurring_occurrence_exceptions WHERE booking_id = $1
+`
+
+func (q *Queries) GetOccurrenceExceptionByBooking(ctx context.Context, bookingID pgtype.UUID) (RecurringOccurrenceException, error) {
+	row := q.db.QueryRow(ctx, getOccurrenceExceptionByBooking, bookingID)
+	var i RecurringOccurrenceException
+	err := row.Scan(
+		&i.GroupID,
+		&i.OriginalDate,
+		&i.ExceptionType,
+		&i.BookingID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const getRecurringGroupByID = `-- name: GetRecurringGroupByID :one
-SELECT id, client_user_id, company_id, preferred_cleaner_id, address_id, recurrence_type, day_of_week, preferred_time, service_type, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total_per_occurrence, is_active, cancelled_at, cancellation_reason, created_at, updated_at FROM recurring_booking_groups WHERE id = $1
+SELECT id, client_user_id, company_id, preferred_cleaner_id, address_id, recurrence_type, day_of_week, preferred_time, service_type, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total_per_occurrence, is_active, cancelled_at, cancellation_reason, created_at, updated_at, estimated_duration_hours, generated_until, anchor_date, rrule, payment_failures FROM recurring_booking_groups WHERE id = $1
 `
 
 func (q *Queries) GetRecurringGroupByID(ctx context.Context, id pgtype.UUID) (RecurringBookingGroup, error) {
@@ -247,6 +387,11 @@ func (q *Queries) GetRecurringGroupByID(ctx context.Context, id pgtype.UUID) (Re
 		&i.CancellationReason,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.EstimatedDurationHours,
+		&i.GeneratedUntil,
+		&i.AnchorDate,
+		&i.Rrule,
+		&i.PaymentFailures,
 	)
 	return i, err
 }
@@ -299,7 +444,7 @@ func (q *Queries) GetRecurringGroupExtras(ctx context.Context, groupID pgtype.UU
 }
 
 const getUpcomingBookingsByRecurringGroup = `-- name: GetUpcomingBookingsByRecurringGroup :many
-SELECT id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number FROM bookings
+SELECT id, reference_code, client_user_id, company_id, cleaner_id, address_id, service_type, scheduled_date, scheduled_start_time, estimated_duration_hours, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total, final_total, platform_commission_pct, platform_commission_amount, status, started_at, completed_at, cancelled_at, cancellation_reason, stripe_payment_intent_id, payment_status, paid_at, created_at, updated_at, recurring_group_id, occurrence_number, team_size, holiday_surcharge, pets_surcharge FROM bookings
 WHERE recurring_group_id = $1
   AND scheduled_date >= CURRENT_DATE
   AND status NOT IN ('cancelled_by_client', 'cancelled_by_company', 'cancelled_by_admin')
@@ -349,6 +494,9 @@ func (q *Queries) GetUpcomingBookingsByRecurringGroup(ctx context.Context, recur
 			&i.UpdatedAt,
 			&i.RecurringGroupID,
 			&i.OccurrenceNumber,
+			&i.TeamSize,
+			&i.HolidaySurcharge,
+			&i.PetsSurcharge,
 		); err != nil {
 			return nil, err
 		}
@@ -360,6 +508,19 @@ func (q *Queries) GetUpcomingBookingsByRecurringGroup(ctx context.Context, recur
 	return items, nil
 }
 
+const incrementRecurringPaymentFailures = `-- name: IncrementRecurringPaymentFailures :one
+UPDATE recurring_booking_groups
+SET payment_failures = payment_failures + 1, updated_at = NOW()
+WHERE id = $1 RETURNING payment_failures
+`
+
+func (q *Queries) IncrementRecurringPaymentFailures(ctx context.Context, id pgtype.UUID) (int32, error) {
+	row := q.db.QueryRow(ctx, incrementRecurringPaymentFailures, id)
+	var paymentFailures int32
+	err := row.Scan(&paymentFailures)
+	return paymentFailures, err
+}
+
 const insertRecurringGroupExtra = `-- name: InsertRecurringGroupExtra :exec
 INSERT INTO recurring_group_extras (group_id, extra_id, quantity)
 VALUES ($1, $2, $3)
@@ -377,7 +538,7 @@ func (q *Queries) InsertRecurringGroupExtra(ctx context.Context, arg InsertRecur
 }
 
 const listActiveRecurringGroupsByClient = `-- name: ListActiveRecurringGroupsByClient :many
-SELECT id, client_user_id, company_id, preferred_cleaner_id, address_id, recurrence_type, day_of_week, preferred_time, service_type, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total_per_occurrence, is_active, cancelled_at, cancellation_reason, created_at, updated_at FROM recurring_booking_groups
+SELECT id, client_user_id, company_id, preferred_cleaner_id, address_id, recurrence_type, day_of_week, preferred_time, service_type, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total_per_occurrence, is_active, cancelled_at, cancellation_reason, created_at, updated_at, estimated_duration_hours, generated_until, anchor_date, rrule, payment_failures FROM recurring_booking_groups
 WHERE client_user_id = $1 AND is_active = TRUE
 ORDER BY created_at DESC
 `
@@ -414,6 +575,44 @@ func (q *Queries) ListActiveRecurringGroupsByClient(ctx context.Context, clientU
 			&i.CancellationReason,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.EstimatedDurationHours,
+			&i.GeneratedUntil,
+			&i.AnchorDate,
+			&i.Rrule,
+			&i.PaymentFailures,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOccurrenceExceptions = `-- name: ListOccurrenceExceptions :many
+SELECT group_id, original_date, exception_type, booking_id, created_at, updated_at FROM recurring_occurrence_exceptions
+WHERE group_id = $1
+ORDER BY original_date
+`
+
+func (q *Queries) ListOccurrenceExceptions(ctx context.Context, groupID pgtype.UUID) ([]RecurringOccurrenceException, error) {
+	rows, err := q.db.Query(ctx, listOccurrenceExceptions, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []RecurringOccurrenceException
+	for rows.Next() {
+		var i RecurringOccurrenceException
+		if err := rows.Scan(
+			&i.GroupID,
+			&i.OriginalDate,
+			&i.ExceptionType,
+			&i.BookingID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -426,7 +625,7 @@ func (q *Queries) ListActiveRecurringGroupsByClient(ctx context.Context, clientU
 }
 
 const listRecurringGroupsByClient = `-- name: ListRecurringGroupsByClient :many
-SELECT id, client_user_id, company_id, preferred_cleaner_id, address_id, recurrence_type, day_of_week, preferred_time, service_type, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total_per_occurrence, is_active, cancelled_at, cancellation_reason, created_at, updated_at FROM recurring_booking_groups
+SELECT id, client_user_id, company_id, preferred_cleaner_id, address_id, recurrence_type, day_of_week, preferred_time, service_type, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total_per_occurrence, is_active, cancelled_at, cancellation_reason, created_at, updated_at, estimated_duration_hours, generated_until, anchor_date, rrule, payment_failures FROM recurring_booking_groups
 WHERE client_user_id = $1
 ORDER BY created_at DESC
 `
@@ -463,6 +662,146 @@ func (q *Queries) ListRecurringGroupsByClient(ctx context.Context, clientUserID
 			&i.CancellationReason,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.EstimatedDurationHours,
+			&i.GeneratedUntil,
+			&i.AnchorDate,
+			&i.Rrule,
+			&i.PaymentFailures,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRecurringGroupsToExtend = `-- name: ListRecurringGroupsToExtend :many
+SELECT id, client_user_id, company_id, preferred_cleaner_id, address_id, recurrence_type, day_of_week, preferred_time, service_type, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total_per_occurrence, is_active, cancelled_at, cancellation_reason, created_at, updated_at, estimated_duration_hours, generated_until, anchor_date, rrule, payment_failures FROM recurring_booking_groups
+WHERE is_active = TRUE
+  AND cancelled_at IS NULL
+  AND (generated_until IS NULL OR generated_until < $1::date)
+ORDER BY created_at
+`
+
+// ListRecurringGroupsToExtend returns active groups whose occurrences are not
+// yet generated up to the horizon date.
+func (q *Queries) ListRecurringGroupsToExtend(ctx context.Context, horizon pgtype.Date) ([]RecurringBookingGroup, error) {
+	rows, err := q.db.Query(ctx, listRecurringGroupsToExtend, horizon)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []RecurringBookingGroup
+	for rows.Next() {
+		var i RecurringBookingGroup
+		if err := rows.Scan(
+			&i.ID,
+			&i.ClientUserID,
+			&i.CompanyID,
+			&i.PreferredCleanerID,
+			&i.AddressID,
+			&i.RecurrenceType,
+			&i.DayOfWeek,
+			&i.PreferredTime,
+			&i.ServiceType,
+			&i.PropertyType,
+			&i.NumRooms,
+			&i.NumBathrooms,
+			&i.AreaSqm,
+			&i.HasPets,
+			&i.SpecialInstructions,
+			&i.HourlyRate,
+			&i.EstimatedTotalPerOccurrence,
+			&i.IsActive,
+			&i.CancelledAt,
+			&i.CancellationReason,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.EstimatedDurationHours,
+			&i.GeneratedUntil,
+			&i.AnchorDate,
+			&i.Rrule,
+			&i.PaymentFailures,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRecurringOccurrencesToCharge = `-- name: ListRecurringOccurrencesToCharge :many
+SELECT b.id, b.reference_code, b.client_user_id, b.company_id, b.cleaner_id, b.address_id, b.service_type, b.scheduled_date, b.scheduled_start_time, b.estimated_duration_hours, b.property_type, b.num_rooms, b.num_bathrooms, b.area_sqm, b.has_pets, b.special_instructions, b.hourly_rate, b.estimated_total, b.final_total, b.platform_commission_pct, b.platform_commission_amount, b.status, b.started_at, b.completed_at, b.cancelled_at, b.cancellation_reason, b.stripe_payment_intent_id, b.payment_status, b.paid_at, b.created_at, b.updated_at, b.recurring_group_id, b.occurrence_number, b.team_size, b.holiday_surcharge, b.pets_surcharge FROM bookings b
+JOIN recurring_booking_groups g ON g.id = b.recurring_group_id
+WHERE g.is_active = TRUE
+  AND b.status IN ('pending', 'assigned', 'confirmed')
+  AND b.company_id IS NOT NULL
+  AND b.stripe_payment_intent_id IS NULL
+  AND COALESCE(b.payment_status, 'pending') = 'pending'
+  AND (b.scheduled_date + b.scheduled_start_time) AT TIME ZONE 'Europe/Bucharest' BETWEEN NOW() AND $1
+ORDER BY b.scheduled_date, b.scheduled_start_time
+LIMIT $2
+`
+
+type ListRecurringOccurrencesToChargeParams struct {
+	ChargeBefore pgtype.Timestamptz `json:"charge_before"`
+	Limit        int32              `json:"limit"`
+}
+
+// ListRecurringOccurrencesToCharge returns unpaid occurrences of active series
+// that start between now and charge_before and were never charged.
+func (q *Queries) ListRecurringOccurrencesToCharge(ctx context.Context, arg ListRecurringOccurrencesToChargeParams) ([]Booking, error) {
+	rows, err := q.db.Query(ctx, listRecurringOccurrencesToCharge, arg.ChargeBefore, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Booking
+	for rows.Next() {
+		var i Booking
+		if err := rows.Scan(
+			&i.ID,
+			&i.ReferenceCode,
+			&i.ClientUserID,
+			&i.CompanyID,
+			&i.CleanerID,
+			&i.AddressID,
+			&i.ServiceType,
+			&i.ScheduledDate,
+			&i.ScheduledStartTime,
+			&i.EstimatedDurationHours,
+			&i.PropertyType,
+			&i.NumRooms,
+			&i.NumBathrooms,
+			&i.AreaSqm,
+			&i.HasPets,
+			&i.SpecialInstructions,
+			&i.HourlyRate,
+			&i.EstimatedTotal,
+			&i.FinalTotal,
+			&i.PlatformCommissionPct,
+			&i.PlatformCommissionAmount,
+			&i.Status,
+			&i.StartedAt,
+			&i.CompletedAt,
+			&i.CancelledAt,
+			&i.CancellationReason,
+			&i.StripePaymentIntentID,
+			&i.PaymentStatus,
+			&i.PaidAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.RecurringGroupID,
+			&i.OccurrenceNumber,
+			&i.TeamSize,
+			&i.HolidaySurcharge,
+			&i.PetsSurcharge,
 		); err != nil {
 			return nil, err
 		}
@@ -476,8 +815,10 @@ func (q *Queries) ListRecurringGroupsByClient(ctx context.Context, clientUserID
 
 const pauseRecurringGroup = `-- name: PauseRecurringGroup :one
 UPDATE recurring_booking_groups
-SET is_active = FALSE, updated_at = NOW()
-WHERE id = $1 RETURNING id, client_user_id, company_id, preferred_cleaner_id, address_id, recurrence_type, day_of_week, preferred_time, service_type, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total_per_occurrence, is_active, cancelled_at, cancellation_reason, created_at, updated_at
+SET is_active = FALSE,
+    generated_until = LEAST(generated_until, CURRENT_DATE - 1),
+    updated_at = NOW()
+WHERE id = $1 RETURNING id, client_user_id, company_id, preferred_cleaner_id, address_id, recurrence_type, day_of_week, preferred_time, service_type, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total_per_occurrence, is_active, cancelled_at, cancellation_reason, created_at, updated_at, estimated_duration_hours, generated_until, anchor_date, rrule, payment_failures
 `
 
 func (q *Queries) PauseRecurringGroup(ctx context.Context, id pgtype.UUID) (RecurringBookingGroup, error) {
@@ -506,14 +847,29 @@ func (q *Queries) PauseRecurringGroup(ctx context.Context, id pgtype.UUID) (Recu
 		&i.CancellationReason,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.EstimatedDurationHours,
+		&i.GeneratedUntil,
+		&i.AnchorDate,
+		&i.Rrule,
+		&i.PaymentFailures,
 	)
 	return i, err
 }
 
+const resetRecurringPaymentFailures = `-- name: ResetRecurringPaymentFailures :exec
+UPDATE recurring_booking_groups SET payment_failures = 0, updated_at = NOW()
+WHERE id = $1 AND payment_failures > 0
+`
+
+func (q *Queries) ResetRecurringPaymentFailures(ctx context.Context, id pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, resetRecurringPaymentFailures, id)
+	return err
+}
+
 const resumeRecurringGroup = `-- name: ResumeRecurringGroup :one
 UPDATE recurring_booking_groups
-SET is_active = TRUE, cancelled_at = NULL, cancellation_reason = NULL, updated_at = NOW()
-WHERE id = $1 RETURNING id, client_user_id, company_id, preferred_cleaner_id, address_id, recurrence_type, day_of_week, preferred_time, service_type, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total_per_occurrence, is_active, cancelled_at, cancellation_reason, created_at, updated_at
+SET is_active = TRUE, cancelled_at = NULL, cancellation_reason = NULL, payment_failures = 0, updated_at = NOW()
+WHERE id = $1 RETURNING id, client_user_id, company_id, preferred_cleaner_id, address_id, recurrence_type, day_of_week, preferred_time, service_type, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total_per_occurrence, is_active, cancelled_at, cancellation_reason, created_at, updated_at, estimated_duration_hours, generated_until, anchor_date, rrule, payment_failures
 `
 
 func (q *Queries) ResumeRecurringGroup(ctx context.Context, id pgtype.UUID) (RecurringBookingGroup, error) {
@@ -542,6 +898,144 @@ func (q *Queries) ResumeRecurringGroup(ctx context.Context, id pgtype.UUID) (Rec
 		&i.CancellationReason,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.EstimatedDurationHours,
+		&i.GeneratedUntil,
+		&i.AnchorDate,
+		&i.Rrule,
+		&i.PaymentFailures,
+	)
+	return i, err
+}
+
+const setRecurringGroupGeneratedUntil = `-- name: SetRecurringGroupGeneratedUntil :exec
+UPDATE recurring_booking_groups
+SET generated_until = $2, updated_at = NOW()
+WHERE id = $1
+`
+
+type SetRecurringGroupGeneratedUntilParams struct {
+	ID             pgtype.UUID `json:"id"`
+	GeneratedUntil pgtype.Date `json:"generated_until"`
+}
+
+func (q *Queries) SetRecurringGroupGeneratedUntil(ctx context.Context, arg SetRecurringGroupGeneratedUntilParams) error {
+	_, err := q.db.Exec(ctx, setRecurringGroupGeneratedUntil, arg.ID, arg.GeneratedUntil)
+	return err
+}
+
+const updateRecurringGroupSeries = `-- name: UpdateRecurringGroupSeries :one
+UPDATE recurring_booking_groups
+SET day_of_week = $2,
+    preferred_time = $3,
+    property_type = $4,
+    num_rooms = $5,
+    num_bathrooms = $6,
+    area_sqm = $7,
+    has_pets = $8,
+    special_instructions = $9,
+    hourly_rate = $10,
+    estimated_total_per_occurrence = $11,
+    estimated_duration_hours = $12,
+    anchor_date = $13,
+    generated_until = $14,
+    recurrence_type = $15,
+    rrule = $16,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING id, client_user_id, company_id, preferred_cleaner_id, address_id, recurrence_type, day_of_week, preferred_time, service_type, property_type, num_rooms, num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total_per_occurrence, is_active, cancelled_at, cancellation_reason, created_at, updated_at, estimated_duration_hours, generated_until, anchor_date, rrule, payment_failures
+`
+
+type UpdateRecurringGroupSeriesParams struct {
+	ID                          pgtype.UUID    `json:"id"`
+	DayOfWeek                   pgtype.Int4    `json:"day_of_week"`
+	PreferredTime               pgtype.Time    `json:"preferred_time"`
+	PropertyType                pgtype.Text    `json:"property_type"`
+	NumRooms                    pgtype.Int4    `json:"num_rooms"`
+	NumBathrooms                pgtype.Int4    `json:"num_bathrooms"`
+	AreaSqm                     pgtype.Int4    `json:"area_sqm"`
+	HasPets                     pgtype.Bool    `json:"has_pets"`
+	SpecialInstructions         pgtype.Text    `json:"special_instructions"`
+	HourlyRate                  pgtype.Numeric `json:"hourly_rate"`
+	EstimatedTotalPerOccurrence pgtype.Numeric `json:"estimated_total_per_occurrence"`
+	EstimatedDurationHours      pgtype.Numeric `json:"estimated_duration_hours"`
+	AnchorDate                  pgtype.Date    `json:"anchor_date"`
+	GeneratedUntil              pgtype.Date    `json:"generated_until"`
+	RecurrenceType              RecurrenceType `json:"recurrence_type"`
+	Rrule                       pgtype.Text    `json:"rrule"`
+}
+
+func (q *Queries) UpdateRecurringGroupSeries(ctx context.Context, arg UpdateRecurringGroupSeriesParams) (RecurringBookingGroup, error) {
+	row := q.db.QueryRow(ctx, updateRecurringGroupSeries,
+		arg.ID,
+		arg.DayOfWeek,
+		arg.PreferredTime,
+		arg.PropertyType,
+		arg.NumRooms,
+		arg.NumBathrooms,
+		arg.AreaSqm,
+		arg.HasPets,
+		arg.SpecialInstructions,
+		arg.HourlyRate,
+		arg.EstimatedTotalPerOccurrence,
+		arg.EstimatedDurationHours,
+		arg.AnchorDate,
+		arg.GeneratedUntil,
+		arg.RecurrenceType,
+		arg.Rrule,
+	)
+	var i RecurringBookingGroup
+	err := row.Scan(
+		&i.ID,
+		&i.ClientUserID,
+		&i.CompanyID,
+		&i.PreferredCleanerID,
+		&i.AddressID,
+		&i.RecurrenceType,
+		&i.DayOfWeek,
+		&i.PreferredTime,
+		&i.ServiceType,
+		&i.PropertyType,
+		&i.NumRooms,
+		&i.NumBathrooms,
+		&i.AreaSqm,
+		&i.HasPets,
+		&i.SpecialInstructions,
+		&i.HourlyRate,
+		&i.EstimatedTotalPerOccurrence,
+		&i.IsActive,
+		&i.CancelledAt,
+		&i.CancellationReason,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.EstimatedDurationHours,
+		&i.GeneratedUntil,
+		&i.AnchorDate,
+		&i.Rrule,
+		&i.PaymentFailures,
 	)
 	return i, err
 }
+
+const upsertOccurrenceException = `-- name: UpsertOccurrenceException :exec
+INSERT INTO recurring_occurrence_exceptions (group_id, original_date, exception_type, booking_id)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (group_id, original_date) DO UPDATE
+SET exception_type = EXCLUDED.exception_type, booking_id = EXCLUDED.booking_id, updated_at = NOW()
+`
+
+type UpsertOccurrenceExceptionParams struct {
+	GroupID       pgtype.UUID             `json:"group_id"`
+	OriginalDate  pgtype.Date             `json:"original_date"`
+	ExceptionType OccurrenceExceptionType `json:"exception_type"`
+	BookingID     pgtype.UUID             `json:"booking_id"`
+}
+
+func (q *Queries) UpsertOccurrenceException(ctx context.Context, arg UpsertOccurrenceExceptionParams) error {
+	_, err := q.db.Exec(ctx, upsertOccurrenceException,
+		arg.GroupID,
+		arg.OriginalDate,
+		arg.ExceptionType,
+		arg.BookingID,
+	)
+	return err
+}
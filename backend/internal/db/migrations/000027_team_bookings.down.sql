@@ -0,0 +1,8 @@
+DELETE FROM platform_settings WHERE key = 'max_team_size';
+
+ALTER TABLE payout_line_items DROP COLUMN IF EXISTS cleaner_id;
+
+DROP INDEX IF EXISTS idx_booking_team_members_cleaner;
+DROP TABLE IF EXISTS booking_team_members;
+
+ALTER TABLE bookings DROP COLUMN IF EXISTS team_size;
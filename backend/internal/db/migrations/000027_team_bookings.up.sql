@@ -0,0 +1,26 @@
+-- Team bookings: large jobs (e.g. move-in/out of big properties) are split
+-- across N cleaners from the same company working the same time window.
+-- estimated_duration_hours stays the on-site window; labour hours are
+-- estimated_duration_hours * team_size.
+
+ALTER TABLE bookings ADD COLUMN team_size INTEGER NOT NULL DEFAULT 1 CHECK (team_size >= 1);
+
+CREATE TABLE booking_team_members (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    booking_id UUID NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+    cleaner_id UUID NOT NULL REFERENCES cleaners(id),
+    is_lead BOOLEAN NOT NULL DEFAULT FALSE,
+    pay_share DECIMAL(10,2),
+    checked_in_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (booking_id, cleaner_id)
+);
+
+CREATE INDEX idx_booking_team_members_cleaner ON booking_team_members(cleaner_id);
+
+ALTER TABLE payout_line_items ADD COLUMN cleaner_id UUID REFERENCES cleaners(id);
+
+INSERT INTO platform_settings (key, value, value_type, description) VALUES
+('max_team_size', '4', 'number', 'Numar maxim curatatori per rezervare in echipa')
+ON CONFLICT (key) DO NOTHING;
@@ -0,0 +1,8 @@
+DELETE FROM platform_settings WHERE key IN (
+    'dispatch_avg_speed_kmh',
+    'dispatch_default_travel_minutes',
+    'dispatch_regular_hours',
+    'dispatch_move_penalty_minutes'
+);
+
+DROP TABLE IF EXISTS cleaner_skills;
@@ -0,0 +1,17 @@
+-- Daily dispatch planning: cleaner skills restrict which service types a
+-- cleaner can be assigned to (no rows = all services), and the planner's
+-- travel/overtime cost model is admin-tunable.
+
+CREATE TABLE cleaner_skills (
+    cleaner_id UUID NOT NULL REFERENCES cleaners(id) ON DELETE CASCADE,
+    service_type service_type NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (cleaner_id, service_type)
+);
+
+INSERT INTO platform_settings (key, value, value_type, description) VALUES
+('dispatch_avg_speed_kmh', '25', 'number', 'Viteza medie de deplasare intre lucrari (km/h)'),
+('dispatch_default_travel_minutes', '20', 'number', 'Timp de deplasare presupus cand adresa nu are coordonate (minute)'),
+('dispatch_regular_hours', '8', 'number', 'Ore lucrate pe zi inainte de ore suplimentare'),
+('dispatch_move_penalty_minutes', '15', 'number', 'Cost (minute) pentru mutarea unei lucrari deja alocate')
+ON CONFLICT (key) DO NOTHING;
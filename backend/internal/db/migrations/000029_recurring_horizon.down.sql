@@ -0,0 +1,5 @@
+DELETE FROM platform_settings WHERE key = 'recurring_horizon_weeks';
+
+ALTER TABLE recurring_booking_groups
+    DROP COLUMN IF EXISTS generated_until,
+    DROP COLUMN IF EXISTS estimated_duration_hours;
@@ -0,0 +1,23 @@
+-- Rolling-horizon recurring bookings: occurrences are generated up to N weeks
+-- ahead by a scheduled job instead of a fixed 8 at creation time.
+-- generated_until is the last date the generator has materialised; pausing a
+-- group winds it back so resuming regenerates the cancelled dates.
+
+ALTER TABLE recurring_booking_groups
+    ADD COLUMN estimated_duration_hours DECIMAL(3,1),
+    ADD COLUMN generated_until DATE;
+
+UPDATE recurring_booking_groups g SET
+    estimated_duration_hours = (
+        SELECT b.estimated_duration_hours FROM bookings b
+        WHERE b.recurring_group_id = g.id
+        ORDER BY b.occurrence_number LIMIT 1
+    ),
+    generated_until = (
+        SELECT MAX(b.scheduled_date) FROM bookings b
+        WHERE b.recurring_group_id = g.id
+    );
+
+INSERT INTO platform_settings (key, value, value_type, description) VALUES
+('recurring_horizon_weeks', '8', 'number', 'Numarul de saptamani pentru care se genereaza in avans programarile recurente')
+ON CONFLICT (key) DO NOTHING;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS recurring_occurrence_exceptions;
+DROP TYPE IF EXISTS occurrence_exception_type;
+
+ALTER TABLE recurring_booking_groups DROP COLUMN IF EXISTS anchor_date;
@@ -0,0 +1,26 @@
+-- Per-occurrence exceptions for recurring series. A skipped or rescheduled
+-- occurrence is recorded against its original series date so that series
+-- edits and regeneration leave it alone. anchor_date is the date the series
+-- is counted from; it moves when an edit changes the day of the week.
+
+ALTER TABLE recurring_booking_groups ADD COLUMN anchor_date DATE;
+
+UPDATE recurring_booking_groups g SET anchor_date = (
+    SELECT b.scheduled_date FROM bookings b
+    WHERE b.recurring_group_id = g.id
+    ORDER BY b.occurrence_number LIMIT 1
+);
+
+CREATE TYPE occurrence_exception_type AS ENUM ('skipped', 'rescheduled');
+
+CREATE TABLE recurring_occurrence_exceptions (
+    group_id UUID NOT NULL REFERENCES recurring_booking_groups(id) ON DELETE CASCADE,
+    original_date DATE NOT NULL,
+    exception_type occurrence_exception_type NOT NULL,
+    booking_id UUID REFERENCES bookings(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (group_id, original_date)
+);
+
+CREATE INDEX idx_occurrence_exceptions_booking ON recurring_occurrence_exceptions(booking_id) WHERE booking_id IS NOT NULL;
@@ -0,0 +1,6 @@
+ALTER TABLE recurring_booking_groups DROP COLUMN IF EXISTS rrule;
+
+-- NOTE: 'custom' cannot be removed from the recurrence_type enum. Custom
+-- series are turned into weekly ones so the column only holds the original
+-- values.
+UPDATE recurring_booking_groups SET recurrence_type = 'weekly' WHERE recurrence_type = 'custom';
@@ -0,0 +1,25 @@
+-- Recurring series are described by an RFC 5545 RRULE (e.g.
+-- "FREQ=WEEKLY;BYDAY=MO,TH"). Skipped and rescheduled dates in
+-- recurring_occurrence_exceptions act as its EXDATEs. recurrence_type stays
+-- for existing clients; series created from a free-form rule are 'custom'.
+
+ALTER TYPE recurrence_type ADD VALUE IF NOT EXISTS 'custom';
+
+ALTER TABLE recurring_booking_groups ADD COLUMN rrule TEXT;
+
+-- Map the existing series onto equivalent rules. Monthly series anchored
+-- after the 28th take the last day of shorter months.
+UPDATE recurring_booking_groups SET rrule = CASE recurrence_type
+    WHEN 'weekly' THEN 'FREQ=WEEKLY;BYDAY='
+        || (ARRAY['SU','MO','TU','WE','TH','FR','SA'])[EXTRACT(DOW FROM anchor_date)::int + 1]
+    WHEN 'biweekly' THEN 'FREQ=WEEKLY;INTERVAL=2;BYDAY='
+        || (ARRAY['SU','MO','TU','WE','TH','FR','SA'])[EXTRACT(DOW FROM anchor_date)::int + 1]
+    WHEN 'monthly' THEN CASE
+        WHEN EXTRACT(DAY FROM anchor_date) <= 28
+            THEN 'FREQ=MONTHLY;BYMONTHDAY=' || EXTRACT(DAY FROM anchor_date)::int
+        ELSE 'FREQ=MONTHLY;BYMONTHDAY='
+            || array_to_string(ARRAY(SELECT generate_series(28, EXTRACT(DAY FROM anchor_date)::int)), ',')
+            || ';BYSETPOS=-1'
+        END
+    END
+WHERE anchor_date IS NOT NULL;
@@ -0,0 +1,9 @@
+DELETE FROM platform_settings WHERE key = 'holiday_surcharge_pct';
+
+ALTER TABLE bookings DROP COLUMN IF EXISTS holiday_surcharge;
+
+DROP TABLE IF EXISTS company_closures;
+
+ALTER TABLE companies
+    DROP COLUMN IF EXISTS holiday_surcharge_pct,
+    DROP COLUMN IF EXISTS works_on_holidays;
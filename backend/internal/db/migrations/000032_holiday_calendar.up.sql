@@ -0,0 +1,22 @@
+-- Holiday calendar. Romanian legal holidays are computed in code; companies
+-- add their own closure days. A company is closed on public holidays unless
+-- it opts in, in which case holiday bookings carry a surcharge on labour.
+
+ALTER TABLE companies
+    ADD COLUMN works_on_holidays BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN holiday_surcharge_pct DECIMAL(5,2);
+
+CREATE TABLE company_closures (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id UUID NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    closure_date DATE NOT NULL,
+    reason TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (company_id, closure_date)
+);
+
+ALTER TABLE bookings ADD COLUMN holiday_surcharge DECIMAL(10,2) NOT NULL DEFAULT 0;
+
+INSERT INTO platform_settings (key, value, value_type, description) VALUES
+('holiday_surcharge_pct', '25', 'number', 'Suprataxa implicita (%) pentru curatenia in zilele de sarbatoare legala')
+ON CONFLICT (key) DO NOTHING;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS stripe_events;
+DROP TYPE IF EXISTS stripe_event_status;
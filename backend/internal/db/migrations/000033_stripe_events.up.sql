@@ -0,0 +1,23 @@
+-- Stripe webhook event log. Every verified event is stored before it is
+-- processed, keyed by the Stripe event ID so redeliveries are recognised.
+-- Events that fail are retried by the stripe-events job with backoff and can
+-- be replayed by an admin.
+
+CREATE TYPE stripe_event_status AS ENUM ('pending', 'processing', 'processed', 'failed');
+
+CREATE TABLE stripe_events (
+    id VARCHAR(255) PRIMARY KEY,
+    event_type VARCHAR(255) NOT NULL,
+    payload JSONB NOT NULL,
+    status stripe_event_status NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMPTZ DEFAULT NOW(),
+    processed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_stripe_events_status ON stripe_events(status);
+CREATE INDEX idx_stripe_events_due ON stripe_events(next_attempt_at) WHERE status IN ('pending', 'failed', 'processing');
+CREATE INDEX idx_stripe_events_created ON stripe_events(created_at DESC);
@@ -0,0 +1,9 @@
+DELETE FROM platform_settings WHERE key IN ('payout_cadence', 'payout_min_amount');
+
+DROP INDEX IF EXISTS idx_payout_line_items_txn_cleaner;
+DROP INDEX IF EXISTS idx_company_payouts_retry;
+DROP INDEX IF EXISTS idx_company_payouts_stripe_payout;
+
+ALTER TABLE company_payouts
+    DROP COLUMN IF EXISTS next_attempt_at,
+    DROP COLUMN IF EXISTS attempts;
@@ -0,0 +1,21 @@
+-- Automated company payouts. Booking charges are destination charges, so the
+-- company's share already sits in its Connect balance; a payout is executed
+-- as a Stripe payout from that balance and its status follows the payout.*
+-- webhooks. Failed payouts are retried with backoff, then left for
+-- reconciliation.
+
+ALTER TABLE company_payouts
+    ADD COLUMN attempts INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN next_attempt_at TIMESTAMPTZ;
+
+CREATE UNIQUE INDEX idx_company_payouts_stripe_payout ON company_payouts(stripe_payout_id) WHERE stripe_payout_id IS NOT NULL;
+CREATE INDEX idx_company_payouts_retry ON company_payouts(next_attempt_at) WHERE status = 'failed';
+
+-- A transaction can only be paid out once. Cancelling a payout deletes its
+-- line items, which releases the transactions for the next payout.
+CREATE UNIQUE INDEX idx_payout_line_items_txn_cleaner ON payout_line_items(payment_transaction_id, cleaner_id);
+
+INSERT INTO platform_settings (key, value, value_type, description) VALUES
+('payout_cadence', 'weekly', 'string', 'Frecventa platilor automate catre companii: weekly, biweekly sau monthly'),
+('payout_min_amount', '100', 'number', 'Suma minima (RON) pentru o plata automata; sumele mai mici se reporteaza')
+ON CONFLICT (key) DO NOTHING;
@@ -0,0 +1,11 @@
+DELETE FROM platform_settings WHERE key IN ('payment_capture_mode', 'payment_capture_overage_pct');
+
+DROP INDEX IF EXISTS idx_payment_transactions_authorized;
+
+ALTER TABLE payment_transactions
+    DROP COLUMN IF EXISTS authorized_at,
+    DROP COLUMN IF EXISTS amount_authorized,
+    DROP COLUMN IF EXISTS capture_method;
+
+-- NOTE: the 'authorized' payment_transaction_status and 'payment_failed'
+-- notification_type values cannot be removed from their enums.
@@ -0,0 +1,21 @@
+-- Authorize at confirmation, capture at completion. In manual capture mode the
+-- booking payment is an authorization hold (optionally above the estimate, if
+-- the client consents to an overage) that is captured for the final total
+-- when the job is completed and released when the booking is cancelled.
+-- Card holds expire after 7 days, so holds for later bookings are renewed
+-- off-session with the saved card.
+
+ALTER TYPE payment_transaction_status ADD VALUE IF NOT EXISTS 'authorized' AFTER 'processing';
+ALTER TYPE notification_type ADD VALUE IF NOT EXISTS 'payment_failed';
+
+ALTER TABLE payment_transactions
+    ADD COLUMN capture_method VARCHAR(20) NOT NULL DEFAULT 'automatic',
+    ADD COLUMN amount_authorized INTEGER,
+    ADD COLUMN authorized_at TIMESTAMPTZ;
+
+CREATE INDEX idx_payment_transactions_authorized ON payment_transactions(authorized_at) WHERE status = 'authorized';
+
+INSERT INTO platform_settings (key, value, value_type, description) VALUES
+('payment_capture_mode', 'automatic', 'string', 'Modul de incasare: automatic (plata la confirmare) sau manual (autorizare la confirmare, incasare la finalizare)'),
+('payment_capture_overage_pct', '15', 'number', 'Depasirea maxima (%) peste totalul estimat pe care clientul o poate accepta la autorizare')
+ON CONFLICT (key) DO NOTHING;
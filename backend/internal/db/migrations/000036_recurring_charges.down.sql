@@ -0,0 +1,3 @@
+DELETE FROM platform_settings WHERE key IN ('recurring_charge_lead_hours', 'recurring_charge_max_failures');
+
+ALTER TABLE recurring_booking_groups DROP COLUMN IF EXISTS payment_failures;
@@ -0,0 +1,12 @@
+-- Off-session charging of recurring occurrences. Each occurrence is charged
+-- with the client's default saved card a configurable time before it starts.
+-- payment_failures counts consecutive failed charges of a series; reaching
+-- the configured maximum pauses the series.
+
+ALTER TABLE recurring_booking_groups
+    ADD COLUMN payment_failures INTEGER NOT NULL DEFAULT 0;
+
+INSERT INTO platform_settings (key, value, value_type, description) VALUES
+('recurring_charge_lead_hours', '48', 'number', 'Cu cate ore inainte de programare se incaseaza automat o programare recurenta'),
+('recurring_charge_max_failures', '3', 'number', 'Numarul de plati automate esuate consecutiv dupa care seria recurenta este pusa pe pauza')
+ON CONFLICT (key) DO NOTHING;
@@ -0,0 +1,10 @@
+DELETE FROM platform_settings WHERE key = 'ledger_reconciliation_days';
+
+DROP TABLE IF EXISTS ledger_reconciliations;
+DROP TABLE IF EXISTS ledger_lines;
+DROP TABLE IF EXISTS ledger_entries;
+DROP TABLE IF EXISTS ledger_accounts;
+DROP FUNCTION IF EXISTS ledger_check_balanced();
+DROP FUNCTION IF EXISTS ledger_reject_change();
+DROP TYPE IF EXISTS ledger_entry_kind;
+DROP TYPE IF EXISTS ledger_account_type;
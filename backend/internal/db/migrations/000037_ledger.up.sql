@@ -0,0 +1,167 @@
+-- Double-entry money ledger. Every payment, refund, payout, Stripe fee and
+-- commission invoice or credit note posts one journal entry whose lines sum
+-- to zero. Amounts are in bani; debits are positive, credits negative.
+-- Entries are append-only: a mistake is corrected by posting a reversing
+-- entry. The reference identifies the Stripe or database object that caused
+-- the entry, so posting is idempotent.
+
+CREATE TYPE ledger_account_type AS ENUM (
+  'stripe_balance', 'client', 'company', 'platform_fees', 'stripe_fees', 'vat_payable'
+);
+
+CREATE TYPE ledger_entry_kind AS ENUM (
+  'payment', 'refund', 'payout', 'stripe_fee', 'commission_invoice', 'credit_note'
+);
+
+-- One account per type; client and company accounts are per owner.
+CREATE TABLE ledger_accounts (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  account_type ledger_account_type NOT NULL,
+  owner_id UUID,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  UNIQUE NULLS NOT DISTINCT (account_type, owner_id)
+);
+
+CREATE TABLE ledger_entries (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  kind ledger_entry_kind NOT NULL,
+  reference VARCHAR(255) NOT NULL UNIQUE,
+  description TEXT NOT NULL,
+  booking_id UUID REFERENCES bookings(id),
+  company_id UUID REFERENCES companies(id),
+  occurred_at TIMESTAMPTZ NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_ledger_entries_occurred ON ledger_entries(occurred_at);
+CREATE INDEX idx_ledger_entries_booking ON ledger_entries(booking_id) WHERE booking_id IS NOT NULL;
+CREATE INDEX idx_ledger_entries_company ON ledger_entries(company_id, occurred_at) WHERE company_id IS NOT NULL;
+
+CREATE TABLE ledger_lines (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  entry_id UUID NOT NULL REFERENCES ledger_entries(id),
+  account_id UUID NOT NULL REFERENCES ledger_accounts(id),
+  amount BIGINT NOT NULL CHECK (amount <> 0)
+);
+
+CREATE INDEX idx_ledger_lines_entry ON ledger_lines(entry_id);
+CREATE INDEX idx_ledger_lines_account ON ledger_lines(account_id);
+
+CREATE FUNCTION ledger_reject_change() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'ledger is append-only: % on % is not allowed', TG_OP, TG_TABLE_NAME;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER ledger_entries_append_only BEFORE UPDATE OR DELETE ON ledger_entries
+  FOR EACH ROW EXECUTE FUNCTION ledger_reject_change();
+CREATE TRIGGER ledger_lines_append_only BEFORE UPDATE OR DELETE ON ledger_lines
+  FOR EACH ROW EXECUTE FUNCTION ledger_reject_change();
+
+-- Checked at commit, once all lines of an entry are inserted.
+CREATE FUNCTION ledger_check_balanced() RETURNS trigger AS $$
+BEGIN
+  IF (SELECT SUM(amount) FROM ledger_lines WHERE entry_id = NEW.entry_id) <> 0 THEN
+    RAISE EXCEPTION 'ledger entry % does not balance', NEW.entry_id;
+  END IF;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER ledger_lines_balanced AFTER INSERT ON ledger_lines
+  DEFERRABLE INITIALLY DEFERRED
+  FOR EACH ROW EXECUTE FUNCTION ledger_check_balanced();
+
+-- Results of the reconciliation job, which diffs the ledger against the
+-- platform's Stripe balance transactions.
+CREATE TABLE ledger_reconciliations (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  period_from TIMESTAMPTZ NOT NULL,
+  period_to TIMESTAMPTZ NOT NULL,
+  checked_count INTEGER NOT NULL DEFAULT 0,
+  fees_posted INTEGER NOT NULL DEFAULT 0,
+  discrepancies JSONB NOT NULL DEFAULT '[]',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_ledger_reconciliations_created ON ledger_reconciliations(created_at DESC);
+
+-- Opening entries for the payments, refunds and payouts made before the
+-- ledger existed, booked like PaymentEntry, RefundEntry and PayoutEntry.
+-- Only the last refund of a transaction was recorded before.
+CREATE TEMPORARY TABLE ledger_backfill (
+  kind ledger_entry_kind,
+  reference VARCHAR(255),
+  description TEXT,
+  booking_id UUID,
+  company_id UUID,
+  occurred_at TIMESTAMPTZ,
+  account_type ledger_account_type,
+  owner_id UUID,
+  amount BIGINT
+);
+
+INSERT INTO ledger_backfill
+SELECT 'payment', 'payment:' || pt.stripe_payment_intent_id, 'Plata ' || pt.stripe_payment_intent_id,
+       pt.booking_id, b.company_id, pt.created_at, v.account_type, v.owner_id, v.amount
+FROM payment_transactions pt
+JOIN bookings b ON b.id = pt.booking_id
+CROSS JOIN LATERAL (VALUES
+  ('stripe_balance'::ledger_account_type, NULL::uuid, pt.amount_total::bigint),
+  ('client', b.client_user_id, -pt.amount_total::bigint),
+  ('client', b.client_user_id, pt.amount_total::bigint),
+  ('company', b.company_id, -pt.amount_company::bigint),
+  ('platform_fees', NULL, -pt.amount_platform_fee::bigint)
+) AS v(account_type, owner_id, amount)
+WHERE pt.status IN ('succeeded', 'refunded', 'partially_refunded')
+  AND b.company_id IS NOT NULL AND v.amount <> 0;
+
+INSERT INTO ledger_backfill
+SELECT 'refund', 'refund:' || pt.stripe_refund_id, 'Rambursare ' || pt.stripe_refund_id || ' pentru plata ' || pt.stripe_payment_intent_id,
+       pt.booking_id, b.company_id, pt.updated_at, v.account_type, v.owner_id, v.amount
+FROM payment_transactions pt
+JOIN bookings b ON b.id = pt.booking_id
+CROSS JOIN LATERAL (SELECT CASE
+  WHEN pt.refund_amount >= pt.amount_total THEN pt.amount_platform_fee::bigint
+  ELSE (2 * pt.amount_platform_fee::bigint * pt.refund_amount + pt.amount_total) / (2 * pt.amount_total)
+END AS fee) f
+CROSS JOIN LATERAL (VALUES
+  ('company'::ledger_account_type, b.company_id, pt.refund_amount::bigint - f.fee),
+  ('platform_fees', NULL::uuid, f.fee),
+  ('client', b.client_user_id, -pt.refund_amount::bigint),
+  ('client', b.client_user_id, pt.refund_amount::bigint),
+  ('stripe_balance', NULL, -pt.refund_amount::bigint)
+) AS v(account_type, owner_id, amount)
+WHERE pt.status IN ('refunded', 'partially_refunded')
+  AND pt.stripe_refund_id IS NOT NULL AND pt.refund_amount > 0 AND pt.amount_total > 0
+  AND b.company_id IS NOT NULL AND v.amount <> 0;
+
+INSERT INTO ledger_backfill
+SELECT 'payout', 'payout:' || p.id, 'Plata catre companie', NULL, p.company_id,
+       COALESCE(p.paid_at, p.updated_at), v.account_type, v.owner_id, v.amount
+FROM company_payouts p
+CROSS JOIN LATERAL (VALUES
+  ('company'::ledger_account_type, p.company_id, p.amount::bigint),
+  ('stripe_balance', NULL::uuid, -p.amount::bigint)
+) AS v(account_type, owner_id, amount)
+WHERE p.status = 'paid' AND p.amount > 0;
+
+INSERT INTO ledger_accounts (account_type, owner_id)
+SELECT DISTINCT account_type, owner_id FROM ledger_backfill
+ON CONFLICT (account_type, owner_id) DO NOTHING;
+
+INSERT INTO ledger_entries (kind, reference, description, booking_id, company_id, occurred_at)
+SELECT DISTINCT kind, reference, description, booking_id, company_id, occurred_at FROM ledger_backfill
+ON CONFLICT (reference) DO NOTHING;
+
+INSERT INTO ledger_lines (entry_id, account_id, amount)
+SELECT e.id, a.id, f.amount
+FROM ledger_backfill f
+JOIN ledger_entries e ON e.reference = f.reference
+JOIN ledger_accounts a ON a.account_type = f.account_type AND a.owner_id IS NOT DISTINCT FROM f.owner_id;
+
+DROP TABLE ledger_backfill;
+
+INSERT INTO platform_settings (key, value, value_type, description) VALUES
+('ledger_reconciliation_days', '3', 'number', 'Cate zile de tranzactii Stripe sunt comparate cu registrul contabil la fiecare reconciliere')
+ON CONFLICT (key) DO NOTHING;
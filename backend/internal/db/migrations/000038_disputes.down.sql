@@ -0,0 +1,7 @@
+UPDATE company_payouts SET status = 'pending', updated_at = NOW() WHERE status = 'held';
+
+DROP TABLE IF EXISTS payment_disputes;
+DROP TYPE IF EXISTS dispute_status;
+
+-- NOTE: the 'payment_disputed' notification_type, 'held' payout_status and
+-- 'dispute' ledger_entry_kind values cannot be removed from their enums.
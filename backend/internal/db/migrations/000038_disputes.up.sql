@@ -0,0 +1,38 @@
+-- Stripe disputes (chargebacks). Disputes are stored from the
+-- charge.dispute.* webhooks and linked to the booking and its payment. A
+-- disputed payment is held out of payouts and unexecuted payouts that
+-- contain it are put on hold until the dispute is won. Admins assemble the
+-- evidence, which is submitted to Stripe through the API.
+
+CREATE TYPE dispute_status AS ENUM (
+  'warning_needs_response', 'warning_under_review', 'warning_closed',
+  'needs_response', 'under_review', 'won', 'lost'
+);
+
+ALTER TYPE notification_type ADD VALUE IF NOT EXISTS 'payment_disputed';
+ALTER TYPE payout_status ADD VALUE IF NOT EXISTS 'held' AFTER 'pending';
+ALTER TYPE ledger_entry_kind ADD VALUE IF NOT EXISTS 'dispute';
+
+CREATE TABLE payment_disputes (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  stripe_dispute_id VARCHAR(255) NOT NULL UNIQUE,
+  stripe_charge_id VARCHAR(255) NOT NULL,
+  payment_transaction_id UUID REFERENCES payment_transactions(id),
+  booking_id UUID REFERENCES bookings(id),
+  company_id UUID REFERENCES companies(id),
+  amount INTEGER NOT NULL,
+  currency VARCHAR(3) NOT NULL DEFAULT 'ron',
+  reason VARCHAR(50) NOT NULL,
+  status dispute_status NOT NULL,
+  evidence_due_by TIMESTAMPTZ,
+  -- Evidence assembled by an admin, submitted as is.
+  evidence JSONB,
+  evidence_submitted_at TIMESTAMPTZ,
+  closed_at TIMESTAMPTZ,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_payment_disputes_txn ON payment_disputes(payment_transaction_id);
+CREATE INDEX idx_payment_disputes_booking ON payment_disputes(booking_id);
+CREATE INDEX idx_payment_disputes_status ON payment_disputes(status, created_at DESC);
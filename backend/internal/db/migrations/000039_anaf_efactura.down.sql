@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_invoices_efactura_pending;
+
+ALTER TABLE invoices
+  DROP COLUMN IF EXISTS efactura_message,
+  DROP COLUMN IF EXISTS efactura_download_id,
+  DROP COLUMN IF EXISTS efactura_response_path,
+  DROP COLUMN IF EXISTS efactura_uploaded_at,
+  DROP COLUMN IF EXISTS efactura_checked_at;
+
+DROP TABLE IF EXISTS anaf_tokens;
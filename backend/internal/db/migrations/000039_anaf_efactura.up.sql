@@ -0,0 +1,29 @@
+-- Direct e-Factura integration with the ANAF SPV API. A company (or the
+-- platform, for commission invoices) connects its ANAF account through
+-- OAuth2; the tokens are stored per fiscal code (CIF) and used to upload the
+-- UBL XML of the invoices it issues. Uploaded invoices are polled until ANAF
+-- accepts or rejects them and the signed response is kept in storage.
+
+CREATE TABLE anaf_tokens (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  cif VARCHAR(20) NOT NULL UNIQUE,
+  company_id UUID REFERENCES companies(id) ON DELETE CASCADE,
+  access_token TEXT NOT NULL,
+  refresh_token TEXT NOT NULL,
+  expires_at TIMESTAMPTZ NOT NULL,
+  connected_by_user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- efactura_status follows the SPV lifecycle for direct uploads:
+-- uploaded -> processing -> accepted | rejected.
+ALTER TABLE invoices
+  ADD COLUMN efactura_message TEXT,
+  ADD COLUMN efactura_download_id VARCHAR(50),
+  ADD COLUMN efactura_response_path TEXT,
+  ADD COLUMN efactura_uploaded_at TIMESTAMPTZ,
+  ADD COLUMN efactura_checked_at TIMESTAMPTZ;
+
+CREATE INDEX idx_invoices_efactura_pending ON invoices(efactura_uploaded_at)
+  WHERE efactura_status IN ('uploaded', 'processing');
@@ -0,0 +1,3 @@
+-- Invoices are rendered as PDF by the backend itself, independently of
+-- Factureaza.ro; pdf_path is the private storage path of the rendered file.
+ALTER TABLE invoices ADD COLUMN pdf_path TEXT;
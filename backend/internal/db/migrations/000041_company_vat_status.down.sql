@@ -0,0 +1,3 @@
+ALTER TABLE companies
+  DROP COLUMN IF EXISTS vat_checked_at,
+  DROP COLUMN IF EXISTS is_vat_payer;
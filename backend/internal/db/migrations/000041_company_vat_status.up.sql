@@ -0,0 +1,6 @@
+-- VAT registration of partner companies, as reported by the ANAF registry.
+-- is_vat_payer is NULL until the company has been checked; invoices re-check
+-- it once vat_checked_at is older than 30 days.
+ALTER TABLE companies
+  ADD COLUMN is_vat_payer BOOLEAN,
+  ADD COLUMN vat_checked_at TIMESTAMPTZ;
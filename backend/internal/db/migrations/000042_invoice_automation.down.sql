@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS idx_invoices_pipeline_due;
+
+ALTER TABLE invoices
+  DROP COLUMN IF EXISTS emailed_at,
+  DROP COLUMN IF EXISTS pipeline_updated_at,
+  DROP COLUMN IF EXISTS pipeline_next_attempt_at,
+  DROP COLUMN IF EXISTS pipeline_error,
+  DROP COLUMN IF EXISTS pipeline_attempts,
+  DROP COLUMN IF EXISTS pipeline_status;
+
+DROP TABLE IF EXISTS company_invoice_settings;
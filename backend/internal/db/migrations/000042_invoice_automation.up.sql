@@ -0,0 +1,31 @@
+-- Automatic invoicing. Each company chooses when the client invoice of a
+-- booking is issued (manually, when the job is completed or when the payment
+-- succeeds), whether its PDF is emailed to the buyer and whether invoices of
+-- business buyers are transmitted to e-Factura. Only bookings completed or
+-- paid after auto_issue_since are issued automatically.
+
+CREATE TABLE company_invoice_settings (
+  company_id UUID PRIMARY KEY REFERENCES companies(id) ON DELETE CASCADE,
+  auto_issue_trigger VARCHAR(20) NOT NULL DEFAULT 'manual'
+    CHECK (auto_issue_trigger IN ('manual', 'job_completed', 'payment_succeeded')),
+  auto_email BOOLEAN NOT NULL DEFAULT FALSE,
+  auto_efactura BOOLEAN NOT NULL DEFAULT FALSE,
+  auto_issue_since TIMESTAMPTZ,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- The pipeline of a client invoice (PDF, email, e-Factura) runs right after
+-- the invoice is issued; failed steps are retried with backoff like webhook
+-- events: pending -> processing -> done | failed. NULL for invoices issued
+-- before automation and for commission invoices.
+ALTER TABLE invoices
+  ADD COLUMN pipeline_status VARCHAR(20),
+  ADD COLUMN pipeline_attempts INTEGER NOT NULL DEFAULT 0,
+  ADD COLUMN pipeline_error TEXT,
+  ADD COLUMN pipeline_next_attempt_at TIMESTAMPTZ,
+  ADD COLUMN pipeline_updated_at TIMESTAMPTZ,
+  ADD COLUMN emailed_at TIMESTAMPTZ;
+
+CREATE INDEX idx_invoices_pipeline_due ON invoices(pipeline_next_attempt_at)
+  WHERE pipeline_status IN ('pending', 'processing', 'failed');
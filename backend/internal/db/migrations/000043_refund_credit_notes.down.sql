@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_invoices_refund;
+DROP INDEX IF EXISTS idx_invoices_credited;
+
+ALTER TABLE invoices
+  DROP COLUMN IF EXISTS payout_id,
+  DROP COLUMN IF EXISTS stripe_refund_id,
+  DROP COLUMN IF EXISTS credited_invoice_date,
+  DROP COLUMN IF EXISTS credited_invoice_number,
+  DROP COLUMN IF EXISTS credited_invoice_id;
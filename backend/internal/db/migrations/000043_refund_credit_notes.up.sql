@@ -0,0 +1,19 @@
+-- Credit notes issued for refunds. A credit note keeps the number and date of
+-- the invoice it corrects, which CIUS-RO requires as the preceding invoice
+-- reference, and the Stripe refund it was issued for: each refund is credited
+-- at most once on the client invoice and once on the commission invoice.
+ALTER TABLE invoices
+  ADD COLUMN credited_invoice_id UUID REFERENCES invoices(id),
+  ADD COLUMN credited_invoice_number VARCHAR(50),
+  ADD COLUMN credited_invoice_date DATE,
+  ADD COLUMN stripe_refund_id VARCHAR(255);
+
+CREATE INDEX idx_invoices_credited ON invoices(credited_invoice_id)
+  WHERE credited_invoice_id IS NOT NULL;
+CREATE UNIQUE INDEX idx_invoices_refund ON invoices(stripe_refund_id, invoice_type)
+  WHERE stripe_refund_id IS NOT NULL;
+
+-- Commission invoices are issued per payout; the payout's line items tell
+-- which bookings an invoice charged commission for.
+ALTER TABLE invoices
+  ADD COLUMN payout_id UUID REFERENCES company_payouts(id);
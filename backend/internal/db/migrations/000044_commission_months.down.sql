@@ -0,0 +1,11 @@
+ALTER TABLE invoice_sequences
+  DROP CONSTRAINT invoice_sequences_company_id_prefix_year_key,
+  ADD CONSTRAINT invoice_sequences_company_id_prefix_year_key UNIQUE (company_id, prefix, year);
+
+ALTER TABLE platform_legal_entity DROP COLUMN IF EXISTS invoice_series;
+
+DROP INDEX IF EXISTS idx_invoices_commission_period;
+
+ALTER TABLE invoices
+  DROP COLUMN IF EXISTS period_to,
+  DROP COLUMN IF EXISTS period_from;
@@ -0,0 +1,29 @@
+-- Monthly commission invoices. At month end the platform issues each
+-- company one commission invoice for the platform fees of the payments it
+-- received in the month, net of the fees refunded before the invoice was
+-- issued; later refunds are credited with a credit note. The invoice keeps
+-- the month it covers, and a company is invoiced at most once per month.
+ALTER TABLE invoices
+  ADD COLUMN period_from DATE,
+  ADD COLUMN period_to DATE;
+
+CREATE UNIQUE INDEX idx_invoices_commission_period ON invoices(company_id, period_from)
+  WHERE invoice_type = 'platform_commission' AND period_from IS NOT NULL
+    AND status NOT IN ('cancelled', 'credit_note');
+
+-- Series of the invoices the platform issues.
+ALTER TABLE platform_legal_entity
+  ADD COLUMN invoice_series VARCHAR(20) NOT NULL DEFAULT 'HMC';
+
+-- Platform sequences have no company, and NULLs never conflicted: every
+-- platform invoice number added a sequence row. Keep the most advanced one
+-- and make NULL companies conflict.
+DELETE FROM invoice_sequences s
+USING invoice_sequences t
+WHERE s.company_id IS NULL AND t.company_id IS NULL
+  AND s.prefix = t.prefix AND s.year = t.year
+  AND (s.current_number < t.current_number OR (s.current_number = t.current_number AND s.id < t.id));
+
+ALTER TABLE invoice_sequences
+  DROP CONSTRAINT invoice_sequences_company_id_prefix_year_key,
+  ADD CONSTRAINT invoice_sequences_company_id_prefix_year_key UNIQUE NULLS NOT DISTINCT (company_id, prefix, year);
@@ -0,0 +1,29 @@
+-- Accounting exports: the invoices and ledger of a company, or of the
+-- platform when company_id is NULL, for a period, in the import format of an
+-- accounting program. Files are generated in the background like invoice
+-- pipelines (pending -> processing -> ready | failed) and kept in private
+-- storage for download.
+CREATE TABLE accounting_exports (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  company_id UUID REFERENCES companies(id) ON DELETE CASCADE,
+  requested_by UUID REFERENCES users(id) ON DELETE SET NULL,
+  format VARCHAR(30) NOT NULL
+    CHECK (format IN ('saga_xml', 'winmentor_csv', 'ledger_csv', 'sales_journal_csv')),
+  period_from DATE NOT NULL,
+  period_to DATE NOT NULL,
+  status VARCHAR(20) NOT NULL DEFAULT 'pending'
+    CHECK (status IN ('pending', 'processing', 'ready', 'failed')),
+  attempts INTEGER NOT NULL DEFAULT 0,
+  file_path TEXT,
+  file_name VARCHAR(255),
+  record_count INTEGER,
+  error TEXT,
+  completed_at TIMESTAMPTZ,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  CHECK (period_from <= period_to)
+);
+
+CREATE INDEX idx_accounting_exports_company ON accounting_exports(company_id, created_at DESC);
+CREATE INDEX idx_accounting_exports_due ON accounting_exports(updated_at)
+  WHERE status IN ('pending', 'processing');
@@ -0,0 +1,21 @@
+CREATE TABLE invoice_sequences (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  company_id UUID REFERENCES companies(id),
+  prefix VARCHAR(20) NOT NULL,
+  current_number INTEGER NOT NULL DEFAULT 0,
+  year INTEGER NOT NULL,
+  CONSTRAINT invoice_sequences_company_id_prefix_year_key UNIQUE NULLS NOT DISTINCT (company_id, prefix, year)
+);
+
+INSERT INTO invoice_sequences (company_id, prefix, current_number, year)
+SELECT company_id, prefix, next_number - 1, current_year
+FROM invoice_series;
+
+ALTER TABLE platform_legal_entity ADD COLUMN invoice_series VARCHAR(20) NOT NULL DEFAULT 'HMC';
+
+UPDATE platform_legal_entity
+SET invoice_series = s.prefix
+FROM invoice_series s
+WHERE s.company_id IS NULL AND s.document_type = 'invoice' AND s.active;
+
+DROP TABLE IF EXISTS invoice_series;
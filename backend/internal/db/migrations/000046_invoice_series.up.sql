@@ -0,0 +1,53 @@
+-- Invoice series managed by the companies, and by the platform for its
+-- commission invoices (company_id NULL). Invoices and credit notes are
+-- numbered in separate series. Each seller has at most one active series
+-- per document type, and a prefix is never reused once its series is
+-- retired. next_number is the number the next document takes; series with
+-- a yearly reset start again from start_number each year, counting the year
+-- in current_year. Numbers are taken in the transaction that inserts the
+-- invoice, so an invoice that fails to insert leaves no gap.
+CREATE TABLE invoice_series (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  company_id UUID REFERENCES companies(id) ON DELETE CASCADE,
+  document_type VARCHAR(20) NOT NULL CHECK (document_type IN ('invoice', 'credit_note')),
+  prefix VARCHAR(20) NOT NULL,
+  start_number INTEGER NOT NULL DEFAULT 1 CHECK (start_number > 0),
+  next_number INTEGER NOT NULL CHECK (next_number > 0),
+  yearly_reset BOOLEAN NOT NULL DEFAULT TRUE,
+  current_year INTEGER NOT NULL,
+  active BOOLEAN NOT NULL DEFAULT TRUE,
+  retired_at TIMESTAMPTZ,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  UNIQUE NULLS NOT DISTINCT (company_id, prefix)
+);
+
+CREATE UNIQUE INDEX idx_invoice_series_active ON invoice_series(company_id, document_type)
+  NULLS NOT DISTINCT WHERE active;
+
+-- The sequences in use become yearly series: per seller and document type
+-- the one used last stays active and the others are retired.
+INSERT INTO invoice_series (company_id, document_type, prefix, next_number, current_year, active, retired_at)
+SELECT company_id, document_type, prefix, current_number + 1, year, pos = 1,
+       CASE WHEN pos = 1 THEN NULL ELSE NOW() END
+FROM (
+  SELECT s.*, ROW_NUMBER() OVER (
+           PARTITION BY company_id, document_type ORDER BY year DESC, current_number DESC
+         ) AS pos
+  FROM (
+    SELECT DISTINCT ON (company_id, prefix) company_id, prefix, year, current_number,
+           CASE WHEN prefix = 'CN' THEN 'credit_note' ELSE 'invoice' END AS document_type
+    FROM invoice_sequences
+    ORDER BY company_id, prefix, year DESC
+  ) s
+) ranked;
+
+-- The platform's commission invoice series now lives with the others.
+INSERT INTO invoice_series (company_id, document_type, prefix, next_number, current_year)
+SELECT NULL, 'invoice', invoice_series, 1, EXTRACT(YEAR FROM NOW())::INTEGER
+FROM platform_legal_entity
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE platform_legal_entity DROP COLUMN invoice_series;
+
+DROP TABLE invoice_sequences;
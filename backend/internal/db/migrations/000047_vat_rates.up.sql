@@ -0,0 +1,20 @@
+-- Romanian VAT rates by category, each in force from valid_from until the
+-- next rate of its category. Documents use the rate in force on the date of
+-- the supply, so a change of rate never alters invoices issued under the old
+-- one.
+CREATE TABLE vat_rates (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  category VARCHAR(20) NOT NULL CHECK (category IN ('standard', 'reduced', 'exempt')),
+  rate_pct INTEGER NOT NULL CHECK (rate_pct BETWEEN 0 AND 100),
+  valid_from DATE NOT NULL,
+  description TEXT,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  UNIQUE (category, valid_from)
+);
+
+INSERT INTO vat_rates (category, rate_pct, valid_from, description) VALUES
+  ('standard', 19, '2017-01-01', 'Cota standard, Legea 227/2015'),
+  ('standard', 21, '2025-08-01', 'Cota standard, Legea 141/2025'),
+  ('reduced', 9, '2017-01-01', 'Cota redusa, Legea 227/2015'),
+  ('reduced', 11, '2025-08-01', 'Cota redusa, Legea 141/2025'),
+  ('exempt', 0, '2017-01-01', 'Scutit de TVA');
@@ -0,0 +1,25 @@
+-- Credit notes owed for Stripe refunds. The charge.refunded webhook records a
+-- row per refund in the transaction that posts the refund to the ledger, so a
+-- refund is never left without its credit notes: the invoice-pipeline job
+-- issues them, and posts the commission credit note to the ledger, retrying
+-- with backoff until both are done.
+
+CREATE TABLE refund_credits (
+    refund_id VARCHAR(255) PRIMARY KEY,
+    payment_intent_id VARCHAR(255) NOT NULL,
+    booking_id UUID NOT NULL REFERENCES bookings(id),
+    company_id UUID REFERENCES companies(id),
+    amount BIGINT NOT NULL,
+    payment_gross BIGINT NOT NULL,
+    payment_fee BIGINT NOT NULL,
+    refunded_at TIMESTAMPTZ NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'processing', 'done', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMPTZ DEFAULT NOW(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_refund_credits_due ON refund_credits(next_attempt_at) WHERE status IN ('pending', 'failed', 'processing');
@@ -0,0 +1,8 @@
+-- The pets surcharge a booking was priced with, stored like the holiday
+-- surcharge so invoices itemise it from the booking instead of working it
+-- out from the estimate. Bookings with pets so far were all priced with the
+-- flat 15 RON fee.
+
+ALTER TABLE bookings ADD COLUMN pets_surcharge DECIMAL(10,2) NOT NULL DEFAULT 0;
+
+UPDATE bookings SET pets_surcharge = 15 WHERE has_pets;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_invoices_seller_number;
+
+ALTER TABLE invoices ADD CONSTRAINT invoices_invoice_number_key UNIQUE (invoice_number);
@@ -0,0 +1,12 @@
+-- Invoice numbers are unique per seller, not across the platform: series
+-- prefixes are only unique within a seller, and every seller's default
+-- credit note series is CN. The seller of a client invoice is its company;
+-- commission invoices and their credit notes are sold by the platform, whose
+-- invoices have the buyer company in company_id.
+
+ALTER TABLE invoices DROP CONSTRAINT IF EXISTS invoices_invoice_number_key;
+
+CREATE UNIQUE INDEX idx_invoices_seller_number
+  ON invoices ((CASE WHEN invoice_type = 'client_service' THEN company_id END), invoice_number)
+  NULLS NOT DISTINCT
+  WHERE invoice_number IS NOT NULL;
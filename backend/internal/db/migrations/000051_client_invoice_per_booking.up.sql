@@ -0,0 +1,7 @@
+-- A booking has one client invoice. Auto-issue on completion, on payment and
+-- the invoice-pipeline job may all try to issue it at once; the index makes
+-- the later inserts issue nothing, so no second fiscal invoice takes a number
+-- of the series. Credit notes correct the invoice and are not counted.
+
+CREATE UNIQUE INDEX idx_invoices_client_booking ON invoices(booking_id)
+  WHERE invoice_type = 'client_service' AND credited_invoice_id IS NULL;
@@ -0,0 +1 @@
+ALTER TABLE payment_disputes DROP COLUMN IF EXISTS event_created_at;
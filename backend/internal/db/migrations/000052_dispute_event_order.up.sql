@@ -0,0 +1,5 @@
+-- Stripe does not deliver webhook events in order. The creation time of the
+-- event a dispute was last stored from lets an older event that arrives late
+-- be skipped instead of overwriting the dispute with a stale status.
+
+ALTER TABLE payment_disputes ADD COLUMN event_created_at TIMESTAMPTZ;
@@ -0,0 +1,44 @@
+-- name: CreateAccountingExport :one
+INSERT INTO accounting_exports (company_id, requested_by, format, period_from, period_to)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetAccountingExport :one
+SELECT * FROM accounting_exports WHERE id = $1;
+
+-- name: ListAccountingExports :many
+-- ListAccountingExports returns the latest exports of a company, or of the
+-- platform for a NULL company.
+SELECT * FROM accounting_exports
+WHERE company_id IS NOT DISTINCT FROM $1
+ORDER BY created_at DESC
+LIMIT $2;
+
+-- name: ClaimAccountingExport :one
+-- ClaimAccountingExport marks an export as being generated. It returns no
+-- rows when the export is finished or another worker holds a fresh claim.
+UPDATE accounting_exports
+SET status = 'processing', attempts = attempts + 1, updated_at = NOW()
+WHERE id = $1
+  AND (status = 'pending'
+       OR (status = 'processing' AND updated_at < NOW() - INTERVAL '10 minutes'))
+RETURNING *;
+
+-- name: MarkAccountingExportReady :one
+UPDATE accounting_exports
+SET status = 'ready', file_path = $2, file_name = $3, record_count = $4, error = NULL,
+    completed_at = NOW(), updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: MarkAccountingExportFailed :exec
+UPDATE accounting_exports
+SET status = 'failed', error = $2, completed_at = NOW(), updated_at = NOW()
+WHERE id = $1;
+
+-- name: ListDueAccountingExports :many
+SELECT * FROM accounting_exports
+WHERE status = 'pending'
+   OR (status = 'processing' AND updated_at < NOW() - INTERVAL '10 minutes')
+ORDER BY created_at
+LIMIT $1;
@@ -0,0 +1,26 @@
+-- ANAF OAuth2 tokens for direct e-Factura uploads, one per fiscal code.
+
+-- name: UpsertAnafToken :one
+-- UpsertAnafToken stores the OAuth2 tokens of the ANAF account connected for a
+-- fiscal code, replacing an earlier connection.
+INSERT INTO anaf_tokens (cif, company_id, access_token, refresh_token, expires_at, connected_by_user_id)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (cif) DO UPDATE SET
+  company_id = EXCLUDED.company_id,
+  access_token = EXCLUDED.access_token,
+  refresh_token = EXCLUDED.refresh_token,
+  expires_at = EXCLUDED.expires_at,
+  connected_by_user_id = EXCLUDED.connected_by_user_id,
+  updated_at = NOW()
+RETURNING *;
+
+-- name: GetAnafTokenByCIF :one
+SELECT * FROM anaf_tokens WHERE cif = $1;
+
+-- name: UpdateAnafTokens :one
+UPDATE anaf_tokens SET access_token = $2, refresh_token = $3, expires_at = $4, updated_at = NOW()
+WHERE cif = $1
+RETURNING *;
+
+-- name: DeleteAnafToken :exec
+DELETE FROM anaf_tokens WHERE cif = $1;
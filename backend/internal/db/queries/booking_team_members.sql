@@ -0,0 +1,34 @@
+-- name: AddBookingTeamMember :one
+INSERT INTO booking_team_members (booking_id, cleaner_id, is_lead)
+VALUES ($1, $2, $3)
+ON CONFLICT (booking_id, cleaner_id) DO UPDATE SET is_lead = EXCLUDED.is_lead
+RETURNING *;
+
+-- name: ListBookingTeamMembers :many
+SELECT * FROM booking_team_members WHERE booking_id = $1 ORDER BY is_lead DESC, created_at;
+
+-- name: GetBookingTeamMember :one
+SELECT * FROM booking_team_members WHERE booking_id = $1 AND cleaner_id = $2;
+
+-- name: DeleteBookingTeamMembers :exec
+DELETE FROM booking_team_members WHERE booking_id = $1;
+
+-- name: CheckInBookingTeamMember :one
+UPDATE booking_team_members SET checked_in_at = COALESCE(checked_in_at, NOW())
+WHERE booking_id = $1 AND cleaner_id = $2 RETURNING *;
+
+-- name: CompleteBookingTeamMember :one
+UPDATE booking_team_members
+SET checked_in_at = COALESCE(checked_in_at, NOW()), completed_at = COALESCE(completed_at, NOW())
+WHERE booking_id = $1 AND cleaner_id = $2 RETURNING *;
+
+-- name: CompleteAllBookingTeamMembers :exec
+UPDATE booking_team_members
+SET checked_in_at = COALESCE(checked_in_at, NOW()), completed_at = COALESCE(completed_at, NOW())
+WHERE booking_id = $1;
+
+-- name: CountPendingBookingTeamMembers :one
+SELECT COUNT(*) FROM booking_team_members WHERE booking_id = $1 AND completed_at IS NULL;
+
+-- name: SetBookingTeamMemberPayShare :exec
+UPDATE booking_team_members SET pay_share = $3 WHERE booking_id = $1 AND cleaner_id = $2;
@@ -14,21 +14,21 @@ SELECT * FROM bookings WHERE company_id = $1 ORDER BY created_at DESC LIMIT $2 O
 SELECT * FROM bookings WHERE company_id = $1 AND status = $2 ORDER BY created_at DESC LIMIT $3 OFFSET $4;
 
 -- name: ListBookingsByCleaner :many
-SELECT * FROM bookings WHERE cleaner_id = $1 ORDER BY scheduled_date DESC;
+SELECT * FROM bookings WHERE (cleaner_id = $1 OR id IN (SELECT booking_id FROM booking_team_members WHERE cleaner_id = $1)) ORDER BY scheduled_date DESC;
 
 -- name: ListBookingsByStatus :many
 SELECT * FROM bookings WHERE status = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3;
 
 -- name: ListTodaysJobsByCleaner :many
-SELECT * FROM bookings WHERE cleaner_id = $1 AND scheduled_date = CURRENT_DATE ORDER BY scheduled_start_time;
+SELECT * FROM bookings WHERE (cleaner_id = $1 OR id IN (SELECT booking_id FROM booking_team_members WHERE cleaner_id = $1)) AND scheduled_date = CURRENT_DATE ORDER BY scheduled_start_time;
 
 -- name: CreateBooking :one
 INSERT INTO bookings (
     reference_code, client_user_id, address_id, service_type, scheduled_date,
     scheduled_start_time, estimated_duration_hours, property_type, num_rooms,
     num_bathrooms, area_sqm, has_pets, special_instructions, hourly_rate, estimated_total,
-    recurring_group_id, occurrence_number
-) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+    recurring_group_id, occurrence_number, pets_surcharge
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
 RETURNING *;
 
 -- name: UpdateBookingStatus :one
@@ -43,8 +43,10 @@ UPDATE bookings SET status = 'in_progress', started_at = NOW(), updated_at = NOW
 WHERE id = $1 RETURNING *;
 
 -- name: CompleteBooking :one
+-- CompleteBooking returns no rows unless the booking is in progress, so a
+-- booking completed concurrently is completed once.
 UPDATE bookings SET status = 'completed', completed_at = NOW(), updated_at = NOW()
-WHERE id = $1 RETURNING *;
+WHERE id = $1 AND status = 'in_progress' RETURNING *;
 
 -- name: CountBookingsByStatus :one
 SELECT COUNT(*) FROM bookings WHERE status = $1;
@@ -53,20 +55,21 @@ SELECT COUNT(*) FROM bookings WHERE status = $1;
 SELECT COUNT(*) FROM bookings;
 
 -- name: CountCompletedJobsByCleaner :one
-SELECT COUNT(*) FROM bookings WHERE cleaner_id = $1 AND status = 'completed';
+SELECT COUNT(*) FROM bookings WHERE (cleaner_id = $1 OR id IN (SELECT booking_id FROM booking_team_members WHERE cleaner_id = $1)) AND status = 'completed';
 
 -- name: CountThisMonthJobsByCleaner :one
 SELECT COUNT(*) FROM bookings
-WHERE cleaner_id = $1
+WHERE (cleaner_id = $1 OR id IN (SELECT booking_id FROM booking_team_members WHERE cleaner_id = $1))
   AND scheduled_date >= date_trunc('month', CURRENT_DATE)::date
   AND status NOT IN ('cancelled_by_client', 'cancelled_by_company', 'cancelled_by_admin');
 
 -- name: SumThisMonthEarningsByCleaner :one
-SELECT COALESCE(SUM(COALESCE(final_total, estimated_total)), 0)::numeric AS total
-FROM bookings
-WHERE cleaner_id = $1
-  AND status = 'completed'
-  AND completed_at >= date_trunc('month', CURRENT_DATE);
+SELECT COALESCE(SUM(COALESCE(btm.pay_share, b.final_total, b.estimated_total)), 0)::numeric AS total
+FROM bookings b
+LEFT JOIN booking_team_members btm ON btm.booking_id = b.id AND btm.cleaner_id = $1
+WHERE (b.cleaner_id = $1 OR btm.id IS NOT NULL)
+  AND b.status = 'completed'
+  AND b.completed_at >= date_trunc('month', CURRENT_DATE);
 
 -- name: CancelBookingWithReason :one
 UPDATE bookings SET status = $2, cancelled_at = NOW(), cancellation_reason = $3, updated_at = NOW()
@@ -100,7 +103,7 @@ ORDER BY scheduled_date, scheduled_start_time;
 
 -- name: ListBookingsByCleanerAndDateRange :many
 SELECT * FROM bookings
-WHERE cleaner_id = $1
+WHERE (cleaner_id = $1 OR id IN (SELECT booking_id FROM booking_team_members WHERE cleaner_id = $1))
   AND scheduled_date >= @date_from::date
   AND scheduled_date <= @date_to::date
   AND status NOT IN ('cancelled_by_client', 'cancelled_by_company', 'cancelled_by_admin')
@@ -129,7 +132,7 @@ WHERE id = $1 RETURNING *;
 
 -- name: SearchCleanerBookings :many
 SELECT * FROM bookings WHERE
-    cleaner_id = $1
+    (cleaner_id = $1 OR id IN (SELECT booking_id FROM booking_team_members WHERE cleaner_id = $1))
     AND (@query::text = '' OR reference_code ILIKE '%' || @query::text || '%')
     AND (@status_filter::text = '' OR status::text = @status_filter::text OR (@status_filter::text = 'cancelled' AND status::text LIKE 'cancelled%'))
     AND (@date_from::date = '0001-01-01' OR scheduled_date >= @date_from::date)
@@ -138,7 +141,7 @@ ORDER BY created_at DESC LIMIT $2 OFFSET $3;
 
 -- name: CountSearchCleanerBookings :one
 SELECT COUNT(*) FROM bookings WHERE
-    cleaner_id = $1
+    (cleaner_id = $1 OR id IN (SELECT booking_id FROM booking_team_members WHERE cleaner_id = $1))
     AND (@query::text = '' OR reference_code ILIKE '%' || @query::text || '%')
     AND (@status_filter::text = '' OR status::text = @status_filter::text OR (@status_filter::text = 'cancelled' AND status::text LIKE 'cancelled%'))
     AND (@date_from::date = '0001-01-01' OR scheduled_date >= @date_from::date)
@@ -158,3 +161,63 @@ SELECT be.id, be.booking_id, be.extra_id, be.price, be.quantity,
 FROM booking_extras be
 JOIN service_extras se ON se.id = be.extra_id
 WHERE be.booking_id = $1;
+
+-- name: SetBookingTeamSize :one
+UPDATE bookings SET team_size = $2, estimated_duration_hours = $3, updated_at = NOW() WHERE id = $1 RETURNING *;
+
+-- name: ReassignBookingCleaner :one
+-- ReassignBookingCleaner moves a booking to another cleaner of the same company,
+-- failing with no rows if its cleaner changed since expected_cleaner_id was read
+-- or the booking is a started or team job.
+UPDATE bookings
+SET cleaner_id = @cleaner_id,
+    status = CASE WHEN status = 'pending' THEN 'assigned'::booking_status ELSE status END,
+    updated_at = NOW()
+WHERE id = @id
+  AND company_id = @company_id
+  AND cleaner_id IS NOT DISTINCT FROM @expected_cleaner_id
+  AND status IN ('pending', 'assigned', 'confirmed')
+  AND team_size = 1
+RETURNING *;
+
+-- name: SetBookingCompany :one
+-- SetBookingCompany attaches a booking to a company without assigning a cleaner.
+UPDATE bookings SET company_id = $2, updated_at = NOW()
+WHERE id = $1 RETURNING *;
+
+-- name: RescheduleBooking :one
+-- RescheduleBooking moves a not-yet-started booking to a new date and time with
+-- the cleaner who can do it; without a cleaner it goes back to pending.
+UPDATE bookings
+SET scheduled_date = @scheduled_date,
+    scheduled_start_time = @scheduled_start_time,
+    cleaner_id = @cleaner_id,
+    status = CASE WHEN @cleaner_id::uuid IS NULL THEN 'pending'::booking_status ELSE status END,
+    updated_at = NOW()
+WHERE id = @id
+  AND status IN ('pending', 'assigned', 'confirmed')
+  AND started_at IS NULL
+RETURNING *;
+
+-- name: SetBookingHolidaySurcharge :one
+-- SetBookingHolidaySurcharge replaces the holiday surcharge included in the
+-- booking's estimated total.
+UPDATE bookings
+SET estimated_total = estimated_total - holiday_surcharge + @holiday_surcharge,
+    holiday_surcharge = @holiday_surcharge,
+    updated_at = NOW()
+WHERE id = @id
+RETURNING *;
+
+-- name: ListBookingsAwaitingInvoice :many
+-- ListBookingsAwaitingInvoice returns bookings that their company's settings
+-- should have invoiced automatically but have no client invoice yet.
+SELECT b.* FROM bookings b
+JOIN company_invoice_settings s ON s.company_id = b.company_id
+WHERE ((s.auto_issue_trigger = 'job_completed' AND b.status = 'completed' AND b.completed_at >= s.auto_issue_since)
+    OR (s.auto_issue_trigger = 'payment_succeeded' AND b.payment_status = 'paid' AND b.paid_at >= s.auto_issue_since))
+  AND NOT EXISTS (
+    SELECT 1 FROM invoices i WHERE i.booking_id = b.id AND i.invoice_type = 'client_service'
+  )
+ORDER BY b.updated_at
+LIMIT $1;
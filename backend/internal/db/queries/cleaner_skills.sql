@@ -0,0 +1,10 @@
+-- name: ListCleanerSkills :many
+SELECT service_type FROM cleaner_skills WHERE cleaner_id = $1 ORDER BY service_type;
+
+-- name: AddCleanerSkill :exec
+INSERT INTO cleaner_skills (cleaner_id, service_type)
+VALUES ($1, $2)
+ON CONFLICT (cleaner_id, service_type) DO NOTHING;
+
+-- name: DeleteCleanerSkills :exec
+DELETE FROM cleaner_skills WHERE cleaner_id = $1;
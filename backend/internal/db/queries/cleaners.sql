@@ -66,11 +66,13 @@ SELECT
     c.rating_avg,
     COUNT(b.id) FILTER (WHERE b.status = 'completed')::bigint AS total_completed_jobs,
     COUNT(b.id) FILTER (WHERE b.status = 'completed' AND b.completed_at >= date_trunc('month', CURRENT_DATE))::bigint AS this_month_completed,
-    COALESCE(SUM(COALESCE(b.final_total, b.estimated_total)) FILTER (WHERE b.status = 'completed'), 0)::numeric AS total_earnings,
-    COALESCE(SUM(COALESCE(b.final_total, b.estimated_total)) FILTER (WHERE b.status = 'completed' AND b.completed_at >= date_trunc('month', CURRENT_DATE)), 0)::numeric AS this_month_earnings
+    COALESCE(SUM(COALESCE(btm.pay_share, b.final_total, b.estimated_total)) FILTER (WHERE b.status = 'completed'), 0)::numeric AS total_earnings,
+    COALESCE(SUM(COALESCE(btm.pay_share, b.final_total, b.estimated_total)) FILTER (WHERE b.status = 'completed' AND b.completed_at >= date_trunc('month', CURRENT_DATE)), 0)::numeric AS this_month_earnings
 FROM cleaners c
 JOIN users u ON c.user_id = u.id
 LEFT JOIN bookings b ON b.cleaner_id = c.id
+    OR b.id IN (SELECT booking_id FROM booking_team_members WHERE cleaner_id = c.id)
+LEFT JOIN booking_team_members btm ON btm.booking_id = b.id AND btm.cleaner_id = c.id
 WHERE c.id = $1
 GROUP BY c.id, u.full_name, c.rating_avg;
 
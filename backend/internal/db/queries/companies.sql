@@ -97,3 +97,13 @@ SELECT
   COUNT(CASE WHEN document_type = 'cui_document' AND status = 'approved' THEN 1 END) = 1 AS all_ready
 FROM company_documents
 WHERE company_id = $1;
+
+-- name: UpdateCompanyHolidayPolicy :one
+UPDATE companies
+SET works_on_holidays = $2, holiday_surcharge_pct = $3, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: SetCompanyVATStatus :exec
+-- SetCompanyVATStatus records the VAT registration of a company as checked now.
+UPDATE companies SET is_vat_payer = $2, vat_checked_at = NOW(), updated_at = NOW() WHERE id = $1;
@@ -0,0 +1,14 @@
+-- name: CreateCompanyClosure :one
+INSERT INTO company_closures (company_id, closure_date, reason)
+VALUES ($1, $2, $3)
+ON CONFLICT (company_id, closure_date) DO UPDATE SET reason = EXCLUDED.reason
+RETURNING *;
+
+-- name: DeleteCompanyClosure :exec
+DELETE FROM company_closures WHERE id = $1 AND company_id = $2;
+
+-- name: ListCompanyClosures :many
+SELECT * FROM company_closures
+WHERE company_id = @company_id
+  AND closure_date BETWEEN @date_from::date AND @date_to::date
+ORDER BY closure_date;
@@ -0,0 +1,19 @@
+-- name: GetCompanyInvoiceSettings :one
+SELECT * FROM company_invoice_settings WHERE company_id = $1;
+
+-- name: UpsertCompanyInvoiceSettings :one
+-- UpsertCompanyInvoiceSettings saves the invoicing settings of a company.
+-- Changing the trigger restarts auto_issue_since, so bookings completed or
+-- paid earlier are not invoiced retroactively.
+INSERT INTO company_invoice_settings (company_id, auto_issue_trigger, auto_email, auto_efactura, auto_issue_since)
+VALUES ($1, $2, $3, $4, NOW())
+ON CONFLICT (company_id) DO UPDATE SET
+  auto_issue_trigger = EXCLUDED.auto_issue_trigger,
+  auto_email = EXCLUDED.auto_email,
+  auto_efactura = EXCLUDED.auto_efactura,
+  auto_issue_since = CASE
+    WHEN company_invoice_settings.auto_issue_trigger = EXCLUDED.auto_issue_trigger THEN company_invoice_settings.auto_issue_since
+    ELSE NOW()
+  END,
+  updated_at = NOW()
+RETURNING *;
@@ -0,0 +1,56 @@
+-- name: UpsertPaymentDispute :one
+-- UpsertPaymentDispute stores a dispute from a webhook. It returns no rows for
+-- an event older than the one the dispute was last stored from, and a closed
+-- dispute keeps its final status.
+INSERT INTO payment_disputes (
+  stripe_dispute_id, stripe_charge_id, payment_transaction_id, booking_id, company_id,
+  amount, currency, reason, status, evidence_due_by, event_created_at, closed_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11,
+  CASE WHEN $9 IN ('won', 'lost', 'warning_closed') THEN NOW() END
+)
+ON CONFLICT (stripe_dispute_id) DO UPDATE SET
+  amount = EXCLUDED.amount,
+  reason = EXCLUDED.reason,
+  status = CASE WHEN payment_disputes.closed_at IS NOT NULL THEN payment_disputes.status ELSE EXCLUDED.status END,
+  evidence_due_by = EXCLUDED.evidence_due_by,
+  event_created_at = EXCLUDED.event_created_at,
+  closed_at = COALESCE(payment_disputes.closed_at, EXCLUDED.closed_at),
+  updated_at = NOW()
+WHERE payment_disputes.event_created_at IS NULL
+   OR payment_disputes.event_created_at <= EXCLUDED.event_created_at
+RETURNING *;
+
+-- name: GetPaymentDisputeByID :one
+SELECT * FROM payment_disputes WHERE id = $1;
+
+-- name: GetPaymentDisputeByStripeID :one
+SELECT * FROM payment_disputes WHERE stripe_dispute_id = $1;
+
+-- name: ListPaymentDisputes :many
+SELECT * FROM payment_disputes
+ORDER BY created_at DESC
+LIMIT $1 OFFSET $2;
+
+-- name: ListPaymentDisputesByStatus :many
+SELECT * FROM payment_disputes
+WHERE status = $1
+ORDER BY created_at DESC
+LIMIT $2 OFFSET $3;
+
+-- name: ListPaymentDisputesByBooking :many
+SELECT * FROM payment_disputes
+WHERE booking_id = $1
+ORDER BY created_at DESC;
+
+-- name: SetPaymentDisputeEvidence :one
+-- SetPaymentDisputeEvidence stores the evidence of a dispute. It returns no
+-- rows once the evidence was submitted.
+UPDATE payment_disputes SET evidence = $2, updated_at = NOW()
+WHERE id = $1 AND evidence_submitted_at IS NULL
+RETURNING *;
+
+-- name: MarkPaymentDisputeEvidenceSubmitted :one
+UPDATE payment_disputes SET evidence_submitted_at = NOW(), updated_at = NOW()
+WHERE id = $1
+RETURNING *;
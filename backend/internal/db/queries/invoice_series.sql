@@ -0,0 +1,48 @@
+-- name: ListInvoiceSeries :many
+-- ListInvoiceSeries returns the invoice series of a company, or of the
+-- platform for a NULL company, active series first.
+SELECT * FROM invoice_series
+WHERE company_id IS NOT DISTINCT FROM $1
+ORDER BY document_type, active DESC, created_at DESC;
+
+-- name: GetInvoiceSeries :one
+SELECT * FROM invoice_series WHERE id = $1;
+
+-- name: CreateInvoiceSeries :one
+INSERT INTO invoice_series (company_id, document_type, prefix, start_number, next_number, yearly_reset, current_year)
+VALUES ($1, $2, $3, $4, $4, $5, $6)
+RETURNING *;
+
+-- name: RetireActiveInvoiceSeries :exec
+UPDATE invoice_series
+SET active = FALSE, retired_at = NOW(), updated_at = NOW()
+WHERE company_id IS NOT DISTINCT FROM $1 AND document_type = $2 AND active;
+
+-- name: RetireInvoiceSeries :one
+UPDATE invoice_series
+SET active = FALSE, retired_at = COALESCE(retired_at, NOW()), updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: EnsureInvoiceSeries :exec
+-- EnsureInvoiceSeries creates the default series of a document type for a
+-- company that never had one. Companies that retired all their series of
+-- the type must create a new one.
+INSERT INTO invoice_series (company_id, document_type, prefix, next_number, current_year)
+SELECT $1::uuid, $2::text, $3::text, 1, $4::int
+WHERE NOT EXISTS (
+  SELECT 1 FROM invoice_series WHERE company_id IS NOT DISTINCT FROM $1 AND document_type = $2
+)
+ON CONFLICT DO NOTHING;
+
+-- name: AllocateInvoiceNumber :one
+-- AllocateInvoiceNumber takes the next number of the active series of a
+-- document type, starting the series again in a new year when it resets
+-- yearly. Run it in the transaction that inserts the invoice: the series row
+-- stays locked until the transaction ends and a rollback returns the number.
+UPDATE invoice_series
+SET next_number = CASE WHEN yearly_reset AND current_year <> $3 THEN start_number ELSE next_number END + 1,
+    current_year = $3,
+    updated_at = NOW()
+WHERE company_id IS NOT DISTINCT FROM $1 AND document_type = $2 AND active
+RETURNING prefix, yearly_reset, next_number - 1 AS number, current_year;
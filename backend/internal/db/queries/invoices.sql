@@ -27,6 +27,7 @@ DELETE FROM client_billing_profiles WHERE id = $1;
 -- ============================================
 
 -- name: CreateInvoice :one
+-- CreateInvoice returns no rows for a second client invoice of a booking.
 INSERT INTO invoices (
   invoice_type, invoice_number,
   seller_company_name, seller_cui, seller_reg_number, seller_address, seller_city, seller_county,
@@ -35,21 +36,27 @@ INSERT INTO invoices (
   buyer_is_vat_payer, buyer_email,
   subtotal_amount, vat_rate, vat_amount, total_amount, currency,
   booking_id, payment_transaction_id, company_id, client_user_id,
-  status, due_date, notes
+  status, due_date, notes,
+  credited_invoice_id, credited_invoice_number, credited_invoice_date, stripe_refund_id, payout_id,
+  period_from, period_to
 ) VALUES (
   $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11,
   $12, $13, $14, $15, $16, $17, $18, $19,
   $20, $21, $22, $23, $24,
   $25, $26, $27, $28,
-  $29, $30, $31
+  $29, $30, $31,
+  $32, $33, $34, $35, $36,
+  $37, $38
 )
+ON CONFLICT (booking_id) WHERE invoice_type = 'client_service' AND credited_invoice_id IS NULL DO NOTHING
 RETURNING *;
 
 -- name: GetInvoiceByID :one
 SELECT * FROM invoices WHERE id = $1;
 
 -- name: GetInvoiceByBookingAndType :one
-SELECT * FROM invoices WHERE booking_id = $1 AND invoice_type = $2 ORDER BY created_at DESC LIMIT 1;
+SELECT * FROM invoices WHERE booking_id = $1 AND invoice_type = $2 AND credited_invoice_id IS NULL
+ORDER BY created_at DESC LIMIT 1;
 
 -- name: UpdateInvoiceStatus :one
 UPDATE invoices SET status = $2, issued_at = CASE WHEN $2 = 'issued' THEN NOW() ELSE issued_at END, updated_at = NOW()
@@ -116,20 +123,6 @@ RETURNING *;
 -- name: ListInvoiceLineItems :many
 SELECT * FROM invoice_line_items WHERE invoice_id = $1 ORDER BY sort_order;
 
--- ============================================
--- INVOICE SEQUENCES
--- ============================================
-
--- name: GetNextInvoiceNumber :one
-UPDATE invoice_sequences SET current_number = current_number + 1
-WHERE company_id IS NOT DISTINCT FROM $1 AND prefix = $2 AND year = $3
-RETURNING current_number;
-
--- name: CreateInvoiceSequence :exec
-INSERT INTO invoice_sequences (company_id, prefix, year, current_number)
-VALUES ($1, $2, $3, 0)
-ON CONFLICT (company_id, prefix, year) DO NOTHING;
-
 -- ============================================
 -- INVOICE ANALYTICS (Admin reporting)
 -- ============================================
@@ -153,3 +146,148 @@ SELECT invoice_type, COUNT(*)::BIGINT as count, COALESCE(SUM(total_amount), 0)::
 FROM invoices
 WHERE created_at >= $1 AND created_at <= $2
 GROUP BY invoice_type;
+
+-- name: MarkInvoiceEFacturaUploaded :exec
+-- MarkInvoiceEFacturaUploaded records an upload to the ANAF SPV and resets the
+-- result of any earlier upload.
+UPDATE invoices SET
+  efactura_status = 'uploaded', efactura_index = $2, efactura_message = NULL,
+  efactura_download_id = NULL, efactura_response_path = NULL,
+  efactura_uploaded_at = NOW(), efactura_checked_at = NULL, updated_at = NOW()
+WHERE id = $1;
+
+-- name: SetInvoiceEFacturaResult :exec
+UPDATE invoices SET
+  efactura_status = $2, efactura_message = $3, efactura_download_id = $4,
+  efactura_response_path = $5, efactura_checked_at = NOW(), updated_at = NOW()
+WHERE id = $1;
+
+-- name: ListPendingEFacturaInvoices :many
+-- ListPendingEFacturaInvoices returns the invoices uploaded to the ANAF SPV
+-- that are still waiting for a result, oldest first.
+SELECT * FROM invoices
+WHERE efactura_status IN ('uploaded', 'processing')
+ORDER BY efactura_uploaded_at
+LIMIT $1;
+
+-- name: SetInvoicePDFPath :exec
+-- SetInvoicePDFPath records where the rendered PDF of an invoice is stored.
+UPDATE invoices SET pdf_path = $2, updated_at = NOW() WHERE id = $1;
+
+-- name: StartInvoicePipeline :exec
+UPDATE invoices
+SET pipeline_status = 'pending', pipeline_attempts = 0, pipeline_error = NULL,
+    pipeline_next_attempt_at = NOW(), pipeline_updated_at = NOW()
+WHERE id = $1;
+
+-- name: ClaimInvoicePipeline :one
+-- ClaimInvoicePipeline marks the pipeline of an invoice as running. It returns
+-- no rows when the pipeline is done or another worker holds a fresh claim.
+UPDATE invoices
+SET pipeline_status = 'processing', pipeline_attempts = pipeline_attempts + 1, pipeline_updated_at = NOW()
+WHERE id = $1
+  AND (pipeline_status IN ('pending', 'failed')
+       OR (pipeline_status = 'processing' AND pipeline_updated_at < NOW() - INTERVAL '10 minutes'))
+RETURNING *;
+
+-- name: MarkInvoicePipelineDone :exec
+UPDATE invoices
+SET pipeline_status = 'done', pipeline_error = NULL, pipeline_next_attempt_at = NULL, pipeline_updated_at = NOW()
+WHERE id = $1;
+
+-- name: MarkInvoicePipelineFailed :exec
+UPDATE invoices
+SET pipeline_status = 'failed', pipeline_error = $2, pipeline_next_attempt_at = $3, pipeline_updated_at = NOW()
+WHERE id = $1;
+
+-- name: ListDueInvoicePipelines :many
+SELECT * FROM invoices
+WHERE (pipeline_status IN ('pending', 'failed') AND pipeline_next_attempt_at <= NOW())
+   OR (pipeline_status = 'processing' AND pipeline_updated_at < NOW() - INTERVAL '10 minutes')
+ORDER BY created_at
+LIMIT $1;
+
+-- name: MarkInvoiceEmailed :exec
+UPDATE invoices SET emailed_at = NOW(), updated_at = NOW() WHERE id = $1;
+
+-- name: GetIssuedInvoiceByBooking :one
+-- GetIssuedInvoiceByBooking returns the invoice of a booking that credit notes
+-- correct, skipping cancelled invoices and earlier credit notes.
+SELECT * FROM invoices
+WHERE booking_id = $1 AND invoice_type = $2 AND status NOT IN ('cancelled', 'credit_note')
+ORDER BY created_at DESC LIMIT 1;
+
+-- name: GetCommissionInvoiceByBooking :one
+-- GetCommissionInvoiceByBooking returns the commission invoice that charged
+-- the platform fee of a booking, if one was issued: the invoice of the month
+-- the booking was paid in, or of the payout that included it.
+SELECT i.* FROM invoices i
+WHERE i.invoice_type = 'platform_commission' AND i.status NOT IN ('cancelled', 'credit_note')
+  AND (
+    i.payout_id IN (SELECT pli.payout_id FROM payout_line_items pli WHERE pli.booking_id = $1)
+    OR EXISTS (
+      SELECT 1 FROM ledger_entries e
+      WHERE e.kind = 'payment' AND e.booking_id = $1 AND e.company_id = i.company_id
+        AND (e.occurred_at AT TIME ZONE 'Europe/Bucharest')::date BETWEEN i.period_from AND i.period_to
+    )
+  )
+ORDER BY i.created_at DESC LIMIT 1;
+
+-- name: GetCreditNoteByRefund :one
+SELECT * FROM invoices WHERE stripe_refund_id = $1 AND invoice_type = $2;
+
+-- name: SumCreditedAmount :one
+-- SumCreditedAmount returns the total of the credit notes issued against an
+-- invoice, as a negative amount in bani.
+SELECT COALESCE(SUM(total_amount), 0)::bigint AS credited FROM invoices
+WHERE credited_invoice_id = $1 AND status = 'credit_note';
+
+-- name: GetCommissionInvoiceForPeriod :one
+SELECT * FROM invoices
+WHERE company_id = $1 AND period_from = $2 AND invoice_type = 'platform_commission'
+  AND status NOT IN ('cancelled', 'credit_note');
+
+-- name: ListCommissionMonth :many
+-- ListCommissionMonth sums, per company, the platform fees of the payments
+-- received between two instants and the fees refunded since on those
+-- bookings, leaving out refunds already credited on a commission invoice and
+-- bookings invoiced per payout.
+WITH payments AS (
+  SELECT e.id, e.kind, e.booking_id, e.company_id FROM ledger_entries e
+  WHERE e.kind = 'payment' AND e.company_id IS NOT NULL
+    AND e.occurred_at >= $1 AND e.occurred_at < $2
+    AND NOT EXISTS (
+      SELECT 1 FROM payout_line_items pli
+      JOIN invoices i ON i.payout_id = pli.payout_id
+      WHERE pli.booking_id = e.booking_id AND i.invoice_type = 'platform_commission'
+        AND i.status NOT IN ('cancelled', 'credit_note')
+    )
+), refunds AS (
+  SELECT e.id, e.kind, e.booking_id, e.company_id FROM ledger_entries e
+  WHERE e.kind = 'refund' AND e.booking_id IN (SELECT booking_id FROM payments)
+    AND NOT EXISTS (
+      SELECT 1 FROM invoices i
+      WHERE i.invoice_type = 'platform_commission' AND i.stripe_refund_id IS NOT NULL
+        AND e.reference = 'refund:' || i.stripe_refund_id
+    )
+)
+SELECT x.company_id,
+       COUNT(DISTINCT x.booking_id) FILTER (WHERE x.kind = 'payment')::int AS booking_count,
+       COALESCE(SUM(-l.amount) FILTER (WHERE x.kind = 'payment'), 0)::bigint AS commission,
+       COALESCE(SUM(l.amount) FILTER (WHERE x.kind = 'refund'), 0)::bigint AS refunded
+FROM (SELECT * FROM payments UNION ALL SELECT * FROM refunds) x
+JOIN ledger_lines l ON l.entry_id = x.id
+JOIN ledger_accounts a ON a.id = l.account_id AND a.account_type = 'platform_fees'
+GROUP BY x.company_id
+ORDER BY x.company_id;
+
+-- name: ListInvoicesForExport :many
+-- ListInvoicesForExport returns the invoices and credit notes of a type issued
+-- in a period, Romanian time, optionally for one company. Drafts and
+-- cancelled invoices are left out.
+SELECT * FROM invoices
+WHERE invoice_type = @invoice_type
+  AND (@company_id::uuid IS NULL OR company_id = @company_id::uuid)
+  AND status NOT IN ('draft', 'cancelled')
+  AND (COALESCE(issued_at, created_at) AT TIME ZONE 'Europe/Bucharest')::date BETWEEN @period_from AND @period_to
+ORDER BY COALESCE(issued_at, created_at), invoice_number;
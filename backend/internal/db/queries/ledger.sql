@@ -0,0 +1,78 @@
+-- name: UpsertLedgerAccount :one
+-- UpsertLedgerAccount returns the account of a type and owner, creating it
+-- the first time it is used.
+INSERT INTO ledger_accounts (account_type, owner_id)
+VALUES ($1, $2)
+ON CONFLICT (account_type, owner_id) DO UPDATE SET account_type = EXCLUDED.account_type
+RETURNING *;
+
+-- name: CreateLedgerEntry :one
+-- CreateLedgerEntry returns no rows when an entry with the reference was
+-- already posted.
+INSERT INTO ledger_entries (kind, reference, description, booking_id, company_id, occurred_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (reference) DO NOTHING
+RETURNING *;
+
+-- name: CreateLedgerLine :exec
+INSERT INTO ledger_lines (entry_id, account_id, amount)
+VALUES ($1, $2, $3);
+
+-- name: SumLedgerMovements :many
+-- SumLedgerMovements totals the debits and credits posted in a period per
+-- entry kind and account type, optionally for one company.
+SELECT e.kind, a.account_type,
+  COALESCE(SUM(l.amount) FILTER (WHERE l.amount > 0), 0)::BIGINT AS debits,
+  COALESCE(-SUM(l.amount) FILTER (WHERE l.amount < 0), 0)::BIGINT AS credits,
+  COUNT(DISTINCT e.booking_id)::BIGINT AS booking_count
+FROM ledger_lines l
+JOIN ledger_entries e ON e.id = l.entry_id
+JOIN ledger_accounts a ON a.id = l.account_id
+WHERE e.occurred_at >= @occurred_from AND e.occurred_at <= @occurred_to
+  AND (@company_id::uuid IS NULL OR e.company_id = @company_id::uuid)
+GROUP BY e.kind, a.account_type;
+
+-- name: SumLedgerBalancesByType :many
+SELECT a.account_type, COALESCE(SUM(l.amount), 0)::BIGINT AS balance
+FROM ledger_accounts a
+JOIN ledger_lines l ON l.account_id = a.id
+GROUP BY a.account_type
+ORDER BY a.account_type;
+
+-- name: GetLedgerAccountBalance :one
+SELECT COALESCE(SUM(l.amount), 0)::BIGINT AS balance
+FROM ledger_accounts a
+JOIN ledger_lines l ON l.account_id = a.id
+WHERE a.account_type = $1 AND a.owner_id = $2;
+
+-- name: ListLedgerStripeMovements :many
+-- ListLedgerStripeMovements returns what payments, refunds and disputes in a
+-- period moved on the Stripe balance, for reconciliation.
+SELECT e.reference, e.kind, e.occurred_at, SUM(l.amount)::BIGINT AS amount
+FROM ledger_entries e
+JOIN ledger_lines l ON l.entry_id = e.id
+JOIN ledger_accounts a ON a.id = l.account_id AND a.account_type = 'stripe_balance'
+WHERE e.kind IN ('payment', 'refund', 'dispute') AND e.occurred_at >= @occurred_from AND e.occurred_at <= @occurred_to
+GROUP BY e.reference, e.kind, e.occurred_at;
+
+-- name: CreateLedgerReconciliation :one
+INSERT INTO ledger_reconciliations (period_from, period_to, checked_count, fees_posted, discrepancies)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: ListLedgerReconciliations :many
+SELECT * FROM ledger_reconciliations
+ORDER BY created_at DESC
+LIMIT $1;
+
+-- name: ListLedgerLinesForExport :many
+-- ListLedgerLinesForExport returns the lines of the ledger entries posted in
+-- a period, optionally of one company, debits before credits.
+SELECT e.occurred_at, e.reference, e.kind, e.description, e.booking_id,
+       a.account_type, a.owner_id, l.amount
+FROM ledger_lines l
+JOIN ledger_entries e ON e.id = l.entry_id
+JOIN ledger_accounts a ON a.id = l.account_id
+WHERE e.occurred_at >= @occurred_from AND e.occurred_at < @occurred_to
+  AND (@company_id::uuid IS NULL OR e.company_id = @company_id::uuid)
+ORDER BY e.occurred_at, e.reference, l.amount DESC;
@@ -13,14 +13,32 @@ ORDER BY c.rating_avg DESC, c.total_jobs_completed DESC;
 -- name: ListCleanerBookingsForDate :many
 SELECT id, scheduled_start_time, estimated_duration_hours
 FROM bookings
-WHERE cleaner_id = $1
+WHERE (cleaner_id = $1 OR id IN (SELECT booking_id FROM booking_team_members WHERE cleaner_id = $1))
   AND scheduled_date = $2
   AND status NOT IN ('cancelled_by_client', 'cancelled_by_company', 'cancelled_by_admin')
 ORDER BY scheduled_start_time;
 
 -- name: CountCleanerBookingsInDateRange :one
 SELECT COUNT(*) FROM bookings
-WHERE cleaner_id = $1
+WHERE (cleaner_id = $1 OR id IN (SELECT booking_id FROM booking_team_members WHERE cleaner_id = $1))
   AND scheduled_date >= $2
   AND scheduled_date <= $3
   AND status NOT IN ('cancelled_by_client', 'cancelled_by_company', 'cancelled_by_admin');
+
+-- name: ListCleanersForSimulation :many
+SELECT c.id, c.company_id, c.rating_avg, c.total_jobs_completed
+FROM cleaners c
+JOIN companies co ON c.company_id = co.id
+WHERE c.status = 'active'
+  AND co.status = 'approved'
+ORDER BY c.id;
+
+-- name: ListBookingsForSimulation :many
+SELECT b.id, b.scheduled_date, b.scheduled_start_time, b.estimated_duration_hours, a.city
+FROM bookings b
+JOIN client_addresses a ON b.address_id = a.id
+WHERE b.scheduled_date >= $1
+  AND b.scheduled_date <= $2
+  AND b.team_size = 1
+  AND b.status NOT IN ('cancelled_by_client', 'cancelled_by_company', 'cancelled_by_admin')
+ORDER BY b.created_at;
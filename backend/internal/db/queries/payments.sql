@@ -33,9 +33,19 @@ WHERE id = $1 RETURNING *;
 
 -- name: MarkBookingPaidAndConfirmed :one
 -- Atomically marks a booking as paid AND auto-confirms it if still pending/assigned.
--- Idempotent: if booking is already confirmed or later, status is left unchanged.
+-- Idempotent: if booking is already confirmed or later, status is left unchanged,
+-- and paid_at keeps the time of the first payment.
 UPDATE bookings
-SET payment_status = 'paid', paid_at = NOW(),
+SET payment_status = 'paid', paid_at = COALESCE(paid_at, NOW()),
+    status = CASE WHEN status IN ('pending', 'assigned') THEN 'confirmed'::booking_status ELSE status END,
+    updated_at = NOW()
+WHERE id = $1 RETURNING *;
+
+-- name: MarkBookingAuthorizedAndConfirmed :one
+-- MarkBookingAuthorizedAndConfirmed records the authorization hold on a booking
+-- and auto-confirms it if still pending/assigned, like MarkBookingPaidAndConfirmed.
+UPDATE bookings
+SET payment_status = 'authorized',
     status = CASE WHEN status IN ('pending', 'assigned') THEN 'confirmed'::booking_status ELSE status END,
     updated_at = NOW()
 WHERE id = $1 RETURNING *;
@@ -57,8 +67,8 @@ UPDATE users SET stripe_customer_id = $2, updated_at = NOW() WHERE id = $1;
 -- name: CreatePaymentTransaction :one
 INSERT INTO payment_transactions (
   booking_id, stripe_payment_intent_id, amount_total, amount_company,
-  amount_platform_fee, currency, status, metadata
-) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+  amount_platform_fee, currency, status, metadata, capture_method, amount_authorized
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 RETURNING *;
 
 -- name: GetPaymentTransactionByStripePI :one
@@ -68,16 +78,31 @@ SELECT * FROM payment_transactions WHERE stripe_payment_intent_id = $1;
 SELECT * FROM payment_transactions WHERE booking_id = $1 ORDER BY created_at DESC LIMIT 1;
 
 -- name: UpdatePaymentTransactionStatus :one
+-- UpdatePaymentTransactionStatus records a succeeded payment. It returns no
+-- rows once the payment has been refunded or cancelled, so a replayed event
+-- cannot move it back.
 UPDATE payment_transactions SET status = $2, stripe_charge_id = $3, updated_at = NOW()
-WHERE stripe_payment_intent_id = $1 RETURNING *;
+WHERE stripe_payment_intent_id = $1
+  AND status IN ('pending', 'requires_action', 'processing', 'authorized', 'failed', 'succeeded')
+RETURNING *;
 
 -- name: UpdatePaymentTransactionFailed :one
+-- UpdatePaymentTransactionFailed records a failed payment attempt. It returns
+-- no rows once the payment has been authorized, has succeeded or has been
+-- cancelled, so a late or replayed event cannot fail it.
 UPDATE payment_transactions SET status = 'failed', failure_reason = $2, updated_at = NOW()
-WHERE stripe_payment_intent_id = $1 RETURNING *;
+WHERE stripe_payment_intent_id = $1
+  AND status IN ('pending', 'requires_action', 'processing', 'failed')
+RETURNING *;
 
 -- name: UpdatePaymentTransactionRefund :one
+-- UpdatePaymentTransactionRefund records the latest refund of a payment. It
+-- returns no rows for a partial refund of a fully refunded payment, so a
+-- replayed event cannot move it back.
 UPDATE payment_transactions SET status = $2, refund_amount = $3, stripe_refund_id = $4, updated_at = NOW()
-WHERE stripe_payment_intent_id = $1 RETURNING *;
+WHERE stripe_payment_intent_id = $1
+  AND (status IN ('succeeded', 'partially_refunded') OR (status = 'refunded' AND $2 = 'refunded'))
+RETURNING *;
 
 -- name: ListPaymentTransactionsByBooking :many
 SELECT * FROM payment_transactions WHERE booking_id = $1 ORDER BY created_at DESC;
@@ -138,8 +163,8 @@ SELECT * FROM company_payouts WHERE company_id = $1 AND status = $2 ORDER BY cre
 -- ============================================
 
 -- name: CreatePayoutLineItem :one
-INSERT INTO payout_line_items (payout_id, payment_transaction_id, booking_id, amount_gross, amount_commission, amount_net)
-VALUES ($1, $2, $3, $4, $5, $6) RETURNING *;
+INSERT INTO payout_line_items (payout_id, payment_transaction_id, booking_id, amount_gross, amount_commission, amount_net, cleaner_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING *;
 
 -- name: ListPayoutLineItems :many
 SELECT * FROM payout_line_items WHERE payout_id = $1 ORDER BY created_at;
@@ -186,46 +211,6 @@ SELECT COUNT(*) FROM payment_transactions pt
 JOIN bookings b ON b.id = pt.booking_id
 WHERE b.client_user_id = $1;
 
--- ============================================
--- COMPANY EARNINGS (Reporting)
--- ============================================
-
--- name: SumCompanyEarnings :one
-SELECT
-  COALESCE(SUM(pt.amount_total), 0)::BIGINT as total_gross,
-  COALESCE(SUM(pt.amount_platform_fee), 0)::BIGINT as total_commission,
-  COALESCE(SUM(pt.amount_company), 0)::BIGINT as total_net,
-  COUNT(pt.id)::BIGINT as booking_count
-FROM payment_transactions pt
-JOIN bookings b ON b.id = pt.booking_id
-WHERE b.company_id = $1 AND pt.status = 'succeeded'
-  AND pt.created_at >= $2 AND pt.created_at <= $3;
-
--- ============================================
--- PLATFORM REVENUE (Admin reporting)
--- ============================================
-
--- name: GetPlatformRevenueReport :one
-SELECT
-  COALESCE(SUM(pt.amount_total), 0)::BIGINT as total_revenue,
-  COALESCE(SUM(pt.amount_platform_fee), 0)::BIGINT as total_commission,
-  COUNT(DISTINCT pt.id)::BIGINT as booking_count
-FROM payment_transactions pt
-WHERE pt.status = 'succeeded'
-  AND pt.created_at >= $1 AND pt.created_at <= $2;
-
--- name: GetTotalPayoutsInPeriod :one
-SELECT
-  COALESCE(SUM(CASE WHEN status = 'paid' THEN amount ELSE 0 END), 0)::BIGINT as total_paid,
-  COALESCE(SUM(CASE WHEN status = 'pending' THEN amount ELSE 0 END), 0)::BIGINT as total_pending
-FROM company_payouts
-WHERE created_at >= $1 AND created_at <= $2;
-
--- name: GetTotalRefundsInPeriod :one
-SELECT COALESCE(SUM(amount), 0)::BIGINT as total_refunds
-FROM refund_requests
-WHERE status = 'processed' AND processed_at >= $1 AND processed_at <= $2;
-
 -- ============================================
 -- UNPAID TRANSACTIONS (Payout calculation)
 -- ============================================
@@ -238,4 +223,134 @@ WHERE b.company_id = $1
   AND pt.status = 'succeeded'
   AND pli.id IS NULL
   AND pt.created_at >= $2 AND pt.created_at <= $3
+  AND NOT EXISTS (
+    SELECT 1 FROM payment_disputes d
+    WHERE d.payment_transaction_id = pt.id AND d.status NOT IN ('won', 'warning_closed')
+  )
 ORDER BY pt.created_at;
+
+-- name: ClaimPayoutForExecution :one
+-- ClaimPayoutForExecution moves a pending or failed payout to processing. It
+-- returns no rows when the payout is already processing, paid or cancelled.
+UPDATE company_payouts
+SET status = 'processing', attempts = attempts + 1, failure_reason = NULL, next_attempt_at = NULL, updated_at = NOW()
+WHERE id = $1 AND status IN ('pending', 'failed')
+RETURNING *;
+
+-- name: SetPayoutStripePayoutID :one
+UPDATE company_payouts SET stripe_payout_id = $2, updated_at = NOW()
+WHERE id = $1 RETURNING *;
+
+-- name: MarkPayoutPaid :one
+UPDATE company_payouts SET status = 'paid', paid_at = NOW(), failure_reason = NULL, next_attempt_at = NULL, updated_at = NOW()
+WHERE id = $1 RETURNING *;
+
+-- name: MarkPayoutFailed :one
+UPDATE company_payouts SET status = 'failed', failure_reason = $2, next_attempt_at = $3, updated_at = NOW()
+WHERE id = $1 RETURNING *;
+
+-- name: CancelPayout :one
+UPDATE company_payouts SET status = 'cancelled', next_attempt_at = NULL, updated_at = NOW()
+WHERE id = $1 AND status IN ('pending', 'failed', 'held')
+RETURNING *;
+
+-- name: ListPayoutsDueForRetry :many
+-- ListPayoutsDueForRetry returns failed payouts whose next attempt is due and
+-- pending payouts that were never executed.
+SELECT * FROM company_payouts
+WHERE (status = 'failed' AND next_attempt_at <= NOW())
+   OR (status = 'pending' AND created_at < NOW() - INTERVAL '10 minutes')
+ORDER BY created_at
+LIMIT $1;
+
+-- name: ListPayoutsForReconciliation :many
+-- ListPayoutsForReconciliation returns payouts that need an admin: failed with
+-- no retries left, or processing with no Stripe outcome for three days.
+SELECT * FROM company_payouts
+WHERE (status = 'failed' AND next_attempt_at IS NULL)
+   OR (status = 'processing' AND updated_at < NOW() - INTERVAL '3 days')
+ORDER BY updated_at;
+
+-- name: DeletePayoutLineItems :exec
+DELETE FROM payout_line_items WHERE payout_id = $1;
+
+-- name: ListCompaniesForPayout :many
+SELECT * FROM companies
+WHERE status = 'approved'
+  AND stripe_connect_account_id IS NOT NULL
+  AND stripe_connect_payouts_enabled = TRUE
+ORDER BY created_at;
+
+-- name: MarkPaymentTransactionAuthorized :one
+-- MarkPaymentTransactionAuthorized records an authorization hold. It returns no
+-- rows once the hold has been captured or cancelled, so a late event cannot
+-- reopen it.
+UPDATE payment_transactions
+SET status = 'authorized', amount_authorized = $2, stripe_charge_id = $3,
+    authorized_at = COALESCE(authorized_at, NOW()), updated_at = NOW()
+WHERE stripe_payment_intent_id = $1 AND status IN ('pending', 'requires_action', 'processing', 'authorized')
+RETURNING *;
+
+-- name: UpdatePaymentTransactionCaptured :one
+UPDATE payment_transactions
+SET amount_total = $2, amount_company = $3, amount_platform_fee = $4, updated_at = NOW()
+WHERE stripe_payment_intent_id = $1 RETURNING *;
+
+-- name: MarkPaymentTransactionCancelled :one
+UPDATE payment_transactions SET status = 'cancelled', updated_at = NOW()
+WHERE stripe_payment_intent_id = $1 AND status IN ('pending', 'requires_action', 'processing', 'authorized')
+RETURNING *;
+
+-- name: ListExpiringAuthorizations :many
+-- ListExpiringAuthorizations returns the current authorization holds of active
+-- bookings that will expire (after 7 days) before the booking takes place.
+SELECT pt.* FROM payment_transactions pt
+JOIN bookings b ON b.id = pt.booking_id AND b.stripe_payment_intent_id = pt.stripe_payment_intent_id
+WHERE pt.status = 'authorized'
+  AND pt.authorized_at < NOW() - INTERVAL '6 days'
+  AND b.scheduled_date >= (pt.authorized_at + INTERVAL '7 days')::date
+  AND b.status IN ('pending', 'assigned', 'confirmed')
+  AND b.payment_status = 'authorized'
+ORDER BY pt.authorized_at
+LIMIT $1;
+
+-- name: ListUncapturedCompletedBookings :many
+SELECT * FROM bookings
+WHERE status = 'completed' AND payment_status = 'authorized'
+ORDER BY completed_at
+LIMIT $1;
+
+-- name: HoldPayoutsForTransaction :many
+-- HoldPayoutsForTransaction puts the unexecuted payouts that contain a
+-- disputed transaction on hold.
+UPDATE company_payouts SET status = 'held', next_attempt_at = NULL, updated_at = NOW()
+WHERE status IN ('pending', 'failed')
+  AND id IN (SELECT payout_id FROM payout_line_items WHERE payment_transaction_id = $1)
+RETURNING *;
+
+-- name: ReleaseHeldPayouts :many
+-- ReleaseHeldPayouts releases the held payouts that contain a transaction once
+-- none of their transactions has a dispute that is open or lost.
+UPDATE company_payouts p SET status = 'pending', updated_at = NOW()
+WHERE p.status = 'held'
+  AND p.id IN (SELECT payout_id FROM payout_line_items WHERE payment_transaction_id = $1)
+  AND NOT EXISTS (
+    SELECT 1 FROM payout_line_items pli
+    JOIN payment_disputes d ON d.payment_transaction_id = pli.payment_transaction_id
+    WHERE pli.payout_id = p.id AND d.status NOT IN ('won', 'warning_closed')
+  )
+RETURNING *;
+
+-- name: GetPaymentTransactionByID :one
+SELECT * FROM payment_transactions WHERE id = $1;
+
+-- name: GetRefundRequestByStripeRefundID :one
+SELECT * FROM refund_requests WHERE stripe_refund_id = $1;
+
+-- name: CancelBookingForFailedPayment :execrows
+-- CancelBookingForFailedPayment cancels a booking whose payment failed. It
+-- leaves alone a booking that has started, is already cancelled or is now paid
+-- with another PaymentIntent.
+UPDATE bookings SET status = 'cancelled_by_admin', updated_at = NOW()
+WHERE id = $1 AND stripe_payment_intent_id = $2
+  AND status IN ('pending', 'assigned', 'confirmed');
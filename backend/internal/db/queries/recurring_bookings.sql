@@ -3,8 +3,9 @@ INSERT INTO recurring_booking_groups (
     client_user_id, company_id, preferred_cleaner_id, address_id,
     recurrence_type, day_of_week, preferred_time, service_type,
     property_type, num_rooms, num_bathrooms, area_sqm, has_pets,
-    special_instructions, hourly_rate, estimated_total_per_occurrence
-) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+    special_instructions, hourly_rate, estimated_total_per_occurrence,
+    estimated_duration_hours, generated_until, anchor_date, rrule
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
 RETURNING *;
 
 -- name: GetRecurringGroupByID :one
@@ -39,12 +40,14 @@ WHERE id = $1 RETURNING *;
 
 -- name: PauseRecurringGroup :one
 UPDATE recurring_booking_groups
-SET is_active = FALSE, updated_at = NOW()
+SET is_active = FALSE,
+    generated_until = LEAST(generated_until, CURRENT_DATE - 1),
+    updated_at = NOW()
 WHERE id = $1 RETURNING *;
 
 -- name: ResumeRecurringGroup :one
 UPDATE recurring_booking_groups
-SET is_active = TRUE, cancelled_at = NULL, cancellation_reason = NULL, updated_at = NOW()
+SET is_active = TRUE, cancelled_at = NULL, cancellation_reason = NULL, payment_failures = 0, updated_at = NOW()
 WHERE id = $1 RETURNING *;
 
 -- name: InsertRecurringGroupExtra :exec
@@ -66,3 +69,104 @@ SET status = 'cancelled_by_client', cancelled_at = NOW(), cancellation_reason =
 WHERE recurring_group_id = $1
   AND scheduled_date >= CURRENT_DATE
   AND status IN ('pending', 'assigned', 'confirmed');
+
+-- name: ListRecurringGroupsToExtend :many
+-- ListRecurringGroupsToExtend returns active groups whose occurrences are not
+-- yet generated up to the horizon date.
+SELECT * FROM recurring_booking_groups
+WHERE is_active = TRUE
+  AND cancelled_at IS NULL
+  AND (generated_until IS NULL OR generated_until < @horizon::date)
+ORDER BY created_at;
+
+-- name: SetRecurringGroupGeneratedUntil :exec
+UPDATE recurring_booking_groups
+SET generated_until = $2, updated_at = NOW()
+WHERE id = $1;
+
+-- name: GetFirstRecurringOccurrence :one
+SELECT * FROM bookings
+WHERE recurring_group_id = $1
+ORDER BY occurrence_number
+LIMIT 1;
+
+-- name: GetMaxOccurrenceNumber :one
+SELECT COALESCE(MAX(occurrence_number), 0)::int AS max_occurrence
+FROM bookings
+WHERE recurring_group_id = $1;
+
+-- name: UpsertOccurrenceException :exec
+INSERT INTO recurring_occurrence_exceptions (group_id, original_date, exception_type, booking_id)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (group_id, original_date) DO UPDATE
+SET exception_type = EXCLUDED.exception_type, booking_id = EXCLUDED.booking_id, updated_at = NOW();
+
+-- name: GetOccurrenceExceptionByBooking :one
+SELECT * FROM recurring_occurrence_exceptions WHERE booking_id = $1;
+
+-- name: ListOccurrenceExceptions :many
+SELECT * FROM recurring_occurrence_exceptions
+WHERE group_id = $1
+ORDER BY original_date;
+
+-- name: CancelRegenerableOccurrences :exec
+-- CancelRegenerableOccurrences cancels the not-yet-started occurrences of a
+-- series from apply_from on, keeping one-off exceptions, so they can be
+-- regenerated after a series edit.
+UPDATE bookings
+SET status = 'cancelled_by_client', cancelled_at = NOW(), cancellation_reason = @cancellation_reason, updated_at = NOW()
+WHERE recurring_group_id = @recurring_group_id
+  AND scheduled_date >= @apply_from::date
+  AND status IN ('pending', 'assigned', 'confirmed')
+  AND started_at IS NULL
+  AND id NOT IN (
+      SELECT booking_id FROM recurring_occurrence_exceptions
+      WHERE group_id = @recurring_group_id AND booking_id IS NOT NULL
+  );
+
+-- name: UpdateRecurringGroupSeries :one
+UPDATE recurring_booking_groups
+SET day_of_week = $2,
+    preferred_time = $3,
+    property_type = $4,
+    num_rooms = $5,
+    num_bathrooms = $6,
+    area_sqm = $7,
+    has_pets = $8,
+    special_instructions = $9,
+    hourly_rate = $10,
+    estimated_total_per_occurrence = $11,
+    estimated_duration_hours = $12,
+    anchor_date = $13,
+    generated_until = $14,
+    recurrence_type = $15,
+    rrule = $16,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteRecurringGroupExtras :exec
+DELETE FROM recurring_group_extras WHERE group_id = $1;
+
+-- name: ListRecurringOccurrencesToCharge :many
+-- ListRecurringOccurrencesToCharge returns unpaid occurrences of active series
+-- that start between now and charge_before and were never charged.
+SELECT b.* FROM bookings b
+JOIN recurring_booking_groups g ON g.id = b.recurring_group_id
+WHERE g.is_active = TRUE
+  AND b.status IN ('pending', 'assigned', 'confirmed')
+  AND b.company_id IS NOT NULL
+  AND b.stripe_payment_intent_id IS NULL
+  AND COALESCE(b.payment_status, 'pending') = 'pending'
+  AND (b.scheduled_date + b.scheduled_start_time) AT TIME ZONE 'Europe/Bucharest' BETWEEN NOW() AND $1
+ORDER BY b.scheduled_date, b.scheduled_start_time
+LIMIT $2;
+
+-- name: IncrementRecurringPaymentFailures :one
+UPDATE recurring_booking_groups
+SET payment_failures = payment_failures + 1, updated_at = NOW()
+WHERE id = $1 RETURNING payment_failures;
+
+-- name: ResetRecurringPaymentFailures :exec
+UPDATE recurring_booking_groups SET payment_failures = 0, updated_at = NOW()
+WHERE id = $1 AND payment_failures > 0;
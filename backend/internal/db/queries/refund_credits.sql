@@ -0,0 +1,33 @@
+-- name: CreateRefundCredit :execrows
+-- CreateRefundCredit records the credit notes owed for a refund. Refunds
+-- already recorded affect no rows.
+INSERT INTO refund_credits (refund_id, payment_intent_id, booking_id, company_id, amount, payment_gross, payment_fee, refunded_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT (refund_id) DO NOTHING;
+
+-- name: ClaimRefundCredit :one
+-- ClaimRefundCredit marks the credit of a refund as being issued. It returns no
+-- rows when the credit is done or another worker holds a fresh claim.
+UPDATE refund_credits
+SET status = 'processing', attempts = attempts + 1, updated_at = NOW()
+WHERE refund_id = $1
+  AND (status IN ('pending', 'failed')
+       OR (status = 'processing' AND updated_at < NOW() - INTERVAL '10 minutes'))
+RETURNING *;
+
+-- name: MarkRefundCreditDone :exec
+UPDATE refund_credits
+SET status = 'done', last_error = NULL, next_attempt_at = NULL, updated_at = NOW()
+WHERE refund_id = $1;
+
+-- name: MarkRefundCreditFailed :exec
+UPDATE refund_credits
+SET status = 'failed', last_error = $2, next_attempt_at = $3, updated_at = NOW()
+WHERE refund_id = $1;
+
+-- name: ListDueRefundCredits :many
+SELECT * FROM refund_credits
+WHERE (status IN ('pending', 'failed') AND next_attempt_at <= NOW())
+   OR (status = 'processing' AND updated_at < NOW() - INTERVAL '10 minutes')
+ORDER BY created_at
+LIMIT $1;
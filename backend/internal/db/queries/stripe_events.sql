@@ -0,0 +1,53 @@
+-- name: RecordStripeEvent :execrows
+-- RecordStripeEvent stores a verified webhook event. Redeliveries of an
+-- event already stored affect no rows.
+INSERT INTO stripe_events (id, event_type, payload)
+VALUES ($1, $2, $3)
+ON CONFLICT (id) DO NOTHING;
+
+-- name: GetStripeEvent :one
+SELECT * FROM stripe_events WHERE id = $1;
+
+-- name: ClaimStripeEvent :one
+-- ClaimStripeEvent marks an event as being processed. It returns no rows when
+-- the event is already processed or another worker holds a fresh claim.
+UPDATE stripe_events
+SET status = 'processing', attempts = attempts + 1, updated_at = NOW()
+WHERE id = $1
+  AND (status IN ('pending', 'failed')
+       OR (status = 'processing' AND updated_at < NOW() - INTERVAL '10 minutes'))
+RETURNING *;
+
+-- name: MarkStripeEventProcessed :exec
+UPDATE stripe_events
+SET status = 'processed', processed_at = NOW(), last_error = NULL, next_attempt_at = NULL, updated_at = NOW()
+WHERE id = $1;
+
+-- name: MarkStripeEventFailed :exec
+UPDATE stripe_events
+SET status = 'failed', last_error = $2, next_attempt_at = $3, updated_at = NOW()
+WHERE id = $1;
+
+-- name: ResetStripeEventForReplay :one
+UPDATE stripe_events
+SET status = 'pending', next_attempt_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND status <> 'processing'
+RETURNING *;
+
+-- name: ListDueStripeEvents :many
+SELECT * FROM stripe_events
+WHERE (status IN ('pending', 'failed') AND next_attempt_at <= NOW())
+   OR (status = 'processing' AND updated_at < NOW() - INTERVAL '10 minutes')
+ORDER BY created_at
+LIMIT $1;
+
+-- name: ListStripeEvents :many
+SELECT * FROM stripe_events
+ORDER BY created_at DESC
+LIMIT $1 OFFSET $2;
+
+-- name: ListStripeEventsByStatus :many
+SELECT * FROM stripe_events
+WHERE status = $1
+ORDER BY created_at DESC
+LIMIT $2 OFFSET $3;
@@ -0,0 +1,16 @@
+-- name: ListVatRates :many
+SELECT * FROM vat_rates
+ORDER BY category, valid_from DESC;
+
+-- name: CreateVatRate :one
+INSERT INTO vat_rates (category, rate_pct, valid_from, description)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetVatRate :one
+-- GetVatRate returns the rate of a category in force on a date: the one that
+-- started last on or before it.
+SELECT rate_pct FROM vat_rates
+WHERE category = $1 AND valid_from <= $2
+ORDER BY valid_from DESC
+LIMIT 1;
@@ -10,6 +10,22 @@ import (
 	"time"
 )
 
+// File of a company's invoices or ledger for a period, or of the platform's for global admins, generated in the background.
+type AccountingExport struct {
+	ID         string                 `json:"id"`
+	Format     AccountingExportFormat `json:"format"`
+	PeriodFrom string                 `json:"periodFrom"`
+	PeriodTo   string                 `json:"periodTo"`
+	Status     AccountingExportStatus `json:"status"`
+	// Invoices or ledger lines in the file.
+	RecordCount *int    `json:"recordCount,omitempty"`
+	Error       *string `json:"error,omitempty"`
+	// Path of the file, relative to the API URL, once it is ready. Requires authentication.
+	DownloadURL *string    `json:"downloadUrl,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+}
+
 type AddAddressInput struct {
 	Label         *string  `json:"label,omitempty"`
 	StreetAddress string   `json:"streetAddress"`
@@ -48,6 +64,20 @@ type AdminUpdateCompanyInput struct {
 	ContactEmail string `json:"contactEmail"`
 }
 
+// ANAF login page to start the OAuth2 authorization, and the state it echoes back.
+type AnafAuthorization struct {
+	URL   string `json:"url"`
+	State string `json:"state"`
+}
+
+// Connection of a fiscal code to the ANAF e-Factura SPV.
+type AnafConnection struct {
+	Cif         string     `json:"cif"`
+	Connected   bool       `json:"connected"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
+	ConnectedAt *time.Time `json:"connectedAt,omitempty"`
+}
+
 type AuthPayload struct {
 	Token     string `json:"token"`
 	User      *User  `json:"user"`
@@ -83,42 +113,46 @@ type BillingProfileInput struct {
 }
 
 type Booking struct {
-	ID                     string             `json:"id"`
-	ReferenceCode          string             `json:"referenceCode"`
-	Client                 *User              `json:"client,omitempty"`
-	Company                *Company           `json:"company,omitempty"`
-	Cleaner                *CleanerProfile    `json:"cleaner,omitempty"`
-	Address                *Address           `json:"address,omitempty"`
-	ServiceType            ServiceType        `json:"serviceType"`
-	ServiceName            string             `json:"serviceName"`
-	IncludedItems          []string           `json:"includedItems"`
-	ScheduledDate          string             `json:"scheduledDate"`
-	ScheduledStartTime     string             `json:"scheduledStartTime"`
-	EstimatedDurationHours float64            `json:"estimatedDurationHours"`
-	PropertyType           *string            `json:"propertyType,omitempty"`
-	NumRooms               *int               `json:"numRooms,omitempty"`
-	NumBathrooms           *int               `json:"numBathrooms,omitempty"`
-	AreaSqm                *int               `json:"areaSqm,omitempty"`
-	HasPets                *bool              `json:"hasPets,omitempty"`
-	SpecialInstructions    *string            `json:"specialInstructions,omitempty"`
-	HourlyRate             float64            `json:"hourlyRate"`
-	EstimatedTotal         float64            `json:"estimatedTotal"`
-	FinalTotal             *float64           `json:"finalTotal,omitempty"`
-	PlatformCommissionPct  float64            `json:"platformCommissionPct"`
-	Extras                 []*BookingExtra    `json:"extras"`
-	Status                 BookingStatus      `json:"status"`
-	StartedAt              *time.Time         `json:"startedAt,omitempty"`
-	CompletedAt            *time.Time         `json:"completedAt,omitempty"`
-	CancelledAt            *time.Time         `json:"cancelledAt,omitempty"`
-	CancellationReason     *string            `json:"cancellationReason,omitempty"`
-	PaymentStatus          string             `json:"paymentStatus"`
-	PaidAt                 *time.Time         `json:"paidAt,omitempty"`
-	RecurringGroupID       *string            `json:"recurringGroupId,omitempty"`
-	OccurrenceNumber       *int               `json:"occurrenceNumber,omitempty"`
-	TimeSlots              []*BookingTimeSlot `json:"timeSlots"`
-	Review                 *Review            `json:"review,omitempty"`
-	ChatRoom               *ChatRoom          `json:"chatRoom,omitempty"`
-	CreatedAt              time.Time          `json:"createdAt"`
+	ID                     string               `json:"id"`
+	ReferenceCode          string               `json:"referenceCode"`
+	Client                 *User                `json:"client,omitempty"`
+	Company                *Company             `json:"company,omitempty"`
+	Cleaner                *CleanerProfile      `json:"cleaner,omitempty"`
+	Address                *Address             `json:"address,omitempty"`
+	ServiceType            ServiceType          `json:"serviceType"`
+	ServiceName            string               `json:"serviceName"`
+	IncludedItems          []string             `json:"includedItems"`
+	ScheduledDate          string               `json:"scheduledDate"`
+	ScheduledStartTime     string               `json:"scheduledStartTime"`
+	EstimatedDurationHours float64              `json:"estimatedDurationHours"`
+	PropertyType           *string              `json:"propertyType,omitempty"`
+	NumRooms               *int                 `json:"numRooms,omitempty"`
+	NumBathrooms           *int                 `json:"numBathrooms,omitempty"`
+	AreaSqm                *int                 `json:"areaSqm,omitempty"`
+	HasPets                *bool                `json:"hasPets,omitempty"`
+	SpecialInstructions    *string              `json:"specialInstructions,omitempty"`
+	HourlyRate             float64              `json:"hourlyRate"`
+	EstimatedTotal         float64              `json:"estimatedTotal"`
+	HolidaySurcharge       float64              `json:"holidaySurcharge"`
+	FinalTotal             *float64             `json:"finalTotal,omitempty"`
+	PlatformCommissionPct  float64              `json:"platformCommissionPct"`
+	Extras                 []*BookingExtra      `json:"extras"`
+	Status                 BookingStatus        `json:"status"`
+	StartedAt              *time.Time           `json:"startedAt,omitempty"`
+	CompletedAt            *time.Time           `json:"completedAt,omitempty"`
+	CancelledAt            *time.Time           `json:"cancelledAt,omitempty"`
+	CancellationReason     *string              `json:"cancellationReason,omitempty"`
+	PaymentStatus          string               `json:"paymentStatus"`
+	PaidAt                 *time.Time           `json:"paidAt,omitempty"`
+	RecurringGroupID       *string              `json:"recurringGroupId,omitempty"`
+	OccurrenceNumber       *int                 `json:"occurrenceNumber,omitempty"`
+	NeedsStaffing          bool                 `json:"needsStaffing"`
+	TeamSize               int                  `json:"teamSize"`
+	TeamMembers            []*BookingTeamMember `json:"teamMembers"`
+	TimeSlots              []*BookingTimeSlot   `json:"timeSlots"`
+	Review                 *Review              `json:"review,omitempty"`
+	ChatRoom               *ChatRoom            `json:"chatRoom,omitempty"`
+	CreatedAt              time.Time            `json:"createdAt"`
 }
 
 type BookingConnection struct {
@@ -133,6 +167,14 @@ type BookingExtra struct {
 	Quantity int           `json:"quantity"`
 }
 
+type BookingTeamMember struct {
+	Cleaner     *CleanerProfile `json:"cleaner"`
+	IsLead      bool            `json:"isLead"`
+	PayShare    *float64        `json:"payShare,omitempty"`
+	CheckedInAt *time.Time      `json:"checkedInAt,omitempty"`
+	CompletedAt *time.Time      `json:"completedAt,omitempty"`
+}
+
 type BookingTimeSlot struct {
 	ID         string `json:"id"`
 	SlotDate   string `json:"slotDate"`
@@ -233,6 +275,7 @@ type CleanerProfile struct {
 	Documents             []*CleanerDocument     `json:"documents"`
 	PersonalityAssessment *PersonalityAssessment `json:"personalityAssessment,omitempty"`
 	Availability          []*AvailabilitySlot    `json:"availability"`
+	Skills                []ServiceType          `json:"skills"`
 	CreatedAt             time.Time              `json:"createdAt"`
 }
 
@@ -245,16 +288,17 @@ type CleanerStats struct {
 }
 
 type CleanerSuggestion struct {
-	Cleaner            *CleanerProfile `json:"cleaner"`
-	Company            *Company        `json:"company"`
-	AvailabilityStatus string          `json:"availabilityStatus"`
-	AvailableFrom      *string         `json:"availableFrom,omitempty"`
-	AvailableTo        *string         `json:"availableTo,omitempty"`
-	SuggestedStartTime *string         `json:"suggestedStartTime,omitempty"`
-	SuggestedEndTime   *string         `json:"suggestedEndTime,omitempty"`
-	SuggestedSlotIndex *int            `json:"suggestedSlotIndex,omitempty"`
-	SuggestedDate      *string         `json:"suggestedDate,omitempty"`
-	MatchScore         float64         `json:"matchScore"`
+	Cleaner            *CleanerProfile      `json:"cleaner"`
+	Company            *Company             `json:"company"`
+	AvailabilityStatus string               `json:"availabilityStatus"`
+	AvailableFrom      *string              `json:"availableFrom,omitempty"`
+	AvailableTo        *string              `json:"availableTo,omitempty"`
+	SuggestedStartTime *string              `json:"suggestedStartTime,omitempty"`
+	SuggestedEndTime   *string              `json:"suggestedEndTime,omitempty"`
+	SuggestedSlotIndex *int                 `json:"suggestedSlotIndex,omitempty"`
+	SuggestedDate      *string              `json:"suggestedDate,omitempty"`
+	MatchScore         float64              `json:"matchScore"`
+	ScoreBreakdown     *MatchScoreBreakdown `json:"scoreBreakdown,omitempty"`
 }
 
 type ClientBillingProfile struct {
@@ -272,6 +316,31 @@ type ClientBillingProfile struct {
 	IsDefault   bool    `json:"isDefault"`
 }
 
+// Platform commission of a month, per company, net of the refunds made on its bookings.
+type CommissionMonth struct {
+	// Month in YYYY-MM form.
+	Month      string                 `json:"month"`
+	PeriodFrom string                 `json:"periodFrom"`
+	PeriodTo   string                 `json:"periodTo"`
+	Lines      []*CommissionMonthLine `json:"lines"`
+}
+
+// Commission a company owes for a month, in bani.
+type CommissionMonthLine struct {
+	Company *Company `json:"company"`
+	// Bookings paid in the month.
+	BookingCount int `json:"bookingCount"`
+	// Platform fee of the bookings, without VAT.
+	Commission int `json:"commission"`
+	// Part of the fee refunded with the bookings.
+	Refunded    int `json:"refunded"`
+	NetAmount   int `json:"netAmount"`
+	VatAmount   int `json:"vatAmount"`
+	TotalAmount int `json:"totalAmount"`
+	// Invoice issued for the month; null until the month is closed, and for companies with nothing to invoice.
+	Invoice *Invoice `json:"invoice,omitempty"`
+}
+
 type Company struct {
 	ID                  string             `json:"id"`
 	CompanyName         string             `json:"companyName"`
@@ -290,6 +359,8 @@ type Company struct {
 	MaxServiceRadiusKm  int                `json:"maxServiceRadiusKm"`
 	RatingAvg           float64            `json:"ratingAvg"`
 	TotalJobsCompleted  int                `json:"totalJobsCompleted"`
+	WorksOnHolidays     bool               `json:"worksOnHolidays"`
+	HolidaySurchargePct *float64           `json:"holidaySurchargePct,omitempty"`
 	Documents           []*CompanyDocument `json:"documents"`
 	Cleaners            []*CleanerProfile  `json:"cleaners"`
 	Admin               *User              `json:"admin,omitempty"`
@@ -314,6 +385,12 @@ type CompanyApplicationResult struct {
 	ClaimToken *string  `json:"claimToken,omitempty"`
 }
 
+type CompanyClosure struct {
+	ID     string  `json:"id"`
+	Date   string  `json:"date"`
+	Reason *string `json:"reason,omitempty"`
+}
+
 type CompanyConnection struct {
 	Edges      []*Company `json:"edges"`
 	PageInfo   *PageInfo  `json:"pageInfo"`
@@ -331,12 +408,15 @@ type CompanyDocument struct {
 	RejectionReason *string        `json:"rejectionReason,omitempty"`
 }
 
+// Earnings in a period, net of refunds, from the ledger.
 type CompanyEarningsSummary struct {
 	TotalGross        int `json:"totalGross"`
 	TotalCommission   int `json:"totalCommission"`
 	TotalNet          int `json:"totalNet"`
 	BookingCount      int `json:"bookingCount"`
 	AveragePerBooking int `json:"averagePerBooking"`
+	// What the platform owes the company now: earnings not yet paid out.
+	Balance int `json:"balance"`
 }
 
 type CompanyFinancialSummary struct {
@@ -346,18 +426,43 @@ type CompanyFinancialSummary struct {
 	NetPayout         float64 `json:"netPayout"`
 }
 
+// Invoicing automation of a company.
+type CompanyInvoiceSettings struct {
+	AutoIssueTrigger InvoiceAutoIssueTrigger `json:"autoIssueTrigger"`
+	// Email the invoice PDF to the buyer once issued.
+	AutoEmail bool `json:"autoEmail"`
+	// Transmit the invoices of business buyers to e-Factura once issued.
+	AutoTransmitEFactura bool `json:"autoTransmitEFactura"`
+	// Bookings completed or paid before this moment are not invoiced automatically.
+	AutoIssueSince *time.Time `json:"autoIssueSince,omitempty"`
+	UpdatedAt      *time.Time `json:"updatedAt,omitempty"`
+}
+
+type CompanyInvoiceSettingsInput struct {
+	AutoIssueTrigger     InvoiceAutoIssueTrigger `json:"autoIssueTrigger"`
+	AutoEmail            bool                    `json:"autoEmail"`
+	AutoTransmitEFactura bool                    `json:"autoTransmitEFactura"`
+}
+
 type CompanyPayout struct {
-	ID           string            `json:"id"`
-	Company      *Company          `json:"company,omitempty"`
-	Amount       int               `json:"amount"`
-	Currency     string            `json:"currency"`
-	PeriodFrom   string            `json:"periodFrom"`
-	PeriodTo     string            `json:"periodTo"`
-	BookingCount int               `json:"bookingCount"`
-	Status       PayoutStatus      `json:"status"`
-	PaidAt       *time.Time        `json:"paidAt,omitempty"`
-	LineItems    []*PayoutLineItem `json:"lineItems"`
-	CreatedAt    time.Time         `json:"createdAt"`
+	ID           string       `json:"id"`
+	Company      *Company     `json:"company,omitempty"`
+	Amount       int          `json:"amount"`
+	Currency     string       `json:"currency"`
+	PeriodFrom   string       `json:"periodFrom"`
+	PeriodTo     string       `json:"periodTo"`
+	BookingCount int          `json:"bookingCount"`
+	Status       PayoutStatus `json:"status"`
+	PaidAt       *time.Time   `json:"paidAt,omitempty"`
+	// Stripe payout on the company's connected account, once executed.
+	StripePayoutID *string `json:"stripePayoutId,omitempty"`
+	FailureReason  *string `json:"failureReason,omitempty"`
+	// How many times the payout has been executed.
+	Attempts int `json:"attempts"`
+	// When a failed payout is retried; null when no retries are left.
+	NextAttemptAt *time.Time        `json:"nextAttemptAt,omitempty"`
+	LineItems     []*PayoutLineItem `json:"lineItems"`
+	CreatedAt     time.Time         `json:"createdAt"`
 }
 
 type CompanyPerformance struct {
@@ -405,6 +510,8 @@ type CreateBookingInput struct {
 	PreferredCleanerID  *string          `json:"preferredCleanerId,omitempty"`
 	SuggestedStartTime  *string          `json:"suggestedStartTime,omitempty"`
 	Recurrence          *RecurrenceInput `json:"recurrence,omitempty"`
+	TeamSize            *int             `json:"teamSize,omitempty"`
+	TeamCleanerIds      []string         `json:"teamCleanerIds,omitempty"`
 }
 
 type CreateServiceDefinitionInput struct {
@@ -439,6 +546,60 @@ type DailyRevenue struct {
 	Commission   float64 `json:"commission"`
 }
 
+type DayPlan struct {
+	Date                   string               `json:"date"`
+	Assignments            []*DayPlanAssignment `json:"assignments"`
+	Changes                []*DayPlanAssignment `json:"changes"`
+	CurrentTravelMinutes   float64              `json:"currentTravelMinutes"`
+	CurrentOvertimeMinutes float64              `json:"currentOvertimeMinutes"`
+	TravelMinutes          float64              `json:"travelMinutes"`
+	OvertimeMinutes        float64              `json:"overtimeMinutes"`
+	UnassignedCount        int                  `json:"unassignedCount"`
+}
+
+type DayPlanAssignment struct {
+	Booking         *Booking        `json:"booking"`
+	CurrentCleaner  *CleanerProfile `json:"currentCleaner,omitempty"`
+	ProposedCleaner *CleanerProfile `json:"proposedCleaner,omitempty"`
+	Changed         bool            `json:"changed"`
+}
+
+type DayPlanChangeInput struct {
+	BookingID     string  `json:"bookingId"`
+	FromCleanerID *string `json:"fromCleanerId,omitempty"`
+	ToCleanerID   string  `json:"toCleanerId"`
+}
+
+// Evidence submitted to Stripe for a dispute.
+type DisputeEvidence struct {
+	ProductDescription   string `json:"productDescription"`
+	CustomerName         string `json:"customerName"`
+	CustomerEmailAddress string `json:"customerEmailAddress"`
+	BillingAddress       string `json:"billingAddress"`
+	ServiceDate          string `json:"serviceDate"`
+	// Booking history and cleaner check-ins.
+	AccessActivityLog string `json:"accessActivityLog"`
+	// Chat transcript of the booking.
+	CustomerCommunication    string `json:"customerCommunication"`
+	RefundRefusalExplanation string `json:"refundRefusalExplanation"`
+	UncategorizedText        string `json:"uncategorizedText"`
+	// Photos sent in the booking chat.
+	PhotoUrls []string `json:"photoUrls"`
+}
+
+type DisputeEvidenceInput struct {
+	ProductDescription       string   `json:"productDescription"`
+	CustomerName             string   `json:"customerName"`
+	CustomerEmailAddress     string   `json:"customerEmailAddress"`
+	BillingAddress           string   `json:"billingAddress"`
+	ServiceDate              string   `json:"serviceDate"`
+	AccessActivityLog        string   `json:"accessActivityLog"`
+	CustomerCommunication    string   `json:"customerCommunication"`
+	RefundRefusalExplanation string   `json:"refundRefusalExplanation"`
+	UncategorizedText        string   `json:"uncategorizedText"`
+	PhotoUrls                []string `json:"photoUrls"`
+}
+
 type EnabledCity struct {
 	ID       string      `json:"id"`
 	Name     string      `json:"name"`
@@ -465,28 +626,41 @@ type InviteCleanerInput struct {
 }
 
 type Invoice struct {
-	ID                string             `json:"id"`
-	InvoiceType       InvoiceType        `json:"invoiceType"`
-	InvoiceNumber     *string            `json:"invoiceNumber,omitempty"`
-	Status            InvoiceStatus      `json:"status"`
-	SellerCompanyName string             `json:"sellerCompanyName"`
-	SellerCui         string             `json:"sellerCui"`
-	BuyerName         string             `json:"buyerName"`
-	BuyerCui          *string            `json:"buyerCui,omitempty"`
-	SubtotalAmount    int                `json:"subtotalAmount"`
-	VatRate           float64            `json:"vatRate"`
-	VatAmount         int                `json:"vatAmount"`
-	TotalAmount       int                `json:"totalAmount"`
-	Currency          string             `json:"currency"`
-	Booking           *Booking           `json:"booking,omitempty"`
-	Company           *Company           `json:"company,omitempty"`
-	EfacturaStatus    *string            `json:"efacturaStatus,omitempty"`
-	DownloadURL       *string            `json:"downloadUrl,omitempty"`
-	IssuedAt          *time.Time         `json:"issuedAt,omitempty"`
-	DueDate           *string            `json:"dueDate,omitempty"`
-	Notes             *string            `json:"notes,omitempty"`
-	LineItems         []*InvoiceLineItem `json:"lineItems"`
-	CreatedAt         time.Time          `json:"createdAt"`
+	ID                string        `json:"id"`
+	InvoiceType       InvoiceType   `json:"invoiceType"`
+	InvoiceNumber     *string       `json:"invoiceNumber,omitempty"`
+	Status            InvoiceStatus `json:"status"`
+	SellerCompanyName string        `json:"sellerCompanyName"`
+	SellerCui         string        `json:"sellerCui"`
+	BuyerName         string        `json:"buyerName"`
+	BuyerCui          *string       `json:"buyerCui,omitempty"`
+	SubtotalAmount    int           `json:"subtotalAmount"`
+	VatRate           float64       `json:"vatRate"`
+	VatAmount         int           `json:"vatAmount"`
+	TotalAmount       int           `json:"totalAmount"`
+	Currency          string        `json:"currency"`
+	Booking           *Booking      `json:"booking,omitempty"`
+	Company           *Company      `json:"company,omitempty"`
+	EfacturaStatus    *string       `json:"efacturaStatus,omitempty"`
+	// Messages from ANAF, e.g. the validation errors of a rejected upload.
+	EfacturaMessage *string `json:"efacturaMessage,omitempty"`
+	// Signed URL of the ANAF response archive (signed invoice or error report).
+	EfacturaResponseURL *string `json:"efacturaResponseUrl,omitempty"`
+	DownloadURL         *string `json:"downloadUrl,omitempty"`
+	// Path of the invoice PDF rendered by the backend, relative to the API URL. Requires authentication.
+	PDFURL   string     `json:"pdfUrl"`
+	IssuedAt *time.Time `json:"issuedAt,omitempty"`
+	DueDate  *string    `json:"dueDate,omitempty"`
+	Notes    *string    `json:"notes,omitempty"`
+	// Number of the invoice a credit note corrects.
+	CreditedInvoiceNumber *string `json:"creditedInvoiceNumber,omitempty"`
+	// First and last day of the month a monthly commission invoice covers.
+	PeriodFrom *string            `json:"periodFrom,omitempty"`
+	PeriodTo   *string            `json:"periodTo,omitempty"`
+	LineItems  []*InvoiceLineItem `json:"lineItems"`
+	// Automated steps after issuing; null for invoices issued before automation.
+	Pipeline  *InvoicePipeline `json:"pipeline,omitempty"`
+	CreatedAt time.Time        `json:"createdAt"`
 }
 
 type InvoiceAnalytics struct {
@@ -515,6 +689,47 @@ type InvoiceLineItem struct {
 	LineTotalWithVat int     `json:"lineTotalWithVat"`
 }
 
+// State of the steps run automatically after an invoice is issued.
+type InvoicePipeline struct {
+	Status              InvoicePipelineStatus `json:"status"`
+	PDFStored           bool                  `json:"pdfStored"`
+	EmailedAt           *time.Time            `json:"emailedAt,omitempty"`
+	EfacturaTransmitted bool                  `json:"efacturaTransmitted"`
+	// End of the legal deadline for transmitting the invoice of a business buyer to e-Factura.
+	EfacturaDeadline *time.Time `json:"efacturaDeadline,omitempty"`
+	// Whether the e-Factura deadline has passed without the invoice being transmitted.
+	EfacturaOverdue bool       `json:"efacturaOverdue"`
+	Attempts        int        `json:"attempts"`
+	LastError       *string    `json:"lastError,omitempty"`
+	NextAttemptAt   *time.Time `json:"nextAttemptAt,omitempty"`
+}
+
+// Numbering series of a company's invoices or credit notes, or of the platform's for global admins.
+type InvoiceSeries struct {
+	ID           string              `json:"id"`
+	DocumentType InvoiceDocumentType `json:"documentType"`
+	Prefix       string              `json:"prefix"`
+	StartNumber  int                 `json:"startNumber"`
+	// Restart the numbering every year, with the year in the number.
+	YearlyReset bool `json:"yearlyReset"`
+	// Only the active series of a document type numbers new documents.
+	Active bool `json:"active"`
+	// Number the next document of the series takes, e.g. ABC-2026-0042.
+	NextInvoiceNumber string     `json:"nextInvoiceNumber"`
+	RetiredAt         *time.Time `json:"retiredAt,omitempty"`
+	CreatedAt         time.Time  `json:"createdAt"`
+}
+
+type InvoiceSeriesInput struct {
+	DocumentType InvoiceDocumentType `json:"documentType"`
+	// Uppercase letters and digits, at most 10.
+	Prefix string `json:"prefix"`
+	// Defaults to 1.
+	StartNumber *int `json:"startNumber,omitempty"`
+	// Defaults to true.
+	YearlyReset *bool `json:"yearlyReset,omitempty"`
+}
+
 type InvoiceStatusCount struct {
 	Status      InvoiceStatus `json:"status"`
 	Count       int           `json:"count"`
@@ -537,6 +752,67 @@ type JoinWaitlistInput struct {
 	Message     *string          `json:"message,omitempty"`
 }
 
+// Balance of all ledger accounts of a type, in bani. Debits are positive, credits negative.
+type LedgerBalance struct {
+	AccountType LedgerAccountType `json:"accountType"`
+	Balance     int               `json:"balance"`
+}
+
+// A difference between the ledger and the platform's Stripe balance transactions.
+type LedgerDiscrepancy struct {
+	// Ledger reference of the payment, refund or dispute, e.g. payment:pi_...
+	Reference string `json:"reference"`
+	// Stripe balance transaction (txn_...); null when Stripe has none.
+	StripeID *string `json:"stripeId,omitempty"`
+	// missing_in_ledger, missing_in_stripe or amount_mismatch.
+	Problem      string `json:"problem"`
+	LedgerAmount int    `json:"ledgerAmount"`
+	StripeAmount int    `json:"stripeAmount"`
+}
+
+// A run of the ledger reconciliation job.
+type LedgerReconciliation struct {
+	ID         string    `json:"id"`
+	PeriodFrom time.Time `json:"periodFrom"`
+	PeriodTo   time.Time `json:"periodTo"`
+	// Stripe charges, refunds and dispute adjustments compared.
+	CheckedCount int `json:"checkedCount"`
+	// Stripe fees newly posted to the ledger.
+	FeesPosted    int                  `json:"feesPosted"`
+	Discrepancies []*LedgerDiscrepancy `json:"discrepancies"`
+	CreatedAt     time.Time            `json:"createdAt"`
+}
+
+type MatchScoreBreakdown struct {
+	Base               float64  `json:"base"`
+	Rating             float64  `json:"rating"`
+	Experience         float64  `json:"experience"`
+	AreaMatch          float64  `json:"areaMatch"`
+	Packing            float64  `json:"packing"`
+	UnavailablePenalty float64  `json:"unavailablePenalty"`
+	DailyLoadPenalty   float64  `json:"dailyLoadPenalty"`
+	WeeklyLoadPenalty  float64  `json:"weeklyLoadPenalty"`
+	RawScore           float64  `json:"rawScore"`
+	Total              float64  `json:"total"`
+	PlacementDate      *string  `json:"placementDate,omitempty"`
+	FreeIntervalStart  *string  `json:"freeIntervalStart,omitempty"`
+	FreeIntervalEnd    *string  `json:"freeIntervalEnd,omitempty"`
+	GapHours           *float64 `json:"gapHours,omitempty"`
+	DayBookingCount    int      `json:"dayBookingCount"`
+	WeekBookingCount   int      `json:"weekBookingCount"`
+	BufferMinutes      int      `json:"bufferMinutes"`
+}
+
+type MatchingExplanation struct {
+	Booking             *Booking             `json:"booking"`
+	DurationHours       float64              `json:"durationHours"`
+	BufferMinutes       int                  `json:"bufferMinutes"`
+	MaxJobsPerDay       int                  `json:"maxJobsPerDay"`
+	LoadBalanceWeight   float64              `json:"loadBalanceWeight"`
+	Candidates          []*CleanerSuggestion `json:"candidates"`
+	AssignedCleanerRank *int                 `json:"assignedCleanerRank,omitempty"`
+}
+
 type Mutation struct {
 }
 
@@ -561,6 +837,29 @@ type PageInfo struct {
 	EndCursor   *string `json:"endCursor,omitempty"`
 }
 
+// A Stripe dispute (chargeback) of a booking payment.
+type PaymentDispute struct {
+	ID string `json:"id"`
+	// Stripe dispute ID (dp_...).
+	StripeDisputeID    string              `json:"stripeDisputeId"`
+	StripeChargeID     string              `json:"stripeChargeId"`
+	Booking            *Booking            `json:"booking,omitempty"`
+	Company            *Company            `json:"company,omitempty"`
+	PaymentTransaction *PaymentTransaction `json:"paymentTransaction,omitempty"`
+	Amount             int                 `json:"amount"`
+	Currency           string              `json:"currency"`
+	// Stripe's reason, e.g. fraudulent or product_not_received.
+	Reason string        `json:"reason"`
+	Status DisputeStatus `json:"status"`
+	// Deadline for submitting evidence to Stripe.
+	EvidenceDueBy *time.Time `json:"evidenceDueBy,omitempty"`
+	// Evidence assembled so far; null until prepared.
+	Evidence            *DisputeEvidence `json:"evidence,omitempty"`
+	EvidenceSubmittedAt *time.Time       `json:"evidenceSubmittedAt,omitempty"`
+	ClosedAt            *time.Time       `json:"closedAt,omitempty"`
+	CreatedAt           time.Time        `json:"createdAt"`
+}
+
 type PaymentHistoryConnection struct {
 	Edges      []*PaymentHistoryEntry `json:"edges"`
 	PageInfo   *PageInfo              `json:"pageInfo"`
@@ -582,6 +881,8 @@ type PaymentIntentResult struct {
 	PaymentIntentID string `json:"paymentIntentId"`
 	Amount          int    `json:"amount"`
 	Currency        string `json:"currency"`
+	// automatic charges the card now; manual only places an authorization hold.
+	CaptureMethod string `json:"captureMethod"`
 }
 
 type PaymentMethod struct {
@@ -606,15 +907,21 @@ type PaymentTransaction struct {
 	Status                PaymentTransactionStatus `json:"status"`
 	FailureReason         *string                  `json:"failureReason,omitempty"`
 	RefundAmount          *int                     `json:"refundAmount,omitempty"`
-	CreatedAt             time.Time                `json:"createdAt"`
+	// automatic or manual (authorized at confirmation, captured at completion).
+	CaptureMethod string `json:"captureMethod"`
+	// Amount of the authorization hold, in bani, for manual captures.
+	AmountAuthorized *int       `json:"amountAuthorized,omitempty"`
+	AuthorizedAt     *time.Time `json:"authorizedAt,omitempty"`
+	CreatedAt        time.Time  `json:"createdAt"`
 }
 
 type PayoutLineItem struct {
-	ID               string   `json:"id"`
-	Booking          *Booking `json:"booking,omitempty"`
-	AmountGross      int      `json:"amountGross"`
-	AmountCommission int      `json:"amountCommission"`
-	AmountNet        int      `json:"amountNet"`
+	ID               string          `json:"id"`
+	Booking          *Booking        `json:"booking,omitempty"`
+	Cleaner          *CleanerProfile `json:"cleaner,omitempty"`
+	AmountGross      int             `json:"amountGross"`
+	AmountCommission int             `json:"amountCommission"`
+	AmountNet        int             `json:"amountNet"`
 }
 
 type PersonalityAnswerInput struct {
@@ -659,14 +966,19 @@ type PersonalityQuestion struct {
 	Text      string `json:"text"`
 }
 
+// Money movement in a period, from the ledger.
 type PlatformRevenueReport struct {
-	TotalRevenue    int `json:"totalRevenue"`
+	TotalRevenue int `json:"totalRevenue"`
+	// Commission net of refunds and commission credit notes.
 	TotalCommission int `json:"totalCommission"`
 	TotalPayouts    int `json:"totalPayouts"`
-	PendingPayouts  int `json:"pendingPayouts"`
-	TotalRefunds    int `json:"totalRefunds"`
-	NetRevenue      int `json:"netRevenue"`
-	BookingCount    int `json:"bookingCount"`
+	// What the platform owes all companies now.
+	PendingPayouts int `json:"pendingPayouts"`
+	TotalRefunds   int `json:"totalRefunds"`
+	StripeFees     int `json:"stripeFees"`
+	// Commission minus Stripe fees.
+	NetRevenue   int `json:"netRevenue"`
+	BookingCount int `json:"bookingCount"`
 }
 
 type PlatformSetting struct {
@@ -720,41 +1032,57 @@ type PriceEstimateInput struct {
 	Extras       []*ExtraInput `json:"extras,omitempty"`
 }
 
+type PublicHoliday struct {
+	Date string `json:"date"`
+	Name string `json:"name"`
+}
+
 type Query struct {
 }
 
 type RecurrenceInput struct {
-	Type      RecurrenceType `json:"type"`
-	DayOfWeek int            `json:"dayOfWeek"`
+	Type      *RecurrenceType `json:"type,omitempty"`
+	DayOfWeek *int            `json:"dayOfWeek,omitempty"`
+	Rrule     *string         `json:"rrule,omitempty"`
 }
 
 type RecurringBookingGroup struct {
-	ID                          string          `json:"id"`
-	Client                      *User           `json:"client,omitempty"`
-	Company                     *Company        `json:"company,omitempty"`
-	PreferredCleaner            *CleanerProfile `json:"preferredCleaner,omitempty"`
-	Address                     *Address        `json:"address,omitempty"`
-	RecurrenceType              RecurrenceType  `json:"recurrenceType"`
-	DayOfWeek                   *int            `json:"dayOfWeek,omitempty"`
-	PreferredTime               string          `json:"preferredTime"`
-	ServiceType                 ServiceType     `json:"serviceType"`
-	ServiceName                 string          `json:"serviceName"`
-	PropertyType                *string         `json:"propertyType,omitempty"`
-	NumRooms                    *int            `json:"numRooms,omitempty"`
-	NumBathrooms                *int            `json:"numBathrooms,omitempty"`
-	AreaSqm                     *int            `json:"areaSqm,omitempty"`
-	HasPets                     *bool           `json:"hasPets,omitempty"`
-	SpecialInstructions         *string         `json:"specialInstructions,omitempty"`
-	HourlyRate                  float64         `json:"hourlyRate"`
-	EstimatedTotalPerOccurrence float64         `json:"estimatedTotalPerOccurrence"`
-	IsActive                    bool            `json:"isActive"`
-	CancelledAt                 *time.Time      `json:"cancelledAt,omitempty"`
-	CancellationReason          *string         `json:"cancellationReason,omitempty"`
-	Occurrences                 []*Booking      `json:"occurrences"`
-	UpcomingOccurrences         []*Booking      `json:"upcomingOccurrences"`
-	TotalOccurrences            int             `json:"totalOccurrences"`
-	CompletedOccurrences        int             `json:"completedOccurrences"`
-	CreatedAt                   time.Time       `json:"createdAt"`
+	ID                          string                          `json:"id"`
+	Client                      *User                           `json:"client,omitempty"`
+	Company                     *Company                        `json:"company,omitempty"`
+	PreferredCleaner            *CleanerProfile                 `json:"preferredCleaner,omitempty"`
+	Address                     *Address                        `json:"address,omitempty"`
+	RecurrenceType              RecurrenceType                  `json:"recurrenceType"`
+	Rrule                       string                          `json:"rrule"`
+	Exdates                     []string                        `json:"exdates"`
+	DayOfWeek                   *int                            `json:"dayOfWeek,omitempty"`
+	PreferredTime               string                          `json:"preferredTime"`
+	ServiceType                 ServiceType                     `json:"serviceType"`
+	ServiceName                 string                          `json:"serviceName"`
+	PropertyType                *string                         `json:"propertyType,omitempty"`
+	NumRooms                    *int                            `json:"numRooms,omitempty"`
+	NumBathrooms                *int                            `json:"numBathrooms,omitempty"`
+	AreaSqm                     *int                            `json:"areaSqm,omitempty"`
+	HasPets                     *bool                           `json:"hasPets,omitempty"`
+	SpecialInstructions         *string                         `json:"specialInstructions,omitempty"`
+	HourlyRate                  float64                         `json:"hourlyRate"`
+	EstimatedTotalPerOccurrence float64                         `json:"estimatedTotalPerOccurrence"`
+	IsActive                    bool                            `json:"isActive"`
+	CancelledAt                 *time.Time                      `json:"cancelledAt,omitempty"`
+	CancellationReason          *string                         `json:"cancellationReason,omitempty"`
+	PaymentFailures             int                             `json:"paymentFailures"`
+	Occurrences                 []*Booking                      `json:"occurrences"`
+	UpcomingOccurrences         []*Booking                      `json:"upcomingOccurrences"`
+	TotalOccurrences            int                             `json:"totalOccurrences"`
+	CompletedOccurrences        int                             `json:"completedOccurrences"`
+	Exceptions                  []*RecurringOccurrenceException `json:"exceptions"`
+	CreatedAt                   time.Time                       `json:"createdAt"`
+}
+
+type RecurringOccurrenceException struct {
+	OriginalDate string                  `json:"originalDate"`
+	Type         OccurrenceExceptionType `json:"type"`
+	Booking      *Booking                `json:"booking,omitempty"`
 }
 
 type RefundRequest struct {
@@ -765,8 +1093,10 @@ type RefundRequest struct {
 	Amount      int          `json:"amount"`
 	Reason      string       `json:"reason"`
 	Status      RefundStatus `json:"status"`
-	ProcessedAt *time.Time   `json:"processedAt,omitempty"`
-	CreatedAt   time.Time    `json:"createdAt"`
+	// Credit note issued on the booking's client invoice once the refund went through.
+	CreditNote  *Invoice   `json:"creditNote,omitempty"`
+	ProcessedAt *time.Time `json:"processedAt,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
 }
 
 type RequestOtpResponse struct {
@@ -850,6 +1180,18 @@ type SubmitReviewInput struct {
 	Comment   *string `json:"comment,omitempty"`
 }
 
+type TeamSuggestion struct {
+	Company            *Company          `json:"company"`
+	Members            []*CleanerProfile `json:"members"`
+	TeamSize           int               `json:"teamSize"`
+	DurationHours      float64           `json:"durationHours"`
+	SuggestedDate      string            `json:"suggestedDate"`
+	SuggestedStartTime string            `json:"suggestedStartTime"`
+	SuggestedEndTime   string            `json:"suggestedEndTime"`
+	SuggestedSlotIndex int               `json:"suggestedSlotIndex"`
+	MatchScore         float64           `json:"matchScore"`
+}
+
 type TimeSlotInput struct {
 	Date      string `json:"date"`
 	StartTime string `json:"startTime"`
@@ -897,6 +1239,19 @@ type UpdateProfileInput struct {
 	PreferredLanguage *string `json:"preferredLanguage,omitempty"`
 }
 
+type UpdateRecurringGroupInput struct {
+	Rrule               *string       `json:"rrule,omitempty"`
+	DayOfWeek           *int          `json:"dayOfWeek,omitempty"`
+	PreferredTime       *string       `json:"preferredTime,omitempty"`
+	PropertyType        *string       `json:"propertyType,omitempty"`
+	NumRooms            *int          `json:"numRooms,omitempty"`
+	NumBathrooms        *int          `json:"numBathrooms,omitempty"`
+	AreaSqm             *int          `json:"areaSqm,omitempty"`
+	HasPets             *bool         `json:"hasPets,omitempty"`
+	SpecialInstructions *string       `json:"specialInstructions,omitempty"`
+	Extras              []*ExtraInput `json:"extras,omitempty"`
+}
+
 type UpdateServiceDefinitionInput struct {
 	ID                 string   `json:"id"`
 	NameRo             string   `json:"nameRo"`
@@ -946,6 +1301,24 @@ type UserConnection struct {
 	TotalCount int     `json:"totalCount"`
 }
 
+// VAT rate of a category, in force from validFrom until the next rate of the category.
+type VatRate struct {
+	ID          string      `json:"id"`
+	Category    VatCategory `json:"category"`
+	RatePct     int         `json:"ratePct"`
+	ValidFrom   string      `json:"validFrom"`
+	Description *string     `json:"description,omitempty"`
+	CreatedAt   time.Time   `json:"createdAt"`
+}
+
+type VatRateInput struct {
+	Category VatCategory `json:"category"`
+	RatePct  int         `json:"ratePct"`
+	// YYYY-MM-DD, today or later.
+	ValidFrom   string  `json:"validFrom"`
+	Description *string `json:"description,omitempty"`
+}
+
 type WaitlistLead struct {
 	ID          string           `json:"id"`
 	LeadType    WaitlistLeadType `json:"leadType"`
@@ -964,6 +1337,22 @@ type WaitlistStats struct {
 	TotalCount   int `json:"totalCount"`
 }
 
+// A Stripe webhook event from the event log. Failed events are retried with backoff.
+type WebhookEvent struct {
+	// Stripe event ID (evt_...).
+	ID        string             `json:"id"`
+	Type      string             `json:"type"`
+	Status    WebhookEventStatus `json:"status"`
+	Attempts  int                `json:"attempts"`
+	LastError *string            `json:"lastError,omitempty"`
+	// When the retry job will try again; null once processed or given up.
+	NextAttemptAt *time.Time `json:"nextAttemptAt,omitempty"`
+	ProcessedAt   *time.Time `json:"processedAt,omitempty"`
+	// Raw event JSON as received from Stripe.
+	Payload   string    `json:"payload"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
 type WorkScheduleDayInput struct {
 	DayOfWeek int    `json:"dayOfWeek"`
 	StartTime string `json:"startTime"`
@@ -971,6 +1360,128 @@ type WorkScheduleDayInput struct {
 	IsWorkDay bool   `json:"isWorkDay"`
 }
 
+type AccountingExportFormat string
+
+const (
+	// Invoice import XML of SAGA.
+	AccountingExportFormatSagaXML AccountingExportFormat = "SAGA_XML"
+	// Invoice lines for the WinMentor import, semicolon-separated.
+	AccountingExportFormatWinmentorCSV AccountingExportFormat = "WINMENTOR_CSV"
+	// Lines of the ledger entries of the period.
+	AccountingExportFormatLedgerCSV AccountingExportFormat = "LEDGER_CSV"
+	// Sales journal (jurnal de vânzări) per invoice and VAT rate, with the SalesInvoices data of SAF-T D406.
+	AccountingExportFormatSalesJournalCSV AccountingExportFormat = "SALES_JOURNAL_CSV"
+)
+
+var AllAccountingExportFormat = []AccountingExportFormat{
+	AccountingExportFormatSagaXML,
+	AccountingExportFormatWinmentorCSV,
+	AccountingExportFormatLedgerCSV,
+	AccountingExportFormatSalesJournalCSV,
+}
+
+func (e AccountingExportFormat) IsValid() bool {
+	switch e {
+	case AccountingExportFormatSagaXML, AccountingExportFormatWinmentorCSV, AccountingExportFormatLedgerCSV, AccountingExportFormatSalesJournalCSV:
+		return true
+	}
+	return false
+}
+
+func (e AccountingExportFormat) String() string {
+	return string(e)
+}
+
+func (e *AccountingExportFormat) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = AccountingExportFormat(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid AccountingExportFormat", str)
+	}
+	return nil
+}
+
+func (e AccountingExportFormat) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *AccountingExportFormat) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e AccountingExportFormat) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type AccountingExportStatus string
+
+const (
+	AccountingExportStatusPending    AccountingExportStatus = "PENDING"
+	AccountingExportStatusProcessing AccountingExportStatus = "PROCESSING"
+	AccountingExportStatusReady      AccountingExportStatus = "READY"
+	AccountingExportStatusFailed     AccountingExportStatus = "FAILED"
+)
+
+var AllAccountingExportStatus = []AccountingExportStatus{
+	AccountingExportStatusPending,
+	AccountingExportStatusProcessing,
+	AccountingExportStatusReady,
+	AccountingExportStatusFailed,
+}
+
+func (e AccountingExportStatus) IsValid() bool {
+	switch e {
+	case AccountingExportStatusPending, AccountingExportStatusProcessing, AccountingExportStatusReady, AccountingExportStatusFailed:
+		return true
+	}
+	return false
+}
+
+func (e AccountingExportStatus) String() string {
+	return string(e)
+}
+
+func (e *AccountingExportStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = AccountingExportStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid AccountingExportStatus", str)
+	}
+	return nil
+}
+
+func (e AccountingExportStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *AccountingExportStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e AccountingExportStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
 type BookingStatus string
 
 const (
@@ -1274,6 +1785,71 @@ func (e ConnectOnboardingStatus) MarshalJSON() ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+type DisputeStatus string
+
+const (
+	DisputeStatusWarningNeedsResponse DisputeStatus = "WARNING_NEEDS_RESPONSE"
+	DisputeStatusWarningUnderReview   DisputeStatus = "WARNING_UNDER_REVIEW"
+	DisputeStatusWarningClosed        DisputeStatus = "WARNING_CLOSED"
+	DisputeStatusNeedsResponse        DisputeStatus = "NEEDS_RESPONSE"
+	DisputeStatusUnderReview          DisputeStatus = "UNDER_REVIEW"
+	DisputeStatusWon                  DisputeStatus = "WON"
+	DisputeStatusLost                 DisputeStatus = "LOST"
+)
+
+var AllDisputeStatus = []DisputeStatus{
+	DisputeStatusWarningNeedsResponse,
+	DisputeStatusWarningUnderReview,
+	DisputeStatusWarningClosed,
+	DisputeStatusNeedsResponse,
+	DisputeStatusUnderReview,
+	DisputeStatusWon,
+	DisputeStatusLost,
+}
+
+func (e DisputeStatus) IsValid() bool {
+	switch e {
+	case DisputeStatusWarningNeedsResponse, DisputeStatusWarningUnderReview, DisputeStatusWarningClosed, DisputeStatusNeedsResponse, DisputeStatusUnderReview, DisputeStatusWon, DisputeStatusLost:
+		return true
+	}
+	return false
+}
+
+func (e DisputeStatus) String() string {
+	return string(e)
+}
+
+func (e *DisputeStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = DisputeStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid DisputeStatus", str)
+	}
+	return nil
+}
+
+func (e DisputeStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *DisputeStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e DisputeStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
 type DocumentStatus string
 
 const (
@@ -1331,6 +1907,178 @@ func (e DocumentStatus) MarshalJSON() ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// When the client invoice of a booking is issued.
+type InvoiceAutoIssueTrigger string
+
+const (
+	InvoiceAutoIssueTriggerManual           InvoiceAutoIssueTrigger = "MANUAL"
+	InvoiceAutoIssueTriggerJobCompleted     InvoiceAutoIssueTrigger = "JOB_COMPLETED"
+	InvoiceAutoIssueTriggerPaymentSucceeded InvoiceAutoIssueTrigger = "PAYMENT_SUCCEEDED"
+)
+
+var AllInvoiceAutoIssueTrigger = []InvoiceAutoIssueTrigger{
+	InvoiceAutoIssueTriggerManual,
+	InvoiceAutoIssueTriggerJobCompleted,
+	InvoiceAutoIssueTriggerPaymentSucceeded,
+}
+
+func (e InvoiceAutoIssueTrigger) IsValid() bool {
+	switch e {
+	case InvoiceAutoIssueTriggerManual, InvoiceAutoIssueTriggerJobCompleted, InvoiceAutoIssueTriggerPaymentSucceeded:
+		return true
+	}
+	return false
+}
+
+func (e InvoiceAutoIssueTrigger) String() string {
+	return string(e)
+}
+
+func (e *InvoiceAutoIssueTrigger) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = InvoiceAutoIssueTrigger(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid InvoiceAutoIssueTrigger", str)
+	}
+	return nil
+}
+
+func (e InvoiceAutoIssueTrigger) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *InvoiceAutoIssueTrigger) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e InvoiceAutoIssueTrigger) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type InvoiceDocumentType string
+
+const (
+	InvoiceDocumentTypeInvoice    InvoiceDocumentType = "INVOICE"
+	InvoiceDocumentTypeCreditNote InvoiceDocumentType = "CREDIT_NOTE"
+)
+
+var AllInvoiceDocumentType = []InvoiceDocumentType{
+	InvoiceDocumentTypeInvoice,
+	InvoiceDocumentTypeCreditNote,
+}
+
+func (e InvoiceDocumentType) IsValid() bool {
+	switch e {
+	case InvoiceDocumentTypeInvoice, InvoiceDocumentTypeCreditNote:
+		return true
+	}
+	return false
+}
+
+func (e InvoiceDocumentType) String() string {
+	return string(e)
+}
+
+func (e *InvoiceDocumentType) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = InvoiceDocumentType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid InvoiceDocumentType", str)
+	}
+	return nil
+}
+
+func (e InvoiceDocumentType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *InvoiceDocumentType) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e InvoiceDocumentType) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type InvoicePipelineStatus string
+
+const (
+	InvoicePipelineStatusPending    InvoicePipelineStatus = "PENDING"
+	InvoicePipelineStatusProcessing InvoicePipelineStatus = "PROCESSING"
+	InvoicePipelineStatusDone       InvoicePipelineStatus = "DONE"
+	InvoicePipelineStatusFailed     InvoicePipelineStatus = "FAILED"
+)
+
+var AllInvoicePipelineStatus = []InvoicePipelineStatus{
+	InvoicePipelineStatusPending,
+	InvoicePipelineStatusProcessing,
+	InvoicePipelineStatusDone,
+	InvoicePipelineStatusFailed,
+}
+
+func (e InvoicePipelineStatus) IsValid() bool {
+	switch e {
+	case InvoicePipelineStatusPending, InvoicePipelineStatusProcessing, InvoicePipelineStatusDone, InvoicePipelineStatusFailed:
+		return true
+	}
+	return false
+}
+
+func (e InvoicePipelineStatus) String() string {
+	return string(e)
+}
+
+func (e *InvoicePipelineStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = InvoicePipelineStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid InvoicePipelineStatus", str)
+	}
+	return nil
+}
+
+func (e InvoicePipelineStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *InvoicePipelineStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e InvoicePipelineStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
 type InvoiceStatus string
 
 const (
@@ -1451,12 +2199,131 @@ func (e InvoiceType) MarshalJSON() ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+type LedgerAccountType string
+
+const (
+	LedgerAccountTypeStripeBalance LedgerAccountType = "STRIPE_BALANCE"
+	LedgerAccountTypeClient        LedgerAccountType = "CLIENT"
+	LedgerAccountTypeCompany       LedgerAccountType = "COMPANY"
+	LedgerAccountTypePlatformFees  LedgerAccountType = "PLATFORM_FEES"
+	LedgerAccountTypeStripeFees    LedgerAccountType = "STRIPE_FEES"
+	LedgerAccountTypeVatPayable    LedgerAccountType = "VAT_PAYABLE"
+)
+
+var AllLedgerAccountType = []LedgerAccountType{
+	LedgerAccountTypeStripeBalance,
+	LedgerAccountTypeClient,
+	LedgerAccountTypeCompany,
+	LedgerAccountTypePlatformFees,
+	LedgerAccountTypeStripeFees,
+	LedgerAccountTypeVatPayable,
+}
+
+func (e LedgerAccountType) IsValid() bool {
+	switch e {
+	case LedgerAccountTypeStripeBalance, LedgerAccountTypeClient, LedgerAccountTypeCompany, LedgerAccountTypePlatformFees, LedgerAccountTypeStripeFees, LedgerAccountTypeVatPayable:
+		return true
+	}
+	return false
+}
+
+func (e LedgerAccountType) String() string {
+	return string(e)
+}
+
+func (e *LedgerAccountType) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = LedgerAccountType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid LedgerAccountType", str)
+	}
+	return nil
+}
+
+func (e LedgerAccountType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *LedgerAccountType) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e LedgerAccountType) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type OccurrenceExceptionType string
+
+const (
+	OccurrenceExceptionTypeSkipped     OccurrenceExceptionType = "SKIPPED"
+	OccurrenceExceptionTypeRescheduled OccurrenceExceptionType = "RESCHEDULED"
+)
+
+var AllOccurrenceExceptionType = []OccurrenceExceptionType{
+	OccurrenceExceptionTypeSkipped,
+	OccurrenceExceptionTypeRescheduled,
+}
+
+func (e OccurrenceExceptionType) IsValid() bool {
+	switch e {
+	case OccurrenceExceptionTypeSkipped, OccurrenceExceptionTypeRescheduled:
+		return true
+	}
+	return false
+}
+
+func (e OccurrenceExceptionType) String() string {
+	return string(e)
+}
+
+func (e *OccurrenceExceptionType) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = OccurrenceExceptionType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid OccurrenceExceptionType", str)
+	}
+	return nil
+}
+
+func (e OccurrenceExceptionType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *OccurrenceExceptionType) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e OccurrenceExceptionType) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
 type PaymentTransactionStatus string
 
 const (
 	PaymentTransactionStatusPending           PaymentTransactionStatus = "PENDING"
 	PaymentTransactionStatusRequiresAction    PaymentTransactionStatus = "REQUIRES_ACTION"
 	PaymentTransactionStatusProcessing        PaymentTransactionStatus = "PROCESSING"
+	PaymentTransactionStatusAuthorized        PaymentTransactionStatus = "AUTHORIZED"
 	PaymentTransactionStatusSucceeded         PaymentTransactionStatus = "SUCCEEDED"
 	PaymentTransactionStatusFailed            PaymentTransactionStatus = "FAILED"
 	PaymentTransactionStatusRefunded          PaymentTransactionStatus = "REFUNDED"
@@ -1468,6 +2335,7 @@ var AllPaymentTransactionStatus = []PaymentTransactionStatus{
 	PaymentTransactionStatusPending,
 	PaymentTransactionStatusRequiresAction,
 	PaymentTransactionStatusProcessing,
+	PaymentTransactionStatusAuthorized,
 	PaymentTransactionStatusSucceeded,
 	PaymentTransactionStatusFailed,
 	PaymentTransactionStatusRefunded,
@@ -1477,7 +2345,7 @@ var AllPaymentTransactionStatus = []PaymentTransactionStatus{
 
 func (e PaymentTransactionStatus) IsValid() bool {
 	switch e {
-	case PaymentTransactionStatusPending, PaymentTransactionStatusRequiresAction, PaymentTransactionStatusProcessing, PaymentTransactionStatusSucceeded, PaymentTransactionStatusFailed, PaymentTransactionStatusRefunded, PaymentTransactionStatusPartiallyRefunded, PaymentTransactionStatusCancelled:
+	case PaymentTransactionStatusPending, PaymentTransactionStatusRequiresAction, PaymentTransactionStatusProcessing, PaymentTransactionStatusAuthorized, PaymentTransactionStatusSucceeded, PaymentTransactionStatusFailed, PaymentTransactionStatusRefunded, PaymentTransactionStatusPartiallyRefunded, PaymentTransactionStatusCancelled:
 		return true
 	}
 	return false
@@ -1521,7 +2389,9 @@ func (e PaymentTransactionStatus) MarshalJSON() ([]byte, error) {
 type PayoutStatus string
 
 const (
-	PayoutStatusPending    PayoutStatus = "PENDING"
+	PayoutStatusPending PayoutStatus = "PENDING"
+	// Contains a payment under dispute; released when the dispute is won.
+	PayoutStatusHeld       PayoutStatus = "HELD"
 	PayoutStatusProcessing PayoutStatus = "PROCESSING"
 	PayoutStatusPaid       PayoutStatus = "PAID"
 	PayoutStatusFailed     PayoutStatus = "FAILED"
@@ -1530,6 +2400,7 @@ const (
 
 var AllPayoutStatus = []PayoutStatus{
 	PayoutStatusPending,
+	PayoutStatusHeld,
 	PayoutStatusProcessing,
 	PayoutStatusPaid,
 	PayoutStatusFailed,
@@ -1538,7 +2409,7 @@ var AllPayoutStatus = []PayoutStatus{
 
 func (e PayoutStatus) IsValid() bool {
 	switch e {
-	case PayoutStatusPending, PayoutStatusProcessing, PayoutStatusPaid, PayoutStatusFailed, PayoutStatusCancelled:
+	case PayoutStatusPending, PayoutStatusHeld, PayoutStatusProcessing, PayoutStatusPaid, PayoutStatusFailed, PayoutStatusCancelled:
 		return true
 	}
 	return false
@@ -1585,17 +2456,19 @@ const (
 	RecurrenceTypeWeekly   RecurrenceType = "WEEKLY"
 	RecurrenceTypeBiweekly RecurrenceType = "BIWEEKLY"
 	RecurrenceTypeMonthly  RecurrenceType = "MONTHLY"
+	RecurrenceTypeCustom   RecurrenceType = "CUSTOM"
 )
 
 var AllRecurrenceType = []RecurrenceType{
 	RecurrenceTypeWeekly,
 	RecurrenceTypeBiweekly,
 	RecurrenceTypeMonthly,
+	RecurrenceTypeCustom,
 }
 
 func (e RecurrenceType) IsValid() bool {
 	switch e {
-	case RecurrenceTypeWeekly, RecurrenceTypeBiweekly, RecurrenceTypeMonthly:
+	case RecurrenceTypeWeekly, RecurrenceTypeBiweekly, RecurrenceTypeMonthly, RecurrenceTypeCustom:
 		return true
 	}
 	return false
@@ -1876,6 +2749,63 @@ func (e UserStatus) MarshalJSON() ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+type VatCategory string
+
+const (
+	VatCategoryStandard VatCategory = "STANDARD"
+	VatCategoryReduced  VatCategory = "REDUCED"
+	VatCategoryExempt   VatCategory = "EXEMPT"
+)
+
+var AllVatCategory = []VatCategory{
+	VatCategoryStandard,
+	VatCategoryReduced,
+	VatCategoryExempt,
+}
+
+func (e VatCategory) IsValid() bool {
+	switch e {
+	case VatCategoryStandard, VatCategoryReduced, VatCategoryExempt:
+		return true
+	}
+	return false
+}
+
+func (e VatCategory) String() string {
+	return string(e)
+}
+
+func (e *VatCategory) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = VatCategory(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid VatCategory", str)
+	}
+	return nil
+}
+
+func (e VatCategory) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *VatCategory) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e VatCategory) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
 type WaitlistLeadType string
 
 const (
@@ -1930,3 +2860,62 @@ func (e WaitlistLeadType) MarshalJSON() ([]byte, error) {
 	e.MarshalGQL(&buf)
 	return buf.Bytes(), nil
 }
+
+type WebhookEventStatus string
+
+const (
+	WebhookEventStatusPending    WebhookEventStatus = "PENDING"
+	WebhookEventStatusProcessing WebhookEventStatus = "PROCESSING"
+	WebhookEventStatusProcessed  WebhookEventStatus = "PROCESSED"
+	WebhookEventStatusFailed     WebhookEventStatus = "FAILED"
+)
+
+var AllWebhookEventStatus = []WebhookEventStatus{
+	WebhookEventStatusPending,
+	WebhookEventStatusProcessing,
+	WebhookEventStatusProcessed,
+	WebhookEventStatusFailed,
+}
+
+func (e WebhookEventStatus) IsValid() bool {
+	switch e {
+	case WebhookEventStatusPending, WebhookEventStatusProcessing, WebhookEventStatusProcessed, WebhookEventStatusFailed:
+		return true
+	}
+	return false
+}
+
+func (e WebhookEventStatus) String() string {
+	return string(e)
+}
+
+func (e *WebhookEventStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = WebhookEventStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid WebhookEventStatus", str)
+	}
+	return nil
+}
+
+func (e WebhookEventStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *WebhookEventStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e WebhookEventStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
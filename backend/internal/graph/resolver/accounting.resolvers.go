@@ -0,0 +1,92 @@
+package resolver
+
+// This file will be automatically regenerated based on the schema, any resolver
+// implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.86
+
+import (
+	"context"
+	"fmt"
+	"helpmeclean-backend/internal/auth"
+	db "helpmeclean-backend/internal/db/generated"
+	"helpmeclean-backend/internal/graph/model"
+	"helpmeclean-backend/internal/service/accounting"
+	"strings"
+	"time"
+)
+
+// ExportAccounting is the resolver for the exportAccounting field.
+func (r *mutationResolver) ExportAccounting(ctx context.Context, from string, to string, format model.AccountingExportFormat) (*model.AccountingExport, error) {
+	claims := auth.GetUserFromContext(ctx)
+	if claims == nil {
+		return nil, fmt.Errorf("not authenticated")
+	}
+	companyID, err := r.accountingScope(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	fromDate, err := time.Parse("2006-01-02", from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from date: %w", err)
+	}
+	toDate, err := time.Parse("2006-01-02", to)
+	if err != nil {
+		return nil, fmt.Errorf("invalid to date: %w", err)
+	}
+
+	exp, err := r.AccountingService.Request(ctx, companyID, stringToUUID(claims.UserID),
+		accounting.Format(strings.ToLower(string(format))), fromDate, toDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start accounting export: %w", err)
+	}
+	return dbAccountingExportToGQL(exp), nil
+}
+
+// AccountingExports is the resolver for the accountingExports field.
+func (r *queryResolver) AccountingExports(ctx context.Context, first *int) ([]*model.AccountingExport, error) {
+	claims := auth.GetUserFromContext(ctx)
+	if claims == nil {
+		return nil, fmt.Errorf("not authenticated")
+	}
+	companyID, err := r.accountingScope(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := int32(20)
+	if first != nil {
+		limit = int32(*first)
+	}
+	exports, err := r.Queries.ListAccountingExports(ctx, db.ListAccountingExportsParams{
+		CompanyID: companyID,
+		Limit:     limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list accounting exports: %w", err)
+	}
+	result := make([]*model.AccountingExport, len(exports))
+	for i, exp := range exports {
+		result[i] = dbAccountingExportToGQL(exp)
+	}
+	return result, nil
+}
+
+// AccountingExport is the resolver for the accountingExport field.
+func (r *queryResolver) AccountingExport(ctx context.Context, id string) (*model.AccountingExport, error) {
+	claims := auth.GetUserFromContext(ctx)
+	if claims == nil {
+		return nil, fmt.Errorf("not authenticated")
+	}
+	companyID, err := r.accountingScope(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	exp, err := r.Queries.GetAccountingExport(ctx, stringToUUID(id))
+	if err != nil || exp.CompanyID != companyID {
+		return nil, fmt.Errorf("accounting export not found")
+	}
+	return dbAccountingExportToGQL(exp), nil
+}
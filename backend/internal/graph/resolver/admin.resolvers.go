@@ -31,6 +31,7 @@ func (r *mutationResolver) AdminCancelBooking(ctx context.Context, id string, re
 	if err != nil {
 		return nil, fmt.Errorf("failed to cancel booking: %w", err)
 	}
+	r.releasePaymentHold(ctx, booking.ID)
 
 	return dbBookingToGQL(booking), nil
 }
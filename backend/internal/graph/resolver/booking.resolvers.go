@@ -7,14 +7,17 @@ package resolver
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"helpmeclean-backend/internal/auth"
 	db "helpmeclean-backend/internal/db/generated"
 	"helpmeclean-backend/internal/graph/model"
+	"helpmeclean-backend/internal/service/matching"
 	"log"
 	"strings"
 	"time"
 
+	pgx "github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
@@ -105,47 +108,37 @@ func (r *mutationResolver) CreateBookingRequest(ctx context.Context, input model
 		}
 	}
 
-	// Look up service definition for pricing.
+	// Price the visit from the service definition and extras.
 	dbServiceType := gqlServiceTypeToDb(input.ServiceType)
-	serviceDef, err := r.Queries.GetServiceByType(ctx, dbServiceType)
+	quote, err := r.quoteBooking(ctx, dbServiceType, input.NumRooms, input.NumBathrooms, input.AreaSqm, input.PropertyType, input.HasPets, input.Extras)
 	if err != nil {
-		return nil, fmt.Errorf("service type not found: %w", err)
+		return nil, err
 	}
+	hourlyRate := quote.HourlyRate
+	estimatedHours := quote.EstimatedHours
 
-	hourlyRate := numericToFloat(serviceDef.BasePricePerHour)
-
-	// Fetch extras for duration and pricing.
-	var extrasDuration []struct {
-		DurationMinutes int32
-		Quantity        int
+	// Jobs longer than one cleaner may work are split across a team. Pricing
+	// stays on total labour hours; the booking stores the shared on-site window.
+	requestedTeamSize := 0
+	if input.TeamSize != nil {
+		requestedTeamSize = *input.TeamSize
 	}
-	extrasTotal := 0.0
-	if input.Extras != nil {
-		for _, extraInput := range input.Extras {
-			extra, err := r.Queries.GetExtraByID(ctx, stringToUUID(extraInput.ExtraID))
-			if err != nil {
-				return nil, fmt.Errorf("extra not found: %w", err)
-			}
-			extrasTotal += numericToFloat(extra.Price) * float64(extraInput.Quantity)
-			extrasDuration = append(extrasDuration, struct {
-				DurationMinutes int32
-				Quantity        int
-			}{DurationMinutes: extra.DurationMinutes, Quantity: extraInput.Quantity})
-		}
+	if len(input.TeamCleanerIds) > 0 {
+		requestedTeamSize = len(input.TeamCleanerIds)
 	}
-
-	// Estimate duration using DB-driven parameters.
-	estimatedHours := estimateDuration(serviceDef, input.NumRooms, input.NumBathrooms, input.AreaSqm, input.PropertyType, input.HasPets, extrasDuration)
-
-	subtotal := hourlyRate * estimatedHours
-
-	// Pets surcharge (flat fee).
-	petsSurcharge := 0.0
-	if input.HasPets != nil && *input.HasPets {
-		petsSurcharge = 15.0
+	maxHoursPerCleaner, maxTeamSize := loadTeamLimits(ctx, r.Queries)
+	teamSize, windowHours := matching.TeamDuration(estimatedHours, maxHoursPerCleaner, requestedTeamSize)
+	if teamSize > maxTeamSize {
+		return nil, fmt.Errorf("this job needs %d cleaners, more than the maximum team size of %d", teamSize, maxTeamSize)
+	}
+	if len(input.TeamCleanerIds) > 0 && len(input.TeamCleanerIds) != teamSize {
+		return nil, fmt.Errorf("this job needs %d cleaners, got %d", teamSize, len(input.TeamCleanerIds))
+	}
+	if teamSize > 1 && input.Recurrence != nil {
+		return nil, fmt.Errorf("recurring bookings are not supported for team jobs")
 	}
 
-	estimatedTotal := subtotal + extrasTotal + petsSurcharge
+	estimatedTotal := quote.Total()
 
 	// Resolve scheduled date/time from timeSlots or legacy fields.
 	var scheduledDate time.Time
@@ -183,6 +176,25 @@ func (r *mutationResolver) CreateBookingRequest(ctx context.Context, input model
 		}
 	}
 
+	// Validate the recurrence before creating anything; the first booking
+	// is the first date of the series.
+	var series recurrenceSpec
+	if input.Recurrence != nil {
+		series, err = resolveRecurrence(input.Recurrence, scheduledDate)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// A preferred cleaner's company must work on the date.
+	if input.PreferredCleanerID != nil && *input.PreferredCleanerID != "" {
+		if cleaner, err := r.Queries.GetCleanerByID(ctx, stringToUUID(*input.PreferredCleanerID)); err == nil {
+			if err := r.checkCompanyOpen(ctx, cleaner.CompanyID, scheduledDate); err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	referenceCode := fmt.Sprintf("HMC-%d", time.Now().UnixNano()%1000000)
 
 	booking, err := r.Queries.CreateBooking(ctx, db.CreateBookingParams{
@@ -198,7 +210,7 @@ func (r *mutationResolver) CreateBookingRequest(ctx context.Context, input model
 			Microseconds: int64(scheduledTime.Hour())*3_600_000_000 + int64(scheduledTime.Minute())*60_000_000,
 			Valid:        true,
 		},
-		EstimatedDurationHours: float64ToNumeric(estimatedHours),
+		EstimatedDurationHours: float64ToNumeric(windowHours),
 		PropertyType:           stringToText(input.PropertyType),
 		NumRooms:               intToInt4Val(input.NumRooms),
 		NumBathrooms:           intToInt4Val(input.NumBathrooms),
@@ -209,6 +221,7 @@ func (r *mutationResolver) CreateBookingRequest(ctx context.Context, input model
 		EstimatedTotal:         float64ToNumeric(estimatedTotal),
 		RecurringGroupID:       pgtype.UUID{},
 		OccurrenceNumber:       pgtype.Int4{},
+		PetsSurcharge:          float64ToNumeric(quote.PetsSurcharge),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create booking: %w", err)
@@ -247,6 +260,45 @@ func (r *mutationResolver) CreateBookingRequest(ctx context.Context, input model
 		}
 	}
 
+	if teamSize > 1 {
+		booking, err = r.Queries.SetBookingTeamSize(ctx, db.SetBookingTeamSizeParams{
+			ID:                     booking.ID,
+			TeamSize:               int32(teamSize),
+			EstimatedDurationHours: float64ToNumeric(windowHours),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to set team size: %w", err)
+		}
+	}
+
+	// If a team was picked from suggestTeams, the first cleaner leads it.
+	if len(input.TeamCleanerIds) > 0 {
+		ids := make([]pgtype.UUID, len(input.TeamCleanerIds))
+		for i, id := range input.TeamCleanerIds {
+			ids[i] = stringToUUID(id)
+		}
+		if err := r.setBookingTeam(ctx, booking.ID, ids); err != nil {
+			return nil, err
+		}
+		lead, err := r.Queries.GetCleanerByID(ctx, ids[0])
+		if err != nil {
+			return nil, fmt.Errorf("cleaner not found: %w", err)
+		}
+		booking, err = r.Queries.SetBookingPreferredCleaner(ctx, db.SetBookingPreferredCleanerParams{
+			ID:        booking.ID,
+			CompanyID: lead.CompanyID,
+			CleanerID: lead.ID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to assign team lead: %w", err)
+		}
+	}
+
+	booking, err = r.applyHolidaySurcharge(ctx, r.Queries, booking)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply holiday surcharge: %w", err)
+	}
+
 	// Handle recurring bookings: generate future occurrences.
 	if input.Recurrence != nil && input.PreferredCleanerID != nil && *input.PreferredCleanerID != "" {
 		cleanerUUID := stringToUUID(*input.PreferredCleanerID)
@@ -257,8 +309,9 @@ func (r *mutationResolver) CreateBookingRequest(ctx context.Context, input model
 				companyID:           cleaner.CompanyID,
 				cleanerID:           cleanerUUID,
 				addressID:           addressID,
-				recurrenceType:      gqlRecurrenceTypeToDb(input.Recurrence.Type),
-				dayOfWeek:           input.Recurrence.DayOfWeek,
+				recurrenceType:      series.recurrenceType,
+				rrule:               series.rrule,
+				dayOfWeek:           series.dayOfWeek,
 				preferredTime:       scheduledTime,
 				serviceType:         dbServiceType,
 				propertyType:        input.PropertyType,
@@ -334,6 +387,7 @@ func (r *mutationResolver) CancelBooking(ctx context.Context, id string, reason
 	if err != nil {
 		return nil, fmt.Errorf("failed to cancel booking: %w", err)
 	}
+	r.releasePaymentHold(ctx, booking.ID)
 
 	return dbBookingToGQL(booking), nil
 }
@@ -359,6 +413,9 @@ func (r *mutationResolver) AssignCleanerToBooking(ctx context.Context, bookingID
 	if err != nil {
 		return nil, fmt.Errorf("cleaner not found: %w", err)
 	}
+	if err := r.checkCompanyOpen(ctx, cleaner.CompanyID, current.ScheduledDate.Time); err != nil {
+		return nil, err
+	}
 
 	booking, err := r.Queries.AssignCleanerToBooking(ctx, db.AssignCleanerToBookingParams{
 		ID:        stringToUUID(bookingID),
@@ -369,6 +426,11 @@ func (r *mutationResolver) AssignCleanerToBooking(ctx context.Context, bookingID
 		return nil, fmt.Errorf("failed to assign cleaner: %w", err)
 	}
 
+	booking, err = r.applyHolidaySurcharge(ctx, r.Queries, booking)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply holiday surcharge: %w", err)
+	}
+
 	return dbBookingToGQL(booking), nil
 }
 
@@ -448,7 +510,7 @@ func (r *mutationResolver) StartJob(ctx context.Context, id string) (*model.Book
 }
 
 // CompleteJob is the resolver for the completeJob field.
-func (r *mutationResolver) CompleteJob(ctx context.Context, id string) (*model.Booking, error) {
+func (r *mutationResolver) CompleteJob(ctx context.Context, id string, actualHours *float64) (*model.Booking, error) {
 	claims := auth.GetUserFromContext(ctx)
 	if claims == nil {
 		return nil, fmt.Errorf("not authenticated")
@@ -468,24 +530,13 @@ func (r *mutationResolver) CompleteJob(ctx context.Context, id string) (*model.B
 	if err := validateStatusTransition(current.Status, db.BookingStatusCompleted); err != nil {
 		return nil, err
 	}
-
-	booking, err := r.Queries.CompleteBooking(ctx, stringToUUID(id))
-	if err != nil {
-		return nil, fmt.Errorf("failed to complete job: %w", err)
+	if actualHours != nil && (*actualHours <= 0 || *actualHours > 24) {
+		return nil, fmt.Errorf("actual hours must be between 0 and 24")
 	}
 
-	// Calculate final total and platform commission.
-	estimatedTotal := numericToFloat(booking.EstimatedTotal)
-	commissionPct := numericToFloat(booking.PlatformCommissionPct)
-	commissionAmount := estimatedTotal * (commissionPct / 100.0)
-
-	booking, err = r.Queries.SetBookingFinalTotal(ctx, db.SetBookingFinalTotalParams{
-		ID:                       booking.ID,
-		FinalTotal:               float64ToNumeric(estimatedTotal),
-		PlatformCommissionAmount: float64ToNumeric(commissionAmount),
-	})
+	booking, err := r.completeBooking(ctx, bookingID, actualHours)
 	if err != nil {
-		return nil, fmt.Errorf("failed to set final total: %w", err)
+		return nil, err
 	}
 
 	return dbBookingToGQL(booking), nil
@@ -531,6 +582,310 @@ func (r *mutationResolver) SelectBookingTimeSlot(ctx context.Context, bookingID
 	return result, nil
 }
 
+// AssignBookingTeam is the resolver for the assignBookingTeam field.
+func (r *mutationResolver) AssignBookingTeam(ctx context.Context, bookingID string, cleanerIds []string) (*model.Booking, error) {
+	claims := auth.GetUserFromContext(ctx)
+	if claims == nil {
+		return nil, fmt.Errorf("not authenticated")
+	}
+	if claims.Role != "company_admin" && claims.Role != "global_admin" {
+		return nil, fmt.Errorf("not authorized")
+	}
+
+	bID := stringToUUID(bookingID)
+	current, err := r.Queries.GetBookingByID(ctx, bID)
+	if err != nil {
+		return nil, fmt.Errorf("booking not found: %w", err)
+	}
+	switch current.Status {
+	case db.BookingStatusPending, db.BookingStatusAssigned, db.BookingStatusConfirmed:
+	default:
+		return nil, fmt.Errorf("cannot change the team of a booking with status %s", current.Status)
+	}
+	if len(cleanerIds) == 0 {
+		return nil, fmt.Errorf("at least one cleaner is required")
+	}
+
+	maxHours, maxTeamSize := loadTeamLimits(ctx, r.Queries)
+	if len(cleanerIds) > maxTeamSize {
+		return nil, fmt.Errorf("a team can have at most %d cleaners", maxTeamSize)
+	}
+
+	ids := make([]pgtype.UUID, len(cleanerIds))
+	for i, id := range cleanerIds {
+		ids[i] = stringToUUID(id)
+	}
+	lead, err := r.Queries.GetCleanerByID(ctx, ids[0])
+	if err != nil {
+		return nil, fmt.Errorf("cleaner not found: %w", err)
+	}
+	if claims.Role == "company_admin" {
+		company, err := r.Queries.GetCompanyByAdminUserID(ctx, stringToUUID(claims.UserID))
+		if err != nil {
+			return nil, fmt.Errorf("company not found: %w", err)
+		}
+		if lead.CompanyID != company.ID {
+			return nil, fmt.Errorf("not authorized")
+		}
+		if current.CompanyID.Valid && current.CompanyID != company.ID {
+			return nil, fmt.Errorf("not authorized")
+		}
+	}
+
+	// The total labour is fixed; the on-site window shrinks as the team grows.
+	laborHours, err := r.bookingLaborHours(ctx, current)
+	if err != nil {
+		return nil, err
+	}
+	teamSize, windowHours := matching.TeamDuration(laborHours, maxHours, len(ids))
+	if teamSize != len(ids) {
+		return nil, fmt.Errorf("this job needs at least %d cleaners", teamSize)
+	}
+	if err := r.checkBookingTeam(ctx, ids); err != nil {
+		return nil, err
+	}
+
+	// The team, its size and its lead change together.
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	qtx := r.Queries.WithTx(tx)
+
+	if err := replaceBookingTeam(ctx, qtx, bID, ids); err != nil {
+		return nil, err
+	}
+	booking, err := qtx.SetBookingTeamSize(ctx, db.SetBookingTeamSizeParams{
+		ID:                     bID,
+		TeamSize:               int32(teamSize),
+		EstimatedDurationHours: float64ToNumeric(windowHours),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update team size: %w", err)
+	}
+
+	// The team lead is the booking's primary cleaner. Confirmed bookings stay confirmed.
+	if booking.Status == db.BookingStatusConfirmed {
+		booking, err = qtx.SetBookingPreferredCleaner(ctx, db.SetBookingPreferredCleanerParams{
+			ID:        bID,
+			CompanyID: lead.CompanyID,
+			CleanerID: lead.ID,
+		})
+	} else {
+		booking, err = qtx.AssignCleanerToBooking(ctx, db.AssignCleanerToBookingParams{
+			ID:        bID,
+			CompanyID: lead.CompanyID,
+			CleanerID: lead.ID,
+		})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to assign team lead: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	result := dbBookingToGQL(booking)
+	r.enrichBooking(ctx, booking, result)
+	return result, nil
+}
+
+// CheckInTeamMember is the resolver for the checkInTeamMember field.
+func (r *mutationResolver) CheckInTeamMember(ctx context.Context, bookingID string) (*model.Booking, error) {
+	claims := auth.GetUserFromContext(ctx)
+	if claims == nil {
+		return nil, fmt.Errorf("not authenticated")
+	}
+	if claims.Role != "cleaner" {
+		return nil, fmt.Errorf("not authorized")
+	}
+
+	cleaner, err := r.Queries.GetCleanerByUserID(ctx, stringToUUID(claims.UserID))
+	if err != nil {
+		return nil, fmt.Errorf("cleaner profile not found: %w", err)
+	}
+	bID := stringToUUID(bookingID)
+	if _, err := r.Queries.GetBookingTeamMember(ctx, db.GetBookingTeamMemberParams{
+		BookingID: bID,
+		CleanerID: cleaner.ID,
+	}); err != nil {
+		return nil, fmt.Errorf("not a member of this booking's team")
+	}
+
+	booking, err := r.Queries.GetBookingByID(ctx, bID)
+	if err != nil {
+		return nil, fmt.Errorf("booking not found: %w", err)
+	}
+	// The first member to check in starts the job.
+	if booking.Status != db.BookingStatusInProgress {
+		if err := validateStatusTransition(booking.Status, db.BookingStatusInProgress); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := r.Queries.CheckInBookingTeamMember(ctx, db.CheckInBookingTeamMemberParams{
+		BookingID: bID,
+		CleanerID: cleaner.ID,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to check in: %w", err)
+	}
+
+	if booking.Status != db.BookingStatusInProgress {
+		booking, err = r.Queries.StartBooking(ctx, bID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to start job: %w", err)
+		}
+	}
+
+	result := dbBookingToGQL(booking)
+	r.enrichBooking(ctx, booking, result)
+	return result, nil
+}
+
+// CompleteTeamMember is the resolver for the completeTeamMember field.
+func (r *mutationResolver) CompleteTeamMember(ctx context.Context, bookingID string) (*model.Booking, error) {
+	claims := auth.GetUserFromContext(ctx)
+	if claims == nil {
+		return nil, fmt.Errorf("not authenticated")
+	}
+	if claims.Role != "cleaner" {
+		return nil, fmt.Errorf("not authorized")
+	}
+
+	cleaner, err := r.Queries.GetCleanerByUserID(ctx, stringToUUID(claims.UserID))
+	if err != nil {
+		return nil, fmt.Errorf("cleaner profile not found: %w", err)
+	}
+	bID := stringToUUID(bookingID)
+	member, err := r.Queries.GetBookingTeamMember(ctx, db.GetBookingTeamMemberParams{
+		BookingID: bID,
+		CleanerID: cleaner.ID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("not a member of this booking's team")
+	}
+	if !member.CheckedInAt.Valid {
+		return nil, fmt.Errorf("you must check in before completing")
+	}
+
+	booking, err := r.Queries.GetBookingByID(ctx, bID)
+	if err != nil {
+		return nil, fmt.Errorf("booking not found: %w", err)
+	}
+	if booking.Status != db.BookingStatusInProgress {
+		return nil, fmt.Errorf("booking is not in progress")
+	}
+
+	if _, err := r.Queries.CompleteBookingTeamMember(ctx, db.CompleteBookingTeamMemberParams{
+		BookingID: bID,
+		CleanerID: cleaner.ID,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to complete: %w", err)
+	}
+
+	// The booking completes when the last member finishes. When the last two
+	// finish together both may see no one pending; only one completes it.
+	pending, err := r.Queries.CountPendingBookingTeamMembers(ctx, bID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count pending team members: %w", err)
+	}
+	if pending == 0 {
+		booking, err = r.completeBooking(ctx, bID, nil)
+		if errors.Is(err, errBookingNotInProgress) {
+			booking, err = r.Queries.GetBookingByID(ctx, bID)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result := dbBookingToGQL(booking)
+	r.enrichBooking(ctx, booking, result)
+	return result, nil
+}
+
+// ApplyDayPlan is the resolver for the applyDayPlan field.
+func (r *mutationResolver) ApplyDayPlan(ctx context.Context, date string, changes []*model.DayPlanChangeInput) ([]*model.Booking, error) {
+	claims := auth.GetUserFromContext(ctx)
+	if claims == nil {
+		return nil, fmt.Errorf("not authenticated")
+	}
+
+	company, err := r.Queries.GetCompanyByAdminUserID(ctx, stringToUUID(claims.UserID))
+	if err != nil {
+		return nil, fmt.Errorf("company not found for user: %w", err)
+	}
+
+	d, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date (use YYYY-MM-DD): %w", err)
+	}
+	day, err := r.loadDispatchDay(ctx, company.ID, d)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate the changes against today's data before writing anything.
+	assign := map[string]string{}
+	for _, j := range day.jobs {
+		assign[j.ID] = j.Current
+	}
+	var touched []string
+	for _, ch := range changes {
+		if _, ok := day.bookings[ch.BookingID]; !ok {
+			return nil, fmt.Errorf("booking %s cannot be moved on %s", ch.BookingID, date)
+		}
+		if _, ok := day.active[ch.ToCleanerID]; !ok {
+			return nil, fmt.Errorf("cleaner %s is not an active cleaner of your company", ch.ToCleanerID)
+		}
+		assign[ch.BookingID] = ch.ToCleanerID
+		touched = append(touched, ch.ToCleanerID)
+	}
+	if bad := matching.CheckDispatch(day.jobs, day.cleaners, assign, day.config, touched); len(bad) > 0 {
+		return nil, fmt.Errorf("cleaner %s cannot take the planned jobs", bad[0])
+	}
+
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	qtx := r.Queries.WithTx(tx)
+
+	updated := make([]db.Booking, 0, len(changes))
+	for _, ch := range changes {
+		var expected pgtype.UUID
+		if ch.FromCleanerID != nil {
+			expected = stringToUUID(*ch.FromCleanerID)
+		}
+		b, err := qtx.ReassignBookingCleaner(ctx, db.ReassignBookingCleanerParams{
+			CleanerID:         stringToUUID(ch.ToCleanerID),
+			ID:                stringToUUID(ch.BookingID),
+			CompanyID:         company.ID,
+			ExpectedCleanerID: expected,
+		})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil, fmt.Errorf("booking %s changed since the plan was made", ch.BookingID)
+			}
+			return nil, fmt.Errorf("failed to reassign booking: %w", err)
+		}
+		updated = append(updated, b)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	result := make([]*model.Booking, len(updated))
+	for i, b := range updated {
+		gqlB := dbBookingToGQL(b)
+		r.enrichBooking(ctx, b, gqlB)
+		result[i] = gqlB
+	}
+	return result, nil
+}
+
 // MyBookings is the resolver for the myBookings field.
 func (r *queryResolver) MyBookings(ctx context.Context, status *model.BookingStatus, first *int, after *string) (*model.BookingConnection, error) {
 	claims := auth.GetUserFromContext(ctx)
@@ -851,6 +1206,35 @@ func (r *queryResolver) CompanyBookingsByDateRange(ctx context.Context, from str
 	return result, nil
 }
 
+// PlanDay is the resolver for the planDay field.
+func (r *queryResolver) PlanDay(ctx context.Context, date string) (*model.DayPlan, error) {
+	claims := auth.GetUserFromContext(ctx)
+	if claims == nil {
+		return nil, fmt.Errorf("not authenticated")
+	}
+
+	company, err := r.Queries.GetCompanyByAdminUserID(ctx, stringToUUID(claims.UserID))
+	if err != nil {
+		return nil, fmt.Errorf("company not found for user: %w", err)
+	}
+
+	d, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date (use YYYY-MM-DD): %w", err)
+	}
+	day, err := r.loadDispatchDay(ctx, company.ID, d)
+	if err != nil {
+		return nil, err
+	}
+
+	current, proposed := matching.PlanDispatch(day.jobs, day.cleaners, day.config)
+	log.Printf("[DISPATCH] Company %s on %s: travel %.0f -> %.0f min, overtime %.0f -> %.0f min, %d moves",
+		uuidToString(company.ID), date, current.TravelMinutes, proposed.TravelMinutes,
+		current.OvertimeMinutes, proposed.OvertimeMinutes, proposed.Moves)
+
+	return r.dayPlanToGQL(ctx, date, day, current, proposed), nil
+}
+
 // SearchCompanyBookings is the resolver for the searchCompanyBookings field.
 func (r *queryResolver) SearchCompanyBookings(ctx context.Context, query *string, status *string, dateFrom *string, dateTo *string, limit *int, offset *int) (*model.BookingConnection, error) {
 	claims := auth.GetUserFromContext(ctx)
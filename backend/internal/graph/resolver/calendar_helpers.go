@@ -0,0 +1,125 @@
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	db "helpmeclean-backend/internal/db/generated"
+	"helpmeclean-backend/internal/service/holidays"
+)
+
+// companyCalendar tells which days a company takes bookings on and what
+// working on a public holiday costs.
+type companyCalendar struct {
+	worksOnHolidays bool
+	surchargePct    float64
+	closures        map[string]string // closure date (YYYY-MM-DD) -> reason
+}
+
+// loadCompanyCalendar loads a company's holiday policy and its closure days
+// within [from, to].
+func (r *Resolver) loadCompanyCalendar(ctx context.Context, companyID pgtype.UUID, from, to time.Time) (*companyCalendar, error) {
+	company, err := r.Queries.GetCompanyByID(ctx, companyID)
+	if err != nil {
+		return nil, fmt.Errorf("company not found: %w", err)
+	}
+	closures, err := r.Queries.ListCompanyClosures(ctx, db.ListCompanyClosuresParams{
+		CompanyID: companyID,
+		DateFrom:  pgtype.Date{Time: from, Valid: true},
+		DateTo:    pgtype.Date{Time: to, Valid: true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load closures: %w", err)
+	}
+
+	cal := &companyCalendar{
+		worksOnHolidays: company.WorksOnHolidays,
+		surchargePct:    holidaySurchargePct(ctx, r.Queries, company),
+		closures:        map[string]string{},
+	}
+	for _, c := range closures {
+		cal.closures[c.ClosureDate.Time.Format("2006-01-02")] = textVal(c.Reason)
+	}
+	return cal, nil
+}
+
+// holidaySurchargePct returns the company's holiday surcharge, falling back
+// to the holiday_surcharge_pct platform setting (default 25%).
+func holidaySurchargePct(ctx context.Context, queries *db.Queries, company db.Company) float64 {
+	if company.HolidaySurchargePct.Valid {
+		return numericToFloat(company.HolidaySurchargePct)
+	}
+	pct := 25.0
+	if v, err := queries.GetPlatformSetting(ctx, "holiday_surcharge_pct"); err == nil {
+		if f, err := strconv.ParseFloat(v.Value, 64); err == nil && f >= 0 {
+			pct = f
+		}
+	}
+	return pct
+}
+
+// closedOn reports whether the company takes no bookings on date and why:
+// one of its closure days, or a public holiday it has not opted in to work.
+func (c *companyCalendar) closedOn(date time.Time) (string, bool) {
+	if reason, ok := c.closures[date.Format("2006-01-02")]; ok {
+		if reason == "" {
+			reason = "inchis"
+		}
+		return reason, true
+	}
+	if h, ok := holidays.Lookup(date); ok && !c.worksOnHolidays {
+		return h.Name, true
+	}
+	return "", false
+}
+
+// surcharge returns the holiday surcharge for a job on date with the given
+// labour cost, or 0 on a regular day.
+func (c *companyCalendar) surcharge(date time.Time, labour float64) float64 {
+	if _, ok := holidays.Lookup(date); !ok || !c.worksOnHolidays {
+		return 0
+	}
+	return math.Round(labour*c.surchargePct) / 100
+}
+
+// checkCompanyOpen returns an error if the company takes no bookings on date.
+func (r *Resolver) checkCompanyOpen(ctx context.Context, companyID pgtype.UUID, date time.Time) error {
+	cal, err := r.loadCompanyCalendar(ctx, companyID, date, date)
+	if err != nil {
+		return err
+	}
+	if reason, closed := cal.closedOn(date); closed {
+		return fmt.Errorf("the company does not work on %s (%s)", date.Format("2006-01-02"), reason)
+	}
+	return nil
+}
+
+// applyHolidaySurcharge sets the booking's holiday surcharge from its
+// company's calendar: a percentage of the labour (hourly rate times the
+// hours of every team member) when the date is a public holiday.
+// Bookings without a company carry none until one is assigned.
+func (r *Resolver) applyHolidaySurcharge(ctx context.Context, queries *db.Queries, b db.Booking) (db.Booking, error) {
+	if !b.CompanyID.Valid {
+		return b, nil
+	}
+	date := b.ScheduledDate.Time
+	cal, err := r.loadCompanyCalendar(ctx, b.CompanyID, date, date)
+	if err != nil {
+		return b, err
+	}
+	teamSize := float64(max(b.TeamSize, 1))
+	labour := numericToFloat(b.HourlyRate) * numericToFloat(b.EstimatedDurationHours) * teamSize
+	surcharge := cal.surcharge(date, labour)
+	if surcharge == numericToFloat(b.HolidaySurcharge) {
+		return b, nil
+	}
+	return queries.SetBookingHolidaySurcharge(ctx, db.SetBookingHolidaySurchargeParams{
+		HolidaySurcharge: float64ToNumeric(surcharge),
+		ID:               b.ID,
+	})
+}
@@ -0,0 +1,96 @@
+package resolver
+
+import (
+	"context"
+	"log"
+	"math"
+	"strconv"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	db "helpmeclean-backend/internal/db/generated"
+	"helpmeclean-backend/internal/service/payment"
+)
+
+// loadCaptureOptions reads how booking payments are collected from
+// platform_settings: payment_capture_mode "manual" authorizes at
+// confirmation and captures at completion, anything else charges upfront.
+// The overage (default 15%) only applies when the client consented to it.
+func loadCaptureOptions(ctx context.Context, queries *db.Queries, allowOverage bool) payment.CaptureOptions {
+	var opts payment.CaptureOptions
+	if v, err := queries.GetPlatformSetting(ctx, "payment_capture_mode"); err == nil {
+		opts.Manual = v.Value == "manual"
+	}
+	if !opts.Manual || !allowOverage {
+		return opts
+	}
+	opts.OveragePct = 15
+	if v, err := queries.GetPlatformSetting(ctx, "payment_capture_overage_pct"); err == nil {
+		if f, err := strconv.ParseFloat(v.Value, 64); err == nil && f >= 0 {
+			opts.OveragePct = f
+		}
+	}
+	return opts
+}
+
+// adjustedFinalTotal returns the booking's total for the hours actually
+// worked: the estimate plus or minus the labour (hourly rate times team size)
+// of the difference, with the holiday surcharge applied to it at the same
+// rate as to the estimated labour.
+func adjustedFinalTotal(b db.Booking, actualHours float64) float64 {
+	rate := numericToFloat(b.HourlyRate)
+	estimatedHours := numericToFloat(b.EstimatedDurationHours)
+	teamSize := float64(max(b.TeamSize, 1))
+
+	extra := rate * teamSize * (actualHours - estimatedHours)
+	if labour := rate * estimatedHours * teamSize; labour > 0 {
+		extra *= 1 + numericToFloat(b.HolidaySurcharge)/labour
+	}
+	total := numericToFloat(b.EstimatedTotal) + extra
+	return math.Max(0, math.Round(total*100)/100)
+}
+
+// capturePayment captures the authorization hold of a completed booking. A
+// failure is only logged: the booking keeps payment status authorized and
+// the payment-holds job retries the capture.
+func (r *Resolver) capturePayment(ctx context.Context, booking db.Booking) {
+	if booking.PaymentStatus.String != "authorized" {
+		return
+	}
+	if err := r.PaymentService.CapturePayment(ctx, booking); err != nil {
+		log.Printf("[PAYMENTS] Capture for booking %s failed, will be retried: %v", booking.ReferenceCode, err)
+	}
+}
+
+// releasePaymentHold releases the authorization hold of a cancelled booking.
+// A failure is only logged; an unreleased hold expires on its own.
+func (r *Resolver) releasePaymentHold(ctx context.Context, bookingID pgtype.UUID) {
+	if err := r.PaymentService.ReleasePaymentHold(ctx, bookingID); err != nil {
+		log.Printf("[PAYMENTS] Failed to release hold for booking %s: %v", uuidToString(bookingID), err)
+	}
+}
+
+// releaseRecurringHolds releases the holds of a series' occurrences that
+// were cancelled with their payment still authorized.
+func (r *Resolver) releaseRecurringHolds(ctx context.Context, groupID pgtype.UUID) {
+	bookings, err := r.Queries.GetBookingsByRecurringGroup(ctx, groupID)
+	if err != nil {
+		log.Printf("[PAYMENTS] Failed to list occurrences of series %s: %v", uuidToString(groupID), err)
+		return
+	}
+	for _, id := range cancelledHolds(bookings) {
+		r.releasePaymentHold(ctx, id)
+	}
+}
+
+// cancelledHolds returns the occurrences cancelled by the client, or for
+// them by a change to the series, whose payment is still authorized.
+func cancelledHolds(bookings []db.Booking) []pgtype.UUID {
+	var ids []pgtype.UUID
+	for _, b := range bookings {
+		if b.Status == db.BookingStatusCancelledByClient && b.PaymentStatus.String == "authorized" {
+			ids = append(ids, b.ID)
+		}
+	}
+	return ids
+}
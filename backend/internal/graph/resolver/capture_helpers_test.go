@@ -0,0 +1,36 @@
+package resolver
+
+import (
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	db "helpmeclean-backend/internal/db/generated"
+)
+
+func TestCancelledHoldsAfterSeriesEdit(t *testing.T) {
+	occurrence := func(n byte, status db.BookingStatus, payment string) db.Booking {
+		return db.Booking{
+			ID:            pgtype.UUID{Bytes: [16]byte{n}, Valid: true},
+			Status:        status,
+			PaymentStatus: pgtype.Text{String: payment, Valid: true},
+		}
+	}
+	// A series edited from its third occurrence: UpdateRecurringGroup cancels
+	// the generated ones from there on, keeps the rescheduled one and
+	// regenerates the rest without a hold.
+	bookings := []db.Booking{
+		occurrence(1, db.BookingStatusCompleted, "paid"),
+		occurrence(2, db.BookingStatusConfirmed, "authorized"),
+		occurrence(3, db.BookingStatusCancelledByClient, "authorized"),
+		occurrence(4, db.BookingStatusConfirmed, "authorized"),
+		occurrence(5, db.BookingStatusCancelledByClient, "authorized"),
+		occurrence(6, db.BookingStatusCancelledByClient, "released"),
+		occurrence(7, db.BookingStatusPending, "pending"),
+	}
+
+	got := cancelledHolds(bookings)
+	if len(got) != 2 || got[0].Bytes[0] != 3 || got[1].Bytes[0] != 5 {
+		t.Errorf("cancelledHolds() = %v, want occurrences 3 and 5", got)
+	}
+}
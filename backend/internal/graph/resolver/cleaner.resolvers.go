@@ -612,6 +612,52 @@ func (r *mutationResolver) ActivateCleaner(ctx context.Context, id string) (*mod
 	return r.cleanerWithCompany(ctx, cleaner)
 }
 
+// SetCleanerSkills is the resolver for the setCleanerSkills field.
+func (r *mutationResolver) SetCleanerSkills(ctx context.Context, cleanerID string, serviceTypes []model.ServiceType) (*model.CleanerProfile, error) {
+	claims := auth.GetUserFromContext(ctx)
+	if claims == nil {
+		return nil, fmt.Errorf("not authenticated")
+	}
+
+	company, err := r.Queries.GetCompanyByAdminUserID(ctx, stringToUUID(claims.UserID))
+	if err != nil {
+		return nil, fmt.Errorf("company not found for user: %w", err)
+	}
+
+	cleaner, err := r.Queries.GetCleanerByID(ctx, stringToUUID(cleanerID))
+	if err != nil {
+		return nil, fmt.Errorf("cleaner not found: %w", err)
+	}
+	if cleaner.CompanyID != company.ID {
+		return nil, fmt.Errorf("cleaner does not belong to your company")
+	}
+
+	// Replace the skill set; an empty list means the cleaner does every service.
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	qtx := r.Queries.WithTx(tx)
+
+	if err := qtx.DeleteCleanerSkills(ctx, cleaner.ID); err != nil {
+		return nil, fmt.Errorf("failed to clear skills: %w", err)
+	}
+	for _, st := range serviceTypes {
+		if err := qtx.AddCleanerSkill(ctx, db.AddCleanerSkillParams{
+			CleanerID:   cleaner.ID,
+			ServiceType: gqlServiceTypeToDb(st),
+		}); err != nil {
+			return nil, fmt.Errorf("failed to add skill: %w", err)
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return r.cleanerWithCompany(ctx, cleaner)
+}
+
 // MyCleaners is the resolver for the myCleaners field.
 func (r *queryResolver) MyCleaners(ctx context.Context) ([]*model.CleanerProfile, error) {
 	claims := auth.GetUserFromContext(ctx)
@@ -0,0 +1,74 @@
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	db "helpmeclean-backend/internal/db/generated"
+	"helpmeclean-backend/internal/graph/model"
+	"helpmeclean-backend/internal/service/invoice"
+	"helpmeclean-backend/internal/service/recurrence"
+)
+
+// RunCommissionMonth closes the previous month's commission: it issues the
+// monthly commission invoice of every company not invoiced for it yet and
+// posts them to the ledger. It is run daily by the job scheduler, so a
+// company that failed is invoiced on a later run.
+func (r *Resolver) RunCommissionMonth(ctx context.Context) error {
+	today := recurrence.Today()
+	month := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0)
+
+	issued, err := r.closeCommissionMonth(ctx, month)
+	if len(issued) > 0 {
+		log.Printf("[COMMISSION] %s: %d commission invoices issued", month.Format("2006-01"), len(issued))
+	}
+	return err
+}
+
+// closeCommissionMonth issues the commission invoices of a month and posts
+// the ones it issued to the ledger.
+func (r *Resolver) closeCommissionMonth(ctx context.Context, month time.Time) ([]db.Invoice, error) {
+	issued, err := r.InvoiceService.CloseCommissionMonth(ctx, month)
+	for _, inv := range issued {
+		r.postInvoiceToLedger(ctx, inv)
+	}
+	if err != nil {
+		return issued, fmt.Errorf("failed to close commission month %s: %w", month.Format("2006-01"), err)
+	}
+	return issued, nil
+}
+
+// commissionMonthToGQL converts the commission of a month, loading the
+// companies and the invoices issued for it.
+func (r *Resolver) commissionMonthToGQL(ctx context.Context, m invoice.CommissionMonth) *model.CommissionMonth {
+	result := &model.CommissionMonth{
+		Month:      m.From.Format("2006-01"),
+		PeriodFrom: m.From.Format("2006-01-02"),
+		PeriodTo:   m.To.Format("2006-01-02"),
+		Lines:      make([]*model.CommissionMonthLine, 0, len(m.Lines)),
+	}
+	for _, l := range m.Lines {
+		company, err := r.Queries.GetCompanyByID(ctx, l.CompanyID)
+		if err != nil {
+			log.Printf("[COMMISSION] Company %s: %v", uuidToString(l.CompanyID), err)
+			continue
+		}
+		line := &model.CommissionMonthLine{
+			Company:      dbCompanyToGQL(company),
+			BookingCount: l.BookingCount,
+			Commission:   int(l.Commission),
+			Refunded:     int(l.Refunded),
+			NetAmount:    int(l.Net),
+			VatAmount:    int(l.VAT),
+			TotalAmount:  int(l.Net + l.VAT),
+		}
+		if l.Invoice != nil {
+			line.Invoice = dbInvoiceToGQL(*l.Invoice)
+			r.enrichInvoice(ctx, *l.Invoice, line.Invoice)
+		}
+		result.Lines = append(result.Lines, line)
+	}
+	return result
+}
@@ -13,8 +13,10 @@ import (
 	"helpmeclean-backend/internal/auth"
 	db "helpmeclean-backend/internal/db/generated"
 	"helpmeclean-backend/internal/graph/model"
+	"helpmeclean-backend/internal/service/holidays"
 	"helpmeclean-backend/internal/storage"
 	"log"
+	"time"
 
 	"github.com/99designs/gqlgen/graphql"
 	"github.com/jackc/pgx/v5/pgtype"
@@ -362,6 +364,91 @@ func (r *mutationResolver) ReviewCompanyDocument(ctx context.Context, id string,
 	return dbCompanyDocToGQL(doc), nil
 }
 
+// AddCompanyClosure is the resolver for the addCompanyClosure field.
+func (r *mutationResolver) AddCompanyClosure(ctx context.Context, date string, reason *string) (*model.CompanyClosure, error) {
+	claims := auth.GetUserFromContext(ctx)
+	if claims == nil {
+		return nil, fmt.Errorf("not authenticated")
+	}
+
+	company, err := r.Queries.GetCompanyByAdminUserID(ctx, stringToUUID(claims.UserID))
+	if err != nil {
+		return nil, fmt.Errorf("company not found for user: %w", err)
+	}
+
+	closureDate, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date format: %w", err)
+	}
+
+	// Existing bookings on the date are kept; the closure only stops new
+	// matches and recurring occurrences.
+	closure, err := r.Queries.CreateCompanyClosure(ctx, db.CreateCompanyClosureParams{
+		CompanyID:   company.ID,
+		ClosureDate: pgtype.Date{Time: closureDate, Valid: true},
+		Reason:      stringToText(reason),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to add closure: %w", err)
+	}
+
+	return dbCompanyClosureToGQL(closure), nil
+}
+
+// RemoveCompanyClosure is the resolver for the removeCompanyClosure field.
+func (r *mutationResolver) RemoveCompanyClosure(ctx context.Context, id string) (bool, error) {
+	claims := auth.GetUserFromContext(ctx)
+	if claims == nil {
+		return false, fmt.Errorf("not authenticated")
+	}
+
+	company, err := r.Queries.GetCompanyByAdminUserID(ctx, stringToUUID(claims.UserID))
+	if err != nil {
+		return false, fmt.Errorf("company not found for user: %w", err)
+	}
+
+	if err := r.Queries.DeleteCompanyClosure(ctx, db.DeleteCompanyClosureParams{
+		ID:        stringToUUID(id),
+		CompanyID: company.ID,
+	}); err != nil {
+		return false, fmt.Errorf("failed to remove closure: %w", err)
+	}
+
+	return true, nil
+}
+
+// UpdateHolidayPolicy is the resolver for the updateHolidayPolicy field.
+func (r *mutationResolver) UpdateHolidayPolicy(ctx context.Context, worksOnHolidays bool, surchargePct *float64) (*model.Company, error) {
+	claims := auth.GetUserFromContext(ctx)
+	if claims == nil {
+		return nil, fmt.Errorf("not authenticated")
+	}
+
+	company, err := r.Queries.GetCompanyByAdminUserID(ctx, stringToUUID(claims.UserID))
+	if err != nil {
+		return nil, fmt.Errorf("company not found for user: %w", err)
+	}
+
+	var pct pgtype.Numeric
+	if surchargePct != nil {
+		if *surchargePct < 0 || *surchargePct > 200 {
+			return nil, fmt.Errorf("surchargePct must be between 0 and 200")
+		}
+		pct = float64ToNumeric(*surchargePct)
+	}
+
+	company, err = r.Queries.UpdateCompanyHolidayPolicy(ctx, db.UpdateCompanyHolidayPolicyParams{
+		ID:                  company.ID,
+		WorksOnHolidays:     worksOnHolidays,
+		HolidaySurchargePct: pct,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update holiday policy: %w", err)
+	}
+
+	return dbCompanyToGQL(company), nil
+}
+
 // MyCompany is the resolver for the myCompany field.
 func (r *queryResolver) MyCompany(ctx context.Context) (*model.Company, error) {
 	claims := auth.GetUserFromContext(ctx)
@@ -461,6 +548,65 @@ func (r *queryResolver) MyCompanyWorkSchedule(ctx context.Context) ([]*model.Com
 	return result, nil
 }
 
+// MyCompanyClosures is the resolver for the myCompanyClosures field.
+func (r *queryResolver) MyCompanyClosures(ctx context.Context, from string, to string) ([]*model.CompanyClosure, error) {
+	claims := auth.GetUserFromContext(ctx)
+	if claims == nil {
+		return nil, fmt.Errorf("not authenticated")
+	}
+
+	company, err := r.Queries.GetCompanyByAdminUserID(ctx, stringToUUID(claims.UserID))
+	if err != nil {
+		return nil, fmt.Errorf("company not found for user: %w", err)
+	}
+
+	fromDate, err := time.Parse("2006-01-02", from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from date: %w", err)
+	}
+	toDate, err := time.Parse("2006-01-02", to)
+	if err != nil {
+		return nil, fmt.Errorf("invalid to date: %w", err)
+	}
+
+	closures, err := r.Queries.ListCompanyClosures(ctx, db.ListCompanyClosuresParams{
+		CompanyID: company.ID,
+		DateFrom:  pgtype.Date{Time: fromDate, Valid: true},
+		DateTo:    pgtype.Date{Time: toDate, Valid: true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list closures: %w", err)
+	}
+
+	result := make([]*model.CompanyClosure, len(closures))
+	for i, c := range closures {
+		result[i] = dbCompanyClosureToGQL(c)
+	}
+	return result, nil
+}
+
+// PublicHolidays is the resolver for the publicHolidays field.
+func (r *queryResolver) PublicHolidays(ctx context.Context, from string, to string) ([]*model.PublicHoliday, error) {
+	fromDate, err := time.Parse("2006-01-02", from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from date: %w", err)
+	}
+	toDate, err := time.Parse("2006-01-02", to)
+	if err != nil {
+		return nil, fmt.Errorf("invalid to date: %w", err)
+	}
+	if toDate.Sub(fromDate) > 5*366*24*time.Hour {
+		return nil, fmt.Errorf("date range too large")
+	}
+
+	list := holidays.Between(fromDate, toDate)
+	result := make([]*model.PublicHoliday, len(list))
+	for i, h := range list {
+		result[i] = &model.PublicHoliday{Date: h.Date.Format("2006-01-02"), Name: h.Name}
+	}
+	return result, nil
+}
+
 // Companies is the resolver for the companies field.
 func (r *queryResolver) Companies(ctx context.Context, status *model.CompanyStatus, first *int, after *string) (*model.CompanyConnection, error) {
 	claims := auth.GetUserFromContext(ctx)
@@ -1,6 +1,7 @@
 package resolver
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
@@ -9,6 +10,8 @@ import (
 
 	db "helpmeclean-backend/internal/db/generated"
 	"helpmeclean-backend/internal/graph/model"
+	"helpmeclean-backend/internal/service/accounting"
+	"helpmeclean-backend/internal/service/invoice"
 )
 
 // UUID helpers
@@ -141,6 +144,15 @@ func dateToString(d pgtype.Date) string {
 	return d.Time.Format("2006-01-02")
 }
 
+// datePtr formats a nullable date, nil when it is NULL.
+func datePtr(d pgtype.Date) *string {
+	if !d.Valid {
+		return nil
+	}
+	s := dateToString(d)
+	return &s
+}
+
 func timeToString(t pgtype.Time) string {
 	if !t.Valid {
 		return ""
@@ -336,6 +348,7 @@ func dbBookingToGQL(b db.Booking) *model.Booking {
 		SpecialInstructions:    textPtr(b.SpecialInstructions),
 		HourlyRate:             numericToFloat(b.HourlyRate),
 		EstimatedTotal:         numericToFloat(b.EstimatedTotal),
+		HolidaySurcharge:       numericToFloat(b.HolidaySurcharge),
 		FinalTotal: func() *float64 {
 			if !b.FinalTotal.Valid {
 				return nil
@@ -347,6 +360,9 @@ func dbBookingToGQL(b db.Booking) *model.Booking {
 		Status:                dbBookingStatusToGQL(b.Status),
 		RecurringGroupID:      recurringGroupID,
 		OccurrenceNumber:      int4Ptr(b.OccurrenceNumber),
+		NeedsStaffing:         b.RecurringGroupID.Valid && !b.CleanerID.Valid && b.Status == db.BookingStatusPending,
+		TeamSize:              int(b.TeamSize),
+		TeamMembers:           []*model.BookingTeamMember{},
 		StartedAt:             timestamptzToTimePtr(b.StartedAt),
 		CompletedAt:           timestamptzToTimePtr(b.CompletedAt),
 		CancelledAt:           timestamptzToTimePtr(b.CancelledAt),
@@ -360,6 +376,14 @@ func dbBookingToGQL(b db.Booking) *model.Booking {
 	}
 }
 
+func dbCompanyClosureToGQL(c db.CompanyClosure) *model.CompanyClosure {
+	return &model.CompanyClosure{
+		ID:     uuidToString(c.ID),
+		Date:   dateToString(c.ClosureDate),
+		Reason: textPtr(c.Reason),
+	}
+}
+
 func dbCompanyToGQL(c db.Company) *model.Company {
 	return &model.Company{
 		ID:                  uuidToString(c.ID),
@@ -379,7 +403,15 @@ func dbCompanyToGQL(c db.Company) *model.Company {
 		MaxServiceRadiusKm:  int4Val(c.MaxServiceRadiusKm),
 		RatingAvg:           numericToFloat(c.RatingAvg),
 		TotalJobsCompleted:  int4Val(c.TotalJobsCompleted),
-		CreatedAt:           timestamptzToTime(c.CreatedAt),
+		WorksOnHolidays:     c.WorksOnHolidays,
+		HolidaySurchargePct: func() *float64 {
+			if !c.HolidaySurchargePct.Valid {
+				return nil
+			}
+			v := numericToFloat(c.HolidaySurchargePct)
+			return &v
+		}(),
+		CreatedAt: timestamptzToTime(c.CreatedAt),
 	}
 }
 
@@ -399,6 +431,7 @@ func dbCleanerToGQL(c db.Cleaner, u *db.User) *model.CleanerProfile {
 		InviteToken:        textPtr(c.InviteToken),
 		RatingAvg:          numericToFloat(c.RatingAvg),
 		TotalJobsCompleted: int4Val(c.TotalJobsCompleted),
+		Skills:             []model.ServiceType{},
 		CreatedAt:          timestamptzToTime(c.CreatedAt),
 	}
 
@@ -459,22 +492,83 @@ func dbPaymentTransactionToGQL(t db.PaymentTransaction) *model.PaymentTransactio
 		Status:                model.PaymentTransactionStatus(strings.ToUpper(string(t.Status))),
 		FailureReason:         textPtr(t.FailureReason),
 		RefundAmount:          int4Ptr(t.RefundAmount),
+		CaptureMethod:         t.CaptureMethod,
+		AmountAuthorized:      int4Ptr(t.AmountAuthorized),
+		AuthorizedAt:          timestamptzToTimePtr(t.AuthorizedAt),
 		CreatedAt:             timestamptzToTime(t.CreatedAt),
 	}
 }
 
+func dbStripeEventToGQL(e db.StripeEvent) *model.WebhookEvent {
+	return &model.WebhookEvent{
+		ID:            e.ID,
+		Type:          e.EventType,
+		Status:        model.WebhookEventStatus(strings.ToUpper(string(e.Status))),
+		Attempts:      int(e.Attempts),
+		LastError:     textPtr(e.LastError),
+		NextAttemptAt: timestamptzToTimePtr(e.NextAttemptAt),
+		ProcessedAt:   timestamptzToTimePtr(e.ProcessedAt),
+		Payload:       string(e.Payload),
+		CreatedAt:     timestamptzToTime(e.CreatedAt),
+	}
+}
+
+func dbLedgerReconciliationToGQL(r db.LedgerReconciliation) *model.LedgerReconciliation {
+	discrepancies := []*model.LedgerDiscrepancy{}
+	_ = json.Unmarshal(r.Discrepancies, &discrepancies)
+	return &model.LedgerReconciliation{
+		ID:            uuidToString(r.ID),
+		PeriodFrom:    timestamptzToTime(r.PeriodFrom),
+		PeriodTo:      timestamptzToTime(r.PeriodTo),
+		CheckedCount:  int(r.CheckedCount),
+		FeesPosted:    int(r.FeesPosted),
+		Discrepancies: discrepancies,
+		CreatedAt:     timestamptzToTime(r.CreatedAt),
+	}
+}
+
+func dbPaymentDisputeToGQL(d db.PaymentDispute) *model.PaymentDispute {
+	var evidence *model.DisputeEvidence
+	if len(d.Evidence) > 0 {
+		evidence = &model.DisputeEvidence{}
+		if err := json.Unmarshal(d.Evidence, evidence); err != nil {
+			evidence = nil
+		} else if evidence.PhotoUrls == nil {
+			evidence.PhotoUrls = []string{}
+		}
+	}
+	return &model.PaymentDispute{
+		ID:                  uuidToString(d.ID),
+		StripeDisputeID:     d.StripeDisputeID,
+		StripeChargeID:      d.StripeChargeID,
+		Amount:              int(d.Amount),
+		Currency:            d.Currency,
+		Reason:              d.Reason,
+		Status:              model.DisputeStatus(strings.ToUpper(string(d.Status))),
+		EvidenceDueBy:       timestamptzToTimePtr(d.EvidenceDueBy),
+		Evidence:            evidence,
+		EvidenceSubmittedAt: timestamptzToTimePtr(d.EvidenceSubmittedAt),
+		ClosedAt:            timestamptzToTimePtr(d.ClosedAt),
+		CreatedAt:           timestamptzToTime(d.CreatedAt),
+	}
+}
+
 func dbCompanyPayoutToGQL(p db.CompanyPayout) *model.CompanyPayout {
 	return &model.CompanyPayout{
-		ID:           uuidToString(p.ID),
-		Amount:       int(p.Amount),
-		Currency:     p.Currency,
-		PeriodFrom:   dateToString(p.PeriodFrom),
-		PeriodTo:     dateToString(p.PeriodTo),
-		BookingCount: int(p.BookingCount),
-		Status:       model.PayoutStatus(strings.ToUpper(string(p.Status))),
-		PaidAt:       timestamptzToTimePtr(p.PaidAt),
-		LineItems:    []*model.PayoutLineItem{},
-		CreatedAt:    timestamptzToTime(p.CreatedAt),
+		ID:             uuidToString(p.ID),
+		Amount:         int(p.Amount),
+		Currency:       p.Currency,
+		PeriodFrom:     dateToString(p.PeriodFrom),
+		PeriodTo:       dateToString(p.PeriodTo),
+		BookingCount:   int(p.BookingCount),
+		Status:         model.PayoutStatus(strings.ToUpper(string(p.Status))),
+		PaidAt:         timestamptzToTimePtr(p.PaidAt),
+		StripePayoutID: textPtr(p.StripePayoutID),
+		FailureReason:  textPtr(p.FailureReason),
+		Attempts:       int(p.Attempts),
+		NextAttemptAt:  timestamptzToTimePtr(p.NextAttemptAt),
+		LineItems:      []*model.PayoutLineItem{},
+		CreatedAt:      timestamptzToTime(p.CreatedAt),
 	}
 }
 
@@ -500,32 +594,116 @@ func dbRefundRequestToGQL(r db.RefundRequest) *model.RefundRequest {
 
 func dbInvoiceToGQL(inv db.Invoice) *model.Invoice {
 	return &model.Invoice{
-		ID:                uuidToString(inv.ID),
-		InvoiceType:       model.InvoiceType(strings.ToUpper(string(inv.InvoiceType))),
-		InvoiceNumber:     textPtr(inv.InvoiceNumber),
-		Status:            model.InvoiceStatus(strings.ToUpper(string(inv.Status))),
-		SellerCompanyName: inv.SellerCompanyName,
-		SellerCui:         inv.SellerCui,
-		BuyerName:         inv.BuyerName,
-		BuyerCui:          textPtr(inv.BuyerCui),
-		SubtotalAmount:    int(inv.SubtotalAmount),
-		VatRate:           numericToFloat(inv.VatRate),
-		VatAmount:         int(inv.VatAmount),
-		TotalAmount:       int(inv.TotalAmount),
-		Currency:          inv.Currency,
-		EfacturaStatus:    textPtr(inv.EfacturaStatus),
-		DownloadURL:       textPtr(inv.FactureazaDownloadUrl),
-		IssuedAt:          timestamptzToTimePtr(inv.IssuedAt),
-		DueDate: func() *string {
-			s := dateToString(inv.DueDate)
-			if s == "" {
-				return nil
-			}
-			return &s
-		}(),
-		Notes:     textPtr(inv.Notes),
-		LineItems: []*model.InvoiceLineItem{},
-		CreatedAt: timestamptzToTime(inv.CreatedAt),
+		ID:                    uuidToString(inv.ID),
+		InvoiceType:           model.InvoiceType(strings.ToUpper(string(inv.InvoiceType))),
+		InvoiceNumber:         textPtr(inv.InvoiceNumber),
+		Status:                model.InvoiceStatus(strings.ToUpper(string(inv.Status))),
+		SellerCompanyName:     inv.SellerCompanyName,
+		SellerCui:             inv.SellerCui,
+		BuyerName:             inv.BuyerName,
+		BuyerCui:              textPtr(inv.BuyerCui),
+		SubtotalAmount:        int(inv.SubtotalAmount),
+		VatRate:               numericToFloat(inv.VatRate),
+		VatAmount:             int(inv.VatAmount),
+		TotalAmount:           int(inv.TotalAmount),
+		Currency:              inv.Currency,
+		EfacturaStatus:        textPtr(inv.EfacturaStatus),
+		EfacturaMessage:       textPtr(inv.EfacturaMessage),
+		DownloadURL:           textPtr(inv.FactureazaDownloadUrl),
+		PDFURL:                "/api/invoices/" + uuidToString(inv.ID) + "/pdf",
+		IssuedAt:              timestamptzToTimePtr(inv.IssuedAt),
+		DueDate:               datePtr(inv.DueDate),
+		Notes:                 textPtr(inv.Notes),
+		CreditedInvoiceNumber: textPtr(inv.CreditedInvoiceNumber),
+		PeriodFrom:            datePtr(inv.PeriodFrom),
+		PeriodTo:              datePtr(inv.PeriodTo),
+		LineItems:             []*model.InvoiceLineItem{},
+		Pipeline:              dbInvoicePipelineToGQL(inv),
+		CreatedAt:             timestamptzToTime(inv.CreatedAt),
+	}
+}
+
+func dbAccountingExportToGQL(exp db.AccountingExport) *model.AccountingExport {
+	result := &model.AccountingExport{
+		ID:          uuidToString(exp.ID),
+		Format:      model.AccountingExportFormat(strings.ToUpper(exp.Format)),
+		PeriodFrom:  dateToString(exp.PeriodFrom),
+		PeriodTo:    dateToString(exp.PeriodTo),
+		Status:      model.AccountingExportStatus(strings.ToUpper(exp.Status)),
+		RecordCount: int4Ptr(exp.RecordCount),
+		Error:       textPtr(exp.Error),
+		CreatedAt:   timestamptzToTime(exp.CreatedAt),
+		CompletedAt: timestamptzToTimePtr(exp.CompletedAt),
+	}
+	if exp.Status == accounting.StatusReady {
+		url := "/api/accounting-exports/" + uuidToString(exp.ID)
+		result.DownloadURL = &url
+	}
+	return result
+}
+
+func dbInvoiceSeriesToGQL(series db.InvoiceSeries) *model.InvoiceSeries {
+	return &model.InvoiceSeries{
+		ID:                uuidToString(series.ID),
+		DocumentType:      model.InvoiceDocumentType(strings.ToUpper(series.DocumentType)),
+		Prefix:            series.Prefix,
+		StartNumber:       int(series.StartNumber),
+		YearlyReset:       series.YearlyReset,
+		Active:            series.Active,
+		NextInvoiceNumber: invoice.NextNumber(series),
+		RetiredAt:         timestamptzToTimePtr(series.RetiredAt),
+		CreatedAt:         timestamptzToTime(series.CreatedAt),
+	}
+}
+
+func dbVatRateToGQL(rate db.VatRate) *model.VatRate {
+	return &model.VatRate{
+		ID:          uuidToString(rate.ID),
+		Category:    model.VatCategory(strings.ToUpper(rate.Category)),
+		RatePct:     int(rate.RatePct),
+		ValidFrom:   dateToString(rate.ValidFrom),
+		Description: textPtr(rate.Description),
+		CreatedAt:   timestamptzToTime(rate.CreatedAt),
+	}
+}
+
+func dbInvoicePipelineToGQL(inv db.Invoice) *model.InvoicePipeline {
+	if !inv.PipelineStatus.Valid {
+		return nil
+	}
+	p := &model.InvoicePipeline{
+		Status:              model.InvoicePipelineStatus(strings.ToUpper(inv.PipelineStatus.String)),
+		PDFStored:           inv.PdfPath.String != "",
+		EmailedAt:           timestamptzToTimePtr(inv.EmailedAt),
+		EfacturaTransmitted: inv.EfacturaStatus.String != "" && inv.EfacturaStatus.String != "error",
+		Attempts:            int(inv.PipelineAttempts),
+		LastError:           textPtr(inv.PipelineError),
+		NextAttemptAt:       timestamptzToTimePtr(inv.PipelineNextAttemptAt),
+	}
+	if invoice.IsB2B(inv) && inv.IssuedAt.Valid {
+		deadline := invoice.EFacturaDeadline(inv.IssuedAt.Time)
+		p.EfacturaDeadline = &deadline
+	}
+	p.EfacturaOverdue = invoice.EFacturaOverdue(inv, time.Now())
+	return p
+}
+
+func dbInvoiceSettingsToGQL(s db.CompanyInvoiceSetting) *model.CompanyInvoiceSettings {
+	return &model.CompanyInvoiceSettings{
+		AutoIssueTrigger:     model.InvoiceAutoIssueTrigger(strings.ToUpper(s.AutoIssueTrigger)),
+		AutoEmail:            s.AutoEmail,
+		AutoTransmitEFactura: s.AutoEfactura,
+		AutoIssueSince:       timestamptzToTimePtr(s.AutoIssueSince),
+		UpdatedAt:            timestamptzToTimePtr(s.UpdatedAt),
+	}
+}
+
+func dbAnafTokenToGQL(t db.AnafToken) *model.AnafConnection {
+	return &model.AnafConnection{
+		Cif:         t.Cif,
+		Connected:   true,
+		ExpiresAt:   timestamptzToTimePtr(t.ExpiresAt),
+		ConnectedAt: timestamptzToTimePtr(t.CreatedAt),
 	}
 }
 
@@ -713,12 +891,23 @@ func dbRecurringGroupToGQL(g db.RecurringBookingGroup) *model.RecurringBookingGr
 		IsActive:                    boolVal(g.IsActive),
 		CancelledAt:                 timestamptzToTimePtr(g.CancelledAt),
 		CancellationReason:          textPtr(g.CancellationReason),
+		PaymentFailures:             int(g.PaymentFailures),
 		Occurrences:                 []*model.Booking{},
 		UpcomingOccurrences:         []*model.Booking{},
+		Rrule:                       textVal(g.Rrule),
+		Exdates:                     []string{},
+		Exceptions:                  []*model.RecurringOccurrenceException{},
 		CreatedAt:                   timestamptzToTime(g.CreatedAt),
 	}
 }
 
+func dbOccurrenceExceptionToGQL(e db.RecurringOccurrenceException) *model.RecurringOccurrenceException {
+	return &model.RecurringOccurrenceException{
+		OriginalDate: dateToString(e.OriginalDate),
+		Type:         model.OccurrenceExceptionType(strings.ToUpper(string(e.ExceptionType))),
+	}
+}
+
 // validateStatusTransition checks whether a booking status transition is allowed.
 func validateStatusTransition(current db.BookingStatus, target db.BookingStatus) error {
 	// Cancellation is allowed from any non-terminal state.
@@ -1218,6 +1218,27 @@ func TestDbBookingToGQL(t *testing.T) {
 		}
 	})
 
+	t.Run("flags recurring occurrence without cleaner as needing staffing", func(t *testing.T) {
+		dbBooking := db.Booking{
+			ID:               makeUUID(0x46),
+			ReferenceCode:    "HMC-2025-006",
+			ServiceType:      db.ServiceTypeStandardCleaning,
+			Status:           db.BookingStatusPending,
+			RecurringGroupID: makeUUID(0x47),
+			OccurrenceNumber: pgtype.Int4{Int32: 9, Valid: true},
+			CreatedAt:        makeTimestamptz(time.Now().UTC()),
+		}
+
+		if !dbBookingToGQL(dbBooking).NeedsStaffing {
+			t.Error("expected NeedsStaffing for unassigned recurring occurrence")
+		}
+
+		dbBooking.CleanerID = makeUUID(0x48)
+		if dbBookingToGQL(dbBooking).NeedsStaffing {
+			t.Error("expected NeedsStaffing false once a cleaner is assigned")
+		}
+	})
+
 	t.Run("minimal booking with zero/invalid optional fields", func(t *testing.T) {
 		dbBooking := db.Booking{
 			ID:                     makeUUID(0x55),
@@ -2144,6 +2165,34 @@ func TestDbPaymentTransactionToGQL(t *testing.T) {
 		}
 	})
 
+	t.Run("converts manual capture authorization", func(t *testing.T) {
+		now := time.Now().UTC().Truncate(time.Microsecond)
+
+		dbTx := db.PaymentTransaction{
+			ID:                    makeUUID(0x07),
+			BookingID:             makeUUID(0x08),
+			StripePaymentIntentID: "pi_hold",
+			AmountTotal:           20000,
+			Status:                db.PaymentTransactionStatusAuthorized,
+			CaptureMethod:         "manual",
+			AmountAuthorized:      pgtype.Int4{Int32: 23000, Valid: true},
+			AuthorizedAt:          makeTimestamptz(now),
+			CreatedAt:             makeTimestamptz(now),
+		}
+
+		result := dbPaymentTransactionToGQL(dbTx)
+
+		if result.CaptureMethod != "manual" {
+			t.Errorf("expected CaptureMethod 'manual', got %q", result.CaptureMethod)
+		}
+		if result.AmountAuthorized == nil || *result.AmountAuthorized != 23000 {
+			t.Errorf("expected AmountAuthorized 23000, got %v", result.AmountAuthorized)
+		}
+		if result.AuthorizedAt == nil || !result.AuthorizedAt.Equal(now) {
+			t.Errorf("expected AuthorizedAt %v, got %v", now, result.AuthorizedAt)
+		}
+	})
+
 	t.Run("status enum conversion for all statuses", func(t *testing.T) {
 		tests := []struct {
 			dbStatus  db.PaymentTransactionStatus
@@ -2152,6 +2201,7 @@ func TestDbPaymentTransactionToGQL(t *testing.T) {
 			{db.PaymentTransactionStatusPending, model.PaymentTransactionStatusPending},
 			{db.PaymentTransactionStatusRequiresAction, model.PaymentTransactionStatusRequiresAction},
 			{db.PaymentTransactionStatusProcessing, model.PaymentTransactionStatusProcessing},
+			{db.PaymentTransactionStatusAuthorized, model.PaymentTransactionStatusAuthorized},
 			{db.PaymentTransactionStatusSucceeded, model.PaymentTransactionStatusSucceeded},
 			{db.PaymentTransactionStatusFailed, model.PaymentTransactionStatusFailed},
 			{db.PaymentTransactionStatusRefunded, model.PaymentTransactionStatusRefunded},
@@ -2266,12 +2316,45 @@ func TestDbCompanyPayoutToGQL(t *testing.T) {
 		}
 	})
 
+	t.Run("failed payout with retry", func(t *testing.T) {
+		now := time.Now().UTC().Truncate(time.Microsecond)
+		retryAt := time.Date(2025, 7, 2, 14, 0, 0, 0, time.UTC)
+		dbPayout := db.CompanyPayout{
+			ID:             makeUUID(0x12),
+			CompanyID:      makeUUID(0x20),
+			StripePayoutID: makeText("po_123"),
+			Amount:         50000,
+			Currency:       "ron",
+			Status:         db.PayoutStatusFailed,
+			FailureReason:  makeText("insufficient_funds"),
+			Attempts:       2,
+			NextAttemptAt:  makeTimestamptz(retryAt),
+			CreatedAt:      makeTimestamptz(now),
+		}
+
+		result := dbCompanyPayoutToGQL(dbPayout)
+
+		if result.StripePayoutID == nil || *result.StripePayoutID != "po_123" {
+			t.Errorf("expected StripePayoutID 'po_123', got %v", result.StripePayoutID)
+		}
+		if result.FailureReason == nil || *result.FailureReason != "insufficient_funds" {
+			t.Errorf("expected FailureReason 'insufficient_funds', got %v", result.FailureReason)
+		}
+		if result.Attempts != 2 {
+			t.Errorf("expected Attempts 2, got %d", result.Attempts)
+		}
+		if result.NextAttemptAt == nil || !result.NextAttemptAt.Equal(retryAt) {
+			t.Errorf("expected NextAttemptAt %v, got %v", retryAt, result.NextAttemptAt)
+		}
+	})
+
 	t.Run("status enum conversion for all payout statuses", func(t *testing.T) {
 		tests := []struct {
 			dbStatus  db.PayoutStatus
 			gqlStatus model.PayoutStatus
 		}{
 			{db.PayoutStatusPending, model.PayoutStatusPending},
+			{db.PayoutStatusHeld, model.PayoutStatusHeld},
 			{db.PayoutStatusProcessing, model.PayoutStatusProcessing},
 			{db.PayoutStatusPaid, model.PayoutStatusPaid},
 			{db.PayoutStatusFailed, model.PayoutStatusFailed},
@@ -2514,6 +2597,9 @@ func TestDbInvoiceToGQL(t *testing.T) {
 		if result.DownloadURL == nil || *result.DownloadURL != "https://app.factureaza.ro/download/abc123" {
 			t.Errorf("expected DownloadURL, got %v", result.DownloadURL)
 		}
+		if want := "/api/invoices/" + uuidToString(makeUUID(0x40)) + "/pdf"; result.PDFURL != want {
+			t.Errorf("expected PDFURL %q, got %q", want, result.PDFURL)
+		}
 		if result.IssuedAt == nil || !result.IssuedAt.Equal(issuedAt) {
 			t.Errorf("expected IssuedAt %v, got %v", issuedAt, result.IssuedAt)
 		}
@@ -2588,6 +2674,43 @@ func TestDbInvoiceToGQL(t *testing.T) {
 		if result.Notes != nil {
 			t.Errorf("expected nil Notes, got %v", result.Notes)
 		}
+		if result.Pipeline != nil {
+			t.Errorf("expected nil Pipeline, got %+v", result.Pipeline)
+		}
+	})
+
+	t.Run("invoice pipeline state", func(t *testing.T) {
+		dbInvoice := db.Invoice{
+			ID:               makeUUID(0x41),
+			InvoiceType:      db.InvoiceTypeClientService,
+			Status:           db.InvoiceStatusIssued,
+			BuyerCui:         makeText("RO87654321"),
+			IssuedAt:         makeTimestamptz(time.Date(2026, 4, 10, 9, 0, 0, 0, time.UTC)),
+			PdfPath:          makeText("invoices/x/factura.pdf"),
+			EfacturaStatus:   makeText("error"),
+			PipelineStatus:   makeText("failed"),
+			PipelineAttempts: 2,
+			PipelineError:    makeText("invoice: e-factura transmission: timeout"),
+			CreatedAt:        makeTimestamptz(time.Date(2026, 4, 10, 9, 0, 0, 0, time.UTC)),
+		}
+
+		p := dbInvoiceToGQL(dbInvoice).Pipeline
+		if p == nil {
+			t.Fatal("expected a pipeline")
+		}
+		if p.Status != model.InvoicePipelineStatusFailed || !p.PDFStored || p.EfacturaTransmitted || p.EmailedAt != nil || p.Attempts != 2 {
+			t.Errorf("unexpected pipeline %+v", p)
+		}
+		if p.LastError == nil || *p.LastError != "invoice: e-factura transmission: timeout" {
+			t.Errorf("expected the last error, got %v", p.LastError)
+		}
+		// Issued on Friday 10 April 2026; five calendar days later is Wednesday.
+		if p.EfacturaDeadline == nil || p.EfacturaDeadline.Format("2006-01-02") != "2026-04-15" {
+			t.Errorf("expected the e-Factura deadline on 2026-04-15, got %v", p.EfacturaDeadline)
+		}
+		if !p.EfacturaOverdue {
+			t.Error("expected the untransmitted invoice to be overdue")
+		}
 	})
 
 	t.Run("invoice status enum conversion for all statuses", func(t *testing.T) {
@@ -3102,3 +3225,170 @@ func TestDbCleanerDocToGQL(t *testing.T) {
 		}
 	})
 }
+
+// ---------------------------------------------------------------------------
+// dbOccurrenceExceptionToGQL
+// ---------------------------------------------------------------------------
+
+func TestDbOccurrenceExceptionToGQL(t *testing.T) {
+	e := db.RecurringOccurrenceException{
+		GroupID:       makeUUID(0x31),
+		OriginalDate:  pgtype.Date{Time: time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC), Valid: true},
+		ExceptionType: db.OccurrenceExceptionTypeRescheduled,
+	}
+
+	result := dbOccurrenceExceptionToGQL(e)
+
+	if result.OriginalDate != "2026-03-09" {
+		t.Errorf("expected OriginalDate '2026-03-09', got %q", result.OriginalDate)
+	}
+	if result.Type != model.OccurrenceExceptionTypeRescheduled {
+		t.Errorf("expected Type RESCHEDULED, got %q", result.Type)
+	}
+	if result.Booking != nil {
+		t.Error("expected nil Booking (filled in by enrichRecurringGroup)")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// dbCompanyClosureToGQL
+// ---------------------------------------------------------------------------
+
+func TestDbCompanyClosureToGQL(t *testing.T) {
+	c := db.CompanyClosure{
+		ID:          makeUUID(0x41),
+		CompanyID:   makeUUID(0x42),
+		ClosureDate: pgtype.Date{Time: time.Date(2026, 8, 14, 0, 0, 0, 0, time.UTC), Valid: true},
+		Reason:      makeText("Inventar"),
+	}
+
+	result := dbCompanyClosureToGQL(c)
+
+	if result.ID != uuidToString(c.ID) {
+		t.Errorf("expected ID %q, got %q", uuidToString(c.ID), result.ID)
+	}
+	if result.Date != "2026-08-14" {
+		t.Errorf("expected Date '2026-08-14', got %q", result.Date)
+	}
+	if result.Reason == nil || *result.Reason != "Inventar" {
+		t.Errorf("expected Reason 'Inventar', got %v", result.Reason)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// dbStripeEventToGQL
+// ---------------------------------------------------------------------------
+
+func TestDbStripeEventToGQL(t *testing.T) {
+	e := db.StripeEvent{
+		ID:            "evt_123",
+		EventType:     "payment_intent.succeeded",
+		Payload:       []byte(`{"id":"evt_123"}`),
+		Status:        db.StripeEventStatusFailed,
+		Attempts:      3,
+		LastError:     makeText("connection reset"),
+		NextAttemptAt: makeTimestamptz(time.Date(2026, 3, 2, 10, 4, 0, 0, time.UTC)),
+		CreatedAt:     makeTimestamptz(time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)),
+	}
+
+	result := dbStripeEventToGQL(e)
+
+	if result.ID != "evt_123" || result.Type != "payment_intent.succeeded" {
+		t.Errorf("unexpected ID/Type: %q / %q", result.ID, result.Type)
+	}
+	if result.Status != model.WebhookEventStatusFailed {
+		t.Errorf("expected Status FAILED, got %q", result.Status)
+	}
+	if result.Attempts != 3 {
+		t.Errorf("expected Attempts 3, got %d", result.Attempts)
+	}
+	if result.LastError == nil || *result.LastError != "connection reset" {
+		t.Errorf("expected LastError 'connection reset', got %v", result.LastError)
+	}
+	if result.NextAttemptAt == nil || result.NextAttemptAt.Minute() != 4 {
+		t.Errorf("expected NextAttemptAt 10:04, got %v", result.NextAttemptAt)
+	}
+	if result.ProcessedAt != nil {
+		t.Errorf("expected nil ProcessedAt, got %v", result.ProcessedAt)
+	}
+	if result.Payload != `{"id":"evt_123"}` {
+		t.Errorf("unexpected Payload %q", result.Payload)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// dbLedgerReconciliationToGQL
+// ---------------------------------------------------------------------------
+
+func TestDbLedgerReconciliationToGQL(t *testing.T) {
+	r := db.LedgerReconciliation{
+		ID:            makeUUID(7),
+		PeriodFrom:    makeTimestamptz(time.Date(2026, 2, 27, 3, 0, 0, 0, time.UTC)),
+		PeriodTo:      makeTimestamptz(time.Date(2026, 3, 2, 3, 0, 0, 0, time.UTC)),
+		CheckedCount:  12,
+		FeesPosted:    4,
+		Discrepancies: []byte(`[{"reference":"payment:pi_1","stripeId":"txn_1","problem":"amount_mismatch","ledgerAmount":20000,"stripeAmount":23000},{"reference":"refund:re_1","problem":"missing_in_stripe","ledgerAmount":-5000,"stripeAmount":0}]`),
+		CreatedAt:     makeTimestamptz(time.Date(2026, 3, 2, 3, 0, 5, 0, time.UTC)),
+	}
+
+	result := dbLedgerReconciliationToGQL(r)
+
+	if result.CheckedCount != 12 || result.FeesPosted != 4 {
+		t.Errorf("unexpected counts: %d checked, %d fees", result.CheckedCount, result.FeesPosted)
+	}
+	if len(result.Discrepancies) != 2 {
+		t.Fatalf("expected 2 discrepancies, got %d", len(result.Discrepancies))
+	}
+	d := result.Discrepancies[0]
+	if d.Reference != "payment:pi_1" || d.Problem != "amount_mismatch" || d.LedgerAmount != 20000 || d.StripeAmount != 23000 {
+		t.Errorf("unexpected first discrepancy %+v", d)
+	}
+	if d.StripeID == nil || *d.StripeID != "txn_1" {
+		t.Errorf("expected StripeID txn_1, got %v", d.StripeID)
+	}
+	if result.Discrepancies[1].StripeID != nil {
+		t.Errorf("expected nil StripeID, got %v", *result.Discrepancies[1].StripeID)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// dbPaymentDisputeToGQL
+// ---------------------------------------------------------------------------
+
+func TestDbPaymentDisputeToGQL(t *testing.T) {
+	d := db.PaymentDispute{
+		ID:              makeUUID(8),
+		StripeDisputeID: "dp_1",
+		StripeChargeID:  "ch_1",
+		Amount:          20000,
+		Currency:        "ron",
+		Reason:          "product_not_received",
+		Status:          db.DisputeStatusNeedsResponse,
+		EvidenceDueBy:   makeTimestamptz(time.Date(2026, 3, 20, 23, 59, 0, 0, time.UTC)),
+		Evidence:        []byte(`{"customerName":"Ana Pop","serviceDate":"02.03.2026"}`),
+		CreatedAt:       makeTimestamptz(time.Date(2026, 3, 5, 9, 0, 0, 0, time.UTC)),
+	}
+
+	result := dbPaymentDisputeToGQL(d)
+
+	if result.Status != model.DisputeStatusNeedsResponse {
+		t.Errorf("expected status NEEDS_RESPONSE, got %s", result.Status)
+	}
+	if result.StripeDisputeID != "dp_1" || result.Amount != 20000 || result.Reason != "product_not_received" {
+		t.Errorf("unexpected dispute %+v", result)
+	}
+	if result.EvidenceDueBy == nil || result.EvidenceSubmittedAt != nil || result.ClosedAt != nil {
+		t.Errorf("unexpected dates: due %v, submitted %v, closed %v", result.EvidenceDueBy, result.EvidenceSubmittedAt, result.ClosedAt)
+	}
+	if result.Evidence == nil || result.Evidence.CustomerName != "Ana Pop" {
+		t.Fatalf("expected evidence for Ana Pop, got %+v", result.Evidence)
+	}
+	if result.Evidence.PhotoUrls == nil {
+		t.Error("expected empty PhotoUrls, got nil")
+	}
+
+	d.Evidence = nil
+	if result := dbPaymentDisputeToGQL(d); result.Evidence != nil {
+		t.Errorf("expected nil evidence, got %+v", result.Evidence)
+	}
+}
@@ -0,0 +1,210 @@
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	db "helpmeclean-backend/internal/db/generated"
+	"helpmeclean-backend/internal/graph/model"
+	"helpmeclean-backend/internal/service/matching"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// loadDispatchConfig reads the dispatch cost model from platform_settings,
+// falling back to DefaultDispatchConfig for any missing keys.
+func loadDispatchConfig(ctx context.Context, queries *db.Queries) matching.DispatchConfig {
+	config := matching.DefaultDispatchConfig()
+
+	if v, err := queries.GetPlatformSetting(ctx, "dispatch_avg_speed_kmh"); err == nil {
+		if f, err := strconv.ParseFloat(v.Value, 64); err == nil && f > 0 {
+			config.SpeedKmh = f
+		}
+	}
+	if v, err := queries.GetPlatformSetting(ctx, "dispatch_default_travel_minutes"); err == nil {
+		if f, err := strconv.ParseFloat(v.Value, 64); err == nil && f >= 0 {
+			config.DefaultTravelMinutes = f
+		}
+	}
+	if v, err := queries.GetPlatformSetting(ctx, "dispatch_regular_hours"); err == nil {
+		if f, err := strconv.ParseFloat(v.Value, 64); err == nil && f > 0 {
+			config.RegularHours = f
+		}
+	}
+	if v, err := queries.GetPlatformSetting(ctx, "dispatch_move_penalty_minutes"); err == nil {
+		if f, err := strconv.ParseFloat(v.Value, 64); err == nil && f >= 0 {
+			config.MovePenalty = f
+		}
+	}
+
+	return config
+}
+
+// dispatchDay is the dispatch planner's view of one company day.
+type dispatchDay struct {
+	bookings map[string]db.Booking // movable bookings by ID
+	order    []string              // movable booking IDs in schedule order
+	jobs     []matching.DispatchJob
+	cleaners []matching.DispatchCleaner
+	active   map[string]db.Cleaner // active company cleaners by ID
+	config   matching.DispatchConfig
+}
+
+// loadDispatchDay builds the planner input for a company's bookings on date.
+// Pending, assigned and confirmed solo bookings can be moved; started and
+// completed bookings and every member's share of a team booking are locked
+// so they still count against the cleaner's day.
+func (r *Resolver) loadDispatchDay(ctx context.Context, companyID pgtype.UUID, date time.Time) (*dispatchDay, error) {
+	pgDate := pgtype.Date{Time: date, Valid: true}
+	bookings, err := r.Queries.ListBookingsByCompanyAndDateRange(ctx, db.ListBookingsByCompanyAndDateRangeParams{
+		CompanyID: companyID,
+		DateFrom:  pgDate,
+		DateTo:    pgDate,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load bookings: %w", err)
+	}
+
+	day := &dispatchDay{
+		bookings: map[string]db.Booking{},
+		active:   map[string]db.Cleaner{},
+		config:   loadDispatchConfig(ctx, r.Queries),
+	}
+
+	addresses := map[pgtype.UUID]db.ClientAddress{}
+	for _, b := range bookings {
+		id := uuidToString(b.ID)
+		start := b.ScheduledStartTime.Microseconds
+		end := start + int64(numericToFloat(b.EstimatedDurationHours)*float64(matching.HourMicros))
+		job := matching.DispatchJob{
+			ID:          id,
+			ServiceType: string(b.ServiceType),
+			StartMicros: start,
+			EndMicros:   end,
+		}
+
+		addr, ok := addresses[b.AddressID]
+		if !ok {
+			if a, err := r.Queries.GetAddressByID(ctx, b.AddressID); err == nil {
+				addr = a
+				addresses[b.AddressID] = a
+			}
+		}
+		if addr.Latitude.Valid && addr.Longitude.Valid {
+			job.Lat, job.Lng, job.HasLocation = addr.Latitude.Float64, addr.Longitude.Float64, true
+		}
+
+		if b.TeamSize > 1 {
+			members, err := r.Queries.ListBookingTeamMembers(ctx, b.ID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load team members: %w", err)
+			}
+			for _, m := range members {
+				mj := job
+				mj.ID = id + "#" + uuidToString(m.CleanerID)
+				mj.Current = uuidToString(m.CleanerID)
+				mj.Locked = true
+				day.jobs = append(day.jobs, mj)
+			}
+			continue
+		}
+
+		if b.CleanerID.Valid {
+			job.Current = uuidToString(b.CleanerID)
+		}
+		switch b.Status {
+		case db.BookingStatusPending, db.BookingStatusAssigned, db.BookingStatusConfirmed:
+			day.bookings[id] = b
+			day.order = append(day.order, id)
+		default:
+			job.Locked = true
+		}
+		day.jobs = append(day.jobs, job)
+	}
+
+	cleaners, err := r.Queries.ListCleanersByCompany(ctx, companyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load cleaners: %w", err)
+	}
+	dateStr := date.Format("2006-01-02")
+	for _, c := range cleaners {
+		if c.Status != db.CleanerStatusActive {
+			continue
+		}
+		id := uuidToString(c.ID)
+		day.active[id] = c
+
+		dc := matching.DispatchCleaner{ID: id}
+		avails, err := r.cleanerDateAvailabilities(ctx, c.ID, companyID, map[string]time.Time{dateStr: date}, 0, pgtype.UUID{})
+		if err == nil && len(avails) > 0 {
+			dc.Available = true
+			dc.AvailStart, dc.AvailEnd = avails[0].AvailStart, avails[0].AvailEnd
+		}
+		if skills, err := r.Queries.ListCleanerSkills(ctx, c.ID); err == nil {
+			for _, st := range skills {
+				dc.Skills = append(dc.Skills, string(st))
+			}
+		}
+		day.cleaners = append(day.cleaners, dc)
+	}
+
+	return day, nil
+}
+
+// dayPlanToGQL converts the current and proposed plans of a day into the
+// GraphQL DayPlan, listing only the bookings the planner may move.
+func (r *Resolver) dayPlanToGQL(ctx context.Context, date string, day *dispatchDay, current, proposed matching.DispatchPlan) *model.DayPlan {
+	profiles := map[string]*model.CleanerProfile{}
+	profile := func(id string) *model.CleanerProfile {
+		if id == "" {
+			return nil
+		}
+		if p, ok := profiles[id]; ok {
+			return p
+		}
+		c, ok := day.active[id]
+		if !ok {
+			var err error
+			if c, err = r.Queries.GetCleanerByID(ctx, stringToUUID(id)); err != nil {
+				return nil
+			}
+		}
+		p, err := r.cleanerWithCompany(ctx, c)
+		if err != nil {
+			return nil
+		}
+		profiles[id] = p
+		return p
+	}
+
+	plan := &model.DayPlan{
+		Date:                   date,
+		Assignments:            []*model.DayPlanAssignment{},
+		Changes:                []*model.DayPlanAssignment{},
+		CurrentTravelMinutes:   current.TravelMinutes,
+		CurrentOvertimeMinutes: current.OvertimeMinutes,
+		TravelMinutes:          proposed.TravelMinutes,
+		OvertimeMinutes:        proposed.OvertimeMinutes,
+		UnassignedCount:        proposed.Unassigned,
+	}
+	for _, id := range day.order {
+		b := day.bookings[id]
+		from, to := current.Assignments[id], proposed.Assignments[id]
+
+		gqlB := dbBookingToGQL(b)
+		r.enrichBooking(ctx, b, gqlB)
+		a := &model.DayPlanAssignment{
+			Booking:         gqlB,
+			CurrentCleaner:  profile(from),
+			ProposedCleaner: profile(to),
+			Changed:         from != to,
+		}
+		plan.Assignments = append(plan.Assignments, a)
+		if a.Changed {
+			plan.Changes = append(plan.Changes, a)
+		}
+	}
+	return plan
+}
@@ -0,0 +1,169 @@
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	db "helpmeclean-backend/internal/db/generated"
+	"helpmeclean-backend/internal/graph/model"
+	"helpmeclean-backend/internal/service/payment"
+	"helpmeclean-backend/internal/service/recurrence"
+)
+
+// disputeChatLimit is how many chat messages of a booking go into the
+// evidence of a dispute.
+const disputeChatLimit = 500
+
+// assembleDisputeEvidence builds the evidence of a dispute from its booking:
+// the client and the address, the booking history, the cleaners' check-ins,
+// the chat transcript and the photos sent in the chat. The text is in English
+// since it is read by the card issuer.
+func (r *Resolver) assembleDisputeEvidence(ctx context.Context, dp db.PaymentDispute) (payment.DisputeEvidence, error) {
+	var e payment.DisputeEvidence
+	if !dp.BookingID.Valid {
+		return e, fmt.Errorf("dispute is not linked to a booking")
+	}
+	booking, err := r.Queries.GetBookingByID(ctx, dp.BookingID)
+	if err != nil {
+		return e, fmt.Errorf("failed to load booking: %w", err)
+	}
+
+	if client, err := r.Queries.GetUserByID(ctx, booking.ClientUserID); err == nil {
+		e.CustomerName = client.FullName
+		e.CustomerEmailAddress = client.Email
+	}
+	if booking.AddressID.Valid {
+		if addr, err := r.Queries.GetAddressByID(ctx, booking.AddressID); err == nil {
+			parts := []string{addr.StreetAddress, addr.City, addr.County}
+			if addr.PostalCode.Valid {
+				parts = append(parts, addr.PostalCode.String)
+			}
+			e.BillingAddress = strings.Join(parts, ", ")
+		}
+	}
+
+	e.ServiceDate = booking.ScheduledDate.Time.Format("2006-01-02")
+	service := string(booking.ServiceType)
+	if def, err := r.Queries.GetServiceByType(ctx, booking.ServiceType); err == nil {
+		service = def.NameEn
+	}
+	e.ProductDescription = fmt.Sprintf(
+		"Home cleaning service (%s) booked on HelpMeClean, booking %s, scheduled for %s at the customer's address, performed by a team of %d cleaner(s).",
+		service, booking.ReferenceCode, e.ServiceDate, booking.TeamSize)
+
+	// Booking history and check-ins, in Romanian time.
+	var history []string
+	stamp := func(t pgtype.Timestamptz, what string) {
+		if t.Valid {
+			history = append(history, t.Time.In(recurrence.Location).Format("2006-01-02 15:04")+" "+what)
+		}
+	}
+	stamp(booking.CreatedAt, "Booking created by the customer")
+	stamp(booking.PaidAt, "Payment received")
+	stamp(booking.StartedAt, "Cleaning started")
+	members, _ := r.Queries.ListBookingTeamMembers(ctx, booking.ID)
+	for _, m := range members {
+		name := r.disputeCleanerName(ctx, m.CleanerID)
+		stamp(m.CheckedInAt, name+" checked in at the address")
+		stamp(m.CompletedAt, name+" finished their part of the job")
+	}
+	stamp(booking.CompletedAt, "Cleaning completed")
+	if booking.CancelledAt.Valid {
+		reason := "no reason given"
+		if booking.CancellationReason.Valid {
+			reason = booking.CancellationReason.String
+		}
+		stamp(booking.CancelledAt, "Booking cancelled: "+reason)
+	}
+	e.AccessActivityLog = strings.Join(history, "\n")
+
+	// Chat transcript; photos are image messages whose content is the URL.
+	if room, err := r.Queries.GetChatRoomByBookingID(ctx, booking.ID); err == nil {
+		messages, err := r.Queries.ListChatMessages(ctx, db.ListChatMessagesParams{
+			RoomID: room.ID,
+			Limit:  disputeChatLimit,
+		})
+		if err != nil {
+			return e, fmt.Errorf("failed to load chat messages: %w", err)
+		}
+		names := map[pgtype.UUID]string{}
+		var transcript []string
+		for _, m := range messages {
+			if m.MessageType.String == "image" {
+				e.PhotoURLs = append(e.PhotoURLs, m.Content)
+				continue
+			}
+			name, ok := names[m.SenderID]
+			if !ok {
+				name = "Unknown"
+				if u, err := r.Queries.GetUserByID(ctx, m.SenderID); err == nil {
+					name = u.FullName
+				}
+				names[m.SenderID] = name
+			}
+			transcript = append(transcript, fmt.Sprintf("[%s] %s: %s",
+				m.CreatedAt.Time.In(recurrence.Location).Format("2006-01-02 15:04"), name, m.Content))
+		}
+		e.CustomerCommunication = strings.Join(transcript, "\n")
+	}
+	if e.PhotoURLs == nil {
+		e.PhotoURLs = []string{}
+	}
+	return e, nil
+}
+
+// disputeCleanerName returns the full name of a cleaner for dispute evidence.
+func (r *Resolver) disputeCleanerName(ctx context.Context, cleanerID pgtype.UUID) string {
+	if cleaner, err := r.Queries.GetCleanerByID(ctx, cleanerID); err == nil {
+		if u, err := r.Queries.GetUserByID(ctx, cleaner.UserID); err == nil {
+			return u.FullName
+		}
+	}
+	return "A cleaner"
+}
+
+// disputeEvidenceFromInput converts edited evidence from GraphQL.
+func disputeEvidenceFromInput(in model.DisputeEvidenceInput) payment.DisputeEvidence {
+	photos := in.PhotoUrls
+	if photos == nil {
+		photos = []string{}
+	}
+	return payment.DisputeEvidence{
+		ProductDescription:       in.ProductDescription,
+		CustomerName:             in.CustomerName,
+		CustomerEmailAddress:     in.CustomerEmailAddress,
+		BillingAddress:           in.BillingAddress,
+		ServiceDate:              in.ServiceDate,
+		AccessActivityLog:        in.AccessActivityLog,
+		CustomerCommunication:    in.CustomerCommunication,
+		RefundRefusalExplanation: in.RefundRefusalExplanation,
+		UncategorizedText:        in.UncategorizedText,
+		PhotoURLs:                photos,
+	}
+}
+
+// enrichDispute converts a dispute to GraphQL with its booking, company and
+// payment transaction.
+func (r *Resolver) enrichDispute(ctx context.Context, dp db.PaymentDispute) *model.PaymentDispute {
+	result := dbPaymentDisputeToGQL(dp)
+	if dp.BookingID.Valid {
+		if booking, err := r.Queries.GetBookingByID(ctx, dp.BookingID); err == nil {
+			result.Booking = dbBookingToGQL(booking)
+			r.enrichBooking(ctx, booking, result.Booking)
+		}
+	}
+	if dp.CompanyID.Valid {
+		if company, err := r.Queries.GetCompanyByID(ctx, dp.CompanyID); err == nil {
+			result.Company = dbCompanyToGQL(company)
+		}
+	}
+	if dp.PaymentTransactionID.Valid {
+		if txn, err := r.Queries.GetPaymentTransactionByID(ctx, dp.PaymentTransactionID); err == nil {
+			result.PaymentTransaction = dbPaymentTransactionToGQL(txn)
+		}
+	}
+	return result
+}
@@ -1,12 +1,14 @@
 package resolver
 
 import (
+	"context"
 	"fmt"
 	"math"
 	"path/filepath"
 	"strings"
 
 	db "helpmeclean-backend/internal/db/generated"
+	"helpmeclean-backend/internal/graph/model"
 )
 
 // microsecondsToHHMM converts PostgreSQL TIME microseconds to "HH:MM" string.
@@ -16,6 +18,64 @@ func microsecondsToHHMM(us int64) string {
 	return fmt.Sprintf("%02d:%02d", hours, minutes)
 }
 
+// petsSurchargeAmount is the flat fee added to visits at homes with pets.
+const petsSurchargeAmount = 15.0
+
+// petsSurcharge returns the pets surcharge of a visit.
+func petsSurcharge(hasPets bool) float64 {
+	if hasPets {
+		return petsSurchargeAmount
+	}
+	return 0
+}
+
+// bookingQuote is the estimated price of one visit.
+type bookingQuote struct {
+	HourlyRate     float64
+	EstimatedHours float64
+	ExtrasTotal    float64
+	PetsSurcharge  float64
+}
+
+// Total returns the estimated total of the visit.
+func (q bookingQuote) Total() float64 {
+	return q.HourlyRate*q.EstimatedHours + q.ExtrasTotal + q.PetsSurcharge
+}
+
+// quoteBooking prices one visit of a service: the service's hourly rate times
+// the estimated duration, plus extras and the pets surcharge.
+func (r *Resolver) quoteBooking(ctx context.Context, serviceType db.ServiceType, numRooms, numBathrooms int, areaSqm *int, propertyType *string, hasPets *bool, extras []*model.ExtraInput) (bookingQuote, error) {
+	serviceDef, err := r.Queries.GetServiceByType(ctx, serviceType)
+	if err != nil {
+		return bookingQuote{}, fmt.Errorf("service type not found: %w", err)
+	}
+
+	quote := bookingQuote{HourlyRate: numericToFloat(serviceDef.BasePricePerHour)}
+
+	// Fetch extras for duration and pricing.
+	var extrasDuration []struct {
+		DurationMinutes int32
+		Quantity        int
+	}
+	for _, extraInput := range extras {
+		extra, err := r.Queries.GetExtraByID(ctx, stringToUUID(extraInput.ExtraID))
+		if err != nil {
+			return bookingQuote{}, fmt.Errorf("extra not found: %w", err)
+		}
+		quote.ExtrasTotal += numericToFloat(extra.Price) * float64(extraInput.Quantity)
+		extrasDuration = append(extrasDuration, struct {
+			DurationMinutes int32
+			Quantity        int
+		}{DurationMinutes: extra.DurationMinutes, Quantity: extraInput.Quantity})
+	}
+
+	// Estimate duration using DB-driven parameters.
+	quote.EstimatedHours = estimateDuration(serviceDef, numRooms, numBathrooms, areaSqm, propertyType, hasPets, extrasDuration)
+
+	quote.PetsSurcharge = petsSurcharge(hasPets != nil && *hasPets)
+	return quote, nil
+}
+
 // estimateDuration calculates the estimated job duration in hours based on
 // the service definition parameters and the property details.
 func estimateDuration(serviceDef db.ServiceDefinition, numRooms, numBathrooms int, areaSqm *int, propertyType *string, hasPets *bool, extras []struct {
@@ -7,10 +7,12 @@ package resolver
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"helpmeclean-backend/internal/auth"
 	db "helpmeclean-backend/internal/db/generated"
 	"helpmeclean-backend/internal/graph/model"
+	"helpmeclean-backend/internal/service/invoice"
 	"strings"
 	"time"
 
@@ -152,6 +154,148 @@ func (r *mutationResolver) TransmitInvoiceToEFactura(ctx context.Context, id str
 	return gqlInvoice, nil
 }
 
+// UpdateCompanyInvoiceSettings is the resolver for the updateCompanyInvoiceSettings field.
+func (r *mutationResolver) UpdateCompanyInvoiceSettings(ctx context.Context, input model.CompanyInvoiceSettingsInput) (*model.CompanyInvoiceSettings, error) {
+	claims := auth.GetUserFromContext(ctx)
+	if claims == nil {
+		return nil, fmt.Errorf("not authenticated")
+	}
+	if claims.Role != "company_admin" {
+		return nil, fmt.Errorf("only company admins can change invoicing settings")
+	}
+	company, err := r.Queries.GetCompanyByAdminUserID(ctx, stringToUUID(claims.UserID))
+	if err != nil {
+		return nil, fmt.Errorf("company not found for admin: %w", err)
+	}
+
+	settings, err := r.Queries.UpsertCompanyInvoiceSettings(ctx, db.UpsertCompanyInvoiceSettingsParams{
+		CompanyID:        company.ID,
+		AutoIssueTrigger: strings.ToLower(string(input.AutoIssueTrigger)),
+		AutoEmail:        input.AutoEmail,
+		AutoEfactura:     input.AutoTransmitEFactura,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update invoicing settings: %w", err)
+	}
+	return dbInvoiceSettingsToGQL(settings), nil
+}
+
+// RetryInvoicePipeline is the resolver for the retryInvoicePipeline field.
+func (r *mutationResolver) RetryInvoicePipeline(ctx context.Context, id string) (*model.Invoice, error) {
+	claims := auth.GetUserFromContext(ctx)
+	if claims == nil {
+		return nil, fmt.Errorf("not authenticated")
+	}
+	if claims.Role != "company_admin" && claims.Role != "global_admin" {
+		return nil, fmt.Errorf("not authorized")
+	}
+
+	inv, err := r.Queries.GetInvoiceByID(ctx, stringToUUID(id))
+	if err != nil {
+		return nil, fmt.Errorf("invoice not found: %w", err)
+	}
+	if claims.Role == "company_admin" {
+		company, err := r.Queries.GetCompanyByAdminUserID(ctx, stringToUUID(claims.UserID))
+		if err != nil || company.ID != inv.CompanyID {
+			return nil, fmt.Errorf("invoice not found")
+		}
+	}
+	if inv.InvoiceType != db.InvoiceTypeClientService {
+		return nil, fmt.Errorf("only client invoices have automated steps")
+	}
+
+	inv, err = r.InvoiceService.RetryPipeline(ctx, inv.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retry invoice pipeline: %w", err)
+	}
+	gqlInvoice := dbInvoiceToGQL(inv)
+	r.enrichInvoice(ctx, inv, gqlInvoice)
+	return gqlInvoice, nil
+}
+
+// ConnectAnafEFactura is the resolver for the connectAnafEFactura field.
+func (r *mutationResolver) ConnectAnafEFactura(ctx context.Context, code string) (*model.AnafConnection, error) {
+	claims := auth.GetUserFromContext(ctx)
+	if claims == nil {
+		return nil, fmt.Errorf("not authenticated")
+	}
+	cif, companyID, err := r.anafFiscalParty(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := r.InvoiceService.ConnectANAF(ctx, cif, companyID, stringToUUID(claims.UserID), code)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect ANAF e-factura: %w", err)
+	}
+	return dbAnafTokenToGQL(token), nil
+}
+
+// DisconnectAnafEFactura is the resolver for the disconnectAnafEFactura field.
+func (r *mutationResolver) DisconnectAnafEFactura(ctx context.Context) (*model.AnafConnection, error) {
+	claims := auth.GetUserFromContext(ctx)
+	if claims == nil {
+		return nil, fmt.Errorf("not authenticated")
+	}
+	cif, _, err := r.anafFiscalParty(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.InvoiceService.DisconnectANAF(ctx, cif); err != nil {
+		return nil, fmt.Errorf("failed to disconnect ANAF e-factura: %w", err)
+	}
+	return &model.AnafConnection{Cif: cif, Connected: false}, nil
+}
+
+// CreateInvoiceSeries is the resolver for the createInvoiceSeries field.
+func (r *mutationResolver) CreateInvoiceSeries(ctx context.Context, input model.InvoiceSeriesInput) (*model.InvoiceSeries, error) {
+	claims := auth.GetUserFromContext(ctx)
+	if claims == nil {
+		return nil, fmt.Errorf("not authenticated")
+	}
+	companyID, err := r.accountingScope(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	in := invoice.SeriesInput{
+		DocumentType: strings.ToLower(string(input.DocumentType)),
+		Prefix:       input.Prefix,
+		StartNumber:  1,
+		YearlyReset:  true,
+	}
+	if input.StartNumber != nil {
+		in.StartNumber = int32(*input.StartNumber)
+	}
+	if input.YearlyReset != nil {
+		in.YearlyReset = *input.YearlyReset
+	}
+	series, err := r.InvoiceService.CreateSeries(ctx, companyID, in)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create invoice series: %w", err)
+	}
+	return dbInvoiceSeriesToGQL(series), nil
+}
+
+// RetireInvoiceSeries is the resolver for the retireInvoiceSeries field.
+func (r *mutationResolver) RetireInvoiceSeries(ctx context.Context, id string) (*model.InvoiceSeries, error) {
+	claims := auth.GetUserFromContext(ctx)
+	if claims == nil {
+		return nil, fmt.Errorf("not authenticated")
+	}
+	companyID, err := r.accountingScope(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	series, err := r.InvoiceService.RetireSeries(ctx, companyID, stringToUUID(id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to retire invoice series: %w", err)
+	}
+	return dbInvoiceSeriesToGQL(series), nil
+}
+
 // GenerateCommissionInvoice is the resolver for the generateCommissionInvoice field.
 func (r *mutationResolver) GenerateCommissionInvoice(ctx context.Context, payoutID string) (*model.Invoice, error) {
 	claims := auth.GetUserFromContext(ctx)
@@ -168,12 +312,24 @@ func (r *mutationResolver) GenerateCommissionInvoice(ctx context.Context, payout
 		return nil, fmt.Errorf("payout not found: %w", err)
 	}
 
+	// Bookings are invoiced once, monthly or with their payout.
+	lineItems, err := r.Queries.ListPayoutLineItems(ctx, payout.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load payout line items: %w", err)
+	}
+	for _, li := range lineItems {
+		if inv, err := r.Queries.GetCommissionInvoiceByBooking(ctx, li.BookingID); err == nil {
+			return nil, fmt.Errorf("commission of the payout's bookings is already invoiced on %s", inv.InvoiceNumber.String)
+		}
+	}
+
 	periodFrom := dateToString(payout.PeriodFrom)
 	periodTo := dateToString(payout.PeriodTo)
 
 	inv, err := r.InvoiceService.GenerateCommissionInvoice(
 		ctx,
 		payout.CompanyID,
+		payout.ID,
 		payout.Amount,
 		int(payout.BookingCount),
 		periodFrom,
@@ -182,12 +338,40 @@ func (r *mutationResolver) GenerateCommissionInvoice(ctx context.Context, payout
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate commission invoice: %w", err)
 	}
+	r.postInvoiceToLedger(ctx, inv)
 
 	gqlInvoice := dbInvoiceToGQL(inv)
 	r.enrichInvoice(ctx, inv, gqlInvoice)
 	return gqlInvoice, nil
 }
 
+// CloseCommissionMonth is the resolver for the closeCommissionMonth field.
+func (r *mutationResolver) CloseCommissionMonth(ctx context.Context, month string) (*model.CommissionMonth, error) {
+	claims := auth.GetUserFromContext(ctx)
+	if claims == nil {
+		return nil, fmt.Errorf("not authenticated")
+	}
+	if claims.Role != "global_admin" {
+		return nil, fmt.Errorf("only global admins can close commission months")
+	}
+
+	date, err := invoice.ParseMonth(month)
+	if err != nil {
+		return nil, err
+	}
+	// Companies that failed are reported in the error; the others keep
+	// their invoices, and closing the month again retries the rest.
+	if _, err := r.closeCommissionMonth(ctx, date); err != nil {
+		return nil, err
+	}
+
+	result, err := r.InvoiceService.PreviewCommissionMonth(ctx, date)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load commission month: %w", err)
+	}
+	return r.commissionMonthToGQL(ctx, result), nil
+}
+
 // GenerateCreditNote is the resolver for the generateCreditNote field.
 func (r *mutationResolver) GenerateCreditNote(ctx context.Context, invoiceID string, amount int, reason string) (*model.Invoice, error) {
 	claims := auth.GetUserFromContext(ctx)
@@ -202,12 +386,42 @@ func (r *mutationResolver) GenerateCreditNote(ctx context.Context, invoiceID str
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate credit note: %w", err)
 	}
+	r.postInvoiceToLedger(ctx, inv)
 
 	gqlInvoice := dbInvoiceToGQL(inv)
 	r.enrichInvoice(ctx, inv, gqlInvoice)
 	return gqlInvoice, nil
 }
 
+// AddVatRate is the resolver for the addVatRate field.
+func (r *mutationResolver) AddVatRate(ctx context.Context, input model.VatRateInput) (*model.VatRate, error) {
+	claims := auth.GetUserFromContext(ctx)
+	if claims == nil {
+		return nil, fmt.Errorf("not authenticated")
+	}
+	if claims.Role != "global_admin" {
+		return nil, fmt.Errorf("only global admins can change VAT rates")
+	}
+
+	validFrom, err := time.Parse("2006-01-02", input.ValidFrom)
+	if err != nil {
+		return nil, fmt.Errorf("invalid validFrom date: %w", err)
+	}
+	in := invoice.VATRateInput{
+		Category:  strings.ToLower(string(input.Category)),
+		RatePct:   int32(input.RatePct),
+		ValidFrom: validFrom,
+	}
+	if input.Description != nil {
+		in.Description = *input.Description
+	}
+	rate, err := r.InvoiceService.AddVATRate(ctx, in)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add VAT rate: %w", err)
+	}
+	return dbVatRateToGQL(rate), nil
+}
+
 // MyBillingProfile is the resolver for the myBillingProfile field.
 func (r *queryResolver) MyBillingProfile(ctx context.Context) (*model.ClientBillingProfile, error) {
 	claims := auth.GetUserFromContext(ctx)
@@ -303,6 +517,98 @@ func (r *queryResolver) InvoiceDetail(ctx context.Context, id string) (*model.In
 	return gqlInvoice, nil
 }
 
+// InvoiceEFacturaXML is the resolver for the invoiceEFacturaXml field.
+func (r *queryResolver) InvoiceEFacturaXML(ctx context.Context, id string) (string, error) {
+	claims := auth.GetUserFromContext(ctx)
+	if claims == nil {
+		return "", fmt.Errorf("not authenticated")
+	}
+
+	inv, err := r.Queries.GetInvoiceByID(ctx, stringToUUID(id))
+	if err != nil {
+		return "", fmt.Errorf("invoice not found: %w", err)
+	}
+
+	switch claims.Role {
+	case "global_admin":
+	case "company_admin":
+		company, err := r.Queries.GetCompanyByAdminUserID(ctx, stringToUUID(claims.UserID))
+		if err != nil || company.ID != inv.CompanyID {
+			return "", fmt.Errorf("not authorized to view this invoice")
+		}
+	default:
+		if inv.ClientUserID != stringToUUID(claims.UserID) {
+			return "", fmt.Errorf("not authorized to view this invoice")
+		}
+	}
+
+	xml, err := r.InvoiceService.EFacturaXML(ctx, inv.ID)
+	if err != nil {
+		return "", fmt.Errorf("failed to render e-factura XML: %w", err)
+	}
+	return string(xml), nil
+}
+
+// AnafConnection is the resolver for the anafConnection field.
+func (r *queryResolver) AnafConnection(ctx context.Context) (*model.AnafConnection, error) {
+	claims := auth.GetUserFromContext(ctx)
+	if claims == nil {
+		return nil, fmt.Errorf("not authenticated")
+	}
+	cif, _, err := r.anafFiscalParty(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := r.InvoiceService.ANAFConnection(ctx, cif)
+	if errors.Is(err, invoice.ErrANAFNotConnected) {
+		return &model.AnafConnection{Cif: cif, Connected: false}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load ANAF connection: %w", err)
+	}
+	return dbAnafTokenToGQL(token), nil
+}
+
+// AnafAuthorizationURL is the resolver for the anafAuthorizationUrl field.
+func (r *queryResolver) AnafAuthorizationURL(ctx context.Context) (*model.AnafAuthorization, error) {
+	claims := auth.GetUserFromContext(ctx)
+	if claims == nil {
+		return nil, fmt.Errorf("not authenticated")
+	}
+	if _, _, err := r.anafFiscalParty(ctx, claims); err != nil {
+		return nil, err
+	}
+
+	authURL, state, err := r.InvoiceService.ANAFAuthorizationURL()
+	if err != nil {
+		return nil, err
+	}
+	return &model.AnafAuthorization{URL: authURL, State: state}, nil
+}
+
+// InvoiceSeries is the resolver for the invoiceSeries field.
+func (r *queryResolver) InvoiceSeries(ctx context.Context) ([]*model.InvoiceSeries, error) {
+	claims := auth.GetUserFromContext(ctx)
+	if claims == nil {
+		return nil, fmt.Errorf("not authenticated")
+	}
+	companyID, err := r.accountingScope(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	series, err := r.InvoiceService.ListSeries(ctx, companyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list invoice series: %w", err)
+	}
+	result := make([]*model.InvoiceSeries, len(series))
+	for i, s := range series {
+		result[i] = dbInvoiceSeriesToGQL(s)
+	}
+	return result, nil
+}
+
 // CompanyInvoices is the resolver for the companyInvoices field.
 func (r *queryResolver) CompanyInvoices(ctx context.Context, status *model.InvoiceStatus, first *int, after *string) (*model.InvoiceConnection, error) {
 	claims := auth.GetUserFromContext(ctx)
@@ -377,6 +683,27 @@ func (r *queryResolver) CompanyInvoices(ctx context.Context, status *model.Invoi
 	}, nil
 }
 
+// CompanyInvoiceSettings is the resolver for the companyInvoiceSettings field.
+func (r *queryResolver) CompanyInvoiceSettings(ctx context.Context) (*model.CompanyInvoiceSettings, error) {
+	claims := auth.GetUserFromContext(ctx)
+	if claims == nil {
+		return nil, fmt.Errorf("not authenticated")
+	}
+	if claims.Role != "company_admin" {
+		return nil, fmt.Errorf("only company admins can view invoicing settings")
+	}
+	company, err := r.Queries.GetCompanyByAdminUserID(ctx, stringToUUID(claims.UserID))
+	if err != nil {
+		return nil, fmt.Errorf("company not found for admin: %w", err)
+	}
+
+	settings, err := r.InvoiceService.Settings(ctx, company.ID)
+	if err != nil {
+		return nil, err
+	}
+	return dbInvoiceSettingsToGQL(settings), nil
+}
+
 // AllInvoices is the resolver for the allInvoices field.
 func (r *queryResolver) AllInvoices(ctx context.Context, typeArg *model.InvoiceType, status *model.InvoiceStatus, companyID *string, first *int, after *string) (*model.InvoiceConnection, error) {
 	claims := auth.GetUserFromContext(ctx)
@@ -536,3 +863,42 @@ func (r *queryResolver) InvoiceAnalytics(ctx context.Context, from string, to st
 		ByType:      byType,
 	}, nil
 }
+
+// CommissionMonthPreview is the resolver for the commissionMonthPreview field.
+func (r *queryResolver) CommissionMonthPreview(ctx context.Context, month string) (*model.CommissionMonth, error) {
+	claims := auth.GetUserFromContext(ctx)
+	if claims == nil {
+		return nil, fmt.Errorf("not authenticated")
+	}
+	if claims.Role != "global_admin" {
+		return nil, fmt.Errorf("only global admins can preview commission months")
+	}
+
+	date, err := invoice.ParseMonth(month)
+	if err != nil {
+		return nil, err
+	}
+	result, err := r.InvoiceService.PreviewCommissionMonth(ctx, date)
+	if err != nil {
+		return nil, fmt.Errorf("failed to preview commission month: %w", err)
+	}
+	return r.commissionMonthToGQL(ctx, result), nil
+}
+
+// VatRates is the resolver for the vatRates field.
+func (r *queryResolver) VatRates(ctx context.Context) ([]*model.VatRate, error) {
+	claims := auth.GetUserFromContext(ctx)
+	if claims == nil {
+		return nil, fmt.Errorf("not authenticated")
+	}
+
+	rates, err := r.InvoiceService.ListVATRates(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list VAT rates: %w", err)
+	}
+	result := make([]*model.VatRate, len(rates))
+	for i, rate := range rates {
+		result[i] = dbVatRateToGQL(rate)
+	}
+	return result, nil
+}
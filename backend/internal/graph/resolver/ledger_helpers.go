@@ -0,0 +1,25 @@
+package resolver
+
+import (
+	"context"
+	"log"
+
+	db "helpmeclean-backend/internal/db/generated"
+	"helpmeclean-backend/internal/service/ledger"
+)
+
+// postInvoiceToLedger posts a commission invoice or a credit note on one to
+// the ledger. Client service invoices are the companies' own sales and are
+// not booked. A failure is only logged; the invoice stays issued.
+func (r *Resolver) postInvoiceToLedger(ctx context.Context, inv db.Invoice) {
+	if inv.InvoiceType != db.InvoiceTypePlatformCommission {
+		return
+	}
+	entry := ledger.CommissionInvoiceEntry(inv.ID, inv.CompanyID, int64(inv.VatAmount), inv.CreatedAt.Time)
+	if inv.Status == db.InvoiceStatusCreditNote {
+		entry = ledger.CreditNoteEntry(inv.ID, inv.CompanyID, -int64(inv.SubtotalAmount), -int64(inv.VatAmount), inv.CreatedAt.Time)
+	}
+	if _, err := r.LedgerService.Post(ctx, entry); err != nil {
+		log.Printf("[LEDGER] Failed to post invoice %s: %v", uuidToString(inv.ID), err)
+	}
+}
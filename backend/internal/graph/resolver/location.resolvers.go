@@ -13,8 +13,8 @@ import (
 	"helpmeclean-backend/internal/graph/model"
 	"helpmeclean-backend/internal/service/matching"
 	"log"
+	"sort"
 	"strings"
-	"time"
 
 	"github.com/jackc/pgx/v5/pgtype"
 )
@@ -366,47 +366,15 @@ func (r *queryResolver) SuggestCleaners(ctx context.Context, cityID string, area
 
 	// Load admin-tunable matchmaking config.
 	config := loadMatchConfig(ctx, r.Queries)
-	bufferMicros := config.BufferMicros()
 
 	areaUUID := stringToUUID(areaID)
 	jobDurationMicros := int64(estimatedDurationHours * float64(matching.HourMicros))
 
 	// Step 1: Parse ALL time slots with date context.
-	datedSlots := make([]matching.DatedTimeSlot, len(timeSlots))
-	uniqueDates := map[string]time.Time{} // date string -> parsed time
-
-	for i, ts := range timeSlots {
-		d, err := time.Parse("2006-01-02", ts.Date)
-		if err != nil {
-			return nil, fmt.Errorf("invalid date in timeSlots[%d] (use YYYY-MM-DD): %w", i, err)
-		}
-		datedSlots[i] = matching.DatedTimeSlot{
-			Date:        ts.Date,
-			DayOfWeek:   int(d.Weekday()),
-			StartMicros: matching.HHMMToMicros(ts.StartTime),
-			EndMicros:   matching.HHMMToMicros(ts.EndTime),
-			SlotIndex:   i,
-		}
-		uniqueDates[ts.Date] = d
-	}
-
-	// Compute the week range (Mon-Sun) spanning all requested dates for workload query.
-	var weekStart, weekEnd time.Time
-	for _, d := range uniqueDates {
-		if weekStart.IsZero() || d.Before(weekStart) {
-			weekStart = d
-		}
-		if weekEnd.IsZero() || d.After(weekEnd) {
-			weekEnd = d
-		}
+	datedSlots, uniqueDates, err := parseDatedTimeSlots(timeSlots)
+	if err != nil {
+		return nil, err
 	}
-	// Extend to full Monday-Sunday week of the earliest date.
-	offset := (int(weekStart.Weekday()) + 6) % 7 // Monday = 0
-	weekStart = weekStart.AddDate(0, 0, -offset)
-	weekEnd = weekStart.AddDate(0, 0, 6)
-
-	weekStartPG := pgtype.Date{Time: weekStart, Valid: true}
-	weekEndPG := pgtype.Date{Time: weekEnd, Valid: true}
 
 	// Step 2: Find cleaners matching this area.
 	candidates, err := r.Queries.FindMatchingCleaners(ctx, areaUUID)
@@ -419,273 +387,293 @@ func (r *queryResolver) SuggestCleaners(ctx context.Context, cityID string, area
 		return []*model.CleanerSuggestion{}, nil
 	}
 
+	// Step 3: For each candidate, evaluate ALL dates.
+	evaluations := r.evaluateCleaners(ctx, candidates, datedSlots, uniqueDates, jobDurationMicros, config, pgtype.UUID{})
+
+	// Score breakdowns are for support staff and company admins only.
+	claims := auth.GetUserFromContext(ctx)
+	showBreakdown := claims != nil && (claims.Role == "global_admin" || claims.Role == "company_admin")
+
 	type scoredSuggestion struct {
 		suggestion *model.CleanerSuggestion
 		score      float64
 	}
 	var available []scoredSuggestion
 	var unavailable []scoredSuggestion
-
-	// Step 3: For each candidate, evaluate ALL dates.
-	for _, candidate := range candidates {
-		cleaner, err := r.Queries.GetCleanerByID(ctx, candidate.ID)
-		if err != nil {
-			continue
+	for _, e := range evaluations {
+		if !showBreakdown {
+			e.suggestion.ScoreBreakdown = nil
 		}
-		company, err := r.Queries.GetCompanyByID(ctx, candidate.CompanyID)
-		if err != nil {
-			continue
+		scored := scoredSuggestion{suggestion: e.suggestion, score: e.score}
+		if e.found {
+			available = append(available, scored)
+		} else {
+			unavailable = append(unavailable, scored)
 		}
+	}
 
-		// Load company schedules once for this candidate.
-		companySchedules, err := r.Queries.ListCompanyWorkSchedule(ctx, candidate.CompanyID)
-		if err != nil {
-			continue
-		}
-		companyScheduleByDay := map[int32]*db.CompanyWorkSchedule{}
-		for i := range companySchedules {
-			companyScheduleByDay[companySchedules[i].DayOfWeek] = &companySchedules[i]
+	// Sort each group by score DESC.
+	sortSuggestions := func(s []scoredSuggestion) {
+		for i := 0; i < len(s); i++ {
+			for j := i + 1; j < len(s); j++ {
+				if s[j].score > s[i].score {
+					s[i], s[j] = s[j], s[i]
+				}
+			}
 		}
+	}
+	sortSuggestions(available)
+	sortSuggestions(unavailable)
 
-		// Load cleaner weekly availability once.
-		weeklySlots, err := r.Queries.ListCleanerAvailability(ctx, candidate.ID)
-		if err != nil {
-			continue
+	// Build final result: available first, backfill with unavailable only if needed.
+	var result []*model.CleanerSuggestion
+	limit := config.MaxResults
+
+	for _, s := range available {
+		if len(result) >= limit {
+			break
 		}
+		result = append(result, s.suggestion)
+	}
 
-		// Load cleaner date overrides for the full date range.
-		var overridesDateFrom, overridesDateTo pgtype.Date
-		for _, d := range uniqueDates {
-			pgd := pgtype.Date{Time: d, Valid: true}
-			if !overridesDateFrom.Valid || d.Before(overridesDateFrom.Time) {
-				overridesDateFrom = pgd
-			}
-			if !overridesDateTo.Valid || d.After(overridesDateTo.Time) {
-				overridesDateTo = pgd
+	// Only show unavailable workers if fewer than MinAvailableCount available.
+	if len(result) < config.MinAvailableCount {
+		for _, s := range unavailable {
+			if len(result) >= limit {
+				break
 			}
+			result = append(result, s.suggestion)
 		}
-		overrides, err := r.Queries.ListCleanerDateOverrides(ctx, db.ListCleanerDateOverridesParams{
-			CleanerID:      candidate.ID,
-			OverrideDate:   overridesDateFrom,
-			OverrideDate_2: overridesDateTo,
-		})
-		if err != nil {
-			continue
-		}
-		overridesByDate := map[string]*db.CleanerDateOverride{}
-		for i := range overrides {
-			dateStr := overrides[i].OverrideDate.Time.Format("2006-01-02")
-			overridesByDate[dateStr] = &overrides[i]
-		}
-
-		// Build DateAvailability for each unique date.
-		var dateAvails []matching.DateAvailability
+	}
 
-		for dateStr, d := range uniqueDates {
-			dayOfWeek := int32(d.Weekday())
+	log.Printf("[MATCHMAKING] areaID=%s: %d candidates, %d available, %d unavailable, returning %d",
+		areaID, len(candidates), len(available), len(unavailable), len(result))
 
-			// Check company schedule for this day.
-			compDaySch := companyScheduleByDay[dayOfWeek]
-			if compDaySch != nil && !compDaySch.IsWorkDay {
-				continue // company doesn't work this day
-			}
+	return result, nil
+}
 
-			// 4-tier availability cascade for this specific date.
-			var availStart, availEnd int64
-			skipDate := false
+// SuggestTeams is the resolver for the suggestTeams field.
+// It splits the labour hours across the smallest team allowed by
+// max_booking_hours (or the requested size) and, per company, finds a window
+// in which that many of its cleaners are free at the same time.
+func (r *queryResolver) SuggestTeams(ctx context.Context, cityID string, areaID string, timeSlots []*model.TimeSlotInput, estimatedDurationHours float64, teamSize *int) ([]*model.TeamSuggestion, error) {
+	if len(timeSlots) == 0 {
+		return nil, fmt.Errorf("at least one time slot is required")
+	}
 
-			// Tier 1: date override.
-			if override, ok := overridesByDate[dateStr]; ok {
-				if !override.IsAvailable {
-					continue // cleaner explicitly unavailable on this date
-				}
-				availStart = override.StartTime.Microseconds
-				availEnd = override.EndTime.Microseconds
-			} else {
-				// Tier 2: weekly slots.
-				found := false
-				for _, slot := range weeklySlots {
-					if slot.DayOfWeek == dayOfWeek && boolVal(slot.IsAvailable) {
-						availStart = slot.StartTime.Microseconds
-						availEnd = slot.EndTime.Microseconds
-						found = true
-						break
-					}
-				}
-				if !found {
-					// Tier 3: company schedule.
-					if compDaySch != nil && compDaySch.IsWorkDay {
-						availStart = compDaySch.StartTime.Microseconds
-						availEnd = compDaySch.EndTime.Microseconds
-					} else if compDaySch == nil {
-						// Tier 4: default 08:00-17:00.
-						availStart = 8 * matching.HourMicros
-						availEnd = 17 * matching.HourMicros
-					} else {
-						skipDate = true
-					}
-				}
-			}
-			if skipDate {
-				continue
-			}
+	config := loadMatchConfig(ctx, r.Queries)
+	maxHours, maxTeamSize := loadTeamLimits(ctx, r.Queries)
 
-			// Load existing bookings for this date.
-			pgDate := pgtype.Date{Time: d, Valid: true}
-			existingBookings, err := r.Queries.ListCleanerBookingsForDate(ctx, db.ListCleanerBookingsForDateParams{
-				CleanerID:     candidate.ID,
-				ScheduledDate: pgDate,
-			})
-			if err != nil {
-				continue
-			}
+	requested := 0
+	if teamSize != nil {
+		requested = *teamSize
+	}
+	size, windowHours := matching.TeamDuration(estimatedDurationHours, maxHours, requested)
+	if size > maxTeamSize {
+		return nil, fmt.Errorf("job needs %d cleaners, more than the maximum team size of %d", size, maxTeamSize)
+	}
+	jobDurationMicros := int64(windowHours * float64(matching.HourMicros))
 
-			bookingSlots := make([]matching.BookingSlot, len(existingBookings))
-			for i, eb := range existingBookings {
-				startMicros := eb.ScheduledStartTime.Microseconds
-				durationHours := numericToFloat(eb.EstimatedDurationHours)
-				endMicros := startMicros + int64(durationHours*float64(matching.HourMicros))
-				bookingSlots[i] = matching.BookingSlot{
-					StartMicros: startMicros,
-					EndMicros:   endMicros,
-				}
-			}
+	datedSlots, uniqueDates, err := parseDatedTimeSlots(timeSlots)
+	if err != nil {
+		return nil, err
+	}
+	weekStartPG, weekEndPG := matchingWeekRange(uniqueDates)
 
-			freeIntervals := matching.ComputeFreeIntervals(availStart, availEnd, bookingSlots, bufferMicros)
+	candidates, err := r.Queries.FindMatchingCleaners(ctx, stringToUUID(areaID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find matching cleaners: %w", err)
+	}
 
-			dateAvails = append(dateAvails, matching.DateAvailability{
-				Date:          dateStr,
-				AvailStart:    availStart,
-				AvailEnd:      availEnd,
-				FreeIntervals: freeIntervals,
-				BookingCount:  len(existingBookings),
-			})
+	// Group candidates by company: a team never mixes companies.
+	type companyPool struct {
+		companyID  pgtype.UUID
+		candidates []matching.TeamCandidate
+		rows       map[string]db.FindMatchingCleanersRow
+	}
+	var companyOrder []string
+	pools := map[string]*companyPool{}
+	for _, candidate := range candidates {
+		dateAvails, err := r.cleanerDateAvailabilities(ctx, candidate.ID, candidate.CompanyID, uniqueDates, config.BufferMicros(), pgtype.UUID{})
+		if err != nil {
+			continue
 		}
-
-		// Find the best date+time placement across all dates.
-		placement := matching.FindBestPlacementAcrossDates(dateAvails, datedSlots, jobDurationMicros, config)
-
-		// Load weekly workload for scoring.
 		weekBookingCount, _ := r.Queries.CountCleanerBookingsInDateRange(ctx, db.CountCleanerBookingsInDateRangeParams{
 			CleanerID:       candidate.ID,
 			ScheduledDate:   weekStartPG,
 			ScheduledDate_2: weekEndPG,
 		})
 
-		// Determine day booking count for the matched date.
-		dayBookingCount := 0
-		if placement.Found {
-			for _, da := range dateAvails {
-				if da.Date == placement.Date {
-					dayBookingCount = da.BookingCount
-					break
-				}
-			}
+		companyKey := uuidToString(candidate.CompanyID)
+		pool, ok := pools[companyKey]
+		if !ok {
+			pool = &companyPool{companyID: candidate.CompanyID, rows: map[string]db.FindMatchingCleanersRow{}}
+			pools[companyKey] = pool
+			companyOrder = append(companyOrder, companyKey)
 		}
-
-		// Calculate match score with workload balancing.
-		rating := numericToFloat(candidate.RatingAvg)
-		jobs := 0
-		if candidate.TotalJobsCompleted.Valid {
-			jobs = int(candidate.TotalJobsCompleted.Int32)
-		}
-
-		score := matching.ComputeMatchScore(matching.ScoreInput{
-			RatingAvg:        rating,
-			TotalJobsDone:    jobs,
-			IsAreaMatch:      true,
-			PlacementFound:   placement.Found,
-			GapScoreH:        placement.GapScoreH,
-			DayBookingCount:  dayBookingCount,
+		cleanerKey := uuidToString(candidate.ID)
+		pool.candidates = append(pool.candidates, matching.TeamCandidate{
+			ID:               cleanerKey,
+			DateAvails:       dateAvails,
 			WeekBookingCount: int(weekBookingCount),
-			Config:           config,
 		})
+		pool.rows[cleanerKey] = candidate
+	}
 
-		// Build suggestion.
-		profile, err := r.cleanerWithCompany(ctx, cleaner)
-		if err != nil {
+	var result []*model.TeamSuggestion
+	for _, companyKey := range companyOrder {
+		pool := pools[companyKey]
+		placement := matching.FindTeamPlacement(pool.candidates, datedSlots, size, jobDurationMicros, config)
+		if !placement.Found {
 			continue
 		}
 
-		availStatus := "available"
-		if !placement.Found {
-			availStatus = "unavailable"
+		company, err := r.Queries.GetCompanyByID(ctx, pool.companyID)
+		if err != nil {
+			continue
 		}
 
-		suggestion := &model.CleanerSuggestion{
-			Cleaner:            profile,
-			Company:            dbCompanyToGQL(company),
-			AvailabilityStatus: availStatus,
-			MatchScore:         score,
-		}
+		// Team score is the average of the members' individual match scores.
+		var members []*model.CleanerProfile
+		totalScore := 0.0
+		for _, memberID := range placement.MemberIDs {
+			row := pool.rows[memberID]
+			cleaner, err := r.Queries.GetCleanerByID(ctx, row.ID)
+			if err != nil {
+				continue
+			}
+			profile, err := r.cleanerWithCompany(ctx, cleaner)
+			if err != nil {
+				continue
+			}
+			members = append(members, profile)
 
-		if placement.Found {
-			startStr := matching.MicrosToHHMM(placement.StartMicros)
-			endStr := matching.MicrosToHHMM(placement.EndMicros)
-			slotIdx := placement.SlotIndex
-			dateStr := placement.Date
-			suggestion.SuggestedStartTime = &startStr
-			suggestion.SuggestedEndTime = &endStr
-			suggestion.SuggestedSlotIndex = &slotIdx
-			suggestion.SuggestedDate = &dateStr
-
-			// Set availableFrom/To from the matched date's availability.
-			for _, da := range dateAvails {
-				if da.Date == placement.Date {
-					availFromStr := microsecondsToHHMM(da.AvailStart)
-					availToStr := microsecondsToHHMM(da.AvailEnd)
-					suggestion.AvailableFrom = &availFromStr
-					suggestion.AvailableTo = &availToStr
-					break
+			dayBookingCount, weekBookingCount := 0, 0
+			for _, tc := range pool.candidates {
+				if tc.ID != memberID {
+					continue
+				}
+				weekBookingCount = tc.WeekBookingCount
+				for _, da := range tc.DateAvails {
+					if da.Date == placement.Date {
+						dayBookingCount = da.BookingCount
+					}
 				}
 			}
+			jobs := 0
+			if row.TotalJobsCompleted.Valid {
+				jobs = int(row.TotalJobsCompleted.Int32)
+			}
+			totalScore += matching.ComputeMatchScore(matching.ScoreInput{
+				RatingAvg:        numericToFloat(row.RatingAvg),
+				TotalJobsDone:    jobs,
+				IsAreaMatch:      true,
+				PlacementFound:   true,
+				GapScoreH:        placement.GapScoreH,
+				DayBookingCount:  dayBookingCount,
+				WeekBookingCount: weekBookingCount,
+				Config:           config,
+			})
 		}
-
-		scored := scoredSuggestion{suggestion: suggestion, score: score}
-		if placement.Found {
-			available = append(available, scored)
-		} else {
-			unavailable = append(unavailable, scored)
+		if len(members) != size {
+			continue
 		}
+
+		result = append(result, &model.TeamSuggestion{
+			Company:            dbCompanyToGQL(company),
+			Members:            members,
+			TeamSize:           size,
+			DurationHours:      windowHours,
+			SuggestedDate:      placement.Date,
+			SuggestedStartTime: matching.MicrosToHHMM(placement.StartMicros),
+			SuggestedEndTime:   matching.MicrosToHHMM(placement.EndMicros),
+			SuggestedSlotIndex: placement.SlotIndex,
+			MatchScore:         totalScore / float64(size),
+		})
 	}
 
-	// Sort each group by score DESC.
-	sortSuggestions := func(s []scoredSuggestion) {
-		for i := 0; i < len(s); i++ {
-			for j := i + 1; j < len(s); j++ {
-				if s[j].score > s[i].score {
-					s[i], s[j] = s[j], s[i]
-				}
-			}
-		}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].MatchScore > result[j].MatchScore })
+	if len(result) > config.MaxResults {
+		result = result[:config.MaxResults]
 	}
-	sortSuggestions(available)
-	sortSuggestions(unavailable)
 
-	// Build final result: available first, backfill with unavailable only if needed.
-	var result []*model.CleanerSuggestion
-	limit := config.MaxResults
+	log.Printf("[MATCHMAKING] teams areaID=%s: size=%d window=%.1fh, %d candidates, %d companies, returning %d",
+		areaID, size, windowHours, len(candidates), len(companyOrder), len(result))
 
-	for _, s := range available {
-		if len(result) >= limit {
-			break
-		}
-		result = append(result, s.suggestion)
+	return result, nil
+}
+
+// ExplainMatching is the resolver for the explainMatching field.
+func (r *queryResolver) ExplainMatching(ctx context.Context, bookingID string, areaID string) (*model.MatchingExplanation, error) {
+	claims := auth.GetUserFromContext(ctx)
+	if claims == nil || claims.Role != "global_admin" {
+		return nil, fmt.Errorf("admin access required")
 	}
 
-	// Only show unavailable workers if fewer than MinAvailableCount available.
-	if len(result) < config.MinAvailableCount {
-		for _, s := range unavailable {
-			if len(result) >= limit {
-				break
-			}
-			result = append(result, s.suggestion)
+	booking, err := r.Queries.GetBookingByID(ctx, stringToUUID(bookingID))
+	if err != nil {
+		return nil, fmt.Errorf("booking not found: %w", err)
+	}
+
+	// Rebuild the client's time slots; legacy bookings only have a start time.
+	durationHours := numericToFloat(booking.EstimatedDurationHours)
+	var timeSlots []*model.TimeSlotInput
+	if slots, err := r.Queries.ListBookingTimeSlots(ctx, booking.ID); err == nil {
+		for _, slot := range slots {
+			timeSlots = append(timeSlots, &model.TimeSlotInput{
+				Date:      slot.SlotDate.Time.Format("2006-01-02"),
+				StartTime: microsecondsToHHMM(slot.StartTime.Microseconds),
+				EndTime:   microsecondsToHHMM(slot.EndTime.Microseconds),
+			})
 		}
 	}
+	if len(timeSlots) == 0 {
+		start := booking.ScheduledStartTime.Microseconds
+		timeSlots = append(timeSlots, &model.TimeSlotInput{
+			Date:      booking.ScheduledDate.Time.Format("2006-01-02"),
+			StartTime: microsecondsToHHMM(start),
+			EndTime:   microsecondsToHHMM(start + int64(durationHours*float64(matching.HourMicros))),
+		})
+	}
+	datedSlots, uniqueDates, err := parseDatedTimeSlots(timeSlots)
+	if err != nil {
+		return nil, err
+	}
 
-	log.Printf("[MATCHMAKING] areaID=%s: %d candidates, %d available, %d unavailable, returning %d",
-		areaID, len(candidates), len(available), len(unavailable), len(result))
+	candidates, err := r.Queries.FindMatchingCleaners(ctx, stringToUUID(areaID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find matching cleaners: %w", err)
+	}
+
+	config := loadMatchConfig(ctx, r.Queries)
+	jobDurationMicros := int64(durationHours * float64(matching.HourMicros))
+	evaluations := r.evaluateCleaners(ctx, candidates, datedSlots, uniqueDates, jobDurationMicros, config, booking.ID)
 
+	// Available first, then by score DESC, as suggestCleaners ranks them.
+	sort.SliceStable(evaluations, func(i, j int) bool {
+		if evaluations[i].found != evaluations[j].found {
+			return evaluations[i].found
+		}
+		return evaluations[i].score > evaluations[j].score
+	})
+
+	gqlBooking := dbBookingToGQL(booking)
+	r.enrichBooking(ctx, booking, gqlBooking)
+	result := &model.MatchingExplanation{
+		Booking:           gqlBooking,
+		DurationHours:     durationHours,
+		BufferMinutes:     config.BufferMinutes,
+		MaxJobsPerDay:     config.MaxJobsPerDay,
+		LoadBalanceWeight: config.LoadBalanceWeight,
+		Candidates:        make([]*model.CleanerSuggestion, len(evaluations)),
+	}
+	for i, e := range evaluations {
+		result.Candidates[i] = e.suggestion
+		if booking.CleanerID.Valid && e.cleanerID == booking.CleanerID {
+			rank := i + 1
+			result.AssignedCleanerRank = &rank
+		}
+	}
 	return result, nil
 }
 
@@ -2,11 +2,16 @@ package resolver
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"strconv"
+	"time"
 
 	db "helpmeclean-backend/internal/db/generated"
+	"helpmeclean-backend/internal/graph/model"
 	"helpmeclean-backend/internal/service/matching"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 // loadMatchConfig reads matchmaking settings from platform_settings KV table,
@@ -40,3 +45,370 @@ func loadMatchConfig(ctx context.Context, queries *db.Queries) matching.MatchCon
 
 	return config
 }
+
+// loadTeamLimits reads the per-cleaner booking length limit and the maximum
+// team size from platform_settings. Jobs longer than maxHours are split
+// across a team; teams larger than maxTeamSize are rejected.
+func loadTeamLimits(ctx context.Context, queries *db.Queries) (maxHours float64, maxTeamSize int) {
+	maxHours = 12
+	maxTeamSize = 4
+	if v, err := queries.GetPlatformSetting(ctx, "max_booking_hours"); err == nil {
+		if f, err := strconv.ParseFloat(v.Value, 64); err == nil && f > 0 {
+			maxHours = f
+		}
+	}
+	if v, err := queries.GetPlatformSetting(ctx, "max_team_size"); err == nil {
+		if n, err := strconv.Atoi(v.Value); err == nil && n > 0 {
+			maxTeamSize = n
+		}
+	}
+	return maxHours, maxTeamSize
+}
+
+// parseDatedTimeSlots converts the client's preferred time slots into dated
+// matching slots and returns the set of distinct dates they cover.
+func parseDatedTimeSlots(timeSlots []*model.TimeSlotInput) ([]matching.DatedTimeSlot, map[string]time.Time, error) {
+	datedSlots := make([]matching.DatedTimeSlot, len(timeSlots))
+	uniqueDates := map[string]time.Time{} // date string -> parsed time
+
+	for i, ts := range timeSlots {
+		d, err := time.Parse("2006-01-02", ts.Date)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid date in timeSlots[%d] (use YYYY-MM-DD): %w", i, err)
+		}
+		datedSlots[i] = matching.DatedTimeSlot{
+			Date:        ts.Date,
+			DayOfWeek:   int(d.Weekday()),
+			StartMicros: matching.HHMMToMicros(ts.StartTime),
+			EndMicros:   matching.HHMMToMicros(ts.EndTime),
+			SlotIndex:   i,
+		}
+		uniqueDates[ts.Date] = d
+	}
+	return datedSlots, uniqueDates, nil
+}
+
+// matchingWeekRange returns the Monday-Sunday week of the earliest date, used
+// for the weekly workload query.
+func matchingWeekRange(dates map[string]time.Time) (pgtype.Date, pgtype.Date) {
+	var weekStart time.Time
+	for _, d := range dates {
+		if weekStart.IsZero() || d.Before(weekStart) {
+			weekStart = d
+		}
+	}
+	offset := (int(weekStart.Weekday()) + 6) % 7 // Monday = 0
+	weekStart = weekStart.AddDate(0, 0, -offset)
+	weekEnd := weekStart.AddDate(0, 0, 6)
+	return pgtype.Date{Time: weekStart, Valid: true}, pgtype.Date{Time: weekEnd, Valid: true}
+}
+
+// cleanerDateAvailabilities computes a cleaner's free intervals on each of the
+// given dates using the availability cascade: date override, then weekly
+// availability, then company work schedule, then the default 08:00-17:00.
+// Dates the company does not work (including its closure days and public
+// holidays it has not opted in to) or the cleaner is off are omitted. The
+// booking excludeBookingID, if valid, is not counted as busy time.
+func (r *Resolver) cleanerDateAvailabilities(ctx context.Context, cleanerID, companyID pgtype.UUID, dates map[string]time.Time, bufferMicros int64, excludeBookingID pgtype.UUID) ([]matching.DateAvailability, error) {
+	// Load company schedules once for this cleaner.
+	companySchedules, err := r.Queries.ListCompanyWorkSchedule(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+	companyScheduleByDay := map[int32]*db.CompanyWorkSchedule{}
+	for i := range companySchedules {
+		companyScheduleByDay[companySchedules[i].DayOfWeek] = &companySchedules[i]
+	}
+
+	// Load cleaner weekly availability once.
+	weeklySlots, err := r.Queries.ListCleanerAvailability(ctx, cleanerID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Load cleaner date overrides for the full date range.
+	var overridesDateFrom, overridesDateTo pgtype.Date
+	for _, d := range dates {
+		pgd := pgtype.Date{Time: d, Valid: true}
+		if !overridesDateFrom.Valid || d.Before(overridesDateFrom.Time) {
+			overridesDateFrom = pgd
+		}
+		if !overridesDateTo.Valid || d.After(overridesDateTo.Time) {
+			overridesDateTo = pgd
+		}
+	}
+	overrides, err := r.Queries.ListCleanerDateOverrides(ctx, db.ListCleanerDateOverridesParams{
+		CleanerID:      cleanerID,
+		OverrideDate:   overridesDateFrom,
+		OverrideDate_2: overridesDateTo,
+	})
+	if err != nil {
+		return nil, err
+	}
+	overridesByDate := map[string]*db.CleanerDateOverride{}
+	for i := range overrides {
+		dateStr := overrides[i].OverrideDate.Time.Format("2006-01-02")
+		overridesByDate[dateStr] = &overrides[i]
+	}
+
+	calendar, err := r.loadCompanyCalendar(ctx, companyID, overridesDateFrom.Time, overridesDateTo.Time)
+	if err != nil {
+		return nil, err
+	}
+
+	// Build DateAvailability for each unique date.
+	var dateAvails []matching.DateAvailability
+
+	for dateStr, d := range dates {
+		dayOfWeek := int32(d.Weekday())
+
+		// Check company schedule for this day.
+		compDaySch := companyScheduleByDay[dayOfWeek]
+		if compDaySch != nil && !compDaySch.IsWorkDay {
+			continue // company doesn't work this day
+		}
+		if _, closed := calendar.closedOn(d); closed {
+			continue // closure day or public holiday
+		}
+
+		// 4-tier availability cascade for this specific date.
+		var availStart, availEnd int64
+		skipDate := false
+
+		// Tier 1: date override.
+		if override, ok := overridesByDate[dateStr]; ok {
+			if !override.IsAvailable {
+				continue // cleaner explicitly unavailable on this date
+			}
+			availStart = override.StartTime.Microseconds
+			availEnd = override.EndTime.Microseconds
+		} else {
+			// Tier 2: weekly slots.
+			found := false
+			for _, slot := range weeklySlots {
+				if slot.DayOfWeek == dayOfWeek && boolVal(slot.IsAvailable) {
+					availStart = slot.StartTime.Microseconds
+					availEnd = slot.EndTime.Microseconds
+					found = true
+					break
+				}
+			}
+			if !found {
+				// Tier 3: company schedule.
+				if compDaySch != nil && compDaySch.IsWorkDay {
+					availStart = compDaySch.StartTime.Microseconds
+					availEnd = compDaySch.EndTime.Microseconds
+				} else if compDaySch == nil {
+					// Tier 4: default 08:00-17:00.
+					availStart = 8 * matching.HourMicros
+					availEnd = 17 * matching.HourMicros
+				} else {
+					skipDate = true
+				}
+			}
+		}
+		if skipDate {
+			continue
+		}
+
+		// Load existing bookings for this date (solo and team jobs).
+		pgDate := pgtype.Date{Time: d, Valid: true}
+		existingBookings, err := r.Queries.ListCleanerBookingsForDate(ctx, db.ListCleanerBookingsForDateParams{
+			CleanerID:     cleanerID,
+			ScheduledDate: pgDate,
+		})
+		if err != nil {
+			continue
+		}
+
+		bookingSlots := make([]matching.BookingSlot, 0, len(existingBookings))
+		for _, eb := range existingBookings {
+			if excludeBookingID.Valid && eb.ID == excludeBookingID {
+				continue
+			}
+			startMicros := eb.ScheduledStartTime.Microseconds
+			durationHours := numericToFloat(eb.EstimatedDurationHours)
+			endMicros := startMicros + int64(durationHours*float64(matching.HourMicros))
+			bookingSlots = append(bookingSlots, matching.BookingSlot{
+				StartMicros: startMicros,
+				EndMicros:   endMicros,
+			})
+		}
+
+		freeIntervals := matching.ComputeFreeIntervals(availStart, availEnd, bookingSlots, bufferMicros)
+
+		dateAvails = append(dateAvails, matching.DateAvailability{
+			Date:          dateStr,
+			AvailStart:    availStart,
+			AvailEnd:      availEnd,
+			FreeIntervals: freeIntervals,
+			BookingCount:  len(bookingSlots),
+		})
+	}
+
+	return dateAvails, nil
+}
+
+// cleanerEvaluation is one placed and scored matchmaking candidate.
+type cleanerEvaluation struct {
+	cleanerID  pgtype.UUID
+	suggestion *model.CleanerSuggestion
+	score      float64
+	found      bool
+}
+
+// evaluateCleaners finds the best placement for every candidate across all
+// requested dates and scores it with workload balancing. Bookings with ID
+// excludeBookingID are ignored when computing free time, so a booking's own
+// matching can be replayed after it has been scheduled.
+func (r *Resolver) evaluateCleaners(ctx context.Context, candidates []db.FindMatchingCleanersRow, datedSlots []matching.DatedTimeSlot, uniqueDates map[string]time.Time, jobDurationMicros int64, config matching.MatchConfig, excludeBookingID pgtype.UUID) []cleanerEvaluation {
+	weekStartPG, weekEndPG := matchingWeekRange(uniqueDates)
+
+	// The excluded booking must not count towards its cleaner's weekly load either.
+	var excluded *db.Booking
+	if excludeBookingID.Valid {
+		if b, err := r.Queries.GetBookingByID(ctx, excludeBookingID); err == nil {
+			excluded = &b
+		}
+	}
+
+	var evaluations []cleanerEvaluation
+	for _, candidate := range candidates {
+		cleaner, err := r.Queries.GetCleanerByID(ctx, candidate.ID)
+		if err != nil {
+			continue
+		}
+		company, err := r.Queries.GetCompanyByID(ctx, candidate.CompanyID)
+		if err != nil {
+			continue
+		}
+
+		// Build per-date availability (override → weekly → company → default).
+		dateAvails, err := r.cleanerDateAvailabilities(ctx, candidate.ID, candidate.CompanyID, uniqueDates, config.BufferMicros(), excludeBookingID)
+		if err != nil {
+			continue
+		}
+
+		// Find the best date+time placement across all dates.
+		placement := matching.FindBestPlacementAcrossDates(dateAvails, datedSlots, jobDurationMicros, config)
+
+		// Load weekly workload for scoring.
+		weekBookingCount, _ := r.Queries.CountCleanerBookingsInDateRange(ctx, db.CountCleanerBookingsInDateRangeParams{
+			CleanerID:       candidate.ID,
+			ScheduledDate:   weekStartPG,
+			ScheduledDate_2: weekEndPG,
+		})
+		if excluded != nil && excluded.CleanerID == candidate.ID && weekBookingCount > 0 &&
+			!excluded.ScheduledDate.Time.Before(weekStartPG.Time) && !excluded.ScheduledDate.Time.After(weekEndPG.Time) {
+			weekBookingCount--
+		}
+
+		// Determine day booking count for the matched date.
+		dayBookingCount := 0
+		if placement.Found {
+			for _, da := range dateAvails {
+				if da.Date == placement.Date {
+					dayBookingCount = da.BookingCount
+					break
+				}
+			}
+		}
+
+		// Calculate match score with workload balancing.
+		rating := numericToFloat(candidate.RatingAvg)
+		jobs := 0
+		if candidate.TotalJobsCompleted.Valid {
+			jobs = int(candidate.TotalJobsCompleted.Int32)
+		}
+
+		breakdown := matching.ComputeScoreBreakdown(matching.ScoreInput{
+			RatingAvg:        rating,
+			TotalJobsDone:    jobs,
+			IsAreaMatch:      true,
+			PlacementFound:   placement.Found,
+			GapScoreH:        placement.GapScoreH,
+			DayBookingCount:  dayBookingCount,
+			WeekBookingCount: int(weekBookingCount),
+			Config:           config,
+		})
+
+		// Build suggestion.
+		profile, err := r.cleanerWithCompany(ctx, cleaner)
+		if err != nil {
+			continue
+		}
+
+		availStatus := "available"
+		if !placement.Found {
+			availStatus = "unavailable"
+		}
+
+		suggestion := &model.CleanerSuggestion{
+			Cleaner:            profile,
+			Company:            dbCompanyToGQL(company),
+			AvailabilityStatus: availStatus,
+			MatchScore:         breakdown.Total,
+			ScoreBreakdown:     scoreBreakdownToGQL(breakdown, placement, dayBookingCount, int(weekBookingCount), config),
+		}
+
+		if placement.Found {
+			startStr := matching.MicrosToHHMM(placement.StartMicros)
+			endStr := matching.MicrosToHHMM(placement.EndMicros)
+			slotIdx := placement.SlotIndex
+			dateStr := placement.Date
+			suggestion.SuggestedStartTime = &startStr
+			suggestion.SuggestedEndTime = &endStr
+			suggestion.SuggestedSlotIndex = &slotIdx
+			suggestion.SuggestedDate = &dateStr
+
+			// Set availableFrom/To from the matched date's availability.
+			for _, da := range dateAvails {
+				if da.Date == placement.Date {
+					availFromStr := microsecondsToHHMM(da.AvailStart)
+					availToStr := microsecondsToHHMM(da.AvailEnd)
+					suggestion.AvailableFrom = &availFromStr
+					suggestion.AvailableTo = &availToStr
+					break
+				}
+			}
+		}
+
+		evaluations = append(evaluations, cleanerEvaluation{
+			cleanerID:  candidate.ID,
+			suggestion: suggestion,
+			score:      breakdown.Total,
+			found:      placement.Found,
+		})
+	}
+	return evaluations
+}
+
+// scoreBreakdownToGQL converts a score breakdown and its placement to GraphQL.
+func scoreBreakdownToGQL(b matching.ScoreBreakdown, placement matching.DatedPlacementResult, dayBookingCount, weekBookingCount int, config matching.MatchConfig) *model.MatchScoreBreakdown {
+	result := &model.MatchScoreBreakdown{
+		Base:               b.Base,
+		Rating:             b.Rating,
+		Experience:         b.Experience,
+		AreaMatch:          b.AreaMatch,
+		Packing:            b.Packing,
+		UnavailablePenalty: b.Unavailable,
+		DailyLoadPenalty:   b.DailyLoad,
+		WeeklyLoadPenalty:  b.WeeklyLoad,
+		RawScore:           b.Raw,
+		Total:              b.Total,
+		DayBookingCount:    dayBookingCount,
+		WeekBookingCount:   weekBookingCount,
+		BufferMinutes:      config.BufferMinutes,
+	}
+	if placement.Found {
+		date := placement.Date
+		freeStart := matching.MicrosToHHMM(placement.Interval.Start)
+		freeEnd := matching.MicrosToHHMM(placement.Interval.End)
+		gap := placement.GapScoreH
+		result.PlacementDate = &date
+		result.FreeIntervalStart = &freeStart
+		result.FreeIntervalEnd = &freeEnd
+		result.GapHours = &gap
+	}
+	return result
+}
@@ -7,13 +7,19 @@ package resolver
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"helpmeclean-backend/internal/auth"
 	db "helpmeclean-backend/internal/db/generated"
+	"helpmeclean-backend/internal/graph"
 	"helpmeclean-backend/internal/graph/model"
+	"helpmeclean-backend/internal/service/ledger"
+	"log"
 	"strings"
 	"time"
 
+	pgx "github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
@@ -63,7 +69,7 @@ func (r *mutationResolver) AttachPaymentMethod(ctx context.Context, stripePaymen
 }
 
 // CreateBookingPaymentIntent is the resolver for the createBookingPaymentIntent field.
-func (r *mutationResolver) CreateBookingPaymentIntent(ctx context.Context, bookingID string) (*model.PaymentIntentResult, error) {
+func (r *mutationResolver) CreateBookingPaymentIntent(ctx context.Context, bookingID string, allowOverage *bool) (*model.PaymentIntentResult, error) {
 	claims := auth.GetUserFromContext(ctx)
 	if claims == nil {
 		return nil, fmt.Errorf("not authenticated")
@@ -77,16 +83,22 @@ func (r *mutationResolver) CreateBookingPaymentIntent(ctx context.Context, booki
 		return nil, fmt.Errorf("booking not found: %w", err)
 	}
 
-	clientSecret, paymentIntentID, amountBani, err := r.PaymentService.CreatePaymentIntentForBooking(ctx, booking)
+	opts := loadCaptureOptions(ctx, r.Queries, allowOverage != nil && *allowOverage)
+	clientSecret, paymentIntentID, amountBani, err := r.PaymentService.CreatePaymentIntentForBooking(ctx, booking, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create payment intent: %w", err)
 	}
 
+	captureMethod := "automatic"
+	if opts.Manual {
+		captureMethod = "manual"
+	}
 	return &model.PaymentIntentResult{
 		ClientSecret:    clientSecret,
 		PaymentIntentID: paymentIntentID,
 		Amount:          int(amountBani),
 		Currency:        "ron",
+		CaptureMethod:   captureMethod,
 	}, nil
 }
 
@@ -247,65 +259,82 @@ func (r *mutationResolver) CreateMonthlyPayout(ctx context.Context, companyID st
 		return nil, fmt.Errorf("no unpaid transactions found for this company in the given period")
 	}
 
-	// Sum up the amounts.
-	var totalNet int32
-	for _, txn := range txns {
-		totalNet += txn.AmountCompany
+	payout, err := r.createCompanyPayout(ctx, companyUUID, fromTime, toTime, txns)
+	if err != nil {
+		return nil, err
 	}
 
-	// Create the payout record.
-	payout, err := r.Queries.CreateCompanyPayout(ctx, db.CreateCompanyPayoutParams{
-		CompanyID:    companyUUID,
-		Amount:       totalNet,
-		Currency:     "ron",
-		PeriodFrom:   pgtype.Date{Time: fromTime, Valid: true},
-		PeriodTo:     pgtype.Date{Time: toTime, Valid: true},
-		BookingCount: int32(len(txns)),
-		Status:       db.PayoutStatusPending,
-	})
+	// Execute right away; a failure is recorded on the payout and retried.
+	executed, err := r.PaymentService.ExecutePayout(ctx, payout.ID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create payout: %w", err)
+		log.Printf("[PAYOUTS] Payout %s failed: %v", uuidToString(payout.ID), err)
+	}
+	if executed.ID.Valid {
+		payout = executed
 	}
 
-	// Create line items for each transaction.
-	for _, txn := range txns {
-		_, err := r.Queries.CreatePayoutLineItem(ctx, db.CreatePayoutLineItemParams{
-			PayoutID:             payout.ID,
-			PaymentTransactionID: txn.ID,
-			BookingID:            txn.BookingID,
-			AmountGross:          txn.AmountTotal,
-			AmountCommission:     txn.AmountPlatformFee,
-			AmountNet:            txn.AmountCompany,
-		})
-		if err != nil {
-			return nil, fmt.Errorf("failed to create payout line item: %w", err)
-		}
+	return r.enrichPayout(ctx, payout), nil
+}
+
+// RetryPayout is the resolver for the retryPayout field.
+func (r *mutationResolver) RetryPayout(ctx context.Context, id string) (*model.CompanyPayout, error) {
+	claims := auth.GetUserFromContext(ctx)
+	if claims == nil {
+		return nil, fmt.Errorf("not authenticated")
+	}
+	if claims.Role != "global_admin" {
+		return nil, fmt.Errorf("only admins can retry payouts")
 	}
 
-	result := dbCompanyPayoutToGQL(payout)
+	payout, err := r.Queries.GetPayoutByID(ctx, stringToUUID(id))
+	if err != nil {
+		return nil, fmt.Errorf("payout not found: %w", err)
+	}
+	if payout.Status != db.PayoutStatusPending && payout.Status != db.PayoutStatusFailed {
+		return nil, fmt.Errorf("only pending or failed payouts can be retried")
+	}
 
-	// Enrich with company data.
-	if company, err := r.Queries.GetCompanyByID(ctx, payout.CompanyID); err == nil {
-		result.Company = dbCompanyToGQL(company)
+	executed, err := r.PaymentService.ExecutePayout(ctx, payout.ID)
+	if err != nil && !executed.ID.Valid {
+		return nil, err
 	}
+	return r.enrichPayout(ctx, executed), nil
+}
 
-	// Load line items with booking data.
-	lineItems, err := r.Queries.ListPayoutLineItems(ctx, payout.ID)
-	if err == nil {
-		gqlLineItems := make([]*model.PayoutLineItem, len(lineItems))
-		for i, li := range lineItems {
-			gqlLI := dbPayoutLineItemToGQL(li)
-			if booking, err := r.Queries.GetBookingByID(ctx, li.BookingID); err == nil {
-				gqlBooking := dbBookingToGQL(booking)
-				r.enrichBooking(ctx, booking, gqlBooking)
-				gqlLI.Booking = gqlBooking
-			}
-			gqlLineItems[i] = gqlLI
-		}
-		result.LineItems = gqlLineItems
+// CancelPayout is the resolver for the cancelPayout field.
+func (r *mutationResolver) CancelPayout(ctx context.Context, id string) (*model.CompanyPayout, error) {
+	claims := auth.GetUserFromContext(ctx)
+	if claims == nil {
+		return nil, fmt.Errorf("not authenticated")
+	}
+	if claims.Role != "global_admin" {
+		return nil, fmt.Errorf("only admins can cancel payouts")
 	}
 
-	return result, nil
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	qtx := r.Queries.WithTx(tx)
+
+	payout, err := qtx.CancelPayout(ctx, stringToUUID(id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("only pending, failed or held payouts can be cancelled")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel payout: %w", err)
+	}
+	// Release the transactions for the next payout.
+	if err := qtx.DeletePayoutLineItems(ctx, payout.ID); err != nil {
+		return nil, fmt.Errorf("failed to release payout transactions: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	log.Printf("[PAYOUTS] Payout %s cancelled by %s", uuidToString(payout.ID), claims.UserID)
+	return r.enrichPayout(ctx, payout), nil
 }
 
 // ProcessRefund is the resolver for the processRefund field.
@@ -474,6 +503,106 @@ func (r *mutationResolver) MarkBookingPaid(ctx context.Context, id string) (*mod
 	return result, nil
 }
 
+// ReplayWebhookEvent is the resolver for the replayWebhookEvent field.
+func (r *mutationResolver) ReplayWebhookEvent(ctx context.Context, id string) (*model.WebhookEvent, error) {
+	claims := auth.GetUserFromContext(ctx)
+	if claims == nil {
+		return nil, fmt.Errorf("not authenticated")
+	}
+	if claims.Role != "global_admin" {
+		return nil, fmt.Errorf("only admins can replay webhook events")
+	}
+
+	ev, err := r.PaymentService.ReplayWebhookEvent(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return dbStripeEventToGQL(ev), nil
+}
+
+// PrepareDisputeEvidence is the resolver for the prepareDisputeEvidence field.
+func (r *mutationResolver) PrepareDisputeEvidence(ctx context.Context, id string) (*model.PaymentDispute, error) {
+	claims := auth.GetUserFromContext(ctx)
+	if claims == nil {
+		return nil, fmt.Errorf("not authenticated")
+	}
+	if claims.Role != "global_admin" {
+		return nil, fmt.Errorf("only admins can prepare dispute evidence")
+	}
+
+	dispute, err := r.Queries.GetPaymentDisputeByID(ctx, stringToUUID(id))
+	if err != nil {
+		return nil, fmt.Errorf("dispute not found: %w", err)
+	}
+	evidence, err := r.assembleDisputeEvidence(ctx, dispute)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(evidence)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode evidence: %w", err)
+	}
+	dp, err := r.Queries.SetPaymentDisputeEvidence(ctx, db.SetPaymentDisputeEvidenceParams{
+		ID:       stringToUUID(id),
+		Evidence: data,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("dispute evidence was already submitted")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to store dispute evidence: %w", err)
+	}
+	return r.enrichDispute(ctx, dp), nil
+}
+
+// UpdateDisputeEvidence is the resolver for the updateDisputeEvidence field.
+func (r *mutationResolver) UpdateDisputeEvidence(ctx context.Context, id string, input model.DisputeEvidenceInput) (*model.PaymentDispute, error) {
+	claims := auth.GetUserFromContext(ctx)
+	if claims == nil {
+		return nil, fmt.Errorf("not authenticated")
+	}
+	if claims.Role != "global_admin" {
+		return nil, fmt.Errorf("only admins can edit dispute evidence")
+	}
+
+	evidence := disputeEvidenceFromInput(input)
+
+	data, err := json.Marshal(evidence)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode evidence: %w", err)
+	}
+	dp, err := r.Queries.SetPaymentDisputeEvidence(ctx, db.SetPaymentDisputeEvidenceParams{
+		ID:       stringToUUID(id),
+		Evidence: data,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("dispute evidence was already submitted")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to store dispute evidence: %w", err)
+	}
+	return r.enrichDispute(ctx, dp), nil
+}
+
+// SubmitDisputeEvidence is the resolver for the submitDisputeEvidence field.
+func (r *mutationResolver) SubmitDisputeEvidence(ctx context.Context, id string) (*model.PaymentDispute, error) {
+	claims := auth.GetUserFromContext(ctx)
+	if claims == nil {
+		return nil, fmt.Errorf("not authenticated")
+	}
+	if claims.Role != "global_admin" {
+		return nil, fmt.Errorf("only admins can submit dispute evidence")
+	}
+
+	dp, err := r.PaymentService.SubmitDisputeEvidence(ctx, stringToUUID(id))
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[PAYMENTS] Evidence of dispute %s submitted by %s", dp.StripeDisputeID, claims.UserID)
+	return r.enrichDispute(ctx, dp), nil
+}
+
 // MyPaymentHistory is the resolver for the myPaymentHistory field.
 func (r *queryResolver) MyPaymentHistory(ctx context.Context, first *int, after *string) (*model.PaymentHistoryConnection, error) {
 	claims := auth.GetUserFromContext(ctx)
@@ -701,6 +830,11 @@ func (r *queryResolver) MyPayoutDetail(ctx context.Context, id string) (*model.C
 				r.enrichBooking(ctx, booking, gqlBooking)
 				gqlLI.Booking = gqlBooking
 			}
+			if li.CleanerID.Valid {
+				if cleaner, err := r.Queries.GetCleanerByID(ctx, li.CleanerID); err == nil {
+					gqlLI.Cleaner, _ = r.cleanerWithCompany(ctx, cleaner)
+				}
+			}
 			gqlLineItems[i] = gqlLI
 		}
 		result.LineItems = gqlLineItems
@@ -733,14 +867,23 @@ func (r *queryResolver) MyCompanyEarnings(ctx context.Context, from string, to s
 		return nil, fmt.Errorf("invalid to date: %w", err)
 	}
 
-	earnings, err := r.Queries.SumCompanyEarnings(ctx, db.SumCompanyEarningsParams{
-		CompanyID:   company.ID,
-		CreatedAt:   pgtype.Timestamptz{Time: fromTime, Valid: true},
-		CreatedAt_2: pgtype.Timestamptz{Time: toTime.Add(24*time.Hour - time.Nanosecond), Valid: true},
+	movements, err := r.Queries.SumLedgerMovements(ctx, db.SumLedgerMovementsParams{
+		OccurredFrom: pgtype.Timestamptz{Time: fromTime, Valid: true},
+		OccurredTo:   pgtype.Timestamptz{Time: toTime.Add(24*time.Hour - time.Nanosecond), Valid: true},
+		CompanyID:    company.ID,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get earnings: %w", err)
 	}
+	earnings := ledger.BuildCompanyEarnings(movements)
+
+	balance, err := r.Queries.GetLedgerAccountBalance(ctx, db.GetLedgerAccountBalanceParams{
+		AccountType: db.LedgerAccountTypeCompany,
+		OwnerID:     company.ID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balance: %w", err)
+	}
 
 	averagePerBooking := 0
 	if earnings.BookingCount > 0 {
@@ -753,6 +896,7 @@ func (r *queryResolver) MyCompanyEarnings(ctx context.Context, from string, to s
 		TotalNet:          int(earnings.TotalNet),
 		BookingCount:      int(earnings.BookingCount),
 		AveragePerBooking: averagePerBooking,
+		Balance:           int(-balance),
 	}, nil
 }
 
@@ -959,45 +1103,242 @@ func (r *queryResolver) PlatformRevenueReport(ctx context.Context, from string,
 		return nil, fmt.Errorf("invalid to date: %w", err)
 	}
 
-	fromTz := pgtype.Timestamptz{Time: fromTime, Valid: true}
-	toTz := pgtype.Timestamptz{Time: toTime.Add(24*time.Hour - time.Nanosecond), Valid: true}
-
-	// Get revenue report.
-	revenue, err := r.Queries.GetPlatformRevenueReport(ctx, db.GetPlatformRevenueReportParams{
-		CreatedAt:   fromTz,
-		CreatedAt_2: toTz,
+	movements, err := r.Queries.SumLedgerMovements(ctx, db.SumLedgerMovementsParams{
+		OccurredFrom: pgtype.Timestamptz{Time: fromTime, Valid: true},
+		OccurredTo:   pgtype.Timestamptz{Time: toTime.Add(24*time.Hour - time.Nanosecond), Valid: true},
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get revenue report: %w", err)
 	}
+	report := ledger.BuildRevenueReport(movements)
 
-	// Get payouts in period.
-	payoutsInfo, err := r.Queries.GetTotalPayoutsInPeriod(ctx, db.GetTotalPayoutsInPeriodParams{
-		CreatedAt:   fromTz,
-		CreatedAt_2: toTz,
-	})
+	// What the platform owes the companies is their accounts' credit balance.
+	balances, err := r.Queries.SumLedgerBalancesByType(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get payouts info: %w", err)
+		return nil, fmt.Errorf("failed to get ledger balances: %w", err)
+	}
+	var pendingPayouts int64
+	for _, b := range balances {
+		if b.AccountType == db.LedgerAccountTypeCompany {
+			pendingPayouts = -b.Balance
+		}
 	}
 
-	// Get refunds in period.
-	totalRefunds, err := r.Queries.GetTotalRefundsInPeriod(ctx, db.GetTotalRefundsInPeriodParams{
-		ProcessedAt:   fromTz,
-		ProcessedAt_2: toTz,
-	})
+	return &model.PlatformRevenueReport{
+		TotalRevenue:    int(report.TotalRevenue),
+		TotalCommission: int(report.TotalCommission),
+		TotalPayouts:    int(report.TotalPayouts),
+		PendingPayouts:  int(pendingPayouts),
+		TotalRefunds:    int(report.TotalRefunds),
+		StripeFees:      int(report.StripeFees),
+		NetRevenue:      int(report.NetRevenue),
+		BookingCount:    int(report.BookingCount),
+	}, nil
+}
+
+// PayoutReconciliation is the resolver for the payoutReconciliation field.
+func (r *queryResolver) PayoutReconciliation(ctx context.Context) ([]*model.CompanyPayout, error) {
+	claims := auth.GetUserFromContext(ctx)
+	if claims == nil {
+		return nil, fmt.Errorf("not authenticated")
+	}
+	if claims.Role != "global_admin" {
+		return nil, fmt.Errorf("only admins can view payout reconciliation")
+	}
+
+	payouts, err := r.Queries.ListPayoutsForReconciliation(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get refunds info: %w", err)
+		return nil, fmt.Errorf("failed to list payouts for reconciliation: %w", err)
 	}
 
-	netRevenue := int(revenue.TotalCommission) - int(totalRefunds)
+	results := make([]*model.CompanyPayout, len(payouts))
+	for i, p := range payouts {
+		gqlPayout := dbCompanyPayoutToGQL(p)
+		if company, err := r.Queries.GetCompanyByID(ctx, p.CompanyID); err == nil {
+			gqlPayout.Company = dbCompanyToGQL(company)
+		}
+		results[i] = gqlPayout
+	}
+	return results, nil
+}
 
-	return &model.PlatformRevenueReport{
-		TotalRevenue:    int(revenue.TotalRevenue),
-		TotalCommission: int(revenue.TotalCommission),
-		TotalPayouts:    int(payoutsInfo.TotalPaid),
-		PendingPayouts:  int(payoutsInfo.TotalPending),
-		TotalRefunds:    int(totalRefunds),
-		NetRevenue:      netRevenue,
-		BookingCount:    int(revenue.BookingCount),
-	}, nil
+// WebhookEvents is the resolver for the webhookEvents field.
+func (r *queryResolver) WebhookEvents(ctx context.Context, status *model.WebhookEventStatus, first *int, after *string) ([]*model.WebhookEvent, error) {
+	claims := auth.GetUserFromContext(ctx)
+	if claims == nil {
+		return nil, fmt.Errorf("not authenticated")
+	}
+	if claims.Role != "global_admin" {
+		return nil, fmt.Errorf("only admins can view webhook events")
+	}
+
+	limit := int32(20)
+	if first != nil {
+		limit = int32(*first)
+	}
+	offset := int32(0)
+	if after != nil {
+		fmt.Sscanf(*after, "%d", &offset)
+	}
+
+	var events []db.StripeEvent
+	var err error
+	if status != nil {
+		events, err = r.Queries.ListStripeEventsByStatus(ctx, db.ListStripeEventsByStatusParams{
+			Status: db.StripeEventStatus(strings.ToLower(string(*status))),
+			Limit:  limit,
+			Offset: offset,
+		})
+	} else {
+		events, err = r.Queries.ListStripeEvents(ctx, db.ListStripeEventsParams{
+			Limit:  limit,
+			Offset: offset,
+		})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook events: %w", err)
+	}
+
+	results := make([]*model.WebhookEvent, len(events))
+	for i, ev := range events {
+		results[i] = dbStripeEventToGQL(ev)
+	}
+	return results, nil
+}
+
+// LedgerBalances is the resolver for the ledgerBalances field.
+func (r *queryResolver) LedgerBalances(ctx context.Context) ([]*model.LedgerBalance, error) {
+	claims := auth.GetUserFromContext(ctx)
+	if claims == nil {
+		return nil, fmt.Errorf("not authenticated")
+	}
+	if claims.Role != "global_admin" {
+		return nil, fmt.Errorf("only admins can view ledger balances")
+	}
+
+	balances, err := r.Queries.SumLedgerBalancesByType(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ledger balances: %w", err)
+	}
+
+	results := make([]*model.LedgerBalance, len(balances))
+	for i, b := range balances {
+		results[i] = &model.LedgerBalance{
+			AccountType: model.LedgerAccountType(strings.ToUpper(string(b.AccountType))),
+			Balance:     int(b.Balance),
+		}
+	}
+	return results, nil
+}
+
+// LedgerReconciliations is the resolver for the ledgerReconciliations field.
+func (r *queryResolver) LedgerReconciliations(ctx context.Context, first *int) ([]*model.LedgerReconciliation, error) {
+	claims := auth.GetUserFromContext(ctx)
+	if claims == nil {
+		return nil, fmt.Errorf("not authenticated")
+	}
+	if claims.Role != "global_admin" {
+		return nil, fmt.Errorf("only admins can view ledger reconciliations")
+	}
+
+	limit := int32(20)
+	if first != nil {
+		limit = int32(*first)
+	}
+
+	runs, err := r.Queries.ListLedgerReconciliations(ctx, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ledger reconciliations: %w", err)
+	}
+
+	results := make([]*model.LedgerReconciliation, len(runs))
+	for i, run := range runs {
+		results[i] = dbLedgerReconciliationToGQL(run)
+	}
+	return results, nil
 }
+
+// PaymentDisputes is the resolver for the paymentDisputes field.
+func (r *queryResolver) PaymentDisputes(ctx context.Context, status *model.DisputeStatus, first *int, after *string) ([]*model.PaymentDispute, error) {
+	claims := auth.GetUserFromContext(ctx)
+	if claims == nil {
+		return nil, fmt.Errorf("not authenticated")
+	}
+	if claims.Role != "global_admin" {
+		return nil, fmt.Errorf("only admins can view disputes")
+	}
+
+	limit := int32(20)
+	if first != nil {
+		limit = int32(*first)
+	}
+	offset := int32(0)
+	if after != nil {
+		fmt.Sscanf(*after, "%d", &offset)
+	}
+
+	var disputes []db.PaymentDispute
+	var err error
+	if status != nil {
+		disputes, err = r.Queries.ListPaymentDisputesByStatus(ctx, db.ListPaymentDisputesByStatusParams{
+			Status: db.DisputeStatus(strings.ToLower(string(*status))),
+			Limit:  limit,
+			Offset: offset,
+		})
+	} else {
+		disputes, err = r.Queries.ListPaymentDisputes(ctx, db.ListPaymentDisputesParams{
+			Limit:  limit,
+			Offset: offset,
+		})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list disputes: %w", err)
+	}
+
+	result := make([]*model.PaymentDispute, len(disputes))
+	for i, d := range disputes {
+		result[i] = r.enrichDispute(ctx, d)
+	}
+	return result, nil
+}
+
+// PaymentDispute is the resolver for the paymentDispute field.
+func (r *queryResolver) PaymentDispute(ctx context.Context, id string) (*model.PaymentDispute, error) {
+	claims := auth.GetUserFromContext(ctx)
+	if claims == nil {
+		return nil, fmt.Errorf("not authenticated")
+	}
+	if claims.Role != "global_admin" {
+		return nil, fmt.Errorf("only admins can view disputes")
+	}
+
+	dp, err := r.Queries.GetPaymentDisputeByID(ctx, stringToUUID(id))
+	if err != nil {
+		return nil, fmt.Errorf("dispute not found: %w", err)
+	}
+	return r.enrichDispute(ctx, dp), nil
+}
+
+// CreditNote is the resolver for the creditNote field.
+func (r *refundRequestResolver) CreditNote(ctx context.Context, obj *model.RefundRequest) (*model.Invoice, error) {
+	refundReq, err := r.Queries.GetRefundRequestByID(ctx, stringToUUID(obj.ID))
+	if err != nil || !refundReq.StripeRefundID.Valid {
+		return nil, nil
+	}
+	inv, err := r.Queries.GetCreditNoteByRefund(ctx, db.GetCreditNoteByRefundParams{
+		StripeRefundID: refundReq.StripeRefundID,
+		InvoiceType:    db.InvoiceTypeClientService,
+	})
+	if err != nil {
+		// No credit note yet, e.g. the refund is still being processed.
+		return nil, nil
+	}
+	gqlInvoice := dbInvoiceToGQL(inv)
+	r.enrichInvoice(ctx, inv, gqlInvoice)
+	return gqlInvoice, nil
+}
+
+// RefundRequest returns graph.RefundRequestResolver implementation.
+func (r *Resolver) RefundRequest() graph.RefundRequestResolver { return &refundRequestResolver{r} }
+
+type refundRequestResolver struct{ *Resolver }
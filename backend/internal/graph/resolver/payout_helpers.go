@@ -0,0 +1,186 @@
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	db "helpmeclean-backend/internal/db/generated"
+	"helpmeclean-backend/internal/graph/model"
+	"helpmeclean-backend/internal/service/payment"
+	"helpmeclean-backend/internal/service/recurrence"
+)
+
+// loadPayoutSchedule reads the automatic payout cadence and the minimum
+// payout amount (in bani) from platform_settings. Defaults: weekly, 100 RON.
+func loadPayoutSchedule(ctx context.Context, queries *db.Queries) (payment.PayoutCadence, int64) {
+	cadence := payment.PayoutWeekly
+	if v, err := queries.GetPlatformSetting(ctx, "payout_cadence"); err == nil {
+		if c, err := payment.ParsePayoutCadence(v.Value); err == nil {
+			cadence = c
+		} else {
+			log.Printf("[PAYOUTS] %v, using %s", err, cadence)
+		}
+	}
+	minBani := int64(10000)
+	if v, err := queries.GetPlatformSetting(ctx, "payout_min_amount"); err == nil {
+		if f, err := strconv.ParseFloat(v.Value, 64); err == nil && f >= 0 {
+			minBani = int64(f * 100)
+		}
+	}
+	return cadence, minBani
+}
+
+// createCompanyPayout creates a pending payout covering the given unpaid
+// transactions, with one line item per transaction and cleaner, in one
+// database transaction.
+func (r *Resolver) createCompanyPayout(ctx context.Context, companyID pgtype.UUID, from, to time.Time, txns []db.PaymentTransaction) (db.CompanyPayout, error) {
+	var totalNet int32
+	for _, txn := range txns {
+		totalNet += txn.AmountCompany
+	}
+
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return db.CompanyPayout{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	qtx := r.Queries.WithTx(tx)
+
+	payout, err := qtx.CreateCompanyPayout(ctx, db.CreateCompanyPayoutParams{
+		CompanyID:    companyID,
+		Amount:       totalNet,
+		Currency:     "ron",
+		PeriodFrom:   pgtype.Date{Time: from, Valid: true},
+		PeriodTo:     pgtype.Date{Time: to, Valid: true},
+		BookingCount: int32(len(txns)),
+		Status:       db.PayoutStatusPending,
+	})
+	if err != nil {
+		return db.CompanyPayout{}, fmt.Errorf("failed to create payout: %w", err)
+	}
+
+	// Create line items for each transaction, one per cleaner on team bookings.
+	for _, txn := range txns {
+		params, err := r.payoutLineItemsForTransaction(ctx, payout.ID, txn)
+		if err != nil {
+			return db.CompanyPayout{}, err
+		}
+		for _, p := range params {
+			if _, err := qtx.CreatePayoutLineItem(ctx, p); err != nil {
+				return db.CompanyPayout{}, fmt.Errorf("failed to create payout line item: %w", err)
+			}
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return db.CompanyPayout{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return payout, nil
+}
+
+// enrichPayout converts a payout to GraphQL with its company and line items
+// (with booking and cleaner).
+func (r *Resolver) enrichPayout(ctx context.Context, payout db.CompanyPayout) *model.CompanyPayout {
+	result := dbCompanyPayoutToGQL(payout)
+
+	if company, err := r.Queries.GetCompanyByID(ctx, payout.CompanyID); err == nil {
+		result.Company = dbCompanyToGQL(company)
+	}
+
+	lineItems, err := r.Queries.ListPayoutLineItems(ctx, payout.ID)
+	if err == nil {
+		gqlLineItems := make([]*model.PayoutLineItem, len(lineItems))
+		for i, li := range lineItems {
+			gqlLI := dbPayoutLineItemToGQL(li)
+			if booking, err := r.Queries.GetBookingByID(ctx, li.BookingID); err == nil {
+				gqlBooking := dbBookingToGQL(booking)
+				r.enrichBooking(ctx, booking, gqlBooking)
+				gqlLI.Booking = gqlBooking
+			}
+			if li.CleanerID.Valid {
+				if cleaner, err := r.Queries.GetCleanerByID(ctx, li.CleanerID); err == nil {
+					gqlLI.Cleaner, _ = r.cleanerWithCompany(ctx, cleaner)
+				}
+			}
+			gqlLineItems[i] = gqlLI
+		}
+		result.LineItems = gqlLineItems
+	}
+	return result
+}
+
+// RunCompanyPayouts retries failed payouts that are due and pays out every
+// company with Stripe payouts enabled for the last closed period of the
+// configured cadence. Unpaid transactions from earlier periods are included,
+// so amounts below the minimum carry over until they reach it. It is run
+// periodically by the job scheduler; transactions already in a payout are
+// never picked up again, so repeated runs in one period are no-ops.
+func (r *Resolver) RunCompanyPayouts(ctx context.Context) error {
+	if err := r.PaymentService.RetryPayouts(ctx); err != nil {
+		log.Printf("[PAYOUTS] Retry failed: %v", err)
+	}
+
+	cadence, minBani := loadPayoutSchedule(ctx, r.Queries)
+	periodFrom, periodTo := cadence.LastClosedPeriod(recurrence.Today())
+	// Transactions up to the end of the period's last day, Romanian time.
+	cutoff := recurrence.StartTime(periodTo.AddDate(0, 0, 1), 0).Add(-time.Nanosecond)
+
+	companies, err := r.Queries.ListCompaniesForPayout(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list companies for payout: %w", err)
+	}
+
+	var created, belowMin, failed int
+	for _, c := range companies {
+		txns, err := r.Queries.ListUnpaidCompanyTransactions(ctx, db.ListUnpaidCompanyTransactionsParams{
+			CompanyID:   c.ID,
+			CreatedAt:   pgtype.Timestamptz{Time: time.Unix(0, 0), Valid: true},
+			CreatedAt_2: pgtype.Timestamptz{Time: cutoff, Valid: true},
+		})
+		if err != nil {
+			log.Printf("[PAYOUTS] Company %s: failed to list unpaid transactions: %v", uuidToString(c.ID), err)
+			failed++
+			continue
+		}
+		if len(txns) == 0 {
+			continue
+		}
+
+		var total int64
+		for _, txn := range txns {
+			total += int64(txn.AmountCompany)
+		}
+		if total < minBani {
+			belowMin++
+			continue
+		}
+
+		from := periodFrom
+		if first := recurrence.Date(txns[0].CreatedAt.Time.In(recurrence.Location)); first.Before(from) {
+			from = first
+		}
+		payout, err := r.createCompanyPayout(ctx, c.ID, from, periodTo, txns)
+		if err != nil {
+			log.Printf("[PAYOUTS] Company %s: %v", uuidToString(c.ID), err)
+			failed++
+			continue
+		}
+		created++
+		// A failed execution is recorded on the payout and retried later.
+		if _, err := r.PaymentService.ExecutePayout(ctx, payout.ID); err != nil {
+			log.Printf("[PAYOUTS] Company %s: payout %s failed: %v", uuidToString(c.ID), uuidToString(payout.ID), err)
+		}
+	}
+
+	log.Printf("[PAYOUTS] %s period %s..%s: %d payouts created, %d companies below the %d bani minimum",
+		cadence, periodFrom.Format("2006-01-02"), periodTo.Format("2006-01-02"), created, belowMin, minBani)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d companies failed", failed, len(companies))
+	}
+	return nil
+}
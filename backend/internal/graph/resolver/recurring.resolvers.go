@@ -7,11 +7,17 @@ package resolver
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"helpmeclean-backend/internal/auth"
 	db "helpmeclean-backend/internal/db/generated"
 	"helpmeclean-backend/internal/graph/model"
+	"helpmeclean-backend/internal/service/matching"
+	"helpmeclean-backend/internal/service/recurrence"
+	"log"
+	"time"
 
+	pgx "github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
@@ -50,6 +56,7 @@ func (r *mutationResolver) CancelRecurringGroup(ctx context.Context, id string,
 		RecurringGroupID:   groupUUID,
 		CancellationReason: pgtype.Text{String: reasonText, Valid: reasonText != ""},
 	})
+	r.releaseRecurringHolds(ctx, groupUUID)
 
 	return r.enrichRecurringGroup(ctx, group)
 }
@@ -76,6 +83,17 @@ func (r *mutationResolver) PauseRecurringGroup(ctx context.Context, id string) (
 		return nil, fmt.Errorf("failed to pause recurring group: %w", err)
 	}
 
+	// Cancel the already generated future occurrences; resuming regenerates
+	// them. Skipped and rescheduled ones are not regenerated and stay.
+	if err := r.Queries.CancelRegenerableOccurrences(ctx, db.CancelRegenerableOccurrencesParams{
+		CancellationReason: pgtype.Text{String: "Serie recurenta pusa pe pauza", Valid: true},
+		RecurringGroupID:   groupUUID,
+		ApplyFrom:          pgtype.Date{Time: recurrence.Today(), Valid: true},
+	}); err != nil {
+		return nil, fmt.Errorf("failed to cancel future occurrences: %w", err)
+	}
+	r.releaseRecurringHolds(ctx, groupUUID)
+
 	return r.enrichRecurringGroup(ctx, group)
 }
 
@@ -101,6 +119,333 @@ func (r *mutationResolver) ResumeRecurringGroup(ctx context.Context, id string)
 		return nil, fmt.Errorf("failed to resume recurring group: %w", err)
 	}
 
+	// Generate the occurrences up to the horizon now instead of waiting for the job.
+	if _, _, err := r.extendRecurringGroup(ctx, group, recurringHorizon(ctx, r.Queries), loadMatchConfig(ctx, r.Queries)); err != nil {
+		log.Printf("failed to generate occurrences for resumed group %s: %v", id, err)
+	}
+
+	return r.enrichRecurringGroup(ctx, group)
+}
+
+// SkipOccurrence is the resolver for the skipOccurrence field.
+func (r *mutationResolver) SkipOccurrence(ctx context.Context, bookingID string) (*model.Booking, error) {
+	claims := auth.GetUserFromContext(ctx)
+	if claims == nil {
+		return nil, fmt.Errorf("not authenticated")
+	}
+
+	booking, err := r.loadEditableOccurrence(ctx, claims, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	originalDate := r.occurrenceOriginalDate(ctx, booking)
+
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	qtx := r.Queries.WithTx(tx)
+
+	booking, err = qtx.CancelBookingWithReason(ctx, db.CancelBookingWithReasonParams{
+		ID:                 booking.ID,
+		Status:             db.BookingStatusCancelledByClient,
+		CancellationReason: pgtype.Text{String: "Programare sarita de client", Valid: true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel occurrence: %w", err)
+	}
+
+	// Record the skip so regenerating the series never recreates this date.
+	if err := qtx.UpsertOccurrenceException(ctx, db.UpsertOccurrenceExceptionParams{
+		GroupID:       booking.RecurringGroupID,
+		OriginalDate:  originalDate,
+		ExceptionType: db.OccurrenceExceptionTypeSkipped,
+		BookingID:     booking.ID,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to record skipped occurrence: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	r.releasePaymentHold(ctx, booking.ID)
+
+	gqlB := dbBookingToGQL(booking)
+	r.enrichBooking(ctx, booking, gqlB)
+	return gqlB, nil
+}
+
+// RescheduleOccurrence is the resolver for the rescheduleOccurrence field.
+func (r *mutationResolver) RescheduleOccurrence(ctx context.Context, bookingID string, date string, startTime string) (*model.Booking, error) {
+	claims := auth.GetUserFromContext(ctx)
+	if claims == nil {
+		return nil, fmt.Errorf("not authenticated")
+	}
+
+	booking, err := r.loadEditableOccurrence(ctx, claims, bookingID)
+	if err != nil {
+		return nil, err
+	}
+
+	newDate, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date format: %w", err)
+	}
+	if !newDate.After(recurrence.Today()) {
+		return nil, fmt.Errorf("occurrences can only be moved to a future date")
+	}
+	if _, err := time.Parse("15:04", startTime); err != nil {
+		return nil, fmt.Errorf("invalid start time format: %w", err)
+	}
+	newStart := parseHHMMToTime(startTime)
+
+	if booking.CompanyID.Valid {
+		if err := r.checkCompanyOpen(ctx, booking.CompanyID, newDate); err != nil {
+			return nil, err
+		}
+	}
+
+	// Keep the current cleaner if they can make the new time, otherwise
+	// look for a free teammate; without one the occurrence goes back to pending.
+	config := loadMatchConfig(ctx, r.Queries)
+	startMicros := newStart.Microseconds
+	endMicros := startMicros + int64(numericToFloat(booking.EstimatedDurationHours)*float64(matching.HourMicros))
+	cleanerID := pgtype.UUID{}
+	if booking.CleanerID.Valid {
+		if _, ok := r.cleanerFreeForWindow(ctx, booking.CleanerID, booking.CompanyID, booking.ServiceType, newDate, startMicros, endMicros, config, booking.ID); ok {
+			cleanerID = booking.CleanerID
+		}
+	}
+	if !cleanerID.Valid && booking.CompanyID.Valid {
+		cleanerID = r.findAvailableCleanerForDate(ctx, booking.CleanerID, booking.CompanyID, booking.ServiceType, newDate, startMicros, endMicros, config, booking.ID)
+	}
+
+	originalDate := r.occurrenceOriginalDate(ctx, booking)
+
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	qtx := r.Queries.WithTx(tx)
+
+	updated, err := qtx.RescheduleBooking(ctx, db.RescheduleBookingParams{
+		ScheduledDate:      pgtype.Date{Time: newDate, Valid: true},
+		ScheduledStartTime: newStart,
+		CleanerID:          cleanerID,
+		ID:                 booking.ID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("only upcoming occurrences can be changed")
+		}
+		return nil, fmt.Errorf("failed to reschedule occurrence: %w", err)
+	}
+
+	// The exception is keyed by the date the series generated, so moving the
+	// same occurrence again updates it instead of adding another one.
+	if err := qtx.UpsertOccurrenceException(ctx, db.UpsertOccurrenceExceptionParams{
+		GroupID:       booking.RecurringGroupID,
+		OriginalDate:  originalDate,
+		ExceptionType: db.OccurrenceExceptionTypeRescheduled,
+		BookingID:     booking.ID,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to record rescheduled occurrence: %w", err)
+	}
+
+	updated, err = r.applyHolidaySurcharge(ctx, qtx, updated)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply holiday surcharge: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	if !updated.CleanerID.Valid {
+		if group, err := r.Queries.GetRecurringGroupByID(ctx, updated.RecurringGroupID); err == nil {
+			r.notifyUnstaffedOccurrence(ctx, group, updated)
+		}
+	}
+
+	gqlB := dbBookingToGQL(updated)
+	r.enrichBooking(ctx, updated, gqlB)
+	return gqlB, nil
+}
+
+// UpdateRecurringGroup is the resolver for the updateRecurringGroup field.
+func (r *mutationResolver) UpdateRecurringGroup(ctx context.Context, id string, input model.UpdateRecurringGroupInput, applyFrom string) (*model.RecurringBookingGroup, error) {
+	claims := auth.GetUserFromContext(ctx)
+	if claims == nil {
+		return nil, fmt.Errorf("not authenticated")
+	}
+
+	groupUUID := stringToUUID(id)
+	group, err := r.Queries.GetRecurringGroupByID(ctx, groupUUID)
+	if err != nil {
+		return nil, fmt.Errorf("recurring group not found: %w", err)
+	}
+
+	if uuidToString(group.ClientUserID) != claims.UserID && claims.Role != "global_admin" {
+		return nil, fmt.Errorf("not authorized")
+	}
+	if group.CancelledAt.Valid {
+		return nil, fmt.Errorf("cannot update a cancelled recurring group")
+	}
+
+	from, err := time.Parse("2006-01-02", applyFrom)
+	if err != nil {
+		return nil, fmt.Errorf("invalid applyFrom date format: %w", err)
+	}
+	if !from.After(recurrence.Today()) {
+		return nil, fmt.Errorf("series changes can only apply from a future date")
+	}
+
+	// Merge the changes into the current series.
+	dayOfWeek := group.DayOfWeek
+	if input.DayOfWeek != nil {
+		if *input.DayOfWeek < 0 || *input.DayOfWeek > 6 {
+			return nil, fmt.Errorf("dayOfWeek must be between 0 and 6")
+		}
+		dayOfWeek = pgtype.Int4{Int32: int32(*input.DayOfWeek), Valid: true}
+	}
+	preferredTime := group.PreferredTime
+	if input.PreferredTime != nil {
+		if _, err := time.Parse("15:04", *input.PreferredTime); err != nil {
+			return nil, fmt.Errorf("invalid preferred time format: %w", err)
+		}
+		preferredTime = parseHHMMToTime(*input.PreferredTime)
+	}
+	propertyType := group.PropertyType
+	if input.PropertyType != nil {
+		propertyType = stringToText(input.PropertyType)
+	}
+	numRooms := group.NumRooms
+	if input.NumRooms != nil {
+		numRooms = intToInt4Val(*input.NumRooms)
+	}
+	numBathrooms := group.NumBathrooms
+	if input.NumBathrooms != nil {
+		numBathrooms = intToInt4Val(*input.NumBathrooms)
+	}
+	areaSqm := group.AreaSqm
+	if input.AreaSqm != nil {
+		areaSqm = intToInt4(input.AreaSqm)
+	}
+	hasPets := group.HasPets
+	if input.HasPets != nil {
+		hasPets = boolToPgBool(input.HasPets)
+	}
+	specialInstructions := group.SpecialInstructions
+	if input.SpecialInstructions != nil {
+		specialInstructions = stringToText(input.SpecialInstructions)
+	}
+	extras := input.Extras
+	if extras == nil {
+		current, err := r.Queries.GetRecurringGroupExtras(ctx, groupUUID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load extras: %w", err)
+		}
+		extras = recurringGroupExtraInputs(current)
+	}
+
+	// Reprice one visit with the merged details.
+	quote, err := r.quoteBooking(ctx, group.ServiceType, int4Val(numRooms), int4Val(numBathrooms),
+		int4Ptr(areaSqm), textPtr(propertyType), boolPtr(hasPets), extras)
+	if err != nil {
+		return nil, err
+	}
+
+	// A new rule restarts the series on applyFrom; a new weekday moves the
+	// anchor to its first date from applyFrom on.
+	recurrenceType, rrule, anchor := group.RecurrenceType, group.Rrule, group.AnchorDate
+	if input.Rrule != nil && *input.Rrule != "" {
+		spec, err := resolveRecurrence(&model.RecurrenceInput{Rrule: input.Rrule}, from)
+		if err != nil {
+			return nil, err
+		}
+		recurrenceType, rrule = spec.recurrenceType, stringToTextVal(spec.rrule)
+		dayOfWeek = intToInt4(spec.dayOfWeek)
+		anchor = pgtype.Date{Time: from, Valid: true}
+	} else if input.DayOfWeek != nil && (!group.DayOfWeek.Valid || group.DayOfWeek.Int32 != dayOfWeek.Int32) {
+		if group.RecurrenceType == db.RecurrenceTypeCustom {
+			return nil, fmt.Errorf("change the days of a custom series through its rrule")
+		}
+		anchor = pgtype.Date{Time: nextWeekday(from, int(dayOfWeek.Int32)), Valid: true}
+		rule, err := recurrence.FromLegacy(string(recurrenceType), anchor.Time)
+		if err != nil {
+			return nil, err
+		}
+		rrule = stringToTextVal(rule)
+	}
+
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	qtx := r.Queries.WithTx(tx)
+
+	group, err = qtx.UpdateRecurringGroupSeries(ctx, db.UpdateRecurringGroupSeriesParams{
+		ID:                          groupUUID,
+		DayOfWeek:                   dayOfWeek,
+		PreferredTime:               preferredTime,
+		PropertyType:                propertyType,
+		NumRooms:                    numRooms,
+		NumBathrooms:                numBathrooms,
+		AreaSqm:                     areaSqm,
+		HasPets:                     hasPets,
+		SpecialInstructions:         specialInstructions,
+		HourlyRate:                  float64ToNumeric(quote.HourlyRate),
+		EstimatedTotalPerOccurrence: float64ToNumeric(quote.Total()),
+		EstimatedDurationHours:      float64ToNumeric(quote.EstimatedHours),
+		AnchorDate:                  anchor,
+		GeneratedUntil:              rewindGeneratedUntil(group.GeneratedUntil, from),
+		RecurrenceType:              recurrenceType,
+		Rrule:                       rrule,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update recurring group: %w", err)
+	}
+
+	if input.Extras != nil {
+		if err := qtx.DeleteRecurringGroupExtras(ctx, groupUUID); err != nil {
+			return nil, fmt.Errorf("failed to replace extras: %w", err)
+		}
+		for _, extra := range input.Extras {
+			if err := qtx.InsertRecurringGroupExtra(ctx, db.InsertRecurringGroupExtraParams{
+				GroupID:  groupUUID,
+				ExtraID:  stringToUUID(extra.ExtraID),
+				Quantity: pgtype.Int4{Int32: int32(extra.Quantity), Valid: true},
+			}); err != nil {
+				return nil, fmt.Errorf("failed to replace extras: %w", err)
+			}
+		}
+	}
+
+	// Drop the generated occurrences from applyFrom on; skipped and
+	// rescheduled ones stay as they are.
+	if err := qtx.CancelRegenerableOccurrences(ctx, db.CancelRegenerableOccurrencesParams{
+		CancellationReason: pgtype.Text{String: "Inlocuita de modificarea seriei", Valid: true},
+		RecurringGroupID:   groupUUID,
+		ApplyFrom:          pgtype.Date{Time: from, Valid: true},
+	}); err != nil {
+		return nil, fmt.Errorf("failed to cancel future occurrences: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	r.releaseRecurringHolds(ctx, groupUUID)
+
+	// Regenerate the occurrences with the new details right away.
+	if boolVal(group.IsActive) {
+		if _, _, err := r.extendRecurringGroup(ctx, group, recurringHorizon(ctx, r.Queries), loadMatchConfig(ctx, r.Queries)); err != nil {
+			log.Printf("failed to regenerate occurrences for recurring group %s: %v", id, err)
+		}
+	}
+
 	return r.enrichRecurringGroup(ctx, group)
 }
 
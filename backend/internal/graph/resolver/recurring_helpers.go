@@ -2,14 +2,19 @@ package resolver
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
+	"strconv"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgtype"
 
+	"helpmeclean-backend/internal/auth"
 	db "helpmeclean-backend/internal/db/generated"
 	"helpmeclean-backend/internal/graph/model"
+	"helpmeclean-backend/internal/service/matching"
+	"helpmeclean-backend/internal/service/recurrence"
 )
 
 // createRecurringGroupInput holds the parameters for creating a recurring booking group.
@@ -19,7 +24,8 @@ type createRecurringGroupInput struct {
 	cleanerID           pgtype.UUID
 	addressID           pgtype.UUID
 	recurrenceType      db.RecurrenceType
-	dayOfWeek           int
+	rrule               string
+	dayOfWeek           *int
 	preferredTime       time.Time
 	serviceType         db.ServiceType
 	propertyType        *string
@@ -35,8 +41,9 @@ type createRecurringGroupInput struct {
 	firstBooking        db.Booking
 }
 
-// createRecurringGroup creates the recurring_booking_groups row, links the first booking,
-// and generates 7 future occurrence bookings (total 8 with the first one).
+// createRecurringGroup creates the recurring_booking_groups row, links the first
+// booking and generates the occurrences up to the rolling horizon. Later
+// occurrences are added by GenerateRecurringOccurrences.
 func (r *Resolver) createRecurringGroup(ctx context.Context, input createRecurringGroupInput) (db.RecurringBookingGroup, error) {
 	preferredTimeMicros := int64(input.preferredTime.Hour())*3_600_000_000 + int64(input.preferredTime.Minute())*60_000_000
 
@@ -46,7 +53,7 @@ func (r *Resolver) createRecurringGroup(ctx context.Context, input createRecurri
 		PreferredCleanerID:          input.cleanerID,
 		AddressID:                   input.addressID,
 		RecurrenceType:              input.recurrenceType,
-		DayOfWeek:                   pgtype.Int4{Int32: int32(input.dayOfWeek), Valid: true},
+		DayOfWeek:                   intToInt4(input.dayOfWeek),
 		PreferredTime:               pgtype.Time{Microseconds: preferredTimeMicros, Valid: true},
 		ServiceType:                 input.serviceType,
 		PropertyType:                stringToText(input.propertyType),
@@ -57,6 +64,10 @@ func (r *Resolver) createRecurringGroup(ctx context.Context, input createRecurri
 		SpecialInstructions:         stringToText(input.specialInstructions),
 		HourlyRate:                  float64ToNumeric(input.hourlyRate),
 		EstimatedTotalPerOccurrence: float64ToNumeric(input.estimatedTotal),
+		EstimatedDurationHours:      float64ToNumeric(input.estimatedHours),
+		GeneratedUntil:              input.firstBooking.ScheduledDate,
+		AnchorDate:                  input.firstBooking.ScheduledDate,
+		Rrule:                       stringToTextVal(input.rrule),
 	})
 	if err != nil {
 		return db.RecurringBookingGroup{}, fmt.Errorf("failed to create recurring group: %w", err)
@@ -80,133 +91,387 @@ func (r *Resolver) createRecurringGroup(ctx context.Context, input createRecurri
 	)
 	if err != nil {
 		log.Printf("failed to link first booking to recurring group: %v", err)
+		return group, nil
 	}
 
-	// Generate 7 more occurrence dates (total 8).
-	firstDate := input.firstBooking.ScheduledDate.Time
-	futureDates := generateOccurrenceDates(input.recurrenceType, firstDate, 7)
-
-	for i, occDate := range futureDates {
-		occNum := i + 2 // occurrence 2 through 8
-
-		// Find available cleaner for this date.
-		assignedCleanerID := r.findAvailableCleanerForDate(ctx, input.cleanerID, input.companyID, occDate)
-
-		refCode := fmt.Sprintf("HMC-%d", time.Now().UnixNano()%1000000+int64(occNum))
-
-		booking, createErr := r.Queries.CreateBooking(ctx, db.CreateBookingParams{
-			ReferenceCode:          refCode,
-			ClientUserID:           input.clientUserID,
-			AddressID:              input.addressID,
-			ServiceType:            input.serviceType,
-			ScheduledDate:          pgtype.Date{Time: occDate, Valid: true},
-			ScheduledStartTime:     pgtype.Time{Microseconds: preferredTimeMicros, Valid: true},
-			EstimatedDurationHours: float64ToNumeric(input.estimatedHours),
-			PropertyType:           stringToText(input.propertyType),
-			NumRooms:               intToInt4Val(input.numRooms),
-			NumBathrooms:           intToInt4Val(input.numBathrooms),
-			AreaSqm:                intToInt4(input.areaSqm),
-			HasPets:                boolToPgBool(input.hasPets),
-			SpecialInstructions:    stringToText(input.specialInstructions),
-			HourlyRate:             float64ToNumeric(input.hourlyRate),
-			EstimatedTotal:         float64ToNumeric(input.estimatedTotal),
-			RecurringGroupID:       group.ID,
-			OccurrenceNumber:       pgtype.Int4{Int32: int32(occNum), Valid: true},
-		})
-		if createErr != nil {
-			log.Printf("failed to create occurrence %d: %v", occNum, createErr)
+	// Materialise the occurrences up to the horizon right away; the
+	// scheduled job keeps extending them from here on.
+	horizon := recurringHorizon(ctx, r.Queries)
+	config := loadMatchConfig(ctx, r.Queries)
+	if _, _, err := r.extendRecurringGroup(ctx, group, horizon, config); err != nil {
+		log.Printf("failed to generate occurrences for recurring group %s: %v", uuidToString(group.ID), err)
+	}
+
+	return group, nil
+}
+
+// recurringHorizon returns the last date recurring occurrences are generated
+// for, recurring_horizon_weeks (default 8) from today.
+func recurringHorizon(ctx context.Context, queries *db.Queries) time.Time {
+	weeks := 8
+	if v, err := queries.GetPlatformSetting(ctx, "recurring_horizon_weeks"); err == nil {
+		if n, err := strconv.Atoi(v.Value); err == nil && n > 0 {
+			weeks = n
+		}
+	}
+	return recurrence.Today().AddDate(0, 0, 7*weeks)
+}
+
+// GenerateRecurringOccurrences keeps every active recurring group materialised
+// up to the rolling horizon. It is run periodically by the job scheduler;
+// paused and cancelled groups are skipped.
+func (r *Resolver) GenerateRecurringOccurrences(ctx context.Context) error {
+	horizon := recurringHorizon(ctx, r.Queries)
+	groups, err := r.Queries.ListRecurringGroupsToExtend(ctx, pgtype.Date{Time: horizon, Valid: true})
+	if err != nil {
+		return fmt.Errorf("failed to list recurring groups: %w", err)
+	}
+	config := loadMatchConfig(ctx, r.Queries)
+
+	var created, unstaffed, failed int
+	for _, g := range groups {
+		c, u, err := r.extendRecurringGroup(ctx, g, horizon, config)
+		created += c
+		unstaffed += u
+		if err != nil {
+			log.Printf("[RECURRING] Group %s: %v", uuidToString(g.ID), err)
+			failed++
+		}
+	}
+
+	log.Printf("[RECURRING] Extended %d groups to %s: %d occurrences created, %d without a cleaner",
+		len(groups)-failed, horizon.Format("2006-01-02"), created, unstaffed)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d recurring groups failed", failed, len(groups))
+	}
+	return nil
+}
+
+// rewindGeneratedUntil moves generated_until back to the day before applyFrom
+// so the edited series is generated again from there. It never moves it
+// forward, which would skip dates not generated yet, nor before yesterday.
+func rewindGeneratedUntil(current pgtype.Date, applyFrom time.Time) pgtype.Date {
+	until := applyFrom.AddDate(0, 0, -1)
+	if current.Valid && current.Time.Before(until) {
+		until = current.Time
+	}
+	if yesterday := recurrence.Today().AddDate(0, 0, -1); until.Before(yesterday) {
+		until = yesterday
+	}
+	return pgtype.Date{Time: until, Valid: true}
+}
+
+// extendRecurringGroup creates the group's occurrences after generated_until
+// (and never in the past) up to horizon by expanding the series rule. Dates
+// with a skip or reschedule exception are its EXDATEs and are left alone, as
+// are the company's closure days and public holidays it does not work. Each occurrence is staffed with the preferred
+// cleaner or a free teammate; if nobody is free it is created without a
+// cleaner and the company admin is notified.
+func (r *Resolver) extendRecurringGroup(ctx context.Context, g db.RecurringBookingGroup, horizon time.Time, config matching.MatchConfig) (created, unstaffed int, err error) {
+	first, err := r.Queries.GetFirstRecurringOccurrence(ctx, g.ID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to load first occurrence: %w", err)
+	}
+
+	from := recurrence.Today().AddDate(0, 0, 1)
+	if g.GeneratedUntil.Valid && !g.GeneratedUntil.Time.Before(from) {
+		from = g.GeneratedUntil.Time.AddDate(0, 0, 1)
+	}
+	anchor := first.ScheduledDate.Time
+	if g.AnchorDate.Valid {
+		anchor = g.AnchorDate.Time
+	}
+	rule, err := groupRule(g, anchor)
+	if err != nil {
+		return 0, 0, err
+	}
+	exdates, err := r.recurringExdates(ctx, g.ID)
+	if err != nil {
+		return 0, 0, err
+	}
+	dates := rule.Between(anchor, from, horizon, exdates)
+
+	calendar, err := r.loadCompanyCalendar(ctx, g.CompanyID, from, horizon)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	lastOcc, err := r.Queries.GetMaxOccurrenceNumber(ctx, g.ID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to load occurrence number: %w", err)
+	}
+	extras, err := r.Queries.GetRecurringGroupExtras(ctx, g.ID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to load extras: %w", err)
+	}
+
+	hours := numericToFloat(g.EstimatedDurationHours)
+	if hours <= 0 {
+		hours = numericToFloat(first.EstimatedDurationHours)
+	}
+	startMicros := g.PreferredTime.Microseconds
+	endMicros := startMicros + int64(hours*float64(matching.HourMicros))
+
+	occNum := lastOcc
+	for _, occDate := range dates {
+		if reason, closed := calendar.closedOn(occDate); closed {
+			log.Printf("[RECURRING] Group %s: no occurrence on %s (%s)",
+				uuidToString(g.ID), occDate.Format("2006-01-02"), reason)
 			continue
 		}
+		occNum++
+		cleanerID := r.findAvailableCleanerForDate(ctx, g.PreferredCleanerID, g.CompanyID, g.ServiceType, occDate, startMicros, endMicros, config, pgtype.UUID{})
+
+		booking, err := r.createRecurringOccurrence(ctx, g, first, occDate, occNum, hours, cleanerID, extras)
+		if err != nil {
+			return created, unstaffed, fmt.Errorf("failed to create occurrence %d: %w", occNum, err)
+		}
+		created++
+		if !cleanerID.Valid {
+			unstaffed++
+			r.notifyUnstaffedOccurrence(ctx, g, booking)
+		}
+	}
+
+	if err := r.Queries.SetRecurringGroupGeneratedUntil(ctx, db.SetRecurringGroupGeneratedUntilParams{
+		ID:             g.ID,
+		GeneratedUntil: pgtype.Date{Time: horizon, Valid: true},
+	}); err != nil {
+		return created, unstaffed, fmt.Errorf("failed to update generated_until: %w", err)
+	}
+	return created, unstaffed, nil
+}
+
+// createRecurringOccurrence inserts one occurrence with its extras and moves
+// the group's generated_until to its date in the same transaction, so a
+// failed run never creates the same occurrence twice.
+func (r *Resolver) createRecurringOccurrence(ctx context.Context, g db.RecurringBookingGroup, first db.Booking, occDate time.Time, occNum int32, hours float64, cleanerID pgtype.UUID, extras []db.GetRecurringGroupExtrasRow) (db.Booking, error) {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return db.Booking{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	qtx := r.Queries.WithTx(tx)
+
+	refCode := fmt.Sprintf("HMC-%d", time.Now().UnixNano()%1000000+int64(occNum))
+	booking, err := qtx.CreateBooking(ctx, db.CreateBookingParams{
+		ReferenceCode:          refCode,
+		ClientUserID:           g.ClientUserID,
+		AddressID:              g.AddressID,
+		ServiceType:            g.ServiceType,
+		ScheduledDate:          pgtype.Date{Time: occDate, Valid: true},
+		ScheduledStartTime:     g.PreferredTime,
+		EstimatedDurationHours: float64ToNumeric(hours),
+		PropertyType:           g.PropertyType,
+		NumRooms:               g.NumRooms,
+		NumBathrooms:           g.NumBathrooms,
+		AreaSqm:                g.AreaSqm,
+		HasPets:                g.HasPets,
+		SpecialInstructions:    g.SpecialInstructions,
+		HourlyRate:             g.HourlyRate,
+		EstimatedTotal:         g.EstimatedTotalPerOccurrence,
+		RecurringGroupID:       g.ID,
+		OccurrenceNumber:       pgtype.Int4{Int32: occNum, Valid: true},
+		// The group's estimate per occurrence was quoted with it.
+		PetsSurcharge: float64ToNumeric(petsSurcharge(g.HasPets.Bool)),
+	})
+	if err != nil {
+		return db.Booking{}, err
+	}
 
-		// Assign cleaner + company to the occurrence.
-		_, _ = r.Queries.SetBookingPreferredCleaner(ctx, db.SetBookingPreferredCleanerParams{
+	// Assign cleaner + company, or only the company if nobody is free.
+	if cleanerID.Valid {
+		booking, err = qtx.SetBookingPreferredCleaner(ctx, db.SetBookingPreferredCleanerParams{
 			ID:        booking.ID,
-			CompanyID: input.companyID,
-			CleanerID: assignedCleanerID,
+			CompanyID: g.CompanyID,
+			CleanerID: cleanerID,
 		})
+	} else {
+		booking, err = qtx.SetBookingCompany(ctx, db.SetBookingCompanyParams{
+			ID:        booking.ID,
+			CompanyID: g.CompanyID,
+		})
+	}
+	if err != nil {
+		return db.Booking{}, fmt.Errorf("failed to assign occurrence: %w", err)
+	}
 
-		// Copy extras to booking_extras.
-		if input.extras != nil {
-			for _, extra := range input.extras {
-				extraDef, extraErr := r.Queries.GetExtraByID(ctx, stringToUUID(extra.ExtraID))
-				if extraErr == nil {
-					_ = r.Queries.InsertBookingExtra(ctx, db.InsertBookingExtraParams{
-						BookingID: booking.ID,
-						ExtraID:   stringToUUID(extra.ExtraID),
-						Price:     extraDef.Price,
-						Quantity:  pgtype.Int4{Int32: int32(extra.Quantity), Valid: true},
-					})
-				}
-			}
+	for _, extra := range extras {
+		if err := qtx.InsertBookingExtra(ctx, db.InsertBookingExtraParams{
+			BookingID: booking.ID,
+			ExtraID:   extra.ExtraID,
+			Price:     extra.Price,
+			Quantity:  extra.Quantity,
+		}); err != nil {
+			return db.Booking{}, fmt.Errorf("failed to copy extras: %w", err)
 		}
 	}
 
-	return group, nil
+	booking, err = r.applyHolidaySurcharge(ctx, qtx, booking)
+	if err != nil {
+		return db.Booking{}, fmt.Errorf("failed to apply holiday surcharge: %w", err)
+	}
+
+	if err := qtx.SetRecurringGroupGeneratedUntil(ctx, db.SetRecurringGroupGeneratedUntilParams{
+		ID:             g.ID,
+		GeneratedUntil: pgtype.Date{Time: occDate, Valid: true},
+	}); err != nil {
+		return db.Booking{}, fmt.Errorf("failed to update generated_until: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return db.Booking{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return booking, nil
 }
 
-// generateOccurrenceDates generates future dates based on recurrence type.
-// Returns `count` dates after the firstDate.
-func generateOccurrenceDates(recType db.RecurrenceType, firstDate time.Time, count int) []time.Time {
-	dates := make([]time.Time, 0, count)
-	current := firstDate
-
-	for i := 0; i < count; i++ {
-		switch recType {
-		case db.RecurrenceTypeWeekly:
-			current = current.AddDate(0, 0, 7)
-		case db.RecurrenceTypeBiweekly:
-			current = current.AddDate(0, 0, 14)
-		case db.RecurrenceTypeMonthly:
-			current = current.AddDate(0, 1, 0)
+// recurrenceSpec is a validated series definition from a RecurrenceInput.
+type recurrenceSpec struct {
+	recurrenceType db.RecurrenceType
+	rrule          string
+	dayOfWeek      *int
+}
+
+// resolveRecurrence validates a RecurrenceInput for a series starting on
+// start. An rrule makes a custom series; the legacy types map onto the
+// equivalent rule so every series is expanded the same way.
+func resolveRecurrence(in *model.RecurrenceInput, start time.Time) (recurrenceSpec, error) {
+	if in.Rrule != nil && *in.Rrule != "" {
+		rule, err := recurrence.Parse(*in.Rrule)
+		if err != nil {
+			return recurrenceSpec{}, fmt.Errorf("invalid recurrence rule: %w", err)
 		}
-		dates = append(dates, current)
+		spec := recurrenceSpec{recurrenceType: db.RecurrenceTypeCustom, rrule: rule.String()}
+		if len(rule.ByDay) == 1 && rule.ByDay[0].N == 0 {
+			day := int(rule.ByDay[0].Weekday)
+			spec.dayOfWeek = &day
+		}
+		return spec, nil
 	}
 
-	return dates
+	if in.Type == nil || *in.Type == model.RecurrenceTypeCustom {
+		return recurrenceSpec{}, fmt.Errorf("recurrence needs a type or an rrule")
+	}
+	recType := gqlRecurrenceTypeToDb(*in.Type)
+	rule, err := recurrence.FromLegacy(string(recType), start)
+	if err != nil {
+		return recurrenceSpec{}, err
+	}
+	day := int(start.Weekday())
+	return recurrenceSpec{recurrenceType: recType, rrule: rule, dayOfWeek: &day}, nil
 }
 
-// findAvailableCleanerForDate checks if the preferred cleaner is available on the given date.
-// If not, returns an available teammate from the same company.
-// Falls back to the preferred cleaner if no teammate is available.
-func (r *Resolver) findAvailableCleanerForDate(ctx context.Context, preferredCleanerID, companyID pgtype.UUID, date time.Time) pgtype.UUID {
-	// Check if preferred cleaner has a conflicting booking on this date.
-	if !r.hasConflictOnDate(ctx, preferredCleanerID, date) {
-		return preferredCleanerID
+// groupRule returns the group's recurrence rule. Groups without a stored
+// rule use the one equivalent to their recurrence type.
+func groupRule(g db.RecurringBookingGroup, anchor time.Time) (*recurrence.Rule, error) {
+	rrule := g.Rrule.String
+	if !g.Rrule.Valid || rrule == "" {
+		var err error
+		if rrule, err = recurrence.FromLegacy(string(g.RecurrenceType), anchor); err != nil {
+			return nil, err
+		}
+	}
+	rule, err := recurrence.Parse(rrule)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recurrence rule %q: %w", rrule, err)
+	}
+	return rule, nil
+}
+
+// recurringExdates returns the original dates of a group's skipped and
+// rescheduled occurrences.
+func (r *Resolver) recurringExdates(ctx context.Context, groupID pgtype.UUID) ([]time.Time, error) {
+	exceptions, err := r.Queries.ListOccurrenceExceptions(ctx, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load exceptions: %w", err)
+	}
+	exdates := make([]time.Time, len(exceptions))
+	for i, e := range exceptions {
+		exdates[i] = e.OriginalDate.Time
+	}
+	return exdates, nil
+}
+
+// findAvailableCleanerForDate returns the preferred cleaner if the occurrence
+// window [startMicros, endMicros) fits in their free time on date, otherwise
+// the least loaded active teammate who can do the service and has the window
+// free. The booking excludeBookingID, if valid, does not count as busy time.
+// It returns an invalid UUID when nobody is available.
+func (r *Resolver) findAvailableCleanerForDate(ctx context.Context, preferredCleanerID, companyID pgtype.UUID, serviceType db.ServiceType, date time.Time, startMicros, endMicros int64, config matching.MatchConfig, excludeBookingID pgtype.UUID) pgtype.UUID {
+	if preferredCleanerID.Valid {
+		if _, ok := r.cleanerFreeForWindow(ctx, preferredCleanerID, companyID, serviceType, date, startMicros, endMicros, config, excludeBookingID); ok {
+			return preferredCleanerID
+		}
 	}
 
-	// Find a teammate from the same company who is available.
 	teammates, err := r.Queries.ListCleanersByCompany(ctx, companyID)
 	if err != nil {
-		return preferredCleanerID // fallback
+		return pgtype.UUID{}
 	}
 
+	var best pgtype.UUID
+	bestLoad := -1
 	for _, mate := range teammates {
-		if mate.ID == preferredCleanerID {
+		if mate.ID == preferredCleanerID || mate.Status != db.CleanerStatusActive {
 			continue
 		}
-		if string(mate.Status) != "active" {
-			continue
+		load, ok := r.cleanerFreeForWindow(ctx, mate.ID, companyID, serviceType, date, startMicros, endMicros, config, excludeBookingID)
+		if ok && (bestLoad < 0 || load < bestLoad) {
+			best, bestLoad = mate.ID, load
+		}
+	}
+	return best
+}
+
+// cleanerFreeForWindow reports whether the window fits in the cleaner's free
+// time on date (buffer included) without exceeding the daily job limit, and
+// how many jobs the cleaner already has that day.
+func (r *Resolver) cleanerFreeForWindow(ctx context.Context, cleanerID, companyID pgtype.UUID, serviceType db.ServiceType, date time.Time, startMicros, endMicros int64, config matching.MatchConfig, excludeBookingID pgtype.UUID) (int, bool) {
+	if skills, err := r.Queries.ListCleanerSkills(ctx, cleanerID); err == nil && len(skills) > 0 {
+		has := false
+		for _, st := range skills {
+			has = has || st == serviceType
 		}
-		if !r.hasConflictOnDate(ctx, mate.ID, date) {
-			return mate.ID
+		if !has {
+			return 0, false
 		}
 	}
 
-	// No available teammate — fall back to preferred cleaner.
-	return preferredCleanerID
+	dateStr := date.Format("2006-01-02")
+	avails, err := r.cleanerDateAvailabilities(ctx, cleanerID, companyID, map[string]time.Time{dateStr: date}, config.BufferMicros(), excludeBookingID)
+	if err != nil || len(avails) == 0 {
+		return 0, false
+	}
+	avail := avails[0]
+	if config.MaxJobsPerDay > 0 && avail.BookingCount >= config.MaxJobsPerDay {
+		return avail.BookingCount, false
+	}
+	placement := matching.FindOptimalPlacement(avail.FreeIntervals,
+		[]matching.TimeSlot{{StartMicros: startMicros, EndMicros: endMicros}}, endMicros-startMicros)
+	return avail.BookingCount, placement.Found
 }
 
-// hasConflictOnDate checks if a cleaner already has a booking on the given date.
-func (r *Resolver) hasConflictOnDate(ctx context.Context, cleanerID pgtype.UUID, date time.Time) bool {
-	bookings, err := r.Queries.ListBookingsByCleanerAndDateRange(ctx, db.ListBookingsByCleanerAndDateRangeParams{
-		CleanerID: cleanerID,
-		DateFrom:  pgtype.Date{Time: date, Valid: true},
-		DateTo:    pgtype.Date{Time: date, Valid: true},
+// notifyUnstaffedOccurrence tells the company admin that a generated
+// occurrence has no cleaner yet.
+func (r *Resolver) notifyUnstaffedOccurrence(ctx context.Context, g db.RecurringBookingGroup, b db.Booking) {
+	company, err := r.Queries.GetCompanyByID(ctx, g.CompanyID)
+	if err != nil || !company.AdminUserID.Valid {
+		return
+	}
+	data, _ := json.Marshal(map[string]string{
+		"bookingId":        uuidToString(b.ID),
+		"recurringGroupId": uuidToString(g.ID),
 	})
-	if err != nil {
-		return false
+	if _, err := r.Queries.CreateNotification(ctx, db.CreateNotificationParams{
+		UserID: company.AdminUserID,
+		Type:   db.NotificationTypeBookingCreated,
+		Title:  "Programare recurenta fara curatator",
+		Body: fmt.Sprintf("Programarea %s din %s nu a putut fi alocata automat. Alegeti un curatator.",
+			b.ReferenceCode, b.ScheduledDate.Time.Format("02.01.2006")),
+		Data: data,
+	}); err != nil {
+		log.Printf("failed to notify company about unstaffed occurrence: %v", err)
 	}
-	return len(bookings) > 0
 }
 
 // enrichRecurringGroup populates related entities on a recurring group GQL model.
@@ -272,6 +537,32 @@ func (r *Resolver) enrichRecurringGroup(ctx context.Context, g db.RecurringBooki
 		gql.CompletedOccurrences = completedCount
 	}
 
+	// Series rule.
+	anchor := g.AnchorDate.Time
+	if !g.AnchorDate.Valid {
+		if first, err := r.Queries.GetFirstRecurringOccurrence(ctx, g.ID); err == nil {
+			anchor = first.ScheduledDate.Time
+		}
+	}
+	if rule, err := groupRule(g, anchor); err == nil {
+		gql.Rrule = rule.String()
+	}
+
+	// One-off exceptions to the series; their original dates are the EXDATEs.
+	if exceptions, err := r.Queries.ListOccurrenceExceptions(ctx, g.ID); err == nil {
+		for _, e := range exceptions {
+			gql.Exdates = append(gql.Exdates, dateToString(e.OriginalDate))
+			gqlE := dbOccurrenceExceptionToGQL(e)
+			if e.BookingID.Valid {
+				if b, err := r.Queries.GetBookingByID(ctx, e.BookingID); err == nil {
+					gqlE.Booking = dbBookingToGQL(b)
+					r.enrichBooking(ctx, b, gqlE.Booking)
+				}
+			}
+			gql.Exceptions = append(gql.Exceptions, gqlE)
+		}
+	}
+
 	// Upcoming occurrences.
 	upcomingBookings, err := r.Queries.GetUpcomingBookingsByRecurringGroup(ctx, g.ID)
 	if err == nil {
@@ -284,3 +575,57 @@ func (r *Resolver) enrichRecurringGroup(ctx context.Context, g db.RecurringBooki
 
 	return gql, nil
 }
+
+// loadEditableOccurrence loads a recurring occurrence the caller may skip or
+// reschedule: it must belong to the caller (or the caller is a global admin)
+// and must not have started yet.
+func (r *Resolver) loadEditableOccurrence(ctx context.Context, claims *auth.Claims, bookingID string) (db.Booking, error) {
+	booking, err := r.Queries.GetBookingByID(ctx, stringToUUID(bookingID))
+	if err != nil {
+		return db.Booking{}, fmt.Errorf("booking not found: %w", err)
+	}
+	if !booking.RecurringGroupID.Valid {
+		return db.Booking{}, fmt.Errorf("booking is not part of a recurring series")
+	}
+	if uuidToString(booking.ClientUserID) != claims.UserID && claims.Role != "global_admin" {
+		return db.Booking{}, fmt.Errorf("not authorized")
+	}
+	switch booking.Status {
+	case db.BookingStatusPending, db.BookingStatusAssigned, db.BookingStatusConfirmed:
+	default:
+		return db.Booking{}, fmt.Errorf("only upcoming occurrences can be changed")
+	}
+	if booking.StartedAt.Valid {
+		return db.Booking{}, fmt.Errorf("only upcoming occurrences can be changed")
+	}
+	return booking, nil
+}
+
+// occurrenceOriginalDate returns the date the series originally generated the
+// occurrence for: the key of its exception if it was already rescheduled,
+// otherwise its scheduled date.
+func (r *Resolver) occurrenceOriginalDate(ctx context.Context, b db.Booking) pgtype.Date {
+	if e, err := r.Queries.GetOccurrenceExceptionByBooking(ctx, b.ID); err == nil {
+		return e.OriginalDate
+	}
+	return b.ScheduledDate
+}
+
+// nextWeekday returns the first date on or after from that falls on weekday
+// (0 = Sunday).
+func nextWeekday(from time.Time, weekday int) time.Time {
+	diff := (weekday - int(from.Weekday()) + 7) % 7
+	return from.AddDate(0, 0, diff)
+}
+
+// recurringGroupExtraInputs returns the group's extras in the form used for pricing.
+func recurringGroupExtraInputs(extras []db.GetRecurringGroupExtrasRow) []*model.ExtraInput {
+	inputs := make([]*model.ExtraInput, 0, len(extras))
+	for _, e := range extras {
+		inputs = append(inputs, &model.ExtraInput{
+			ExtraID:  uuidToString(e.ExtraID),
+			Quantity: int(e.Quantity.Int32),
+		})
+	}
+	return inputs
+}
@@ -0,0 +1,35 @@
+package resolver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"helpmeclean-backend/internal/service/recurrence"
+)
+
+func TestRewindGeneratedUntil(t *testing.T) {
+	today := recurrence.Today()
+	day := func(offset int) pgtype.Date {
+		return pgtype.Date{Time: today.AddDate(0, 0, offset), Valid: true}
+	}
+	applyFrom := today.AddDate(0, 0, 10)
+
+	tests := []struct {
+		name    string
+		current pgtype.Date
+		want    time.Time
+	}{
+		{"generated past applyFrom", day(30), today.AddDate(0, 0, 9)},
+		{"generated short of applyFrom", day(4), today.AddDate(0, 0, 4)},
+		{"generated long ago", day(-20), today.AddDate(0, 0, -1)},
+		{"never generated", pgtype.Date{}, today.AddDate(0, 0, 9)},
+	}
+	for _, tt := range tests {
+		got := rewindGeneratedUntil(tt.current, applyFrom)
+		if !got.Valid || !got.Time.Equal(tt.want) {
+			t.Errorf("%s: generated_until = %v, want %v", tt.name, got.Time, tt.want)
+		}
+	}
+}
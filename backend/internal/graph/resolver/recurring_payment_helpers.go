@@ -0,0 +1,162 @@
+package resolver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	db "helpmeclean-backend/internal/db/generated"
+	"helpmeclean-backend/internal/service/payment"
+	"helpmeclean-backend/internal/service/recurrence"
+)
+
+// recurringChargeBatch is how many occurrences one charging run handles.
+const recurringChargeBatch = 100
+
+// loadRecurringChargeConfig reads how long before an occurrence starts it is
+// charged (default 48 hours) and after how many consecutive failed charges
+// its series is paused (default 3) from platform_settings.
+func loadRecurringChargeConfig(ctx context.Context, queries *db.Queries) (time.Duration, int32) {
+	lead := 48 * time.Hour
+	if v, err := queries.GetPlatformSetting(ctx, "recurring_charge_lead_hours"); err == nil {
+		if f, err := strconv.ParseFloat(v.Value, 64); err == nil && f > 0 {
+			lead = time.Duration(f * float64(time.Hour))
+		}
+	}
+	maxFailures := int32(3)
+	if v, err := queries.GetPlatformSetting(ctx, "recurring_charge_max_failures"); err == nil {
+		if n, err := strconv.Atoi(v.Value); err == nil && n > 0 {
+			maxFailures = int32(n)
+		}
+	}
+	return lead, maxFailures
+}
+
+// ChargeRecurringOccurrences charges the occurrences of active recurring
+// series that start within the configured lead time, off-session with the
+// client's default saved card. When a charge fails the client is notified
+// with a link to pay on-session; a series whose charges fail the configured
+// number of times in a row is paused. It is run periodically by the job
+// scheduler; an occurrence is only ever charged once automatically.
+func (r *Resolver) ChargeRecurringOccurrences(ctx context.Context) error {
+	lead, maxFailures := loadRecurringChargeConfig(ctx, r.Queries)
+	opts := loadCaptureOptions(ctx, r.Queries, false)
+
+	bookings, err := r.Queries.ListRecurringOccurrencesToCharge(ctx, db.ListRecurringOccurrencesToChargeParams{
+		ChargeBefore: pgtype.Timestamptz{Time: time.Now().Add(lead), Valid: true},
+		Limit:        recurringChargeBatch,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list occurrences to charge: %w", err)
+	}
+
+	var charged, declined, failed int
+	for _, b := range bookings {
+		outcome, err := r.PaymentService.ChargeOffSession(ctx, b, opts)
+		if err != nil {
+			log.Printf("[RECURRING] Booking %s: charge not attempted: %v", b.ReferenceCode, err)
+			failed++
+			continue
+		}
+		if outcome.Succeeded() {
+			charged++
+			if err := r.Queries.ResetRecurringPaymentFailures(ctx, b.RecurringGroupID); err != nil {
+				log.Printf("[RECURRING] Series %s: failed to reset payment failures: %v", uuidToString(b.RecurringGroupID), err)
+			}
+			continue
+		}
+
+		declined++
+		r.notifyRecurringPaymentFailed(ctx, b, outcome)
+		failures, err := r.Queries.IncrementRecurringPaymentFailures(ctx, b.RecurringGroupID)
+		if err != nil {
+			log.Printf("[RECURRING] Series %s: failed to count payment failure: %v", uuidToString(b.RecurringGroupID), err)
+			continue
+		}
+		if failures >= maxFailures {
+			r.pauseRecurringGroupForPayment(ctx, b, failures)
+		}
+	}
+
+	if len(bookings) > 0 {
+		log.Printf("[RECURRING] Charged %d occurrences, %d declined, %d not attempted", charged, declined, failed)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d occurrences could not be charged", failed, len(bookings))
+	}
+	return nil
+}
+
+// notifyRecurringPaymentFailed asks the client to pay an occurrence whose
+// automatic charge failed, with a link to the booking's payment page.
+func (r *Resolver) notifyRecurringPaymentFailed(ctx context.Context, b db.Booking, outcome payment.ChargeOutcome) {
+	link := r.PaymentService.PaymentLink(b.ID)
+	body := fmt.Sprintf("Plata automata pentru programarea %s din %s nu a reusit (%s). Platiti aici: %s",
+		b.ReferenceCode, b.ScheduledDate.Time.Format("02.01.2006"), outcome.FailureReason, link)
+	if outcome.RequiresAction {
+		body = fmt.Sprintf("Banca dumneavoastra cere confirmarea platii pentru programarea %s din %s. Confirmati plata aici: %s",
+			b.ReferenceCode, b.ScheduledDate.Time.Format("02.01.2006"), link)
+	}
+	data, _ := json.Marshal(map[string]string{
+		"bookingId":        uuidToString(b.ID),
+		"recurringGroupId": uuidToString(b.RecurringGroupID),
+		"paymentUrl":       link,
+	})
+	if _, err := r.Queries.CreateNotification(ctx, db.CreateNotificationParams{
+		UserID: b.ClientUserID,
+		Type:   db.NotificationTypePaymentFailed,
+		Title:  "Plata programarii recurente nu a reusit",
+		Body:   body,
+		Data:   data,
+	}); err != nil {
+		log.Printf("[RECURRING] Failed to notify client about failed payment: %v", err)
+	}
+}
+
+// pauseRecurringGroupForPayment pauses a series after repeated failed
+// charges, cancelling its future occurrences like pauseRecurringGroup, and
+// tells the client and the company.
+func (r *Resolver) pauseRecurringGroupForPayment(ctx context.Context, b db.Booking, failures int32) {
+	group, err := r.Queries.PauseRecurringGroup(ctx, b.RecurringGroupID)
+	if err != nil {
+		log.Printf("[RECURRING] Series %s: failed to pause: %v", uuidToString(b.RecurringGroupID), err)
+		return
+	}
+	if err := r.Queries.CancelRegenerableOccurrences(ctx, db.CancelRegenerableOccurrencesParams{
+		CancellationReason: pgtype.Text{String: "Serie recurenta pusa pe pauza: plati esuate", Valid: true},
+		RecurringGroupID:   group.ID,
+		ApplyFrom:          pgtype.Date{Time: recurrence.Today(), Valid: true},
+	}); err != nil {
+		log.Printf("[RECURRING] Series %s: failed to cancel future occurrences: %v", uuidToString(group.ID), err)
+	}
+	r.releaseRecurringHolds(ctx, group.ID)
+	log.Printf("[RECURRING] Series %s paused after %d failed payments", uuidToString(group.ID), failures)
+
+	data, _ := json.Marshal(map[string]string{"recurringGroupId": uuidToString(group.ID)})
+	if _, err := r.Queries.CreateNotification(ctx, db.CreateNotificationParams{
+		UserID: group.ClientUserID,
+		Type:   db.NotificationTypePaymentFailed,
+		Title:  "Seria recurenta a fost pusa pe pauza",
+		Body: fmt.Sprintf("Dupa %d plati automate esuate, seria de curatenie a fost pusa pe pauza. Actualizati cardul salvat si reluati seria.",
+			failures),
+		Data: data,
+	}); err != nil {
+		log.Printf("[RECURRING] Failed to notify client about paused series: %v", err)
+	}
+	if company, err := r.Queries.GetCompanyByID(ctx, group.CompanyID); err == nil && company.AdminUserID.Valid {
+		if _, err := r.Queries.CreateNotification(ctx, db.CreateNotificationParams{
+			UserID: company.AdminUserID,
+			Type:   db.NotificationTypeBookingCancelled,
+			Title:  "Serie recurenta pusa pe pauza",
+			Body:   "Programarile viitoare ale unei serii recurente au fost anulate deoarece platile clientului au esuat.",
+			Data:   data,
+		}); err != nil {
+			log.Printf("[RECURRING] Failed to notify company about paused series: %v", err)
+		}
+	}
+}
@@ -8,24 +8,29 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 
+	"helpmeclean-backend/internal/auth"
 	db "helpmeclean-backend/internal/db/generated"
 	"helpmeclean-backend/internal/graph/model"
 	"helpmeclean-backend/internal/middleware"
+	"helpmeclean-backend/internal/service/accounting"
 	"helpmeclean-backend/internal/service/email"
 	"helpmeclean-backend/internal/service/invoice"
+	"helpmeclean-backend/internal/service/ledger"
 	"helpmeclean-backend/internal/service/payment"
 	"helpmeclean-backend/internal/storage"
 )
 
 // Resolver is the root resolver struct.
 type Resolver struct {
-	Pool           *pgxpool.Pool
-	Queries        *db.Queries
-	PaymentService *payment.Service
-	InvoiceService *invoice.Service
-	EmailService   *email.Service
-	Storage        storage.Storage
-	AuthzHelper    *middleware.AuthzHelper
+	Pool              *pgxpool.Pool
+	Queries           *db.Queries
+	PaymentService    *payment.Service
+	InvoiceService    *invoice.Service
+	LedgerService     *ledger.Service
+	AccountingService *accounting.Service
+	EmailService      *email.Service
+	Storage           storage.Storage
+	AuthzHelper       *middleware.AuthzHelper
 }
 
 // cleanerWithCompany loads a cleaner's company, user, documents, and assessment, returns the full CleanerProfile.
@@ -56,6 +61,13 @@ func (r *Resolver) cleanerWithCompany(ctx context.Context, c db.Cleaner) (*model
 		}
 	}
 
+	// Load service skills; none means the cleaner does every service.
+	if skills, err := r.Queries.ListCleanerSkills(ctx, c.ID); err == nil {
+		for _, st := range skills {
+			profile.Skills = append(profile.Skills, dbServiceTypeToGQL(st))
+		}
+	}
+
 	// Load personality assessment if exists
 	if assessment, err := r.Queries.GetPersonalityAssessmentByCleanerID(ctx, c.ID); err == nil {
 		profile.PersonalityAssessment = dbPersonalityAssessmentToGQL(assessment)
@@ -206,6 +218,8 @@ func (r *Resolver) enrichBooking(ctx context.Context, dbB db.Booking, gqlB *mode
 			}
 		}
 	}
+	// Load team members for multi-cleaner bookings.
+	r.enrichBookingTeam(ctx, dbB, gqlB)
 	// Load time slots.
 	if slots, err := r.Queries.ListBookingTimeSlots(ctx, dbB.ID); err == nil {
 		for _, slot := range slots {
@@ -269,4 +283,51 @@ func (r *Resolver) enrichInvoice(ctx context.Context, inv db.Invoice, gql *model
 			gql.Company = dbCompanyToGQL(company)
 		}
 	}
+
+	// Sign the URL of the ANAF response archive if present.
+	if inv.EfacturaResponsePath.Valid {
+		if url, err := r.Storage.GetSignedURL(ctx, inv.EfacturaResponsePath.String); err == nil {
+			gql.EfacturaResponseURL = &url
+		}
+	}
+}
+
+// anafFiscalParty returns the fiscal code whose ANAF e-Factura connection the
+// caller manages: their company's for a company admin, the platform's for a
+// global admin (with an invalid company ID).
+func (r *Resolver) anafFiscalParty(ctx context.Context, claims *auth.Claims) (string, pgtype.UUID, error) {
+	switch claims.Role {
+	case "company_admin":
+		company, err := r.Queries.GetCompanyByAdminUserID(ctx, stringToUUID(claims.UserID))
+		if err != nil {
+			return "", pgtype.UUID{}, fmt.Errorf("company not found: %w", err)
+		}
+		return company.Cui, company.ID, nil
+	case "global_admin":
+		entity, err := r.Queries.GetPlatformLegalEntity(ctx)
+		if err != nil {
+			return "", pgtype.UUID{}, fmt.Errorf("platform legal entity not found: %w", err)
+		}
+		return entity.Cui, pgtype.UUID{}, nil
+	default:
+		return "", pgtype.UUID{}, fmt.Errorf("only company admins and global admins can manage the ANAF e-factura connection")
+	}
+}
+
+// accountingScope returns the company whose books a user exports and whose
+// invoice series they manage: the company of a company admin, or none for
+// global admins, who keep the platform's.
+func (r *Resolver) accountingScope(ctx context.Context, claims *auth.Claims) (pgtype.UUID, error) {
+	switch claims.Role {
+	case "company_admin":
+		company, err := r.Queries.GetCompanyByAdminUserID(ctx, stringToUUID(claims.UserID))
+		if err != nil {
+			return pgtype.UUID{}, fmt.Errorf("company not found: %w", err)
+		}
+		return company.ID, nil
+	case "global_admin":
+		return pgtype.UUID{}, nil
+	default:
+		return pgtype.UUID{}, fmt.Errorf("only company admins and global admins can manage accounting data")
+	}
 }
@@ -210,7 +210,7 @@ func (r *queryResolver) EstimatePrice(ctx context.Context, input model.PriceEsti
 	// Pets surcharge (flat fee on top of duration).
 	petsSurcharge := 0.0
 	if input.HasPets != nil && *input.HasPets {
-		petsSurcharge = 15.0
+		petsSurcharge = petsSurchargeAmount
 	}
 
 	total := subtotal + extrasTotal + petsSurcharge
@@ -0,0 +1,288 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	db "helpmeclean-backend/internal/db/generated"
+	"helpmeclean-backend/internal/graph/model"
+	"helpmeclean-backend/internal/service/invoice"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// errBookingNotInProgress is returned by completeBooking for a booking that
+// is not in progress, such as one a teammate has just completed.
+var errBookingNotInProgress = errors.New("booking is not in progress")
+
+// splitBani divides an amount in bani into n equal shares. Any remainder goes
+// to the first share (the team lead) so the shares always sum to the total.
+func splitBani(total int64, n int) []int64 {
+	if n < 1 {
+		n = 1
+	}
+	shares := make([]int64, n)
+	base := total / int64(n)
+	for i := range shares {
+		shares[i] = base
+	}
+	shares[0] += total - base*int64(n)
+	return shares
+}
+
+// teamPayShares splits the company's net amount of a booking, its final
+// total less the platform commission (both in RON), into n shares rounded to
+// the ban, as the booking's payout line items split it.
+func teamPayShares(finalTotal, commission float64, n int) []float64 {
+	net := int64(math.Round(finalTotal*100)) - int64(math.Round(commission*100))
+	bani := splitBani(net, n)
+	shares := make([]float64, len(bani))
+	for i, b := range bani {
+		shares[i] = float64(b) / 100
+	}
+	return shares
+}
+
+// setBookingTeam validates the cleaners and replaces the booking's team in
+// one transaction, so a failed insert never leaves the booking with a partial
+// or empty team.
+func (r *Resolver) setBookingTeam(ctx context.Context, bookingID pgtype.UUID, cleanerIDs []pgtype.UUID) error {
+	if err := r.checkBookingTeam(ctx, cleanerIDs); err != nil {
+		return err
+	}
+
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	if err := replaceBookingTeam(ctx, r.Queries.WithTx(tx), bookingID, cleanerIDs); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit booking team: %w", err)
+	}
+	return nil
+}
+
+// checkBookingTeam checks that the cleaners of a team are listed once, are
+// active and belong to the same company.
+func (r *Resolver) checkBookingTeam(ctx context.Context, cleanerIDs []pgtype.UUID) error {
+	seen := map[string]bool{}
+	var companyID pgtype.UUID
+	for i, id := range cleanerIDs {
+		key := uuidToString(id)
+		if seen[key] {
+			return fmt.Errorf("cleaner %s is listed twice", key)
+		}
+		seen[key] = true
+
+		cleaner, err := r.Queries.GetCleanerByID(ctx, id)
+		if err != nil {
+			return fmt.Errorf("cleaner not found: %w", err)
+		}
+		if cleaner.Status != db.CleanerStatusActive {
+			return fmt.Errorf("cleaner %s is not active", key)
+		}
+		if i == 0 {
+			companyID = cleaner.CompanyID
+		} else if cleaner.CompanyID != companyID {
+			return fmt.Errorf("all team members must belong to the same company")
+		}
+	}
+	return nil
+}
+
+// replaceBookingTeam replaces the booking's team with the cleaners, the
+// first of whom becomes the team lead. Run it with transaction-bound queries.
+func replaceBookingTeam(ctx context.Context, q *db.Queries, bookingID pgtype.UUID, cleanerIDs []pgtype.UUID) error {
+	if err := q.DeleteBookingTeamMembers(ctx, bookingID); err != nil {
+		return fmt.Errorf("failed to reset booking team: %w", err)
+	}
+	for i, id := range cleanerIDs {
+		if _, err := q.AddBookingTeamMember(ctx, db.AddBookingTeamMemberParams{
+			BookingID: bookingID,
+			CleanerID: id,
+			IsLead:    i == 0,
+		}); err != nil {
+			return fmt.Errorf("failed to add team member: %w", err)
+		}
+	}
+	return nil
+}
+
+// bookingLaborHours returns the total labour of a booking, estimated from its
+// service and details as when it was booked. The on-site window times the
+// team size overstates it, since the window is rounded up.
+func (r *Resolver) bookingLaborHours(ctx context.Context, b db.Booking) (float64, error) {
+	serviceDef, err := r.Queries.GetServiceByType(ctx, b.ServiceType)
+	if err != nil {
+		return 0, fmt.Errorf("service type not found: %w", err)
+	}
+	extras, err := r.Queries.ListBookingExtras(ctx, b.ID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load booking extras: %w", err)
+	}
+	var extrasDuration []struct {
+		DurationMinutes int32
+		Quantity        int
+	}
+	for _, e := range extras {
+		extrasDuration = append(extrasDuration, struct {
+			DurationMinutes int32
+			Quantity        int
+		}{DurationMinutes: e.DurationMinutes, Quantity: int(e.Quantity.Int32)})
+	}
+	return estimateDuration(serviceDef, int4Val(b.NumRooms), int4Val(b.NumBathrooms),
+		int4Ptr(b.AreaSqm), textPtr(b.PropertyType), boolPtr(b.HasPets), extrasDuration), nil
+}
+
+// completeBooking marks a booking completed, sets its final total (the
+// estimate, or adjusted to the actual hours when given) and platform
+// commission, and, for team bookings, closes out every member and records
+// each member's share of the company's net amount, all in one transaction.
+// An authorization hold is then captured for the final total. Only the call
+// that moves the booking out of in progress completes it; any other gets
+// errBookingNotInProgress.
+func (r *Resolver) completeBooking(ctx context.Context, bookingID pgtype.UUID, actualHours *float64) (db.Booking, error) {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return db.Booking{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	qtx := r.Queries.WithTx(tx)
+
+	booking, err := qtx.CompleteBooking(ctx, bookingID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return db.Booking{}, errBookingNotInProgress
+	}
+	if err != nil {
+		return db.Booking{}, fmt.Errorf("failed to complete job: %w", err)
+	}
+
+	// Calculate final total and platform commission.
+	finalTotal := numericToFloat(booking.EstimatedTotal)
+	if actualHours != nil {
+		finalTotal = adjustedFinalTotal(booking, *actualHours)
+	}
+	commissionPct := numericToFloat(booking.PlatformCommissionPct)
+	commissionAmount := finalTotal * (commissionPct / 100.0)
+
+	booking, err = qtx.SetBookingFinalTotal(ctx, db.SetBookingFinalTotalParams{
+		ID:                       booking.ID,
+		FinalTotal:               float64ToNumeric(finalTotal),
+		PlatformCommissionAmount: float64ToNumeric(commissionAmount),
+	})
+	if err != nil {
+		return db.Booking{}, fmt.Errorf("failed to set final total: %w", err)
+	}
+
+	if booking.TeamSize > 1 {
+		if err := qtx.CompleteAllBookingTeamMembers(ctx, booking.ID); err != nil {
+			return db.Booking{}, fmt.Errorf("failed to complete team members: %w", err)
+		}
+		members, err := qtx.ListBookingTeamMembers(ctx, booking.ID)
+		if err != nil {
+			return db.Booking{}, fmt.Errorf("failed to list team members: %w", err)
+		}
+		if len(members) > 0 {
+			shares := teamPayShares(finalTotal, commissionAmount, len(members))
+			for i, m := range members {
+				if err := qtx.SetBookingTeamMemberPayShare(ctx, db.SetBookingTeamMemberPayShareParams{
+					BookingID: booking.ID,
+					CleanerID: m.CleanerID,
+					PayShare:  float64ToNumeric(shares[i]),
+				}); err != nil {
+					return db.Booking{}, fmt.Errorf("failed to set team member pay share: %w", err)
+				}
+			}
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return db.Booking{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	r.capturePayment(ctx, booking)
+	// Companies that invoice on completion get the client invoice issued in
+	// the background; the invoice-pipeline job catches up on failures.
+	go r.InvoiceService.AutoIssue(context.Background(), booking, invoice.TriggerJobCompleted)
+	return booking, nil
+}
+
+// enrichBookingTeam populates the team members of a GQL booking.
+func (r *Resolver) enrichBookingTeam(ctx context.Context, dbB db.Booking, gqlB *model.Booking) {
+	if dbB.TeamSize <= 1 {
+		return
+	}
+	members, err := r.Queries.ListBookingTeamMembers(ctx, dbB.ID)
+	if err != nil {
+		return
+	}
+	for _, m := range members {
+		cleaner, err := r.Queries.GetCleanerByID(ctx, m.CleanerID)
+		if err != nil {
+			continue
+		}
+		profile, err := r.cleanerWithCompany(ctx, cleaner)
+		if err != nil {
+			continue
+		}
+		var payShare *float64
+		if m.PayShare.Valid {
+			v := numericToFloat(m.PayShare)
+			payShare = &v
+		}
+		gqlB.TeamMembers = append(gqlB.TeamMembers, &model.BookingTeamMember{
+			Cleaner:     profile,
+			IsLead:      m.IsLead,
+			PayShare:    payShare,
+			CheckedInAt: timestamptzToTimePtr(m.CheckedInAt),
+			CompletedAt: timestamptzToTimePtr(m.CompletedAt),
+		})
+	}
+}
+
+// payoutLineItemsForTransaction builds the payout line items for one payment
+// transaction. Solo bookings get a single line for the assigned cleaner; team
+// bookings get one line per member with the amounts split evenly in bani.
+func (r *Resolver) payoutLineItemsForTransaction(ctx context.Context, payoutID pgtype.UUID, txn db.PaymentTransaction) ([]db.CreatePayoutLineItemParams, error) {
+	booking, err := r.Queries.GetBookingByID(ctx, txn.BookingID)
+	if err != nil {
+		return nil, fmt.Errorf("booking not found: %w", err)
+	}
+
+	var cleanerIDs []pgtype.UUID
+	if booking.TeamSize > 1 {
+		members, err := r.Queries.ListBookingTeamMembers(ctx, booking.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list team members: %w", err)
+		}
+		for _, m := range members {
+			cleanerIDs = append(cleanerIDs, m.CleanerID)
+		}
+	}
+	if len(cleanerIDs) == 0 {
+		cleanerIDs = []pgtype.UUID{booking.CleanerID}
+	}
+
+	gross := splitBani(int64(txn.AmountTotal), len(cleanerIDs))
+	commission := splitBani(int64(txn.AmountPlatformFee), len(cleanerIDs))
+	net := splitBani(int64(txn.AmountCompany), len(cleanerIDs))
+
+	params := make([]db.CreatePayoutLineItemParams, len(cleanerIDs))
+	for i, id := range cleanerIDs {
+		params[i] = db.CreatePayoutLineItemParams{
+			PayoutID:             payoutID,
+			PaymentTransactionID: txn.ID,
+			BookingID:            txn.BookingID,
+			AmountGross:          int32(gross[i]),
+			AmountCommission:     int32(commission[i]),
+			AmountNet:            int32(net[i]),
+			CleanerID:            id,
+		}
+	}
+	return params, nil
+}
@@ -0,0 +1,23 @@
+package resolver
+
+import "testing"
+
+func TestTeamPayShares(t *testing.T) {
+	// 250 RON at 15% commission leaves the company 212.50 RON.
+	shares := teamPayShares(250, 37.5, 3)
+	want := []float64{70.84, 70.83, 70.83}
+	var sum float64
+	for i, s := range shares {
+		if s != want[i] {
+			t.Errorf("share %d = %.2f, want %.2f", i, s, want[i])
+		}
+		sum += s
+	}
+	if got := int64(sum*100 + 0.5); got != 21250 {
+		t.Errorf("shares add up to %d bani, want the 21250 bani net of commission", got)
+	}
+
+	if shares := teamPayShares(100, 0, 2); shares[0] != 50 || shares[1] != 50 {
+		t.Errorf("shares without commission = %v", shares)
+	}
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path/filepath"
+
+	"github.com/go-chi/chi/v5"
+
+	"helpmeclean-backend/internal/auth"
+	db "helpmeclean-backend/internal/db/generated"
+	"helpmeclean-backend/internal/service/accounting"
+)
+
+// NewAccountingExportHandler returns an HTTP handler that streams the file of
+// an accounting export.
+// Route: GET /api/accounting-exports/{id} (behind auth.AuthMiddleware)
+// Security: only global admins and the admin of the export's company may
+// download it.
+func NewAccountingExportHandler(queries *db.Queries, exports *accounting.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		claims := auth.GetUserFromContext(r.Context())
+		if claims == nil {
+			http.Error(w, "not authenticated", http.StatusUnauthorized)
+			return
+		}
+
+		exportID, err := parseUUID(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "invalid export id", http.StatusBadRequest)
+			return
+		}
+		exp, err := queries.GetAccountingExport(r.Context(), exportID)
+		if err != nil {
+			http.Error(w, "export not found", http.StatusNotFound)
+			return
+		}
+
+		switch claims.Role {
+		case "global_admin":
+		case "company_admin":
+			userID, _ := parseUUID(claims.UserID)
+			company, err := queries.GetCompanyByAdminUserID(r.Context(), userID)
+			if err != nil || !exp.CompanyID.Valid || company.ID != exp.CompanyID {
+				http.Error(w, "export not found", http.StatusNotFound)
+				return
+			}
+		default:
+			http.Error(w, "export not found", http.StatusNotFound)
+			return
+		}
+
+		if exp.Status != accounting.StatusReady {
+			http.Error(w, "export is not ready", http.StatusConflict)
+			return
+		}
+		rc, err := exports.Open(r.Context(), exp)
+		if err != nil {
+			log.Printf("accounting export: %v", err)
+			http.Error(w, "failed to read export", http.StatusInternalServerError)
+			return
+		}
+		defer rc.Close()
+
+		name := accounting.Filename(exp)
+		contentType := mime.TypeByExtension(filepath.Ext(name))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+		w.Header().Set("Cache-Control", "private, no-store")
+		io.Copy(w, rc) //nolint:errcheck
+	}
+}
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"helpmeclean-backend/internal/auth"
+	db "helpmeclean-backend/internal/db/generated"
+	"helpmeclean-backend/internal/service/invoice"
+)
+
+// NewInvoicePDFHandler returns an HTTP handler that streams the PDF of an invoice.
+// Route: GET /api/invoices/{id}/pdf (behind auth.AuthMiddleware)
+// Security: only global admins, the admin of the invoice's company and the
+// invoiced client may download it.
+func NewInvoicePDFHandler(queries *db.Queries, invoices *invoice.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		claims := auth.GetUserFromContext(r.Context())
+		if claims == nil {
+			http.Error(w, "not authenticated", http.StatusUnauthorized)
+			return
+		}
+
+		invoiceID, err := parseUUID(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "invalid invoice id", http.StatusBadRequest)
+			return
+		}
+		inv, err := queries.GetInvoiceByID(r.Context(), invoiceID)
+		if err != nil {
+			http.Error(w, "invoice not found", http.StatusNotFound)
+			return
+		}
+
+		userID, _ := parseUUID(claims.UserID)
+		switch claims.Role {
+		case "global_admin":
+		case "company_admin":
+			company, err := queries.GetCompanyByAdminUserID(r.Context(), userID)
+			if err != nil || company.ID != inv.CompanyID {
+				http.Error(w, "invoice not found", http.StatusNotFound)
+				return
+			}
+		default:
+			if inv.ClientUserID != userID {
+				http.Error(w, "invoice not found", http.StatusNotFound)
+				return
+			}
+		}
+
+		rc, err := invoices.PDF(r.Context(), inv)
+		if err != nil {
+			log.Printf("invoice pdf: %v", err)
+			http.Error(w, "failed to render invoice", http.StatusInternalServerError)
+			return
+		}
+		defer rc.Close()
+
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", `inline; filename="`+invoice.PDFFilename(inv)+`"`)
+		w.Header().Set("Cache-Control", "private, no-store")
+		io.Copy(w, rc) //nolint:errcheck
+	}
+}
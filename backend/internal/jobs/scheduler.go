@@ -0,0 +1,122 @@
+// Package jobs runs periodic background work such as generating recurring
+// booking occurrences.
+//
+// Jobs can run in-process on a ticker (long-lived server) and can always be
+// triggered over HTTP by an external scheduler (Cloud Scheduler, Vercel Cron),
+// authenticated with the CRON_SECRET bearer token.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Func is one run of a job.
+type Func func(ctx context.Context) error
+
+type job struct {
+	name     string
+	interval time.Duration
+	fn       Func
+	running  sync.Mutex
+}
+
+// Scheduler holds the registered jobs.
+type Scheduler struct {
+	jobs   map[string]*job
+	order  []string
+	secret string
+}
+
+// NewScheduler creates an empty scheduler reading CRON_SECRET from the environment.
+func NewScheduler() *Scheduler {
+	return &Scheduler{
+		jobs:   map[string]*job{},
+		secret: os.Getenv("CRON_SECRET"),
+	}
+}
+
+// Register adds a job that runs every interval when the scheduler is started.
+func (s *Scheduler) Register(name string, interval time.Duration, fn Func) {
+	s.jobs[name] = &job{name: name, interval: interval, fn: fn}
+	s.order = append(s.order, name)
+}
+
+// Run runs a job once. If the job is already running the call is skipped,
+// so a slow run and an HTTP trigger never overlap.
+func (s *Scheduler) Run(ctx context.Context, name string) (ran bool, err error) {
+	j, ok := s.jobs[name]
+	if !ok {
+		return false, nil
+	}
+	if !j.running.TryLock() {
+		log.Printf("[JOBS] %s already running, skipped", name)
+		return false, nil
+	}
+	defer j.running.Unlock()
+
+	start := time.Now()
+	err = j.fn(ctx)
+	if err != nil {
+		log.Printf("[JOBS] %s failed after %s: %v", name, time.Since(start).Round(time.Millisecond), err)
+	} else {
+		log.Printf("[JOBS] %s done in %s", name, time.Since(start).Round(time.Millisecond))
+	}
+	return true, err
+}
+
+// Start runs every job once and then on its interval until ctx is cancelled.
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, name := range s.order {
+		j := s.jobs[name]
+		go func() {
+			ticker := time.NewTicker(j.interval)
+			defer ticker.Stop()
+			for {
+				s.Run(ctx, j.name) //nolint:errcheck // logged by Run
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}()
+	}
+	log.Printf("[JOBS] Scheduler started with %d jobs", len(s.order))
+}
+
+// Handler serves POST /jobs/{name}: it runs the named job synchronously.
+// Requests must carry "Authorization: Bearer $CRON_SECRET"; when no secret
+// is configured the endpoint is disabled.
+func (s *Scheduler) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if s.secret == "" || r.Header.Get("Authorization") != "Bearer "+s.secret {
+			http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+		name := chi.URLParam(r, "name")
+		if _, ok := s.jobs[name]; !ok {
+			http.Error(w, `{"error":"unknown job"}`, http.StatusNotFound)
+			return
+		}
+
+		ran, err := s.Run(r.Context(), name)
+		if err != nil {
+			http.Error(w, `{"error":"job failed"}`, http.StatusInternalServerError)
+			return
+		}
+		status := "ok"
+		if !ran {
+			status = "skipped"
+		}
+		json.NewEncoder(w).Encode(map[string]string{"job": name, "status": status}) //nolint:errcheck
+	}
+}
@@ -26,7 +26,7 @@ func NewAuthzHelper(queries *db.Queries) *AuthzHelper {
 // - Global admins (can access all bookings)
 // - The client who created the booking
 // - Company admins whose company is assigned to the booking
-// - Cleaners who are assigned to the booking
+// - Cleaners who are assigned to the booking or are on its team
 func (h *AuthzHelper) CanAccessBooking(ctx context.Context, bookingID pgtype.UUID) error {
 	claims := auth.GetUserFromContext(ctx)
 	if claims == nil {
@@ -60,12 +60,20 @@ func (h *AuthzHelper) CanAccessBooking(ctx context.Context, bookingID pgtype.UUI
 		}
 	}
 
-	// Cleaners can access bookings assigned to them
+	// Cleaners can access bookings assigned to them, directly or as a team member
 	if claims.Role == "cleaner" {
 		cleaner, err := h.queries.GetCleanerByUserID(ctx, userUUID)
 		if err == nil && booking.CleanerID.Valid && booking.CleanerID.Bytes == cleaner.ID.Bytes {
 			return nil
 		}
+		if err == nil && booking.TeamSize > 1 {
+			if _, err := h.queries.GetBookingTeamMember(ctx, db.GetBookingTeamMemberParams{
+				BookingID: booking.ID,
+				CleanerID: cleaner.ID,
+			}); err == nil {
+				return nil
+			}
+		}
 	}
 
 	return fmt.Errorf("unauthorized: you do not have access to this booking")
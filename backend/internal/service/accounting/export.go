@@ -0,0 +1,274 @@
+// Package accounting exports invoices and the ledger for a period in the
+// import formats of the accounting programs Romanian companies use, so their
+// accountants do not re-type them.
+package accounting
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	db "helpmeclean-backend/internal/db/generated"
+	"helpmeclean-backend/internal/service/recurrence"
+	"helpmeclean-backend/internal/storage"
+)
+
+// Format is the file format of an export.
+type Format string
+
+const (
+	// FormatSagaXML is the invoice import XML of SAGA.
+	FormatSagaXML Format = "saga_xml"
+	// FormatWinMentorCSV is a semicolon-separated list of invoice lines for
+	// the WinMentor invoice import.
+	FormatWinMentorCSV Format = "winmentor_csv"
+	// FormatLedgerCSV lists the lines of the ledger entries of the period.
+	FormatLedgerCSV Format = "ledger_csv"
+	// FormatSalesJournalCSV is the sales journal (jurnal de vânzări), one
+	// row per invoice and VAT rate, with the SalesInvoices data of SAF-T D406.
+	FormatSalesJournalCSV Format = "sales_journal_csv"
+)
+
+// Valid reports whether f is a known format.
+func (f Format) Valid() bool {
+	switch f {
+	case FormatSagaXML, FormatWinMentorCSV, FormatLedgerCSV, FormatSalesJournalCSV:
+		return true
+	}
+	return false
+}
+
+// Export statuses.
+const (
+	StatusPending    = "pending"
+	StatusProcessing = "processing"
+	StatusReady      = "ready"
+	StatusFailed     = "failed"
+)
+
+// maxPeriodDays bounds the period of one export.
+const maxPeriodDays = 366
+
+// Service generates accounting exports and keeps their files in storage.
+type Service struct {
+	queries *db.Queries
+	storage storage.Storage
+}
+
+// NewService creates a new accounting export service.
+func NewService(queries *db.Queries, store storage.Storage) *Service {
+	return &Service{queries: queries, storage: store}
+}
+
+// Request records an export of the invoices and ledger of a company between
+// two dates, or of the platform when companyID is not valid, and starts
+// generating it in the background. Exports left pending, e.g. by a restart,
+// are generated by RunPendingExports.
+func (s *Service) Request(ctx context.Context, companyID, userID pgtype.UUID, format Format, from, to time.Time) (db.AccountingExport, error) {
+	if !format.Valid() {
+		return db.AccountingExport{}, fmt.Errorf("accounting: unknown export format %q", format)
+	}
+	if to.Before(from) {
+		return db.AccountingExport{}, errors.New("accounting: the period ends before it starts")
+	}
+	if to.Sub(from) > maxPeriodDays*24*time.Hour {
+		return db.AccountingExport{}, fmt.Errorf("accounting: the period is longer than %d days", maxPeriodDays)
+	}
+
+	exp, err := s.queries.CreateAccountingExport(ctx, db.CreateAccountingExportParams{
+		CompanyID:   companyID,
+		RequestedBy: userID,
+		Format:      string(format),
+		PeriodFrom:  pgtype.Date{Time: from, Valid: true},
+		PeriodTo:    pgtype.Date{Time: to, Valid: true},
+	})
+	if err != nil {
+		return db.AccountingExport{}, fmt.Errorf("accounting: create export: %w", err)
+	}
+
+	go func() {
+		if err := s.Generate(context.Background(), exp.ID); err != nil {
+			log.Printf("accounting: export %s failed: %v", uuidToString(exp.ID), err)
+		}
+	}()
+	return exp, nil
+}
+
+// RunPendingExports generates the exports still pending and those whose
+// generation was interrupted. It is run periodically by the job scheduler.
+func (s *Service) RunPendingExports(ctx context.Context) error {
+	exports, err := s.queries.ListDueAccountingExports(ctx, 20)
+	if err != nil {
+		return fmt.Errorf("accounting: list pending exports: %w", err)
+	}
+	var failed int
+	for _, exp := range exports {
+		if err := s.Generate(ctx, exp.ID); err != nil {
+			log.Printf("accounting: export %s failed: %v", uuidToString(exp.ID), err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("accounting: %d of %d exports failed", failed, len(exports))
+	}
+	return nil
+}
+
+// Generate generates the file of an export and stores it. The export is
+// claimed first so it is never generated twice at once; a failure is
+// recorded on the export, which can then be requested again.
+func (s *Service) Generate(ctx context.Context, exportID pgtype.UUID) error {
+	exp, err := s.queries.ClaimAccountingExport(ctx, exportID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("accounting: claim export: %w", err)
+	}
+
+	content, count, err := s.generate(ctx, exp)
+	if err == nil {
+		err = s.store(ctx, exp, content, count)
+	}
+	if err != nil {
+		if markErr := s.queries.MarkAccountingExportFailed(ctx, db.MarkAccountingExportFailedParams{
+			ID:    exp.ID,
+			Error: pgtype.Text{String: err.Error(), Valid: true},
+		}); markErr != nil {
+			log.Printf("accounting: record failure of export %s: %v", uuidToString(exp.ID), markErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// generate loads the records of an export and renders its file. It returns
+// the file and the number of invoices or ledger lines in it.
+func (s *Service) generate(ctx context.Context, exp db.AccountingExport) ([]byte, int, error) {
+	format := Format(exp.Format)
+	if format == FormatLedgerCSV {
+		lines, err := s.queries.ListLedgerLinesForExport(ctx, db.ListLedgerLinesForExportParams{
+			OccurredFrom: pgtype.Timestamptz{Time: recurrence.StartTime(exp.PeriodFrom.Time, 0), Valid: true},
+			OccurredTo:   pgtype.Timestamptz{Time: recurrence.StartTime(exp.PeriodTo.Time.AddDate(0, 0, 1), 0), Valid: true},
+			CompanyID:    exp.CompanyID,
+		})
+		if err != nil {
+			return nil, 0, fmt.Errorf("accounting: list ledger lines: %w", err)
+		}
+		content, err := ledgerCSV(lines)
+		return content, len(lines), err
+	}
+
+	docs, err := s.loadDocuments(ctx, exp)
+	if err != nil {
+		return nil, 0, err
+	}
+	var content []byte
+	switch format {
+	case FormatSagaXML:
+		content, err = sagaXML(docs)
+	case FormatWinMentorCSV:
+		content, err = winMentorCSV(docs)
+	case FormatSalesJournalCSV:
+		content, err = salesJournalCSV(docs)
+	default:
+		err = fmt.Errorf("accounting: unknown export format %q", format)
+	}
+	return content, len(docs), err
+}
+
+// loadDocuments loads the sales of an export: the client invoices a company
+// issued, or the commission invoices of the platform, with their credit
+// notes, line items and payments.
+func (s *Service) loadDocuments(ctx context.Context, exp db.AccountingExport) ([]document, error) {
+	invoiceType := db.InvoiceTypeClientService
+	if !exp.CompanyID.Valid {
+		invoiceType = db.InvoiceTypePlatformCommission
+	}
+	invoices, err := s.queries.ListInvoicesForExport(ctx, db.ListInvoicesForExportParams{
+		InvoiceType: invoiceType,
+		CompanyID:   exp.CompanyID,
+		PeriodFrom:  exp.PeriodFrom,
+		PeriodTo:    exp.PeriodTo,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("accounting: list invoices: %w", err)
+	}
+
+	docs := make([]document, 0, len(invoices))
+	for _, inv := range invoices {
+		doc := document{Invoice: inv}
+		if doc.Lines, err = s.queries.ListInvoiceLineItems(ctx, inv.ID); err != nil {
+			return nil, fmt.Errorf("accounting: list line items of invoice %s: %w", inv.InvoiceNumber.String, err)
+		}
+		if inv.PaymentTransactionID.Valid {
+			if pt, err := s.queries.GetPaymentTransactionByID(ctx, inv.PaymentTransactionID); err == nil {
+				doc.Payment = &pt
+			}
+		}
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
+
+// store uploads the file of an export and marks it ready.
+func (s *Service) store(ctx context.Context, exp db.AccountingExport, content []byte, count int) error {
+	name := Filename(exp)
+	path, err := s.storage.Upload(ctx, "accounting-exports/"+uuidToString(exp.ID), name, bytes.NewReader(content), storage.StorageTypePrivate)
+	if err != nil {
+		return fmt.Errorf("accounting: store export: %w", err)
+	}
+	if _, err := s.queries.MarkAccountingExportReady(ctx, db.MarkAccountingExportReadyParams{
+		ID:          exp.ID,
+		FilePath:    pgtype.Text{String: path, Valid: true},
+		FileName:    pgtype.Text{String: name, Valid: true},
+		RecordCount: pgtype.Int4{Int32: int32(count), Valid: true},
+	}); err != nil {
+		return fmt.Errorf("accounting: mark export ready: %w", err)
+	}
+	return nil
+}
+
+// Open returns the stored file of a ready export.
+func (s *Service) Open(ctx context.Context, exp db.AccountingExport) (io.ReadCloser, error) {
+	if exp.Status != StatusReady || !exp.FilePath.Valid {
+		return nil, errors.New("accounting: export is not ready")
+	}
+	rc, err := s.storage.GetReader(ctx, exp.FilePath.String)
+	if err != nil {
+		return nil, fmt.Errorf("accounting: read export: %w", err)
+	}
+	return rc, nil
+}
+
+// Filename returns the file name of an export, e.g.
+// "saga-2026-10-01-2026-10-31.xml".
+func Filename(exp db.AccountingExport) string {
+	var name, ext string
+	switch Format(exp.Format) {
+	case FormatSagaXML:
+		name, ext = "saga", "xml"
+	case FormatWinMentorCSV:
+		name, ext = "winmentor", "csv"
+	case FormatLedgerCSV:
+		name, ext = "registru", "csv"
+	default:
+		name, ext = "jurnal-vanzari", "csv"
+	}
+	return fmt.Sprintf("%s-%s-%s.%s", name, exp.PeriodFrom.Time.Format("2006-01-02"), exp.PeriodTo.Time.Format("2006-01-02"), ext)
+}
+
+func uuidToString(u pgtype.UUID) string {
+	if !u.Valid {
+		return ""
+	}
+	b := u.Bytes
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
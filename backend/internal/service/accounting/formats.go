@@ -0,0 +1,378 @@
+package accounting
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	db "helpmeclean-backend/internal/db/generated"
+	"helpmeclean-backend/internal/service/recurrence"
+)
+
+// document is an invoice or credit note with its line items and, for client
+// invoices, the payment of its booking.
+type document struct {
+	Invoice db.Invoice
+	Lines   []db.InvoiceLineItem
+	Payment *db.PaymentTransaction
+}
+
+// issueDate returns the date a document was issued, Romanian time.
+func (d document) issueDate() time.Time {
+	at := d.Invoice.IssuedAt
+	if !at.Valid {
+		at = d.Invoice.CreatedAt
+	}
+	return at.Time.In(recurrence.Location)
+}
+
+// isCreditNote reports whether a document is a credit note.
+func (d document) isCreditNote() bool {
+	return d.Invoice.Status == db.InvoiceStatusCreditNote || d.Invoice.TotalAmount < 0
+}
+
+// ─── SAGA ────────────────────────────────────────────────────────────────────
+
+type sagaInvoices struct {
+	XMLName  xml.Name      `xml:"Facturi"`
+	Invoices []sagaInvoice `xml:"Factura"`
+}
+
+type sagaInvoice struct {
+	Header  sagaHeader `xml:"Antet"`
+	Lines   []sagaLine `xml:"Detalii>Continut>Linie"`
+	Summary sagaTotals `xml:"Sumar"`
+}
+
+type sagaHeader struct {
+	SellerName      string `xml:"FurnizorNume"`
+	SellerCIF       string `xml:"FurnizorCIF"`
+	SellerRegNumber string `xml:"FurnizorNrRegCom"`
+	SellerAddress   string `xml:"FurnizorAdresa"`
+	SellerBank      string `xml:"FurnizorBanca"`
+	SellerIBAN      string `xml:"FurnizorIBAN"`
+	BuyerName       string `xml:"ClientNume"`
+	BuyerCIF        string `xml:"ClientCIF"`
+	BuyerRegNumber  string `xml:"ClientNrRegCom"`
+	BuyerCounty     string `xml:"ClientJudet"`
+	BuyerAddress    string `xml:"ClientAdresa"`
+	Number          string `xml:"FacturaNumar"`
+	Date            string `xml:"FacturaData"`
+	DueDate         string `xml:"FacturaScadenta"`
+	ReverseCharge   string `xml:"FacturaTaxareInversa"`
+	CashVAT         string `xml:"FacturaTVAIncasare"`
+	AdditionalInfo  string `xml:"FacturaInformatiiSuplimentare"`
+	Currency        string `xml:"FacturaMoneda"`
+}
+
+type sagaLine struct {
+	Number      int    `xml:"LinieNrCrt"`
+	Description string `xml:"Descriere"`
+	Unit        string `xml:"UM"`
+	Quantity    string `xml:"Cantitate"`
+	Price       string `xml:"Pret"`
+	Value       string `xml:"Valoare"`
+	VATRate     string `xml:"ProcTVA"`
+	VAT         string `xml:"TVA"`
+}
+
+type sagaTotals struct {
+	Net   string `xml:"TotalValoare"`
+	VAT   string `xml:"TotalTVA"`
+	Total string `xml:"Total"`
+}
+
+// sagaXML renders documents for the SAGA invoice import. Credit notes are
+// invoices with negative quantities, which SAGA books as reversals; the
+// invoice they correct is named in the additional information.
+func sagaXML(docs []document) ([]byte, error) {
+	out := sagaInvoices{Invoices: make([]sagaInvoice, 0, len(docs))}
+	for _, d := range docs {
+		inv := d.Invoice
+		si := sagaInvoice{
+			Header: sagaHeader{
+				SellerName:      inv.SellerCompanyName,
+				SellerCIF:       inv.SellerCui,
+				SellerRegNumber: textVal(inv.SellerRegNumber),
+				SellerAddress:   joinAddress(inv.SellerAddress, inv.SellerCity, inv.SellerCounty),
+				SellerBank:      textVal(inv.SellerBankName),
+				SellerIBAN:      textVal(inv.SellerIban),
+				BuyerName:       inv.BuyerName,
+				BuyerCIF:        textVal(inv.BuyerCui),
+				BuyerRegNumber:  textVal(inv.BuyerRegNumber),
+				BuyerCounty:     textVal(inv.BuyerCounty),
+				BuyerAddress:    joinAddress(textVal(inv.BuyerAddress), textVal(inv.BuyerCity), ""),
+				Number:          textVal(inv.InvoiceNumber),
+				Date:            d.issueDate().Format("02.01.2006"),
+				ReverseCharge:   "Nu",
+				CashVAT:         "Nu",
+				Currency:        inv.Currency,
+			},
+			Summary: sagaTotals{
+				Net:   formatBani(int64(inv.SubtotalAmount)),
+				VAT:   formatBani(int64(inv.VatAmount)),
+				Total: formatBani(int64(inv.TotalAmount)),
+			},
+		}
+		if inv.DueDate.Valid {
+			si.Header.DueDate = inv.DueDate.Time.Format("02.01.2006")
+		}
+		if d.isCreditNote() && inv.CreditedInvoiceNumber.Valid {
+			si.Header.AdditionalInfo = "Storno factura " + inv.CreditedInvoiceNumber.String
+		}
+		for i, l := range d.Lines {
+			qty := numericRat(l.Quantity)
+			price := int64(l.UnitPrice)
+			if d.isCreditNote() {
+				// Reversals are negative quantities at a positive price.
+				qty.Neg(qty)
+				price = -price
+			}
+			si.Lines = append(si.Lines, sagaLine{
+				Number:      i + 1,
+				Description: l.DescriptionRo,
+				Unit:        "buc",
+				Quantity:    formatRat(qty),
+				Price:       formatBani(price),
+				Value:       formatBani(int64(l.LineTotal)),
+				VATRate:     formatRat(numericRat(l.VatRate)),
+				VAT:         formatBani(int64(l.VatAmount)),
+			})
+		}
+		out.Invoices = append(out.Invoices, si)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		return nil, fmt.Errorf("accounting: encode SAGA XML: %w", err)
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// ─── WinMentor ───────────────────────────────────────────────────────────────
+
+var winMentorHeader = []string{
+	"NrDoc", "DataDoc", "Scadenta", "CodFiscal", "Partener", "NrLinie", "Articol", "UM",
+	"Cantitate", "Pret", "CotaTVA", "Valoare", "TVA", "Moneda", "FacturaStornata",
+}
+
+// winMentorCSV renders one row per invoice line for the WinMentor import,
+// separated by semicolons with decimal commas as Romanian spreadsheets
+// expect.
+func winMentorCSV(docs []document) ([]byte, error) {
+	rows := [][]string{winMentorHeader}
+	for _, d := range docs {
+		inv := d.Invoice
+		dueDate := ""
+		if inv.DueDate.Valid {
+			dueDate = inv.DueDate.Time.Format("02.01.2006")
+		}
+		for i, l := range d.Lines {
+			rows = append(rows, []string{
+				textVal(inv.InvoiceNumber),
+				d.issueDate().Format("02.01.2006"),
+				dueDate,
+				textVal(inv.BuyerCui),
+				inv.BuyerName,
+				fmt.Sprint(i + 1),
+				l.DescriptionRo,
+				"buc",
+				decimalComma(formatRat(numericRat(l.Quantity))),
+				decimalComma(formatBani(int64(l.UnitPrice))),
+				decimalComma(formatRat(numericRat(l.VatRate))),
+				decimalComma(formatBani(int64(l.LineTotal))),
+				decimalComma(formatBani(int64(l.VatAmount))),
+				inv.Currency,
+				textVal(inv.CreditedInvoiceNumber),
+			})
+		}
+	}
+	return writeCSV(rows, ';')
+}
+
+// ─── Ledger ──────────────────────────────────────────────────────────────────
+
+var ledgerHeader = []string{
+	"date", "reference", "kind", "description", "booking_id", "account", "account_owner_id", "debit", "credit",
+}
+
+// ledgerCSV renders the ledger lines of a period, amounts in RON.
+func ledgerCSV(lines []db.ListLedgerLinesForExportRow) ([]byte, error) {
+	rows := [][]string{ledgerHeader}
+	for _, l := range lines {
+		debit, credit := "", ""
+		if l.Amount > 0 {
+			debit = formatBani(l.Amount)
+		} else {
+			credit = formatBani(-l.Amount)
+		}
+		rows = append(rows, []string{
+			l.OccurredAt.Time.In(recurrence.Location).Format("2006-01-02 15:04:05"),
+			l.Reference,
+			string(l.Kind),
+			l.Description,
+			uuidToString(l.BookingID),
+			string(l.AccountType),
+			uuidToString(l.OwnerID),
+			debit,
+			credit,
+		})
+	}
+	return writeCSV(rows, ',')
+}
+
+// ─── Sales journal ───────────────────────────────────────────────────────────
+
+// salesJournalHeader names the columns after the SalesInvoices elements of
+// SAF-T D406. TaxType 300 is VAT.
+var salesJournalHeader = []string{
+	"InvoiceNo", "InvoiceDate", "InvoiceType", "ReferencedInvoiceNo", "CustomerID", "CustomerName",
+	"TaxType", "TaxPercentage", "TaxBase", "TaxAmount", "GrossTotal", "Currency",
+	"PaymentReference", "PaymentStatus",
+}
+
+// vatGroup is the base and VAT of a document at one VAT rate.
+type vatGroup struct {
+	rate     *big.Rat
+	net, vat int64
+}
+
+// salesJournalCSV renders the sales journal: one row per document and VAT
+// rate, in bani-exact RON amounts that add up to the document totals.
+func salesJournalCSV(docs []document) ([]byte, error) {
+	rows := [][]string{salesJournalHeader}
+	for _, d := range docs {
+		inv := d.Invoice
+		typeCode := "380"
+		if d.isCreditNote() {
+			typeCode = "381"
+		}
+		paymentRef, paymentStatus := "", ""
+		if d.Payment != nil {
+			paymentRef = d.Payment.StripePaymentIntentID
+			paymentStatus = string(d.Payment.Status)
+		}
+		for _, g := range vatGroups(d) {
+			rows = append(rows, []string{
+				textVal(inv.InvoiceNumber),
+				d.issueDate().Format("2006-01-02"),
+				typeCode,
+				textVal(inv.CreditedInvoiceNumber),
+				textVal(inv.BuyerCui),
+				inv.BuyerName,
+				"300",
+				formatRat(g.rate),
+				formatBani(g.net),
+				formatBani(g.vat),
+				formatBani(g.net + g.vat),
+				inv.Currency,
+				paymentRef,
+				paymentStatus,
+			})
+		}
+	}
+	return writeCSV(rows, ',')
+}
+
+// vatGroups totals the lines of a document per VAT rate, highest rate first.
+// Documents without line items are one group at the document's rate.
+func vatGroups(d document) []vatGroup {
+	if len(d.Lines) == 0 {
+		return []vatGroup{{
+			rate: numericRat(d.Invoice.VatRate),
+			net:  int64(d.Invoice.SubtotalAmount),
+			vat:  int64(d.Invoice.VatAmount),
+		}}
+	}
+	var groups []vatGroup
+	for _, l := range d.Lines {
+		rate := numericRat(l.VatRate)
+		i := sort.Search(len(groups), func(i int) bool { return groups[i].rate.Cmp(rate) <= 0 })
+		if i == len(groups) || groups[i].rate.Cmp(rate) != 0 {
+			groups = append(groups[:i], append([]vatGroup{{rate: rate}}, groups[i:]...)...)
+		}
+		groups[i].net += int64(l.LineTotal)
+		groups[i].vat += int64(l.VatAmount)
+	}
+	return groups
+}
+
+// ─── Helpers ─────────────────────────────────────────────────────────────────
+
+func writeCSV(rows [][]string, comma rune) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Comma = comma
+	if err := w.WriteAll(rows); err != nil {
+		return nil, fmt.Errorf("accounting: write CSV: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// formatBani formats an amount in bani as RON with two decimals.
+func formatBani(bani int64) string {
+	s := ""
+	if bani < 0 {
+		s, bani = "-", -bani
+	}
+	return fmt.Sprintf("%s%d.%02d", s, bani/100, bani%100)
+}
+
+// decimalComma switches a formatted number to a decimal comma.
+func decimalComma(s string) string {
+	return strings.Replace(s, ".", ",", 1)
+}
+
+// numericRat converts a pgtype.Numeric to an exact rational; NULL is zero.
+func numericRat(n pgtype.Numeric) *big.Rat {
+	r := new(big.Rat)
+	if !n.Valid || n.Int == nil {
+		return r
+	}
+	r.SetInt(n.Int)
+	exp := int64(n.Exp)
+	if exp < 0 {
+		exp = -exp
+	}
+	scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(exp), nil))
+	if n.Exp >= 0 {
+		return r.Mul(r, scale)
+	}
+	return r.Quo(r, scale)
+}
+
+// formatRat formats a decimal without trailing zeros ("21", "2.5").
+func formatRat(r *big.Rat) string {
+	s := r.FloatString(6)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// joinAddress joins the non-empty parts of an address.
+func joinAddress(parts ...string) string {
+	var out []string
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return strings.Join(out, ", ")
+}
+
+func textVal(t pgtype.Text) string {
+	if !t.Valid {
+		return ""
+	}
+	return t.String
+}
@@ -0,0 +1,168 @@
+package accounting
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	db "helpmeclean-backend/internal/db/generated"
+)
+
+func num(hundredths int64) pgtype.Numeric {
+	return pgtype.Numeric{Int: big.NewInt(hundredths), Exp: -2, Valid: true}
+}
+
+func text(s string) pgtype.Text {
+	return pgtype.Text{String: s, Valid: true}
+}
+
+// testDocuments returns an invoice with lines at two VAT rates and a credit
+// note on it.
+func testDocuments() []document {
+	issued := pgtype.Timestamptz{Time: time.Date(2026, 10, 31, 22, 30, 0, 0, time.UTC), Valid: true}
+	invoice := document{
+		Invoice: db.Invoice{
+			InvoiceNumber:     text("CLC-2026-0007"),
+			SellerCompanyName: "Clean Cluj SRL",
+			SellerCui:         "RO12345678",
+			SellerAddress:     "Str. Horea 3",
+			SellerCity:        "Cluj-Napoca",
+			BuyerName:         "Ion Popescu",
+			SubtotalAmount:    13397,
+			VatAmount:         2103,
+			TotalAmount:       15500,
+			Currency:          "RON",
+			Status:            db.InvoiceStatusIssued,
+			IssuedAt:          issued,
+		},
+		Lines: []db.InvoiceLineItem{
+			{DescriptionRo: "Curățenie generală (ore)", Quantity: num(300), UnitPrice: 4132, VatRate: num(2100), LineTotal: 12397, VatAmount: 2103, LineTotalWithVat: 14500},
+			{DescriptionRo: "Deplasare", Quantity: num(100), UnitPrice: 1000, VatRate: num(0), LineTotal: 1000, VatAmount: 0, LineTotalWithVat: 1000},
+		},
+		Payment: &db.PaymentTransaction{StripePaymentIntentID: "pi_1", Status: db.PaymentTransactionStatusSucceeded},
+	}
+	credit := document{
+		Invoice: db.Invoice{
+			InvoiceNumber:         text("CN-2026-0001"),
+			BuyerName:             "Ion Popescu",
+			SubtotalAmount:        -1000,
+			TotalAmount:           -1000,
+			Currency:              "RON",
+			Status:                db.InvoiceStatusCreditNote,
+			CreditedInvoiceNumber: text("CLC-2026-0007"),
+			IssuedAt:              issued,
+		},
+		Lines: []db.InvoiceLineItem{
+			{DescriptionRo: "Stornare - Deplasare", Quantity: num(100), UnitPrice: -1000, VatRate: num(0), LineTotal: -1000, LineTotalWithVat: -1000},
+		},
+	}
+	return []document{invoice, credit}
+}
+
+func TestSagaXML(t *testing.T) {
+	out, err := sagaXML(testDocuments())
+	if err != nil {
+		t.Fatalf("sagaXML() error: %v", err)
+	}
+	var got sagaInvoices
+	if err := xml.Unmarshal(out, &got); err != nil {
+		t.Fatalf("sagaXML() is not valid XML: %v\n%s", err, out)
+	}
+	if len(got.Invoices) != 2 {
+		t.Fatalf("sagaXML() has %d invoices, want 2", len(got.Invoices))
+	}
+	inv := got.Invoices[0]
+	// Issued late evening UTC, already 1 November in Romania.
+	if inv.Header.Number != "CLC-2026-0007" || inv.Header.Date != "01.11.2026" || inv.Header.SellerAddress != "Str. Horea 3, Cluj-Napoca" {
+		t.Errorf("header = %+v", inv.Header)
+	}
+	if l := inv.Lines[0]; l.Quantity != "3" || l.Price != "41.32" || l.Value != "123.97" || l.VATRate != "21" {
+		t.Errorf("line = %+v", l)
+	}
+	if inv.Summary.Total != "155.00" {
+		t.Errorf("total = %s", inv.Summary.Total)
+	}
+
+	cn := got.Invoices[1]
+	if l := cn.Lines[0]; l.Quantity != "-1" || l.Price != "10.00" || l.Value != "-10.00" {
+		t.Errorf("credit note line = %+v, want a negative quantity at a positive price", l)
+	}
+	if cn.Header.AdditionalInfo != "Storno factura CLC-2026-0007" {
+		t.Errorf("credit note info = %q", cn.Header.AdditionalInfo)
+	}
+}
+
+func TestWinMentorCSV(t *testing.T) {
+	out, err := winMentorCSV(testDocuments())
+	if err != nil {
+		t.Fatalf("winMentorCSV() error: %v", err)
+	}
+	r := csv.NewReader(strings.NewReader(string(out)))
+	r.Comma = ';'
+	rows, err := r.ReadAll()
+	if err != nil {
+		t.Fatalf("winMentorCSV() is not valid CSV: %v", err)
+	}
+	if len(rows) != 4 {
+		t.Fatalf("winMentorCSV() has %d rows, want a header and 3 lines", len(rows))
+	}
+	if row := rows[1]; row[0] != "CLC-2026-0007" || row[8] != "3" || row[9] != "41,32" || row[11] != "123,97" {
+		t.Errorf("first line = %q", row)
+	}
+	if row := rows[3]; row[11] != "-10,00" || row[14] != "CLC-2026-0007" {
+		t.Errorf("credit note line = %q", row)
+	}
+}
+
+func TestSalesJournalCSV(t *testing.T) {
+	out, err := salesJournalCSV(testDocuments())
+	if err != nil {
+		t.Fatalf("salesJournalCSV() error: %v", err)
+	}
+	rows, err := csv.NewReader(strings.NewReader(string(out))).ReadAll()
+	if err != nil {
+		t.Fatalf("salesJournalCSV() is not valid CSV: %v", err)
+	}
+	want := [][]string{
+		// InvoiceNo, InvoiceDate, InvoiceType, ReferencedInvoiceNo, TaxPercentage, TaxBase, TaxAmount, GrossTotal, PaymentReference
+		{"CLC-2026-0007", "2026-11-01", "380", "", "21", "123.97", "21.03", "145.00", "pi_1"},
+		{"CLC-2026-0007", "2026-11-01", "380", "", "0", "10.00", "0.00", "10.00", "pi_1"},
+		{"CN-2026-0001", "2026-11-01", "381", "CLC-2026-0007", "0", "-10.00", "0.00", "-10.00", ""},
+	}
+	if len(rows) != len(want)+1 {
+		t.Fatalf("salesJournalCSV() = %q", rows)
+	}
+	for i, w := range want {
+		row := rows[i+1]
+		got := []string{row[0], row[1], row[2], row[3], row[7], row[8], row[9], row[10], row[12]}
+		if strings.Join(got, "|") != strings.Join(w, "|") {
+			t.Errorf("row %d = %q, want %q", i+1, got, w)
+		}
+	}
+}
+
+func TestLedgerCSV(t *testing.T) {
+	at := pgtype.Timestamptz{Time: time.Date(2026, 7, 1, 9, 0, 0, 0, time.UTC), Valid: true}
+	out, err := ledgerCSV([]db.ListLedgerLinesForExportRow{
+		{OccurredAt: at, Reference: "payment:pi_1", Kind: db.LedgerEntryKindPayment, AccountType: db.LedgerAccountTypeStripeBalance, Amount: 15500},
+		{OccurredAt: at, Reference: "payment:pi_1", Kind: db.LedgerEntryKindPayment, AccountType: db.LedgerAccountTypePlatformFees, Amount: -2325},
+	})
+	if err != nil {
+		t.Fatalf("ledgerCSV() error: %v", err)
+	}
+	rows, err := csv.NewReader(strings.NewReader(string(out))).ReadAll()
+	if err != nil || len(rows) != 3 {
+		t.Fatalf("ledgerCSV() = %q, %v", rows, err)
+	}
+	if row := rows[1]; row[0] != "2026-07-01 12:00:00" || row[7] != "155.00" || row[8] != "" {
+		t.Errorf("debit row = %q", row)
+	}
+	if row := rows[2]; row[5] != "platform_fees" || row[7] != "" || row[8] != "23.25" {
+		t.Errorf("credit row = %q", row)
+	}
+}
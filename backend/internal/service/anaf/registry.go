@@ -0,0 +1,113 @@
+// Package anaf queries the public ANAF registry of Romanian taxpayers
+// (PlatitorTvaRest), which gives a company's registration details and
+// whether it is registered for VAT on a given date.
+package anaf
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultRegistryURL is the endpoint of the public ANAF VAT registry.
+const DefaultRegistryURL = "https://webservicesp.anaf.ro/api/PlatitorTvaRest/v9/tva"
+
+// ErrNotFound is returned for fiscal codes ANAF does not know.
+var ErrNotFound = errors.New("anaf: fiscal code not found")
+
+// Taxpayer is the registry entry of a fiscal code.
+type Taxpayer struct {
+	CUI       int
+	Name      string
+	Address   string
+	Phone     string
+	RegNumber string
+	CAEN      string
+	// VATPayer reports whether the taxpayer is registered for VAT (scpTVA)
+	// on the date of the lookup.
+	VATPayer bool
+}
+
+// Registry looks taxpayers up in the ANAF registry.
+type Registry struct {
+	url        string
+	httpClient *http.Client
+}
+
+// NewRegistry creates a registry client for url, usually DefaultRegistryURL.
+func NewRegistry(url string) *Registry {
+	return &Registry{url: url, httpClient: &http.Client{Timeout: 15 * time.Second}}
+}
+
+// ParseCUI returns the numeric part of a fiscal code such as "RO 12345678".
+func ParseCUI(cui string) (int, error) {
+	s := strings.ReplaceAll(strings.TrimSpace(cui), " ", "")
+	s = strings.TrimPrefix(strings.ToUpper(s), "RO")
+	n, err := strconv.Atoi(s)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("anaf: invalid fiscal code %q", cui)
+	}
+	return n, nil
+}
+
+// Lookup returns the registry entry of cui as of date.
+func (r *Registry) Lookup(ctx context.Context, cui string, date time.Time) (Taxpayer, error) {
+	n, err := ParseCUI(cui)
+	if err != nil {
+		return Taxpayer{}, err
+	}
+	payload, _ := json.Marshal([]map[string]any{{"cui": n, "data": date.Format("2006-01-02")}})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url, bytes.NewReader(payload))
+	if err != nil {
+		return Taxpayer{}, fmt.Errorf("anaf: build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return Taxpayer{}, fmt.Errorf("anaf: registry unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Taxpayer{}, fmt.Errorf("anaf: registry returned status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Found []struct {
+			DateGenerale struct {
+				CUI      int    `json:"cui"`
+				Denumire string `json:"denumire"`
+				Adresa   string `json:"adresa"`
+				Telefon  string `json:"telefon"`
+				NrRegCom string `json:"nrRegCom"`
+				CodCAEN  string `json:"cod_CAEN"`
+			} `json:"date_generale"`
+			InregistrareScopTva struct {
+				ScpTVA bool `json:"scpTVA"`
+			} `json:"inregistrare_scop_Tva"`
+		} `json:"found"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return Taxpayer{}, fmt.Errorf("anaf: decode registry response: %w", err)
+	}
+	if len(body.Found) == 0 {
+		return Taxpayer{}, ErrNotFound
+	}
+
+	f := body.Found[0]
+	return Taxpayer{
+		CUI:       n,
+		Name:      strings.TrimSpace(f.DateGenerale.Denumire),
+		Address:   strings.TrimSpace(f.DateGenerale.Adresa),
+		Phone:     strings.TrimSpace(f.DateGenerale.Telefon),
+		RegNumber: strings.TrimSpace(f.DateGenerale.NrRegCom),
+		CAEN:      strings.TrimSpace(f.DateGenerale.CodCAEN),
+		VATPayer:  f.InregistrareScopTva.ScpTVA,
+	}, nil
+}
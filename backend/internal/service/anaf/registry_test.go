@@ -0,0 +1,50 @@
+package anaf
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLookup(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req []struct {
+			CUI  int    `json:"cui"`
+			Data string `json:"data"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req) != 1 {
+			t.Errorf("bad request body: %v", err)
+		}
+		if req[0].Data != "2026-03-31" {
+			t.Errorf("data = %q", req[0].Data)
+		}
+		if req[0].CUI != 12345678 {
+			w.Write([]byte(`{"cod":200,"found":[],"notFound":[1]}`)) //nolint:errcheck
+			return
+		}
+		w.Write([]byte(`{"cod":200,"found":[{"date_generale":{"cui":12345678,"denumire":"CLEAN CLUJ SRL ","adresa":"MUNICIPIUL CLUJ-NAPOCA, JUD. CLUJ, STR. HOREA, NR. 3","telefon":"0740000000","nrRegCom":"J12/345/2020","cod_CAEN":"8121"},"inregistrare_scop_Tva":{"scpTVA":true}}]}`)) //nolint:errcheck
+	}))
+	defer srv.Close()
+
+	reg := NewRegistry(srv.URL)
+	date := time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC)
+
+	tp, err := reg.Lookup(context.Background(), "RO 12345678", date)
+	if err != nil {
+		t.Fatalf("Lookup() error: %v", err)
+	}
+	if tp.CUI != 12345678 || tp.Name != "CLEAN CLUJ SRL" || tp.RegNumber != "J12/345/2020" || tp.CAEN != "8121" || !tp.VATPayer {
+		t.Errorf("Lookup() = %+v", tp)
+	}
+
+	if _, err := reg.Lookup(context.Background(), "87654321", date); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Lookup() of an unknown CUI error = %v, want ErrNotFound", err)
+	}
+	if _, err := reg.Lookup(context.Background(), "ROXYZ", date); err == nil {
+		t.Error("Lookup() of an invalid CUI succeeded")
+	}
+}
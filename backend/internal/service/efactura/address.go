@@ -0,0 +1,95 @@
+package efactura
+
+import (
+	"regexp"
+	"strings"
+)
+
+// countyCodes maps Romanian county names, without diacritics, spaces or
+// hyphens, to their ISO 3166-2:RO subdivision codes.
+var countyCodes = map[string]string{
+	"alba":                "AB",
+	"arad":                "AR",
+	"arges":               "AG",
+	"bacau":               "BC",
+	"bihor":               "BH",
+	"bistritanasaud":      "BN",
+	"botosani":            "BT",
+	"braila":              "BR",
+	"brasov":              "BV",
+	"bucuresti":           "B",
+	"municipiulbucuresti": "B",
+	"buzau":               "BZ",
+	"calarasi":            "CL",
+	"carasseverin":        "CS",
+	"cluj":                "CJ",
+	"constanta":           "CT",
+	"covasna":             "CV",
+	"dambovita":           "DB",
+	"dolj":                "DJ",
+	"galati":              "GL",
+	"giurgiu":             "GR",
+	"gorj":                "GJ",
+	"harghita":            "HR",
+	"hunedoara":           "HD",
+	"ialomita":            "IL",
+	"iasi":                "IS",
+	"ilfov":               "IF",
+	"maramures":           "MM",
+	"mehedinti":           "MH",
+	"mures":               "MS",
+	"neamt":               "NT",
+	"olt":                 "OT",
+	"prahova":             "PH",
+	"salaj":               "SJ",
+	"satumare":            "SM",
+	"sibiu":               "SB",
+	"suceava":             "SV",
+	"teleorman":           "TR",
+	"timis":               "TM",
+	"tulcea":              "TL",
+	"valcea":              "VL",
+	"vaslui":              "VS",
+	"vrancea":             "VN",
+}
+
+var diacritics = strings.NewReplacer(
+	"ă", "a", "â", "a", "î", "i", "ș", "s", "ş", "s", "ț", "t", "ţ", "t",
+	"Ă", "a", "Â", "a", "Î", "i", "Ș", "s", "Ş", "s", "Ț", "t", "Ţ", "t",
+)
+
+// CountyCode returns the ISO 3166-2 code of a Romanian county ("RO-CJ" for
+// "Cluj", "RO-B" for Bucharest). It accepts names with or without
+// diacritics, a "Judetul" prefix and bare or prefixed codes. ok is false when
+// the county is not recognised.
+func CountyCode(county string) (code string, ok bool) {
+	key := strings.ToLower(diacritics.Replace(strings.TrimSpace(county)))
+	key = strings.TrimPrefix(key, "judetul ")
+	key = strings.TrimPrefix(key, "jud. ")
+	key = strings.NewReplacer(" ", "", "-", "", ".", "").Replace(key)
+	if c, found := countyCodes[key]; found {
+		return "RO-" + c, true
+	}
+
+	short := strings.ToUpper(strings.TrimPrefix(key, "ro"))
+	for _, c := range countyCodes {
+		if c == short {
+			return "RO-" + c, true
+		}
+	}
+	return "", false
+}
+
+var sectorPattern = regexp.MustCompile(`(?i)\bsector(?:ul)?\s*([1-6])\b`)
+
+// bucharestSector finds the Bucharest sector ("SECTOR3") named in any of the
+// given address parts. CIUS-RO requires it as the city name of addresses in
+// Bucharest.
+func bucharestSector(parts ...string) (string, bool) {
+	for _, p := range parts {
+		if m := sectorPattern.FindStringSubmatch(p); m != nil {
+			return "SECTOR" + m[1], true
+		}
+	}
+	return "", false
+}
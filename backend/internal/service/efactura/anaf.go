@@ -0,0 +1,348 @@
+package efactura
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Lifecycle of an invoice uploaded directly to the ANAF SPV, as stored in
+// invoices.efactura_status.
+const (
+	StatusUploaded   = "uploaded"
+	StatusProcessing = "processing"
+	StatusAccepted   = "accepted"
+	StatusRejected   = "rejected"
+)
+
+// Values of "stare" returned by the stareMesaj endpoint.
+const (
+	stareOK         = "ok"
+	stareNOK        = "nok"
+	stareProcessing = "in prelucrare"
+	stareXMLErrors  = "XML cu erori nepreluat de sistem"
+)
+
+// Default endpoints. The e-Factura API has a test environment that accepts
+// the same requests without any legal effect.
+const (
+	defaultOAuthURL      = "https://logincert.anaf.ro/anaf-oauth2/v1"
+	defaultAPIURL        = "https://api.anaf.ro/prod/FCTEL/rest"
+	defaultTestAPIURL    = "https://api.anaf.ro/test/FCTEL/rest"
+	maxResponseSize      = 20 << 20
+	tokenRefreshLeadTime = 24 * time.Hour
+)
+
+// Client talks to the ANAF OAuth2 server and the e-Factura REST API.
+type Client struct {
+	clientID     string
+	clientSecret string
+	redirectURI  string
+	oauthURL     string
+	apiURL       string
+	httpClient   *http.Client
+}
+
+// NewClient creates a client for the given OAuth2 application and endpoints.
+func NewClient(clientID, clientSecret, redirectURI, oauthURL, apiURL string) *Client {
+	return &Client{
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		redirectURI:  redirectURI,
+		oauthURL:     strings.TrimRight(oauthURL, "/"),
+		apiURL:       strings.TrimRight(apiURL, "/"),
+		httpClient:   &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// NewClientFromEnv creates a client configured from ANAF_CLIENT_ID,
+// ANAF_CLIENT_SECRET and ANAF_REDIRECT_URI. ANAF_OAUTH_URL and
+// ANAF_EFACTURA_URL override the endpoints, e.g. to point at a local stub;
+// outside production the e-Factura test environment is the default.
+func NewClientFromEnv() *Client {
+	oauthURL := os.Getenv("ANAF_OAUTH_URL")
+	if oauthURL == "" {
+		oauthURL = defaultOAuthURL
+	}
+	apiURL := os.Getenv("ANAF_EFACTURA_URL")
+	if apiURL == "" {
+		apiURL = defaultTestAPIURL
+		if os.Getenv("ENVIRONMENT") == "production" {
+			apiURL = defaultAPIURL
+		}
+	}
+	return NewClient(os.Getenv("ANAF_CLIENT_ID"), os.Getenv("ANAF_CLIENT_SECRET"), os.Getenv("ANAF_REDIRECT_URI"), oauthURL, apiURL)
+}
+
+// Configured reports whether the OAuth2 application is set up.
+func (c *Client) Configured() bool {
+	return c.clientID != "" && c.clientSecret != "" && c.redirectURI != ""
+}
+
+// Token is an ANAF OAuth2 token pair.
+type Token struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresAt    time.Time
+}
+
+// NeedsRefresh reports whether the access token expires within a day.
+func (t Token) NeedsRefresh(now time.Time) bool {
+	return now.Add(tokenRefreshLeadTime).After(t.ExpiresAt)
+}
+
+// AuthorizationURL returns the ANAF login page that asks the owner of a
+// qualified certificate to authorize the application. ANAF redirects back to
+// the redirect URI with a code for ExchangeCode.
+func (c *Client) AuthorizationURL(state string) string {
+	q := url.Values{
+		"response_type":      {"code"},
+		"client_id":          {c.clientID},
+		"redirect_uri":       {c.redirectURI},
+		"token_content_type": {"jwt"},
+		"state":              {state},
+	}
+	return c.oauthURL + "/authorize?" + q.Encode()
+}
+
+// ExchangeCode exchanges an authorization code for a token pair.
+func (c *Client) ExchangeCode(ctx context.Context, code string) (Token, error) {
+	return c.requestToken(ctx, url.Values{
+		"grant_type":   {"authorization_code"},
+		"code":         {code},
+		"redirect_uri": {c.redirectURI},
+	})
+}
+
+// RefreshToken obtains a new token pair from a refresh token.
+func (c *Client) RefreshToken(ctx context.Context, refreshToken string) (Token, error) {
+	return c.requestToken(ctx, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+	})
+}
+
+func (c *Client) requestToken(ctx context.Context, form url.Values) (Token, error) {
+	if !c.Configured() {
+		return Token{}, errors.New("efactura: ANAF OAuth2 application is not configured")
+	}
+	form.Set("client_id", c.clientID)
+	form.Set("client_secret", c.clientSecret)
+	form.Set("token_content_type", "jwt")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.oauthURL+"/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return Token{}, fmt.Errorf("efactura: create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(c.clientID, c.clientSecret)
+
+	body, status, err := c.do(req)
+	if err != nil {
+		return Token{}, err
+	}
+	var resp struct {
+		AccessToken      string `json:"access_token"`
+		RefreshToken     string `json:"refresh_token"`
+		ExpiresIn        int64  `json:"expires_in"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return Token{}, fmt.Errorf("efactura: parse token response (status %d): %w", status, err)
+	}
+	if resp.Error != "" || status != http.StatusOK || resp.AccessToken == "" {
+		return Token{}, fmt.Errorf("efactura: token request failed with status %d: %s %s", status, resp.Error, resp.ErrorDescription)
+	}
+	return Token{
+		AccessToken:  resp.AccessToken,
+		RefreshToken: resp.RefreshToken,
+		ExpiresAt:    time.Now().Add(time.Duration(resp.ExpiresIn) * time.Second),
+	}, nil
+}
+
+// UploadResult is the answer of the SPV to an upload. Index
+// (index_incarcare) identifies the upload for Status; Errors is set when the
+// file was refused outright.
+type UploadResult struct {
+	Index  string
+	Errors []string
+}
+
+// Upload sends the XML of an invoice issued by cif to the SPV. Credit notes
+// are uploaded under the CN standard.
+func (c *Client) Upload(ctx context.Context, accessToken, cif string, creditNote bool, document []byte) (UploadResult, error) {
+	standard := "UBL"
+	if creditNote {
+		standard = "CN"
+	}
+	q := url.Values{"standard": {standard}, "cif": {cif}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.apiURL+"/upload?"+q.Encode(), bytes.NewReader(document))
+	if err != nil {
+		return UploadResult{}, fmt.Errorf("efactura: create upload request: %w", err)
+	}
+	req.Header.Set("Content-Type", "text/plain")
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	body, status, err := c.do(req)
+	if err != nil {
+		return UploadResult{}, err
+	}
+	var resp struct {
+		ExecutionStatus string     `xml:"ExecutionStatus,attr"`
+		Index           string     `xml:"index_incarcare,attr"`
+		Errors          []spvError `xml:"Errors"`
+	}
+	if err := xml.Unmarshal(body, &resp); err != nil {
+		return UploadResult{}, fmt.Errorf("efactura: parse upload response (status %d): %w", status, err)
+	}
+	result := UploadResult{Index: resp.Index, Errors: messages(resp.Errors)}
+	if resp.ExecutionStatus != "0" && len(result.Errors) == 0 {
+		result.Errors = []string{fmt.Sprintf("upload refused with status %d", status)}
+	}
+	return result, nil
+}
+
+// StatusResult is the processing state of an upload. DownloadID is set once
+// ANAF has a response to download, for accepted and rejected invoices alike.
+type StatusResult struct {
+	Status     string
+	DownloadID string
+	Messages   []string
+}
+
+// Status returns the processing state of an upload.
+func (c *Client) Status(ctx context.Context, accessToken, index string) (StatusResult, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.apiURL+"/stareMesaj?"+url.Values{"id_incarcare": {index}}.Encode(), nil)
+	if err != nil {
+		return StatusResult{}, fmt.Errorf("efactura: create status request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	body, status, err := c.do(req)
+	if err != nil {
+		return StatusResult{}, err
+	}
+	var resp struct {
+		Stare      string     `xml:"stare,attr"`
+		DownloadID string     `xml:"id_descarcare,attr"`
+		Errors     []spvError `xml:"Errors"`
+	}
+	if err := xml.Unmarshal(body, &resp); err != nil {
+		return StatusResult{}, fmt.Errorf("efactura: parse status response (status %d): %w", status, err)
+	}
+
+	result := StatusResult{DownloadID: resp.DownloadID, Messages: messages(resp.Errors)}
+	switch resp.Stare {
+	case stareOK:
+		result.Status = StatusAccepted
+	case stareNOK:
+		result.Status = StatusRejected
+	case stareXMLErrors:
+		result.Status = StatusRejected
+		if len(result.Messages) == 0 {
+			result.Messages = []string{stareXMLErrors}
+		}
+	case stareProcessing:
+		result.Status = StatusProcessing
+	default:
+		return StatusResult{}, fmt.Errorf("efactura: status of upload %s unavailable: %s", index, strings.Join(result.Messages, "; "))
+	}
+	return result, nil
+}
+
+// Download returns the ZIP archive of a response: the invoice with the
+// signature of the Ministry of Finance when accepted, the error report when
+// rejected.
+func (c *Client) Download(ctx context.Context, accessToken, downloadID string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.apiURL+"/descarcare?"+url.Values{"id": {downloadID}}.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("efactura: create download request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	body, status, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(body, []byte("PK")) {
+		// Failures come back as JSON: {"eroare": "..."}.
+		var resp struct {
+			Eroare string `json:"eroare"`
+		}
+		_ = json.Unmarshal(body, &resp)
+		return nil, fmt.Errorf("efactura: download %s failed with status %d: %s", downloadID, status, resp.Eroare)
+	}
+	return body, nil
+}
+
+// ErrorMessages returns the validation errors in the error report of a
+// rejected invoice's response archive.
+func ErrorMessages(archive []byte) []string {
+	r, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return nil
+	}
+	var out []string
+	for _, f := range r.File {
+		if !strings.HasSuffix(strings.ToLower(f.Name), ".xml") {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			continue
+		}
+		var report struct {
+			Errors []spvError `xml:"Error"`
+		}
+		if xml.NewDecoder(io.LimitReader(rc, maxResponseSize)).Decode(&report) == nil {
+			out = append(out, messages(report.Errors)...)
+		}
+		rc.Close()
+	}
+	return out
+}
+
+// spvError is an error element of the SPV responses.
+type spvError struct {
+	Message string `xml:"errorMessage,attr"`
+}
+
+func messages(errs []spvError) []string {
+	var out []string
+	for _, e := range errs {
+		if e.Message != "" {
+			out = append(out, e.Message)
+		}
+	}
+	return out
+}
+
+// do performs a request and returns the response body. Only transport
+// failures and authorization errors are errors; the callers interpret the
+// other statuses from the body.
+func (c *Client) do(req *http.Request) ([]byte, int, error) {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("efactura: %s %s: %w", req.Method, req.URL.Path, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, resp.StatusCode, fmt.Errorf("efactura: read response of %s: %w", req.URL.Path, err)
+	}
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return nil, resp.StatusCode, fmt.Errorf("efactura: %s not authorized (status %d)", req.URL.Path, resp.StatusCode)
+	}
+	return body, resp.StatusCode, nil
+}
@@ -0,0 +1,143 @@
+package efactura
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	db "helpmeclean-backend/internal/db/generated"
+	"helpmeclean-backend/internal/service/efactura/anafstub"
+)
+
+func stubClient(t *testing.T) (*Client, *anafstub.Server) {
+	t.Helper()
+	stub := anafstub.New()
+	srv := httptest.NewServer(stub)
+	t.Cleanup(srv.Close)
+	return NewClient("client", "secret", "https://app.example/anaf/callback", srv.URL+"/oauth", srv.URL+"/api"), stub
+}
+
+func TestClientOAuth(t *testing.T) {
+	c, stub := stubClient(t)
+	ctx := context.Background()
+
+	noRedirect := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := noRedirect.Get(c.AuthorizationURL("state-1"))
+	if err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	resp.Body.Close()
+	back, _ := url.Parse(resp.Header.Get("Location"))
+	if !strings.HasPrefix(back.String(), "https://app.example/anaf/callback") || back.Query().Get("state") != "state-1" {
+		t.Fatalf("redirect = %s", back)
+	}
+
+	token, err := c.ExchangeCode(ctx, back.Query().Get("code"))
+	if err != nil {
+		t.Fatalf("ExchangeCode() error: %v", err)
+	}
+	if token.AccessToken == "" || token.RefreshToken == "" || token.NeedsRefresh(time.Now()) {
+		t.Errorf("token = %+v", token)
+	}
+	if _, err := c.ExchangeCode(ctx, back.Query().Get("code")); err == nil {
+		t.Error("ExchangeCode() accepted a used code")
+	}
+
+	stub.ExpireTokens()
+	if _, err := c.Status(ctx, token.AccessToken, "1"); err == nil {
+		t.Error("Status() succeeded with an expired token")
+	}
+	refreshed, err := c.RefreshToken(ctx, token.RefreshToken)
+	if err != nil || refreshed.AccessToken == token.AccessToken {
+		t.Fatalf("RefreshToken() = %+v, %v", refreshed, err)
+	}
+	if _, err := c.RefreshToken(ctx, token.RefreshToken); err == nil {
+		t.Error("RefreshToken() accepted a used refresh token")
+	}
+}
+
+func TestClientUploadAccepted(t *testing.T) {
+	c, stub := stubClient(t)
+	ctx := context.Background()
+	token, _ := c.ExchangeCode(ctx, stub.IssueCode())
+
+	inv, items := clientInvoice()
+	document, err := Render(inv, items)
+	if err != nil {
+		t.Fatalf("Render() error: %v", err)
+	}
+	up, err := c.Upload(ctx, token.AccessToken, "12345678", false, document)
+	if err != nil || up.Index == "" || len(up.Errors) > 0 {
+		t.Fatalf("Upload() = %+v, %v", up, err)
+	}
+	if _, cif, _ := stub.Uploaded(up.Index); cif != "12345678" {
+		t.Errorf("uploaded for CIF %q", cif)
+	}
+
+	st, err := c.Status(ctx, token.AccessToken, up.Index)
+	if err != nil || st.Status != StatusProcessing {
+		t.Fatalf("first Status() = %+v, %v", st, err)
+	}
+	st, err = c.Status(ctx, token.AccessToken, up.Index)
+	if err != nil || st.Status != StatusAccepted || st.DownloadID == "" {
+		t.Fatalf("second Status() = %+v, %v", st, err)
+	}
+
+	archive, err := c.Download(ctx, token.AccessToken, st.DownloadID)
+	if err != nil {
+		t.Fatalf("Download() error: %v", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil || len(zr.File) != 2 {
+		t.Fatalf("archive has %v files, error %v", len(zr.File), err)
+	}
+	if msgs := ErrorMessages(archive); len(msgs) != 0 {
+		t.Errorf("accepted archive has errors %v", msgs)
+	}
+	if _, err := c.Download(ctx, token.AccessToken, "999"); err == nil {
+		t.Error("Download() of an unknown id succeeded")
+	}
+}
+
+func TestClientUploadRejected(t *testing.T) {
+	c, stub := stubClient(t)
+	stub.ProcessingPolls = 0
+	stub.Reject = func([]byte) []string {
+		return []string{"E: validari globale eroare: [BR-RO-100] Daca Codul subdiviziunii tarii este RO-B, orasul trebuie sa fie SECTOR1-6"}
+	}
+	ctx := context.Background()
+	token, _ := c.ExchangeCode(ctx, stub.IssueCode())
+
+	inv, items := clientInvoice()
+	inv.Status = db.InvoiceStatusCreditNote
+	document, _ := Render(inv, items)
+	up, err := c.Upload(ctx, token.AccessToken, "12345678", true, document)
+	if err != nil || up.Index == "" {
+		t.Fatalf("Upload() = %+v, %v", up, err)
+	}
+	st, err := c.Status(ctx, token.AccessToken, up.Index)
+	if err != nil || st.Status != StatusRejected || st.DownloadID == "" {
+		t.Fatalf("Status() = %+v, %v", st, err)
+	}
+	archive, err := c.Download(ctx, token.AccessToken, st.DownloadID)
+	if err != nil {
+		t.Fatalf("Download() error: %v", err)
+	}
+	if msgs := ErrorMessages(archive); len(msgs) != 1 || !strings.Contains(msgs[0], "BR-RO-100") {
+		t.Errorf("ErrorMessages() = %v", msgs)
+	}
+
+	refused, err := c.Upload(ctx, token.AccessToken, "RO12345678", false, []byte("<Invoice>"))
+	if err != nil || refused.Index != "" || len(refused.Errors) != 2 {
+		t.Errorf("Upload() of an invalid file = %+v, %v", refused, err)
+	}
+	if _, err := c.Status(ctx, token.AccessToken, "1"); err == nil {
+		t.Error("Status() of an unknown upload succeeded")
+	}
+}
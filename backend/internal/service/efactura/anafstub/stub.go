@@ -0,0 +1,310 @@
+// Package anafstub is an in-memory stand-in for the ANAF OAuth2 server and
+// the e-Factura SPV API, for tests and local development. It serves the
+// OAuth2 endpoints under /oauth and the e-Factura endpoints under /api.
+package anafstub
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CIUS-RO customization identifier every uploaded invoice must declare.
+const customizationID = "urn:cen.eu:en16931:2017#compliant#urn:efactura.mfinante.ro:CIUS-RO:1.0.1"
+
+// Server is the stub. Uploads are reported as being processed for
+// ProcessingPolls status requests and then accepted, unless Reject returns
+// validation errors for the document.
+type Server struct {
+	// ProcessingPolls is how many status requests report an upload as still
+	// being processed.
+	ProcessingPolls int
+	// Reject returns the validation errors of an uploaded document; nil
+	// accepts every well-formed CIUS-RO document.
+	Reject func(document []byte) []string
+	// TokenLifetime is the lifetime of the access tokens issued.
+	TokenLifetime time.Duration
+
+	mu       sync.Mutex
+	mux      *http.ServeMux
+	codes    map[string]bool
+	access   map[string]time.Time
+	refresh  map[string]bool
+	uploads  map[string]*upload
+	archives map[string][]byte
+	nextID   int
+}
+
+type upload struct {
+	cif, standard string
+	document      []byte
+	polls         int
+	downloadID    string
+	errors        []string
+}
+
+// New creates a stub with one processing poll and 90-day access tokens, like
+// ANAF.
+func New() *Server {
+	s := &Server{
+		ProcessingPolls: 1,
+		TokenLifetime:   90 * 24 * time.Hour,
+		codes:           map[string]bool{},
+		access:          map[string]time.Time{},
+		refresh:         map[string]bool{},
+		uploads:         map[string]*upload{},
+		archives:        map[string][]byte{},
+		nextID:          5000000,
+	}
+	s.mux = http.NewServeMux()
+	s.mux.HandleFunc("GET /oauth/authorize", s.authorize)
+	s.mux.HandleFunc("POST /oauth/token", s.token)
+	s.mux.HandleFunc("POST /api/upload", s.authorized(s.upload))
+	s.mux.HandleFunc("GET /api/stareMesaj", s.authorized(s.status))
+	s.mux.HandleFunc("GET /api/descarcare", s.authorized(s.download))
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Uploaded returns the document of an upload and the CIF it was sent for.
+func (s *Server) Uploaded(index string) (document []byte, cif string, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, found := s.uploads[index]
+	if !found {
+		return nil, "", false
+	}
+	return u.document, u.cif, true
+}
+
+// ExpireTokens makes every issued access token expired, to exercise refresh.
+func (s *Server) ExpireTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for t := range s.access {
+		s.access[t] = time.Now().Add(-time.Minute)
+	}
+}
+
+// authorize stands in for the ANAF login with a certificate: it approves at
+// once and redirects back with a code.
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request) {
+	redirect, err := url.Parse(r.URL.Query().Get("redirect_uri"))
+	if err != nil || redirect.Scheme == "" {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+	code := s.IssueCode()
+
+	q := redirect.Query()
+	q.Set("code", code)
+	q.Set("state", r.URL.Query().Get("state"))
+	redirect.RawQuery = q.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+// IssueCode returns an authorization code as if a user had logged in.
+func (s *Server) IssueCode() string {
+	code := s.newID("code")
+	s.mu.Lock()
+	s.codes[code] = true
+	s.mu.Unlock()
+	return code
+}
+
+func (s *Server) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.Form.Get("client_id") == "" || r.Form.Get("client_secret") == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	s.mu.Lock()
+	var ok bool
+	switch r.Form.Get("grant_type") {
+	case "authorization_code":
+		code := r.Form.Get("code")
+		ok = s.codes[code]
+		delete(s.codes, code)
+	case "refresh_token":
+		rt := r.Form.Get("refresh_token")
+		ok = s.refresh[rt]
+		delete(s.refresh, rt)
+	}
+	s.mu.Unlock()
+	if !ok {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	access, refresh := s.newID("at"), s.newID("rt")
+	s.mu.Lock()
+	s.access[access] = time.Now().Add(s.TokenLifetime)
+	s.refresh[refresh] = true
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token":  access,
+		"refresh_token": refresh,
+		"token_type":    "Bearer",
+		"expires_in":    int64(s.TokenLifetime / time.Second),
+	})
+}
+
+func (s *Server) authorized(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		s.mu.Lock()
+		expires, ok := s.access[token]
+		s.mu.Unlock()
+		if !ok || time.Now().After(expires) {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (s *Server) upload(w http.ResponseWriter, r *http.Request) {
+	const ns = "mfp:anaf:dgti:spv:respUploadFisier:v1"
+	standard, cif := r.URL.Query().Get("standard"), r.URL.Query().Get("cif")
+	document, _ := io.ReadAll(r.Body)
+	now := time.Now().Format("200601021504")
+
+	var errs []string
+	if standard != "UBL" && standard != "CN" {
+		errs = append(errs, "Valorile acceptate pentru parametrul standard sunt UBL, CN, CII sau RASP")
+	}
+	if cif == "" || strings.Trim(cif, "0123456789") != "" {
+		errs = append(errs, fmt.Sprintf("CIF introdus= %s nu este un numar", cif))
+	}
+	if !bytes.Contains(document, []byte(customizationID)) || xml.Unmarshal(document, new(struct{})) != nil {
+		errs = append(errs, "Fisierul transmis nu este valid.")
+	}
+	if len(errs) > 0 {
+		writeXML(w, fmt.Sprintf(`<header xmlns="%s" dateResponse="%s" ExecutionStatus="1">%s</header>`, ns, now, errorElements("Errors", errs)))
+		return
+	}
+
+	index := s.newIndex()
+	s.mu.Lock()
+	s.uploads[index] = &upload{cif: cif, standard: standard, document: document}
+	s.mu.Unlock()
+	writeXML(w, fmt.Sprintf(`<header xmlns="%s" dateResponse="%s" ExecutionStatus="0" index_incarcare="%s"/>`, ns, now, index))
+}
+
+func (s *Server) status(w http.ResponseWriter, r *http.Request) {
+	const ns = "mfp:anaf:dgti:efactura:stareMesajFactura:v1"
+	index := r.URL.Query().Get("id_incarcare")
+
+	s.mu.Lock()
+	u, ok := s.uploads[index]
+	if !ok {
+		s.mu.Unlock()
+		writeXML(w, fmt.Sprintf(`<header xmlns="%s">%s</header>`, ns,
+			errorElements("Errors", []string{"Nu exista factura cu id_incarcare= " + index})))
+		return
+	}
+	u.polls++
+	if u.polls <= s.ProcessingPolls {
+		s.mu.Unlock()
+		writeXML(w, fmt.Sprintf(`<header xmlns="%s" stare="in prelucrare"/>`, ns))
+		return
+	}
+	if u.downloadID == "" {
+		if s.Reject != nil {
+			u.errors = s.Reject(u.document)
+		}
+		u.downloadID = s.newIndexLocked()
+		s.archives[u.downloadID] = archive(index, u)
+	}
+	stare := "ok"
+	if len(u.errors) > 0 {
+		stare = "nok"
+	}
+	downloadID := u.downloadID
+	s.mu.Unlock()
+	writeXML(w, fmt.Sprintf(`<header xmlns="%s" stare="%s" id_descarcare="%s"/>`, ns, stare, downloadID))
+}
+
+func (s *Server) download(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	data, ok := s.archives[r.URL.Query().Get("id")]
+	s.mu.Unlock()
+	if !ok {
+		writeJSON(w, http.StatusOK, map[string]string{"eroare": "Pentru id=" + r.URL.Query().Get("id") + " nu exista inregistrata nici o factura"})
+		return
+	}
+	w.Header().Set("Content-Type", "application/zip")
+	w.Write(data)
+}
+
+// archive builds the response ZIP of an upload: the invoice and the
+// signature when accepted, the error report when rejected.
+func archive(index string, u *upload) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	add := func(name string, data []byte) {
+		f, _ := zw.Create(name)
+		f.Write(data)
+	}
+	if len(u.errors) > 0 {
+		add(index+".xml", []byte(fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<header xmlns="mfp:anaf:dgti:efactura:mesajEroriFactuta:v1" Index_incarcare="%s" Cif_emitent="%s">%s</header>`,
+			index, u.cif, errorElements("Error", u.errors))))
+	} else {
+		add(index+".xml", u.document)
+		add("semnatura_"+index+".xml", []byte(`<?xml version="1.0" encoding="UTF-8"?><Signature xmlns="http://www.w3.org/2000/09/xmldsig#"/>`))
+	}
+	zw.Close()
+	return buf.Bytes()
+}
+
+func errorElements(name string, errs []string) string {
+	var b strings.Builder
+	for _, e := range errs {
+		b.WriteString("<" + name + ` errorMessage="`)
+		xml.EscapeText(&b, []byte(e))
+		b.WriteString(`"/>`)
+	}
+	return b.String()
+}
+
+func (s *Server) newIndex() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.newIndexLocked()
+}
+
+func (s *Server) newIndexLocked() string {
+	s.nextID++
+	return fmt.Sprint(s.nextID)
+}
+
+func (s *Server) newID(prefix string) string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return prefix + "_" + hex.EncodeToString(b)
+}
+
+func writeXML(w http.ResponseWriter, body string) {
+	w.Header().Set("Content-Type", "application/xml")
+	io.WriteString(w, body)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
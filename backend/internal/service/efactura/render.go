@@ -0,0 +1,362 @@
+// Package efactura renders invoices as UBL 2.1 XML following CIUS-RO, the
+// Romanian national specification of EN 16931 required by the e-Factura
+// system, independently of any invoicing provider.
+package efactura
+
+import (
+	"encoding/xml"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	db "helpmeclean-backend/internal/db/generated"
+	"helpmeclean-backend/internal/service/recurrence"
+)
+
+// CIUS-RO length limits.
+const (
+	maxItemName        = 100
+	maxItemDescription = 200
+	maxNote            = 300
+)
+
+// anonymousBuyerID stands in for the CNP of individual buyers, as accepted by
+// ANAF.
+const anonymousBuyerID = "0000000000000"
+
+// Render returns the CIUS-RO XML of an invoice and its line items. Credit
+// notes (status credit_note or a negative total) are rendered as a UBL
+// CreditNote with positive amounts, referencing the invoice they correct when
+// it is known. Sellers that are VAT payers charge VAT
+// under the standard rate category (S); sellers that are not are outside the
+// scope of VAT (O) and must not charge any.
+//
+// The seller is identified by its CUI (BT-30) and, when it has one, its
+// trade register number (J-number) is given as additional legal information
+// (BT-33).
+//
+// The line items must add up to the invoice totals. When the invoice lacks
+// data required by CIUS-RO, the error lists every missing piece. Render does
+// not run the official UBL schema or CIUS-RO schematron; ANAF validates each
+// upload and a rejection is recorded on the invoice.
+func Render(inv db.Invoice, items []db.InvoiceLineItem) ([]byte, error) {
+	var problems []string
+	problem := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	number := textVal(inv.InvoiceNumber)
+	if !strings.ContainsAny(number, "0123456789") {
+		problem("invoice number %q must contain a digit", number)
+	}
+	if inv.Currency != "RON" {
+		problem("currency %s is not supported, only RON", inv.Currency)
+	}
+	if len(items) == 0 {
+		problem("invoice has no line items")
+	}
+
+	credit := inv.Status == db.InvoiceStatusCreditNote || inv.TotalAmount < 0
+	sign := int64(1)
+	if inv.TotalAmount < 0 {
+		sign = -1
+	}
+	money := func(bani int64) amount {
+		return amount{Currency: inv.Currency, Value: formatBani(bani)}
+	}
+
+	doc := document{
+		CacNamespace:         cacNamespace,
+		CbcNamespace:         cbcNamespace,
+		CustomizationID:      CustomizationID,
+		ID:                   number,
+		IssueDate:            issueDate(inv),
+		DocumentCurrencyCode: inv.Currency,
+	}
+	if credit {
+		doc.XMLName = xml.Name{Local: "CreditNote"}
+		doc.Namespace = creditNoteNamespace
+		doc.CreditNoteTypeCode = typeCodeCreditNote
+	} else {
+		doc.XMLName = xml.Name{Local: "Invoice"}
+		doc.Namespace = invoiceNamespace
+		doc.InvoiceTypeCode = typeCodeInvoice
+		if inv.DueDate.Valid {
+			doc.DueDate = inv.DueDate.Time.Format("2006-01-02")
+		}
+	}
+	if note := textVal(inv.Notes); note != "" {
+		doc.Note = []string{truncate(note, maxNote)}
+	}
+	if ref := textVal(inv.CreditedInvoiceNumber); ref != "" {
+		doc.BillingReference = &documentRef{ID: ref}
+		if inv.CreditedInvoiceDate.Valid {
+			doc.BillingReference.IssueDate = inv.CreditedInvoiceDate.Time.Format("2006-01-02")
+		}
+	}
+
+	// Seller.
+	sellerCUI := bareCUI(inv.SellerCui)
+	doc.Supplier = party{
+		Name:          inv.SellerCompanyName,
+		PostalAddress: address("seller", inv.SellerAddress, inv.SellerCity, inv.SellerCounty, problem),
+		LegalEntity: legalEntity{
+			RegistrationName: inv.SellerCompanyName,
+			CompanyID:        sellerCUI,
+		},
+	}
+	if reg := textVal(inv.SellerRegNumber); reg != "" {
+		doc.Supplier.LegalEntity.CompanyLegalForm = reg
+	}
+	if inv.SellerCompanyName == "" {
+		problem("seller name is missing")
+	}
+	if sellerCUI == "" {
+		problem("seller CUI is missing")
+	}
+	if inv.SellerIsVatPayer {
+		doc.Supplier.PartyTaxScheme = &partyTaxScheme{CompanyID: "RO" + sellerCUI, TaxScheme: "VAT"}
+	}
+
+	// Buyer. Individuals have no CUI and are identified by 13 zeros.
+	buyerCUI := bareCUI(textVal(inv.BuyerCui))
+	doc.Customer = party{
+		Name:          inv.BuyerName,
+		PostalAddress: address("buyer", textVal(inv.BuyerAddress), textVal(inv.BuyerCity), textVal(inv.BuyerCounty), problem),
+		LegalEntity: legalEntity{
+			RegistrationName: inv.BuyerName,
+			CompanyID:        anonymousBuyerID,
+		},
+	}
+	if email := textVal(inv.BuyerEmail); email != "" {
+		doc.Customer.Contact = &contact{Email: email}
+	}
+	if buyerCUI != "" {
+		doc.Customer.LegalEntity.CompanyID = buyerCUI
+		if inv.BuyerIsVatPayer.Bool && inv.SellerIsVatPayer {
+			doc.Customer.PartyTaxScheme = &partyTaxScheme{CompanyID: "RO" + buyerCUI, TaxScheme: "VAT"}
+		}
+	}
+	if inv.BuyerName == "" {
+		problem("buyer name is missing")
+	}
+
+	if iban := textVal(inv.SellerIban); iban != "" {
+		doc.PaymentMeans = &paymentMeans{
+			Code:    "42", // payment to bank account
+			Account: &financialAccount{ID: strings.ReplaceAll(iban, " ", ""), Name: textVal(inv.SellerBankName)},
+		}
+	} else {
+		doc.PaymentMeans = &paymentMeans{Code: "48"} // bank card
+	}
+
+	// Lines, grouped by VAT rate for the tax breakdown.
+	type vatGroup struct {
+		rate             *big.Rat
+		taxable, vatBani int64
+	}
+	groups := map[string]*vatGroup{}
+	var lineTotal, vatTotal int64
+	sorted := append([]db.InvoiceLineItem(nil), items...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].SortOrder.Int32 < sorted[j].SortOrder.Int32 })
+	for i, li := range sorted {
+		net := sign * int64(li.LineTotal)
+		vat := sign * int64(li.VatAmount)
+		price := sign * int64(li.UnitPrice)
+		rate := numericRat(li.VatRate)
+
+		category := taxCategory{TaxScheme: "VAT"}
+		if inv.SellerIsVatPayer {
+			if rate.Sign() <= 0 {
+				problem("line %d has no VAT rate", i+1)
+			}
+			category.ID = "S"
+			category.Percent = formatRat(rate)
+		} else {
+			if vat != 0 {
+				problem("line %d charges VAT but the seller is not a VAT payer", i+1)
+			}
+			category.ID = "O"
+		}
+
+		key := category.ID + category.Percent
+		g, found := groups[key]
+		if !found {
+			g = &vatGroup{rate: rate}
+			groups[key] = g
+		}
+		g.taxable += net
+		g.vatBani += vat
+		lineTotal += net
+		vatTotal += vat
+
+		qty := &quantity{UnitCode: "C62", Value: formatRat(numericRat(li.Quantity))}
+		line := documentLine{
+			ID:                  fmt.Sprint(i + 1),
+			LineExtensionAmount: money(net),
+			Item: item{
+				Name:        truncate(li.DescriptionRo, maxItemName),
+				TaxCategory: category,
+			},
+			Price: money(price),
+		}
+		if utf8.RuneCountInString(li.DescriptionRo) > maxItemName {
+			line.Item.Description = truncate(li.DescriptionRo, maxItemDescription)
+		}
+		if price < 0 {
+			problem("line %d has a negative price", i+1)
+		}
+		if credit {
+			line.CreditedQuantity = qty
+			doc.CreditNoteLines = append(doc.CreditNoteLines, line)
+		} else {
+			line.InvoicedQuantity = qty
+			doc.InvoiceLines = append(doc.InvoiceLines, line)
+		}
+	}
+
+	keys := make([]string, 0, len(groups))
+	for k := range groups {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	doc.TaxTotal.TaxAmount = money(vatTotal)
+	for _, k := range keys {
+		g := groups[k]
+		category := taxCategory{ID: k[:1], TaxScheme: "VAT"}
+		if category.ID == "S" {
+			category.Percent = formatRat(g.rate)
+		} else {
+			category.ExemptionReasonCode = "VATEX-EU-O"
+			category.ExemptionReason = "Neplatitor de TVA"
+		}
+		doc.TaxTotal.Subtotals = append(doc.TaxTotal.Subtotals, taxSubtotal{
+			TaxableAmount: money(g.taxable),
+			TaxAmount:     money(g.vatBani),
+			Category:      category,
+		})
+	}
+
+	total := lineTotal + vatTotal
+	doc.LegalMonetaryTotal = monetaryTotal{
+		LineExtensionAmount: money(lineTotal),
+		TaxExclusiveAmount:  money(lineTotal),
+		TaxInclusiveAmount:  money(total),
+		PayableAmount:       money(total),
+	}
+	if len(items) > 0 && (lineTotal != sign*int64(inv.SubtotalAmount) ||
+		vatTotal != sign*int64(inv.VatAmount) || total != sign*int64(inv.TotalAmount)) {
+		problem("line items (%s + %s VAT) do not add up to the invoice totals (%s + %s VAT = %s)",
+			formatBani(lineTotal), formatBani(vatTotal),
+			formatBani(sign*int64(inv.SubtotalAmount)), formatBani(sign*int64(inv.VatAmount)), formatBani(sign*int64(inv.TotalAmount)))
+	}
+
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("efactura: invoice %s cannot be rendered: %s", number, strings.Join(problems, "; "))
+	}
+
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("efactura: marshal invoice %s: %w", number, err)
+	}
+	return append([]byte(xml.Header), out...), nil
+}
+
+// address builds a Romanian postal address. Addresses in Bucharest use the
+// sector ("SECTOR1" to "SECTOR6") as the city name.
+func address(who, street, city, county string, problem func(string, ...any)) postalAddress {
+	a := postalAddress{StreetName: street, CityName: city, Country: "RO"}
+	if strings.TrimSpace(street) == "" {
+		problem("%s street address is missing", who)
+	}
+	if strings.TrimSpace(city) == "" {
+		problem("%s city is missing", who)
+	}
+	code, ok := CountyCode(county)
+	if !ok {
+		problem("%s county %q is not a Romanian county", who, county)
+		return a
+	}
+	a.CountrySubentity = code
+	if code == "RO-B" {
+		sector, found := bucharestSector(city, street)
+		if !found {
+			problem("%s address in Bucharest does not name the sector", who)
+		}
+		a.CityName = sector
+	}
+	return a
+}
+
+// issueDate is the issue date of an invoice in Romanian time, falling back to
+// its creation for drafts that were never issued.
+func issueDate(inv db.Invoice) string {
+	t := inv.CreatedAt.Time
+	if inv.IssuedAt.Valid {
+		t = inv.IssuedAt.Time
+	}
+	return t.In(recurrence.Location).Format("2006-01-02")
+}
+
+// bareCUI strips spaces and the "RO" VAT prefix from a fiscal code.
+func bareCUI(cui string) string {
+	cui = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(cui), " ", ""))
+	return strings.TrimPrefix(cui, "RO")
+}
+
+// formatBani formats an amount in bani as RON with two decimals.
+func formatBani(bani int64) string {
+	s := ""
+	if bani < 0 {
+		s, bani = "-", -bani
+	}
+	return fmt.Sprintf("%s%d.%02d", s, bani/100, bani%100)
+}
+
+// numericRat converts a pgtype.Numeric to an exact rational; NULL is zero.
+func numericRat(n pgtype.Numeric) *big.Rat {
+	r := new(big.Rat)
+	if !n.Valid || n.Int == nil {
+		return r
+	}
+	r.SetInt(n.Int)
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(n.Exp))), nil)
+	if n.Exp >= 0 {
+		return r.Mul(r, new(big.Rat).SetInt(scale))
+	}
+	return r.Quo(r, new(big.Rat).SetInt(scale))
+}
+
+// formatRat formats a decimal without trailing zeros ("21", "9.5", "1.25").
+func formatRat(r *big.Rat) string {
+	s := r.FloatString(6)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+func abs(n int32) int32 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// truncate shortens s to at most n characters.
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
+
+// textVal extracts a string from a pgtype.Text, returning "" if null.
+func textVal(t pgtype.Text) string {
+	if !t.Valid {
+		return ""
+	}
+	return t.String
+}
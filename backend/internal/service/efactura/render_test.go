@@ -0,0 +1,491 @@
+package efactura
+
+import (
+	"bytes"
+	"encoding/xml"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	db "helpmeclean-backend/internal/db/generated"
+)
+
+// node is a parsed XML element, addressed by local names.
+type node struct {
+	Space, Name string
+	Attrs       map[string]string
+	Text        string
+	Children    []*node
+}
+
+func parse(t *testing.T, data []byte) *node {
+	t.Helper()
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	var stack []*node
+	var root *node
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			n := &node{Space: tok.Name.Space, Name: tok.Name.Local, Attrs: map[string]string{}}
+			for _, a := range tok.Attr {
+				n.Attrs[a.Name.Local] = a.Value
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.Children = append(parent.Children, n)
+			} else {
+				root = n
+			}
+			stack = append(stack, n)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].Text += strings.TrimSpace(string(tok))
+			}
+		}
+	}
+	if root == nil {
+		t.Fatalf("no XML document in %s", data)
+	}
+	return root
+}
+
+// all returns the descendants at a slash-separated path of local names.
+func (n *node) all(path string) []*node {
+	nodes := []*node{n}
+	for _, name := range strings.Split(path, "/") {
+		var next []*node
+		for _, p := range nodes {
+			for _, c := range p.Children {
+				if c.Name == name {
+					next = append(next, c)
+				}
+			}
+		}
+		nodes = next
+	}
+	return nodes
+}
+
+func (n *node) text(path string) string {
+	if found := n.all(path); len(found) > 0 {
+		return found[0].Text
+	}
+	return ""
+}
+
+// cents parses an amount with two decimals into bani.
+func cents(t *testing.T, s string) int64 {
+	t.Helper()
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		t.Fatalf("invalid amount %q", s)
+		return 0
+	}
+	r.Mul(r, big.NewRat(100, 1))
+	if !r.IsInt() {
+		t.Errorf("amount %q has more than two decimals", s)
+	}
+	return new(big.Int).Quo(r.Num(), r.Denom()).Int64()
+}
+
+// rootOrder is the order of the top-level elements in the UBL 2.1 Invoice and
+// CreditNote schemas that Render emits.
+var rootOrder = []string{
+	"CustomizationID", "ID", "IssueDate", "DueDate", "InvoiceTypeCode", "CreditNoteTypeCode", "Note",
+	"DocumentCurrencyCode", "BillingReference", "AccountingSupplierParty", "AccountingCustomerParty", "PaymentMeans",
+	"TaxTotal", "LegalMonetaryTotal", "InvoiceLine", "CreditNoteLine",
+}
+
+var subdivision = regexp.MustCompile(`^RO-[A-Z]{1,2}$`)
+
+// validate checks a document against the UBL 2.1 structure and the EN 16931
+// and CIUS-RO business rules that apply to the invoices we issue, returning
+// the broken rules. It is a hand-written subset of those rules, not the
+// official schema and schematron, which are not run here.
+func validate(t *testing.T, data []byte) []string {
+	t.Helper()
+	doc := parse(t, data)
+	var broken []string
+	check := func(ok bool, rule string) {
+		if !ok {
+			broken = append(broken, rule)
+		}
+	}
+
+	lineName, qtyName, typeName := "InvoiceLine", "InvoicedQuantity", "InvoiceTypeCode"
+	switch doc.Space {
+	case invoiceNamespace:
+		check(doc.Name == "Invoice", "UBL: root element")
+	case creditNoteNamespace:
+		check(doc.Name == "CreditNote", "UBL: root element")
+		lineName, qtyName, typeName = "CreditNoteLine", "CreditedQuantity", "CreditNoteTypeCode"
+	default:
+		broken = append(broken, "UBL: namespace")
+	}
+
+	last := -1
+	for _, c := range doc.Children {
+		pos := -1
+		for i, name := range rootOrder {
+			if name == c.Name {
+				pos = i
+			}
+		}
+		check(pos >= last, "UBL: element order at "+c.Name)
+		last = pos
+	}
+
+	check(doc.text("CustomizationID") == CustomizationID, "BR-01 CIUS-RO specification identifier")
+	check(strings.ContainsAny(doc.text("ID"), "0123456789"), "BR-RO-010 invoice number contains a digit")
+	_, err := time.Parse("2006-01-02", doc.text("IssueDate"))
+	check(err == nil, "BR-03 issue date")
+	code := doc.text(typeName)
+	check(code == "380" || code == "381" || code == "384" || code == "389" || code == "751", "BR-RO-020 type code")
+	check(doc.text("DocumentCurrencyCode") == "RON", "BR-05 currency")
+	for _, note := range doc.all("Note") {
+		check(len([]rune(note.Text)) <= 300, "BR-RO-A020 note length")
+	}
+
+	for _, who := range []string{"AccountingSupplierParty", "AccountingCustomerParty"} {
+		p := doc.all(who + "/Party")
+		if len(p) != 1 {
+			broken = append(broken, "BR-06/BR-07 "+who)
+			continue
+		}
+		party := p[0]
+		check(party.text("PartyLegalEntity/RegistrationName") != "", "BR-06/BR-07 name of "+who)
+		check(party.text("PostalAddress/StreetName") != "", "BR-RO-080/BR-RO-100 street of "+who)
+		check(party.text("PostalAddress/Country/IdentificationCode") == "RO", "BR-09/BR-11 country of "+who)
+		sub := party.text("PostalAddress/CountrySubentity")
+		check(subdivision.MatchString(sub), "BR-RO-110/BR-RO-111 county code of "+who)
+		city := party.text("PostalAddress/CityName")
+		check(city != "", "BR-RO-090 city of "+who)
+		if sub == "RO-B" {
+			check(regexp.MustCompile(`^SECTOR[1-6]$`).MatchString(city), "BR-RO-100 Bucharest sector of "+who)
+		}
+		if vat := party.text("PartyTaxScheme/CompanyID"); vat != "" {
+			check(regexp.MustCompile(`^RO[0-9]{2,10}$`).MatchString(vat), "BR-CO-09 VAT identifier prefix of "+who)
+		}
+	}
+	supplier := doc.all("AccountingSupplierParty/Party")
+	if len(supplier) == 1 {
+		s := supplier[0]
+		check(s.text("PartyTaxScheme/CompanyID") != "" || s.text("PartyLegalEntity/CompanyID") != "", "BR-CO-26 seller identifier")
+	}
+
+	lines := doc.all(lineName)
+	check(len(lines) > 0, "BR-16 at least one line")
+	var lineSum int64
+	for _, l := range lines {
+		check(l.text("ID") != "", "BR-21 line identifier")
+		check(l.text(qtyName) != "", "BR-22 line quantity")
+		check(l.text("Item/Name") != "" && len([]rune(l.text("Item/Name"))) <= 100, "BR-25/BR-RO-A100 item name")
+		check(!strings.HasPrefix(l.text("Price/PriceAmount"), "-"), "BR-27 non-negative price")
+		check(l.text("Item/ClassifiedTaxCategory/ID") != "", "BR-CO-04 line VAT category")
+		lineSum += cents(t, l.text("LineExtensionAmount"))
+	}
+
+	totals := doc.all("LegalMonetaryTotal")
+	if len(totals) != 1 {
+		return append(broken, "BR-12..BR-15 document totals")
+	}
+	total := totals[0]
+	lineExt := cents(t, total.text("LineExtensionAmount"))
+	taxExcl := cents(t, total.text("TaxExclusiveAmount"))
+	taxIncl := cents(t, total.text("TaxInclusiveAmount"))
+	payable := cents(t, total.text("PayableAmount"))
+	check(lineExt == lineSum, "BR-CO-10 sum of line net amounts")
+	check(taxExcl == lineExt, "BR-CO-13 total without VAT")
+
+	var vatSum int64
+	for _, sub := range doc.all("TaxTotal/TaxSubtotal") {
+		taxable := cents(t, sub.text("TaxableAmount"))
+		vat := cents(t, sub.text("TaxAmount"))
+		vatSum += vat
+		category := sub.text("TaxCategory/ID")
+		var categoryLines int64
+		for _, l := range lines {
+			if l.text("Item/ClassifiedTaxCategory/ID") == category &&
+				l.text("Item/ClassifiedTaxCategory/Percent") == sub.text("TaxCategory/Percent") {
+				categoryLines += cents(t, l.text("LineExtensionAmount"))
+			}
+		}
+		check(taxable == categoryLines, "BR-"+category+"-08 taxable amount of category "+category)
+		switch category {
+		case "S":
+			rate, err := strconv.ParseFloat(sub.text("TaxCategory/Percent"), 64)
+			check(err == nil && rate > 0, "BR-S-05 standard rate")
+			// EN 16931 allows a difference of one currency unit.
+			expected := float64(taxable) * rate / 100
+			check(float64(vat) > expected-100 && float64(vat) < expected+100, "BR-S-09 VAT amount of category S")
+		case "O":
+			check(vat == 0, "BR-O-09 no VAT in category O")
+			check(sub.text("TaxCategory/Percent") == "", "BR-O-05 no VAT rate in category O")
+			check(sub.text("TaxCategory/TaxExemptionReasonCode") != "" || sub.text("TaxCategory/TaxExemptionReason") != "", "BR-O-10 exemption reason")
+			for _, who := range []string{"AccountingSupplierParty", "AccountingCustomerParty"} {
+				check(doc.text(who+"/Party/PartyTaxScheme/CompanyID") == "", "BR-O-02/BR-O-03 no VAT identifiers with category O")
+			}
+		}
+	}
+	check(cents(t, doc.text("TaxTotal/TaxAmount")) == vatSum, "BR-CO-14 invoice VAT total")
+	check(taxIncl == taxExcl+vatSum, "BR-CO-15 total with VAT")
+	check(payable == taxIncl, "BR-CO-16 amount due")
+	return broken
+}
+
+func text(s string) pgtype.Text {
+	return pgtype.Text{String: s, Valid: true}
+}
+
+func numeric(n int64) pgtype.Numeric {
+	return pgtype.Numeric{Int: big.NewInt(n), Valid: true}
+}
+
+func clientInvoice() (db.Invoice, []db.InvoiceLineItem) {
+	inv := db.Invoice{
+		InvoiceType:       db.InvoiceTypeClientService,
+		InvoiceNumber:     text("CLE-2026-000042"),
+		SellerCompanyName: "Clean Cluj SRL",
+		SellerCui:         "RO 12345678",
+		SellerRegNumber:   text("J12/345/2020"),
+		SellerAddress:     "Str. Memorandumului 10",
+		SellerCity:        "Cluj-Napoca",
+		SellerCounty:      "Cluj",
+		SellerIsVatPayer:  true,
+		BuyerName:         "Ion Popescu",
+		BuyerAddress:      text("Str. Horea 3, ap. 4"),
+		BuyerCity:         text("Cluj-Napoca"),
+		BuyerCounty:       text("Județul Cluj"),
+		BuyerEmail:        text("ion@example.com"),
+		SubtotalAmount:    20000,
+		VatRate:           numeric(21),
+		VatAmount:         4200,
+		TotalAmount:       24200,
+		Currency:          "RON",
+		Status:            db.InvoiceStatusIssued,
+		IssuedAt:          pgtype.Timestamptz{Time: time.Date(2026, 3, 31, 22, 30, 0, 0, time.UTC), Valid: true},
+		DueDate:           pgtype.Date{Time: time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC), Valid: true},
+		Notes:             text("Servicii curatenie - rezervare HMC-1234"),
+	}
+	items := []db.InvoiceLineItem{
+		{DescriptionRo: "Curatenie generala", Quantity: numeric(1), UnitPrice: 15000, VatRate: numeric(21), VatAmount: 3150, LineTotal: 15000, LineTotalWithVat: 18150, SortOrder: pgtype.Int4{Int32: 1, Valid: true}},
+		{DescriptionRo: "Curatare geamuri", Quantity: numeric(2), UnitPrice: 2500, VatRate: numeric(21), VatAmount: 1050, LineTotal: 5000, LineTotalWithVat: 6050, SortOrder: pgtype.Int4{Int32: 2, Valid: true}},
+	}
+	return inv, items
+}
+
+func TestRenderVATPayerInvoice(t *testing.T) {
+	inv, items := clientInvoice()
+	out, err := Render(inv, items)
+	if err != nil {
+		t.Fatalf("Render() error: %v", err)
+	}
+	if broken := validate(t, out); len(broken) > 0 {
+		t.Fatalf("broken rules: %v\n%s", broken, out)
+	}
+
+	doc := parse(t, out)
+	checks := map[string]string{
+		"IssueDate":       "2026-04-01", // Romanian time
+		"DueDate":         "2026-05-01",
+		"InvoiceTypeCode": "380",
+		"AccountingSupplierParty/Party/PartyTaxScheme/CompanyID":          "RO12345678",
+		"AccountingSupplierParty/Party/PartyLegalEntity/CompanyID":        "12345678",
+		"AccountingSupplierParty/Party/PartyLegalEntity/CompanyLegalForm": "J12/345/2020",
+		"AccountingSupplierParty/Party/PostalAddress/CountrySubentity":    "RO-CJ",
+		"AccountingCustomerParty/Party/PartyLegalEntity/CompanyID":        anonymousBuyerID,
+		"AccountingCustomerParty/Party/PostalAddress/CountrySubentity":    "RO-CJ",
+		"PaymentMeans/PaymentMeansCode":                                   "48",
+		"TaxTotal/TaxSubtotal/TaxCategory/ID":                             "S",
+		"TaxTotal/TaxSubtotal/TaxCategory/Percent":                        "21",
+		"LegalMonetaryTotal/PayableAmount":                                "242.00",
+		"InvoiceLine/InvoicedQuantity":                                    "1",
+		"InvoiceLine/Item/ClassifiedTaxCategory/Percent":                  "21",
+	}
+	for path, want := range checks {
+		if got := doc.text(path); got != want {
+			t.Errorf("%s = %q, want %q", path, got, want)
+		}
+	}
+	if got := doc.all("InvoiceLine")[1].text("InvoicedQuantity"); got != "2" {
+		t.Errorf("second line quantity = %q, want 2", got)
+	}
+	if doc.text("AccountingCustomerParty/Party/PartyTaxScheme/CompanyID") != "" {
+		t.Error("individual buyer has a VAT identifier")
+	}
+}
+
+func TestRenderNonVATPayer(t *testing.T) {
+	inv := db.Invoice{
+		InvoiceType:       db.InvoiceTypePlatformCommission,
+		InvoiceNumber:     text("HMC-2026-000007"),
+		SellerCompanyName: "Mica Firma PFA",
+		SellerCui:         "45678901",
+		SellerAddress:     "Bd. Unirii 20, Sector 3",
+		SellerCity:        "Bucuresti",
+		SellerCounty:      "București",
+		SellerIban:        text("RO49 AAAA 1B31 0075 9384 0000"),
+		SellerBankName:    text("ING Bank"),
+		BuyerName:         "Clean Cluj SRL",
+		BuyerCui:          text("RO12345678"),
+		BuyerIsVatPayer:   pgtype.Bool{Bool: true, Valid: true},
+		BuyerAddress:      text("Str. Memorandumului 10"),
+		BuyerCity:         text("Cluj-Napoca"),
+		BuyerCounty:       text("CJ"),
+		SubtotalAmount:    5000,
+		VatAmount:         0,
+		TotalAmount:       5000,
+		Currency:          "RON",
+		Status:            db.InvoiceStatusIssued,
+		CreatedAt:         pgtype.Timestamptz{Time: time.Date(2026, 4, 1, 9, 0, 0, 0, time.UTC), Valid: true},
+	}
+	items := []db.InvoiceLineItem{
+		{DescriptionRo: "Comision platforma", Quantity: numeric(1), UnitPrice: 5000, LineTotal: 5000, LineTotalWithVat: 5000},
+	}
+	out, err := Render(inv, items)
+	if err != nil {
+		t.Fatalf("Render() error: %v", err)
+	}
+	if broken := validate(t, out); len(broken) > 0 {
+		t.Fatalf("broken rules: %v\n%s", broken, out)
+	}
+
+	doc := parse(t, out)
+	checks := map[string]string{
+		"AccountingSupplierParty/Party/PostalAddress/CityName":         "SECTOR3",
+		"AccountingSupplierParty/Party/PostalAddress/CountrySubentity": "RO-B",
+		"AccountingSupplierParty/Party/PartyLegalEntity/CompanyID":     "45678901",
+		"AccountingCustomerParty/Party/PartyLegalEntity/CompanyID":     "12345678",
+		"PaymentMeans/PaymentMeansCode":                                "42",
+		"PaymentMeans/PayeeFinancialAccount/ID":                        "RO49AAAA1B31007593840000",
+		"TaxTotal/TaxSubtotal/TaxCategory/ID":                          "O",
+		"TaxTotal/TaxSubtotal/TaxCategory/TaxExemptionReasonCode":      "VATEX-EU-O",
+		"TaxTotal/TaxAmount":                                           "0.00",
+	}
+	for path, want := range checks {
+		if got := doc.text(path); got != want {
+			t.Errorf("%s = %q, want %q", path, got, want)
+		}
+	}
+
+	items[0].VatAmount = 1050
+	inv.VatAmount, inv.TotalAmount = 1050, 6050
+	if _, err := Render(inv, items); err == nil || !strings.Contains(err.Error(), "not a VAT payer") {
+		t.Errorf("Render() with VAT from a non-payer error = %v", err)
+	}
+}
+
+func TestRenderNonVATPayerWithRegNumber(t *testing.T) {
+	// A company that is not a VAT payer is identified by its CUI alone; its
+	// J-number is additional legal information, never its identifier.
+	inv, _ := clientInvoice()
+	inv.SellerIsVatPayer = false
+	inv.VatRate = pgtype.Numeric{}
+	inv.SubtotalAmount, inv.VatAmount, inv.TotalAmount = 20000, 0, 20000
+	items := []db.InvoiceLineItem{
+		{DescriptionRo: "Curatenie generala", Quantity: numeric(1), UnitPrice: 20000, LineTotal: 20000, LineTotalWithVat: 20000},
+	}
+	out, err := Render(inv, items)
+	if err != nil {
+		t.Fatalf("Render() error: %v", err)
+	}
+	if broken := validate(t, out); len(broken) > 0 {
+		t.Fatalf("broken rules: %v\n%s", broken, out)
+	}
+
+	doc := parse(t, out)
+	checks := map[string]string{
+		"AccountingSupplierParty/Party/PartyLegalEntity/CompanyID":        "12345678",
+		"AccountingSupplierParty/Party/PartyLegalEntity/CompanyLegalForm": "J12/345/2020",
+		"AccountingSupplierParty/Party/PartyTaxScheme/CompanyID":          "",
+		"TaxTotal/TaxSubtotal/TaxCategory/ID":                             "O",
+	}
+	for path, want := range checks {
+		if got := doc.text(path); got != want {
+			t.Errorf("%s = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestRenderCreditNote(t *testing.T) {
+	inv, _ := clientInvoice()
+	inv.InvoiceNumber = text("CN-2026-000003")
+	inv.Status = db.InvoiceStatusCreditNote
+	inv.SubtotalAmount, inv.VatAmount, inv.TotalAmount = -8264, -1736, -10000
+	inv.CreditedInvoiceNumber = text("CLE-2026-000012")
+	inv.CreditedInvoiceDate = pgtype.Date{Time: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), Valid: true}
+	items := []db.InvoiceLineItem{
+		{DescriptionRo: "Stornare - anulare", Quantity: numeric(1), UnitPrice: -8264, VatRate: numeric(21), VatAmount: -1736, LineTotal: -8264, LineTotalWithVat: -10000},
+	}
+	out, err := Render(inv, items)
+	if err != nil {
+		t.Fatalf("Render() error: %v", err)
+	}
+	if broken := validate(t, out); len(broken) > 0 {
+		t.Fatalf("broken rules: %v\n%s", broken, out)
+	}
+
+	doc := parse(t, out)
+	if doc.Name != "CreditNote" || doc.text("CreditNoteTypeCode") != "381" {
+		t.Errorf("root = %s with type code %q, want a 381 CreditNote", doc.Name, doc.text("CreditNoteTypeCode"))
+	}
+	if got := doc.text("LegalMonetaryTotal/PayableAmount"); got != "100.00" {
+		t.Errorf("PayableAmount = %q, want 100.00", got)
+	}
+	if got := doc.text("CreditNoteLine/Price/PriceAmount"); got != "82.64" {
+		t.Errorf("PriceAmount = %q, want 82.64", got)
+	}
+	if id, date := doc.text("BillingReference/InvoiceDocumentReference/ID"), doc.text("BillingReference/InvoiceDocumentReference/IssueDate"); id != "CLE-2026-000012" || date != "2026-03-02" {
+		t.Errorf("BillingReference = %q of %q, want the credited invoice", id, date)
+	}
+}
+
+func TestRenderReportsMissingData(t *testing.T) {
+	inv, items := clientInvoice()
+	inv.SellerCounty = "Bucuresti"
+	inv.SellerCity = "Bucuresti"
+	inv.BuyerAddress = pgtype.Text{}
+	inv.TotalAmount++
+
+	_, err := Render(inv, items)
+	if err == nil {
+		t.Fatal("Render() succeeded with missing data")
+	}
+	for _, want := range []string{"seller address in Bucharest does not name the sector", "buyer street address is missing", "do not add up"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+}
+
+func TestCountyCode(t *testing.T) {
+	tests := map[string]string{
+		"Cluj":                 "RO-CJ",
+		"Județul Argeș":        "RO-AG",
+		"Bistrița-Năsăud":      "RO-BN",
+		"Satu Mare":            "RO-SM",
+		"București":            "RO-B",
+		"Municipiul Bucuresti": "RO-B",
+		"RO-TM":                "RO-TM",
+		"is":                   "RO-IS",
+	}
+	for county, want := range tests {
+		if got, ok := CountyCode(county); !ok || got != want {
+			t.Errorf("CountyCode(%q) = %q, %v, want %q", county, got, ok, want)
+		}
+	}
+	if _, ok := CountyCode("Bavaria"); ok {
+		t.Error("CountyCode accepted a foreign region")
+	}
+}
@@ -0,0 +1,143 @@
+package efactura
+
+import "encoding/xml"
+
+// UBL 2.1 namespaces and the CIUS-RO customization of EN 16931.
+const (
+	invoiceNamespace    = "urn:oasis:names:specification:ubl:schema:xsd:Invoice-2"
+	creditNoteNamespace = "urn:oasis:names:specification:ubl:schema:xsd:CreditNote-2"
+	cacNamespace        = "urn:oasis:names:specification:ubl:schema:xsd:CommonAggregateComponents-2"
+	cbcNamespace        = "urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2"
+
+	// CustomizationID identifies documents that follow CIUS-RO 1.0.1.
+	CustomizationID = "urn:cen.eu:en16931:2017#compliant#urn:efactura.mfinante.ro:CIUS-RO:1.0.1"
+)
+
+// Document type codes (UNTDID 1001).
+const (
+	typeCodeInvoice    = "380"
+	typeCodeCreditNote = "381"
+)
+
+// The fields of document follow the element order of the UBL 2.1 Invoice and
+// CreditNote schemas; encoding/xml writes them in declaration order.
+type document struct {
+	XMLName              xml.Name
+	Namespace            string         `xml:"xmlns,attr"`
+	CacNamespace         string         `xml:"xmlns:cac,attr"`
+	CbcNamespace         string         `xml:"xmlns:cbc,attr"`
+	CustomizationID      string         `xml:"cbc:CustomizationID"`
+	ID                   string         `xml:"cbc:ID"`
+	IssueDate            string         `xml:"cbc:IssueDate"`
+	DueDate              string         `xml:"cbc:DueDate,omitempty"`
+	InvoiceTypeCode      string         `xml:"cbc:InvoiceTypeCode,omitempty"`
+	CreditNoteTypeCode   string         `xml:"cbc:CreditNoteTypeCode,omitempty"`
+	Note                 []string       `xml:"cbc:Note,omitempty"`
+	DocumentCurrencyCode string         `xml:"cbc:DocumentCurrencyCode"`
+	BillingReference     *documentRef   `xml:"cac:BillingReference>cac:InvoiceDocumentReference,omitempty"`
+	Supplier             party          `xml:"cac:AccountingSupplierParty>cac:Party"`
+	Customer             party          `xml:"cac:AccountingCustomerParty>cac:Party"`
+	PaymentMeans         *paymentMeans  `xml:"cac:PaymentMeans,omitempty"`
+	TaxTotal             taxTotal       `xml:"cac:TaxTotal"`
+	LegalMonetaryTotal   monetaryTotal  `xml:"cac:LegalMonetaryTotal"`
+	InvoiceLines         []documentLine `xml:"cac:InvoiceLine,omitempty"`
+	CreditNoteLines      []documentLine `xml:"cac:CreditNoteLine,omitempty"`
+}
+
+// documentRef references the invoice a credit note corrects (BT-25, BT-26).
+type documentRef struct {
+	ID        string `xml:"cbc:ID"`
+	IssueDate string `xml:"cbc:IssueDate,omitempty"`
+}
+
+type party struct {
+	Name           string          `xml:"cac:PartyName>cbc:Name"`
+	PostalAddress  postalAddress   `xml:"cac:PostalAddress"`
+	PartyTaxScheme *partyTaxScheme `xml:"cac:PartyTaxScheme,omitempty"`
+	LegalEntity    legalEntity     `xml:"cac:PartyLegalEntity"`
+	Contact        *contact        `xml:"cac:Contact,omitempty"`
+}
+
+type contact struct {
+	Email string `xml:"cbc:ElectronicMail"`
+}
+
+type postalAddress struct {
+	StreetName       string `xml:"cbc:StreetName"`
+	CityName         string `xml:"cbc:CityName"`
+	CountrySubentity string `xml:"cbc:CountrySubentity"`
+	Country          string `xml:"cac:Country>cbc:IdentificationCode"`
+}
+
+type partyTaxScheme struct {
+	CompanyID string `xml:"cbc:CompanyID"`
+	TaxScheme string `xml:"cac:TaxScheme>cbc:ID"`
+}
+
+type legalEntity struct {
+	RegistrationName string `xml:"cbc:RegistrationName"`
+	CompanyID        string `xml:"cbc:CompanyID,omitempty"`
+	// CompanyLegalForm is the seller's additional legal information (BT-33).
+	CompanyLegalForm string `xml:"cbc:CompanyLegalForm,omitempty"`
+}
+
+type paymentMeans struct {
+	Code    string            `xml:"cbc:PaymentMeansCode"`
+	Account *financialAccount `xml:"cac:PayeeFinancialAccount,omitempty"`
+}
+
+type financialAccount struct {
+	ID   string `xml:"cbc:ID"`
+	Name string `xml:"cbc:Name,omitempty"`
+}
+
+type amount struct {
+	Currency string `xml:"currencyID,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type taxTotal struct {
+	TaxAmount amount        `xml:"cbc:TaxAmount"`
+	Subtotals []taxSubtotal `xml:"cac:TaxSubtotal"`
+}
+
+type taxSubtotal struct {
+	TaxableAmount amount      `xml:"cbc:TaxableAmount"`
+	TaxAmount     amount      `xml:"cbc:TaxAmount"`
+	Category      taxCategory `xml:"cac:TaxCategory"`
+}
+
+type taxCategory struct {
+	ID                  string `xml:"cbc:ID"`
+	Percent             string `xml:"cbc:Percent,omitempty"`
+	ExemptionReasonCode string `xml:"cbc:TaxExemptionReasonCode,omitempty"`
+	ExemptionReason     string `xml:"cbc:TaxExemptionReason,omitempty"`
+	TaxScheme           string `xml:"cac:TaxScheme>cbc:ID"`
+}
+
+type monetaryTotal struct {
+	LineExtensionAmount amount `xml:"cbc:LineExtensionAmount"`
+	TaxExclusiveAmount  amount `xml:"cbc:TaxExclusiveAmount"`
+	TaxInclusiveAmount  amount `xml:"cbc:TaxInclusiveAmount"`
+	PayableAmount       amount `xml:"cbc:PayableAmount"`
+}
+
+type quantity struct {
+	UnitCode string `xml:"unitCode,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type documentLine struct {
+	ID                  string    `xml:"cbc:ID"`
+	InvoicedQuantity    *quantity `xml:"cbc:InvoicedQuantity,omitempty"`
+	CreditedQuantity    *quantity `xml:"cbc:CreditedQuantity,omitempty"`
+	LineExtensionAmount amount    `xml:"cbc:LineExtensionAmount"`
+	Item                item      `xml:"cac:Item"`
+	Price               amount    `xml:"cac:Price>cbc:PriceAmount"`
+}
+
+type item struct {
+	Description string      `xml:"cbc:Description,omitempty"`
+	Name        string      `xml:"cbc:Name"`
+	TaxCategory taxCategory `xml:"cac:ClassifiedTaxCategory"`
+}
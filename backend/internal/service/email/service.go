@@ -1,7 +1,10 @@
 package email
 
 import (
+	"encoding/base64"
 	"fmt"
+	"html"
+	"mime"
 	"net/smtp"
 	"os"
 	"strings"
@@ -101,3 +104,79 @@ func buildOTPEmail(code string) string {
 </body>
 </html>`, code)
 }
+
+// SendInvoice emails an invoice PDF to the buyer, as an attachment named
+// filename. Like SendOTP, it returns (skipped=true, nil) outside production.
+func (s *Service) SendInvoice(to, buyerName, invoiceNumber, sellerName, filename string, pdf []byte) (skipped bool, err error) {
+	if !s.isProd {
+		return true, nil
+	}
+	if !s.configured {
+		return false, fmt.Errorf("email service not configured: set SMTP_HOST in environment")
+	}
+
+	const boundary = "helpmeclean-invoice-boundary"
+	subject := fmt.Sprintf("Factura %s de la %s", invoiceNumber, sellerName)
+	attachment := base64.StdEncoding.EncodeToString(pdf)
+	var wrapped strings.Builder
+	for len(attachment) > 76 {
+		wrapped.WriteString(attachment[:76] + "\r\n")
+		attachment = attachment[76:]
+	}
+	wrapped.WriteString(attachment)
+
+	msg := []byte(strings.Join([]string{
+		"From: HelpMeClean <" + s.from + ">",
+		"To: " + to,
+		"Subject: " + mime.QEncoding.Encode("UTF-8", subject),
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/mixed; boundary=\"" + boundary + "\"",
+		"",
+		"--" + boundary,
+		"Content-Type: text/html; charset=\"UTF-8\"",
+		"",
+		buildInvoiceEmail(buyerName, invoiceNumber, sellerName),
+		"--" + boundary,
+		"Content-Type: application/pdf; name=\"" + filename + "\"",
+		"Content-Disposition: attachment; filename=\"" + filename + "\"",
+		"Content-Transfer-Encoding: base64",
+		"",
+		wrapped.String(),
+		"--" + boundary + "--",
+		"",
+	}, "\r\n"))
+
+	var smtpAuth smtp.Auth
+	if s.user != "" {
+		smtpAuth = smtp.PlainAuth("", s.user, s.pass, s.host)
+	}
+	if err := smtp.SendMail(s.host+":"+s.port, smtpAuth, s.from, []string{to}, msg); err != nil {
+		return false, fmt.Errorf("smtp send failed: %w", err)
+	}
+	return false, nil
+}
+
+// buildInvoiceEmail returns the branded HTML body of an invoice email.
+func buildInvoiceEmail(buyerName, invoiceNumber, sellerName string) string {
+	return fmt.Sprintf(`<!DOCTYPE html>
+<html lang="ro">
+<head><meta charset="UTF-8"><meta name="viewport" content="width=device-width, initial-scale=1.0"></head>
+<body style="margin:0;padding:0;background:#FAFBFC;font-family:'Inter',Arial,sans-serif;">
+<div style="max-width:480px;margin:40px auto;background:#ffffff;border-radius:12px;padding:40px;border:1px solid #e5e7eb;">
+  <div style="margin-bottom:24px;">
+    <span style="font-size:24px;font-weight:800;color:#2563EB;">HelpMeClean</span>
+  </div>
+  <h2 style="color:#111827;font-size:20px;font-weight:700;margin:0 0 8px 0;">
+    Factura %s
+  </h2>
+  <p style="color:#6B7280;font-size:14px;margin:0 0 24px 0;">
+    Bună, %s!<br>
+    Găsești atașată factura emisă de <strong>%s</strong> pentru serviciile de curățenie rezervate pe HelpMeClean.ro.
+  </p>
+  <p style="color:#9CA3AF;font-size:12px;margin:0;">
+    Factura poate fi descărcată oricând și din contul tău HelpMeClean.
+  </p>
+</div>
+</body>
+</html>`, html.EscapeString(invoiceNumber), html.EscapeString(buyerName), html.EscapeString(sellerName))
+}
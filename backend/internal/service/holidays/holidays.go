@@ -0,0 +1,101 @@
+// Package holidays knows the Romanian legal public holidays (Codul Muncii,
+// art. 139), including the movable Orthodox Easter and Pentecost days.
+package holidays
+
+import (
+	"sort"
+	"time"
+)
+
+// Holiday is one public holiday. Date is the calendar date at midnight UTC.
+type Holiday struct {
+	Date time.Time
+	Name string
+}
+
+// OrthodoxEaster returns the date of Orthodox Easter Sunday in year, in the
+// Gregorian calendar (Meeus' Julian algorithm).
+func OrthodoxEaster(year int) time.Time {
+	a := year % 4
+	b := year % 7
+	c := year % 19
+	d := (19*c + 15) % 30
+	e := (2*a + 4*b - d + 34) % 7
+	month := (d + e + 114) / 31
+	day := (d+e+114)%31 + 1
+
+	// Julian to Gregorian: 13 days between 1900 and 2099.
+	julianOffset := year/100 - year/400 - 2
+	return time.Date(year, time.Month(month), day+julianOffset, 0, 0, 0, 0, time.UTC)
+}
+
+// Romanian returns the legal public holidays of year in date order. When two
+// holidays fall on the same day (e.g. Children's Day and Pentecost Monday)
+// the day is listed once.
+func Romanian(year int) []Holiday {
+	date := func(month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	easter := OrthodoxEaster(year)
+
+	list := []Holiday{
+		{date(time.January, 1), "Anul Nou"},
+		{date(time.January, 2), "Anul Nou"},
+		{date(time.January, 24), "Ziua Unirii Principatelor Romane"},
+		{easter.AddDate(0, 0, -2), "Vinerea Mare"},
+		{easter, "Pastele"},
+		{easter.AddDate(0, 0, 1), "A doua zi de Paste"},
+		{date(time.May, 1), "Ziua Muncii"},
+		{date(time.June, 1), "Ziua Copilului"},
+		{easter.AddDate(0, 0, 49), "Rusaliile"},
+		{easter.AddDate(0, 0, 50), "A doua zi de Rusalii"},
+		{date(time.August, 15), "Adormirea Maicii Domnului"},
+		{date(time.November, 30), "Sfantul Andrei"},
+		{date(time.December, 1), "Ziua Nationala a Romaniei"},
+		{date(time.December, 25), "Craciunul"},
+		{date(time.December, 26), "A doua zi de Craciun"},
+	}
+	if year >= 2024 {
+		list = append(list,
+			Holiday{date(time.January, 6), "Boboteaza"},
+			Holiday{date(time.January, 7), "Sfantul Ioan Botezatorul"},
+		)
+	}
+
+	sort.SliceStable(list, func(i, j int) bool { return list[i].Date.Before(list[j].Date) })
+	out := list[:0]
+	for i, h := range list {
+		if i > 0 && h.Date.Equal(list[i-1].Date) {
+			continue
+		}
+		out = append(out, h)
+	}
+	return out
+}
+
+// Lookup returns the holiday on date, if any. Only the calendar date of
+// date (in its own location) is used.
+func Lookup(date time.Time) (Holiday, bool) {
+	d := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	for _, h := range Romanian(d.Year()) {
+		if h.Date.Equal(d) {
+			return h, true
+		}
+	}
+	return Holiday{}, false
+}
+
+// Between returns the holidays within [from, to], in date order.
+func Between(from, to time.Time) []Holiday {
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	to = time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	var out []Holiday
+	for year := from.Year(); year <= to.Year(); year++ {
+		for _, h := range Romanian(year) {
+			if !h.Date.Before(from) && !h.Date.After(to) {
+				out = append(out, h)
+			}
+		}
+	}
+	return out
+}
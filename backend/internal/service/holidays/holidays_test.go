@@ -0,0 +1,102 @@
+package holidays
+
+import (
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestOrthodoxEaster(t *testing.T) {
+	tests := map[int]string{
+		2021: "2021-05-02",
+		2022: "2022-04-24",
+		2023: "2023-04-16",
+		2024: "2024-05-05",
+		2025: "2025-04-20",
+		2026: "2026-04-12",
+		2027: "2027-05-02",
+	}
+	for year, want := range tests {
+		if got := OrthodoxEaster(year).Format("2006-01-02"); got != want {
+			t.Errorf("OrthodoxEaster(%d) = %s, want %s", year, got, want)
+		}
+	}
+}
+
+func TestRomanian_2026(t *testing.T) {
+	want := []string{
+		"2026-01-01", "2026-01-02", "2026-01-06", "2026-01-07", "2026-01-24",
+		"2026-04-10", "2026-04-12", "2026-04-13",
+		"2026-05-01", "2026-05-31", "2026-06-01",
+		"2026-08-15", "2026-11-30", "2026-12-01", "2026-12-25", "2026-12-26",
+	}
+	got := Romanian(2026)
+	if len(got) != len(want) {
+		t.Fatalf("got %d holidays, want %d: %v", len(got), len(want), got)
+	}
+	for i, h := range got {
+		if d := h.Date.Format("2006-01-02"); d != want[i] {
+			t.Errorf("holiday %d = %s (%s), want %s", i, d, h.Name, want[i])
+		}
+	}
+}
+
+func TestRomanian_SharedDayListedOnce(t *testing.T) {
+	// In 2026 Pentecost Monday is 1 June, Children's Day.
+	count := 0
+	for _, h := range Romanian(2026) {
+		if h.Date.Equal(date("2026-06-01")) {
+			count++
+		}
+	}
+	if count != 1 {
+		t.Errorf("1 June listed %d times, want 1", count)
+	}
+}
+
+func TestRomanian_EpiphanyOnlyFrom2024(t *testing.T) {
+	if _, ok := Lookup(date("2023-01-06")); ok {
+		t.Error("6 January 2023 should not be a holiday")
+	}
+	if h, ok := Lookup(date("2024-01-06")); !ok || h.Name != "Boboteaza" {
+		t.Errorf("Lookup(2024-01-06) = %v, %v; want Boboteaza", h, ok)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	if h, ok := Lookup(date("2025-12-01")); !ok || h.Name != "Ziua Nationala a Romaniei" {
+		t.Errorf("Lookup(2025-12-01) = %v, %v", h, ok)
+	}
+	if h, ok := Lookup(date("2025-04-21")); !ok || h.Name != "A doua zi de Paste" {
+		t.Errorf("Lookup(2025-04-21) = %v, %v", h, ok)
+	}
+	if _, ok := Lookup(date("2025-04-22")); ok {
+		t.Error("2025-04-22 should not be a holiday")
+	}
+
+	// Only the calendar date counts, whatever the location.
+	bucharest, _ := time.LoadLocation("Europe/Bucharest")
+	if _, ok := Lookup(time.Date(2025, 12, 25, 23, 30, 0, 0, bucharest)); !ok {
+		t.Error("late evening of 25 December in Bucharest should be a holiday")
+	}
+}
+
+func TestBetween(t *testing.T) {
+	got := Between(date("2025-12-20"), date("2026-01-03"))
+	want := []string{"2025-12-25", "2025-12-26", "2026-01-01", "2026-01-02"}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i, h := range got {
+		if d := h.Date.Format("2006-01-02"); d != want[i] {
+			t.Errorf("holiday %d = %s, want %s", i, d, want[i])
+		}
+	}
+}
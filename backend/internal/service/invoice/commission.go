@@ -0,0 +1,290 @@
+package invoice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	db "helpmeclean-backend/internal/db/generated"
+	"helpmeclean-backend/internal/service/recurrence"
+)
+
+// CommissionLine is the platform commission a company owes for a month, in
+// bani. Commission is the platform fee of the bookings paid in the month and
+// Refunded the part of it refunded since; Net is what is left to invoice, on
+// which VAT is charged on top.
+type CommissionLine struct {
+	CompanyID    pgtype.UUID
+	BookingCount int
+	Commission   int64
+	Refunded     int64
+	Net          int64
+	VAT          int64
+	// Invoice is the commission invoice already issued for the month, if any.
+	Invoice *db.Invoice
+}
+
+// CommissionMonth is the commission of every company with payments in a
+// month, from its first to its last day.
+type CommissionMonth struct {
+	From  time.Time
+	To    time.Time
+	Lines []CommissionLine
+}
+
+// commissionInvoice is what a commission invoice charges a company.
+type commissionInvoice struct {
+	companyID pgtype.UUID
+	// payoutID links the invoice of a payout; monthly invoices have none.
+	payoutID     pgtype.UUID
+	net          int64
+	bookingCount int
+	periodFrom   string
+	periodTo     string
+	// month is the first day of the month of a monthly invoice.
+	month pgtype.Date
+}
+
+// ParseMonth parses a month given as "2006-01" and returns its first day.
+func ParseMonth(s string) (time.Time, error) {
+	month, err := time.Parse("2006-01", s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invoice: invalid month %q, want YYYY-MM", s)
+	}
+	return month, nil
+}
+
+// monthBounds returns the first and last day of the month of date, and the
+// instants it starts and ends, Romanian time.
+func monthBounds(date time.Time) (first, last, start, end time.Time) {
+	first = time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+	next := first.AddDate(0, 1, 0)
+	return first, next.AddDate(0, 0, -1), recurrence.StartTime(first, 0), recurrence.StartTime(next, 0)
+}
+
+// PreviewCommissionMonth computes the commission invoice of every company for
+// the month of date without issuing anything: the platform fees of the
+// payments received in the month, net of the refunds made on them since.
+// Refunds already credited on a commission invoice and bookings invoiced per
+// payout are left out. Companies invoiced for the month already carry their
+// invoice.
+func (s *Service) PreviewCommissionMonth(ctx context.Context, date time.Time) (CommissionMonth, error) {
+	first, last, start, end := monthBounds(date)
+	month := CommissionMonth{From: first, To: last}
+
+	pc, err := s.loadPlatformConfig(ctx)
+	if err != nil {
+		return CommissionMonth{}, err
+	}
+	var ratePct int64
+	if pc.IsVATPayer {
+		if ratePct, err = s.vatRatePct(ctx, VATStandard, last); err != nil {
+			return CommissionMonth{}, err
+		}
+	}
+
+	rows, err := s.queries.ListCommissionMonth(ctx, db.ListCommissionMonthParams{
+		OccurredAt:   pgtype.Timestamptz{Time: start, Valid: true},
+		OccurredAt_2: pgtype.Timestamptz{Time: end, Valid: true},
+	})
+	if err != nil {
+		return CommissionMonth{}, fmt.Errorf("invoice: list commission of %s: %w", first.Format("2006-01"), err)
+	}
+	for _, row := range rows {
+		line := CommissionLine{
+			CompanyID:    row.CompanyID,
+			BookingCount: int(row.BookingCount),
+			Commission:   row.Commission,
+			Refunded:     row.Refunded,
+			Net:          row.Commission - row.Refunded,
+		}
+		if line.Net > 0 {
+			line.VAT = (line.Net*ratePct + 50) / 100
+		}
+		inv, err := s.queries.GetCommissionInvoiceForPeriod(ctx, db.GetCommissionInvoiceForPeriodParams{
+			CompanyID:  row.CompanyID,
+			PeriodFrom: pgtype.Date{Time: first, Valid: true},
+		})
+		switch {
+		case err == nil:
+			line.Invoice = &inv
+		case !errors.Is(err, pgx.ErrNoRows):
+			return CommissionMonth{}, fmt.Errorf("invoice: get commission invoice of company %s: %w", uuidToString(row.CompanyID), err)
+		}
+		month.Lines = append(month.Lines, line)
+	}
+	return month, nil
+}
+
+// CloseCommissionMonth issues the commission invoice of the month of date to
+// every company with a positive net commission not invoiced for it yet, and
+// returns the invoices it issued. Only months that have ended can be closed.
+// Closing a month again only invoices the companies missed before, so a
+// failure for one company is retried by closing the month again.
+func (s *Service) CloseCommissionMonth(ctx context.Context, date time.Time) ([]db.Invoice, error) {
+	_, _, _, end := monthBounds(date)
+	if time.Now().Before(end) {
+		return nil, fmt.Errorf("invoice: month %s has not ended yet", date.Format("2006-01"))
+	}
+	month, err := s.PreviewCommissionMonth(ctx, date)
+	if err != nil {
+		return nil, err
+	}
+
+	var issued []db.Invoice
+	var errs []error
+	for _, line := range month.Lines {
+		if line.Invoice != nil || line.Net <= 0 {
+			continue
+		}
+		inv, err := s.issueCommissionInvoice(ctx, commissionInvoice{
+			companyID:    line.CompanyID,
+			net:          line.Net,
+			bookingCount: line.BookingCount,
+			periodFrom:   month.From.Format("2006-01-02"),
+			periodTo:     month.To.Format("2006-01-02"),
+			month:        pgtype.Date{Time: month.From, Valid: true},
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("company %s: %w", uuidToString(line.CompanyID), err))
+			continue
+		}
+		issued = append(issued, inv)
+	}
+	return issued, errors.Join(errs...)
+}
+
+// issueCommissionInvoice issues a commission invoice from the platform to a
+// company in the platform's series, with VAT on top of the net commission
+// unless the platform is not registered for VAT. It is emailed to the
+// company's admin by the invoice pipeline.
+func (s *Service) issueCommissionInvoice(ctx context.Context, c commissionInvoice) (db.Invoice, error) {
+	company, err := s.queries.GetCompanyByID(ctx, c.companyID)
+	if err != nil {
+		return db.Invoice{}, fmt.Errorf("invoice: get company: %w", err)
+	}
+
+	pc, err := s.loadPlatformConfig(ctx)
+	if err != nil {
+		return db.Invoice{}, fmt.Errorf("invoice: load platform config: %w", err)
+	}
+
+	dueDate := pgtype.Date{Time: time.Now().AddDate(0, 0, 30), Valid: true}
+
+	// The commission of a month is supplied on its last day, that of a
+	// payout when it is invoiced.
+	supplied := recurrence.Today()
+	var periodTo pgtype.Date
+	if c.month.Valid {
+		_, last, _, _ := monthBounds(c.month.Time)
+		supplied = last
+		periodTo = pgtype.Date{Time: last, Valid: true}
+	}
+
+	// Commission amount is the net (without VAT). Calculate VAT on top,
+	// unless the platform itself is not registered for VAT.
+	var ratePct int64
+	if pc.IsVATPayer {
+		if ratePct, err = s.vatRatePct(ctx, VATStandard, supplied); err != nil {
+			return db.Invoice{}, err
+		}
+	}
+	subtotalNet := int32(c.net)
+	vatAmount := int32((int64(subtotalNet)*ratePct + 50) / 100)
+	totalAmount := subtotalNet + vatAmount
+
+	descRo := fmt.Sprintf("Comision platforma HelpMeClean - %d rezervari (%s - %s)", c.bookingCount, c.periodFrom, c.periodTo)
+	descEn := fmt.Sprintf("HelpMeClean platform commission - %d bookings (%s - %s)", c.bookingCount, c.periodFrom, c.periodTo)
+	item := db.CreateInvoiceLineItemParams{
+		DescriptionRo:    descRo,
+		DescriptionEn:    pgText(descEn),
+		Quantity:         numericFromInt(1),
+		UnitPrice:        subtotalNet,
+		VatRate:          numericFromInt(int(ratePct)),
+		VatAmount:        vatAmount,
+		LineTotal:        subtotalNet,
+		LineTotalWithVat: totalAmount,
+		SortOrder:        pgtype.Int4{Int32: 1, Valid: true},
+	}
+
+	inv, err := s.insertInvoice(ctx, pgtype.UUID{}, DocumentInvoice, defaultPlatformSeries, db.CreateInvoiceParams{
+		InvoiceType:          db.InvoiceTypePlatformCommission,
+		SellerCompanyName:    pc.CompanyName,
+		SellerCui:            pc.CUI,
+		SellerRegNumber:      pgText(pc.RegNumber),
+		SellerAddress:        pc.Address,
+		SellerCity:           pc.City,
+		SellerCounty:         pc.County,
+		SellerIsVatPayer:     pc.IsVATPayer,
+		SellerBankName:       pgText(pc.BankName),
+		SellerIban:           pgText(pc.IBAN),
+		BuyerName:            company.CompanyName,
+		BuyerCui:             pgText(company.Cui),
+		BuyerRegNumber:       pgtype.Text{},
+		BuyerAddress:         pgText(company.Address),
+		BuyerCity:            pgText(company.City),
+		BuyerCounty:          pgText(company.County),
+		BuyerIsVatPayer:      pgtype.Bool{Bool: s.companyIsVATPayer(ctx, company), Valid: true},
+		BuyerEmail:           pgText(s.companyAdminEmail(ctx, company)),
+		SubtotalAmount:       subtotalNet,
+		VatRate:              numericFromInt(int(ratePct)),
+		VatAmount:            vatAmount,
+		TotalAmount:          totalAmount,
+		Currency:             "RON",
+		BookingID:            pgtype.UUID{},
+		PaymentTransactionID: pgtype.UUID{},
+		CompanyID:            c.companyID,
+		ClientUserID:         pgtype.UUID{},
+		Status:               db.InvoiceStatusIssued,
+		DueDate:              dueDate,
+		Notes:                pgText(fmt.Sprintf("Comision platforma HelpMeClean - %s pana la %s", c.periodFrom, c.periodTo)),
+		PayoutID:             c.payoutID,
+		PeriodFrom:           c.month,
+		PeriodTo:             periodTo,
+	}, []db.CreateInvoiceLineItemParams{item})
+	if err != nil {
+		return db.Invoice{}, err
+	}
+
+	// Sync to Factureaza.ro (best-effort).
+	lineItems, _ := s.queries.ListInvoiceLineItems(ctx, inv.ID)
+	factureazaID, downloadURL, apiErr := s.createInvoiceOnFactureaza(ctx, inv, lineItems)
+	if apiErr != nil {
+		log.Printf("invoice: factureaza.ro API error (non-fatal): %v", apiErr)
+	} else if factureazaID != "" {
+		updateErr := s.queries.UpdateInvoiceFactureaza(ctx, db.UpdateInvoiceFactureazaParams{
+			ID:                    inv.ID,
+			FactureazaID:          pgText(factureazaID),
+			FactureazaDownloadUrl: pgText(downloadURL),
+		})
+		if updateErr != nil {
+			log.Printf("invoice: failed to persist factureaza metadata: %v", updateErr)
+		} else {
+			inv.FactureazaID = pgText(factureazaID)
+			inv.FactureazaDownloadUrl = pgText(downloadURL)
+		}
+	}
+
+	// Store the PDF and email it to the company; failed steps are retried
+	// by the invoice-pipeline job.
+	s.startPipeline(ctx, &inv)
+
+	log.Printf("invoice: created commission invoice %s for company %s", textVal(inv.InvoiceNumber), company.CompanyName)
+	return inv, nil
+}
+
+// companyAdminEmail returns the address commission invoices of a company go
+// to: its admin's, or the company's contact address if it has no admin.
+func (s *Service) companyAdminEmail(ctx context.Context, company db.Company) string {
+	if company.AdminUserID.Valid {
+		if admin, err := s.queries.GetUserByID(ctx, company.AdminUserID); err == nil && admin.Email != "" {
+			return admin.Email
+		}
+	}
+	return company.ContactEmail
+}
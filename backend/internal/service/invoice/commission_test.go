@@ -0,0 +1,38 @@
+package invoice
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMonthBounds(t *testing.T) {
+	month, err := ParseMonth("2026-10")
+	if err != nil {
+		t.Fatalf("ParseMonth() error: %v", err)
+	}
+	first, last, start, end := monthBounds(month)
+	if d := first.Format("2006-01-02"); d != "2026-10-01" {
+		t.Errorf("first = %s", d)
+	}
+	if d := last.Format("2006-01-02"); d != "2026-10-31" {
+		t.Errorf("last = %s", d)
+	}
+	// October starts in summer time and ends in winter time, Romanian time.
+	if want := time.Date(2026, 9, 30, 21, 0, 0, 0, time.UTC); !start.Equal(want) {
+		t.Errorf("start = %v, want %v", start, want)
+	}
+	if want := time.Date(2026, 10, 31, 22, 0, 0, 0, time.UTC); !end.Equal(want) {
+		t.Errorf("end = %v, want %v", end, want)
+	}
+
+	// Any day of a month gives the same bounds.
+	if f, l, _, _ := monthBounds(time.Date(2026, 2, 17, 0, 0, 0, 0, time.UTC)); f.Day() != 1 || l.Day() != 28 {
+		t.Errorf("bounds of February = %v..%v", f, l)
+	}
+
+	for _, s := range []string{"2026-13", "10-2026", "2026-10-01", ""} {
+		if _, err := ParseMonth(s); err == nil {
+			t.Errorf("ParseMonth(%q) succeeded", s)
+		}
+	}
+}
@@ -0,0 +1,295 @@
+package invoice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	db "helpmeclean-backend/internal/db/generated"
+	"helpmeclean-backend/internal/service/ledger"
+)
+
+// refundReason is the reason given on the credit notes of refunds made
+// without a refund request, e.g. from the Stripe dashboard.
+const refundReason = "Rambursare plată"
+
+// GenerateCreditNote creates a credit note (storno) referencing an original invoice.
+// The amount parameter is in bani and represents the credited total (VAT-inclusive).
+func (s *Service) GenerateCreditNote(ctx context.Context, invoiceID pgtype.UUID, amount int32, reason string) (db.Invoice, error) {
+	original, err := s.queries.GetInvoiceByID(ctx, invoiceID)
+	if err != nil {
+		return db.Invoice{}, fmt.Errorf("invoice: get original invoice for credit note: %w", err)
+	}
+
+	if amount <= 0 {
+		return db.Invoice{}, errors.New("invoice: credit note amount must be positive")
+	}
+	return s.issueCreditNote(ctx, original, int64(amount), reason, "")
+}
+
+// CreditRefund issues the credit notes of a refund: one against the booking's
+// client invoice for the refunded amount and, when the refund gave back part
+// of a platform fee already invoiced to the company, one against the
+// commission invoice for that part. Each refund is credited once per invoice,
+// so CreditRefund can be called again for the same refund. Bookings without
+// an issued invoice have nothing to credit. It returns the credit notes of
+// the refund.
+func (s *Service) CreditRefund(ctx context.Context, refund ledger.Refund) ([]db.Invoice, error) {
+	reason := refundReason
+	if rr, err := s.queries.GetRefundRequestByStripeRefundID(ctx, pgText(refund.RefundID)); err == nil && rr.Reason != "" {
+		reason = rr.Reason
+	}
+
+	var notes []db.Invoice
+	var errs []error
+
+	original, err := s.queries.GetIssuedInvoiceByBooking(ctx, db.GetIssuedInvoiceByBookingParams{
+		BookingID:   refund.BookingID,
+		InvoiceType: db.InvoiceTypeClientService,
+	})
+	switch {
+	case err == nil:
+		note, err := s.issueCreditNote(ctx, original, refund.Amount, reason, refund.RefundID)
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			notes = append(notes, note)
+		}
+	case errors.Is(err, pgx.ErrNoRows):
+		log.Printf("invoice: refund %s: booking %s has no client invoice to credit", refund.RefundID, uuidToString(refund.BookingID))
+	default:
+		errs = append(errs, fmt.Errorf("invoice: get client invoice of refund %s: %w", refund.RefundID, err))
+	}
+
+	// A platform fee not invoiced yet is simply invoiced net of the refund.
+	if fee := ledger.RefundedFee(refund.Amount, refund.PaymentGross, refund.PaymentFee); fee > 0 {
+		original, err := s.queries.GetCommissionInvoiceByBooking(ctx, refund.BookingID)
+		switch {
+		case err == nil:
+			// Commission invoices charge VAT on top of the net commission.
+			ratePct, err := s.creditRatePct(ctx, original)
+			if err != nil {
+				errs = append(errs, err)
+				break
+			}
+			gross := fee + (fee*ratePct+50)/100
+			note, err := s.issueCreditNote(ctx, original, gross, reason, refund.RefundID)
+			if err != nil {
+				errs = append(errs, err)
+			} else {
+				notes = append(notes, note)
+			}
+		case errors.Is(err, pgx.ErrNoRows):
+		default:
+			errs = append(errs, fmt.Errorf("invoice: get commission invoice of refund %s: %w", refund.RefundID, err))
+		}
+	}
+	return notes, errors.Join(errs...)
+}
+
+// IssueRefundCredit issues the credit notes recorded for a refund right
+// away. Failures are only logged: the invoice-pipeline job retries them.
+func (s *Service) IssueRefundCredit(ctx context.Context, refund ledger.Refund) {
+	if err := s.RunRefundCredit(ctx, refund.RefundID); err != nil {
+		log.Printf("invoice: credit of refund %s failed, will be retried: %v", refund.RefundID, err)
+	}
+}
+
+// RunRefundCredit issues the credit notes recorded for a refund and posts the
+// one on a commission invoice to the ledger. The refund entry already took
+// the refunded commission back from the platform, so only the VAT on it is
+// given back there. The credit is claimed first so it never runs twice at
+// once; credit notes and entries already made are skipped, so a failed
+// credit is simply run again later.
+func (s *Service) RunRefundCredit(ctx context.Context, refundID string) error {
+	rc, err := s.queries.ClaimRefundCredit(ctx, refundID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("invoice: claim credit of refund %s: %w", refundID, err)
+	}
+
+	if err := s.creditRecordedRefund(ctx, rc); err != nil {
+		s.recordRefundCreditFailure(ctx, rc, err)
+		return err
+	}
+	if err := s.queries.MarkRefundCreditDone(ctx, refundID); err != nil {
+		return fmt.Errorf("invoice: mark credit of refund %s done: %w", refundID, err)
+	}
+	return nil
+}
+
+func (s *Service) creditRecordedRefund(ctx context.Context, rc db.RefundCredit) error {
+	notes, err := s.CreditRefund(ctx, ledger.Refund{
+		RefundID:        rc.RefundID,
+		PaymentIntentID: rc.PaymentIntentID,
+		BookingID:       rc.BookingID,
+		CompanyID:       rc.CompanyID,
+		Amount:          rc.Amount,
+		PaymentGross:    rc.PaymentGross,
+		PaymentFee:      rc.PaymentFee,
+		At:              rc.RefundedAt.Time,
+	})
+	errs := []error{err}
+	for _, inv := range notes {
+		if inv.InvoiceType != db.InvoiceTypePlatformCommission || inv.VatAmount == 0 {
+			continue
+		}
+		entry := ledger.CreditNoteEntry(inv.ID, inv.CompanyID, 0, -int64(inv.VatAmount), inv.CreatedAt.Time)
+		if _, err := s.ledger.Post(ctx, entry); err != nil {
+			errs = append(errs, fmt.Errorf("invoice: post credit note %s: %w", textVal(inv.InvoiceNumber), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// recordRefundCreditFailure stores the error of a refund credit and schedules
+// the next attempt with the backoff of invoice pipelines.
+func (s *Service) recordRefundCreditFailure(ctx context.Context, rc db.RefundCredit, cause error) {
+	var next pgtype.Timestamptz
+	if rc.Attempts < maxPipelineAttempts {
+		delay := time.Duration(5<<min(rc.Attempts-1, 9)) * time.Minute
+		next = pgtype.Timestamptz{Time: time.Now().Add(delay), Valid: true}
+	} else {
+		log.Printf("invoice: giving up on the credit of refund %s after %d attempts", rc.RefundID, rc.Attempts)
+	}
+	if err := s.queries.MarkRefundCreditFailed(ctx, db.MarkRefundCreditFailedParams{
+		RefundID:      rc.RefundID,
+		LastError:     pgText(cause.Error()),
+		NextAttemptAt: next,
+	}); err != nil {
+		log.Printf("invoice: warning: failed to record failure of the credit of refund %s: %v", rc.RefundID, err)
+	}
+}
+
+// issueCreditNote credits gross bani, VAT-inclusive, of an invoice. The lines
+// of the invoice are credited in proportion at its VAT rate, and never more
+// than what earlier credit notes left of it. Credit notes issued for a Stripe
+// refund carry its ID; if the refund already has a credit note on the
+// invoice, that one is returned.
+func (s *Service) issueCreditNote(ctx context.Context, original db.Invoice, gross int64, reason, refundID string) (db.Invoice, error) {
+	var stripeRefundID pgtype.Text
+	if refundID != "" {
+		stripeRefundID = pgText(refundID)
+		existing, err := s.queries.GetCreditNoteByRefund(ctx, db.GetCreditNoteByRefundParams{
+			StripeRefundID: stripeRefundID,
+			InvoiceType:    original.InvoiceType,
+		})
+		if err == nil {
+			return existing, nil
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return db.Invoice{}, fmt.Errorf("invoice: get credit note of refund %s: %w", refundID, err)
+		}
+	}
+
+	credited, err := s.queries.SumCreditedAmount(ctx, original.ID)
+	if err != nil {
+		return db.Invoice{}, fmt.Errorf("invoice: sum credit notes: %w", err)
+	}
+	remaining := int64(original.TotalAmount) + credited
+	if remaining <= 0 {
+		return db.Invoice{}, fmt.Errorf("invoice: invoice %s is already fully credited", textVal(original.InvoiceNumber))
+	}
+	if gross > remaining {
+		log.Printf("invoice: credit of %d bani on invoice %s capped to the %d bani left", gross, textVal(original.InvoiceNumber), remaining)
+		gross = remaining
+	}
+
+	items, err := s.queries.ListInvoiceLineItems(ctx, original.ID)
+	if err != nil {
+		return db.Invoice{}, fmt.Errorf("invoice: list line items of original invoice: %w", err)
+	}
+	ratePct, err := s.creditRatePct(ctx, original)
+	if err != nil {
+		return db.Invoice{}, err
+	}
+	charges := creditCharges(items, gross)
+	lines, net, vat := itemise(charges, ratePct)
+
+	dueDate := pgtype.Date{Time: time.Now().AddDate(0, 0, 30), Valid: true}
+	notes := fmt.Sprintf("Nota de credit pentru factura %s - %s", textVal(original.InvoiceNumber), reason)
+	if !original.SellerIsVatPayer {
+		notes += ". " + nonVATPayerMention
+	}
+	issued := original.IssuedAt
+	if !issued.Valid {
+		issued = original.CreatedAt
+	}
+
+	// Use negative amounts to represent the credit.
+	creditItems := make([]db.CreateInvoiceLineItemParams, len(charges))
+	for i, c := range charges {
+		creditItems[i] = db.CreateInvoiceLineItemParams{
+			DescriptionRo:    c.descRo,
+			DescriptionEn:    pgText(c.descEn),
+			Quantity:         numericFromHundredths(c.quantity),
+			UnitPrice:        int32(-lines[i].unitPrice),
+			VatRate:          numericFromInt(int(ratePct)),
+			VatAmount:        int32(-lines[i].vat),
+			LineTotal:        int32(-lines[i].net),
+			LineTotalWithVat: int32(-c.gross),
+			SortOrder:        pgtype.Int4{Int32: int32(i + 1), Valid: true},
+		}
+	}
+
+	// Credit notes are numbered in the seller's credit note series: the
+	// company's, or the platform's for commission invoices.
+	seller := original.CompanyID
+	if original.InvoiceType == db.InvoiceTypePlatformCommission {
+		seller = pgtype.UUID{}
+	}
+	creditNote, err := s.insertInvoice(ctx, seller, DocumentCreditNote, creditNotePrefix, db.CreateInvoiceParams{
+		InvoiceType:           original.InvoiceType,
+		SellerCompanyName:     original.SellerCompanyName,
+		SellerCui:             original.SellerCui,
+		SellerRegNumber:       original.SellerRegNumber,
+		SellerAddress:         original.SellerAddress,
+		SellerCity:            original.SellerCity,
+		SellerCounty:          original.SellerCounty,
+		SellerIsVatPayer:      original.SellerIsVatPayer,
+		SellerBankName:        original.SellerBankName,
+		SellerIban:            original.SellerIban,
+		BuyerName:             original.BuyerName,
+		BuyerCui:              original.BuyerCui,
+		BuyerRegNumber:        original.BuyerRegNumber,
+		BuyerAddress:          original.BuyerAddress,
+		BuyerCity:             original.BuyerCity,
+		BuyerCounty:           original.BuyerCounty,
+		BuyerIsVatPayer:       original.BuyerIsVatPayer,
+		BuyerEmail:            original.BuyerEmail,
+		SubtotalAmount:        int32(-net),
+		VatRate:               numericFromInt(int(ratePct)),
+		VatAmount:             int32(-vat),
+		TotalAmount:           int32(-gross),
+		Currency:              original.Currency,
+		BookingID:             original.BookingID,
+		PaymentTransactionID:  original.PaymentTransactionID,
+		CompanyID:             original.CompanyID,
+		ClientUserID:          original.ClientUserID,
+		Status:                db.InvoiceStatusCreditNote,
+		DueDate:               dueDate,
+		Notes:                 pgText(notes),
+		CreditedInvoiceID:     original.ID,
+		CreditedInvoiceNumber: original.InvoiceNumber,
+		CreditedInvoiceDate:   pgtype.Date{Time: issued.Time, Valid: issued.Valid},
+		StripeRefundID:        stripeRefundID,
+		PayoutID:              original.PayoutID,
+	}, creditItems)
+	if err != nil {
+		return db.Invoice{}, err
+	}
+
+	// Store the PDF, email it and transmit it to e-Factura if the original
+	// invoice was; failed steps are retried by the invoice-pipeline job.
+	s.startPipeline(ctx, &creditNote)
+
+	log.Printf("invoice: created credit note %s for original invoice %s", textVal(creditNote.InvoiceNumber), textVal(original.InvoiceNumber))
+	return creditNote, nil
+}
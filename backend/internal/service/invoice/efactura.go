@@ -0,0 +1,225 @@
+package invoice
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	db "helpmeclean-backend/internal/db/generated"
+	"helpmeclean-backend/internal/service/efactura"
+	"helpmeclean-backend/internal/storage"
+)
+
+// efacturaPollBatch is how many pending uploads one status poll checks.
+const efacturaPollBatch = 100
+
+// ErrANAFNotConnected is returned when no ANAF account is connected for the
+// fiscal code of an invoice's seller.
+var ErrANAFNotConnected = errors.New("invoice: no ANAF account is connected for this fiscal code")
+
+// ANAFAuthorizationURL returns the ANAF login page where the owner of a
+// qualified certificate authorizes uploads to e-Factura, and the state the
+// callback must echo back.
+func (s *Service) ANAFAuthorizationURL() (authURL string, state string, err error) {
+	if !s.anaf.Configured() {
+		return "", "", errors.New("invoice: ANAF OAuth2 application is not configured")
+	}
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", fmt.Errorf("invoice: generate OAuth2 state: %w", err)
+	}
+	state = hex.EncodeToString(b)
+	return s.anaf.AuthorizationURL(state), state, nil
+}
+
+// ConnectANAF exchanges the authorization code from the ANAF callback and
+// stores the tokens for cif. companyID is invalid for the platform itself.
+func (s *Service) ConnectANAF(ctx context.Context, cif string, companyID, userID pgtype.UUID, code string) (db.AnafToken, error) {
+	cif = bareCIF(cif)
+	if cif == "" {
+		return db.AnafToken{}, errors.New("invoice: fiscal code is missing")
+	}
+	token, err := s.anaf.ExchangeCode(ctx, code)
+	if err != nil {
+		return db.AnafToken{}, fmt.Errorf("invoice: exchange ANAF authorization code: %w", err)
+	}
+	stored, err := s.queries.UpsertAnafToken(ctx, db.UpsertAnafTokenParams{
+		Cif:               cif,
+		CompanyID:         companyID,
+		AccessToken:       token.AccessToken,
+		RefreshToken:      token.RefreshToken,
+		ExpiresAt:         pgtype.Timestamptz{Time: token.ExpiresAt, Valid: true},
+		ConnectedByUserID: userID,
+	})
+	if err != nil {
+		return db.AnafToken{}, fmt.Errorf("invoice: store ANAF tokens: %w", err)
+	}
+	log.Printf("invoice: connected ANAF e-Factura account for CIF %s", cif)
+	return stored, nil
+}
+
+// ANAFConnection returns the ANAF connection of cif, or ErrANAFNotConnected.
+func (s *Service) ANAFConnection(ctx context.Context, cif string) (db.AnafToken, error) {
+	token, err := s.queries.GetAnafTokenByCIF(ctx, bareCIF(cif))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return db.AnafToken{}, ErrANAFNotConnected
+	}
+	if err != nil {
+		return db.AnafToken{}, fmt.Errorf("invoice: get ANAF tokens: %w", err)
+	}
+	return token, nil
+}
+
+// DisconnectANAF forgets the ANAF tokens of cif. Invoices are then
+// transmitted through Factureaza.ro again.
+func (s *Service) DisconnectANAF(ctx context.Context, cif string) error {
+	if err := s.queries.DeleteAnafToken(ctx, bareCIF(cif)); err != nil {
+		return fmt.Errorf("invoice: delete ANAF tokens: %w", err)
+	}
+	return nil
+}
+
+// anafAccessToken returns a valid access token for cif, refreshing it when
+// it is about to expire.
+func (s *Service) anafAccessToken(ctx context.Context, cif string) (string, error) {
+	stored, err := s.ANAFConnection(ctx, cif)
+	if err != nil {
+		return "", err
+	}
+	token := efactura.Token{AccessToken: stored.AccessToken, RefreshToken: stored.RefreshToken, ExpiresAt: stored.ExpiresAt.Time}
+	if !token.NeedsRefresh(time.Now()) {
+		return token.AccessToken, nil
+	}
+
+	refreshed, err := s.anaf.RefreshToken(ctx, token.RefreshToken)
+	if err != nil {
+		return "", fmt.Errorf("invoice: refresh ANAF token for CIF %s: %w", stored.Cif, err)
+	}
+	if _, err := s.queries.UpdateAnafTokens(ctx, db.UpdateAnafTokensParams{
+		Cif:          stored.Cif,
+		AccessToken:  refreshed.AccessToken,
+		RefreshToken: refreshed.RefreshToken,
+		ExpiresAt:    pgtype.Timestamptz{Time: refreshed.ExpiresAt, Valid: true},
+	}); err != nil {
+		return "", fmt.Errorf("invoice: store refreshed ANAF token: %w", err)
+	}
+	return refreshed.AccessToken, nil
+}
+
+// uploadToSPV uploads the CIUS-RO XML of an invoice to the ANAF SPV. A file
+// refused outright marks the invoice rejected with the SPV's messages.
+func (s *Service) uploadToSPV(ctx context.Context, inv db.Invoice, accessToken string) error {
+	document, err := s.EFacturaXML(ctx, inv.ID)
+	if err != nil {
+		return err
+	}
+	creditNote := inv.Status == db.InvoiceStatusCreditNote || inv.TotalAmount < 0
+	result, err := s.anaf.Upload(ctx, accessToken, bareCIF(inv.SellerCui), creditNote, document)
+	if err != nil {
+		return fmt.Errorf("invoice: upload to ANAF SPV: %w", err)
+	}
+
+	if len(result.Errors) > 0 {
+		message := strings.Join(result.Errors, "\n")
+		if err := s.queries.SetInvoiceEFacturaResult(ctx, db.SetInvoiceEFacturaResultParams{
+			ID:              inv.ID,
+			EfacturaStatus:  pgText(efactura.StatusRejected),
+			EfacturaMessage: pgText(message),
+		}); err != nil {
+			return fmt.Errorf("invoice: update e-factura status: %w", err)
+		}
+		log.Printf("invoice: ANAF SPV refused invoice %s: %s", textVal(inv.InvoiceNumber), message)
+		return nil
+	}
+
+	if err := s.queries.MarkInvoiceEFacturaUploaded(ctx, db.MarkInvoiceEFacturaUploadedParams{
+		ID:            inv.ID,
+		EfacturaIndex: pgText(result.Index),
+	}); err != nil {
+		return fmt.Errorf("invoice: update e-factura status: %w", err)
+	}
+	log.Printf("invoice: uploaded invoice %s to ANAF SPV (index_incarcare: %s)", textVal(inv.InvoiceNumber), result.Index)
+	return nil
+}
+
+// PollEFacturaStatus checks the invoices uploaded to the ANAF SPV that are
+// still being processed. Once ANAF has a result, its response archive (the
+// signed invoice or the error report) is downloaded into storage and the
+// invoice is marked accepted or rejected.
+func (s *Service) PollEFacturaStatus(ctx context.Context) error {
+	invoices, err := s.queries.ListPendingEFacturaInvoices(ctx, efacturaPollBatch)
+	if err != nil {
+		return fmt.Errorf("invoice: list pending e-factura uploads: %w", err)
+	}
+
+	failed := 0
+	for _, inv := range invoices {
+		if err := s.checkEFacturaStatus(ctx, inv); err != nil {
+			log.Printf("invoice: e-factura status of invoice %s: %v", textVal(inv.InvoiceNumber), err)
+			failed++
+		}
+	}
+	if len(invoices) > 0 {
+		log.Printf("invoice: checked %d e-factura uploads, %d failed", len(invoices), failed)
+	}
+	return nil
+}
+
+func (s *Service) checkEFacturaStatus(ctx context.Context, inv db.Invoice) error {
+	accessToken, err := s.anafAccessToken(ctx, inv.SellerCui)
+	if err != nil {
+		return err
+	}
+	status, err := s.anaf.Status(ctx, accessToken, textVal(inv.EfacturaIndex))
+	if err != nil {
+		return err
+	}
+
+	result := db.SetInvoiceEFacturaResultParams{
+		ID:                 inv.ID,
+		EfacturaStatus:     pgText(status.Status),
+		EfacturaDownloadID: pgtype.Text{String: status.DownloadID, Valid: status.DownloadID != ""},
+	}
+	messages := status.Messages
+	if status.DownloadID != "" {
+		archive, err := s.anaf.Download(ctx, accessToken, status.DownloadID)
+		if err != nil {
+			return err
+		}
+		path, err := s.storage.Upload(ctx, "efactura/"+uuidToString(inv.ID), textVal(inv.EfacturaIndex)+".zip",
+			bytes.NewReader(archive), storage.StorageTypePrivate)
+		if err != nil {
+			return fmt.Errorf("store ANAF response: %w", err)
+		}
+		result.EfacturaResponsePath = pgText(path)
+		if status.Status == efactura.StatusRejected {
+			messages = append(messages, efactura.ErrorMessages(archive)...)
+		}
+	}
+	if len(messages) > 0 {
+		result.EfacturaMessage = pgText(strings.Join(messages, "\n"))
+	}
+
+	if err := s.queries.SetInvoiceEFacturaResult(ctx, result); err != nil {
+		return fmt.Errorf("update e-factura status: %w", err)
+	}
+	if status.Status != inv.EfacturaStatus.String {
+		log.Printf("invoice: e-factura status of invoice %s is now %s", textVal(inv.InvoiceNumber), status.Status)
+	}
+	return nil
+}
+
+// bareCIF strips spaces and the "RO" VAT prefix from a fiscal code.
+func bareCIF(cif string) string {
+	cif = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(cif), " ", ""))
+	return strings.TrimPrefix(cif, "RO")
+}
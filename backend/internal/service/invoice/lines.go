@@ -0,0 +1,209 @@
+package invoice
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	db "helpmeclean-backend/internal/db/generated"
+)
+
+// charge is one billable component of a booking. Amounts are VAT-inclusive,
+// in bani; quantity is in hundredths (150 = 1.5 hours).
+type charge struct {
+	descRo   string
+	descEn   string
+	quantity int64
+	gross    int64
+}
+
+// bookingCharges splits the total of a booking into the charges the client
+// paid for: the cleaning hours, each extra, the holiday surcharge and the
+// pets surcharge. Bookings whose components do not add up are billed as a
+// single line. Booking amounts are stored in RON.
+func bookingCharges(booking db.Booking, serviceNameRo, serviceNameEn string, extras []db.ListBookingExtrasRow) []charge {
+	total := numericToBani(booking.FinalTotal)
+	if total == 0 {
+		total = numericToBani(booking.EstimatedTotal)
+	}
+	if total <= 0 {
+		return nil
+	}
+
+	var surcharges []charge
+	for _, e := range extras {
+		qty := int64(max(e.Quantity.Int32, 1))
+		price := numericToBani(e.Price)
+		if price <= 0 {
+			continue
+		}
+		surcharges = append(surcharges, charge{descRo: e.NameRo, descEn: e.NameEn, quantity: qty * 100, gross: price * qty})
+	}
+	var extrasTotal int64
+	for _, c := range surcharges {
+		extrasTotal += c.gross
+	}
+
+	holiday := numericToBani(booking.HolidaySurcharge)
+	if holiday > 0 {
+		surcharges = append(surcharges, charge{descRo: "Supliment zi de sărbătoare legală", descEn: "Public holiday surcharge", quantity: 100, gross: holiday})
+	}
+
+	if pets := numericToBani(booking.PetsSurcharge); pets > 0 {
+		surcharges = append(surcharges, charge{descRo: "Supliment animale de companie", descEn: "Pet surcharge", quantity: 100, gross: pets})
+	}
+
+	base := total
+	for _, c := range surcharges {
+		base -= c.gross
+	}
+	if base <= 0 {
+		return []charge{{
+			descRo:   fmt.Sprintf("Servicii curățenie - rezervare %s", booking.ReferenceCode),
+			descEn:   fmt.Sprintf("Cleaning services - booking %s", booking.ReferenceCode),
+			quantity: 100,
+			gross:    total,
+		}}
+	}
+
+	// The cleaning line is billed in hours at the booking's hourly rate.
+	rate := numericToBani(booking.HourlyRate)
+	hours := int64(100)
+	if rate > 0 {
+		if h := int64(math.Round(float64(base) * 100 / float64(rate))); h > 0 {
+			hours = h
+		}
+	}
+	cleaning := charge{
+		descRo:   fmt.Sprintf("%s (ore) - rezervare %s", serviceNameRo, booking.ReferenceCode),
+		descEn:   fmt.Sprintf("%s (hours) - booking %s", serviceNameEn, booking.ReferenceCode),
+		quantity: hours,
+		gross:    base,
+	}
+	return append([]charge{cleaning}, surcharges...)
+}
+
+// lineAmounts are the net amounts of one invoice line, in bani.
+type lineAmounts struct {
+	unitPrice int64
+	net       int64
+	vat       int64
+}
+
+// itemise splits VAT-inclusive charges into net and VAT at ratePct. The VAT
+// is computed once on the whole invoice and the net amount distributed over
+// the lines by largest remainder, so the lines add up to the totals exactly.
+// A rate of 0 bills the charges without VAT.
+func itemise(charges []charge, ratePct int64) (lines []lineAmounts, net, vat int64) {
+	var gross int64
+	for _, c := range charges {
+		gross += c.gross
+	}
+	if gross <= 0 {
+		return nil, 0, 0
+	}
+	net = (gross*200 + 100 + ratePct) / (2 * (100 + ratePct))
+	vat = gross - net
+
+	weights := make([]int64, len(charges))
+	for i, c := range charges {
+		weights[i] = c.gross
+	}
+	lines = make([]lineAmounts, len(charges))
+	for i, n := range distribute(net, weights) {
+		lines[i].net = n
+	}
+
+	for i, c := range charges {
+		lines[i].vat = c.gross - lines[i].net
+		lines[i].unitPrice = int64(math.Round(float64(lines[i].net) * 100 / float64(c.quantity)))
+	}
+	return lines, net, vat
+}
+
+// distribute splits amount over weights in proportion, by largest remainder,
+// so the parts add up to amount exactly. The weights must be positive.
+func distribute(amount int64, weights []int64) []int64 {
+	var total int64
+	for _, w := range weights {
+		total += w
+	}
+	parts := make([]int64, len(weights))
+	if total <= 0 {
+		return parts
+	}
+	order := make([]int, len(weights))
+	distributed := int64(0)
+	for i, w := range weights {
+		parts[i] = amount * w / total
+		distributed += parts[i]
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return amount*weights[order[a]]%total > amount*weights[order[b]]%total
+	})
+	for k := int64(0); k < amount-distributed; k++ {
+		parts[order[k]]++
+	}
+	return parts
+}
+
+// creditCharges mirrors the lines of an invoice for a credit note of gross,
+// VAT-inclusive: each line is credited in proportion to its share of the
+// invoice. A credit note for the whole invoice keeps the quantities; a partial
+// one credits each line as a single unit.
+func creditCharges(items []db.InvoiceLineItem, gross int64) []charge {
+	weights := make([]int64, 0, len(items))
+	var total int64
+	for _, it := range items {
+		if it.LineTotalWithVat > 0 {
+			weights = append(weights, int64(it.LineTotalWithVat))
+			total += int64(it.LineTotalWithVat)
+		}
+	}
+	if total <= 0 {
+		return []charge{{descRo: "Stornare", descEn: "Credit note", quantity: 100, gross: gross}}
+	}
+
+	parts := distribute(gross, weights)
+	charges := make([]charge, 0, len(parts))
+	k := 0
+	for _, it := range items {
+		if it.LineTotalWithVat <= 0 {
+			continue
+		}
+		c := charge{descRo: "Stornare - ", descEn: "Credit note - ", quantity: 100, gross: parts[k]}
+		k++
+		if gross == total {
+			// Quantities are stored in units; numericToBani gives hundredths.
+			if q := numericToBani(it.Quantity); q > 0 {
+				c.quantity = q
+			}
+		} else {
+			c.descRo, c.descEn = "Stornare parțială - ", "Partial credit note - "
+		}
+		c.descRo += it.DescriptionRo
+		if en := textVal(it.DescriptionEn); en != "" {
+			c.descEn += en
+		} else {
+			c.descEn += it.DescriptionRo
+		}
+		if c.gross > 0 {
+			charges = append(charges, c)
+		}
+	}
+	return charges
+}
+
+// numericToBani converts a RON amount stored as numeric to bani.
+func numericToBani(n pgtype.Numeric) int64 {
+	return int64(math.Round(numericToFloat64(n) * 100))
+}
+
+// numericFromHundredths creates a pgtype.Numeric from a value in hundredths.
+func numericFromHundredths(v int64) pgtype.Numeric {
+	return pgtype.Numeric{Int: big.NewInt(v), Exp: -2, Valid: true}
+}
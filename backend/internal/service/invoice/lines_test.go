@@ -0,0 +1,179 @@
+package invoice
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	db "helpmeclean-backend/internal/db/generated"
+)
+
+// ron creates a numeric RON amount from a value in bani.
+func ron(bani int64) pgtype.Numeric {
+	return pgtype.Numeric{Int: big.NewInt(bani), Exp: -2, Valid: true}
+}
+
+func TestBookingCharges(t *testing.T) {
+	booking := db.Booking{
+		ReferenceCode:          "HMC-1234",
+		HourlyRate:             ron(5000),
+		EstimatedDurationHours: ron(300),
+		TeamSize:               1,
+		HasPets:                pgtype.Bool{Bool: true, Valid: true},
+		HolidaySurcharge:       ron(3000),
+		PetsSurcharge:          ron(1500),
+		// 3h x 50 + 2 x 25 extras + 15 pets + 30 holiday
+		EstimatedTotal: ron(24500),
+	}
+	extras := []db.ListBookingExtrasRow{
+		{NameRo: "Curățare geamuri", NameEn: "Window cleaning", Price: ron(2500), Quantity: pgtype.Int4{Int32: 2, Valid: true}},
+		{NameRo: "Gratuit", Price: ron(0)},
+	}
+
+	charges := bookingCharges(booking, "Curățenie generală", "Standard cleaning", extras)
+	want := []charge{
+		{descRo: "Curățenie generală (ore) - rezervare HMC-1234", quantity: 300, gross: 15000},
+		{descRo: "Curățare geamuri", quantity: 200, gross: 5000},
+		{descRo: "Supliment zi de sărbătoare legală", quantity: 100, gross: 3000},
+		{descRo: "Supliment animale de companie", quantity: 100, gross: 1500},
+	}
+	if len(charges) != len(want) {
+		t.Fatalf("bookingCharges() = %+v", charges)
+	}
+	for i, w := range want {
+		c := charges[i]
+		if c.descRo != w.descRo || c.quantity != w.quantity || c.gross != w.gross {
+			t.Errorf("charge %d = %+v, want %+v", i, c, w)
+		}
+	}
+
+	// A longer visit is billed as more hours.
+	booking.FinalTotal = ron(27000)
+	if c := bookingCharges(booking, "Curățenie generală", "Standard cleaning", extras)[0]; c.quantity != 350 || c.gross != 17500 {
+		t.Errorf("cleaning after overtime = %+v", c)
+	}
+
+	// Components that exceed the total are billed as one line.
+	booking.FinalTotal = ron(5000)
+	if c := bookingCharges(booking, "Curățenie generală", "Standard cleaning", extras); len(c) != 1 || c[0].gross != 5000 {
+		t.Errorf("bookingCharges() below the surcharges = %+v", c)
+	}
+
+	if c := bookingCharges(db.Booking{}, "", "", nil); c != nil {
+		t.Errorf("bookingCharges() without a total = %+v", c)
+	}
+}
+
+func TestBookingChargesPetsWithAdjustedEstimate(t *testing.T) {
+	// The estimate carries 20 RON beyond the hours, extras and surcharges,
+	// e.g. a price agreed with the company; it is billed as cleaning, not
+	// as pets surcharge.
+	booking := db.Booking{
+		ReferenceCode:          "HMC-5678",
+		HourlyRate:             ron(5000),
+		EstimatedDurationHours: ron(300),
+		TeamSize:               1,
+		HasPets:                pgtype.Bool{Bool: true, Valid: true},
+		PetsSurcharge:          ron(1500),
+		HolidaySurcharge:       ron(3000),
+		// 3h x 50 + 15 pets + 30 holiday + 20
+		EstimatedTotal: ron(21500),
+	}
+
+	charges := bookingCharges(booking, "Curățenie generală", "Standard cleaning", nil)
+	want := []charge{
+		{descRo: "Curățenie generală (ore) - rezervare HMC-5678", quantity: 340, gross: 17000},
+		{descRo: "Supliment zi de sărbătoare legală", quantity: 100, gross: 3000},
+		{descRo: "Supliment animale de companie", quantity: 100, gross: 1500},
+	}
+	if len(charges) != len(want) {
+		t.Fatalf("bookingCharges() = %+v", charges)
+	}
+	for i, w := range want {
+		c := charges[i]
+		if c.descRo != w.descRo || c.quantity != w.quantity || c.gross != w.gross {
+			t.Errorf("charge %d = %+v, want %+v", i, c, w)
+		}
+	}
+}
+
+func TestItemise(t *testing.T) {
+	charges := []charge{
+		{quantity: 300, gross: 15000},
+		{quantity: 200, gross: 5000},
+		{quantity: 100, gross: 3333},
+		{quantity: 100, gross: 1},
+	}
+
+	lines, net, vat := itemise(charges, 21)
+	if net+vat != 23334 {
+		t.Fatalf("net %d + vat %d != gross", net, vat)
+	}
+	if net != 19284 { // 23334 / 1.21 = 19284.30
+		t.Errorf("net = %d, want 19284", net)
+	}
+	var sumNet, sumVAT int64
+	for i, l := range lines {
+		sumNet += l.net
+		sumVAT += l.vat
+		if l.net+l.vat != charges[i].gross || l.vat < 0 {
+			t.Errorf("line %d = %+v does not split %d", i, l, charges[i].gross)
+		}
+	}
+	if sumNet != net || sumVAT != vat {
+		t.Errorf("lines add up to %d + %d, want %d + %d", sumNet, sumVAT, net, vat)
+	}
+	if lines[0].unitPrice != 4132 { // 12397 net over 3 hours
+		t.Errorf("unit price = %d, want 4132", lines[0].unitPrice)
+	}
+
+	lines, net, vat = itemise(charges, 0)
+	if net != 23334 || vat != 0 {
+		t.Errorf("itemise() at 0%% = %d + %d", net, vat)
+	}
+	for i, l := range lines {
+		if l.vat != 0 || l.net != charges[i].gross {
+			t.Errorf("line %d at 0%% = %+v", i, l)
+		}
+	}
+}
+
+func TestCreditCharges(t *testing.T) {
+	items := []db.InvoiceLineItem{
+		{DescriptionRo: "Curățenie generală (ore)", DescriptionEn: pgtype.Text{String: "Standard cleaning (hours)", Valid: true}, Quantity: ron(300), LineTotalWithVat: 15000},
+		{DescriptionRo: "Curățare geamuri", Quantity: ron(200), LineTotalWithVat: 5000},
+		{DescriptionRo: "Supliment zi de sărbătoare legală", Quantity: ron(100), LineTotalWithVat: 3000},
+	}
+
+	full := creditCharges(items, 23000)
+	want := []charge{
+		{descRo: "Stornare - Curățenie generală (ore)", descEn: "Credit note - Standard cleaning (hours)", quantity: 300, gross: 15000},
+		{descRo: "Stornare - Curățare geamuri", descEn: "Credit note - Curățare geamuri", quantity: 200, gross: 5000},
+		{descRo: "Stornare - Supliment zi de sărbătoare legală", descEn: "Credit note - Supliment zi de sărbătoare legală", quantity: 100, gross: 3000},
+	}
+	if len(full) != len(want) {
+		t.Fatalf("creditCharges() of the whole invoice = %+v", full)
+	}
+	for i, w := range want {
+		if full[i] != w {
+			t.Errorf("charge %d = %+v, want %+v", i, full[i], w)
+		}
+	}
+
+	// A partial credit is spread over the lines and adds up exactly.
+	partial := creditCharges(items, 10000)
+	wantGross := []int64{6522, 2174, 1304}
+	if len(partial) != len(wantGross) {
+		t.Fatalf("creditCharges() of 100 RON = %+v", partial)
+	}
+	for i, g := range wantGross {
+		if c := partial[i]; c.gross != g || c.quantity != 100 || c.descRo != "Stornare parțială - "+items[i].DescriptionRo {
+			t.Errorf("partial charge %d = %+v, want %d bani for one unit", i, c, g)
+		}
+	}
+
+	if c := creditCharges(nil, 5000); len(c) != 1 || c[0].gross != 5000 {
+		t.Errorf("creditCharges() without line items = %+v", c)
+	}
+}
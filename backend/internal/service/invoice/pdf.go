@@ -0,0 +1,96 @@
+package invoice
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	db "helpmeclean-backend/internal/db/generated"
+	"helpmeclean-backend/internal/service/invoicepdf"
+	"helpmeclean-backend/internal/storage"
+)
+
+// RenderPDF renders the PDF of an invoice or credit note.
+func (s *Service) RenderPDF(ctx context.Context, invoiceID pgtype.UUID) ([]byte, error) {
+	inv, lineItems, err := s.loadDocument(ctx, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	// The platform is only named in the footer; render without it rather
+	// than fail.
+	platform, err := s.queries.GetPlatformLegalEntity(ctx)
+	if err != nil {
+		log.Printf("invoice: load platform legal entity for PDF: %v", err)
+	}
+	return invoicepdf.Render(inv, lineItems, platform)
+}
+
+// StorePDF renders the PDF of an invoice, stores it privately and records
+// its path on the invoice. It returns the storage path.
+func (s *Service) StorePDF(ctx context.Context, invoiceID pgtype.UUID) (string, error) {
+	pdf, err := s.RenderPDF(ctx, invoiceID)
+	if err != nil {
+		return "", err
+	}
+	inv, err := s.queries.GetInvoiceByID(ctx, invoiceID)
+	if err != nil {
+		return "", fmt.Errorf("invoice: get invoice for PDF: %w", err)
+	}
+	path, err := s.storage.Upload(ctx, "invoices/"+uuidToString(inv.ID), PDFFilename(inv), bytes.NewReader(pdf), storage.StorageTypePrivate)
+	if err != nil {
+		return "", fmt.Errorf("invoice: store PDF: %w", err)
+	}
+	if err := s.queries.SetInvoicePDFPath(ctx, db.SetInvoicePDFPathParams{ID: inv.ID, PdfPath: pgText(path)}); err != nil {
+		return "", fmt.Errorf("invoice: record PDF path: %w", err)
+	}
+	return path, nil
+}
+
+// PDF returns the stored PDF of an invoice, rendering and storing it first
+// for invoices that have none yet.
+func (s *Service) PDF(ctx context.Context, inv db.Invoice) (io.ReadCloser, error) {
+	path := textVal(inv.PdfPath)
+	if path == "" {
+		var err error
+		if path, err = s.StorePDF(ctx, inv.ID); err != nil {
+			return nil, err
+		}
+	}
+	rc, err := s.storage.GetReader(ctx, path)
+	if err != nil {
+		return nil, fmt.Errorf("invoice: read PDF: %w", err)
+	}
+	return rc, nil
+}
+
+// PDFFilename returns the file name of an invoice's PDF, e.g.
+// "factura-HMC-2026-0001.pdf".
+func PDFFilename(inv db.Invoice) string {
+	prefix := "factura"
+	if inv.Status == db.InvoiceStatusCreditNote || inv.TotalAmount < 0 {
+		prefix = "nota-credit"
+	}
+	number := strings.Map(func(r rune) rune {
+		if r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' {
+			return r
+		}
+		return '-'
+	}, textVal(inv.InvoiceNumber))
+	return prefix + "-" + number + ".pdf"
+}
+
+// storePDF stores the PDF of a newly created invoice. Failures are logged and
+// not returned: the PDF is rendered again on the first download.
+func (s *Service) storePDF(ctx context.Context, inv *db.Invoice) {
+	path, err := s.StorePDF(ctx, inv.ID)
+	if err != nil {
+		log.Printf("invoice: store PDF of invoice %s (non-fatal): %v", textVal(inv.InvoiceNumber), err)
+		return
+	}
+	inv.PdfPath = pgText(path)
+}
@@ -0,0 +1,337 @@
+package invoice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	db "helpmeclean-backend/internal/db/generated"
+	"helpmeclean-backend/internal/service/efactura"
+	"helpmeclean-backend/internal/service/holidays"
+	"helpmeclean-backend/internal/service/recurrence"
+)
+
+// Auto-issue triggers of the company invoicing settings.
+const (
+	TriggerManual           = "manual"
+	TriggerJobCompleted     = "job_completed"
+	TriggerPaymentSucceeded = "payment_succeeded"
+)
+
+// Pipeline states of a client invoice.
+const (
+	PipelinePending    = "pending"
+	PipelineProcessing = "processing"
+	PipelineDone       = "done"
+	PipelineFailed     = "failed"
+)
+
+const (
+	// maxPipelineAttempts bounds the retries of an invoice pipeline; the
+	// backoff doubles from 5 minutes, so the last attempt is about 10 hours
+	// after the first.
+	maxPipelineAttempts = 8
+	// pipelineBatch is how many bookings and invoices one run of the job
+	// handles.
+	pipelineBatch = 50
+	// efacturaDeadlineDays is the legal deadline, in calendar days after the
+	// issue date, for transmitting B2B invoices to e-Factura (OUG 120/2021
+	// art. 10, as amended by Law 296/2023).
+	efacturaDeadlineDays = 5
+)
+
+// Settings returns the invoicing settings of a company. Companies that never
+// saved any issue their invoices manually.
+func (s *Service) Settings(ctx context.Context, companyID pgtype.UUID) (db.CompanyInvoiceSetting, error) {
+	settings, err := s.queries.GetCompanyInvoiceSettings(ctx, companyID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return db.CompanyInvoiceSetting{CompanyID: companyID, AutoIssueTrigger: TriggerManual}, nil
+	}
+	if err != nil {
+		return db.CompanyInvoiceSetting{}, fmt.Errorf("invoice: get invoicing settings: %w", err)
+	}
+	return settings, nil
+}
+
+// AutoIssue issues the client invoice of a booking when its company issues
+// invoices automatically on trigger. Failures are only logged: the
+// invoice-pipeline job issues the invoices of bookings left without one.
+func (s *Service) AutoIssue(ctx context.Context, booking db.Booking, trigger string) {
+	if !booking.CompanyID.Valid {
+		return
+	}
+	settings, err := s.Settings(ctx, booking.CompanyID)
+	if err != nil {
+		log.Printf("invoice: auto-issue for booking %s: %v", booking.ReferenceCode, err)
+		return
+	}
+	if settings.AutoIssueTrigger != trigger {
+		return
+	}
+	if err := s.issueBookingInvoice(ctx, booking); err != nil {
+		log.Printf("invoice: auto-issue for booking %s failed, will be retried: %v", booking.ReferenceCode, err)
+	}
+}
+
+func (s *Service) issueBookingInvoice(ctx context.Context, booking db.Booking) error {
+	company, err := s.queries.GetCompanyByID(ctx, booking.CompanyID)
+	if err != nil {
+		return fmt.Errorf("invoice: get company: %w", err)
+	}
+	_, err = s.GenerateClientServiceInvoice(ctx, booking, company, booking.ClientUserID)
+	return err
+}
+
+// RunInvoicePipelines issues the invoices of bookings their company's
+// settings should have invoiced, and retries the invoice pipelines and refund
+// credits that are due. It is run periodically by the job scheduler.
+func (s *Service) RunInvoicePipelines(ctx context.Context) error {
+	bookings, err := s.queries.ListBookingsAwaitingInvoice(ctx, pipelineBatch)
+	if err != nil {
+		return fmt.Errorf("invoice: list bookings awaiting an invoice: %w", err)
+	}
+	issued := 0
+	for _, b := range bookings {
+		if err := s.issueBookingInvoice(ctx, b); err != nil {
+			log.Printf("invoice: auto-issue for booking %s failed: %v", b.ReferenceCode, err)
+			continue
+		}
+		issued++
+	}
+
+	invoices, err := s.queries.ListDueInvoicePipelines(ctx, pipelineBatch)
+	if err != nil {
+		return fmt.Errorf("invoice: list due invoice pipelines: %w", err)
+	}
+	failed := 0
+	for _, inv := range invoices {
+		if err := s.RunPipeline(ctx, inv.ID); err != nil {
+			log.Printf("invoice: pipeline of invoice %s failed: %v", textVal(inv.InvoiceNumber), err)
+			failed++
+		}
+	}
+
+	credits, err := s.queries.ListDueRefundCredits(ctx, pipelineBatch)
+	if err != nil {
+		return fmt.Errorf("invoice: list due refund credits: %w", err)
+	}
+	creditsFailed := 0
+	for _, rc := range credits {
+		if err := s.RunRefundCredit(ctx, rc.RefundID); err != nil {
+			log.Printf("invoice: credit of refund %s failed: %v", rc.RefundID, err)
+			creditsFailed++
+		}
+	}
+
+	if len(bookings) > 0 || len(invoices) > 0 || len(credits) > 0 {
+		log.Printf("invoice: auto-issued %d of %d invoices, ran %d pipelines, %d failed, credited %d refunds, %d failed",
+			issued, len(bookings), len(invoices), failed, len(credits), creditsFailed)
+	}
+	return nil
+}
+
+// RetryPipeline restarts the pipeline of an invoice, e.g. after a failure the
+// company has fixed, and runs it.
+func (s *Service) RetryPipeline(ctx context.Context, invoiceID pgtype.UUID) (db.Invoice, error) {
+	if err := s.queries.StartInvoicePipeline(ctx, invoiceID); err != nil {
+		return db.Invoice{}, fmt.Errorf("invoice: restart pipeline: %w", err)
+	}
+	if err := s.RunPipeline(ctx, invoiceID); err != nil {
+		log.Printf("invoice: pipeline of invoice %s failed, will be retried: %v", uuidToString(invoiceID), err)
+	}
+	return s.queries.GetInvoiceByID(ctx, invoiceID)
+}
+
+// startPipeline starts and runs the pipeline of a newly issued invoice, and
+// refreshes inv with its outcome.
+func (s *Service) startPipeline(ctx context.Context, inv *db.Invoice) {
+	if err := s.queries.StartInvoicePipeline(ctx, inv.ID); err != nil {
+		log.Printf("invoice: start pipeline of invoice %s: %v", textVal(inv.InvoiceNumber), err)
+		s.storePDF(ctx, inv)
+		return
+	}
+	if err := s.RunPipeline(ctx, inv.ID); err != nil {
+		log.Printf("invoice: pipeline of invoice %s failed, will be retried: %v", textVal(inv.InvoiceNumber), err)
+	}
+	if updated, err := s.queries.GetInvoiceByID(ctx, inv.ID); err == nil {
+		*inv = updated
+	}
+}
+
+// RunPipeline runs the pipeline of an invoice or a credit note: it stores the
+// PDF, emails it to the buyer and transmits the invoice of a business buyer
+// to e-Factura, as the company's settings ask; commission invoices are always
+// emailed to the company, and credit notes are transmitted when the invoice
+// they correct reached e-Factura. The pipeline is claimed
+// first so it never runs twice at once; steps already done are skipped, so a
+// failed pipeline is simply run again later.
+func (s *Service) RunPipeline(ctx context.Context, invoiceID pgtype.UUID) error {
+	inv, err := s.queries.ClaimInvoicePipeline(ctx, invoiceID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("invoice: claim pipeline: %w", err)
+	}
+
+	if err := s.runPipelineSteps(ctx, inv); err != nil {
+		s.recordPipelineFailure(ctx, inv, err)
+		return err
+	}
+	if err := s.queries.MarkInvoicePipelineDone(ctx, inv.ID); err != nil {
+		return fmt.Errorf("invoice: mark pipeline done: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) runPipelineSteps(ctx context.Context, inv db.Invoice) error {
+	if inv.Status == db.InvoiceStatusCancelled {
+		return nil
+	}
+	settings, err := s.Settings(ctx, inv.CompanyID)
+	if err != nil {
+		return err
+	}
+
+	if textVal(inv.PdfPath) == "" {
+		path, err := s.StorePDF(ctx, inv.ID)
+		if err != nil {
+			return err
+		}
+		inv.PdfPath = pgText(path)
+	}
+	// The settings are the company's own: they apply to its client invoices,
+	// not to the commission invoices it receives, which are always emailed.
+	email := inv.InvoiceType == db.InvoiceTypePlatformCommission ||
+		settings.AutoEmail && inv.InvoiceType == db.InvoiceTypeClientService
+	transmit := settings.AutoEfactura && inv.InvoiceType == db.InvoiceTypeClientService && IsB2B(inv)
+	if inv.CreditedInvoiceID.Valid {
+		// Credit notes are transmitted when the invoice they correct reached
+		// e-Factura.
+		original, err := s.queries.GetInvoiceByID(ctx, inv.CreditedInvoiceID)
+		if err != nil {
+			return fmt.Errorf("invoice: get credited invoice: %w", err)
+		}
+		transmit = inEFactura(original)
+	}
+
+	if email && !inv.EmailedAt.Valid && textVal(inv.BuyerEmail) != "" {
+		if err := s.emailInvoice(ctx, inv); err != nil {
+			return err
+		}
+	}
+	if transmit && needsEFactura(inv) {
+		if err := s.TransmitToEFactura(ctx, inv.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// emailInvoice emails the PDF of an invoice to its buyer.
+func (s *Service) emailInvoice(ctx context.Context, inv db.Invoice) error {
+	if s.mailer == nil {
+		return nil
+	}
+	rc, err := s.PDF(ctx, inv)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	pdf, err := io.ReadAll(rc)
+	if err != nil {
+		return fmt.Errorf("invoice: read PDF: %w", err)
+	}
+
+	skipped, err := s.mailer.SendInvoice(textVal(inv.BuyerEmail), inv.BuyerName, textVal(inv.InvoiceNumber),
+		inv.SellerCompanyName, PDFFilename(inv), pdf)
+	if err != nil {
+		return fmt.Errorf("invoice: email invoice: %w", err)
+	}
+	if skipped {
+		log.Printf("invoice: email of invoice %s to %s skipped outside production", textVal(inv.InvoiceNumber), textVal(inv.BuyerEmail))
+		return nil
+	}
+	if err := s.queries.MarkInvoiceEmailed(ctx, inv.ID); err != nil {
+		return fmt.Errorf("invoice: record invoice email: %w", err)
+	}
+	return nil
+}
+
+// recordPipelineFailure stores the error of a pipeline run and schedules the
+// next attempt with exponential backoff. After maxPipelineAttempts the
+// pipeline is left failed with no next attempt.
+func (s *Service) recordPipelineFailure(ctx context.Context, inv db.Invoice, cause error) {
+	var next pgtype.Timestamptz
+	if inv.PipelineAttempts < maxPipelineAttempts {
+		delay := time.Duration(5<<min(inv.PipelineAttempts-1, 9)) * time.Minute
+		next = pgtype.Timestamptz{Time: time.Now().Add(delay), Valid: true}
+	} else {
+		log.Printf("invoice: giving up on the pipeline of invoice %s after %d attempts", textVal(inv.InvoiceNumber), inv.PipelineAttempts)
+	}
+	if EFacturaOverdue(inv, time.Now()) {
+		log.Printf("invoice: invoice %s is past its e-Factura deadline and still not transmitted", textVal(inv.InvoiceNumber))
+	}
+
+	if err := s.queries.MarkInvoicePipelineFailed(ctx, db.MarkInvoicePipelineFailedParams{
+		ID:                    inv.ID,
+		PipelineError:         pgText(cause.Error()),
+		PipelineNextAttemptAt: next,
+	}); err != nil {
+		log.Printf("invoice: warning: failed to record pipeline failure of invoice %s: %v", textVal(inv.InvoiceNumber), err)
+	}
+}
+
+// IsB2B reports whether the buyer of an invoice is a business, identified by
+// its fiscal code; only those invoices must be transmitted to e-Factura.
+func IsB2B(inv db.Invoice) bool {
+	return textVal(inv.BuyerCui) != ""
+}
+
+// needsEFactura reports whether an invoice still has to be transmitted: it
+// was never transmitted, or the last transmission failed before reaching
+// ANAF.
+func needsEFactura(inv db.Invoice) bool {
+	status := textVal(inv.EfacturaStatus)
+	return status == "" || status == "error"
+}
+
+// inEFactura reports whether an invoice reached e-Factura: it was uploaded to
+// the ANAF SPV and is being processed or was accepted. ANAF rejects a credit
+// note correcting an invoice it does not have.
+func inEFactura(inv db.Invoice) bool {
+	switch textVal(inv.EfacturaStatus) {
+	case efactura.StatusUploaded, efactura.StatusProcessing, efactura.StatusAccepted:
+		return true
+	}
+	return false
+}
+
+// EFacturaDeadline returns the end of the legal deadline for transmitting an
+// invoice issued at issuedAt to e-Factura: five calendar days after the issue
+// date, in Romanian time. A deadline that ends on a weekend or public holiday
+// runs to the end of the next working day (Fiscal Procedure Code art. 181).
+func EFacturaDeadline(issuedAt time.Time) time.Time {
+	local := issuedAt.In(recurrence.Location)
+	day := time.Date(local.Year(), local.Month(), local.Day()+efacturaDeadlineDays, 0, 0, 0, 0, recurrence.Location)
+	for {
+		_, holiday := holidays.Lookup(day)
+		if !holiday && day.Weekday() != time.Saturday && day.Weekday() != time.Sunday {
+			break
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	return day.AddDate(0, 0, 1).Add(-time.Second)
+}
+
+// EFacturaOverdue reports whether the invoice of a business buyer is past its
+// e-Factura deadline at now without having been transmitted.
+func EFacturaOverdue(inv db.Invoice, now time.Time) bool {
+	return IsB2B(inv) && needsEFactura(inv) && inv.IssuedAt.Valid && now.After(EFacturaDeadline(inv.IssuedAt.Time))
+}
@@ -0,0 +1,93 @@
+package invoice
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	db "helpmeclean-backend/internal/db/generated"
+)
+
+func TestEFacturaDeadline(t *testing.T) {
+	tests := []struct {
+		issued time.Time
+		want   string
+	}{
+		// Thursday: five calendar days later is the next Tuesday.
+		{time.Date(2026, 3, 5, 10, 0, 0, 0, time.UTC), "2026-03-10"},
+		// Monday: the deadline ends on Saturday and runs to Monday.
+		{time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC), "2026-03-09"},
+		// Late evening UTC is already the next day in Romania.
+		{time.Date(2026, 3, 1, 22, 30, 0, 0, time.UTC), "2026-03-09"},
+		// 30 November and 1 December are public holidays.
+		{time.Date(2026, 11, 25, 12, 0, 0, 0, time.UTC), "2026-12-02"},
+		// So are 1 and 2 January, but not the Sunday deadline's Monday.
+		{time.Date(2026, 12, 29, 12, 0, 0, 0, time.UTC), "2027-01-04"},
+	}
+	for _, tt := range tests {
+		got := EFacturaDeadline(tt.issued)
+		if d := got.Format("2006-01-02"); d != tt.want {
+			t.Errorf("EFacturaDeadline(%v) = %s, want %s", tt.issued, d, tt.want)
+		}
+		if h, m := got.Hour(), got.Minute(); h != 23 || m != 59 {
+			t.Errorf("EFacturaDeadline(%v) = %v, want the end of the day", tt.issued, got)
+		}
+	}
+}
+
+func TestNeedsEFactura(t *testing.T) {
+	inv := db.Invoice{BuyerCui: pgtype.Text{String: "RO123", Valid: true}}
+	if !IsB2B(inv) || !needsEFactura(inv) {
+		t.Error("untransmitted B2B invoice does not need e-Factura")
+	}
+	inv.EfacturaStatus = pgtype.Text{String: "error", Valid: true}
+	if !needsEFactura(inv) {
+		t.Error("failed transmission is not retried")
+	}
+	inv.EfacturaStatus = pgtype.Text{String: "uploaded", Valid: true}
+	if needsEFactura(inv) {
+		t.Error("uploaded invoice is transmitted again")
+	}
+	if IsB2B(db.Invoice{}) {
+		t.Error("invoice without a buyer CUI is B2B")
+	}
+}
+
+func TestInEFactura(t *testing.T) {
+	// Credit notes follow only invoices that reached e-Factura.
+	for status, want := range map[string]bool{
+		"":           false,
+		"error":      false,
+		"uploaded":   true,
+		"processing": true,
+		"accepted":   true,
+		"rejected":   false,
+	} {
+		inv := db.Invoice{EfacturaStatus: pgtype.Text{String: status, Valid: status != ""}}
+		if got := inEFactura(inv); got != want {
+			t.Errorf("inEFactura(%q) = %v, want %v", status, got, want)
+		}
+	}
+}
+
+func TestEFacturaOverdue(t *testing.T) {
+	issued := time.Date(2026, 3, 5, 10, 0, 0, 0, time.UTC)
+	inv := db.Invoice{
+		BuyerCui: pgtype.Text{String: "RO123", Valid: true},
+		IssuedAt: pgtype.Timestamptz{Time: issued, Valid: true},
+	}
+	if EFacturaOverdue(inv, issued.AddDate(0, 0, 5)) {
+		t.Error("invoice is overdue before its deadline")
+	}
+	if !EFacturaOverdue(inv, issued.AddDate(0, 0, 6)) {
+		t.Error("untransmitted invoice is not overdue after its deadline")
+	}
+	inv.EfacturaStatus = pgtype.Text{String: "uploaded", Valid: true}
+	if EFacturaOverdue(inv, issued.AddDate(0, 0, 6)) {
+		t.Error("uploaded invoice is overdue")
+	}
+	if IsB2B(db.Invoice{}) {
+		t.Error("invoice without a buyer CUI is B2B")
+	}
+}
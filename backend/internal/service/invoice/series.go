@@ -0,0 +1,229 @@
+package invoice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	db "helpmeclean-backend/internal/db/generated"
+	"helpmeclean-backend/internal/service/recurrence"
+)
+
+// Document types, numbered in separate series.
+const (
+	DocumentInvoice    = "invoice"
+	DocumentCreditNote = "credit_note"
+)
+
+const (
+	// defaultPlatformSeries numbers commission invoices until the platform
+	// creates a series of its own.
+	defaultPlatformSeries = "HMC"
+	// creditNotePrefix numbers the credit notes of a seller until it creates
+	// a credit note series of its own.
+	creditNotePrefix = "CN"
+)
+
+// maxPrefixLen bounds the prefix of a series.
+const maxPrefixLen = 10
+
+// errInvoiceExists is returned by insertInvoice for a second client invoice
+// of a booking, issued concurrently with the first.
+var errInvoiceExists = errors.New("invoice: the booking already has a client invoice")
+
+// SeriesInput describes a new invoice series.
+type SeriesInput struct {
+	DocumentType string
+	// Prefix starts every number of the series, e.g. "ABC" in ABC-2026-0001.
+	Prefix string
+	// StartNumber is the first number of the series, and of every year if
+	// the series resets yearly.
+	StartNumber int32
+	// YearlyReset starts the numbering again every year, with the year in
+	// the number.
+	YearlyReset bool
+}
+
+// ListSeries returns the invoice series of a company, or of the platform when
+// companyID is not valid, active series first.
+func (s *Service) ListSeries(ctx context.Context, companyID pgtype.UUID) ([]db.InvoiceSeries, error) {
+	series, err := s.queries.ListInvoiceSeries(ctx, companyID)
+	if err != nil {
+		return nil, fmt.Errorf("invoice: list series: %w", err)
+	}
+	return series, nil
+}
+
+// CreateSeries starts a new series for a company, or for the platform when
+// companyID is not valid. The active series of the same document type is
+// retired: documents issued from now on are numbered in the new series. A
+// prefix can be used by one series of a seller only, so numbers never repeat.
+func (s *Service) CreateSeries(ctx context.Context, companyID pgtype.UUID, in SeriesInput) (db.InvoiceSeries, error) {
+	if in.DocumentType != DocumentInvoice && in.DocumentType != DocumentCreditNote {
+		return db.InvoiceSeries{}, fmt.Errorf("invoice: unknown document type %q", in.DocumentType)
+	}
+	prefix := strings.ToUpper(strings.TrimSpace(in.Prefix))
+	if err := validatePrefix(prefix); err != nil {
+		return db.InvoiceSeries{}, err
+	}
+	if in.StartNumber < 1 {
+		return db.InvoiceSeries{}, errors.New("invoice: a series starts at number 1 or later")
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return db.InvoiceSeries{}, fmt.Errorf("invoice: failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	qtx := s.queries.WithTx(tx)
+
+	existing, err := qtx.ListInvoiceSeries(ctx, companyID)
+	if err != nil {
+		return db.InvoiceSeries{}, fmt.Errorf("invoice: list series: %w", err)
+	}
+	for _, es := range existing {
+		if es.Prefix == prefix {
+			return db.InvoiceSeries{}, fmt.Errorf("invoice: the prefix %s is already used by another series", prefix)
+		}
+	}
+
+	if err := qtx.RetireActiveInvoiceSeries(ctx, db.RetireActiveInvoiceSeriesParams{
+		CompanyID:    companyID,
+		DocumentType: in.DocumentType,
+	}); err != nil {
+		return db.InvoiceSeries{}, fmt.Errorf("invoice: retire active series: %w", err)
+	}
+	series, err := qtx.CreateInvoiceSeries(ctx, db.CreateInvoiceSeriesParams{
+		CompanyID:    companyID,
+		DocumentType: in.DocumentType,
+		Prefix:       prefix,
+		StartNumber:  in.StartNumber,
+		YearlyReset:  in.YearlyReset,
+		CurrentYear:  int32(recurrence.Today().Year()),
+	})
+	if err != nil {
+		return db.InvoiceSeries{}, fmt.Errorf("invoice: create series: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return db.InvoiceSeries{}, fmt.Errorf("invoice: failed to commit series: %w", err)
+	}
+	return series, nil
+}
+
+// RetireSeries retires a series of a company, or of the platform when
+// companyID is not valid. Its numbers are kept; documents of its type cannot
+// be issued again until a new series is created.
+func (s *Service) RetireSeries(ctx context.Context, companyID, seriesID pgtype.UUID) (db.InvoiceSeries, error) {
+	series, err := s.queries.GetInvoiceSeries(ctx, seriesID)
+	if err != nil || series.CompanyID != companyID {
+		return db.InvoiceSeries{}, errors.New("invoice: series not found")
+	}
+	if !series.Active {
+		return series, nil
+	}
+	series, err = s.queries.RetireInvoiceSeries(ctx, seriesID)
+	if err != nil {
+		return db.InvoiceSeries{}, fmt.Errorf("invoice: retire series: %w", err)
+	}
+	return series, nil
+}
+
+// NextNumber returns the number the next document of a series takes, e.g.
+// "ABC-2026-0042".
+func NextNumber(series db.InvoiceSeries) string {
+	year := int32(recurrence.Today().Year())
+	number := series.NextNumber
+	if series.YearlyReset && series.CurrentYear != year {
+		number = series.StartNumber
+	}
+	return formatNumber(series.Prefix, year, number, series.YearlyReset)
+}
+
+// insertInvoice numbers an invoice or credit note in the active series of its
+// seller and inserts it with its line items in one transaction, so a failed
+// insert gives its number back and the series has no gaps. Sellers that never
+// had a series of the document type get one with defaultPrefix. Numbers are
+// unique per seller only, as sellers share prefixes such as the default CN of
+// credit notes. The InvoiceNumber of params and the InvoiceID of items are
+// filled in.
+func (s *Service) insertInvoice(
+	ctx context.Context,
+	companyID pgtype.UUID,
+	documentType, defaultPrefix string,
+	params db.CreateInvoiceParams,
+	items []db.CreateInvoiceLineItemParams,
+) (db.Invoice, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return db.Invoice{}, fmt.Errorf("invoice: failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	qtx := s.queries.WithTx(tx)
+
+	year := int32(recurrence.Today().Year())
+	if err := qtx.EnsureInvoiceSeries(ctx, db.EnsureInvoiceSeriesParams{
+		CompanyID:    companyID,
+		DocumentType: documentType,
+		Prefix:       defaultPrefix,
+		CurrentYear:  year,
+	}); err != nil {
+		return db.Invoice{}, fmt.Errorf("invoice: create default series: %w", err)
+	}
+	n, err := qtx.AllocateInvoiceNumber(ctx, db.AllocateInvoiceNumberParams{
+		CompanyID:    companyID,
+		DocumentType: documentType,
+		Year:         year,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return db.Invoice{}, fmt.Errorf("invoice: the seller has no active %s series", strings.ReplaceAll(documentType, "_", " "))
+	}
+	if err != nil {
+		return db.Invoice{}, fmt.Errorf("invoice: allocate number: %w", err)
+	}
+	params.InvoiceNumber = pgText(formatNumber(n.Prefix, n.CurrentYear, n.Number, n.YearlyReset))
+
+	inv, err := qtx.CreateInvoice(ctx, params)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return db.Invoice{}, errInvoiceExists
+	}
+	if err != nil {
+		return db.Invoice{}, fmt.Errorf("invoice: create invoice: %w", err)
+	}
+	for _, item := range items {
+		item.InvoiceID = inv.ID
+		if _, err := qtx.CreateInvoiceLineItem(ctx, item); err != nil {
+			return db.Invoice{}, fmt.Errorf("invoice: create line item: %w", err)
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return db.Invoice{}, fmt.Errorf("invoice: failed to commit invoice: %w", err)
+	}
+	return inv, nil
+}
+
+// formatNumber formats a document number: "{prefix}-{year}-{number:04d}" in
+// series that reset yearly, "{prefix}-{number:04d}" in the others.
+func formatNumber(prefix string, year, number int32, yearly bool) string {
+	if yearly {
+		return fmt.Sprintf("%s-%d-%04d", prefix, year, number)
+	}
+	return fmt.Sprintf("%s-%04d", prefix, number)
+}
+
+// validatePrefix checks that a series prefix is 1 to maxPrefixLen uppercase
+// letters and digits, which every invoicing program and e-Factura accept.
+func validatePrefix(prefix string) error {
+	if prefix == "" || len(prefix) > maxPrefixLen {
+		return fmt.Errorf("invoice: a series prefix has 1 to %d characters", maxPrefixLen)
+	}
+	for _, r := range prefix {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return fmt.Errorf("invoice: the series prefix %q may only contain letters and digits", prefix)
+		}
+	}
+	return nil
+}
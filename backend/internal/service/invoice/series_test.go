@@ -0,0 +1,48 @@
+package invoice
+
+import (
+	"testing"
+
+	db "helpmeclean-backend/internal/db/generated"
+	"helpmeclean-backend/internal/service/recurrence"
+)
+
+func TestFormatNumber(t *testing.T) {
+	if got := formatNumber("ABC", 2026, 42, true); got != "ABC-2026-0042" {
+		t.Errorf("yearly series: got %s", got)
+	}
+	if got := formatNumber("ABC", 2026, 12345, false); got != "ABC-12345" {
+		t.Errorf("continuous series: got %s", got)
+	}
+}
+
+func TestNextNumber(t *testing.T) {
+	year := int32(recurrence.Today().Year())
+	series := db.InvoiceSeries{Prefix: "CN", StartNumber: 100, NextNumber: 117, YearlyReset: true, CurrentYear: year}
+	if got, want := NextNumber(series), formatNumber("CN", year, 117, true); got != want {
+		t.Errorf("NextNumber() = %s, want %s", got, want)
+	}
+
+	// A yearly series last used in an earlier year starts again.
+	series.CurrentYear = year - 1
+	if got, want := NextNumber(series), formatNumber("CN", year, 100, true); got != want {
+		t.Errorf("NextNumber() in a new year = %s, want %s", got, want)
+	}
+	series.YearlyReset = false
+	if got := NextNumber(series); got != "CN-0117" {
+		t.Errorf("NextNumber() of a continuous series = %s", got)
+	}
+}
+
+func TestValidatePrefix(t *testing.T) {
+	for _, p := range []string{"A", "HMC", "CLC2026", "ABCDEFGHIJ"} {
+		if err := validatePrefix(p); err != nil {
+			t.Errorf("validatePrefix(%q) error: %v", p, err)
+		}
+	}
+	for _, p := range []string{"", "ABCDEFGHIJK", "AB-C", "abc", "ĂBC"} {
+		if err := validatePrefix(p); err == nil {
+			t.Errorf("validatePrefix(%q) succeeded", p)
+		}
+	}
+}
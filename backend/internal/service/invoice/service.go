@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"math"
 	"math/big"
 	"net/http"
 	"os"
@@ -17,13 +18,16 @@ import (
 	"time"
 
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
 
 	db "helpmeclean-backend/internal/db/generated"
+	"helpmeclean-backend/internal/service/anaf"
+	"helpmeclean-backend/internal/service/efactura"
+	"helpmeclean-backend/internal/service/email"
+	"helpmeclean-backend/internal/service/ledger"
+	"helpmeclean-backend/internal/storage"
 )
 
-// vatRatePct is the standard Romanian VAT rate (21%).
-const vatRatePct = 21
-
 // PlatformConfig holds the platform's legal entity details for commission invoices.
 type PlatformConfig struct {
 	CompanyName string
@@ -39,15 +43,23 @@ type PlatformConfig struct {
 
 // Service handles invoice generation, storage, and Factureaza.ro API integration.
 type Service struct {
+	pool           *pgxpool.Pool
 	queries        *db.Queries
+	ledger         *ledger.Service
 	apiBaseURL     string
 	apiKey         string
 	httpClient     *http.Client
 	platformConfig PlatformConfig
+	anaf           *efactura.Client
+	registry       *anaf.Registry
+	storage        storage.Storage
+	mailer         *email.Service
 }
 
 // NewService creates a new invoice service, reading configuration from environment variables.
-func NewService(queries *db.Queries) *Service {
+// Credit notes of refunds are posted to ledgerSvc. Responses of the ANAF SPV are kept in store; the VAT status of companies is
+// checked against registry. Invoices are emailed to buyers through mailer.
+func NewService(pool *pgxpool.Pool, queries *db.Queries, ledgerSvc *ledger.Service, store storage.Storage, registry *anaf.Registry, mailer *email.Service) *Service {
 	apiBaseURL := os.Getenv("FACTUREAZA_API_URL")
 	if apiBaseURL == "" {
 		apiBaseURL = "https://sandbox.factureaza.ro/api/v1"
@@ -56,11 +68,17 @@ func NewService(queries *db.Queries) *Service {
 	apiBaseURL = strings.TrimRight(apiBaseURL, "/")
 
 	svc := &Service{
+		pool:           pool,
 		queries:        queries,
+		ledger:         ledgerSvc,
 		apiBaseURL:     apiBaseURL,
 		apiKey:         os.Getenv("FACTUREAZA_API_KEY"),
 		httpClient:     &http.Client{Timeout: 30 * time.Second},
 		platformConfig: PlatformConfig{}, // Will be loaded from DB on first use
+		anaf:           efactura.NewClientFromEnv(),
+		registry:       registry,
+		storage:        store,
+		mailer:         mailer,
 	}
 
 	log.Println("Invoice service initialized")
@@ -107,34 +125,6 @@ func (s *Service) Ping(ctx context.Context) string {
 // Public API
 // ---------------------------------------------------------------------------
 
-// NewInvoiceNumber generates the next sequential invoice number for a given company
-// and prefix. The format is "{prefix}-{year}-{number:04d}", for example "HMC-2026-0001".
-func (s *Service) NewInvoiceNumber(ctx context.Context, companyID pgtype.UUID, prefix string) (string, error) {
-	year := int32(time.Now().Year())
-
-	// Ensure the sequence row exists (idempotent via ON CONFLICT DO NOTHING).
-	err := s.queries.CreateInvoiceSequence(ctx, db.CreateInvoiceSequenceParams{
-		CompanyID: companyID,
-		Prefix:    prefix,
-		Year:      year,
-	})
-	if err != nil {
-		return "", fmt.Errorf("invoice: create sequence: %w", err)
-	}
-
-	// Atomically increment and return the next number.
-	num, err := s.queries.GetNextInvoiceNumber(ctx, db.GetNextInvoiceNumberParams{
-		CompanyID: companyID,
-		Prefix:    prefix,
-		Year:      year,
-	})
-	if err != nil {
-		return "", fmt.Errorf("invoice: get next number: %w", err)
-	}
-
-	return fmt.Sprintf("%s-%d-%04d", prefix, year, num), nil
-}
-
 // GenerateClientServiceInvoice creates an invoice for a client service booking.
 // The seller is the cleaning company and the buyer is the client.
 func (s *Service) GenerateClientServiceInvoice(
@@ -153,25 +143,34 @@ func (s *Service) GenerateClientServiceInvoice(
 		return existing, nil
 	}
 
-	// Determine the total amount in bani. Prefer FinalTotal; fall back to EstimatedTotal.
-	totalBani := numericToInt32(booking.FinalTotal)
-	if totalBani == 0 {
-		totalBani = numericToInt32(booking.EstimatedTotal)
+	// Bill every component of the booking on its own line.
+	serviceNameRo, serviceNameEn := "Servicii curățenie", "Cleaning services"
+	if def, err := s.queries.GetServiceByType(ctx, booking.ServiceType); err == nil {
+		serviceNameRo, serviceNameEn = def.NameRo, def.NameEn
 	}
-	if totalBani == 0 {
+	extras, err := s.queries.ListBookingExtras(ctx, booking.ID)
+	if err != nil {
+		return db.Invoice{}, fmt.Errorf("invoice: list booking extras: %w", err)
+	}
+	charges := bookingCharges(booking, serviceNameRo, serviceNameEn, extras)
+	if len(charges) == 0 {
 		return db.Invoice{}, errors.New("invoice: booking has no total amount")
 	}
 
-	// Treat booking total as VAT-inclusive (21%): net = total * 100 / 121, vat = total - net.
-	subtotalNet := totalBani * 100 / 121
-	vatAmount := totalBani - subtotalNet
-
-	// Build a prefix from the company name (first 3 uppercase characters).
-	prefix := companyPrefix(company.CompanyName)
-	invoiceNumber, err := s.NewInvoiceNumber(ctx, company.ID, prefix)
-	if err != nil {
-		return db.Invoice{}, fmt.Errorf("invoice: generate number: %w", err)
+	// Booking prices are VAT-inclusive. Companies that are not registered
+	// for VAT invoice them without VAT; the others at the standard rate in
+	// force on the day of the service.
+	sellerIsVATPayer := s.companyIsVATPayer(ctx, company)
+	var ratePct int64
+	notes := fmt.Sprintf("Servicii curatenie - rezervare %s", booking.ReferenceCode)
+	if sellerIsVATPayer {
+		if ratePct, err = s.vatRatePct(ctx, VATStandard, supplyDate(booking)); err != nil {
+			return db.Invoice{}, err
+		}
+	} else {
+		notes += ". " + nonVATPayerMention
 	}
+	lines, subtotalNet, vatAmount := itemise(charges, ratePct)
 
 	// Attempt to load the client's billing profile for B2B details.
 	billingProfile, profileErr := s.queries.GetBillingProfileByUser(ctx, clientUserID)
@@ -182,16 +181,32 @@ func (s *Service) GenerateClientServiceInvoice(
 
 	dueDate := pgtype.Date{Time: time.Now().AddDate(0, 0, 30), Valid: true}
 
-	inv, err := s.queries.CreateInvoice(ctx, db.CreateInvoiceParams{
+	items := make([]db.CreateInvoiceLineItemParams, len(charges))
+	for i, c := range charges {
+		items[i] = db.CreateInvoiceLineItemParams{
+			DescriptionRo:    c.descRo,
+			DescriptionEn:    pgText(c.descEn),
+			Quantity:         numericFromHundredths(c.quantity),
+			UnitPrice:        int32(lines[i].unitPrice),
+			VatRate:          numericFromInt(int(ratePct)),
+			VatAmount:        int32(lines[i].vat),
+			LineTotal:        int32(lines[i].net),
+			LineTotalWithVat: int32(c.gross),
+			SortOrder:        pgtype.Int4{Int32: int32(i + 1), Valid: true},
+		}
+	}
+
+	// The company's first invoice opens a series prefixed with its name
+	// (first 3 uppercase characters).
+	inv, err := s.insertInvoice(ctx, company.ID, DocumentInvoice, companyPrefix(company.CompanyName), db.CreateInvoiceParams{
 		InvoiceType:          db.InvoiceTypeClientService,
-		InvoiceNumber:        pgText(invoiceNumber),
 		SellerCompanyName:    company.CompanyName,
 		SellerCui:            company.Cui,
 		SellerRegNumber:      pgtype.Text{},
 		SellerAddress:        company.Address,
 		SellerCity:           company.City,
 		SellerCounty:         company.County,
-		SellerIsVatPayer:     true,
+		SellerIsVatPayer:     sellerIsVATPayer,
 		SellerBankName:       pgtype.Text{},
 		SellerIban:           pgtype.Text{},
 		BuyerName:            buyerName,
@@ -202,10 +217,10 @@ func (s *Service) GenerateClientServiceInvoice(
 		BuyerCounty:          buyerCounty,
 		BuyerIsVatPayer:      buyerIsVATPayer,
 		BuyerEmail:           buyerEmail,
-		SubtotalAmount:       subtotalNet,
-		VatRate:              numericFromInt(vatRatePct),
-		VatAmount:            vatAmount,
-		TotalAmount:          totalBani,
+		SubtotalAmount:       int32(subtotalNet),
+		VatRate:              numericFromInt(int(ratePct)),
+		VatAmount:            int32(vatAmount),
+		TotalAmount:          int32(subtotalNet + vatAmount),
 		Currency:             "RON",
 		BookingID:            booking.ID,
 		PaymentTransactionID: pgtype.UUID{},
@@ -213,27 +228,19 @@ func (s *Service) GenerateClientServiceInvoice(
 		ClientUserID:         clientUserID,
 		Status:               db.InvoiceStatusIssued,
 		DueDate:              dueDate,
-		Notes:                pgText(fmt.Sprintf("Servicii curatenie - rezervare %s", booking.ReferenceCode)),
-	})
-	if err != nil {
-		return db.Invoice{}, fmt.Errorf("invoice: create client service invoice: %w", err)
-	}
-
-	// Create the line item.
-	_, err = s.queries.CreateInvoiceLineItem(ctx, db.CreateInvoiceLineItemParams{
-		InvoiceID:        inv.ID,
-		DescriptionRo:    fmt.Sprintf("Servicii curatenie - %s", booking.ReferenceCode),
-		DescriptionEn:    pgText(fmt.Sprintf("Cleaning services - %s", booking.ReferenceCode)),
-		Quantity:         numericFromInt(1),
-		UnitPrice:        subtotalNet,
-		VatRate:          numericFromInt(vatRatePct),
-		VatAmount:        vatAmount,
-		LineTotal:        subtotalNet,
-		LineTotalWithVat: totalBani,
-		SortOrder:        pgtype.Int4{Int32: 1, Valid: true},
-	})
+		Notes:                pgText(notes),
+	}, items)
+	if errors.Is(err, errInvoiceExists) {
+		// Issued concurrently since the guard above; the number taken for
+		// this one was given back.
+		log.Printf("invoice: client service invoice already exists for booking %s", uuidToString(booking.ID))
+		return s.queries.GetInvoiceByBookingAndType(ctx, db.GetInvoiceByBookingAndTypeParams{
+			BookingID:   booking.ID,
+			InvoiceType: db.InvoiceTypeClientService,
+		})
+	}
 	if err != nil {
-		return db.Invoice{}, fmt.Errorf("invoice: create line item: %w", err)
+		return db.Invoice{}, err
 	}
 
 	// Sync to Factureaza.ro (best-effort for MVP; do not fail the whole operation).
@@ -255,121 +262,37 @@ func (s *Service) GenerateClientServiceInvoice(
 		}
 	}
 
-	log.Printf("invoice: created client service invoice %s for booking %s", invoiceNumber, booking.ReferenceCode)
+	// Store the PDF, email it and transmit it to e-Factura as the company
+	// asks; failed steps are retried by the invoice-pipeline job.
+	s.startPipeline(ctx, &inv)
+
+	log.Printf("invoice: created client service invoice %s for booking %s", textVal(inv.InvoiceNumber), booking.ReferenceCode)
 	return inv, nil
 }
 
 // GenerateCommissionInvoice creates a platform commission invoice where the
 // platform (seller) invoices a cleaning company (buyer) for commission fees.
 // The amount parameter is the net commission in bani (without VAT). VAT is calculated on top.
+// The invoice is linked to the payout it covers, whose bookings it charged.
+//
+// Deprecated: commission is invoiced monthly by CloseCommissionMonth.
 func (s *Service) GenerateCommissionInvoice(
 	ctx context.Context,
 	companyID pgtype.UUID,
+	payoutID pgtype.UUID,
 	amount int32,
 	bookingCount int,
 	periodFrom string,
 	periodTo string,
 ) (db.Invoice, error) {
-	company, err := s.queries.GetCompanyByID(ctx, companyID)
-	if err != nil {
-		return db.Invoice{}, fmt.Errorf("invoice: get company: %w", err)
-	}
-
-	// Commission amount is the net (without VAT). Calculate VAT on top.
-	subtotalNet := amount
-	vatAmount := subtotalNet * vatRatePct / 100
-	totalAmount := subtotalNet + vatAmount
-
-	invoiceNumber, err := s.NewInvoiceNumber(ctx, pgtype.UUID{}, "HMC")
-	if err != nil {
-		return db.Invoice{}, fmt.Errorf("invoice: generate number: %w", err)
-	}
-
-	dueDate := pgtype.Date{Time: time.Now().AddDate(0, 0, 30), Valid: true}
-
-	// Load platform config from database
-	pc, err := s.loadPlatformConfig(ctx)
-	if err != nil {
-		return db.Invoice{}, fmt.Errorf("invoice: load platform config: %w", err)
-	}
-
-	inv, err := s.queries.CreateInvoice(ctx, db.CreateInvoiceParams{
-		InvoiceType:          db.InvoiceTypePlatformCommission,
-		InvoiceNumber:        pgText(invoiceNumber),
-		SellerCompanyName:    pc.CompanyName,
-		SellerCui:            pc.CUI,
-		SellerRegNumber:      pgText(pc.RegNumber),
-		SellerAddress:        pc.Address,
-		SellerCity:           pc.City,
-		SellerCounty:         pc.County,
-		SellerIsVatPayer:     pc.IsVATPayer,
-		SellerBankName:       pgText(pc.BankName),
-		SellerIban:           pgText(pc.IBAN),
-		BuyerName:            company.CompanyName,
-		BuyerCui:             pgText(company.Cui),
-		BuyerRegNumber:       pgtype.Text{},
-		BuyerAddress:         pgText(company.Address),
-		BuyerCity:            pgText(company.City),
-		BuyerCounty:          pgText(company.County),
-		BuyerIsVatPayer:      pgtype.Bool{Bool: true, Valid: true},
-		BuyerEmail:           pgText(company.ContactEmail),
-		SubtotalAmount:       subtotalNet,
-		VatRate:              numericFromInt(vatRatePct),
-		VatAmount:            vatAmount,
-		TotalAmount:          totalAmount,
-		Currency:             "RON",
-		BookingID:            pgtype.UUID{},
-		PaymentTransactionID: pgtype.UUID{},
-		CompanyID:            companyID,
-		ClientUserID:         pgtype.UUID{},
-		Status:               db.InvoiceStatusIssued,
-		DueDate:              dueDate,
-		Notes:                pgText(fmt.Sprintf("Comision platforma HelpMeClean - %s pana la %s", periodFrom, periodTo)),
+	return s.issueCommissionInvoice(ctx, commissionInvoice{
+		companyID:    companyID,
+		payoutID:     payoutID,
+		net:          int64(amount),
+		bookingCount: bookingCount,
+		periodFrom:   periodFrom,
+		periodTo:     periodTo,
 	})
-	if err != nil {
-		return db.Invoice{}, fmt.Errorf("invoice: create commission invoice: %w", err)
-	}
-
-	descRo := fmt.Sprintf("Comision platforma HelpMeClean - %d rezervari (%s - %s)", bookingCount, periodFrom, periodTo)
-	descEn := fmt.Sprintf("HelpMeClean platform commission - %d bookings (%s - %s)", bookingCount, periodFrom, periodTo)
-
-	_, err = s.queries.CreateInvoiceLineItem(ctx, db.CreateInvoiceLineItemParams{
-		InvoiceID:        inv.ID,
-		DescriptionRo:    descRo,
-		DescriptionEn:    pgText(descEn),
-		Quantity:         numericFromInt(1),
-		UnitPrice:        subtotalNet,
-		VatRate:          numericFromInt(vatRatePct),
-		VatAmount:        vatAmount,
-		LineTotal:        subtotalNet,
-		LineTotalWithVat: totalAmount,
-		SortOrder:        pgtype.Int4{Int32: 1, Valid: true},
-	})
-	if err != nil {
-		return db.Invoice{}, fmt.Errorf("invoice: create commission line item: %w", err)
-	}
-
-	// Sync to Factureaza.ro (best-effort).
-	lineItems, _ := s.queries.ListInvoiceLineItems(ctx, inv.ID)
-	factureazaID, downloadURL, apiErr := s.createInvoiceOnFactureaza(ctx, inv, lineItems)
-	if apiErr != nil {
-		log.Printf("invoice: factureaza.ro API error (non-fatal): %v", apiErr)
-	} else if factureazaID != "" {
-		updateErr := s.queries.UpdateInvoiceFactureaza(ctx, db.UpdateInvoiceFactureazaParams{
-			ID:                    inv.ID,
-			FactureazaID:          pgText(factureazaID),
-			FactureazaDownloadUrl: pgText(downloadURL),
-		})
-		if updateErr != nil {
-			log.Printf("invoice: failed to persist factureaza metadata: %v", updateErr)
-		} else {
-			inv.FactureazaID = pgText(factureazaID)
-			inv.FactureazaDownloadUrl = pgText(downloadURL)
-		}
-	}
-
-	log.Printf("invoice: created commission invoice %s for company %s", invoiceNumber, company.CompanyName)
-	return inv, nil
 }
 
 // CancelInvoice marks an invoice as cancelled, both locally and on Factureaza.ro.
@@ -403,13 +326,24 @@ func (s *Service) CancelInvoice(ctx context.Context, invoiceID pgtype.UUID) (db.
 	return updated, nil
 }
 
-// TransmitToEFactura triggers e-factura transmission via the Factureaza.ro API.
+// TransmitToEFactura transmits an invoice to e-factura. When the seller has
+// connected its ANAF account, the XML is uploaded directly to the ANAF SPV and
+// PollEFacturaStatus follows it up; otherwise the transmission goes through
+// the Factureaza.ro API.
 func (s *Service) TransmitToEFactura(ctx context.Context, invoiceID pgtype.UUID) error {
 	inv, err := s.queries.GetInvoiceByID(ctx, invoiceID)
 	if err != nil {
 		return fmt.Errorf("invoice: get invoice for e-factura: %w", err)
 	}
 
+	accessToken, err := s.anafAccessToken(ctx, inv.SellerCui)
+	if err == nil {
+		return s.uploadToSPV(ctx, inv, accessToken)
+	}
+	if !errors.Is(err, ErrANAFNotConnected) {
+		return err
+	}
+
 	if !inv.FactureazaID.Valid || inv.FactureazaID.String == "" {
 		return errors.New("invoice: cannot transmit to e-factura without a factureaza.ro ID")
 	}
@@ -445,92 +379,39 @@ func (s *Service) TransmitToEFactura(ctx context.Context, invoiceID pgtype.UUID)
 	return nil
 }
 
-// GenerateCreditNote creates a credit note (storno) referencing an original invoice.
-// The amount parameter is in bani and represents the credited total (VAT-inclusive).
-func (s *Service) GenerateCreditNote(ctx context.Context, invoiceID pgtype.UUID, amount int32, reason string) (db.Invoice, error) {
-	original, err := s.queries.GetInvoiceByID(ctx, invoiceID)
+// EFacturaXML renders an invoice as CIUS-RO UBL XML for e-Factura, without
+// going through Factureaza.ro.
+func (s *Service) EFacturaXML(ctx context.Context, invoiceID pgtype.UUID) ([]byte, error) {
+	inv, lineItems, err := s.loadDocument(ctx, invoiceID)
 	if err != nil {
-		return db.Invoice{}, fmt.Errorf("invoice: get original invoice for credit note: %w", err)
+		return nil, err
 	}
+	return efactura.Render(inv, lineItems)
+}
 
-	if amount <= 0 {
-		return db.Invoice{}, errors.New("invoice: credit note amount must be positive")
-	}
-
-	// Compute net and VAT from the credited total (VAT-inclusive).
-	creditTotal := amount
-	creditNet := creditTotal * 100 / 121
-	creditVAT := creditTotal - creditNet
-
-	// Use negative amounts to represent the credit.
-	negNet := -creditNet
-	negVAT := -creditVAT
-	negTotal := -creditTotal
-
-	// Generate a credit note number using the "CN" prefix.
-	invoiceNumber, err := s.NewInvoiceNumber(ctx, original.CompanyID, "CN")
+// loadDocument loads an invoice with its line items for rendering. Individual
+// buyers without a billing address are given the address of the booking the
+// invoice is for.
+func (s *Service) loadDocument(ctx context.Context, invoiceID pgtype.UUID) (db.Invoice, []db.InvoiceLineItem, error) {
+	inv, err := s.queries.GetInvoiceByID(ctx, invoiceID)
 	if err != nil {
-		return db.Invoice{}, fmt.Errorf("invoice: generate credit note number: %w", err)
+		return db.Invoice{}, nil, fmt.Errorf("invoice: get invoice for rendering: %w", err)
 	}
-
-	dueDate := pgtype.Date{Time: time.Now().AddDate(0, 0, 30), Valid: true}
-	notes := fmt.Sprintf("Nota de credit pentru factura %s - %s", textVal(original.InvoiceNumber), reason)
-
-	creditNote, err := s.queries.CreateInvoice(ctx, db.CreateInvoiceParams{
-		InvoiceType:          original.InvoiceType,
-		InvoiceNumber:        pgText(invoiceNumber),
-		SellerCompanyName:    original.SellerCompanyName,
-		SellerCui:            original.SellerCui,
-		SellerRegNumber:      original.SellerRegNumber,
-		SellerAddress:        original.SellerAddress,
-		SellerCity:           original.SellerCity,
-		SellerCounty:         original.SellerCounty,
-		SellerIsVatPayer:     original.SellerIsVatPayer,
-		SellerBankName:       original.SellerBankName,
-		SellerIban:           original.SellerIban,
-		BuyerName:            original.BuyerName,
-		BuyerCui:             original.BuyerCui,
-		BuyerRegNumber:       original.BuyerRegNumber,
-		BuyerAddress:         original.BuyerAddress,
-		BuyerCity:            original.BuyerCity,
-		BuyerCounty:          original.BuyerCounty,
-		BuyerIsVatPayer:      original.BuyerIsVatPayer,
-		BuyerEmail:           original.BuyerEmail,
-		SubtotalAmount:       negNet,
-		VatRate:              numericFromInt(vatRatePct),
-		VatAmount:            negVAT,
-		TotalAmount:          negTotal,
-		Currency:             original.Currency,
-		BookingID:            original.BookingID,
-		PaymentTransactionID: original.PaymentTransactionID,
-		CompanyID:            original.CompanyID,
-		ClientUserID:         original.ClientUserID,
-		Status:               db.InvoiceStatusCreditNote,
-		DueDate:              dueDate,
-		Notes:                pgText(notes),
-	})
-	if err != nil {
-		return db.Invoice{}, fmt.Errorf("invoice: create credit note: %w", err)
-	}
-
-	_, err = s.queries.CreateInvoiceLineItem(ctx, db.CreateInvoiceLineItemParams{
-		InvoiceID:        creditNote.ID,
-		DescriptionRo:    fmt.Sprintf("Stornare - %s", reason),
-		DescriptionEn:    pgText(fmt.Sprintf("Credit note - %s", reason)),
-		Quantity:         numericFromInt(1),
-		UnitPrice:        negNet,
-		VatRate:          numericFromInt(vatRatePct),
-		VatAmount:        negVAT,
-		LineTotal:        negNet,
-		LineTotalWithVat: negTotal,
-		SortOrder:        pgtype.Int4{Int32: 1, Valid: true},
-	})
+	lineItems, err := s.queries.ListInvoiceLineItems(ctx, inv.ID)
 	if err != nil {
-		return db.Invoice{}, fmt.Errorf("invoice: create credit note line item: %w", err)
+		return db.Invoice{}, nil, fmt.Errorf("invoice: list line items for rendering: %w", err)
 	}
 
-	log.Printf("invoice: created credit note %s for original invoice %s", invoiceNumber, textVal(original.InvoiceNumber))
-	return creditNote, nil
+	if textVal(inv.BuyerAddress) == "" && textVal(inv.BuyerCui) == "" && inv.BookingID.Valid {
+		if booking, err := s.queries.GetBookingByID(ctx, inv.BookingID); err == nil && booking.AddressID.Valid {
+			if addr, err := s.queries.GetAddressByID(ctx, booking.AddressID); err == nil {
+				inv.BuyerAddress = pgText(addr.StreetAddress)
+				inv.BuyerCity = pgText(addr.City)
+				inv.BuyerCounty = pgText(addr.County)
+			}
+		}
+	}
+	return inv, lineItems, nil
 }
 
 // ---------------------------------------------------------------------------
@@ -629,7 +510,7 @@ func (s *Service) createInvoiceOnFactureaza(ctx context.Context, inv db.Invoice,
 			Description: li.DescriptionRo,
 			Quantity:    numericToFloat64(li.Quantity),
 			UnitPrice:   baniToRON(li.UnitPrice),
-			VATRate:     vatRatePct,
+			VATRate:     int(math.Round(numericToFloat64(li.VatRate))),
 		})
 	}
 
@@ -639,7 +520,7 @@ func (s *Service) createInvoiceOnFactureaza(ctx context.Context, inv db.Invoice,
 			Description: "Servicii curatenie",
 			Quantity:    1,
 			UnitPrice:   baniToRON(inv.SubtotalAmount),
-			VATRate:     vatRatePct,
+			VATRate:     int(math.Round(numericToFloat64(inv.VatRate))),
 		})
 	}
 
@@ -797,19 +678,6 @@ func numericToFloat64(n pgtype.Numeric) float64 {
 	return f.Float64
 }
 
-// numericToInt32 extracts an int32 from a pgtype.Numeric, useful for reading
-// monetary amounts stored as numeric in the Booking model.
-func numericToInt32(n pgtype.Numeric) int32 {
-	if !n.Valid {
-		return 0
-	}
-	f, err := n.Float64Value()
-	if err != nil {
-		return 0
-	}
-	return int32(f.Float64)
-}
-
 // baniToRON converts an amount in bani (RON cents) to RON with 2 decimal places.
 func baniToRON(bani int32) float64 {
 	return float64(bani) / 100.0
@@ -0,0 +1,50 @@
+package invoice
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	db "helpmeclean-backend/internal/db/generated"
+)
+
+// vatStatusMaxAge is how long a company's VAT status from the ANAF registry
+// is trusted before it is checked again.
+const vatStatusMaxAge = 30 * 24 * time.Hour
+
+// nonVATPayerMention is printed on invoices of sellers that are not
+// registered for VAT.
+const nonVATPayerMention = "Furnizor neplătitor de TVA - regim special de scutire conform art. 310 din Legea nr. 227/2015 privind Codul fiscal."
+
+// companyIsVATPayer reports whether a company is registered for VAT. The
+// status comes from the ANAF registry and is cached on the company; when the
+// registry cannot be reached the last known status is used, and companies
+// never checked are assumed to pay VAT as an SRL and not to as a PFA or II.
+func (s *Service) companyIsVATPayer(ctx context.Context, company db.Company) bool {
+	if company.IsVatPayer.Valid && company.VatCheckedAt.Valid && time.Since(company.VatCheckedAt.Time) < vatStatusMaxAge {
+		return company.IsVatPayer.Bool
+	}
+
+	if s.registry != nil {
+		tp, err := s.registry.Lookup(ctx, company.Cui, time.Now())
+		if err == nil {
+			if err := s.queries.SetCompanyVATStatus(ctx, db.SetCompanyVATStatusParams{
+				ID:         company.ID,
+				IsVatPayer: pgtype.Bool{Bool: tp.VATPayer, Valid: true},
+			}); err != nil {
+				log.Printf("invoice: failed to store VAT status of company %s: %v", company.CompanyName, err)
+			}
+			return tp.VATPayer
+		}
+		log.Printf("invoice: VAT status lookup for company %s (CUI %s) failed: %v", company.CompanyName, company.Cui, err)
+	}
+
+	if company.IsVatPayer.Valid {
+		return company.IsVatPayer.Bool
+	}
+	vatPayer := company.CompanyType == db.CompanyTypeSrl
+	log.Printf("invoice: VAT status of company %s unknown, assuming vat payer=%t from company type %s", company.CompanyName, vatPayer, company.CompanyType)
+	return vatPayer
+}
@@ -0,0 +1,115 @@
+package invoice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	db "helpmeclean-backend/internal/db/generated"
+	"helpmeclean-backend/internal/service/recurrence"
+)
+
+// VAT rate categories.
+const (
+	VATStandard = "standard"
+	VATReduced  = "reduced"
+	VATExempt   = "exempt"
+)
+
+// VATRateInput describes a new VAT rate.
+type VATRateInput struct {
+	Category string
+	RatePct  int32
+	// ValidFrom is the calendar date the rate applies from, until the next
+	// rate of its category.
+	ValidFrom   time.Time
+	Description string
+}
+
+// ListVATRates returns the VAT rates of every category, latest first.
+func (s *Service) ListVATRates(ctx context.Context) ([]db.VatRate, error) {
+	rates, err := s.queries.ListVatRates(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("invoice: list VAT rates: %w", err)
+	}
+	return rates, nil
+}
+
+// AddVATRate records a rate change. Rates apply from today at the earliest:
+// the rate in force when a document was issued never changes after the fact.
+func (s *Service) AddVATRate(ctx context.Context, in VATRateInput) (db.VatRate, error) {
+	switch in.Category {
+	case VATStandard, VATReduced, VATExempt:
+	default:
+		return db.VatRate{}, fmt.Errorf("invoice: unknown VAT category %q", in.Category)
+	}
+	if in.RatePct < 0 || in.RatePct > 100 {
+		return db.VatRate{}, fmt.Errorf("invoice: invalid VAT rate %d%%", in.RatePct)
+	}
+	if in.Category == VATExempt && in.RatePct != 0 {
+		return db.VatRate{}, errors.New("invoice: exempt supplies have no VAT")
+	}
+	from := recurrence.Date(in.ValidFrom)
+	if from.Before(recurrence.Today()) {
+		return db.VatRate{}, errors.New("invoice: a VAT rate cannot apply from a past date")
+	}
+
+	rate, err := s.queries.CreateVatRate(ctx, db.CreateVatRateParams{
+		Category:    in.Category,
+		RatePct:     in.RatePct,
+		ValidFrom:   pgtype.Date{Time: from, Valid: true},
+		Description: pgText(in.Description),
+	})
+	if err != nil {
+		return db.VatRate{}, fmt.Errorf("invoice: create VAT rate: %w", err)
+	}
+	return rate, nil
+}
+
+// vatRatePct returns the rate of a category in force on date, a calendar date
+// in Romania.
+func (s *Service) vatRatePct(ctx context.Context, category string, date time.Time) (int64, error) {
+	pct, err := s.queries.GetVatRate(ctx, db.GetVatRateParams{
+		Category:  category,
+		ValidFrom: pgtype.Date{Time: date, Valid: true},
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, fmt.Errorf("invoice: no %s VAT rate in force on %s", category, date.Format("2006-01-02"))
+	}
+	if err != nil {
+		return 0, fmt.Errorf("invoice: get %s VAT rate: %w", category, err)
+	}
+	return int64(pct), nil
+}
+
+// creditRatePct returns the VAT rate credit notes of an invoice use: the rate
+// of the invoice, or none if its seller is not a VAT payer. Invoices stored
+// without a rate are credited at the standard rate in force when they were
+// issued.
+func (s *Service) creditRatePct(ctx context.Context, original db.Invoice) (int64, error) {
+	if !original.SellerIsVatPayer {
+		return 0, nil
+	}
+	if r := int64(math.Round(numericToFloat64(original.VatRate))); r > 0 {
+		return r, nil
+	}
+	issued := original.IssuedAt
+	if !issued.Valid {
+		issued = original.CreatedAt
+	}
+	return s.vatRatePct(ctx, VATStandard, recurrence.Date(issued.Time.In(recurrence.Location)))
+}
+
+// supplyDate returns the date a booking's service was supplied, in Romania:
+// the day the job was completed, or the day it was scheduled for.
+func supplyDate(b db.Booking) time.Time {
+	if b.CompletedAt.Valid {
+		return recurrence.Date(b.CompletedAt.Time.In(recurrence.Location))
+	}
+	return b.ScheduledDate.Time
+}
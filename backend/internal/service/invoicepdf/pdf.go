@@ -0,0 +1,222 @@
+package invoicepdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// A4 page size in points.
+const (
+	pageWidth  = 595.28
+	pageHeight = 841.89
+)
+
+// Codes 128-133 are remapped to the Romanian letters missing from
+// WinAnsiEncoding; the standard Helvetica fonts have these glyphs.
+const encodingDifferences = "[128 /Abreve /abreve /Scommaaccent /scommaaccent /Tcommaaccent /tcommaaccent]"
+
+var romanianCodes = map[rune]byte{
+	'Ă': 128, 'ă': 129,
+	'Ș': 130, 'ș': 131, 'Ş': 130, 'ş': 131,
+	'Ț': 132, 'ț': 133, 'Ţ': 132, 'ţ': 133,
+}
+
+// Typographic characters outside Latin-1 that have a plain substitute.
+var substitutes = map[rune]string{
+	'‘': "'", '’': "'", '‚': ",", '“': `"`, '”': `"`, '„': `"`,
+	'–': "-", '—': "-", '…': "...", '€': "EUR",
+}
+
+// document is a minimal PDF 1.4 writer: A4 pages of text, lines and filled
+// rectangles in the standard Helvetica fonts, which every viewer has, so no
+// font needs to be embedded. Coordinates are in points from the top left.
+type document struct {
+	title string
+	pages []*bytes.Buffer
+	page  *bytes.Buffer
+}
+
+func newDocument(title string) *document {
+	d := &document{title: title}
+	d.addPage()
+	return d
+}
+
+func (d *document) addPage() {
+	d.page = new(bytes.Buffer)
+	d.pages = append(d.pages, d.page)
+}
+
+// text draws s with its baseline at y.
+func (d *document) text(x, y, size float64, bold bool, s string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(d.page, "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, pageHeight-y, escape(encode(s)))
+}
+
+// textRight draws s ending at x.
+func (d *document) textRight(x, y, size float64, bold bool, s string) {
+	d.text(x-textWidth(s, size, bold), y, size, bold, s)
+}
+
+func (d *document) line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(d.page, "%.2f w %.2f %.2f m %.2f %.2f l S\n", width, x1, pageHeight-y1, x2, pageHeight-y2)
+}
+
+// fillRect fills a rectangle with a shade of gray (0 black, 1 white).
+func (d *document) fillRect(x, y, w, h, gray float64) {
+	fmt.Fprintf(d.page, "%.2f g %.2f %.2f %.2f %.2f re f 0 g\n", gray, x, pageHeight-y-h, w, h)
+}
+
+// bytes serialises the document.
+func (d *document) bytes() []byte {
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Objects 1-5 are fixed; each page is followed by its content stream.
+	const firstPage = 6
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding 5 0 R >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding 5 0 R >>")
+	object("<< /Type /Encoding /BaseEncoding /WinAnsiEncoding /Differences " + encodingDifferences + " >>")
+	for i, content := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] "+
+			"/Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, firstPage+2*i+1))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.Bytes()))
+	}
+	object("<< /Title (" + escape(encode(d.title)) + ") /Producer (HelpMeClean) >>")
+	info := len(offsets)
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, info, xref)
+	return out.Bytes()
+}
+
+// encode converts s to the font encoding. Characters the fonts cannot show
+// become '?'.
+func encode(s string) []byte {
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r < 0x80:
+			out = append(out, byte(r))
+		case romanianCodes[r] != 0:
+			out = append(out, romanianCodes[r])
+		case substitutes[r] != "":
+			out = append(out, substitutes[r]...)
+		case r >= 0xA0 && r <= 0xFF:
+			out = append(out, byte(r))
+		default:
+			out = append(out, '?')
+		}
+	}
+	return out
+}
+
+func escape(b []byte) string {
+	var s strings.Builder
+	for _, c := range b {
+		switch c {
+		case '(', ')', '\\':
+			s.WriteByte('\\')
+			s.WriteByte(c)
+		case '\n', '\r', '\t':
+			s.WriteByte(' ')
+		default:
+			s.WriteByte(c)
+		}
+	}
+	return s.String()
+}
+
+// textWidth returns the width of s in points.
+func textWidth(s string, size float64, bold bool) float64 {
+	widths := helvetica
+	if bold {
+		widths = helveticaBold
+	}
+	total := 0
+	for _, c := range encode(s) {
+		switch {
+		case c >= 32 && c < 127:
+			total += widths[c-32]
+		case c >= 128 && c <= 133:
+			// The Romanian letters are as wide as their base letters.
+			total += widths["AaSsTt"[c-128]-32]
+		default:
+			total += widths['o'-32]
+		}
+	}
+	return float64(total) * size / 1000
+}
+
+// wrap splits s into lines no wider than width.
+func wrap(s string, width, size float64, bold bool) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(s) {
+		if line != "" && textWidth(line+" "+word, size, bold) <= width {
+			line += " " + word
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+		line = word
+		// A word wider than the column is broken anywhere.
+		for textWidth(line, size, bold) > width && utf8.RuneCountInString(line) > 1 {
+			_, first := utf8.DecodeRuneInString(line)
+			cut := len(line)
+			for cut > first && textWidth(line[:cut], size, bold) > width {
+				_, n := utf8.DecodeLastRuneInString(line[:cut])
+				cut -= n
+			}
+			lines = append(lines, line[:cut])
+			line = line[cut:]
+		}
+	}
+	if line != "" || len(lines) == 0 {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// Advance widths of the printable ASCII characters (32-126) in the standard
+// Helvetica fonts, in thousandths of the font size.
+var helvetica = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+var helveticaBold = [95]int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
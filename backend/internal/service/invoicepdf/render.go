@@ -0,0 +1,427 @@
+// Package invoicepdf renders invoices and credit notes as PDF documents
+// carrying the mentions required by the Romanian Fiscal Code (art. 319), so
+// that every invoice has a document independently of any invoicing provider.
+package invoicepdf
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	db "helpmeclean-backend/internal/db/generated"
+	"helpmeclean-backend/internal/service/recurrence"
+)
+
+// Page layout, in points.
+const (
+	marginLeft   = 40.0
+	marginRight  = pageWidth - 40
+	marginTop    = 50.0
+	bodyBottom   = pageHeight - 70
+	footerY      = pageHeight - 40
+	bodySize     = 9.0
+	smallSize    = 7.5
+	lineHeight   = 12.0
+	columnGap    = 20.0
+	partyWidth   = (marginRight - marginLeft - columnGap) / 2
+	descWidth    = 185.0
+	tableRowPad  = 4.0
+	tableRowLine = 10.0
+)
+
+// Table columns: the left edge of the text columns and the right edge of the
+// numeric ones.
+var (
+	colNumber   = marginLeft + 4
+	colDesc     = marginLeft + 26
+	colUnit     = colDesc + descWidth + 6
+	colQuantity = colUnit + 62
+	colPrice    = colQuantity + 62
+	colValue    = colPrice + 62
+	colVATRate  = colValue + 42
+	colVAT      = marginRight - 4
+)
+
+// Render returns the PDF of an invoice and its line items. Credit notes
+// (status credit_note or a negative total) are titled as such, name the
+// invoice they correct and show the credited amounts as negative. platform is
+// the legal entity operating the platform the invoice was issued through.
+func Render(inv db.Invoice, items []db.InvoiceLineItem, platform db.PlatformLegalEntity) ([]byte, error) {
+	number := textVal(inv.InvoiceNumber)
+	if number == "" {
+		return nil, errors.New("invoicepdf: invoice has no number")
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("invoicepdf: invoice %s has no line items", number)
+	}
+
+	title := "FACTURĂ"
+	if inv.Status == db.InvoiceStatusCreditNote || inv.TotalAmount < 0 {
+		title = "NOTĂ DE CREDIT"
+	}
+	series, seq := splitNumber(number)
+
+	r := &renderer{doc: newDocument(asciiTitle(title) + " " + number), inv: inv}
+	r.header(title, series, seq)
+	r.parties()
+	r.table(items)
+	r.totals(items)
+	r.notes()
+	r.footers(platform)
+	return r.doc.bytes(), nil
+}
+
+type renderer struct {
+	doc *document
+	inv db.Invoice
+	y   float64
+}
+
+func (r *renderer) header(title, series, seq string) {
+	d, inv := r.doc, r.inv
+	d.text(marginLeft, marginTop+8, 20, true, title)
+
+	y := marginTop
+	right := func(label, value string) {
+		d.textRight(marginRight-80, y, bodySize, false, label)
+		d.textRight(marginRight, y, bodySize, true, value)
+		y += lineHeight
+	}
+	if series != "" {
+		right("Seria:", series)
+	}
+	right("Nr.:", seq)
+	right("Data emiterii:", issueDate(inv))
+	if ref := textVal(inv.CreditedInvoiceNumber); ref != "" {
+		right("Stornează factura:", ref)
+		if inv.CreditedInvoiceDate.Valid {
+			right("Din data:", inv.CreditedInvoiceDate.Time.Format("02.01.2006"))
+		}
+	}
+	if inv.DueDate.Valid {
+		right("Data scadenței:", inv.DueDate.Time.Format("02.01.2006"))
+	}
+	right("Moneda:", inv.Currency)
+
+	r.y = y + 10
+	d.line(marginLeft, r.y, marginRight, r.y, 0.8)
+	r.y += 18
+}
+
+func (r *renderer) parties() {
+	inv := r.inv
+
+	seller := []string{inv.SellerCompanyName}
+	seller = append(seller, "CIF: "+fiscalCode(inv.SellerCui, inv.SellerIsVatPayer))
+	if reg := textVal(inv.SellerRegNumber); reg != "" {
+		seller = append(seller, "Nr. Reg. Com.: "+reg)
+	}
+	seller = append(seller, address(inv.SellerAddress, inv.SellerCity, inv.SellerCounty)...)
+	if bank := textVal(inv.SellerBankName); bank != "" {
+		seller = append(seller, "Banca: "+bank)
+	}
+	if iban := textVal(inv.SellerIban); iban != "" {
+		seller = append(seller, "IBAN: "+iban)
+	}
+	if !inv.SellerIsVatPayer {
+		seller = append(seller, "Neplătitor de TVA")
+	}
+
+	buyer := []string{inv.BuyerName}
+	if cui := textVal(inv.BuyerCui); cui != "" {
+		buyer = append(buyer, "CIF: "+fiscalCode(cui, inv.BuyerIsVatPayer.Bool))
+	}
+	if reg := textVal(inv.BuyerRegNumber); reg != "" {
+		buyer = append(buyer, "Nr. Reg. Com.: "+reg)
+	}
+	buyer = append(buyer, address(textVal(inv.BuyerAddress), textVal(inv.BuyerCity), textVal(inv.BuyerCounty))...)
+	if email := textVal(inv.BuyerEmail); email != "" {
+		buyer = append(buyer, "E-mail: "+email)
+	}
+
+	top := r.y
+	left := r.party(marginLeft, top, "Furnizor", seller)
+	right := r.party(marginLeft+partyWidth+columnGap, top, "Cumpărător", buyer)
+	r.y = math.Max(left, right) + 16
+}
+
+// party draws a block of party details and returns the y below it.
+func (r *renderer) party(x, y float64, label string, lines []string) float64 {
+	d := r.doc
+	d.text(x, y, smallSize, true, strings.ToUpper(label))
+	y += lineHeight + 2
+	for i, line := range lines {
+		for _, l := range wrap(line, partyWidth, bodySize, i == 0) {
+			d.text(x, y, bodySize, i == 0, l)
+			y += lineHeight
+		}
+	}
+	return y
+}
+
+func (r *renderer) table(items []db.InvoiceLineItem) {
+	r.tableHeader()
+	for i, li := range items {
+		desc := wrap(li.DescriptionRo, descWidth, bodySize, false)
+		height := float64(len(desc))*tableRowLine + 2*tableRowPad
+		if r.y+height > bodyBottom {
+			r.doc.addPage()
+			r.y = marginTop
+			r.tableHeader()
+		}
+
+		d, y := r.doc, r.y+tableRowPad+8
+		d.text(colNumber, y, bodySize, false, strconv.Itoa(i+1))
+		for j, l := range desc {
+			d.text(colDesc, y+float64(j)*tableRowLine, bodySize, false, l)
+		}
+		d.text(colUnit, y, bodySize, false, "buc")
+		d.textRight(colQuantity, y, bodySize, false, formatDecimal(li.Quantity))
+		d.textRight(colPrice, y, bodySize, false, formatBani(int64(li.UnitPrice)))
+		d.textRight(colValue, y, bodySize, false, formatBani(int64(li.LineTotal)))
+		d.textRight(colVATRate, y, bodySize, false, r.vatRate(li.VatRate))
+		d.textRight(colVAT, y, bodySize, false, formatBani(int64(li.VatAmount)))
+
+		r.y += height
+		d.line(marginLeft, r.y, marginRight, r.y, 0.3)
+	}
+	r.y += 14
+}
+
+func (r *renderer) tableHeader() {
+	d := r.doc
+	d.fillRect(marginLeft, r.y, marginRight-marginLeft, 26, 0.9)
+	first, second := r.y+11, r.y+21
+	d.text(colNumber, first, smallSize, true, "Nr.")
+	d.text(colNumber, second, smallSize, true, "crt.")
+	d.text(colDesc, first, smallSize, true, "Denumirea produselor")
+	d.text(colDesc, second, smallSize, true, "sau a serviciilor")
+	d.text(colUnit, first, smallSize, true, "U.M.")
+	d.textRight(colQuantity, first, smallSize, true, "Cantitatea")
+	d.textRight(colPrice, first, smallSize, true, "Preț unitar")
+	d.textRight(colPrice, second, smallSize, true, "(fără TVA)")
+	d.textRight(colValue, first, smallSize, true, "Valoarea")
+	d.textRight(colValue, second, smallSize, true, "(fără TVA)")
+	d.textRight(colVATRate, first, smallSize, true, "Cota")
+	d.textRight(colVATRate, second, smallSize, true, "TVA")
+	d.textRight(colVAT, first, smallSize, true, "Valoarea")
+	d.textRight(colVAT, second, smallSize, true, "TVA")
+	r.y += 26
+}
+
+// vatRate shows the VAT rate of a line, or "-" when the seller does not
+// charge VAT.
+func (r *renderer) vatRate(rate pgtype.Numeric) string {
+	if !r.inv.SellerIsVatPayer {
+		return "-"
+	}
+	return formatDecimal(rate) + "%"
+}
+
+func (r *renderer) totals(items []db.InvoiceLineItem) {
+	inv := r.inv
+
+	// The VAT breakdown per rate, then the totals and the amount in words.
+	var rows [][2]string
+	if inv.SellerIsVatPayer {
+		for _, b := range vatBreakdown(items) {
+			rows = append(rows, [2]string{
+				fmt.Sprintf("Bază impozabilă cota %s%%:", b.rate),
+				formatBani(b.base) + " " + inv.Currency,
+			}, [2]string{
+				fmt.Sprintf("TVA cota %s%%:", b.rate),
+				formatBani(b.vat) + " " + inv.Currency,
+			})
+		}
+	}
+	rows = append(rows,
+		[2]string{"Total fără TVA:", formatBani(int64(inv.SubtotalAmount)) + " " + inv.Currency},
+		[2]string{"Total TVA:", formatBani(int64(inv.VatAmount)) + " " + inv.Currency},
+	)
+	words := wrap("Suma în litere: "+AmountInWords(int64(inv.TotalAmount)), marginRight-marginLeft, bodySize, false)
+
+	needed := float64(len(rows))*lineHeight + 26 + float64(len(words))*lineHeight
+	if r.y+needed > bodyBottom {
+		r.doc.addPage()
+		r.y = marginTop
+	}
+
+	d := r.doc
+	labelX := marginRight - 110
+	for _, row := range rows {
+		d.textRight(labelX, r.y, bodySize, false, row[0])
+		d.textRight(marginRight, r.y, bodySize, false, row[1])
+		r.y += lineHeight
+	}
+	r.y += 2
+	d.line(labelX-120, r.y-8, marginRight, r.y-8, 0.8)
+	r.y += 4
+	d.textRight(labelX, r.y, 11, true, "TOTAL DE PLATĂ:")
+	d.textRight(marginRight, r.y, 11, true, formatBani(int64(inv.TotalAmount))+" "+inv.Currency)
+	r.y += 20
+
+	for _, l := range words {
+		d.text(marginLeft, r.y, bodySize, false, l)
+		r.y += lineHeight
+	}
+	r.y += 8
+}
+
+func (r *renderer) notes() {
+	notes := textVal(r.inv.Notes)
+	if notes == "" {
+		return
+	}
+	lines := wrap(notes, marginRight-marginLeft, bodySize, false)
+	if r.y+float64(len(lines)+1)*lineHeight > bodyBottom {
+		r.doc.addPage()
+		r.y = marginTop
+	}
+	r.doc.text(marginLeft, r.y, smallSize, true, "MENȚIUNI")
+	r.y += lineHeight
+	for _, l := range lines {
+		r.doc.text(marginLeft, r.y, bodySize, false, l)
+		r.y += lineHeight
+	}
+}
+
+// footers adds the legal mention, the platform and the page number to every
+// page.
+func (r *renderer) footers(platform db.PlatformLegalEntity) {
+	issuedVia := "Document emis prin platforma HelpMeClean"
+	if platform.CompanyName != "" {
+		issuedVia += fmt.Sprintf(", operată de %s (CIF %s)", platform.CompanyName, fiscalCode(platform.Cui, platform.IsVatPayer))
+	}
+	const mention = "Factura circulă fără semnătură și ștampilă, conform art. 319 alin. (29) din Legea nr. 227/2015 privind Codul fiscal."
+
+	total := len(r.doc.pages)
+	for i, page := range r.doc.pages {
+		r.doc.page = page
+		r.doc.line(marginLeft, footerY-20, marginRight, footerY-20, 0.3)
+		r.doc.text(marginLeft, footerY-8, smallSize, false, mention)
+		r.doc.text(marginLeft, footerY+2, smallSize, false, issuedVia)
+		r.doc.textRight(marginRight, footerY+2, smallSize, false, fmt.Sprintf("Pagina %d din %d", i+1, total))
+	}
+}
+
+type vatGroup struct {
+	rate      string
+	base, vat int64
+}
+
+// vatBreakdown sums the line items per VAT rate, highest rate first.
+func vatBreakdown(items []db.InvoiceLineItem) []vatGroup {
+	byRate := map[string]*vatGroup{}
+	var groups []*vatGroup
+	for _, li := range items {
+		rate := formatDecimal(li.VatRate)
+		g, ok := byRate[rate]
+		if !ok {
+			g = &vatGroup{rate: rate}
+			byRate[rate] = g
+			groups = append(groups, g)
+		}
+		g.base += int64(li.LineTotal)
+		g.vat += int64(li.VatAmount)
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		a, _ := strconv.ParseFloat(strings.Replace(groups[i].rate, ",", ".", 1), 64)
+		b, _ := strconv.ParseFloat(strings.Replace(groups[j].rate, ",", ".", 1), 64)
+		return a > b
+	})
+	out := make([]vatGroup, len(groups))
+	for i, g := range groups {
+		out[i] = *g
+	}
+	return out
+}
+
+// splitNumber splits an invoice number such as "HMC-2026-0001" into its
+// series ("HMC-2026") and sequence number ("0001").
+func splitNumber(number string) (series, seq string) {
+	i := strings.LastIndex(number, "-")
+	if i < 0 {
+		return "", number
+	}
+	return number[:i], number[i+1:]
+}
+
+// fiscalCode returns a CIF with the "RO" prefix for VAT payers.
+func fiscalCode(cui string, vatPayer bool) string {
+	cui = strings.TrimPrefix(strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(cui), " ", "")), "RO")
+	if vatPayer {
+		return "RO" + cui
+	}
+	return cui
+}
+
+func address(street, city, county string) []string {
+	var lines []string
+	if street != "" {
+		lines = append(lines, street)
+	}
+	var place []string
+	if city != "" {
+		place = append(place, city)
+	}
+	if county != "" && county != city {
+		place = append(place, "jud. "+county)
+	}
+	if len(place) > 0 {
+		lines = append(lines, strings.Join(place, ", "))
+	}
+	return lines
+}
+
+func issueDate(inv db.Invoice) string {
+	t := inv.CreatedAt.Time
+	if inv.IssuedAt.Valid {
+		t = inv.IssuedAt.Time
+	}
+	return t.In(recurrence.Location).Format("02.01.2006")
+}
+
+// formatBani formats an amount in bani the Romanian way, e.g. "-1.234,50".
+func formatBani(bani int64) string {
+	sign := ""
+	if bani < 0 {
+		sign = "-"
+		bani = -bani
+	}
+	lei := strconv.FormatInt(bani/100, 10)
+	var grouped []string
+	for len(lei) > 3 {
+		grouped = append([]string{lei[len(lei)-3:]}, grouped...)
+		lei = lei[:len(lei)-3]
+	}
+	grouped = append([]string{lei}, grouped...)
+	return fmt.Sprintf("%s%s,%02d", sign, strings.Join(grouped, "."), bani%100)
+}
+
+// formatDecimal formats a quantity or rate with a decimal comma and without
+// trailing zeros, e.g. "1" or "2,5".
+func formatDecimal(n pgtype.Numeric) string {
+	f, err := n.Float64Value()
+	if err != nil || !f.Valid {
+		return "0"
+	}
+	return strings.Replace(strconv.FormatFloat(f.Float64, 'f', -1, 64), ".", ",", 1)
+}
+
+// asciiTitle drops the diacritics of the document titles, for the PDF
+// metadata which uses a different encoding.
+func asciiTitle(s string) string {
+	return strings.NewReplacer("Ă", "A", "Ț", "T").Replace(s)
+}
+
+func textVal(t pgtype.Text) string {
+	if !t.Valid {
+		return ""
+	}
+	return t.String
+}
@@ -0,0 +1,196 @@
+package invoicepdf
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	db "helpmeclean-backend/internal/db/generated"
+)
+
+func text(s string) pgtype.Text { return pgtype.Text{String: s, Valid: true} }
+
+func numeric(n int64) pgtype.Numeric { return pgtype.Numeric{Int: big.NewInt(n), Valid: true} }
+
+func sampleInvoice() (db.Invoice, []db.InvoiceLineItem) {
+	inv := db.Invoice{
+		InvoiceType:       db.InvoiceTypeClientService,
+		InvoiceNumber:     text("CLE-2026-000042"),
+		SellerCompanyName: "Clean Cluj SRL",
+		SellerCui:         "12345678",
+		SellerRegNumber:   text("J12/345/2020"),
+		SellerAddress:     "Str. Memorandumului 10",
+		SellerCity:        "Cluj-Napoca",
+		SellerCounty:      "Cluj",
+		SellerIsVatPayer:  true,
+		SellerIban:        text("RO49AAAA1B31007593840000"),
+		BuyerName:         "Ion Popescu",
+		BuyerAddress:      text("Str. Horea 3, ap. 4"),
+		BuyerCity:         text("Cluj-Napoca"),
+		BuyerCounty:       text("Cluj"),
+		SubtotalAmount:    20000,
+		VatRate:           numeric(21),
+		VatAmount:         4200,
+		TotalAmount:       24200,
+		Currency:          "RON",
+		Status:            db.InvoiceStatusIssued,
+		IssuedAt:          pgtype.Timestamptz{Time: time.Date(2026, 3, 31, 22, 30, 0, 0, time.UTC), Valid: true},
+		DueDate:           pgtype.Date{Time: time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC), Valid: true},
+		Notes:             text("Servicii curățenie - rezervare HMC-1234"),
+	}
+	items := []db.InvoiceLineItem{
+		{DescriptionRo: "Curățenie generală", Quantity: numeric(1), UnitPrice: 15000, VatRate: numeric(21), VatAmount: 3150, LineTotal: 15000, LineTotalWithVat: 18150},
+		{DescriptionRo: "Curățare geamuri", Quantity: numeric(2), UnitPrice: 2500, VatRate: numeric(21), VatAmount: 1050, LineTotal: 5000, LineTotalWithVat: 6050},
+	}
+	return inv, items
+}
+
+var platform = db.PlatformLegalEntity{CompanyName: "HelpMeClean SRL", Cui: "87654321", IsVatPayer: true}
+
+// checkStructure verifies the cross-reference table of a PDF and returns
+// its page count.
+func checkStructure(t *testing.T, pdf []byte) int {
+	t.Helper()
+	if !bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(pdf, []byte("%%EOF\n")) {
+		t.Fatal("missing PDF header or trailer")
+	}
+	m := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(pdf)
+	if m == nil {
+		t.Fatal("missing startxref")
+	}
+	xref, _ := strconv.Atoi(string(m[1]))
+	if !bytes.HasPrefix(pdf[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref %d does not point at the xref table", xref)
+	}
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(pdf[xref:], -1)
+	for i, e := range entries {
+		off, _ := strconv.Atoi(string(e[1]))
+		if want := fmt.Sprintf("%d 0 obj\n", i+1); !bytes.HasPrefix(pdf[off:], []byte(want)) {
+			t.Errorf("xref entry %d points at %q", i+1, pdf[off:off+10])
+		}
+	}
+	for _, s := range regexp.MustCompile(`/Length (\d+) >>\nstream\n`).FindAllSubmatchIndex(pdf, -1) {
+		n, _ := strconv.Atoi(string(pdf[s[2]:s[3]]))
+		if !bytes.HasPrefix(pdf[s[1]+n:], []byte("endstream")) {
+			t.Errorf("stream at %d has a wrong length", s[0])
+		}
+	}
+	count := regexp.MustCompile(`/Count (\d+)`).FindSubmatch(pdf)
+	pages, _ := strconv.Atoi(string(count[1]))
+	return pages
+}
+
+// shows reports whether the PDF draws s as one string.
+func shows(pdf []byte, s string) bool {
+	return bytes.Contains(pdf, []byte("("+escape(encode(s))+") Tj"))
+}
+
+func TestRenderInvoice(t *testing.T) {
+	inv, items := sampleInvoice()
+	pdf, err := Render(inv, items, platform)
+	if err != nil {
+		t.Fatalf("Render() error: %v", err)
+	}
+	if pages := checkStructure(t, pdf); pages != 1 {
+		t.Errorf("pages = %d, want 1", pages)
+	}
+
+	for _, want := range []string{
+		"FACTURĂ",
+		"CLE-2026", "000042",
+		"01.04.2026", // Romanian time
+		"01.05.2026",
+		"Clean Cluj SRL", "CIF: RO12345678", "Nr. Reg. Com.: J12/345/2020", "IBAN: RO49AAAA1B31007593840000",
+		"Ion Popescu", "Cluj-Napoca, jud. Cluj",
+		"Curățenie generală", "150,00", "2",
+		"Bază impozabilă cota 21%:", "TVA cota 21%:", "42,00 RON",
+		"242,00 RON",
+		"Suma în litere: două sute patruzeci și doi de lei",
+		"Servicii curățenie - rezervare HMC-1234",
+		"Document emis prin platforma HelpMeClean, operată de HelpMeClean SRL (CIF RO87654321)",
+		"Pagina 1 din 1",
+	} {
+		if !shows(pdf, want) {
+			t.Errorf("PDF does not show %q", want)
+		}
+	}
+	if shows(pdf, "Neplătitor de TVA") {
+		t.Error("VAT payer marked as not paying VAT")
+	}
+}
+
+func TestRenderCreditNoteAndNonVATPayer(t *testing.T) {
+	inv, items := sampleInvoice()
+	inv.Status = db.InvoiceStatusCreditNote
+	inv.SellerIsVatPayer = false
+	inv.SubtotalAmount, inv.VatAmount, inv.TotalAmount = -20000, 0, -20000
+	items = items[:1]
+	items[0].UnitPrice, items[0].LineTotal, items[0].VatAmount, items[0].LineTotalWithVat = -20000, -20000, 0, -20000
+
+	pdf, err := Render(inv, items, platform)
+	if err != nil {
+		t.Fatalf("Render() error: %v", err)
+	}
+	checkStructure(t, pdf)
+	for _, want := range []string{"NOTĂ DE CREDIT", "CIF: 12345678", "Neplătitor de TVA", "-200,00 RON", "Suma în litere: minus două sute de lei"} {
+		if !shows(pdf, want) {
+			t.Errorf("PDF does not show %q", want)
+		}
+	}
+	if shows(pdf, "Bază impozabilă cota 21%:") {
+		t.Error("non-payer shows a VAT breakdown")
+	}
+}
+
+func TestRenderPaginates(t *testing.T) {
+	inv, items := sampleInvoice()
+	for i := 0; i < 60; i++ {
+		items = append(items, db.InvoiceLineItem{
+			DescriptionRo: fmt.Sprintf("Ședința %d de curățenie recurentă, cu o descriere suficient de lungă pentru a fi împărțită pe două rânduri", i+1),
+			Quantity:      numeric(1), UnitPrice: 100, VatRate: numeric(21), VatAmount: 21, LineTotal: 100, LineTotalWithVat: 121,
+		})
+	}
+	pdf, err := Render(inv, items, db.PlatformLegalEntity{})
+	if err != nil {
+		t.Fatalf("Render() error: %v", err)
+	}
+	pages := checkStructure(t, pdf)
+	if pages < 3 {
+		t.Fatalf("pages = %d, want at least 3", pages)
+	}
+	if !shows(pdf, fmt.Sprintf("Pagina %d din %d", pages, pages)) || !shows(pdf, "Document emis prin platforma HelpMeClean") {
+		t.Error("missing footer on the last page")
+	}
+}
+
+func TestRenderRequiresNumberAndItems(t *testing.T) {
+	inv, items := sampleInvoice()
+	if _, err := Render(inv, nil, platform); err == nil {
+		t.Error("Render() without line items succeeded")
+	}
+	inv.InvoiceNumber = pgtype.Text{}
+	if _, err := Render(inv, items, platform); err == nil {
+		t.Error("Render() without a number succeeded")
+	}
+}
+
+func TestWrap(t *testing.T) {
+	lines := wrap("Curățenie generală apartament 3 camere "+string(bytes.Repeat([]byte("x"), 80)), 100, bodySize, false)
+	if len(lines) < 3 {
+		t.Fatalf("wrap() = %q", lines)
+	}
+	for _, l := range lines {
+		if w := textWidth(l, bodySize, false); w > 100 {
+			t.Errorf("line %q is %.1f wide", l, w)
+		}
+	}
+	if got := formatBani(-123456789); got != "-1.234.567,89" {
+		t.Errorf("formatBani() = %q", got)
+	}
+}
@@ -0,0 +1,108 @@
+package invoicepdf
+
+import "strings"
+
+var (
+	unitsMasculine = [10]string{"zero", "unu", "doi", "trei", "patru", "cinci", "șase", "șapte", "opt", "nouă"}
+	unitsFeminine  = [10]string{"zero", "una", "două", "trei", "patru", "cinci", "șase", "șapte", "opt", "nouă"}
+	teens          = [10]string{"zece", "unsprezece", "doisprezece", "treisprezece", "paisprezece", "cincisprezece", "șaisprezece", "șaptesprezece", "optsprezece", "nouăsprezece"}
+	tens           = [10]string{"", "", "douăzeci", "treizeci", "patruzeci", "cincizeci", "șaizeci", "șaptezeci", "optzeci", "nouăzeci"}
+)
+
+// AmountInWords spells out an amount in bani as Romanian lei and bani, as
+// required on invoices, e.g. 12345 is "o sută douăzeci și trei de lei și
+// patruzeci și cinci de bani". Negative amounts are prefixed with "minus".
+func AmountInWords(bani int64) string {
+	prefix := ""
+	if bani < 0 {
+		prefix = "minus "
+		bani = -bani
+	}
+	lei, rest := bani/100, bani%100
+	words := counted(lei, "leu", "lei")
+	if rest > 0 {
+		words += " și " + counted(rest, "ban", "bani")
+	}
+	return prefix + words
+}
+
+// counted spells out n followed by a masculine noun: "un leu", "doi lei",
+// "douăzeci de lei".
+func counted(n int64, singular, plural string) string {
+	switch {
+	case n == 0:
+		return "zero " + plural
+	case n == 1:
+		return "un " + singular
+	}
+	return number(n, false) + of(n) + plural
+}
+
+// number spells out n > 0. feminine selects the forms agreeing with a
+// feminine noun ("două", "una") for the last group.
+func number(n int64, feminine bool) string {
+	var parts []string
+	if billions := n / 1_000_000_000; billions > 0 {
+		parts = append(parts, group(billions, "un miliard", "miliarde"))
+	}
+	if millions := n / 1_000_000 % 1000; millions > 0 {
+		parts = append(parts, group(millions, "un milion", "milioane"))
+	}
+	if thousands := n / 1000 % 1000; thousands > 0 {
+		parts = append(parts, group(thousands, "o mie", "mii"))
+	}
+	if rest := n % 1000; rest > 0 {
+		parts = append(parts, belowThousand(rest, feminine))
+	}
+	return strings.Join(parts, " ")
+}
+
+// group spells out n of a scale word; the plurals of all scale words are
+// feminine ("două mii", "două milioane").
+func group(n int64, one, plural string) string {
+	if n == 1 {
+		return one
+	}
+	return belowThousand(n, true) + of(n) + plural
+}
+
+func belowThousand(n int64, feminine bool) string {
+	units := unitsMasculine
+	if feminine {
+		units = unitsFeminine
+	}
+	var parts []string
+	switch h := n / 100; {
+	case h == 1:
+		parts = append(parts, "o sută")
+	case h > 1:
+		parts = append(parts, unitsFeminine[h]+" sute")
+	}
+	switch r := n % 100; {
+	case r >= 20:
+		word := tens[r/10]
+		if r%10 > 0 {
+			word += " și " + units[r%10]
+		}
+		parts = append(parts, word)
+	case r >= 10:
+		word := teens[r-10]
+		if r == 12 && feminine {
+			word = "douăsprezece"
+		}
+		parts = append(parts, word)
+	case r > 0:
+		parts = append(parts, units[r])
+	}
+	return strings.Join(parts, " ")
+}
+
+// of returns the separator between a number and the noun it counts: numbers
+// from 20 up take "de" unless they end in 1-19 ("douăzeci de lei", "o sută
+// unu lei").
+func of(n int64) string {
+	if r := n % 100; n >= 20 && (r == 0 || r >= 20) {
+		return " de "
+	}
+	return " "
+}
@@ -0,0 +1,37 @@
+package invoicepdf
+
+import "testing"
+
+func TestAmountInWords(t *testing.T) {
+	tests := []struct {
+		bani int64
+		want string
+	}{
+		{0, "zero lei"},
+		{100, "un leu"},
+		{1, "zero lei și un ban"},
+		{200, "doi lei"},
+		{1200, "doisprezece lei"},
+		{2000, "douăzeci de lei"},
+		{2100, "douăzeci și unu de lei"},
+		{2250, "douăzeci și doi de lei și cincizeci de bani"},
+		{10000, "o sută de lei"},
+		{10100, "o sută unu lei"},
+		{12345, "o sută douăzeci și trei de lei și patruzeci și cinci de bani"},
+		{25000, "două sute cincizeci de lei"},
+		{100000, "o mie de lei"},
+		{100100, "o mie unu lei"},
+		{200000, "două mii de lei"},
+		{1200000, "douăsprezece mii de lei"},
+		{2100000, "douăzeci și una de mii de lei"},
+		{12199, "o sută douăzeci și unu de lei și nouăzeci și nouă de bani"},
+		{100000000, "un milion de lei"},
+		{250000000, "două milioane cinci sute de mii de lei"},
+		{-11900, "minus o sută nouăsprezece lei"},
+	}
+	for _, tt := range tests {
+		if got := AmountInWords(tt.bani); got != tt.want {
+			t.Errorf("AmountInWords(%d) = %q, want %q", tt.bani, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,284 @@
+// Package ledger keeps the double-entry money ledger: every payment, refund,
+// payout, dispute, Stripe fee and commission invoice or credit note is posted
+// as a journal entry whose lines sum to zero. Amounts are in bani; debits are
+// positive and credits negative.
+//
+// The accounts are the platform's Stripe balance (which also holds the
+// companies' Connect balances), one account per client and per company, the
+// platform's commission revenue, the Stripe fees it pays and the VAT it owes.
+// A client account is a clearing account: what a client pays is applied to
+// the company and the platform in the same entry, so its balance stays zero.
+// A company account's credit balance is what the platform owes the company.
+package ledger
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	db "helpmeclean-backend/internal/db/generated"
+)
+
+// Account is a ledger account: a type and, for client and company accounts,
+// its owner.
+type Account struct {
+	Type  db.LedgerAccountType
+	Owner pgtype.UUID
+}
+
+// The platform's own accounts.
+var (
+	StripeBalance = Account{Type: db.LedgerAccountTypeStripeBalance}
+	PlatformFees  = Account{Type: db.LedgerAccountTypePlatformFees}
+	StripeFees    = Account{Type: db.LedgerAccountTypeStripeFees}
+	VATPayable    = Account{Type: db.LedgerAccountTypeVatPayable}
+)
+
+// Client returns the account of a client user.
+func Client(userID pgtype.UUID) Account {
+	return Account{Type: db.LedgerAccountTypeClient, Owner: userID}
+}
+
+// Company returns the account of a company.
+func Company(companyID pgtype.UUID) Account {
+	return Account{Type: db.LedgerAccountTypeCompany, Owner: companyID}
+}
+
+// Line moves Amount bani on an account: a debit when positive, a credit when
+// negative.
+type Line struct {
+	Account Account
+	Amount  int64
+}
+
+// Entry is a journal entry. Reference identifies what caused it (e.g.
+// "payment:pi_..."); an entry is posted at most once per reference.
+type Entry struct {
+	Kind        db.LedgerEntryKind
+	Reference   string
+	Description string
+	BookingID   pgtype.UUID
+	CompanyID   pgtype.UUID
+	OccurredAt  time.Time
+	Lines       []Line
+}
+
+// Validate checks that the entry can be posted: it has a reference, at least
+// two non-zero lines on typed accounts, client and company accounts have an
+// owner, and the lines balance.
+func (e Entry) Validate() error {
+	if e.Reference == "" {
+		return errors.New("ledger: entry has no reference")
+	}
+	if len(e.Lines) < 2 {
+		return fmt.Errorf("ledger: entry %s has %d lines, needs at least 2", e.Reference, len(e.Lines))
+	}
+	var sum int64
+	for _, l := range e.Lines {
+		if l.Amount == 0 {
+			return fmt.Errorf("ledger: entry %s has a zero line on %s", e.Reference, l.Account.Type)
+		}
+		if l.Account.Type == "" {
+			return fmt.Errorf("ledger: entry %s has a line without an account", e.Reference)
+		}
+		if (l.Account.Type == db.LedgerAccountTypeClient || l.Account.Type == db.LedgerAccountTypeCompany) && !l.Account.Owner.Valid {
+			return fmt.Errorf("ledger: entry %s has a %s line without an owner", e.Reference, l.Account.Type)
+		}
+		sum += l.Amount
+	}
+	if sum != 0 {
+		return fmt.Errorf("ledger: entry %s does not balance (off by %d bani)", e.Reference, sum)
+	}
+	return nil
+}
+
+// lines drops the zero amounts from a list of lines, e.g. a payment without
+// a platform fee.
+func lines(ls ...Line) []Line {
+	out := ls[:0]
+	for _, l := range ls {
+		if l.Amount != 0 {
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+// Payment is a booking payment Stripe collected: Gross charged to the
+// client, of which PlatformFee is the platform's commission (without VAT)
+// and the rest the company's share.
+type Payment struct {
+	PaymentIntentID string
+	BookingID       pgtype.UUID
+	ClientID        pgtype.UUID
+	CompanyID       pgtype.UUID
+	Gross           int64
+	PlatformFee     int64
+	At              time.Time
+}
+
+// PaymentEntry moves a payment into the Stripe balance and applies it to the
+// company's share and the platform's commission.
+func PaymentEntry(p Payment) Entry {
+	return Entry{
+		Kind:        db.LedgerEntryKindPayment,
+		Reference:   "payment:" + p.PaymentIntentID,
+		Description: fmt.Sprintf("Plata %s", p.PaymentIntentID),
+		BookingID:   p.BookingID,
+		CompanyID:   p.CompanyID,
+		OccurredAt:  p.At,
+		Lines: lines(
+			Line{StripeBalance, p.Gross},
+			Line{Client(p.ClientID), -p.Gross},
+			Line{Client(p.ClientID), p.Gross},
+			Line{Company(p.CompanyID), -(p.Gross - p.PlatformFee)},
+			Line{PlatformFees, -p.PlatformFee},
+		),
+	}
+}
+
+// Refund is a refund of part or all of a payment. Refunds reverse the
+// transfer to the company and the application fee in proportion to the
+// refunded amount, so both give back their share.
+type Refund struct {
+	RefundID        string
+	PaymentIntentID string
+	BookingID       pgtype.UUID
+	ClientID        pgtype.UUID
+	CompanyID       pgtype.UUID
+	Amount          int64
+	// PaymentGross and PaymentFee are the refunded payment's amount and
+	// platform fee.
+	PaymentGross int64
+	PaymentFee   int64
+	At           time.Time
+}
+
+// RefundedFee returns the part of a payment's platform fee that a refund of
+// amount gives back, rounded half up.
+func RefundedFee(amount, paymentGross, paymentFee int64) int64 {
+	if paymentGross <= 0 {
+		return 0
+	}
+	if amount >= paymentGross {
+		return paymentFee
+	}
+	return (2*paymentFee*amount + paymentGross) / (2 * paymentGross)
+}
+
+// RefundEntry takes the refunded amount back from the company and the
+// platform and pays it out of the Stripe balance to the client.
+func RefundEntry(r Refund) Entry {
+	fee := RefundedFee(r.Amount, r.PaymentGross, r.PaymentFee)
+	return Entry{
+		Kind:        db.LedgerEntryKindRefund,
+		Reference:   "refund:" + r.RefundID,
+		Description: fmt.Sprintf("Rambursare %s pentru plata %s", r.RefundID, r.PaymentIntentID),
+		BookingID:   r.BookingID,
+		CompanyID:   r.CompanyID,
+		OccurredAt:  r.At,
+		Lines: lines(
+			Line{Company(r.CompanyID), r.Amount - fee},
+			Line{PlatformFees, fee},
+			Line{Client(r.ClientID), -r.Amount},
+			Line{Client(r.ClientID), r.Amount},
+			Line{StripeBalance, -r.Amount},
+		),
+	}
+}
+
+// PayoutEntry pays a company's balance out to its bank account.
+func PayoutEntry(payoutID, companyID pgtype.UUID, amount int64, at time.Time) Entry {
+	return Entry{
+		Kind:        db.LedgerEntryKindPayout,
+		Reference:   "payout:" + uuidString(payoutID),
+		Description: "Plata catre companie",
+		CompanyID:   companyID,
+		OccurredAt:  at,
+		Lines: lines(
+			Line{Company(companyID), amount},
+			Line{StripeBalance, -amount},
+		),
+	}
+}
+
+// StripeFeeEntry records the fee Stripe took from a balance transaction. A
+// negative fee (a fee Stripe gave back) is posted the other way round.
+func StripeFeeEntry(balanceTransactionID string, bookingID pgtype.UUID, fee int64, at time.Time) Entry {
+	return Entry{
+		Kind:        db.LedgerEntryKindStripeFee,
+		Reference:   "stripe_fee:" + balanceTransactionID,
+		Description: fmt.Sprintf("Comision Stripe %s", balanceTransactionID),
+		BookingID:   bookingID,
+		OccurredAt:  at,
+		Lines: lines(
+			Line{StripeFees, fee},
+			Line{StripeBalance, -fee},
+		),
+	}
+}
+
+// CommissionInvoiceEntry bills a company the VAT of a commission invoice.
+// The net commission was already kept from the company's payments as the
+// application fee, so only the VAT is new.
+func CommissionInvoiceEntry(invoiceID, companyID pgtype.UUID, vat int64, at time.Time) Entry {
+	return Entry{
+		Kind:        db.LedgerEntryKindCommissionInvoice,
+		Reference:   "invoice:" + uuidString(invoiceID),
+		Description: "TVA factura comision",
+		CompanyID:   companyID,
+		OccurredAt:  at,
+		Lines: lines(
+			Line{Company(companyID), vat},
+			Line{VATPayable, -vat},
+		),
+	}
+}
+
+// CreditNoteEntry credits a company for a credit note on a commission
+// invoice: the platform gives back net commission and the VAT on it. Both
+// amounts are positive. Credit notes issued for a refund have a net of 0, as
+// the refund entry already gave the commission back.
+func CreditNoteEntry(invoiceID, companyID pgtype.UUID, net, vat int64, at time.Time) Entry {
+	return Entry{
+		Kind:        db.LedgerEntryKindCreditNote,
+		Reference:   "credit_note:" + uuidString(invoiceID),
+		Description: "Nota de credit comision",
+		CompanyID:   companyID,
+		OccurredAt:  at,
+		Lines: lines(
+			Line{PlatformFees, net},
+			Line{VATPayable, vat},
+			Line{Company(companyID), -(net + vat)},
+		),
+	}
+}
+
+// DisputeEntry records what a dispute moved on the Stripe balance: a
+// withdrawal (a negative amount) when the dispute opens and a reinstatement
+// (a positive amount) when it is won. The company bears the disputed amount,
+// so a withdrawal is charged to its account and a reinstatement given back.
+func DisputeEntry(balanceTransactionID, disputeID string, bookingID, companyID pgtype.UUID, amount int64, at time.Time) Entry {
+	return Entry{
+		Kind:        db.LedgerEntryKindDispute,
+		Reference:   "dispute:" + balanceTransactionID,
+		Description: fmt.Sprintf("Disputa %s", disputeID),
+		BookingID:   bookingID,
+		CompanyID:   companyID,
+		OccurredAt:  at,
+		Lines: lines(
+			Line{StripeBalance, amount},
+			Line{Company(companyID), -amount},
+		),
+	}
+}
+
+func uuidString(u pgtype.UUID) string {
+	if !u.Valid {
+		return ""
+	}
+	b := u.Bytes
+	return fmt.Sprintf("%08x-%04x-%04x-%04x-%012x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,166 @@
+package ledger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	db "helpmeclean-backend/internal/db/generated"
+)
+
+var (
+	clientID  = pgtype.UUID{Bytes: [16]byte{1}, Valid: true}
+	companyID = pgtype.UUID{Bytes: [16]byte{2}, Valid: true}
+	bookingID = pgtype.UUID{Bytes: [16]byte{3}, Valid: true}
+	at        = time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+)
+
+// balances sums the lines of entries per account.
+func balances(entries ...Entry) map[Account]int64 {
+	out := map[Account]int64{}
+	for _, e := range entries {
+		for _, l := range e.Lines {
+			out[l.Account] += l.Amount
+		}
+	}
+	return out
+}
+
+func TestEntriesBalance(t *testing.T) {
+	entries := []Entry{
+		PaymentEntry(Payment{PaymentIntentID: "pi_1", BookingID: bookingID, ClientID: clientID, CompanyID: companyID, Gross: 20000, PlatformFee: 3000, At: at}),
+		PaymentEntry(Payment{PaymentIntentID: "pi_2", BookingID: bookingID, ClientID: clientID, CompanyID: companyID, Gross: 5000, At: at}),
+		RefundEntry(Refund{RefundID: "re_1", PaymentIntentID: "pi_1", BookingID: bookingID, ClientID: clientID, CompanyID: companyID, Amount: 5000, PaymentGross: 20000, PaymentFee: 3000, At: at}),
+		PayoutEntry(companyID, companyID, 10000, at),
+		StripeFeeEntry("txn_1", bookingID, 320, at),
+		StripeFeeEntry("txn_2", bookingID, -20, at),
+		CommissionInvoiceEntry(bookingID, companyID, 630, at),
+		CreditNoteEntry(clientID, companyID, 100, 21, at),
+		DisputeEntry("txn_3", "dp_1", bookingID, companyID, -20000, at),
+		DisputeEntry("txn_4", "dp_1", bookingID, companyID, 20000, at),
+	}
+	for _, e := range entries {
+		if err := e.Validate(); err != nil {
+			t.Errorf("%s: %v", e.Reference, err)
+		}
+	}
+}
+
+func TestPaymentAndRefundBalances(t *testing.T) {
+	pay := PaymentEntry(Payment{PaymentIntentID: "pi_1", BookingID: bookingID, ClientID: clientID, CompanyID: companyID, Gross: 20000, PlatformFee: 3000, At: at})
+	ref := RefundEntry(Refund{RefundID: "re_1", PaymentIntentID: "pi_1", BookingID: bookingID, ClientID: clientID, CompanyID: companyID, Amount: 5000, PaymentGross: 20000, PaymentFee: 3000, At: at})
+
+	got := balances(pay, ref)
+	want := map[Account]int64{
+		StripeBalance:      15000,
+		Client(clientID):   0,
+		Company(companyID): -12750,
+		PlatformFees:       -2250,
+	}
+	for acct, w := range want {
+		if got[acct] != w {
+			t.Errorf("balance of %s = %d, want %d", acct.Type, got[acct], w)
+		}
+	}
+}
+
+func TestDisputeChargesCompany(t *testing.T) {
+	got := balances(DisputeEntry("txn_1", "dp_1", bookingID, companyID, -20000, at))
+	if got[Company(companyID)] != 20000 || got[StripeBalance] != -20000 {
+		t.Errorf("dispute withdrawal balances = %v, want the company debited 20000", got)
+	}
+	got = balances(
+		DisputeEntry("txn_1", "dp_1", bookingID, companyID, -20000, at),
+		DisputeEntry("txn_2", "dp_1", bookingID, companyID, 20000, at),
+	)
+	if got[Company(companyID)] != 0 || got[StripeBalance] != 0 {
+		t.Errorf("won dispute balances = %v, want zero", got)
+	}
+}
+
+func TestPaymentWithoutFeeDropsZeroLine(t *testing.T) {
+	e := PaymentEntry(Payment{PaymentIntentID: "pi_1", ClientID: clientID, CompanyID: companyID, Gross: 5000, At: at})
+	for _, l := range e.Lines {
+		if l.Account == PlatformFees {
+			t.Errorf("payment without a fee has a platform fee line of %d", l.Amount)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry Entry
+	}{
+		{"no reference", Entry{Lines: []Line{{StripeBalance, 1}, {PlatformFees, -1}}}},
+		{"one line", Entry{Reference: "x", Lines: []Line{{StripeBalance, 1}}}},
+		{"unbalanced", Entry{Reference: "x", Lines: []Line{{StripeBalance, 2}, {PlatformFees, -1}}}},
+		{"zero line", Entry{Reference: "x", Lines: []Line{{StripeBalance, 0}, {PlatformFees, 1}, {StripeFees, -1}}}},
+		{"company without owner", Entry{Reference: "x", Lines: []Line{{StripeBalance, 1}, {Company(pgtype.UUID{}), -1}}}},
+	}
+	for _, tt := range tests {
+		if err := tt.entry.Validate(); err == nil {
+			t.Errorf("%s: Validate() = nil, want an error", tt.name)
+		}
+	}
+}
+
+func TestRefundedFee(t *testing.T) {
+	tests := []struct {
+		amount, gross, fee, want int64
+	}{
+		{20000, 20000, 3000, 3000},
+		{5000, 20000, 3000, 750},
+		{1, 20000, 3000, 0},
+		{7, 20000, 3000, 1},
+		{25000, 20000, 3000, 3000},
+		{100, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		if got := RefundedFee(tt.amount, tt.gross, tt.fee); got != tt.want {
+			t.Errorf("RefundedFee(%d, %d, %d) = %d, want %d", tt.amount, tt.gross, tt.fee, got, tt.want)
+		}
+	}
+}
+
+func TestBuildRevenueReport(t *testing.T) {
+	r := BuildRevenueReport([]db.SumLedgerMovementsRow{
+		{Kind: db.LedgerEntryKindPayment, AccountType: db.LedgerAccountTypeStripeBalance, Debits: 25000},
+		{Kind: db.LedgerEntryKindPayment, AccountType: db.LedgerAccountTypeClient, Debits: 25000, Credits: 25000, BookingCount: 2},
+		{Kind: db.LedgerEntryKindPayment, AccountType: db.LedgerAccountTypeCompany, Credits: 22000},
+		{Kind: db.LedgerEntryKindPayment, AccountType: db.LedgerAccountTypePlatformFees, Credits: 3000},
+		{Kind: db.LedgerEntryKindRefund, AccountType: db.LedgerAccountTypeStripeBalance, Credits: 5000},
+		{Kind: db.LedgerEntryKindRefund, AccountType: db.LedgerAccountTypePlatformFees, Debits: 750},
+		{Kind: db.LedgerEntryKindPayout, AccountType: db.LedgerAccountTypeCompany, Debits: 10000},
+		{Kind: db.LedgerEntryKindStripeFee, AccountType: db.LedgerAccountTypeStripeFees, Debits: 340, Credits: 20},
+		{Kind: db.LedgerEntryKindCreditNote, AccountType: db.LedgerAccountTypePlatformFees, Debits: 100},
+	})
+	want := RevenueReport{
+		TotalRevenue:    25000,
+		TotalCommission: 2150,
+		TotalPayouts:    10000,
+		TotalRefunds:    5000,
+		StripeFees:      320,
+		NetRevenue:      1830,
+		BookingCount:    2,
+	}
+	if r != want {
+		t.Errorf("BuildRevenueReport() = %+v, want %+v", r, want)
+	}
+}
+
+func TestBuildCompanyEarnings(t *testing.T) {
+	e := BuildCompanyEarnings([]db.SumLedgerMovementsRow{
+		{Kind: db.LedgerEntryKindPayment, AccountType: db.LedgerAccountTypeClient, Debits: 20000, Credits: 20000, BookingCount: 1},
+		{Kind: db.LedgerEntryKindPayment, AccountType: db.LedgerAccountTypeCompany, Credits: 17000},
+		{Kind: db.LedgerEntryKindPayment, AccountType: db.LedgerAccountTypePlatformFees, Credits: 3000},
+		{Kind: db.LedgerEntryKindRefund, AccountType: db.LedgerAccountTypeCompany, Debits: 4250},
+		{Kind: db.LedgerEntryKindRefund, AccountType: db.LedgerAccountTypePlatformFees, Debits: 750},
+		{Kind: db.LedgerEntryKindPayout, AccountType: db.LedgerAccountTypeCompany, Debits: 10000},
+	})
+	want := CompanyEarnings{TotalGross: 15000, TotalCommission: 2250, TotalNet: 12750, BookingCount: 1}
+	if e != want {
+		t.Errorf("BuildCompanyEarnings() = %+v, want %+v", e, want)
+	}
+}
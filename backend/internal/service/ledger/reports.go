@@ -0,0 +1,74 @@
+package ledger
+
+import (
+	db "helpmeclean-backend/internal/db/generated"
+)
+
+// RevenueReport is the platform's money movement in a period, in bani.
+type RevenueReport struct {
+	// TotalRevenue is what clients paid.
+	TotalRevenue int64
+	// TotalCommission is the platform's commission net of refunds and
+	// commission credit notes.
+	TotalCommission int64
+	TotalPayouts    int64
+	TotalRefunds    int64
+	StripeFees      int64
+	// NetRevenue is the commission minus the Stripe fees.
+	NetRevenue   int64
+	BookingCount int64
+}
+
+// BuildRevenueReport sums the movements of a period into a revenue report.
+func BuildRevenueReport(movements []db.SumLedgerMovementsRow) RevenueReport {
+	var r RevenueReport
+	for _, m := range movements {
+		switch {
+		case m.Kind == db.LedgerEntryKindPayment && m.AccountType == db.LedgerAccountTypeClient:
+			r.TotalRevenue += m.Credits
+			r.BookingCount += m.BookingCount
+		case m.Kind == db.LedgerEntryKindRefund && m.AccountType == db.LedgerAccountTypeStripeBalance:
+			r.TotalRefunds += m.Credits
+		case m.Kind == db.LedgerEntryKindPayout && m.AccountType == db.LedgerAccountTypeCompany:
+			r.TotalPayouts += m.Debits
+		case m.AccountType == db.LedgerAccountTypeStripeFees:
+			r.StripeFees += m.Debits - m.Credits
+		case m.AccountType == db.LedgerAccountTypePlatformFees:
+			r.TotalCommission += m.Credits - m.Debits
+		}
+	}
+	r.NetRevenue = r.TotalCommission - r.StripeFees
+	return r
+}
+
+// CompanyEarnings is what a company earned from its bookings in a period,
+// net of refunds, in bani.
+type CompanyEarnings struct {
+	TotalGross      int64
+	TotalCommission int64
+	TotalNet        int64
+	BookingCount    int64
+}
+
+// BuildCompanyEarnings sums a company's payment and refund movements of a
+// period into its earnings.
+func BuildCompanyEarnings(movements []db.SumLedgerMovementsRow) CompanyEarnings {
+	var e CompanyEarnings
+	for _, m := range movements {
+		if m.Kind != db.LedgerEntryKindPayment && m.Kind != db.LedgerEntryKindRefund {
+			continue
+		}
+		switch m.AccountType {
+		case db.LedgerAccountTypeCompany:
+			e.TotalNet += m.Credits - m.Debits
+		case db.LedgerAccountTypePlatformFees:
+			e.TotalCommission += m.Credits - m.Debits
+		case db.LedgerAccountTypeClient:
+			if m.Kind == db.LedgerEntryKindPayment {
+				e.BookingCount += m.BookingCount
+			}
+		}
+	}
+	e.TotalGross = e.TotalNet + e.TotalCommission
+	return e
+}
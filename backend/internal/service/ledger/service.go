@@ -0,0 +1,85 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	db "helpmeclean-backend/internal/db/generated"
+)
+
+// Service posts entries to the ledger.
+type Service struct {
+	pool    *pgxpool.Pool
+	queries *db.Queries
+}
+
+// NewService creates a new ledger service.
+func NewService(pool *pgxpool.Pool, queries *db.Queries) *Service {
+	return &Service{pool: pool, queries: queries}
+}
+
+// Post posts an entry in its own transaction. It reports whether the entry
+// was posted; an entry whose reference was already posted is skipped.
+func (s *Service) Post(ctx context.Context, e Entry) (bool, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("ledger: failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	posted, err := s.PostWith(ctx, s.queries.WithTx(tx), e)
+	if err != nil {
+		return false, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("ledger: failed to commit entry %s: %w", e.Reference, err)
+	}
+	return posted, nil
+}
+
+// PostWith posts an entry with the caller's transaction-bound queries, so
+// the entry commits together with the change that caused it. The database
+// checks at commit that the entry balances.
+func (s *Service) PostWith(ctx context.Context, q *db.Queries, e Entry) (bool, error) {
+	if err := e.Validate(); err != nil {
+		return false, err
+	}
+
+	entry, err := q.CreateLedgerEntry(ctx, db.CreateLedgerEntryParams{
+		Kind:        e.Kind,
+		Reference:   e.Reference,
+		Description: e.Description,
+		BookingID:   e.BookingID,
+		CompanyID:   e.CompanyID,
+		OccurredAt:  pgtype.Timestamptz{Time: e.OccurredAt, Valid: true},
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("ledger: failed to create entry %s: %w", e.Reference, err)
+	}
+
+	for _, l := range e.Lines {
+		account, err := q.UpsertLedgerAccount(ctx, db.UpsertLedgerAccountParams{
+			AccountType: l.Account.Type,
+			OwnerID:     l.Account.Owner,
+		})
+		if err != nil {
+			return false, fmt.Errorf("ledger: failed to get %s account: %w", l.Account.Type, err)
+		}
+		if err := q.CreateLedgerLine(ctx, db.CreateLedgerLineParams{
+			EntryID:   entry.ID,
+			AccountID: account.ID,
+			Amount:    l.Amount,
+		}); err != nil {
+			return false, fmt.Errorf("ledger: failed to create line of entry %s: %w", e.Reference, err)
+		}
+	}
+	return true, nil
+}
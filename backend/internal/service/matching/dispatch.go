@@ -0,0 +1,309 @@
+package matching
+
+import (
+	"math"
+	"sort"
+)
+
+// ---------------------------------------------------------------------------
+// Daily dispatch: assign a company's bookings for one day to its cleaners so
+// that total travel and overtime are minimal, keeping existing assignments
+// unless moving them is worth it.
+// ---------------------------------------------------------------------------
+
+// DispatchJob is one booking of the day. Its time window is fixed; only the
+// cleaner can change.
+type DispatchJob struct {
+	ID          string
+	ServiceType string
+	StartMicros int64
+	EndMicros   int64
+	Lat         float64
+	Lng         float64
+	HasLocation bool
+	Current     string // currently assigned cleaner ID, "" if none
+	Locked      bool   // started, completed or team jobs stay where they are
+}
+
+// DispatchCleaner is one cleaner's working window for the day.
+type DispatchCleaner struct {
+	ID         string
+	Available  bool
+	AvailStart int64
+	AvailEnd   int64
+	Skills     []string // service types the cleaner does; empty means all
+}
+
+// DispatchConfig holds the cost model of the dispatch optimizer. All costs
+// are expressed in travel minutes.
+type DispatchConfig struct {
+	SpeedKmh             float64 // average travel speed between jobs
+	DefaultTravelMinutes float64 // travel assumed when a job has no coordinates
+	RegularHours         float64 // worked hours per day before overtime starts
+	OvertimeWeight       float64 // cost of one overtime minute
+	MovePenalty          float64 // cost of changing an existing assignment
+	UnassignedPenalty    float64 // cost of leaving a job without a cleaner
+}
+
+// DefaultDispatchConfig returns the default dispatch cost model.
+func DefaultDispatchConfig() DispatchConfig {
+	return DispatchConfig{
+		SpeedKmh:             25,
+		DefaultTravelMinutes: 20,
+		RegularHours:         8,
+		OvertimeWeight:       2,
+		MovePenalty:          15,
+		UnassignedPenalty:    1000,
+	}
+}
+
+// DispatchPlan is an assignment of every job to a cleaner and its cost.
+type DispatchPlan struct {
+	Assignments     map[string]string // job ID -> cleaner ID, "" when unassigned
+	TravelMinutes   float64
+	OvertimeMinutes float64
+	Moves           int // existing assignments changed
+	Unassigned      int
+	Cost            float64
+}
+
+// PlanDispatch evaluates the current assignments and returns them together
+// with an improved plan. Current assignments that break a constraint
+// (availability, skills, overlap including travel) are dropped first, then
+// unassigned jobs are inserted greedily and the plan is improved by moving
+// single jobs and swapping pairs of jobs between cleaners for as long as the
+// cost decreases.
+func PlanDispatch(jobs []DispatchJob, cleaners []DispatchCleaner, config DispatchConfig) (current, proposed DispatchPlan) {
+	d := newDispatcher(jobs, cleaners, config)
+
+	currentAssign := map[string]string{}
+	for _, j := range jobs {
+		currentAssign[j.ID] = j.Current
+	}
+	current = d.evaluate(currentAssign)
+
+	// Start from the feasible part of the current plan.
+	assign := map[string]string{}
+	for _, j := range d.jobsByStart() {
+		if j.Locked {
+			assign[j.ID] = j.Current
+			continue
+		}
+		assign[j.ID] = ""
+		if j.Current != "" {
+			assign[j.ID] = j.Current
+			if !d.routeFeasible(assign, j.Current) {
+				assign[j.ID] = ""
+			}
+		}
+	}
+
+	cost := d.evaluate(assign).Cost
+	for pass := 0; pass < 50; pass++ {
+		improved := false
+
+		// Insert or relocate each job to the cleaner where it costs least.
+		for _, j := range d.jobsByStart() {
+			if j.Locked {
+				continue
+			}
+			from := assign[j.ID]
+			bestTo, bestCost := from, cost
+			for _, c := range cleaners {
+				if c.ID == from {
+					continue
+				}
+				assign[j.ID] = c.ID
+				if d.routeFeasible(assign, c.ID) {
+					if nc := d.evaluate(assign).Cost; nc < bestCost-1e-9 {
+						bestTo, bestCost = c.ID, nc
+					}
+				}
+			}
+			assign[j.ID] = bestTo
+			if bestTo != from {
+				cost = bestCost
+				improved = true
+			}
+		}
+
+		// Swap pairs of jobs between two cleaners.
+		for a := 0; a < len(jobs); a++ {
+			for b := a + 1; b < len(jobs); b++ {
+				ja, jb := jobs[a], jobs[b]
+				ca, cb := assign[ja.ID], assign[jb.ID]
+				if ja.Locked || jb.Locked || ca == cb || ca == "" || cb == "" {
+					continue
+				}
+				assign[ja.ID], assign[jb.ID] = cb, ca
+				if d.routeFeasible(assign, ca) && d.routeFeasible(assign, cb) {
+					if nc := d.evaluate(assign).Cost; nc < cost-1e-9 {
+						cost = nc
+						improved = true
+						continue
+					}
+				}
+				assign[ja.ID], assign[jb.ID] = ca, cb
+			}
+		}
+
+		if !improved {
+			break
+		}
+	}
+
+	return current, d.evaluate(assign)
+}
+
+// CheckDispatch returns the IDs of the given cleaners whose route under
+// assign breaks a hard constraint, in the order they were given.
+func CheckDispatch(jobs []DispatchJob, cleaners []DispatchCleaner, assign map[string]string, config DispatchConfig, cleanerIDs []string) []string {
+	d := newDispatcher(jobs, cleaners, config)
+	var infeasible []string
+	for _, id := range cleanerIDs {
+		if !d.routeFeasible(assign, id) {
+			infeasible = append(infeasible, id)
+		}
+	}
+	return infeasible
+}
+
+type dispatcher struct {
+	jobs     []DispatchJob
+	jobByID  map[string]*DispatchJob
+	cleaners map[string]*DispatchCleaner
+	config   DispatchConfig
+}
+
+func newDispatcher(jobs []DispatchJob, cleaners []DispatchCleaner, config DispatchConfig) *dispatcher {
+	d := &dispatcher{
+		jobs:     jobs,
+		jobByID:  map[string]*DispatchJob{},
+		cleaners: map[string]*DispatchCleaner{},
+		config:   config,
+	}
+	for i := range jobs {
+		d.jobByID[jobs[i].ID] = &jobs[i]
+	}
+	for i := range cleaners {
+		d.cleaners[cleaners[i].ID] = &cleaners[i]
+	}
+	return d
+}
+
+func (d *dispatcher) jobsByStart() []DispatchJob {
+	sorted := append([]DispatchJob(nil), d.jobs...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].StartMicros < sorted[j].StartMicros })
+	return sorted
+}
+
+// route returns the jobs assigned to a cleaner, sorted by start time.
+func (d *dispatcher) route(assign map[string]string, cleanerID string) []*DispatchJob {
+	var route []*DispatchJob
+	for i := range d.jobs {
+		if assign[d.jobs[i].ID] == cleanerID {
+			route = append(route, &d.jobs[i])
+		}
+	}
+	sort.SliceStable(route, func(i, j int) bool { return route[i].StartMicros < route[j].StartMicros })
+	return route
+}
+
+// routeFeasible checks the hard constraints of one cleaner's route. Locked
+// jobs are taken as given: only constraints involving a movable job count.
+func (d *dispatcher) routeFeasible(assign map[string]string, cleanerID string) bool {
+	c, ok := d.cleaners[cleanerID]
+	route := d.route(assign, cleanerID)
+	for i, j := range route {
+		if !j.Locked {
+			if !ok || !c.Available || j.StartMicros < c.AvailStart || j.EndMicros > c.AvailEnd {
+				return false
+			}
+			if !hasSkill(c.Skills, j.ServiceType) {
+				return false
+			}
+		}
+		if i > 0 {
+			prev := route[i-1]
+			if prev.Locked && j.Locked {
+				continue
+			}
+			travel := int64(d.travelMinutes(prev, j) * float64(MinuteMicros))
+			if prev.EndMicros+travel > j.StartMicros {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// evaluate computes the cost of an assignment without checking feasibility.
+func (d *dispatcher) evaluate(assign map[string]string) DispatchPlan {
+	plan := DispatchPlan{Assignments: map[string]string{}}
+	for k, v := range assign {
+		plan.Assignments[k] = v
+	}
+
+	routes := map[string][]*DispatchJob{}
+	for i := range d.jobs {
+		j := &d.jobs[i]
+		to := assign[j.ID]
+		if to == "" {
+			plan.Unassigned++
+		} else {
+			routes[to] = append(routes[to], j)
+		}
+		if !j.Locked && j.Current != "" && to != j.Current {
+			plan.Moves++
+		}
+	}
+
+	for _, route := range routes {
+		sort.SliceStable(route, func(i, j int) bool { return route[i].StartMicros < route[j].StartMicros })
+		worked := 0.0
+		for i, j := range route {
+			worked += float64(j.EndMicros-j.StartMicros) / float64(MinuteMicros)
+			if i > 0 {
+				t := d.travelMinutes(route[i-1], j)
+				plan.TravelMinutes += t
+				worked += t
+			}
+		}
+		if over := worked - d.config.RegularHours*60; d.config.RegularHours > 0 && over > 0 {
+			plan.OvertimeMinutes += over
+		}
+	}
+
+	plan.Cost = plan.TravelMinutes +
+		plan.OvertimeMinutes*d.config.OvertimeWeight +
+		float64(plan.Moves)*d.config.MovePenalty +
+		float64(plan.Unassigned)*d.config.UnassignedPenalty
+	return plan
+}
+
+// travelMinutes estimates the drive between two jobs from the straight-line
+// distance and the configured average speed.
+func (d *dispatcher) travelMinutes(a, b *DispatchJob) float64 {
+	if !a.HasLocation || !b.HasLocation || d.config.SpeedKmh <= 0 {
+		return d.config.DefaultTravelMinutes
+	}
+	return HaversineKm(a.Lat, a.Lng, b.Lat, b.Lng) / d.config.SpeedKmh * 60
+}
+
+// HaversineKm returns the great-circle distance between two points in km.
+func HaversineKm(lat1, lng1, lat2, lng2 float64) float64 {
+	const earthRadiusKm = 6371.0
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat := toRad(lat2 - lat1)
+	dLng := toRad(lng2 - lng1)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
+
+func hasSkill(skills []string, serviceType string) bool {
+	if len(skills) == 0 {
+		return true
+	}
+	return containsString(skills, serviceType)
+}
@@ -0,0 +1,190 @@
+package matching
+
+import (
+	"math"
+	"testing"
+)
+
+// ---------------------------------------------------------------------------
+// HaversineKm
+// ---------------------------------------------------------------------------
+
+func TestHaversineKm(t *testing.T) {
+	// Piata Unirii to Piata Victoriei, Bucharest: about 3.1 km.
+	got := HaversineKm(44.4268, 26.1025, 44.4522, 26.0862)
+	if math.Abs(got-3.1) > 0.2 {
+		t.Errorf("HaversineKm = %.2f km, want about 3.1 km", got)
+	}
+	if HaversineKm(44.4, 26.1, 44.4, 26.1) != 0 {
+		t.Error("distance to self should be 0")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// PlanDispatch
+// ---------------------------------------------------------------------------
+
+func dayCleaner(id string, skills ...string) DispatchCleaner {
+	return DispatchCleaner{ID: id, Available: true, AvailStart: h(8), AvailEnd: h(18), Skills: skills}
+}
+
+func job(id string, start, end int, lat, lng float64, current string) DispatchJob {
+	return DispatchJob{
+		ID:          id,
+		ServiceType: "standard_cleaning",
+		StartMicros: h(start),
+		EndMicros:   h(end),
+		Lat:         lat,
+		Lng:         lng,
+		HasLocation: true,
+		Current:     current,
+	}
+}
+
+func TestPlanDispatch_KeepsGoodPlan(t *testing.T) {
+	jobs := []DispatchJob{
+		job("j1", 9, 11, 44.43, 26.10, "a"),
+		job("j2", 12, 14, 44.43, 26.10, "a"),
+	}
+	cleaners := []DispatchCleaner{dayCleaner("a"), dayCleaner("b")}
+
+	current, proposed := PlanDispatch(jobs, cleaners, DefaultDispatchConfig())
+	if proposed.Moves != 0 {
+		t.Errorf("Moves = %d, want 0 (nothing to gain)", proposed.Moves)
+	}
+	if proposed.Cost != current.Cost {
+		t.Errorf("Cost = %.1f, want unchanged %.1f", proposed.Cost, current.Cost)
+	}
+}
+
+func TestPlanDispatch_ReducesTravel(t *testing.T) {
+	// Cleaner a crosses town twice; swapping j2 and j3 keeps each cleaner in one area.
+	west, east := 26.00, 26.20
+	jobs := []DispatchJob{
+		job("j1", 8, 10, 44.43, west, "a"),
+		job("j2", 11, 13, 44.43, east, "a"),
+		job("j3", 11, 13, 44.43, west, "b"),
+		job("j4", 14, 16, 44.43, east, "b"),
+		job("j5", 14, 16, 44.43, west, "a"),
+	}
+	cleaners := []DispatchCleaner{dayCleaner("a"), dayCleaner("b")}
+
+	current, proposed := PlanDispatch(jobs, cleaners, DefaultDispatchConfig())
+	if proposed.TravelMinutes >= current.TravelMinutes {
+		t.Fatalf("TravelMinutes = %.1f, want below current %.1f", proposed.TravelMinutes, current.TravelMinutes)
+	}
+	if proposed.Assignments["j2"] != "b" || proposed.Assignments["j3"] != "a" {
+		t.Errorf("assignments = %v, want j2->b and j3->a", proposed.Assignments)
+	}
+	if proposed.Assignments["j1"] != "a" {
+		t.Errorf("j1 moved to %q, want it to stay with a", proposed.Assignments["j1"])
+	}
+}
+
+func TestPlanDispatch_SmallGainDoesNotMove(t *testing.T) {
+	// Moving j2 to b saves a couple of travel minutes, less than the move penalty.
+	jobs := []DispatchJob{
+		job("j1", 9, 11, 44.430, 26.100, "a"),
+		job("j2", 12, 14, 44.432, 26.110, "a"),
+		job("j3", 9, 11, 44.432, 26.111, "b"),
+	}
+	cleaners := []DispatchCleaner{dayCleaner("a"), dayCleaner("b")}
+
+	_, proposed := PlanDispatch(jobs, cleaners, DefaultDispatchConfig())
+	if proposed.Moves != 0 {
+		t.Errorf("Moves = %d, want 0; assignments = %v", proposed.Moves, proposed.Assignments)
+	}
+}
+
+func TestPlanDispatch_RespectsConstraints(t *testing.T) {
+	deep := job("deep", 9, 12, 44.43, 26.10, "")
+	deep.ServiceType = "deep_cleaning"
+	late := job("late", 17, 19, 44.43, 26.10, "")
+	offDay := DispatchCleaner{ID: "off", Available: false}
+
+	jobs := []DispatchJob{deep, late, job("std", 9, 12, 44.43, 26.10, "off")}
+	cleaners := []DispatchCleaner{
+		dayCleaner("std-only", "standard_cleaning"),
+		dayCleaner("deep-only", "deep_cleaning"),
+		offDay,
+	}
+
+	_, proposed := PlanDispatch(jobs, cleaners, DefaultDispatchConfig())
+	if got := proposed.Assignments["deep"]; got != "deep-only" {
+		t.Errorf("deep -> %q, want deep-only", got)
+	}
+	if got := proposed.Assignments["std"]; got != "std-only" {
+		t.Errorf("std -> %q, want std-only (off is unavailable)", got)
+	}
+	if got := proposed.Assignments["late"]; got != "" {
+		t.Errorf("late -> %q, want unassigned (ends after every window)", got)
+	}
+	if proposed.Unassigned != 1 {
+		t.Errorf("Unassigned = %d, want 1", proposed.Unassigned)
+	}
+}
+
+func TestPlanDispatch_TravelTimeBlocksTightHandover(t *testing.T) {
+	// j2 starts when j1 ends, 10 km away: the same cleaner cannot do both.
+	jobs := []DispatchJob{
+		job("j1", 9, 11, 44.43, 26.00, "a"),
+		job("j2", 11, 13, 44.43, 26.13, ""),
+	}
+	cleaners := []DispatchCleaner{dayCleaner("a"), dayCleaner("b")}
+
+	_, proposed := PlanDispatch(jobs, cleaners, DefaultDispatchConfig())
+	if proposed.Assignments["j2"] != "b" {
+		t.Errorf("j2 -> %q, want b", proposed.Assignments["j2"])
+	}
+}
+
+func TestPlanDispatch_LockedJobsStay(t *testing.T) {
+	started := job("started", 9, 12, 44.43, 26.00, "a")
+	started.Locked = true
+	jobs := []DispatchJob{started, job("j2", 13, 15, 44.43, 26.20, "")}
+	cleaners := []DispatchCleaner{dayCleaner("b")} // a is no longer listed
+
+	_, proposed := PlanDispatch(jobs, cleaners, DefaultDispatchConfig())
+	if proposed.Assignments["started"] != "a" {
+		t.Errorf("locked job moved to %q", proposed.Assignments["started"])
+	}
+	if proposed.Assignments["j2"] != "b" {
+		t.Errorf("j2 -> %q, want b", proposed.Assignments["j2"])
+	}
+}
+
+func TestPlanDispatch_AvoidsOvertime(t *testing.T) {
+	config := DefaultDispatchConfig()
+	config.RegularHours = 4
+	config.DefaultTravelMinutes = 0
+	jobs := []DispatchJob{
+		{ID: "j1", StartMicros: h(8), EndMicros: h(11), Current: "a"},
+		{ID: "j2", StartMicros: h(12), EndMicros: h(15), Current: "a"},
+	}
+	cleaners := []DispatchCleaner{dayCleaner("a"), dayCleaner("b")}
+
+	current, proposed := PlanDispatch(jobs, cleaners, config)
+	if current.OvertimeMinutes != 120 {
+		t.Errorf("current OvertimeMinutes = %.0f, want 120", current.OvertimeMinutes)
+	}
+	if proposed.OvertimeMinutes != 0 || proposed.Moves != 1 {
+		t.Errorf("proposed overtime = %.0f, moves = %d, want 0 and 1", proposed.OvertimeMinutes, proposed.Moves)
+	}
+}
+
+func TestCheckDispatch(t *testing.T) {
+	jobs := []DispatchJob{
+		job("j1", 9, 11, 44.43, 26.10, "a"),
+		job("j2", 10, 12, 44.43, 26.10, "b"),
+	}
+	cleaners := []DispatchCleaner{dayCleaner("a"), dayCleaner("b")}
+	config := DefaultDispatchConfig()
+
+	if bad := CheckDispatch(jobs, cleaners, map[string]string{"j1": "a", "j2": "b"}, config, []string{"a", "b"}); len(bad) != 0 {
+		t.Errorf("infeasible = %v, want none", bad)
+	}
+	bad := CheckDispatch(jobs, cleaners, map[string]string{"j1": "a", "j2": "a"}, config, []string{"a", "b"})
+	if len(bad) != 1 || bad[0] != "a" {
+		t.Errorf("infeasible = %v, want [a] (overlapping jobs)", bad)
+	}
+}
@@ -35,8 +35,9 @@ type BookingSlot struct {
 type PlacementResult struct {
 	StartMicros int64
 	EndMicros   int64
-	SlotIndex   int     // which client time slot was used (0-based)
-	GapScoreH   float64 // total surrounding gap in hours (lower = tighter packing)
+	SlotIndex   int          // which client time slot was used (0-based)
+	GapScoreH   float64      // total surrounding gap in hours (lower = tighter packing)
+	Interval    FreeInterval // free interval the job was placed in
 	Found       bool
 }
 
@@ -102,6 +103,7 @@ type placementCandidate struct {
 	slotIndex   int
 	minGap      int64 // min(gap to left edge, gap to right edge) of free interval
 	totalGap    int64 // sum of both gaps within the free interval
+	free        FreeInterval
 }
 
 // FindOptimalPlacement finds the best position for a job within free intervals,
@@ -142,6 +144,7 @@ func FindOptimalPlacement(freeIntervals []FreeInterval, clientSlots []TimeSlot,
 		EndMicros:   best.endMicros,
 		SlotIndex:   best.slotIndex,
 		GapScoreH:   float64(best.totalGap) / float64(HourMicros),
+		Interval:    best.free,
 		Found:       true,
 	}
 }
@@ -158,6 +161,7 @@ func evaluatePlacement(best **placementCandidate, free FreeInterval, jobStart, j
 		slotIndex:   slotIdx,
 		minGap:      minGap,
 		totalGap:    totalGap,
+		free:        free,
 	}
 
 	if *best == nil || isBetterPlacement(candidate, *best) {
@@ -193,11 +197,12 @@ type DatedTimeSlot struct {
 
 // DatedPlacementResult extends PlacementResult with the matched date.
 type DatedPlacementResult struct {
-	Date        string  // "2006-01-02" — which date was matched
+	Date        string // "2006-01-02" — which date was matched
 	StartMicros int64
 	EndMicros   int64
-	SlotIndex   int     // original client time slot index (0-based)
-	GapScoreH   float64 // total surrounding gap in hours
+	SlotIndex   int          // original client time slot index (0-based)
+	GapScoreH   float64      // total surrounding gap in hours
+	Interval    FreeInterval // free interval the job was placed in
 	Found       bool
 }
 
@@ -292,6 +297,7 @@ func FindBestPlacementAcrossDates(
 				EndMicros:   placement.EndMicros,
 				SlotIndex:   originalSlotIndex,
 				GapScoreH:   placement.GapScoreH,
+				Interval:    placement.Interval,
 				Found:       true,
 			}
 			bestScore = score
@@ -316,53 +322,75 @@ type ScoreInput struct {
 	Config           MatchConfig
 }
 
+// ScoreBreakdown lists the contribution of every component of a match score.
+// Penalties are negative. Raw is the sum of all components before the result
+// is clamped to [0, 100] into Total.
+type ScoreBreakdown struct {
+	Base        float64
+	Rating      float64
+	Experience  float64
+	AreaMatch   float64
+	Packing     float64
+	Unavailable float64
+	DailyLoad   float64
+	WeeklyLoad  float64
+	Raw         float64
+	Total       float64
+}
+
 // ComputeMatchScore returns a 0-100 score for a cleaner suggestion.
 func ComputeMatchScore(input ScoreInput) float64 {
-	score := 50.0
+	return ComputeScoreBreakdown(input).Total
+}
+
+// ComputeScoreBreakdown computes the match score and the contribution of
+// each of its components.
+func ComputeScoreBreakdown(input ScoreInput) ScoreBreakdown {
+	b := ScoreBreakdown{Base: 50.0}
 
 	// Rating bonus: 0-5 rating * 5 = max 25 points.
-	score += input.RatingAvg * 5.0
+	b.Rating = input.RatingAvg * 5.0
 
 	// Experience bonus: up to 15 points.
-	jobsBonus := float64(input.TotalJobsDone) / 100.0 * 15.0
-	if jobsBonus > 15.0 {
-		jobsBonus = 15.0
+	b.Experience = float64(input.TotalJobsDone) / 100.0 * 15.0
+	if b.Experience > 15.0 {
+		b.Experience = 15.0
 	}
-	score += jobsBonus
 
 	// Area match bonus: 10 points.
 	if input.IsAreaMatch {
-		score += 10.0
+		b.AreaMatch = 10.0
 	}
 
 	// Packing bonus: 5 points for adjacent placement (gap = 0).
 	if input.PlacementFound && input.GapScoreH == 0 {
-		score += 5.0
+		b.Packing = 5.0
 	}
 
 	// Unavailability penalty.
 	if !input.PlacementFound {
-		score -= 40.0
+		b.Unavailable = -40.0
 	}
 
 	// Workload balancing: penalize overloaded workers.
 	if input.Config.LoadBalanceWeight > 0 {
 		// Daily load: each existing booking today costs loadWeight/2 points.
-		dailyPenalty := float64(input.DayBookingCount) * (input.Config.LoadBalanceWeight / 2.0)
-		score -= dailyPenalty
+		b.DailyLoad = -float64(input.DayBookingCount) * (input.Config.LoadBalanceWeight / 2.0)
 
 		// Weekly load: each booking this week costs loadWeight/10 points.
-		weeklyPenalty := float64(input.WeekBookingCount) * (input.Config.LoadBalanceWeight / 10.0)
-		score -= weeklyPenalty
+		b.WeeklyLoad = -float64(input.WeekBookingCount) * (input.Config.LoadBalanceWeight / 10.0)
 	}
 
+	b.Raw = b.Base + b.Rating + b.Experience + b.AreaMatch + b.Packing + b.Unavailable + b.DailyLoad + b.WeeklyLoad
+
 	// Clamp to [0, 100].
-	if score < 0 {
-		score = 0
+	b.Total = b.Raw
+	if b.Total < 0 {
+		b.Total = 0
 	}
-	if score > 100 {
-		score = 100
+	if b.Total > 100 {
+		b.Total = 100
 	}
 
-	return score
+	return b
 }
@@ -382,6 +382,9 @@ func TestFindBestPlacementAcrossDates_SingleDate(t *testing.T) {
 	if result.SlotIndex != 0 {
 		t.Errorf("SlotIndex = %d, want 0", result.SlotIndex)
 	}
+	if result.Interval != (FreeInterval{Start: h(8), End: h(17)}) {
+		t.Errorf("Interval = %+v, want 08:00-17:00", result.Interval)
+	}
 }
 
 func TestFindBestPlacementAcrossDates_MultiDate(t *testing.T) {
@@ -597,6 +600,47 @@ func TestComputeMatchScore_UnavailablePenalty(t *testing.T) {
 	}
 }
 
+func TestComputeScoreBreakdown(t *testing.T) {
+	input := ScoreInput{
+		RatingAvg:        4.0,
+		TotalJobsDone:    20,
+		IsAreaMatch:      true,
+		PlacementFound:   true,
+		GapScoreH:        1.0,
+		DayBookingCount:  3,
+		WeekBookingCount: 10,
+		Config:           DefaultMatchConfig(),
+	}
+	b := ComputeScoreBreakdown(input)
+
+	want := ScoreBreakdown{
+		Base:       50,
+		Rating:     20,
+		Experience: 3,
+		AreaMatch:  10,
+		DailyLoad:  -15,
+		WeeklyLoad: -10,
+		Raw:        58,
+		Total:      58,
+	}
+	if b != want {
+		t.Errorf("breakdown = %+v, want %+v", b, want)
+	}
+	if score := ComputeMatchScore(input); score != b.Total {
+		t.Errorf("ComputeMatchScore = %.1f, breakdown total = %.1f", score, b.Total)
+	}
+
+	// Raw keeps the unclamped sum.
+	input.PlacementFound = false
+	input.RatingAvg = 0
+	input.TotalJobsDone = 0
+	input.DayBookingCount = 10
+	b = ComputeScoreBreakdown(input)
+	if b.Raw >= 0 || b.Total != 0 {
+		t.Errorf("Raw = %.1f, Total = %.1f, want negative raw clamped to 0", b.Raw, b.Total)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // MatchConfig
 // ---------------------------------------------------------------------------
@@ -0,0 +1,306 @@
+package matching
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Offline simulation: replay a booking stream through the matching algorithm
+// under a given MatchConfig and measure how the workforce would be used.
+// ---------------------------------------------------------------------------
+
+// SimWindow is a working window in "HH:MM" format.
+type SimWindow struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// SimOverride replaces a cleaner's weekly window on one date.
+type SimOverride struct {
+	Available bool   `json:"available"`
+	Start     string `json:"start,omitempty"`
+	End       string `json:"end,omitempty"`
+}
+
+// SimCleaner is one cleaner in a simulation snapshot. Weekly maps the day of
+// week (0=Sunday) to the cleaner's working window after the company schedule
+// has been applied; days missing from the map are days off.
+type SimCleaner struct {
+	ID        string                 `json:"id"`
+	Areas     []string               `json:"areas"`
+	RatingAvg float64                `json:"ratingAvg"`
+	JobsDone  int                    `json:"jobsDone"`
+	Weekly    map[int]SimWindow      `json:"weekly"`
+	Overrides map[string]SimOverride `json:"overrides,omitempty"` // keyed by "2006-01-02"
+}
+
+// SimSlot is one of a request's preferred time slots.
+type SimSlot struct {
+	Date  string `json:"date"`
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// SimRequest is one booking request of the replayed stream.
+type SimRequest struct {
+	ID            string    `json:"id"`
+	Area          string    `json:"area"`
+	DurationHours float64   `json:"durationHours"`
+	Slots         []SimSlot `json:"slots"`
+}
+
+// SimSnapshot is the input of a simulation: the workforce and the booking
+// requests in arrival order.
+type SimSnapshot struct {
+	Cleaners []SimCleaner `json:"cleaners"`
+	Requests []SimRequest `json:"requests"`
+}
+
+// SimReport summarises one simulation run.
+type SimReport struct {
+	Config          MatchConfig
+	Requests        int
+	Fulfilled       int
+	FulfilmentRate  float64 // Fulfilled / Requests
+	BookedHours     float64
+	AvailableHours  float64 // working hours of all cleaners over the simulated dates
+	Utilisation     float64 // BookedHours / AvailableHours
+	IdleGapHours    float64 // idle time between consecutive jobs on working days
+	WorkingDays     int     // cleaner-days with at least one job
+	AvgIdleGapHours float64 // IdleGapHours / WorkingDays
+	Gini            float64 // inequality of booked hours across cleaners, 0 = equal
+	BackfillRate    float64 // share of requests where unavailable cleaners were shown
+}
+
+// Simulate replays the snapshot's requests in order. Each request goes to the
+// best-scored available cleaner, as if the client always picked the first
+// suggestion, and the booking is added to that cleaner's schedule before the
+// next request is matched.
+func Simulate(snapshot SimSnapshot, config MatchConfig) (SimReport, error) {
+	report := SimReport{Config: config, Requests: len(snapshot.Requests)}
+	buffer := config.BufferMicros()
+
+	// Simulated schedule: cleaner index -> date -> bookings sorted by start.
+	schedule := make([]map[string][]BookingSlot, len(snapshot.Cleaners))
+	for i := range schedule {
+		schedule[i] = map[string][]BookingSlot{}
+	}
+	bookedHours := make([]float64, len(snapshot.Cleaners))
+
+	var firstDate, lastDate time.Time
+	backfilled := 0
+
+	for _, req := range snapshot.Requests {
+		datedSlots := make([]DatedTimeSlot, len(req.Slots))
+		dates := map[string]time.Time{}
+		for i, s := range req.Slots {
+			d, err := time.Parse("2006-01-02", s.Date)
+			if err != nil {
+				return SimReport{}, fmt.Errorf("request %s: invalid slot date %q: %w", req.ID, s.Date, err)
+			}
+			datedSlots[i] = DatedTimeSlot{
+				Date:        s.Date,
+				DayOfWeek:   int(d.Weekday()),
+				StartMicros: HHMMToMicros(s.Start),
+				EndMicros:   HHMMToMicros(s.End),
+				SlotIndex:   i,
+			}
+			dates[s.Date] = d
+			if firstDate.IsZero() || d.Before(firstDate) {
+				firstDate = d
+			}
+			if d.After(lastDate) {
+				lastDate = d
+			}
+		}
+		if len(dates) == 0 {
+			continue
+		}
+		weekStart, weekEnd := simWeekRange(dates)
+		jobDuration := int64(req.DurationHours * float64(HourMicros))
+
+		type scored struct {
+			idx       int
+			score     float64
+			placement DatedPlacementResult
+		}
+		var available []scored
+		unavailable := 0
+
+		for ci, c := range snapshot.Cleaners {
+			if !containsString(c.Areas, req.Area) {
+				continue
+			}
+
+			var dateAvails []DateAvailability
+			for dateStr, d := range dates {
+				start, end, ok := c.window(dateStr, d)
+				if !ok {
+					continue
+				}
+				busy := schedule[ci][dateStr]
+				dateAvails = append(dateAvails, DateAvailability{
+					Date:          dateStr,
+					AvailStart:    start,
+					AvailEnd:      end,
+					FreeIntervals: ComputeFreeIntervals(start, end, busy, buffer),
+					BookingCount:  len(busy),
+				})
+			}
+			sort.Slice(dateAvails, func(i, j int) bool { return dateAvails[i].Date < dateAvails[j].Date })
+
+			placement := FindBestPlacementAcrossDates(dateAvails, datedSlots, jobDuration, config)
+
+			weekCount := 0
+			for dateStr, slots := range schedule[ci] {
+				if dateStr >= weekStart && dateStr <= weekEnd {
+					weekCount += len(slots)
+				}
+			}
+			dayCount := 0
+			if placement.Found {
+				dayCount = len(schedule[ci][placement.Date])
+			}
+
+			score := ComputeMatchScore(ScoreInput{
+				RatingAvg:        c.RatingAvg,
+				TotalJobsDone:    c.JobsDone,
+				IsAreaMatch:      true,
+				PlacementFound:   placement.Found,
+				GapScoreH:        placement.GapScoreH,
+				DayBookingCount:  dayCount,
+				WeekBookingCount: weekCount,
+				Config:           config,
+			})
+			if placement.Found {
+				available = append(available, scored{idx: ci, score: score, placement: placement})
+			} else {
+				unavailable++
+			}
+		}
+
+		// Mirror the suggestion list: unavailable cleaners are only shown to
+		// pad a short list of available ones.
+		shown := min(len(available), config.MaxResults)
+		if shown < config.MinAvailableCount && unavailable > 0 && shown < config.MaxResults {
+			backfilled++
+		}
+		if len(available) == 0 {
+			continue
+		}
+
+		sort.SliceStable(available, func(i, j int) bool { return available[i].score > available[j].score })
+		best := available[0]
+		slots := append(schedule[best.idx][best.placement.Date], BookingSlot{
+			StartMicros: best.placement.StartMicros,
+			EndMicros:   best.placement.EndMicros,
+		})
+		sort.Slice(slots, func(i, j int) bool { return slots[i].StartMicros < slots[j].StartMicros })
+		schedule[best.idx][best.placement.Date] = slots
+		bookedHours[best.idx] += req.DurationHours
+		report.Fulfilled++
+	}
+
+	// Available hours over every date between the first and last requested date.
+	if !firstDate.IsZero() {
+		for d := firstDate; !d.After(lastDate); d = d.AddDate(0, 0, 1) {
+			dateStr := d.Format("2006-01-02")
+			for _, c := range snapshot.Cleaners {
+				if start, end, ok := c.window(dateStr, d); ok {
+					report.AvailableHours += float64(end-start) / float64(HourMicros)
+				}
+			}
+		}
+	}
+
+	for ci := range schedule {
+		report.BookedHours += bookedHours[ci]
+		for _, slots := range schedule[ci] {
+			if len(slots) == 0 {
+				continue
+			}
+			report.WorkingDays++
+			for i := 1; i < len(slots); i++ {
+				if gap := slots[i].StartMicros - slots[i-1].EndMicros; gap > 0 {
+					report.IdleGapHours += float64(gap) / float64(HourMicros)
+				}
+			}
+		}
+	}
+
+	if report.Requests > 0 {
+		report.FulfilmentRate = float64(report.Fulfilled) / float64(report.Requests)
+		report.BackfillRate = float64(backfilled) / float64(report.Requests)
+	}
+	if report.AvailableHours > 0 {
+		report.Utilisation = report.BookedHours / report.AvailableHours
+	}
+	if report.WorkingDays > 0 {
+		report.AvgIdleGapHours = report.IdleGapHours / float64(report.WorkingDays)
+	}
+	report.Gini = Gini(bookedHours)
+
+	return report, nil
+}
+
+// Gini returns the Gini coefficient of the values: 0 when all are equal,
+// approaching 1 when a single value holds the whole total.
+func Gini(values []float64) float64 {
+	n := len(values)
+	if n == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	var sum, weighted float64
+	for i, v := range sorted {
+		sum += v
+		weighted += float64(i+1) * v
+	}
+	if sum == 0 {
+		return 0
+	}
+	return 2*weighted/(float64(n)*sum) - float64(n+1)/float64(n)
+}
+
+// window returns the cleaner's working window on a date: the date override if
+// present, otherwise the weekly window for that day.
+func (c SimCleaner) window(dateStr string, d time.Time) (start, end int64, ok bool) {
+	if o, found := c.Overrides[dateStr]; found {
+		if !o.Available {
+			return 0, 0, false
+		}
+		return HHMMToMicros(o.Start), HHMMToMicros(o.End), true
+	}
+	w, found := c.Weekly[int(d.Weekday())]
+	if !found {
+		return 0, 0, false
+	}
+	return HHMMToMicros(w.Start), HHMMToMicros(w.End), true
+}
+
+// simWeekRange returns the Monday-Sunday week of the earliest date as
+// "2006-01-02" strings, matching the weekly workload window used online.
+func simWeekRange(dates map[string]time.Time) (string, string) {
+	var weekStart time.Time
+	for _, d := range dates {
+		if weekStart.IsZero() || d.Before(weekStart) {
+			weekStart = d
+		}
+	}
+	offset := (int(weekStart.Weekday()) + 6) % 7 // Monday = 0
+	weekStart = weekStart.AddDate(0, 0, -offset)
+	return weekStart.Format("2006-01-02"), weekStart.AddDate(0, 0, 6).Format("2006-01-02")
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
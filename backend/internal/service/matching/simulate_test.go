@@ -0,0 +1,165 @@
+package matching
+
+import (
+	"math"
+	"testing"
+)
+
+// ---------------------------------------------------------------------------
+// Gini
+// ---------------------------------------------------------------------------
+
+func TestGini(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		want   float64
+	}{
+		{name: "empty", values: nil, want: 0},
+		{name: "all zero", values: []float64{0, 0, 0}, want: 0},
+		{name: "perfectly equal", values: []float64{5, 5, 5, 5}, want: 0},
+		{name: "one holds everything", values: []float64{0, 0, 0, 8}, want: 0.75},
+		{name: "order does not matter", values: []float64{3, 1, 2}, want: 2.0 / 9.0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Gini(tt.values)
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Gini(%v) = %.4f, want %.4f", tt.values, got, tt.want)
+			}
+		})
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Simulate
+// ---------------------------------------------------------------------------
+
+func weekdays(start, end string) map[int]SimWindow {
+	w := map[int]SimWindow{}
+	for d := 1; d <= 5; d++ {
+		w[d] = SimWindow{Start: start, End: end}
+	}
+	return w
+}
+
+func simRequest(id, date, start, end string, hours float64) SimRequest {
+	return SimRequest{
+		ID:            id,
+		Area:          "centru",
+		DurationHours: hours,
+		Slots:         []SimSlot{{Date: date, Start: start, End: end}},
+	}
+}
+
+func TestSimulate_LoadBalancingSpreadsWork(t *testing.T) {
+	// 2026-03-02 is a Monday.
+	snapshot := SimSnapshot{
+		Cleaners: []SimCleaner{
+			{ID: "a", Areas: []string{"centru"}, RatingAvg: 5, Weekly: weekdays("08:00", "16:00")},
+			{ID: "b", Areas: []string{"centru"}, RatingAvg: 4, Weekly: weekdays("08:00", "16:00")},
+		},
+		Requests: []SimRequest{
+			simRequest("r1", "2026-03-02", "08:00", "16:00", 2),
+			simRequest("r2", "2026-03-02", "08:00", "16:00", 2),
+		},
+	}
+
+	balanced, err := Simulate(snapshot, DefaultMatchConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balanced.Fulfilled != 2 || balanced.FulfilmentRate != 1 {
+		t.Errorf("Fulfilled = %d (%.2f), want 2 (1.00)", balanced.Fulfilled, balanced.FulfilmentRate)
+	}
+	if balanced.Gini != 0 {
+		t.Errorf("Gini with load balancing = %.2f, want 0", balanced.Gini)
+	}
+	if balanced.AvailableHours != 16 || balanced.Utilisation != 0.25 {
+		t.Errorf("AvailableHours = %.1f, Utilisation = %.2f, want 16.0, 0.25", balanced.AvailableHours, balanced.Utilisation)
+	}
+
+	config := DefaultMatchConfig()
+	config.LoadBalanceWeight = 0
+	greedy, err := Simulate(snapshot, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if greedy.Gini != 0.5 {
+		t.Errorf("Gini without load balancing = %.2f, want 0.50", greedy.Gini)
+	}
+	if greedy.WorkingDays != 1 {
+		t.Errorf("WorkingDays = %d, want 1", greedy.WorkingDays)
+	}
+}
+
+func TestSimulate_BufferAndUnfulfilled(t *testing.T) {
+	snapshot := SimSnapshot{
+		Cleaners: []SimCleaner{
+			{ID: "a", Areas: []string{"centru"}, Weekly: weekdays("08:00", "12:00")},
+			{ID: "other-area", Areas: []string{"nord"}, Weekly: weekdays("08:00", "12:00")},
+		},
+		Requests: []SimRequest{
+			simRequest("r1", "2026-03-02", "08:00", "12:00", 2),
+			simRequest("r2", "2026-03-02", "08:00", "12:00", 2),
+		},
+	}
+
+	// With a 15 minute buffer the second 2h job no longer fits in the 4h day.
+	report, err := Simulate(snapshot, DefaultMatchConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Fulfilled != 1 {
+		t.Errorf("Fulfilled = %d, want 1", report.Fulfilled)
+	}
+
+	config := DefaultMatchConfig()
+	config.BufferMinutes = 0
+	report, err = Simulate(snapshot, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Fulfilled != 2 {
+		t.Errorf("Fulfilled without buffer = %d, want 2", report.Fulfilled)
+	}
+	if report.IdleGapHours != 0 {
+		t.Errorf("IdleGapHours = %.2f, want 0 (jobs packed back to back)", report.IdleGapHours)
+	}
+}
+
+func TestSimulate_OverrideDayOff(t *testing.T) {
+	snapshot := SimSnapshot{
+		Cleaners: []SimCleaner{
+			{
+				ID:        "a",
+				Areas:     []string{"centru"},
+				Weekly:    weekdays("08:00", "16:00"),
+				Overrides: map[string]SimOverride{"2026-03-02": {Available: false}},
+			},
+		},
+		Requests: []SimRequest{simRequest("r1", "2026-03-02", "08:00", "16:00", 2)},
+	}
+
+	report, err := Simulate(snapshot, DefaultMatchConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Fulfilled != 0 {
+		t.Errorf("Fulfilled = %d, want 0", report.Fulfilled)
+	}
+	if report.AvailableHours != 0 {
+		t.Errorf("AvailableHours = %.1f, want 0", report.AvailableHours)
+	}
+	if report.BackfillRate != 1 {
+		t.Errorf("BackfillRate = %.2f, want 1.00", report.BackfillRate)
+	}
+}
+
+func TestSimulate_InvalidDate(t *testing.T) {
+	snapshot := SimSnapshot{Requests: []SimRequest{simRequest("bad", "02/03/2026", "08:00", "16:00", 2)}}
+	if _, err := Simulate(snapshot, DefaultMatchConfig()); err == nil {
+		t.Error("expected error for invalid slot date")
+	}
+}
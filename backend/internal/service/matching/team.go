@@ -0,0 +1,185 @@
+package matching
+
+import (
+	"math"
+	"sort"
+)
+
+// ---------------------------------------------------------------------------
+// Team scheduling: jobs too long for one cleaner are split across N cleaners
+// from the same company working the same time window.
+// ---------------------------------------------------------------------------
+
+// TeamCandidate holds one cleaner's pre-computed availability for team matching.
+type TeamCandidate struct {
+	ID               string
+	DateAvails       []DateAvailability
+	WeekBookingCount int
+}
+
+// TeamPlacementResult is the shared window chosen for a team and its members.
+type TeamPlacementResult struct {
+	Date        string // "2006-01-02"
+	StartMicros int64
+	EndMicros   int64
+	SlotIndex   int      // original client time slot index (0-based)
+	MemberIDs   []string // chosen members, tightest fit first
+	GapScoreH   float64  // summed surrounding gap across all members, in hours
+	Found       bool
+}
+
+// TeamDuration returns how many cleaners are needed for a job of laborHours
+// when no cleaner may work more than maxHoursPerCleaner, and the resulting
+// on-site window in hours (rounded up to the nearest half hour).
+// requestedSize raises the team above the minimum when the client asks for it.
+func TeamDuration(laborHours, maxHoursPerCleaner float64, requestedSize int) (teamSize int, windowHours float64) {
+	teamSize = 1
+	if maxHoursPerCleaner > 0 && laborHours > maxHoursPerCleaner {
+		teamSize = int(math.Ceil(laborHours / maxHoursPerCleaner))
+	}
+	if requestedSize > teamSize {
+		teamSize = requestedSize
+	}
+	windowHours = math.Ceil(laborHours/float64(teamSize)*2) / 2
+	return teamSize, windowHours
+}
+
+type teamMemberFit struct {
+	id       string
+	minGap   int64
+	totalGap int64
+	dayLoad  int
+	weekLoad int
+}
+
+// FindTeamPlacement finds a single window, inside one of the client's time
+// slots, in which at least teamSize candidates are simultaneously free. Start
+// times are taken from the left/right pack positions of every candidate's free
+// intervals, so the window is always flush with at least one member's schedule.
+// Among feasible windows it prefers the smallest summed gap across the chosen
+// members, then the lowest combined load, then the earliest client slot.
+func FindTeamPlacement(candidates []TeamCandidate, datedSlots []DatedTimeSlot, teamSize int, jobDurationMicros int64, config MatchConfig) TeamPlacementResult {
+	if teamSize < 1 || len(candidates) < teamSize {
+		return TeamPlacementResult{Found: false}
+	}
+
+	var best *TeamPlacementResult
+	var bestCost float64
+
+	for _, slot := range datedSlots {
+		// Collect free intervals of every candidate that can still take a job that day.
+		type memberDay struct {
+			id       string
+			free     []FreeInterval
+			dayLoad  int
+			weekLoad int
+		}
+		var members []memberDay
+		for _, c := range candidates {
+			for _, da := range c.DateAvails {
+				if da.Date != slot.Date {
+					continue
+				}
+				if config.MaxJobsPerDay > 0 && da.BookingCount >= config.MaxJobsPerDay {
+					break
+				}
+				members = append(members, memberDay{id: c.ID, free: da.FreeIntervals, dayLoad: da.BookingCount, weekLoad: c.WeekBookingCount})
+				break
+			}
+		}
+		if len(members) < teamSize {
+			continue
+		}
+
+		// Candidate start times: left/right pack within each member's free intervals.
+		starts := map[int64]bool{}
+		for _, m := range members {
+			for _, f := range m.free {
+				intStart := max(slot.StartMicros, f.Start)
+				intEnd := min(slot.EndMicros, f.End)
+				if intEnd-intStart < jobDurationMicros {
+					continue
+				}
+				starts[intStart] = true
+				starts[intEnd-jobDurationMicros] = true
+			}
+		}
+		sortedStarts := make([]int64, 0, len(starts))
+		for s := range starts {
+			sortedStarts = append(sortedStarts, s)
+		}
+		sort.Slice(sortedStarts, func(i, j int) bool { return sortedStarts[i] < sortedStarts[j] })
+
+		for _, start := range sortedStarts {
+			end := start + jobDurationMicros
+
+			var fits []teamMemberFit
+			for _, m := range members {
+				for _, f := range m.free {
+					if f.Start <= start && end <= f.End {
+						gapBefore := start - f.Start
+						gapAfter := f.End - end
+						fits = append(fits, teamMemberFit{
+							id:       m.id,
+							minGap:   min(gapBefore, gapAfter),
+							totalGap: gapBefore + gapAfter,
+							dayLoad:  m.dayLoad,
+							weekLoad: m.weekLoad,
+						})
+						break
+					}
+				}
+			}
+			if len(fits) < teamSize {
+				continue
+			}
+
+			sort.SliceStable(fits, func(i, j int) bool {
+				a, b := fits[i], fits[j]
+				if a.minGap != b.minGap {
+					return a.minGap < b.minGap
+				}
+				if a.totalGap != b.totalGap {
+					return a.totalGap < b.totalGap
+				}
+				if a.dayLoad != b.dayLoad {
+					return a.dayLoad < b.dayLoad
+				}
+				if a.weekLoad != b.weekLoad {
+					return a.weekLoad < b.weekLoad
+				}
+				return a.id < b.id
+			})
+			chosen := fits[:teamSize]
+
+			var gapMicros int64
+			load := 0
+			ids := make([]string, teamSize)
+			for i, f := range chosen {
+				gapMicros += f.totalGap
+				load += f.dayLoad
+				ids[i] = f.id
+			}
+			gapH := float64(gapMicros) / float64(HourMicros)
+			cost := gapH + float64(load)*0.5
+
+			if best == nil || cost < bestCost || (cost == bestCost && slot.SlotIndex < best.SlotIndex) {
+				best = &TeamPlacementResult{
+					Date:        slot.Date,
+					StartMicros: start,
+					EndMicros:   end,
+					SlotIndex:   slot.SlotIndex,
+					MemberIDs:   ids,
+					GapScoreH:   gapH,
+					Found:       true,
+				}
+				bestCost = cost
+			}
+		}
+	}
+
+	if best == nil {
+		return TeamPlacementResult{Found: false}
+	}
+	return *best
+}
@@ -0,0 +1,187 @@
+package matching
+
+import (
+	"testing"
+)
+
+// ---------------------------------------------------------------------------
+// TeamDuration
+// ---------------------------------------------------------------------------
+
+func TestTeamDuration(t *testing.T) {
+	tests := []struct {
+		name       string
+		labor      float64
+		maxHours   float64
+		requested  int
+		wantSize   int
+		wantWindow float64
+	}{
+		{name: "fits one cleaner", labor: 6, maxHours: 12, requested: 0, wantSize: 1, wantWindow: 6},
+		{name: "exactly at limit", labor: 12, maxHours: 12, requested: 0, wantSize: 1, wantWindow: 12},
+		{name: "14h move-out needs two", labor: 14, maxHours: 12, requested: 0, wantSize: 2, wantWindow: 7},
+		{name: "uneven split rounds up to half hour", labor: 25, maxHours: 12, requested: 0, wantSize: 3, wantWindow: 8.5},
+		{name: "client requests bigger team", labor: 8, maxHours: 12, requested: 4, wantSize: 4, wantWindow: 2},
+		{name: "request below minimum is ignored", labor: 30, maxHours: 12, requested: 2, wantSize: 3, wantWindow: 10},
+		{name: "no limit configured", labor: 20, maxHours: 0, requested: 0, wantSize: 1, wantWindow: 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			size, window := TeamDuration(tt.labor, tt.maxHours, tt.requested)
+			if size != tt.wantSize || window != tt.wantWindow {
+				t.Errorf("TeamDuration(%.1f, %.1f, %d) = (%d, %.1f), want (%d, %.1f)",
+					tt.labor, tt.maxHours, tt.requested, size, window, tt.wantSize, tt.wantWindow)
+			}
+		})
+	}
+}
+
+// ---------------------------------------------------------------------------
+// FindTeamPlacement
+// ---------------------------------------------------------------------------
+
+func freeDay(date string, busy ...BookingSlot) DateAvailability {
+	return DateAvailability{
+		Date:          date,
+		AvailStart:    h(8),
+		AvailEnd:      h(17),
+		FreeIntervals: ComputeFreeIntervals(h(8), h(17), busy, 0),
+		BookingCount:  len(busy),
+	}
+}
+
+func TestFindTeamPlacement(t *testing.T) {
+	const day = "2026-03-02"
+	slot := []DatedTimeSlot{{Date: day, DayOfWeek: 1, StartMicros: h(8), EndMicros: h(17), SlotIndex: 0}}
+
+	tests := []struct {
+		name        string
+		candidates  []TeamCandidate
+		slots       []DatedTimeSlot
+		teamSize    int
+		duration    int64
+		wantFound   bool
+		wantStart   int64
+		wantMembers []string
+	}{
+		{
+			name: "two fully free cleaners share the morning",
+			candidates: []TeamCandidate{
+				{ID: "a", DateAvails: []DateAvailability{freeDay(day)}},
+				{ID: "b", DateAvails: []DateAvailability{freeDay(day)}},
+			},
+			slots:       slot,
+			teamSize:    2,
+			duration:    h(7),
+			wantFound:   true,
+			wantStart:   h(8),
+			wantMembers: []string{"a", "b"},
+		},
+		{
+			name: "window shifts to where both are free",
+			candidates: []TeamCandidate{
+				{ID: "a", DateAvails: []DateAvailability{freeDay(day, BookingSlot{StartMicros: h(8), EndMicros: h(10)})}},
+				{ID: "b", DateAvails: []DateAvailability{freeDay(day)}},
+			},
+			slots:       slot,
+			teamSize:    2,
+			duration:    h(7),
+			wantFound:   true,
+			wantStart:   h(10),
+			wantMembers: []string{"a", "b"},
+		},
+		{
+			name: "no common window",
+			candidates: []TeamCandidate{
+				{ID: "a", DateAvails: []DateAvailability{freeDay(day, BookingSlot{StartMicros: h(12), EndMicros: h(13)})}},
+				{ID: "b", DateAvails: []DateAvailability{freeDay(day, BookingSlot{StartMicros: h(9), EndMicros: h(10)})}},
+			},
+			slots:     slot,
+			teamSize:  2,
+			duration:  h(6),
+			wantFound: false,
+		},
+		{
+			name: "picks the two tightest fits out of three",
+			candidates: []TeamCandidate{
+				{ID: "loose", DateAvails: []DateAvailability{freeDay(day)}},
+				{ID: "tight1", DateAvails: []DateAvailability{freeDay(day, BookingSlot{StartMicros: h(14), EndMicros: h(17)})}},
+				{ID: "tight2", DateAvails: []DateAvailability{freeDay(day, BookingSlot{StartMicros: h(14), EndMicros: h(17)})}},
+			},
+			slots:       slot,
+			teamSize:    2,
+			duration:    h(6),
+			wantFound:   true,
+			wantStart:   h(8),
+			wantMembers: []string{"tight1", "tight2"},
+		},
+		{
+			name: "not enough candidates",
+			candidates: []TeamCandidate{
+				{ID: "a", DateAvails: []DateAvailability{freeDay(day)}},
+			},
+			slots:     slot,
+			teamSize:  2,
+			duration:  h(4),
+			wantFound: false,
+		},
+		{
+			name: "candidate at max jobs is skipped",
+			candidates: []TeamCandidate{
+				{ID: "a", DateAvails: []DateAvailability{freeDay(day)}},
+				{ID: "busy", DateAvails: []DateAvailability{{Date: day, AvailStart: h(8), AvailEnd: h(17), FreeIntervals: []FreeInterval{{Start: h(8), End: h(17)}}, BookingCount: 6}}},
+				{ID: "c", DateAvails: []DateAvailability{freeDay(day)}},
+			},
+			slots:       slot,
+			teamSize:    2,
+			duration:    h(4),
+			wantFound:   true,
+			wantStart:   h(8),
+			wantMembers: []string{"a", "c"},
+		},
+		{
+			name: "falls through to second date",
+			candidates: []TeamCandidate{
+				{ID: "a", DateAvails: []DateAvailability{freeDay("2026-03-03")}},
+				{ID: "b", DateAvails: []DateAvailability{freeDay(day), freeDay("2026-03-03")}},
+			},
+			slots: []DatedTimeSlot{
+				{Date: day, DayOfWeek: 1, StartMicros: h(8), EndMicros: h(17), SlotIndex: 0},
+				{Date: "2026-03-03", DayOfWeek: 2, StartMicros: h(9), EndMicros: h(17), SlotIndex: 1},
+			},
+			teamSize:    2,
+			duration:    h(5),
+			wantFound:   true,
+			wantStart:   h(9),
+			wantMembers: []string{"a", "b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FindTeamPlacement(tt.candidates, tt.slots, tt.teamSize, tt.duration, DefaultMatchConfig())
+			if got.Found != tt.wantFound {
+				t.Fatalf("Found = %v, want %v", got.Found, tt.wantFound)
+			}
+			if !tt.wantFound {
+				return
+			}
+			if got.StartMicros != tt.wantStart {
+				t.Errorf("Start = %s, want %s", MicrosToHHMM(got.StartMicros), MicrosToHHMM(tt.wantStart))
+			}
+			if got.EndMicros != tt.wantStart+tt.duration {
+				t.Errorf("End = %s, want %s", MicrosToHHMM(got.EndMicros), MicrosToHHMM(tt.wantStart+tt.duration))
+			}
+			if len(got.MemberIDs) != len(tt.wantMembers) {
+				t.Fatalf("members = %v, want %v", got.MemberIDs, tt.wantMembers)
+			}
+			for i := range tt.wantMembers {
+				if got.MemberIDs[i] != tt.wantMembers[i] {
+					t.Errorf("members = %v, want %v", got.MemberIDs, tt.wantMembers)
+					break
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,506 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stripe/stripe-go/v81"
+
+	db "helpmeclean-backend/internal/db/generated"
+	"helpmeclean-backend/internal/service/recurrence"
+)
+
+const (
+	// paymentHoldsBatch is how many captures and renewals one holds run
+	// handles.
+	paymentHoldsBatch = 50
+	// holdValidity is how long Stripe keeps an authorization hold on a card.
+	holdValidity = 7 * 24 * time.Hour
+)
+
+// CaptureOptions selects how a booking payment is collected.
+type CaptureOptions struct {
+	// Manual places an authorization hold instead of charging the card.
+	Manual bool
+	// OveragePct is how far above the estimate the client agreed the final
+	// total may go; the hold is raised by it. Zero when the client did not
+	// consent, so at most the estimate is captured.
+	OveragePct float64
+}
+
+// holdAmount returns the amount to authorize for an estimate, in bani.
+func (o CaptureOptions) holdAmount(estimateBani int64) int64 {
+	if o.OveragePct <= 0 {
+		return estimateBani
+	}
+	return int64(math.Round(float64(estimateBani) * (1 + o.OveragePct/100)))
+}
+
+// CapturePayment captures the authorization hold of a completed booking for
+// its final total, capped at the authorized amount, and recomputes the
+// platform fee on the captured amount. Bookings paid upfront have nothing to
+// capture. A failed capture leaves the hold in place, so it can be retried.
+func (s *Service) CapturePayment(ctx context.Context, booking db.Booking) error {
+	if !booking.StripePaymentIntentID.Valid || booking.StripePaymentIntentID.String == "" {
+		return fmt.Errorf("payment: booking %s has no payment intent", booking.ReferenceCode)
+	}
+	piID := booking.StripePaymentIntentID.String
+
+	txn, err := s.queries.GetPaymentTransactionByStripePI(ctx, piID)
+	if err != nil {
+		return fmt.Errorf("payment: failed to load transaction for PI %s: %w", piID, err)
+	}
+	if txn.CaptureMethod != string(stripe.PaymentIntentCaptureMethodManual) {
+		return nil
+	}
+	if txn.Status != db.PaymentTransactionStatusAuthorized {
+		return fmt.Errorf("payment: PI %s is not authorized (status %s)", piID, txn.Status)
+	}
+
+	total := booking.FinalTotal
+	if !total.Valid {
+		total = booking.EstimatedTotal
+	}
+	totalRON, err := numericToFloat64(total)
+	if err != nil {
+		return fmt.Errorf("payment: booking %s has no valid total amount: %w", booking.ReferenceCode, err)
+	}
+	totalBani := int64(math.Round(totalRON * 100))
+	authorized := int64(txn.AmountAuthorized.Int32)
+	if totalBani > authorized {
+		log.Printf("payment: booking %s final total %d bani exceeds the %d bani hold, capturing the hold",
+			booking.ReferenceCode, totalBani, authorized)
+	}
+
+	commissionPct := 0.0
+	if booking.PlatformCommissionPct.Valid {
+		if f64, err := numericToFloat64(booking.PlatformCommissionPct); err == nil {
+			commissionPct = f64
+		}
+	}
+	captureBani, applicationFee := captureSplit(totalBani, authorized, commissionPct)
+
+	// Record the captured split first; the transaction stays authorized until
+	// the capture succeeds, so a retry recomputes it.
+	if _, err := s.queries.UpdatePaymentTransactionCaptured(ctx, db.UpdatePaymentTransactionCapturedParams{
+		StripePaymentIntentID: piID,
+		AmountTotal:           int32(captureBani),
+		AmountCompany:         int32(captureBani - applicationFee),
+		AmountPlatformFee:     int32(applicationFee),
+	}); err != nil {
+		return fmt.Errorf("payment: failed to update transaction amounts for PI %s: %w", piID, err)
+	}
+
+	pi, err := s.captureHold(piID, captureBani, applicationFee)
+	if err != nil {
+		return err
+	}
+
+	var chargeID string
+	if pi.LatestCharge != nil {
+		chargeID = pi.LatestCharge.ID
+	}
+	if _, err := s.queries.UpdatePaymentTransactionStatus(ctx, db.UpdatePaymentTransactionStatusParams{
+		StripePaymentIntentID: piID,
+		Status:                db.PaymentTransactionStatusSucceeded,
+		StripeChargeID:        pgtype.Text{String: chargeID, Valid: chargeID != ""},
+	}); err != nil {
+		return fmt.Errorf("payment: failed to update transaction for captured PI %s: %w", piID, err)
+	}
+	if _, err := s.queries.MarkBookingPaid(ctx, booking.ID); err != nil {
+		return fmt.Errorf("payment: failed to mark booking paid for captured PI %s: %w", piID, err)
+	}
+
+	log.Printf("payment: captured PI %s for booking %s, amount=%d of %d bani, fee=%d bani",
+		piID, booking.ReferenceCode, captureBani, authorized, applicationFee)
+	return nil
+}
+
+// captureSplit returns the amount to capture of a hold of authorized bani for
+// a final total, capped at the hold, and the platform fee on it.
+func captureSplit(totalBani, authorized int64, commissionPct float64) (capture, fee int64) {
+	capture = min(totalBani, authorized)
+	return capture, int64(math.Round(float64(capture) * commissionPct / 100.0))
+}
+
+// captureHold captures amount bani of a hold, taking fee bani of it as the
+// platform fee.
+func (s *Service) captureHold(piID string, amount, fee int64) (*stripe.PaymentIntent, error) {
+	params := &stripe.PaymentIntentCaptureParams{
+		AmountToCapture:      stripe.Int64(amount),
+		ApplicationFeeAmount: stripe.Int64(fee),
+	}
+	params.SetIdempotencyKey(fmt.Sprintf("capture-%s-%d", piID, amount))
+	pi, err := s.provider.CapturePaymentIntent(piID, params)
+	if err != nil {
+		return nil, fmt.Errorf("payment: failed to capture PI %s: %w", piID, err)
+	}
+	return pi, nil
+}
+
+// ReleasePaymentHold cancels the uncaptured authorization hold of a cancelled
+// booking, so the client's funds are released right away instead of when the
+// hold expires. Bookings without a hold are left alone.
+func (s *Service) ReleasePaymentHold(ctx context.Context, bookingID pgtype.UUID) error {
+	booking, err := s.queries.GetBookingByID(ctx, bookingID)
+	if err != nil {
+		return fmt.Errorf("payment: failed to load booking: %w", err)
+	}
+	if !booking.StripePaymentIntentID.Valid || booking.StripePaymentIntentID.String == "" {
+		return nil
+	}
+	piID := booking.StripePaymentIntentID.String
+
+	txn, err := s.queries.GetPaymentTransactionByStripePI(ctx, piID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("payment: failed to load transaction for PI %s: %w", piID, err)
+	}
+	if txn.CaptureMethod != string(stripe.PaymentIntentCaptureMethodManual) {
+		return nil
+	}
+	switch txn.Status {
+	case db.PaymentTransactionStatusPending, db.PaymentTransactionStatusRequiresAction,
+		db.PaymentTransactionStatusProcessing, db.PaymentTransactionStatusAuthorized:
+	default:
+		return nil
+	}
+
+	if err := s.cancelPaymentIntent(ctx, piID); err != nil {
+		return err
+	}
+	if _, err := s.queries.UpdateBookingPayment(ctx, db.UpdateBookingPaymentParams{
+		ID:                    booking.ID,
+		StripePaymentIntentID: booking.StripePaymentIntentID,
+		PaymentStatus:         pgtype.Text{String: "released", Valid: true},
+	}); err != nil {
+		return fmt.Errorf("payment: failed to update booking payment status: %w", err)
+	}
+
+	log.Printf("payment: released hold %s for booking %s", piID, booking.ReferenceCode)
+	return nil
+}
+
+// cancelPaymentIntent cancels an uncaptured PaymentIntent and its transaction.
+func (s *Service) cancelPaymentIntent(ctx context.Context, piID string) error {
+	if err := s.releaseHold(piID); err != nil {
+		return err
+	}
+	if _, err := s.queries.MarkPaymentTransactionCancelled(ctx, piID); err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("payment: failed to cancel transaction for PI %s: %w", piID, err)
+	}
+	return nil
+}
+
+// releaseHold cancels an uncaptured PaymentIntent, releasing its hold on the
+// client's card.
+func (s *Service) releaseHold(piID string) error {
+	params := &stripe.PaymentIntentCancelParams{
+		CancellationReason: stripe.String(string(stripe.PaymentIntentCancellationReasonRequestedByCustomer)),
+	}
+	if _, err := s.provider.CancelPaymentIntent(piID, params); err != nil {
+		return fmt.Errorf("payment: failed to cancel PI %s: %w", piID, err)
+	}
+	return nil
+}
+
+// ReauthorizeHold renews an authorization hold that will expire before its
+// booking takes place. A new hold for the same amount is placed off-session
+// with the card saved by the original one and the original is cancelled. If
+// the card is declined or needs the client to authenticate, the old hold is
+// left to expire, the booking is marked reauthorization_failed and the client
+// is asked to authorize the payment again.
+func (s *Service) ReauthorizeHold(ctx context.Context, txn db.PaymentTransaction) error {
+	booking, err := s.queries.GetBookingByID(ctx, txn.BookingID)
+	if err != nil {
+		return fmt.Errorf("payment: failed to load booking: %w", err)
+	}
+	if !txn.AuthorizedAt.Valid || !holdExpiresBefore(txn.AuthorizedAt.Time, booking.ScheduledDate.Time) {
+		return nil
+	}
+
+	old, err := s.provider.GetPaymentIntent(txn.StripePaymentIntentID)
+	if err != nil {
+		return fmt.Errorf("payment: failed to get PI %s: %w", txn.StripePaymentIntentID, err)
+	}
+	params, err := reauthorizationParams(old, booking)
+	if err != nil {
+		s.reauthorizationFailed(ctx, booking, "", "no saved payment method")
+		return err
+	}
+
+	pi, err := s.provider.CreatePaymentIntent(params)
+	if err != nil {
+		return fmt.Errorf("payment: failed to create reauthorization of PI %s: %w", old.ID, err)
+	}
+
+	// The transaction must exist before the hold is confirmed, so the
+	// webhooks for the new PaymentIntent find it.
+	metadata, _ := json.Marshal(map[string]string{
+		"booking_id":         uuidToString(booking.ID),
+		"reference_code":     booking.ReferenceCode,
+		"reauthorization_of": old.ID,
+	})
+	if _, err := s.queries.GetPaymentTransactionByStripePI(ctx, pi.ID); errors.Is(err, pgx.ErrNoRows) {
+		if _, err := s.queries.CreatePaymentTransaction(ctx, db.CreatePaymentTransactionParams{
+			BookingID:             booking.ID,
+			StripePaymentIntentID: pi.ID,
+			AmountTotal:           txn.AmountTotal,
+			AmountCompany:         txn.AmountCompany,
+			AmountPlatformFee:     txn.AmountPlatformFee,
+			Currency:              txn.Currency,
+			Status:                db.PaymentTransactionStatusPending,
+			Metadata:              metadata,
+			CaptureMethod:         txn.CaptureMethod,
+			AmountAuthorized:      pgtype.Int4{Int32: int32(pi.Amount), Valid: true},
+		}); err != nil {
+			return fmt.Errorf("payment: failed to create payment transaction record: %w", err)
+		}
+	} else if err != nil {
+		return fmt.Errorf("payment: failed to load transaction for PI %s: %w", pi.ID, err)
+	}
+
+	confirmed, reason := s.confirmReauthorization(pi.ID)
+	if confirmed == nil {
+		s.reauthorizationFailed(ctx, booking, pi.ID, reason)
+		return fmt.Errorf("payment: reauthorization of PI %s failed: %s", old.ID, reason)
+	}
+
+	var chargeID string
+	if confirmed.LatestCharge != nil {
+		chargeID = confirmed.LatestCharge.ID
+	}
+	if _, err := s.queries.MarkPaymentTransactionAuthorized(ctx, db.MarkPaymentTransactionAuthorizedParams{
+		StripePaymentIntentID: pi.ID,
+		AmountAuthorized:      pgtype.Int4{Int32: int32(confirmed.AmountCapturable), Valid: true},
+		StripeChargeID:        pgtype.Text{String: chargeID, Valid: chargeID != ""},
+	}); err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("payment: failed to record authorization of PI %s: %w", pi.ID, err)
+	}
+	if _, err := s.queries.UpdateBookingPayment(ctx, db.UpdateBookingPaymentParams{
+		ID:                    booking.ID,
+		StripePaymentIntentID: pgtype.Text{String: pi.ID, Valid: true},
+		PaymentStatus:         pgtype.Text{String: "authorized", Valid: true},
+	}); err != nil {
+		return fmt.Errorf("payment: failed to update booking with payment intent: %w", err)
+	}
+
+	if err := s.cancelPaymentIntent(ctx, old.ID); err != nil {
+		log.Printf("payment: warning: failed to release replaced hold %s: %v", old.ID, err)
+	}
+
+	log.Printf("payment: reauthorized hold %s as %s for booking %s, amount=%d bani",
+		old.ID, pi.ID, booking.ReferenceCode, confirmed.AmountCapturable)
+	return nil
+}
+
+// holdExpiresBefore reports whether a hold placed at authorizedAt expires
+// before a booking scheduled on the calendar date scheduled, in Romania.
+func holdExpiresBefore(authorizedAt, scheduled time.Time) bool {
+	return !recurrence.Date(authorizedAt.Add(holdValidity).In(recurrence.Location)).After(scheduled)
+}
+
+// reauthorizationParams returns the parameters of a hold that replaces old,
+// for the same amount and with the same card, platform fee and destination.
+func reauthorizationParams(old *stripe.PaymentIntent, booking db.Booking) (*stripe.PaymentIntentParams, error) {
+	if old.PaymentMethod == nil || old.Customer == nil || old.TransferData == nil || old.TransferData.Destination == nil {
+		return nil, fmt.Errorf("payment: PI %s has no saved payment method to reauthorize", old.ID)
+	}
+	params := &stripe.PaymentIntentParams{
+		Amount:               stripe.Int64(old.Amount),
+		Currency:             stripe.String(string(old.Currency)),
+		Customer:             stripe.String(old.Customer.ID),
+		PaymentMethod:        stripe.String(old.PaymentMethod.ID),
+		CaptureMethod:        stripe.String(string(stripe.PaymentIntentCaptureMethodManual)),
+		ApplicationFeeAmount: stripe.Int64(old.ApplicationFeeAmount),
+		TransferData: &stripe.PaymentIntentTransferDataParams{
+			Destination: stripe.String(old.TransferData.Destination.ID),
+		},
+	}
+	params.AddMetadata("booking_id", uuidToString(booking.ID))
+	params.AddMetadata("reference_code", booking.ReferenceCode)
+	params.AddMetadata("reauthorization_of", old.ID)
+	params.SetIdempotencyKey("reauth-" + old.ID)
+	return params, nil
+}
+
+// confirmReauthorization places a renewed hold off-session. It returns the
+// held PaymentIntent, or nil and why the hold could not be placed.
+func (s *Service) confirmReauthorization(piID string) (*stripe.PaymentIntent, string) {
+	confirmed, err := s.provider.ConfirmPaymentIntent(piID, &stripe.PaymentIntentConfirmParams{OffSession: stripe.Bool(true)})
+	if err == nil && confirmed.Status == stripe.PaymentIntentStatusRequiresCapture {
+		return confirmed, ""
+	}
+	var stripeErr *stripe.Error
+	switch {
+	case errors.As(err, &stripeErr) && stripeErr.Msg != "":
+		return nil, stripeErr.Msg
+	case err != nil:
+		return nil, err.Error()
+	default:
+		return nil, "the card requires authentication"
+	}
+}
+
+// reauthorizationFailed marks the booking's payment reauthorization_failed
+// and asks the client to authorize the payment again from the booking page.
+func (s *Service) reauthorizationFailed(ctx context.Context, booking db.Booking, piID, reason string) {
+	if piID != "" {
+		if _, err := s.queries.UpdatePaymentTransactionFailed(ctx, db.UpdatePaymentTransactionFailedParams{
+			StripePaymentIntentID: piID,
+			FailureReason:         pgtype.Text{String: reason, Valid: reason != ""},
+		}); err != nil {
+			log.Printf("payment: warning: failed to record failed reauthorization %s: %v", piID, err)
+		}
+	}
+
+	if _, err := s.queries.UpdateBookingPayment(ctx, db.UpdateBookingPaymentParams{
+		ID:                    booking.ID,
+		StripePaymentIntentID: booking.StripePaymentIntentID,
+		PaymentStatus:         pgtype.Text{String: "reauthorization_failed", Valid: true},
+	}); err != nil {
+		log.Printf("payment: warning: failed to update booking %s payment status: %v", booking.ReferenceCode, err)
+	}
+
+	data, _ := json.Marshal(map[string]string{"bookingId": uuidToString(booking.ID)})
+	if _, err := s.queries.CreateNotification(ctx, db.CreateNotificationParams{
+		UserID: booking.ClientUserID,
+		Type:   db.NotificationTypePaymentFailed,
+		Title:  "Plata nu a putut fi autorizata",
+		Body: fmt.Sprintf("Nu am putut reinnoi autorizarea platii pentru programarea %s din %s. Autorizati din nou plata din pagina programarii.",
+			booking.ReferenceCode, booking.ScheduledDate.Time.Format("02.01.2006")),
+		Data: data,
+	}); err != nil {
+		log.Printf("payment: warning: failed to notify client about failed reauthorization: %v", err)
+	}
+	log.Printf("payment: reauthorization failed for booking %s: %s", booking.ReferenceCode, reason)
+}
+
+// RunPaymentHolds captures the holds of completed bookings whose capture
+// failed and renews holds that expire before their booking. It is run
+// periodically by the job scheduler.
+func (s *Service) RunPaymentHolds(ctx context.Context) error {
+	bookings, err := s.queries.ListUncapturedCompletedBookings(ctx, paymentHoldsBatch)
+	if err != nil {
+		return fmt.Errorf("payment: failed to list uncaptured bookings: %w", err)
+	}
+	captureFailed := 0
+	for _, b := range bookings {
+		if err := s.CapturePayment(ctx, b); err != nil {
+			log.Printf("payment: capture retry failed for booking %s: %v", b.ReferenceCode, err)
+			captureFailed++
+		}
+	}
+
+	expiring, err := s.queries.ListExpiringAuthorizations(ctx, paymentHoldsBatch)
+	if err != nil {
+		return fmt.Errorf("payment: failed to list expiring authorizations: %w", err)
+	}
+	renewFailed := 0
+	for _, txn := range expiring {
+		if err := s.ReauthorizeHold(ctx, txn); err != nil {
+			log.Printf("payment: %v", err)
+			renewFailed++
+		}
+	}
+
+	if len(bookings) > 0 || len(expiring) > 0 {
+		log.Printf("payment: holds run: %d captures (%d failed), %d renewals (%d failed)",
+			len(bookings), captureFailed, len(expiring), renewFailed)
+	}
+	return nil
+}
+
+// handlePaymentIntentAuthorized processes payment_intent.amount_capturable_updated,
+// sent when a manual-capture hold is placed. Like a successful payment it
+// auto-confirms the booking and returns it when this hold confirmed it.
+// Renewed holds only update their transaction; ReauthorizeHold moves the
+// booking over to them.
+func (s *Service) handlePaymentIntentAuthorized(ctx context.Context, q *db.Queries, event stripe.Event) (*db.Booking, error) {
+	var pi stripe.PaymentIntent
+	if err := json.Unmarshal(event.Data.Raw, &pi); err != nil {
+		return nil, fmt.Errorf("payment: failed to unmarshal %s: %w", event.Type, err)
+	}
+
+	var chargeID string
+	if pi.LatestCharge != nil {
+		chargeID = pi.LatestCharge.ID
+	}
+	txn, err := q.MarkPaymentTransactionAuthorized(ctx, db.MarkPaymentTransactionAuthorizedParams{
+		StripePaymentIntentID: pi.ID,
+		AmountAuthorized:      pgtype.Int4{Int32: int32(pi.AmountCapturable), Valid: true},
+		StripeChargeID:        pgtype.Text{String: chargeID, Valid: chargeID != ""},
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		log.Printf("payment: %s for PI %s that is no longer pending, skipping", event.Type, pi.ID)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("payment: failed to record authorization for PI %s: %w", pi.ID, err)
+	}
+
+	before, err := q.GetBookingByID(ctx, txn.BookingID)
+	if err != nil {
+		return nil, fmt.Errorf("payment: failed to load booking for PI %s: %w", pi.ID, err)
+	}
+	if before.StripePaymentIntentID.String != pi.ID {
+		return nil, nil
+	}
+
+	booking, err := q.MarkBookingAuthorizedAndConfirmed(ctx, txn.BookingID)
+	if err != nil {
+		return nil, fmt.Errorf("payment: failed to mark booking authorized for PI %s: %w", pi.ID, err)
+	}
+
+	log.Printf("payment: %s processed for PI %s, booking %s, amount=%d bani, status=%s",
+		event.Type, pi.ID, uuidToString(txn.BookingID), pi.AmountCapturable, booking.Status)
+
+	if before.Status != db.BookingStatusConfirmed && booking.Status == db.BookingStatusConfirmed {
+		return &booking, nil
+	}
+	return nil, nil
+}
+
+// handlePaymentIntentCanceled processes payment_intent.canceled: a released,
+// replaced or expired hold. An expired hold that was still the booking's
+// payment leaves the booking with payment status expired.
+func (s *Service) handlePaymentIntentCanceled(ctx context.Context, q *db.Queries, event stripe.Event) error {
+	var pi stripe.PaymentIntent
+	if err := json.Unmarshal(event.Data.Raw, &pi); err != nil {
+		return fmt.Errorf("payment: failed to unmarshal %s: %w", event.Type, err)
+	}
+
+	txn, err := q.MarkPaymentTransactionCancelled(ctx, pi.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("payment: failed to cancel transaction for PI %s: %w", pi.ID, err)
+	}
+
+	booking, err := q.GetBookingByID(ctx, txn.BookingID)
+	if err != nil {
+		return fmt.Errorf("payment: failed to load booking for PI %s: %w", pi.ID, err)
+	}
+	if booking.StripePaymentIntentID.String == pi.ID && booking.PaymentStatus.String == "authorized" {
+		if _, err := q.UpdateBookingPayment(ctx, db.UpdateBookingPaymentParams{
+			ID:                    booking.ID,
+			StripePaymentIntentID: booking.StripePaymentIntentID,
+			PaymentStatus:         pgtype.Text{String: "expired", Valid: true},
+		}); err != nil {
+			return fmt.Errorf("payment: failed to update booking payment status: %w", err)
+		}
+	}
+
+	log.Printf("payment: %s processed for PI %s, reason: %s", event.Type, pi.ID, pi.CancellationReason)
+	return nil
+}
@@ -0,0 +1,184 @@
+package payment
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stripe/stripe-go/v81"
+
+	db "helpmeclean-backend/internal/db/generated"
+	"helpmeclean-backend/internal/service/recurrence"
+)
+
+func TestCaptureOptionsHoldAmount(t *testing.T) {
+	tests := []struct {
+		overage  float64
+		estimate int64
+		want     int64
+	}{
+		{0, 20000, 20000},
+		{15, 20000, 23000},
+		{10, 12345, 13580},
+		{-5, 20000, 20000},
+	}
+	for _, tt := range tests {
+		opts := CaptureOptions{Manual: true, OveragePct: tt.overage}
+		if got := opts.holdAmount(tt.estimate); got != tt.want {
+			t.Errorf("holdAmount(%d) with %.0f%% overage = %d, want %d", tt.estimate, tt.overage, got, tt.want)
+		}
+	}
+}
+
+// placeHold authorizes amount bani on the test card, as a booking paid by
+// manual capture does.
+func placeHold(t *testing.T, f *FakeProvider, amount, fee int64) *stripe.PaymentIntent {
+	t.Helper()
+	pi, err := f.CreatePaymentIntent(&stripe.PaymentIntentParams{
+		Amount:               stripe.Int64(amount),
+		Currency:             stripe.String("ron"),
+		Customer:             stripe.String("cus_fake_client"),
+		PaymentMethod:        stripe.String("pm_card_visa"),
+		ApplicationFeeAmount: stripe.Int64(fee),
+		CaptureMethod:        stripe.String(string(stripe.PaymentIntentCaptureMethodManual)),
+		TransferData:         &stripe.PaymentIntentTransferDataParams{Destination: stripe.String("acct_fake_company")},
+		Confirm:              stripe.Bool(true),
+	})
+	if err != nil || pi.Status != stripe.PaymentIntentStatusRequiresCapture || pi.AmountCapturable != amount {
+		t.Fatalf("CreatePaymentIntent() = %+v, %v", pi, err)
+	}
+	return pi
+}
+
+func TestCaptureFinalTotal(t *testing.T) {
+	// An estimate of 200 RON held with a 15% overage, at a 15% commission.
+	opts := CaptureOptions{Manual: true, OveragePct: 15}
+	tests := []struct {
+		name      string
+		total     int64
+		wantTotal int64
+		wantFee   int64
+	}{
+		{"below the estimate", 18000, 18000, 2700},
+		{"within the overage", 22000, 22000, 3300},
+		{"above the overage", 26000, 23000, 3450},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFakeProvider()
+			s := &Service{provider: f}
+			hold := placeHold(t, f, opts.holdAmount(20000), 3000)
+
+			amount, fee := captureSplit(tt.total, hold.AmountCapturable, 15)
+			if amount != tt.wantTotal || fee != tt.wantFee {
+				t.Fatalf("captureSplit(%d) = %d, %d; want %d, %d", tt.total, amount, fee, tt.wantTotal, tt.wantFee)
+			}
+			pi, err := s.captureHold(hold.ID, amount, fee)
+			if err != nil {
+				t.Fatalf("captureHold() error: %v", err)
+			}
+			if pi.Status != stripe.PaymentIntentStatusSucceeded || pi.AmountReceived != tt.wantTotal || pi.ApplicationFeeAmount != tt.wantFee {
+				t.Errorf("captured PaymentIntent = %s, %d bani, fee %d; want succeeded, %d, %d",
+					pi.Status, pi.AmountReceived, pi.ApplicationFeeAmount, tt.wantTotal, tt.wantFee)
+			}
+		})
+	}
+}
+
+func TestReleaseHold(t *testing.T) {
+	f, next := fakeWithEvents(t)
+	s := &Service{provider: f}
+	hold := placeHold(t, f, 23000, 3000)
+	if event := next(); event.Type != "payment_intent.amount_capturable_updated" {
+		t.Fatalf("event type = %s, want payment_intent.amount_capturable_updated", event.Type)
+	}
+
+	if err := s.releaseHold(hold.ID); err != nil {
+		t.Fatalf("releaseHold() error: %v", err)
+	}
+	if event := next(); event.Type != "payment_intent.canceled" {
+		t.Errorf("event type = %s, want payment_intent.canceled", event.Type)
+	}
+	pi, err := f.GetPaymentIntent(hold.ID)
+	if err != nil || pi.Status != stripe.PaymentIntentStatusCanceled || pi.AmountCapturable != 0 {
+		t.Errorf("released PaymentIntent = %+v, %v", pi, err)
+	}
+	if _, err := s.captureHold(hold.ID, 20000, 3000); err == nil {
+		t.Error("a released hold was captured")
+	}
+}
+
+func TestHoldExpiresBefore(t *testing.T) {
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, time.October, day, hour, minute, 0, 0, recurrence.Location)
+	}
+	date := func(day int) time.Time { return time.Date(2026, time.October, day, 0, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		authorized time.Time
+		scheduled  time.Time
+		want       bool
+	}{
+		{at(1, 10, 0), date(5), false},
+		{at(1, 10, 0), date(7), false},
+		{at(1, 10, 0), date(8), true},
+		{at(1, 10, 0), date(20), true},
+		// 23:30 in Bucharest is still October 1 there, though not in UTC.
+		{at(1, 23, 30), date(7), false},
+		{at(1, 23, 30), date(8), true},
+	}
+	for _, tt := range tests {
+		if got := holdExpiresBefore(tt.authorized, tt.scheduled); got != tt.want {
+			t.Errorf("holdExpiresBefore(%s, %s) = %v, want %v",
+				tt.authorized.Format(time.RFC3339), tt.scheduled.Format("2006-01-02"), got, tt.want)
+		}
+	}
+}
+
+func TestReauthorizeHoldForLaterBooking(t *testing.T) {
+	f := NewFakeProvider()
+	s := &Service{provider: f}
+	authorized := time.Now()
+	booking := db.Booking{
+		ReferenceCode: "HMC-0001",
+		ScheduledDate: pgtype.Date{Time: recurrence.Today().AddDate(0, 0, 10), Valid: true},
+	}
+	if !holdExpiresBefore(authorized, booking.ScheduledDate.Time) {
+		t.Fatal("a hold placed today does not expire before a booking 10 days out")
+	}
+	hold := placeHold(t, f, 23000, 3000)
+
+	old, err := f.GetPaymentIntent(hold.ID)
+	if err != nil {
+		t.Fatalf("GetPaymentIntent() error: %v", err)
+	}
+	params, err := reauthorizationParams(old, booking)
+	if err != nil {
+		t.Fatalf("reauthorizationParams() error: %v", err)
+	}
+	renewed, err := f.CreatePaymentIntent(params)
+	if err != nil {
+		t.Fatalf("CreatePaymentIntent() error: %v", err)
+	}
+	confirmed, reason := s.confirmReauthorization(renewed.ID)
+	if confirmed == nil {
+		t.Fatalf("confirmReauthorization() failed: %s", reason)
+	}
+	if confirmed.AmountCapturable != 23000 || confirmed.ApplicationFeeAmount != 3000 ||
+		confirmed.TransferData == nil || confirmed.TransferData.Destination.ID != "acct_fake_company" ||
+		confirmed.Metadata["reauthorization_of"] != hold.ID {
+		t.Errorf("renewed hold = %+v", confirmed)
+	}
+
+	if err := s.releaseHold(old.ID); err != nil {
+		t.Fatalf("releaseHold() error: %v", err)
+	}
+	if pi, _ := f.GetPaymentIntent(old.ID); pi.Status != stripe.PaymentIntentStatusCanceled {
+		t.Errorf("replaced hold status = %s, want canceled", pi.Status)
+	}
+
+	// A hold without a saved card cannot be renewed.
+	old.PaymentMethod = nil
+	if _, err := reauthorizationParams(old, booking); err == nil {
+		t.Error("reauthorizationParams() accepted a hold without a saved card")
+	}
+}
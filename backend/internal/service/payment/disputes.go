@@ -0,0 +1,286 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stripe/stripe-go/v81"
+
+	db "helpmeclean-backend/internal/db/generated"
+	"helpmeclean-backend/internal/service/ledger"
+)
+
+// maxEvidenceField is how many characters of each evidence text field are
+// sent to Stripe, which limits the evidence of a dispute in total.
+const maxEvidenceField = 20000
+
+// DisputeEvidence is the evidence of a dispute, assembled from the booking by
+// an admin and submitted to Stripe as text.
+type DisputeEvidence struct {
+	ProductDescription   string `json:"productDescription"`
+	CustomerName         string `json:"customerName"`
+	CustomerEmailAddress string `json:"customerEmailAddress"`
+	BillingAddress       string `json:"billingAddress"`
+	ServiceDate          string `json:"serviceDate"`
+	// AccessActivityLog is the booking history and the cleaners' check-ins.
+	AccessActivityLog string `json:"accessActivityLog"`
+	// CustomerCommunication is the chat transcript of the booking.
+	CustomerCommunication    string `json:"customerCommunication"`
+	RefundRefusalExplanation string `json:"refundRefusalExplanation"`
+	UncategorizedText        string `json:"uncategorizedText"`
+	// PhotoURLs are photos sent in the booking chat.
+	PhotoURLs []string `json:"photoUrls"`
+}
+
+// params converts the evidence to Stripe's evidence fields. Stripe takes the
+// customer communication only as a file, so the transcript, the photo links
+// and the free text go into the uncategorized text.
+func (e DisputeEvidence) params() *stripe.DisputeEvidenceParams {
+	var other []string
+	if e.UncategorizedText != "" {
+		other = append(other, e.UncategorizedText)
+	}
+	if e.CustomerCommunication != "" {
+		other = append(other, "Customer communication:\n"+e.CustomerCommunication)
+	}
+	if len(e.PhotoURLs) > 0 {
+		other = append(other, "Photos:\n"+strings.Join(e.PhotoURLs, "\n"))
+	}
+
+	return &stripe.DisputeEvidenceParams{
+		ProductDescription:       evidenceField(e.ProductDescription),
+		CustomerName:             evidenceField(e.CustomerName),
+		CustomerEmailAddress:     evidenceField(e.CustomerEmailAddress),
+		BillingAddress:           evidenceField(e.BillingAddress),
+		ServiceDate:              evidenceField(e.ServiceDate),
+		AccessActivityLog:        evidenceField(e.AccessActivityLog),
+		RefundRefusalExplanation: evidenceField(e.RefundRefusalExplanation),
+		UncategorizedText:        evidenceField(strings.Join(other, "\n\n")),
+	}
+}
+
+// evidenceField returns an evidence text field cut to maxEvidenceField
+// characters, or nil for an empty one so Stripe keeps what it has.
+func evidenceField(s string) *string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	if utf8.RuneCountInString(s) > maxEvidenceField {
+		s = string([]rune(s)[:maxEvidenceField])
+	}
+	return stripe.String(s)
+}
+
+// disputeOpen reports whether a dispute can still take or keep the money.
+func disputeOpen(status db.DisputeStatus) bool {
+	switch status {
+	case db.DisputeStatusWon, db.DisputeStatusLost, db.DisputeStatusWarningClosed:
+		return false
+	}
+	return true
+}
+
+// handleDisputeEvent processes a charge.dispute.* event. The dispute is stored
+// and linked to its payment and booking, payouts that contain the payment are
+// held while it is open and released once it is won, and what it moved on the
+// Stripe balance is posted to the ledger. Admins are notified when a dispute
+// opens and when it closes. Events older than the one the dispute was last
+// stored from are skipped: the newer event already carried the dispute's
+// later state and all of its balance transactions.
+func (s *Service) handleDisputeEvent(ctx context.Context, q *db.Queries, event stripe.Event) error {
+	var d stripe.Dispute
+	if err := json.Unmarshal(event.Data.Raw, &d); err != nil {
+		return fmt.Errorf("payment: failed to unmarshal %s: %w", event.Type, err)
+	}
+
+	var chargeID, piID string
+	if d.Charge != nil {
+		chargeID = d.Charge.ID
+	}
+	if d.PaymentIntent != nil {
+		piID = d.PaymentIntent.ID
+	}
+
+	var txnID pgtype.UUID
+	var booking *db.Booking
+	if piID != "" {
+		txn, err := q.GetPaymentTransactionByStripePI(ctx, piID)
+		switch {
+		case err == nil:
+			txnID = txn.ID
+			b, err := q.GetBookingByID(ctx, txn.BookingID)
+			if err != nil {
+				return fmt.Errorf("payment: failed to load booking for PI %s: %w", piID, err)
+			}
+			booking = &b
+		case errors.Is(err, pgx.ErrNoRows):
+			log.Printf("payment: dispute %s is for unknown PI %s", d.ID, piID)
+		default:
+			return fmt.Errorf("payment: failed to load transaction for PI %s: %w", piID, err)
+		}
+	}
+
+	before, err := q.GetPaymentDisputeByStripeID(ctx, d.ID)
+	known := err == nil
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("payment: failed to load dispute %s: %w", d.ID, err)
+	}
+
+	params := db.UpsertPaymentDisputeParams{
+		StripeDisputeID:      d.ID,
+		StripeChargeID:       chargeID,
+		PaymentTransactionID: txnID,
+		Amount:               int32(d.Amount),
+		Currency:             string(d.Currency),
+		Reason:               string(d.Reason),
+		Status:               db.DisputeStatus(d.Status),
+		EventCreatedAt:       pgtype.Timestamptz{Time: time.Unix(event.Created, 0), Valid: true},
+	}
+	if booking != nil {
+		params.BookingID = booking.ID
+		params.CompanyID = booking.CompanyID
+	}
+	if params.Currency == "" {
+		params.Currency = "ron"
+	}
+	if d.EvidenceDetails != nil && d.EvidenceDetails.DueBy > 0 {
+		params.EvidenceDueBy = pgtype.Timestamptz{Time: time.Unix(d.EvidenceDetails.DueBy, 0), Valid: true}
+	}
+	dp, err := q.UpsertPaymentDispute(ctx, params)
+	if errors.Is(err, pgx.ErrNoRows) {
+		log.Printf("payment: %s for dispute %s skipped, a newer event was processed", event.Type, d.ID)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("payment: failed to store dispute %s: %w", d.ID, err)
+	}
+
+	// A lost dispute keeps its payouts held: the company was already charged
+	// the disputed amount, so an admin cancels or recreates them.
+	if dp.PaymentTransactionID.Valid {
+		switch {
+		case disputeOpen(dp.Status):
+			held, err := q.HoldPayoutsForTransaction(ctx, dp.PaymentTransactionID)
+			if err != nil {
+				return fmt.Errorf("payment: failed to hold payouts for dispute %s: %w", d.ID, err)
+			}
+			for _, p := range held {
+				log.Printf("payment: payout %s held for dispute %s", uuidToString(p.ID), d.ID)
+			}
+		case dp.Status != db.DisputeStatusLost:
+			released, err := q.ReleaseHeldPayouts(ctx, dp.PaymentTransactionID)
+			if err != nil {
+				return fmt.Errorf("payment: failed to release payouts for dispute %s: %w", d.ID, err)
+			}
+			for _, p := range released {
+				log.Printf("payment: payout %s released after dispute %s", uuidToString(p.ID), d.ID)
+			}
+		}
+	}
+
+	// Each withdrawal and reinstatement is posted once; the event lists all
+	// of them.
+	for _, bt := range d.BalanceTransactions {
+		if bt == nil || bt.ID == "" || bt.Amount == 0 {
+			continue
+		}
+		if !dp.CompanyID.Valid {
+			log.Printf("payment: dispute %s has no company, balance transaction %s not posted to the ledger", d.ID, bt.ID)
+			continue
+		}
+		if _, err := s.ledger.PostWith(ctx, q, ledger.DisputeEntry(bt.ID, d.ID, dp.BookingID, dp.CompanyID, bt.Amount, time.Unix(bt.Created, 0))); err != nil {
+			return fmt.Errorf("payment: failed to post dispute %s to the ledger: %w", d.ID, err)
+		}
+	}
+
+	reference := "necunoscuta"
+	if booking != nil {
+		reference = booking.ReferenceCode
+	}
+	switch {
+	case !known:
+		due := "-"
+		if dp.EvidenceDueBy.Valid {
+			due = dp.EvidenceDueBy.Time.Format("02.01.2006")
+		}
+		s.notifyAdminsAboutDispute(ctx, q, dp, "Plata contestata", fmt.Sprintf(
+			"Clientul a contestat plata de %.2f lei pentru programarea %s (motiv: %s). Trimiteti dovezile pana la %s.",
+			float64(dp.Amount)/100, reference, dp.Reason, due))
+	case disputeOpen(before.Status) && !disputeOpen(dp.Status):
+		s.notifyAdminsAboutDispute(ctx, q, dp, "Disputa inchisa", fmt.Sprintf(
+			"Disputa pentru plata de %.2f lei a programarii %s s-a inchis cu statusul %s.",
+			float64(dp.Amount)/100, reference, dp.Status))
+	}
+
+	log.Printf("payment: %s processed for dispute %s, status=%s", event.Type, d.ID, dp.Status)
+	return nil
+}
+
+// notifyAdminsAboutDispute sends every admin a payment_disputed notification.
+func (s *Service) notifyAdminsAboutDispute(ctx context.Context, q *db.Queries, dp db.PaymentDispute, title, body string) {
+	admins, err := q.ListUsersByRole(ctx, db.UserRoleGlobalAdmin)
+	if err != nil {
+		log.Printf("payment: warning: failed to list admins for dispute %s: %v", dp.StripeDisputeID, err)
+		return
+	}
+	data, _ := json.Marshal(map[string]string{
+		"disputeId": uuidToString(dp.ID),
+		"bookingId": uuidToString(dp.BookingID),
+	})
+	for _, a := range admins {
+		if _, err := q.CreateNotification(ctx, db.CreateNotificationParams{
+			UserID: a.ID,
+			Type:   db.NotificationTypePaymentDisputed,
+			Title:  title,
+			Body:   body,
+			Data:   data,
+		}); err != nil {
+			log.Printf("payment: warning: failed to notify admin about dispute %s: %v", dp.StripeDisputeID, err)
+		}
+	}
+}
+
+// SubmitDisputeEvidence sends the stored evidence of a dispute to Stripe and
+// submits it for review. Evidence can be submitted once.
+func (s *Service) SubmitDisputeEvidence(ctx context.Context, disputeID pgtype.UUID) (db.PaymentDispute, error) {
+	dp, err := s.queries.GetPaymentDisputeByID(ctx, disputeID)
+	if err != nil {
+		return db.PaymentDispute{}, fmt.Errorf("payment: failed to load dispute: %w", err)
+	}
+	if dp.EvidenceSubmittedAt.Valid {
+		return db.PaymentDispute{}, fmt.Errorf("payment: evidence of dispute %s was already submitted", dp.StripeDisputeID)
+	}
+	if dp.Status != db.DisputeStatusNeedsResponse && dp.Status != db.DisputeStatusWarningNeedsResponse {
+		return db.PaymentDispute{}, fmt.Errorf("payment: dispute %s does not accept evidence (status %s)", dp.StripeDisputeID, dp.Status)
+	}
+	if len(dp.Evidence) == 0 {
+		return db.PaymentDispute{}, fmt.Errorf("payment: dispute %s has no evidence", dp.StripeDisputeID)
+	}
+	var evidence DisputeEvidence
+	if err := json.Unmarshal(dp.Evidence, &evidence); err != nil {
+		return db.PaymentDispute{}, fmt.Errorf("payment: failed to decode evidence of dispute %s: %w", dp.StripeDisputeID, err)
+	}
+
+	if _, err := s.provider.UpdateDispute(dp.StripeDisputeID, &stripe.DisputeParams{
+		Evidence: evidence.params(),
+		Submit:   stripe.Bool(true),
+	}); err != nil {
+		return db.PaymentDispute{}, fmt.Errorf("payment: failed to submit evidence of dispute %s: %w", dp.StripeDisputeID, err)
+	}
+
+	dp, err = s.queries.MarkPaymentDisputeEvidenceSubmitted(ctx, dp.ID)
+	if err != nil {
+		return db.PaymentDispute{}, fmt.Errorf("payment: failed to mark evidence of dispute submitted: %w", err)
+	}
+	log.Printf("payment: evidence submitted for dispute %s", dp.StripeDisputeID)
+	return dp, nil
+}
@@ -0,0 +1,52 @@
+package payment
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	db "helpmeclean-backend/internal/db/generated"
+)
+
+func TestDisputeEvidenceParams(t *testing.T) {
+	e := DisputeEvidence{
+		CustomerName:          "Ana Pop",
+		ServiceDate:           "02.03.2026",
+		CustomerCommunication: "Ana: Multumesc!",
+		PhotoURLs:             []string{"https://cdn/a.jpg", "https://cdn/b.jpg"},
+		AccessActivityLog:     strings.Repeat("ă", maxEvidenceField+10),
+	}
+	p := e.params()
+
+	if p.CustomerName == nil || *p.CustomerName != "Ana Pop" {
+		t.Errorf("CustomerName = %v, want Ana Pop", p.CustomerName)
+	}
+	if p.BillingAddress != nil {
+		t.Errorf("empty BillingAddress = %q, want nil", *p.BillingAddress)
+	}
+	if p.UncategorizedText == nil ||
+		!strings.Contains(*p.UncategorizedText, "Ana: Multumesc!") ||
+		!strings.Contains(*p.UncategorizedText, "https://cdn/b.jpg") {
+		t.Errorf("UncategorizedText = %v, want the transcript and the photos", p.UncategorizedText)
+	}
+	if n := utf8.RuneCountInString(*p.AccessActivityLog); n != maxEvidenceField {
+		t.Errorf("AccessActivityLog has %d characters, want %d", n, maxEvidenceField)
+	}
+}
+
+func TestDisputeOpen(t *testing.T) {
+	open := map[db.DisputeStatus]bool{
+		db.DisputeStatusWarningNeedsResponse: true,
+		db.DisputeStatusWarningUnderReview:   true,
+		db.DisputeStatusWarningClosed:        false,
+		db.DisputeStatusNeedsResponse:        true,
+		db.DisputeStatusUnderReview:          true,
+		db.DisputeStatusWon:                  false,
+		db.DisputeStatusLost:                 false,
+	}
+	for status, want := range open {
+		if got := disputeOpen(status); got != want {
+			t.Errorf("disputeOpen(%s) = %v, want %v", status, got, want)
+		}
+	}
+}
@@ -0,0 +1,521 @@
+package payment
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/stripe/stripe-go/v81"
+)
+
+// fakeDeclinedCard is the Stripe test payment method whose charges the fake
+// declines, so failed payments can be tried offline.
+const fakeDeclinedCard = "pm_card_chargeDeclined"
+
+// FakeProvider is an in-memory PaymentProvider for local development without
+// Stripe keys or network access. Like Stripe, it reports what happens to the
+// objects it creates through webhook events, delivered to the payment
+// service's HandleWebhookEvent a moment after the call.
+//
+// PaymentIntents the client would confirm in the browser (created without a
+// payment method) are confirmed by the fake as if the client paid with a test
+// card. Connect accounts are fully onboarded as soon as an onboarding link is
+// created, and payouts are paid at once.
+type FakeProvider struct {
+	mu sync.Mutex
+	// secret is the signature header of the fake's events; other payloads
+	// are rejected, so the webhook endpoint stays closed.
+	secret string
+	// delay is how long after a call its webhook events are delivered.
+	delay   time.Duration
+	handler func(ctx context.Context, payload []byte, sigHeader string) error
+	intents map[string]*stripe.PaymentIntent
+	charges map[string]*stripe.Charge
+	// refunds holds the refunds of each charge, newest first. Like Stripe,
+	// the fake never embeds them in the charge.
+	refunds  map[string][]*stripe.Refund
+	accounts map[string]*stripe.Account
+	balance  []*stripe.BalanceTransaction
+}
+
+// NewFakeProvider creates an empty fake provider.
+func NewFakeProvider() *FakeProvider {
+	return &FakeProvider{
+		secret:   fakeID("whsec"),
+		delay:    2 * time.Second,
+		intents:  map[string]*stripe.PaymentIntent{},
+		charges:  map[string]*stripe.Charge{},
+		refunds:  map[string][]*stripe.Refund{},
+		accounts: map[string]*stripe.Account{},
+	}
+}
+
+// SetWebhookHandler sets where the fake delivers its webhook events.
+func (f *FakeProvider) SetWebhookHandler(handler func(ctx context.Context, payload []byte, sigHeader string) error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.handler = handler
+}
+
+// fakeID returns a random Stripe-style ID with the given prefix.
+func fakeID(prefix string) string {
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return prefix + "_fake_" + hex.EncodeToString(b)
+}
+
+// emit delivers a webhook event for obj after the delay. obj is encoded
+// right away, so later changes are not part of the event. Callers hold f.mu.
+func (f *FakeProvider) emit(eventType string, obj any) {
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		log.Printf("payment: fake provider failed to encode %s: %v", eventType, err)
+		return
+	}
+	payload, _ := json.Marshal(map[string]any{
+		"id":          fakeID("evt"),
+		"object":      "event",
+		"type":        eventType,
+		"created":     time.Now().Unix(),
+		"api_version": stripe.APIVersion,
+		"livemode":    false,
+		"data":        map[string]json.RawMessage{"object": raw},
+	})
+	handler, delay, secret := f.handler, f.delay, f.secret
+	if handler == nil {
+		return
+	}
+	go func() {
+		time.Sleep(delay)
+		if err := handler(context.Background(), payload, secret); err != nil {
+			log.Printf("payment: fake provider failed to deliver %s: %v", eventType, err)
+		}
+	}()
+}
+
+// fakeError returns a Stripe invalid request error.
+func fakeError(format string, args ...any) error {
+	return &stripe.Error{
+		Type:           stripe.ErrorTypeInvalidRequest,
+		HTTPStatusCode: 400,
+		Msg:            fmt.Sprintf(format, args...),
+	}
+}
+
+// copyIntent returns a copy of a PaymentIntent for the caller, so the fake's
+// state only changes under its lock.
+func copyIntent(pi *stripe.PaymentIntent) *stripe.PaymentIntent {
+	c := *pi
+	return &c
+}
+
+func (f *FakeProvider) CreateCustomer(params *stripe.CustomerParams) (*stripe.Customer, error) {
+	return &stripe.Customer{
+		ID:       fakeID("cus"),
+		Email:    stripe.StringValue(params.Email),
+		Name:     stripe.StringValue(params.Name),
+		Metadata: params.Metadata,
+	}, nil
+}
+
+func (f *FakeProvider) CreateSetupIntent(params *stripe.SetupIntentParams) (*stripe.SetupIntent, error) {
+	id := fakeID("seti")
+	return &stripe.SetupIntent{
+		ID:           id,
+		ClientSecret: id + "_secret_fake",
+		Customer:     &stripe.Customer{ID: stripe.StringValue(params.Customer)},
+		Status:       stripe.SetupIntentStatusRequiresPaymentMethod,
+	}, nil
+}
+
+// GetPaymentMethod returns a Visa test card for any ID.
+func (f *FakeProvider) GetPaymentMethod(id string) (*stripe.PaymentMethod, error) {
+	return &stripe.PaymentMethod{
+		ID:   id,
+		Type: stripe.PaymentMethodTypeCard,
+		Card: &stripe.PaymentMethodCard{
+			Brand:    stripe.PaymentMethodCardBrandVisa,
+			Last4:    "4242",
+			ExpMonth: 12,
+			ExpYear:  int64(time.Now().Year() + 3),
+		},
+	}, nil
+}
+
+func (f *FakeProvider) DetachPaymentMethod(id string) (*stripe.PaymentMethod, error) {
+	return f.GetPaymentMethod(id)
+}
+
+func (f *FakeProvider) CreatePaymentIntent(params *stripe.PaymentIntentParams) (*stripe.PaymentIntent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	id := fakeID("pi")
+	pi := &stripe.PaymentIntent{
+		ID:                   id,
+		Object:               "payment_intent",
+		Amount:               stripe.Int64Value(params.Amount),
+		Currency:             stripe.Currency(stripe.StringValue(params.Currency)),
+		ApplicationFeeAmount: stripe.Int64Value(params.ApplicationFeeAmount),
+		CaptureMethod:        stripe.PaymentIntentCaptureMethodAutomatic,
+		ClientSecret:         id + "_secret_fake",
+		Metadata:             params.Metadata,
+		Status:               stripe.PaymentIntentStatusRequiresPaymentMethod,
+		Created:              time.Now().Unix(),
+	}
+	if params.CaptureMethod != nil {
+		pi.CaptureMethod = stripe.PaymentIntentCaptureMethod(*params.CaptureMethod)
+	}
+	if params.Customer != nil {
+		pi.Customer = &stripe.Customer{ID: *params.Customer}
+	}
+	if params.PaymentMethod != nil {
+		pi.PaymentMethod = &stripe.PaymentMethod{ID: *params.PaymentMethod}
+		pi.Status = stripe.PaymentIntentStatusRequiresConfirmation
+	}
+	if params.TransferData != nil && params.TransferData.Destination != nil {
+		pi.TransferData = &stripe.PaymentIntentTransferData{
+			Destination: &stripe.Account{ID: *params.TransferData.Destination},
+		}
+	}
+	f.intents[id] = pi
+
+	switch {
+	case stripe.BoolValue(params.Confirm):
+		if err := f.confirm(pi); err != nil {
+			return nil, err
+		}
+	case pi.PaymentMethod == nil:
+		// The client confirms in the browser: pay with the test card once
+		// the caller has recorded the PaymentIntent.
+		go func() {
+			time.Sleep(f.delay)
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			if pi.Status == stripe.PaymentIntentStatusRequiresPaymentMethod {
+				pi.PaymentMethod = &stripe.PaymentMethod{ID: fakeID("pm")}
+				_ = f.confirm(pi)
+			}
+		}()
+	}
+	return copyIntent(pi), nil
+}
+
+// confirm charges a PaymentIntent's payment method: a hold for manual
+// captures, a payment otherwise. Callers hold f.mu.
+func (f *FakeProvider) confirm(pi *stripe.PaymentIntent) error {
+	if pi.PaymentMethod == nil {
+		return fakeError("You cannot confirm this PaymentIntent because it's missing a payment method.")
+	}
+	if pi.PaymentMethod.ID == fakeDeclinedCard {
+		declined := &stripe.Error{
+			Type:           stripe.ErrorTypeCard,
+			Code:           stripe.ErrorCodeCardDeclined,
+			HTTPStatusCode: 402,
+			Msg:            "Your card was declined.",
+		}
+		pi.Status = stripe.PaymentIntentStatusRequiresPaymentMethod
+		pi.LastPaymentError = declined
+		f.emit("payment_intent.payment_failed", pi)
+		return declined
+	}
+
+	charge := &stripe.Charge{
+		ID:            fakeID("ch"),
+		Object:        "charge",
+		Amount:        pi.Amount,
+		Currency:      pi.Currency,
+		PaymentIntent: &stripe.PaymentIntent{ID: pi.ID},
+		Status:        stripe.ChargeStatusSucceeded,
+		Created:       time.Now().Unix(),
+	}
+	if pi.TransferData != nil {
+		charge.TransferData = &stripe.ChargeTransferData{Destination: pi.TransferData.Destination}
+	}
+	f.charges[pi.ID] = charge
+	pi.LatestCharge = &stripe.Charge{ID: charge.ID}
+	pi.LastPaymentError = nil
+
+	if pi.CaptureMethod == stripe.PaymentIntentCaptureMethodManual {
+		pi.Status = stripe.PaymentIntentStatusRequiresCapture
+		pi.AmountCapturable = pi.Amount
+		f.emit("payment_intent.amount_capturable_updated", pi)
+		return nil
+	}
+	f.succeed(pi, charge, pi.Amount)
+	return nil
+}
+
+// succeed collects amount of a PaymentIntent into the balance. Callers hold
+// f.mu.
+func (f *FakeProvider) succeed(pi *stripe.PaymentIntent, charge *stripe.Charge, amount int64) {
+	pi.Status = stripe.PaymentIntentStatusSucceeded
+	pi.AmountReceived = amount
+	pi.AmountCapturable = 0
+	charge.Amount = amount
+	charge.ApplicationFeeAmount = pi.ApplicationFeeAmount
+	charge.Captured = true
+	f.balance = append(f.balance, &stripe.BalanceTransaction{
+		ID:       fakeID("txn"),
+		Amount:   amount,
+		Currency: pi.Currency,
+		Created:  time.Now().Unix(),
+		Type:     stripe.BalanceTransactionTypeCharge,
+		Source: &stripe.BalanceTransactionSource{
+			ID:     charge.ID,
+			Type:   stripe.BalanceTransactionSourceTypeCharge,
+			Charge: &stripe.Charge{ID: charge.ID, PaymentIntent: &stripe.PaymentIntent{ID: pi.ID}},
+		},
+	})
+	f.emit("payment_intent.succeeded", pi)
+}
+
+func (f *FakeProvider) GetPaymentIntent(id string) (*stripe.PaymentIntent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	pi, ok := f.intents[id]
+	if !ok {
+		return nil, fakeError("No such payment_intent: '%s'", id)
+	}
+	return copyIntent(pi), nil
+}
+
+func (f *FakeProvider) ConfirmPaymentIntent(id string, params *stripe.PaymentIntentConfirmParams) (*stripe.PaymentIntent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	pi, ok := f.intents[id]
+	if !ok {
+		return nil, fakeError("No such payment_intent: '%s'", id)
+	}
+	if pi.Status != stripe.PaymentIntentStatusRequiresConfirmation && pi.Status != stripe.PaymentIntentStatusRequiresPaymentMethod {
+		return nil, fakeError("This PaymentIntent's status is %s, it cannot be confirmed.", pi.Status)
+	}
+	if params != nil && params.PaymentMethod != nil {
+		pi.PaymentMethod = &stripe.PaymentMethod{ID: *params.PaymentMethod}
+	}
+	if err := f.confirm(pi); err != nil {
+		return nil, err
+	}
+	return copyIntent(pi), nil
+}
+
+func (f *FakeProvider) CapturePaymentIntent(id string, params *stripe.PaymentIntentCaptureParams) (*stripe.PaymentIntent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	pi, ok := f.intents[id]
+	if !ok {
+		return nil, fakeError("No such payment_intent: '%s'", id)
+	}
+	if pi.Status == stripe.PaymentIntentStatusSucceeded {
+		return copyIntent(pi), nil // idempotent retry
+	}
+	if pi.Status != stripe.PaymentIntentStatusRequiresCapture {
+		return nil, fakeError("This PaymentIntent's status is %s, it cannot be captured.", pi.Status)
+	}
+	amount := pi.AmountCapturable
+	if params != nil && params.AmountToCapture != nil {
+		if *params.AmountToCapture > pi.AmountCapturable {
+			return nil, fakeError("The amount to capture exceeds the amount capturable.")
+		}
+		amount = *params.AmountToCapture
+	}
+	if params != nil && params.ApplicationFeeAmount != nil {
+		pi.ApplicationFeeAmount = *params.ApplicationFeeAmount
+	}
+	f.succeed(pi, f.charges[id], amount)
+	return copyIntent(pi), nil
+}
+
+func (f *FakeProvider) CancelPaymentIntent(id string, params *stripe.PaymentIntentCancelParams) (*stripe.PaymentIntent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	pi, ok := f.intents[id]
+	if !ok {
+		return nil, fakeError("No such payment_intent: '%s'", id)
+	}
+	if pi.Status == stripe.PaymentIntentStatusSucceeded || pi.Status == stripe.PaymentIntentStatusCanceled {
+		return nil, fakeError("This PaymentIntent's status is %s, it cannot be canceled.", pi.Status)
+	}
+	pi.Status = stripe.PaymentIntentStatusCanceled
+	pi.AmountCapturable = 0
+	if params != nil && params.CancellationReason != nil {
+		pi.CancellationReason = stripe.PaymentIntentCancellationReason(*params.CancellationReason)
+	}
+	f.emit("payment_intent.canceled", pi)
+	return copyIntent(pi), nil
+}
+
+func (f *FakeProvider) CreateRefund(params *stripe.RefundParams) (*stripe.Refund, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	piID := stripe.StringValue(params.PaymentIntent)
+	pi, ok := f.intents[piID]
+	if !ok || pi.Status != stripe.PaymentIntentStatusSucceeded {
+		return nil, fakeError("PaymentIntent '%s' has no successful charge to refund.", piID)
+	}
+	charge := f.charges[piID]
+	amount := charge.Amount - charge.AmountRefunded
+	if params.Amount != nil {
+		amount = *params.Amount
+	}
+	if amount <= 0 || amount > charge.Amount-charge.AmountRefunded {
+		return nil, fakeError("Refund amount (%d) is greater than unrefunded amount on charge (%d).", amount, charge.Amount-charge.AmountRefunded)
+	}
+
+	re := &stripe.Refund{
+		ID:            fakeID("re"),
+		Object:        "refund",
+		Amount:        amount,
+		Currency:      charge.Currency,
+		Charge:        &stripe.Charge{ID: charge.ID},
+		PaymentIntent: &stripe.PaymentIntent{ID: piID},
+		Status:        stripe.RefundStatusSucceeded,
+		Created:       time.Now().Unix(),
+	}
+	charge.AmountRefunded += amount
+	charge.Refunded = charge.AmountRefunded == charge.Amount
+	// Newest first, as Stripe lists them.
+	f.refunds[charge.ID] = append([]*stripe.Refund{re}, f.refunds[charge.ID]...)
+	f.balance = append(f.balance, &stripe.BalanceTransaction{
+		ID:       fakeID("txn"),
+		Amount:   -amount,
+		Currency: charge.Currency,
+		Created:  re.Created,
+		Type:     stripe.BalanceTransactionTypeRefund,
+		Source: &stripe.BalanceTransactionSource{
+			ID:     re.ID,
+			Type:   stripe.BalanceTransactionSourceTypeRefund,
+			Refund: &stripe.Refund{ID: re.ID, PaymentIntent: &stripe.PaymentIntent{ID: piID}},
+		},
+	})
+	f.emit("charge.refunded", charge)
+	return re, nil
+}
+
+// ListRefunds lists the refunds of a charge or PaymentIntent, newest first.
+func (f *FakeProvider) ListRefunds(params *stripe.RefundListParams) ([]*stripe.Refund, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []*stripe.Refund
+	for piID, charge := range f.charges {
+		if params.Charge != nil && *params.Charge != charge.ID {
+			continue
+		}
+		if params.PaymentIntent != nil && *params.PaymentIntent != piID {
+			continue
+		}
+		for _, re := range f.refunds[charge.ID] {
+			c := *re
+			out = append(out, &c)
+		}
+	}
+	return out, nil
+}
+
+// UpdateDispute accepts the evidence and puts the dispute under review. The
+// fake never opens disputes, so no event follows.
+func (f *FakeProvider) UpdateDispute(id string, params *stripe.DisputeParams) (*stripe.Dispute, error) {
+	return &stripe.Dispute{ID: id, Status: stripe.DisputeStatusUnderReview}, nil
+}
+
+func (f *FakeProvider) ListBalanceTransactions(params *stripe.BalanceTransactionListParams) ([]*stripe.BalanceTransaction, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []*stripe.BalanceTransaction
+	for _, bt := range f.balance {
+		if r := params.CreatedRange; r != nil {
+			if (r.GreaterThanOrEqual != 0 && bt.Created < r.GreaterThanOrEqual) || (r.LesserThan != 0 && bt.Created >= r.LesserThan) {
+				continue
+			}
+		}
+		out = append(out, bt)
+	}
+	return out, nil
+}
+
+func (f *FakeProvider) CreateAccount(params *stripe.AccountParams) (*stripe.Account, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	acct := &stripe.Account{
+		ID:       fakeID("acct"),
+		Object:   "account",
+		Type:     stripe.AccountType(stripe.StringValue(params.Type)),
+		Country:  stripe.StringValue(params.Country),
+		Email:    stripe.StringValue(params.Email),
+		Metadata: params.Metadata,
+	}
+	f.accounts[acct.ID] = acct
+	c := *acct
+	return &c, nil
+}
+
+// GetAccount returns an account. Accounts the fake does not know, e.g. from
+// before a restart, are returned fully onboarded.
+func (f *FakeProvider) GetAccount(id string) (*stripe.Account, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	acct, ok := f.accounts[id]
+	if !ok {
+		return &stripe.Account{ID: id, Object: "account", DetailsSubmitted: true, ChargesEnabled: true, PayoutsEnabled: true}, nil
+	}
+	c := *acct
+	return &c, nil
+}
+
+// CreateAccountLink completes the account's onboarding right away and links
+// back to the return URL.
+func (f *FakeProvider) CreateAccountLink(params *stripe.AccountLinkParams) (*stripe.AccountLink, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	id := stripe.StringValue(params.Account)
+	acct, ok := f.accounts[id]
+	if !ok {
+		acct = &stripe.Account{ID: id, Object: "account"}
+		f.accounts[id] = acct
+	}
+	acct.DetailsSubmitted = true
+	acct.ChargesEnabled = true
+	acct.PayoutsEnabled = true
+	f.emit("account.updated", acct)
+
+	url := stripe.StringValue(params.ReturnURL)
+	if url == "" {
+		url = "http://localhost:3000"
+	}
+	return &stripe.AccountLink{Object: "account_link", URL: url, Created: time.Now().Unix()}, nil
+}
+
+// CreatePayout pays out at once.
+func (f *FakeProvider) CreatePayout(params *stripe.PayoutParams) (*stripe.Payout, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	po := &stripe.Payout{
+		ID:          fakeID("po"),
+		Object:      "payout",
+		Amount:      stripe.Int64Value(params.Amount),
+		Currency:    stripe.Currency(stripe.StringValue(params.Currency)),
+		Description: stripe.StringValue(params.Description),
+		Metadata:    params.Metadata,
+		Status:      stripe.PayoutStatusPaid,
+		Created:     time.Now().Unix(),
+	}
+	f.emit("payout.paid", po)
+	return po, nil
+}
+
+// ConstructEvent accepts only the fake's own events.
+func (f *FakeProvider) ConstructEvent(payload []byte, sigHeader string) (stripe.Event, error) {
+	var event stripe.Event
+	if sigHeader != f.secret {
+		return event, fmt.Errorf("payment: not an event of the fake provider")
+	}
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return event, fmt.Errorf("payment: failed to decode fake event: %w", err)
+	}
+	return event, nil
+}
@@ -0,0 +1,158 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stripe/stripe-go/v81"
+)
+
+// fakeWithEvents returns a fake provider without delivery delay and a
+// function that waits for its next event.
+func fakeWithEvents(t *testing.T) (*FakeProvider, func() stripe.Event) {
+	t.Helper()
+	f := NewFakeProvider()
+	f.delay = 0
+	events := make(chan []byte, 10)
+	f.SetWebhookHandler(func(ctx context.Context, payload []byte, sigHeader string) error {
+		if _, err := f.ConstructEvent(payload, sigHeader); err != nil {
+			t.Errorf("ConstructEvent() rejected the fake's own event: %v", err)
+		}
+		events <- payload
+		return nil
+	})
+	next := func() stripe.Event {
+		t.Helper()
+		select {
+		case payload := <-events:
+			var event stripe.Event
+			if err := json.Unmarshal(payload, &event); err != nil {
+				t.Fatalf("failed to decode event: %v", err)
+			}
+			return event
+		case <-time.After(2 * time.Second):
+			t.Fatal("no webhook event delivered")
+			return stripe.Event{}
+		}
+	}
+	return f, next
+}
+
+func TestFakeProviderBrowserPayment(t *testing.T) {
+	f, next := fakeWithEvents(t)
+	pi, err := f.CreatePaymentIntent(&stripe.PaymentIntentParams{
+		Amount:               stripe.Int64(20000),
+		Currency:             stripe.String("ron"),
+		ApplicationFeeAmount: stripe.Int64(3000),
+		Metadata:             map[string]string{"booking_id": "b1"},
+	})
+	if err != nil {
+		t.Fatalf("CreatePaymentIntent() error: %v", err)
+	}
+	if pi.Status != stripe.PaymentIntentStatusRequiresPaymentMethod || pi.ClientSecret == "" {
+		t.Errorf("new PaymentIntent has status %s and client secret %q", pi.Status, pi.ClientSecret)
+	}
+
+	event := next()
+	if event.Type != "payment_intent.succeeded" {
+		t.Fatalf("event type = %s, want payment_intent.succeeded", event.Type)
+	}
+	var paid stripe.PaymentIntent
+	if err := json.Unmarshal(event.Data.Raw, &paid); err != nil {
+		t.Fatalf("failed to decode PaymentIntent: %v", err)
+	}
+	if paid.ID != pi.ID || paid.AmountReceived != 20000 || paid.LatestCharge == nil || paid.Metadata["booking_id"] != "b1" {
+		t.Errorf("succeeded PaymentIntent = %+v", paid)
+	}
+}
+
+func TestFakeProviderCaptureAndRefund(t *testing.T) {
+	f, next := fakeWithEvents(t)
+	pi, err := f.CreatePaymentIntent(&stripe.PaymentIntentParams{
+		Amount:               stripe.Int64(23000),
+		Currency:             stripe.String("ron"),
+		ApplicationFeeAmount: stripe.Int64(3450),
+		PaymentMethod:        stripe.String("pm_card_visa"),
+		CaptureMethod:        stripe.String(string(stripe.PaymentIntentCaptureMethodManual)),
+	})
+	if err != nil {
+		t.Fatalf("CreatePaymentIntent() error: %v", err)
+	}
+	held, err := f.ConfirmPaymentIntent(pi.ID, &stripe.PaymentIntentConfirmParams{OffSession: stripe.Bool(true)})
+	if err != nil || held.Status != stripe.PaymentIntentStatusRequiresCapture || held.AmountCapturable != 23000 {
+		t.Fatalf("ConfirmPaymentIntent() = %+v, %v", held, err)
+	}
+	if event := next(); event.Type != "payment_intent.amount_capturable_updated" {
+		t.Errorf("event type = %s, want payment_intent.amount_capturable_updated", event.Type)
+	}
+
+	captured, err := f.CapturePaymentIntent(pi.ID, &stripe.PaymentIntentCaptureParams{AmountToCapture: stripe.Int64(20000)})
+	if err != nil || captured.Status != stripe.PaymentIntentStatusSucceeded || captured.AmountReceived != 20000 {
+		t.Fatalf("CapturePaymentIntent() = %+v, %v", captured, err)
+	}
+	if event := next(); event.Type != "payment_intent.succeeded" {
+		t.Errorf("event type = %s, want payment_intent.succeeded", event.Type)
+	}
+
+	re, err := f.CreateRefund(&stripe.RefundParams{PaymentIntent: stripe.String(pi.ID), Amount: stripe.Int64(5000)})
+	if err != nil {
+		t.Fatalf("CreateRefund() error: %v", err)
+	}
+	event := next()
+	var charge stripe.Charge
+	if err := json.Unmarshal(event.Data.Raw, &charge); err != nil {
+		t.Fatalf("failed to decode charge: %v", err)
+	}
+	if event.Type != "charge.refunded" || charge.AmountRefunded != 5000 || charge.PaymentIntent == nil || charge.PaymentIntent.ID != pi.ID ||
+		charge.ApplicationFeeAmount != 3450 {
+		t.Errorf("charge.refunded event %s = %+v", event.Type, charge)
+	}
+	// Stripe no longer embeds the refunds in charges; they are listed.
+	if charge.Refunds != nil {
+		t.Errorf("charge.refunded event embeds refunds %+v", charge.Refunds)
+	}
+	refunds, err := f.ListRefunds(&stripe.RefundListParams{Charge: stripe.String(charge.ID)})
+	if err != nil || len(refunds) != 1 || refunds[0].ID != re.ID || refunds[0].Amount != 5000 {
+		t.Errorf("ListRefunds() = %+v, %v", refunds, err)
+	}
+	if _, err := f.CreateRefund(&stripe.RefundParams{PaymentIntent: stripe.String(pi.ID), Amount: stripe.Int64(20000)}); err == nil {
+		t.Error("refunding more than the rest of the charge succeeded")
+	}
+
+	transactions, _ := f.ListBalanceTransactions(&stripe.BalanceTransactionListParams{})
+	var references []string
+	for _, bt := range transactions {
+		reference, _ := balanceTransactionReference(bt)
+		references = append(references, reference)
+	}
+	if len(references) != 2 || references[0] != "payment:"+pi.ID || references[1] != "refund:"+re.ID {
+		t.Errorf("balance transaction references = %v", references)
+	}
+}
+
+func TestFakeProviderDeclinedCard(t *testing.T) {
+	f, next := fakeWithEvents(t)
+	pi, _ := f.CreatePaymentIntent(&stripe.PaymentIntentParams{
+		Amount:        stripe.Int64(10000),
+		Currency:      stripe.String("ron"),
+		PaymentMethod: stripe.String(fakeDeclinedCard),
+	})
+	_, err := f.ConfirmPaymentIntent(pi.ID, nil)
+	var stripeErr *stripe.Error
+	if !errors.As(err, &stripeErr) || stripeErr.Type != stripe.ErrorTypeCard || stripeErr.Code != stripe.ErrorCodeCardDeclined {
+		t.Fatalf("ConfirmPaymentIntent() error = %v, want a declined card", err)
+	}
+	if event := next(); event.Type != "payment_intent.payment_failed" {
+		t.Errorf("event type = %s, want payment_intent.payment_failed", event.Type)
+	}
+}
+
+func TestFakeProviderRejectsForeignEvents(t *testing.T) {
+	f := NewFakeProvider()
+	if _, err := f.ConstructEvent([]byte(`{"id":"evt_1","type":"payment_intent.succeeded"}`), "t=1,v1=abc"); err == nil {
+		t.Error("ConstructEvent() accepted an event without the fake's signature")
+	}
+}
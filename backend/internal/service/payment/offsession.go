@@ -0,0 +1,109 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stripe/stripe-go/v81"
+
+	db "helpmeclean-backend/internal/db/generated"
+)
+
+// ChargeOutcome is the result of an off-session charge the card issuer has
+// answered. A declined or unauthenticated charge is an outcome, not an error.
+type ChargeOutcome struct {
+	PaymentIntentID string
+	// RequiresAction is set when the bank wants the client to authenticate
+	// the payment (SCA); the client has to pay on-session.
+	RequiresAction bool
+	// FailureReason is empty when the charge (or hold) went through.
+	FailureReason string
+}
+
+// Succeeded reports whether the card was charged or the hold placed.
+func (o ChargeOutcome) Succeeded() bool {
+	return o.FailureReason == ""
+}
+
+// ChargeOffSession charges a booking with its client's default saved card
+// while the client is not present, as a hold in manual capture mode. The
+// booking's payment status becomes the outcome: pending until the
+// payment_intent webhooks confirm the payment, requires_action or failed.
+// A client without a saved card gets a failed outcome and no PaymentIntent.
+// Errors are returned only when the charge could not be attempted.
+func (s *Service) ChargeOffSession(ctx context.Context, booking db.Booking, opts CaptureOptions) (ChargeOutcome, error) {
+	methods, err := s.queries.ListPaymentMethodsByUser(ctx, booking.ClientUserID)
+	if err != nil {
+		return ChargeOutcome{}, fmt.Errorf("payment: failed to list payment methods: %w", err)
+	}
+	// Listed default first.
+	if len(methods) == 0 || !methods[0].StripePaymentMethodID.Valid {
+		outcome := ChargeOutcome{FailureReason: "no saved card"}
+		return outcome, s.setOffSessionStatus(ctx, booking, outcome)
+	}
+	paymentMethodID := methods[0].StripePaymentMethodID.String
+
+	pi, amountBani, err := s.createBookingPaymentIntent(ctx, booking, opts, paymentMethodID)
+	if err != nil {
+		return ChargeOutcome{}, err
+	}
+
+	outcome := ChargeOutcome{PaymentIntentID: pi.ID}
+	confirmed, err := s.provider.ConfirmPaymentIntent(pi.ID, &stripe.PaymentIntentConfirmParams{OffSession: stripe.Bool(true)})
+	var stripeErr *stripe.Error
+	switch {
+	case errors.As(err, &stripeErr) && stripeErr.Type == stripe.ErrorTypeCard:
+		outcome.RequiresAction = stripeErr.Code == stripe.ErrorCodeAuthenticationRequired
+		outcome.FailureReason = stripeErr.Msg
+		if outcome.FailureReason == "" {
+			outcome.FailureReason = string(stripeErr.Code)
+		}
+	case err != nil:
+		return ChargeOutcome{}, fmt.Errorf("payment: failed to confirm PI %s: %w", pi.ID, err)
+	case confirmed.Status == stripe.PaymentIntentStatusRequiresAction:
+		outcome.RequiresAction = true
+		outcome.FailureReason = "the payment requires authentication"
+	}
+
+	if err := s.setOffSessionStatus(ctx, booking, outcome); err != nil {
+		return outcome, err
+	}
+	if outcome.Succeeded() {
+		log.Printf("payment: charged booking %s off-session with PI %s, amount=%d bani",
+			booking.ReferenceCode, pi.ID, amountBani)
+	} else {
+		log.Printf("payment: off-session charge of booking %s failed (PI %s, requires action: %t): %s",
+			booking.ReferenceCode, pi.ID, outcome.RequiresAction, outcome.FailureReason)
+	}
+	return outcome, nil
+}
+
+// setOffSessionStatus records a failed off-session charge on the booking.
+// Successful charges keep the pending status set when the PaymentIntent was
+// created; the webhooks mark them paid or authorized.
+func (s *Service) setOffSessionStatus(ctx context.Context, booking db.Booking, outcome ChargeOutcome) error {
+	if outcome.Succeeded() {
+		return nil
+	}
+	status := "failed"
+	if outcome.RequiresAction {
+		status = "requires_action"
+	}
+	_, err := s.queries.UpdateBookingPayment(ctx, db.UpdateBookingPaymentParams{
+		ID:                    booking.ID,
+		StripePaymentIntentID: pgtype.Text{String: outcome.PaymentIntentID, Valid: outcome.PaymentIntentID != ""},
+		PaymentStatus:         pgtype.Text{String: status, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("payment: failed to update booking payment status: %w", err)
+	}
+	return nil
+}
+
+// PaymentLink returns the page where a client pays for a booking on-session.
+func (s *Service) PaymentLink(bookingID pgtype.UUID) string {
+	return fmt.Sprintf("%s/%s?plata=1", s.paymentLinkBaseURL, uuidToString(bookingID))
+}
@@ -0,0 +1,48 @@
+package payment
+
+import (
+	"fmt"
+	"time"
+)
+
+// PayoutCadence is how often companies are paid out automatically.
+type PayoutCadence string
+
+const (
+	PayoutWeekly   PayoutCadence = "weekly"
+	PayoutBiweekly PayoutCadence = "biweekly"
+	PayoutMonthly  PayoutCadence = "monthly"
+)
+
+// biweeklyEpoch is the Monday the two-week payout periods are counted from.
+var biweeklyEpoch = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// ParsePayoutCadence validates a cadence setting.
+func ParsePayoutCadence(s string) (PayoutCadence, error) {
+	switch c := PayoutCadence(s); c {
+	case PayoutWeekly, PayoutBiweekly, PayoutMonthly:
+		return c, nil
+	}
+	return "", fmt.Errorf("payment: unknown payout cadence %q", s)
+}
+
+// LastClosedPeriod returns the last payout period that ended before today, as
+// inclusive calendar dates (midnight UTC). Weekly periods run Monday to
+// Sunday, biweekly periods are consecutive two-week blocks from 1 January
+// 2024, and monthly periods are calendar months.
+func (c PayoutCadence) LastClosedPeriod(today time.Time) (from, to time.Time) {
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+
+	switch c {
+	case PayoutMonthly:
+		from = time.Date(today.Year(), today.Month()-1, 1, 0, 0, 0, 0, time.UTC)
+		return from, from.AddDate(0, 1, -1)
+	case PayoutBiweekly:
+		days := int(today.Sub(biweeklyEpoch).Hours() / 24)
+		start := biweeklyEpoch.AddDate(0, 0, days-((days%14)+14)%14)
+		return start.AddDate(0, 0, -14), start.AddDate(0, 0, -1)
+	default:
+		monday := today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+		return monday.AddDate(0, 0, -7), monday.AddDate(0, 0, -1)
+	}
+}
@@ -0,0 +1,63 @@
+package payment
+
+import (
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestParsePayoutCadence(t *testing.T) {
+	for _, s := range []string{"weekly", "biweekly", "monthly"} {
+		if c, err := ParsePayoutCadence(s); err != nil || string(c) != s {
+			t.Errorf("ParsePayoutCadence(%q) = %q, %v", s, c, err)
+		}
+	}
+	if _, err := ParsePayoutCadence("daily"); err == nil {
+		t.Error("ParsePayoutCadence(daily) succeeded, want error")
+	}
+}
+
+func TestLastClosedPeriod(t *testing.T) {
+	tests := []struct {
+		cadence  PayoutCadence
+		today    string
+		from, to string
+	}{
+		// 2026-03-11 is a Wednesday.
+		{PayoutWeekly, "2026-03-11", "2026-03-02", "2026-03-08"},
+		{PayoutWeekly, "2026-03-09", "2026-03-02", "2026-03-08"},
+		{PayoutWeekly, "2026-03-08", "2026-02-23", "2026-03-01"},
+		// Two-week blocks from Monday 2024-01-01: 2026-03-09 starts a block.
+		{PayoutBiweekly, "2026-03-11", "2026-02-23", "2026-03-08"},
+		{PayoutBiweekly, "2026-03-23", "2026-03-09", "2026-03-22"},
+		{PayoutBiweekly, "2026-03-22", "2026-02-23", "2026-03-08"},
+		{PayoutBiweekly, "2023-12-20", "2023-12-04", "2023-12-17"},
+		{PayoutMonthly, "2026-03-11", "2026-02-01", "2026-02-28"},
+		{PayoutMonthly, "2026-01-01", "2025-12-01", "2025-12-31"},
+	}
+	for _, tt := range tests {
+		from, to := tt.cadence.LastClosedPeriod(date(tt.today))
+		if got := from.Format("2006-01-02"); got != tt.from {
+			t.Errorf("%s on %s: from = %s, want %s", tt.cadence, tt.today, got, tt.from)
+		}
+		if got := to.Format("2006-01-02"); got != tt.to {
+			t.Errorf("%s on %s: to = %s, want %s", tt.cadence, tt.today, got, tt.to)
+		}
+	}
+}
+
+func TestLastClosedPeriod_UsesCalendarDate(t *testing.T) {
+	// Late Sunday evening in Bucharest is still in the current week.
+	bucharest, _ := time.LoadLocation("Europe/Bucharest")
+	from, _ := PayoutWeekly.LastClosedPeriod(time.Date(2026, 3, 8, 23, 30, 0, 0, bucharest))
+	if got := from.Format("2006-01-02"); got != "2026-02-23" {
+		t.Errorf("from = %s, want 2026-02-23", got)
+	}
+}
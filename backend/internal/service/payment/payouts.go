@@ -0,0 +1,202 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stripe/stripe-go/v81"
+
+	db "helpmeclean-backend/internal/db/generated"
+	"helpmeclean-backend/internal/service/ledger"
+)
+
+// payoutRetryDelays is the wait before each retry of a failed payout. Once
+// they are used up the payout is left failed for reconciliation.
+var payoutRetryDelays = []time.Duration{time.Hour, 4 * time.Hour, 12 * time.Hour, 24 * time.Hour}
+
+// payoutRetryBatch is how many due payouts one retry run executes.
+const payoutRetryBatch = 50
+
+// ExecutePayout sends a company payout to the company's bank account.
+//
+// Booking charges are destination charges, so the company's share already
+// sits in its Connect balance; the payout is created on the connected account
+// and its outcome arrives through the payout.* webhooks. A failure to create
+// it marks the payout failed and schedules a retry. Payouts that are already
+// processing, paid or cancelled are returned unchanged.
+func (s *Service) ExecutePayout(ctx context.Context, payoutID pgtype.UUID) (db.CompanyPayout, error) {
+	p, err := s.queries.ClaimPayoutForExecution(ctx, payoutID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return s.queries.GetPayoutByID(ctx, payoutID)
+	}
+	if err != nil {
+		return db.CompanyPayout{}, fmt.Errorf("payment: failed to claim payout %s: %w", uuidToString(payoutID), err)
+	}
+
+	stripePayoutID, err := s.createStripePayout(ctx, p)
+	if err != nil {
+		failed, markErr := s.failPayout(ctx, s.queries, p, err.Error(), true)
+		if markErr != nil {
+			return db.CompanyPayout{}, markErr
+		}
+		return failed, err
+	}
+
+	p, err = s.queries.SetPayoutStripePayoutID(ctx, db.SetPayoutStripePayoutIDParams{
+		ID:             p.ID,
+		StripePayoutID: pgtype.Text{String: stripePayoutID, Valid: true},
+	})
+	if err != nil {
+		return db.CompanyPayout{}, fmt.Errorf("payment: failed to store stripe payout %s: %w", stripePayoutID, err)
+	}
+
+	log.Printf("payment: created payout %s for company payout %s, amount=%d bani, attempt %d",
+		stripePayoutID, uuidToString(p.ID), p.Amount, p.Attempts)
+	return p, nil
+}
+
+// createStripePayout creates the payout on the company's connected account.
+// The idempotency key is per attempt, so a network retry within one attempt
+// never pays twice.
+func (s *Service) createStripePayout(ctx context.Context, p db.CompanyPayout) (string, error) {
+	connectInfo, err := s.queries.GetCompanyStripeConnect(ctx, p.CompanyID)
+	if err != nil {
+		return "", fmt.Errorf("payment: failed to get company stripe connect info: %w", err)
+	}
+	if !connectInfo.StripeConnectAccountID.Valid || connectInfo.StripeConnectAccountID.String == "" {
+		return "", fmt.Errorf("payment: company does not have a stripe connect account")
+	}
+	if !connectInfo.StripeConnectPayoutsEnabled.Valid || !connectInfo.StripeConnectPayoutsEnabled.Bool {
+		return "", fmt.Errorf("payment: payouts are not enabled on the company's stripe connect account")
+	}
+
+	params := &stripe.PayoutParams{
+		Amount:      stripe.Int64(int64(p.Amount)),
+		Currency:    stripe.String(p.Currency),
+		Description: stripe.String(fmt.Sprintf("HelpMeClean %s - %s", p.PeriodFrom.Time.Format("2006-01-02"), p.PeriodTo.Time.Format("2006-01-02"))),
+	}
+	params.AddMetadata("payout_id", uuidToString(p.ID))
+	params.AddMetadata("company_id", uuidToString(p.CompanyID))
+	params.SetStripeAccount(connectInfo.StripeConnectAccountID.String)
+	params.SetIdempotencyKey(fmt.Sprintf("payout-%s-%d", uuidToString(p.ID), p.Attempts))
+
+	po, err := s.provider.CreatePayout(params)
+	if err != nil {
+		return "", fmt.Errorf("payment: failed to create stripe payout: %w", err)
+	}
+	return po.ID, nil
+}
+
+// failPayout records a payout failure. When retry is set and retries are
+// left the next attempt is scheduled; otherwise the payout waits for an
+// admin in the reconciliation view.
+func (s *Service) failPayout(ctx context.Context, q *db.Queries, p db.CompanyPayout, reason string, retry bool) (db.CompanyPayout, error) {
+	var next pgtype.Timestamptz
+	if retry && int(p.Attempts) <= len(payoutRetryDelays) {
+		next = pgtype.Timestamptz{Time: time.Now().Add(payoutRetryDelays[max(p.Attempts, 1)-1]), Valid: true}
+	}
+
+	failed, err := q.MarkPayoutFailed(ctx, db.MarkPayoutFailedParams{
+		ID:            p.ID,
+		FailureReason: pgtype.Text{String: reason, Valid: reason != ""},
+		NextAttemptAt: next,
+	})
+	if err != nil {
+		return db.CompanyPayout{}, fmt.Errorf("payment: failed to mark payout %s failed: %w", uuidToString(p.ID), err)
+	}
+
+	if next.Valid {
+		log.Printf("payment: payout %s failed (attempt %d), retrying at %s: %s",
+			uuidToString(p.ID), p.Attempts, next.Time.Format(time.RFC3339), reason)
+	} else {
+		log.Printf("payment: payout %s failed (attempt %d), needs reconciliation: %s", uuidToString(p.ID), p.Attempts, reason)
+	}
+	return failed, nil
+}
+
+// RetryPayouts executes failed payouts whose next attempt is due and pending
+// payouts that were never executed.
+func (s *Service) RetryPayouts(ctx context.Context) error {
+	due, err := s.queries.ListPayoutsDueForRetry(ctx, payoutRetryBatch)
+	if err != nil {
+		return fmt.Errorf("payment: failed to list payouts due for retry: %w", err)
+	}
+
+	failed := 0
+	for _, p := range due {
+		if _, err := s.ExecutePayout(ctx, p.ID); err != nil {
+			failed++
+		}
+	}
+	if len(due) > 0 {
+		log.Printf("payment: retried %d payouts, %d failed", len(due), failed)
+	}
+	return nil
+}
+
+// handlePayoutEvent applies a payout.paid, payout.failed or payout.canceled
+// event from a connected account to the company payout that created it.
+// Payouts not created by ExecutePayout (no payout_id metadata) are ignored,
+// as are events for an earlier attempt of a payout that has been retried.
+func (s *Service) handlePayoutEvent(ctx context.Context, q *db.Queries, event stripe.Event) error {
+	var po stripe.Payout
+	if err := json.Unmarshal(event.Data.Raw, &po); err != nil {
+		return fmt.Errorf("payment: failed to unmarshal %s: %w", event.Type, err)
+	}
+
+	payoutIDStr, ok := po.Metadata["payout_id"]
+	if !ok || payoutIDStr == "" {
+		log.Printf("payment: %s for stripe payout %s has no payout_id metadata, skipping", event.Type, po.ID)
+		return nil
+	}
+	payoutID, err := parseUUID(payoutIDStr)
+	if err != nil {
+		return fmt.Errorf("payment: invalid payout_id in payout metadata: %w", err)
+	}
+
+	p, err := q.GetPayoutByID(ctx, payoutID)
+	if err != nil {
+		return fmt.Errorf("payment: failed to load payout %s: %w", payoutIDStr, err)
+	}
+	if p.StripePayoutID.Valid && p.StripePayoutID.String != po.ID {
+		log.Printf("payment: %s for stale stripe payout %s of payout %s, skipping", event.Type, po.ID, payoutIDStr)
+		return nil
+	}
+	if !p.StripePayoutID.Valid {
+		// The webhook beat ExecutePayout storing the ID.
+		if p, err = q.SetPayoutStripePayoutID(ctx, db.SetPayoutStripePayoutIDParams{
+			ID:             p.ID,
+			StripePayoutID: pgtype.Text{String: po.ID, Valid: true},
+		}); err != nil {
+			return fmt.Errorf("payment: failed to store stripe payout %s: %w", po.ID, err)
+		}
+	}
+
+	switch event.Type {
+	case "payout.paid":
+		if _, err := q.MarkPayoutPaid(ctx, p.ID); err != nil {
+			return fmt.Errorf("payment: failed to mark payout %s paid: %w", payoutIDStr, err)
+		}
+		if _, err := s.ledger.PostWith(ctx, q, ledger.PayoutEntry(p.ID, p.CompanyID, int64(p.Amount), time.Unix(event.Created, 0))); err != nil {
+			return fmt.Errorf("payment: failed to post payout %s to the ledger: %w", payoutIDStr, err)
+		}
+		log.Printf("payment: payout.paid processed for stripe payout %s, payout %s", po.ID, payoutIDStr)
+	case "payout.failed":
+		reason := po.FailureMessage
+		if reason == "" {
+			reason = string(po.FailureCode)
+		}
+		_, err = s.failPayout(ctx, q, p, reason, true)
+		return err
+	case "payout.canceled":
+		_, err = s.failPayout(ctx, q, p, "payout canceled in Stripe", false)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package payment
+
+import (
+	"os"
+
+	"github.com/stripe/stripe-go/v81"
+	"github.com/stripe/stripe-go/v81/account"
+	"github.com/stripe/stripe-go/v81/accountlink"
+	"github.com/stripe/stripe-go/v81/balancetransaction"
+	"github.com/stripe/stripe-go/v81/customer"
+	"github.com/stripe/stripe-go/v81/dispute"
+	"github.com/stripe/stripe-go/v81/paymentintent"
+	"github.com/stripe/stripe-go/v81/paymentmethod"
+	"github.com/stripe/stripe-go/v81/payout"
+	"github.com/stripe/stripe-go/v81/refund"
+	"github.com/stripe/stripe-go/v81/setupintent"
+	"github.com/stripe/stripe-go/v81/webhook"
+)
+
+// PaymentProvider is the payment processor behind the payment service. It
+// speaks Stripe's types: StripeProvider calls the Stripe API and FakeProvider
+// simulates it in memory for local development. Money reaches the companies
+// through destination charges and payouts on their Connect accounts, so there
+// is no transfer method: the PaymentIntent's transfer data moves the
+// company's share, less the application fee, to its account when the charge
+// is captured, and refunds take it back with reverse_transfer and
+// refund_application_fee. Separate transfers would only be needed to split
+// one charge between several accounts, which bookings never do.
+type PaymentProvider interface {
+	// Customers and saved cards.
+	CreateCustomer(params *stripe.CustomerParams) (*stripe.Customer, error)
+	CreateSetupIntent(params *stripe.SetupIntentParams) (*stripe.SetupIntent, error)
+	GetPaymentMethod(id string) (*stripe.PaymentMethod, error)
+	DetachPaymentMethod(id string) (*stripe.PaymentMethod, error)
+
+	// Payments.
+	CreatePaymentIntent(params *stripe.PaymentIntentParams) (*stripe.PaymentIntent, error)
+	GetPaymentIntent(id string) (*stripe.PaymentIntent, error)
+	ConfirmPaymentIntent(id string, params *stripe.PaymentIntentConfirmParams) (*stripe.PaymentIntent, error)
+	CapturePaymentIntent(id string, params *stripe.PaymentIntentCaptureParams) (*stripe.PaymentIntent, error)
+	CancelPaymentIntent(id string, params *stripe.PaymentIntentCancelParams) (*stripe.PaymentIntent, error)
+	CreateRefund(params *stripe.RefundParams) (*stripe.Refund, error)
+	// ListRefunds returns all refunds matching params, following pagination.
+	// Charges no longer embed their refunds, so this is the only way to see
+	// them.
+	ListRefunds(params *stripe.RefundListParams) ([]*stripe.Refund, error)
+	UpdateDispute(id string, params *stripe.DisputeParams) (*stripe.Dispute, error)
+	// ListBalanceTransactions returns all balance transactions matching
+	// params, following pagination.
+	ListBalanceTransactions(params *stripe.BalanceTransactionListParams) ([]*stripe.BalanceTransaction, error)
+
+	// Connect accounts and payouts.
+	CreateAccount(params *stripe.AccountParams) (*stripe.Account, error)
+	GetAccount(id string) (*stripe.Account, error)
+	CreateAccountLink(params *stripe.AccountLinkParams) (*stripe.AccountLink, error)
+	CreatePayout(params *stripe.PayoutParams) (*stripe.Payout, error)
+
+	// ConstructEvent verifies a webhook payload and its signature header.
+	ConstructEvent(payload []byte, sigHeader string) (stripe.Event, error)
+}
+
+// NewPaymentProvider returns the provider selected by PAYMENT_PROVIDER:
+// "fake" for FakeProvider, otherwise StripeProvider configured from
+// STRIPE_SECRET_KEY and STRIPE_WEBHOOK_SECRET. The fake is never used in
+// production.
+func NewPaymentProvider() PaymentProvider {
+	if os.Getenv("PAYMENT_PROVIDER") == "fake" && os.Getenv("ENVIRONMENT") != "production" {
+		return NewFakeProvider()
+	}
+	return NewStripeProvider(os.Getenv("STRIPE_SECRET_KEY"), os.Getenv("STRIPE_WEBHOOK_SECRET"))
+}
+
+// StripeProvider is the PaymentProvider backed by the Stripe API.
+type StripeProvider struct {
+	webhookSecret string
+}
+
+// NewStripeProvider creates a Stripe provider and configures the global
+// Stripe API key.
+func NewStripeProvider(secretKey, webhookSecret string) *StripeProvider {
+	stripe.Key = secretKey
+	return &StripeProvider{webhookSecret: webhookSecret}
+}
+
+func (p *StripeProvider) CreateCustomer(params *stripe.CustomerParams) (*stripe.Customer, error) {
+	return customer.New(params)
+}
+
+func (p *StripeProvider) CreateSetupIntent(params *stripe.SetupIntentParams) (*stripe.SetupIntent, error) {
+	return setupintent.New(params)
+}
+
+func (p *StripeProvider) GetPaymentMethod(id string) (*stripe.PaymentMethod, error) {
+	return paymentmethod.Get(id, nil)
+}
+
+func (p *StripeProvider) DetachPaymentMethod(id string) (*stripe.PaymentMethod, error) {
+	return paymentmethod.Detach(id, nil)
+}
+
+func (p *StripeProvider) CreatePaymentIntent(params *stripe.PaymentIntentParams) (*stripe.PaymentIntent, error) {
+	return paymentintent.New(params)
+}
+
+func (p *StripeProvider) GetPaymentIntent(id string) (*stripe.PaymentIntent, error) {
+	return paymentintent.Get(id, nil)
+}
+
+func (p *StripeProvider) ConfirmPaymentIntent(id string, params *stripe.PaymentIntentConfirmParams) (*stripe.PaymentIntent, error) {
+	return paymentintent.Confirm(id, params)
+}
+
+func (p *StripeProvider) CapturePaymentIntent(id string, params *stripe.PaymentIntentCaptureParams) (*stripe.PaymentIntent, error) {
+	return paymentintent.Capture(id, params)
+}
+
+func (p *StripeProvider) CancelPaymentIntent(id string, params *stripe.PaymentIntentCancelParams) (*stripe.PaymentIntent, error) {
+	return paymentintent.Cancel(id, params)
+}
+
+func (p *StripeProvider) CreateRefund(params *stripe.RefundParams) (*stripe.Refund, error) {
+	return refund.New(params)
+}
+
+func (p *StripeProvider) ListRefunds(params *stripe.RefundListParams) ([]*stripe.Refund, error) {
+	var out []*stripe.Refund
+	iter := refund.List(params)
+	for iter.Next() {
+		out = append(out, iter.Refund())
+	}
+	return out, iter.Err()
+}
+
+func (p *StripeProvider) UpdateDispute(id string, params *stripe.DisputeParams) (*stripe.Dispute, error) {
+	return dispute.Update(id, params)
+}
+
+func (p *StripeProvider) ListBalanceTransactions(params *stripe.BalanceTransactionListParams) ([]*stripe.BalanceTransaction, error) {
+	var out []*stripe.BalanceTransaction
+	iter := balancetransaction.List(params)
+	for iter.Next() {
+		out = append(out, iter.BalanceTransaction())
+	}
+	return out, iter.Err()
+}
+
+func (p *StripeProvider) CreateAccount(params *stripe.AccountParams) (*stripe.Account, error) {
+	return account.New(params)
+}
+
+func (p *StripeProvider) GetAccount(id string) (*stripe.Account, error) {
+	return account.GetByID(id, nil)
+}
+
+func (p *StripeProvider) CreateAccountLink(params *stripe.AccountLinkParams) (*stripe.AccountLink, error) {
+	return accountlink.New(params)
+}
+
+func (p *StripeProvider) CreatePayout(params *stripe.PayoutParams) (*stripe.Payout, error) {
+	return payout.New(params)
+}
+
+func (p *StripeProvider) ConstructEvent(payload []byte, sigHeader string) (stripe.Event, error) {
+	return webhook.ConstructEventWithOptions(payload, sigHeader, p.webhookSecret, webhook.ConstructEventOptions{
+		IgnoreAPIVersionMismatch: true,
+	})
+}
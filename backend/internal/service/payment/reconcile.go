@@ -0,0 +1,174 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stripe/stripe-go/v81"
+
+	db "helpmeclean-backend/internal/db/generated"
+	"helpmeclean-backend/internal/service/ledger"
+)
+
+// reconciliationLag is how long a Stripe movement may take to reach the
+// ledger through its webhook. Movements younger than that, and ledger entries
+// just outside the window, are not reported as missing.
+const reconciliationLag = time.Hour
+
+// Discrepancy is a difference between the ledger and Stripe.
+type Discrepancy struct {
+	// Reference is the ledger reference of the payment, refund or dispute.
+	Reference string `json:"reference"`
+	// StripeID is the balance transaction, empty when Stripe has none.
+	StripeID string `json:"stripeId,omitempty"`
+	// Problem is missing_in_ledger, missing_in_stripe or amount_mismatch.
+	Problem      string `json:"problem"`
+	LedgerAmount int64  `json:"ledgerAmount"`
+	StripeAmount int64  `json:"stripeAmount"`
+}
+
+// ReconcileLedger diffs the ledger against the platform's Stripe balance
+// transactions of the last ledger_reconciliation_days days (default 3). Every
+// charge, refund and dispute adjustment must match the amount its ledger entry
+// moved on the Stripe balance, and every ledger payment, refund and dispute
+// must exist in Stripe. The fees
+// Stripe took are posted to the ledger along the way. The result is stored in
+// ledger_reconciliations for admins.
+func (s *Service) ReconcileLedger(ctx context.Context) error {
+	days := 3
+	if v, err := s.queries.GetPlatformSetting(ctx, "ledger_reconciliation_days"); err == nil {
+		if n, err := strconv.Atoi(v.Value); err == nil && n > 0 {
+			days = n
+		}
+	}
+	to := time.Now()
+	from := to.AddDate(0, 0, -days)
+
+	movements, err := s.queries.ListLedgerStripeMovements(ctx, db.ListLedgerStripeMovementsParams{
+		OccurredFrom: pgtype.Timestamptz{Time: from.Add(-reconciliationLag), Valid: true},
+		OccurredTo:   pgtype.Timestamptz{Time: to, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("payment: failed to list ledger movements: %w", err)
+	}
+	inLedger := make(map[string]db.ListLedgerStripeMovementsRow, len(movements))
+	for _, m := range movements {
+		inLedger[m.Reference] = m
+	}
+
+	params := &stripe.BalanceTransactionListParams{
+		CreatedRange: &stripe.RangeQueryParams{
+			GreaterThanOrEqual: from.Add(-reconciliationLag).Unix(),
+			LesserThan:         to.Unix(),
+		},
+		Currency: stripe.String("ron"),
+	}
+	params.AddExpand("data.source")
+
+	discrepancies := []Discrepancy{}
+	seen := map[string]bool{}
+	var checked, feesPosted int32
+	transactions, err := s.provider.ListBalanceTransactions(params)
+	if err != nil {
+		return fmt.Errorf("payment: failed to list stripe balance transactions: %w", err)
+	}
+	for _, bt := range transactions {
+		reference, piID := balanceTransactionReference(bt)
+		if reference == "" {
+			continue
+		}
+		checked++
+		seen[reference] = true
+
+		created := time.Unix(bt.Created, 0)
+		m, ok := inLedger[reference]
+		switch {
+		case !ok && created.After(from) && created.Before(to.Add(-reconciliationLag)):
+			discrepancies = append(discrepancies, Discrepancy{
+				Reference: reference, StripeID: bt.ID, Problem: "missing_in_ledger", StripeAmount: bt.Amount,
+			})
+		case ok && m.Amount != bt.Amount:
+			discrepancies = append(discrepancies, Discrepancy{
+				Reference: reference, StripeID: bt.ID, Problem: "amount_mismatch", LedgerAmount: m.Amount, StripeAmount: bt.Amount,
+			})
+		}
+
+		if bt.Fee == 0 {
+			continue
+		}
+		var bookingID pgtype.UUID
+		if txn, err := s.queries.GetPaymentTransactionByStripePI(ctx, piID); err == nil {
+			bookingID = txn.BookingID
+		}
+		posted, err := s.ledger.Post(ctx, ledger.StripeFeeEntry(bt.ID, bookingID, bt.Fee, created))
+		if err != nil {
+			return fmt.Errorf("payment: failed to post stripe fee of %s: %w", bt.ID, err)
+		}
+		if posted {
+			feesPosted++
+		}
+	}
+
+	for _, m := range movements {
+		if seen[m.Reference] || m.OccurredAt.Time.Before(from) {
+			continue
+		}
+		discrepancies = append(discrepancies, Discrepancy{
+			Reference: m.Reference, Problem: "missing_in_stripe", LedgerAmount: m.Amount,
+		})
+	}
+
+	data, _ := json.Marshal(discrepancies)
+	if _, err := s.queries.CreateLedgerReconciliation(ctx, db.CreateLedgerReconciliationParams{
+		PeriodFrom:    pgtype.Timestamptz{Time: from, Valid: true},
+		PeriodTo:      pgtype.Timestamptz{Time: to, Valid: true},
+		CheckedCount:  checked,
+		FeesPosted:    feesPosted,
+		Discrepancies: data,
+	}); err != nil {
+		return fmt.Errorf("payment: failed to store ledger reconciliation: %w", err)
+	}
+
+	log.Printf("payment: reconciled %d stripe transactions with the ledger, %d fees posted, %d discrepancies",
+		checked, feesPosted, len(discrepancies))
+	return nil
+}
+
+// balanceTransactionReference returns the ledger reference of a charge,
+// refund or dispute balance transaction and its PaymentIntent. Other
+// transactions (transfers, payouts, fees) return an empty reference.
+func balanceTransactionReference(bt *stripe.BalanceTransaction) (reference, piID string) {
+	if bt.Source == nil {
+		return "", ""
+	}
+	switch bt.Type {
+	case stripe.BalanceTransactionTypeCharge, stripe.BalanceTransactionTypePayment:
+		if bt.Source.Charge == nil || bt.Source.Charge.PaymentIntent == nil {
+			return "", ""
+		}
+		piID = bt.Source.Charge.PaymentIntent.ID
+		return "payment:" + piID, piID
+	case stripe.BalanceTransactionTypeRefund, stripe.BalanceTransactionTypePaymentRefund:
+		if bt.Source.Refund == nil {
+			return "", ""
+		}
+		if bt.Source.Refund.PaymentIntent != nil {
+			piID = bt.Source.Refund.PaymentIntent.ID
+		}
+		return "refund:" + bt.Source.Refund.ID, piID
+	case stripe.BalanceTransactionTypeAdjustment:
+		if bt.Source.Dispute == nil {
+			return "", ""
+		}
+		if bt.Source.Dispute.PaymentIntent != nil {
+			piID = bt.Source.Dispute.PaymentIntent.ID
+		}
+		return "dispute:" + bt.ID, piID
+	}
+	return "", ""
+}
@@ -3,48 +3,68 @@ package payment
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"math"
 	"os"
+	"sort"
+	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/stripe/stripe-go/v81"
-	"github.com/stripe/stripe-go/v81/account"
-	"github.com/stripe/stripe-go/v81/accountlink"
-	"github.com/stripe/stripe-go/v81/customer"
-	"github.com/stripe/stripe-go/v81/paymentintent"
-	"github.com/stripe/stripe-go/v81/paymentmethod"
-	"github.com/stripe/stripe-go/v81/refund"
-	"github.com/stripe/stripe-go/v81/setupintent"
-	"github.com/stripe/stripe-go/v81/webhook"
 
 	db "helpmeclean-backend/internal/db/generated"
+	"helpmeclean-backend/internal/service/ledger"
 )
 
 // Service handles Stripe payment processing for HelpMeClean.
 type Service struct {
+	pool              *pgxpool.Pool
 	queries           *db.Queries
-	stripeKey         string
-	webhookSecret     string
+	ledger            *ledger.Service
+	provider          PaymentProvider
 	connectReturnURL  string
 	connectRefreshURL string
+	// paymentLinkBaseURL is the client's booking page, where payments that
+	// failed off-session are completed.
+	paymentLinkBaseURL string
 	// OnBookingConfirmed is called when a booking is auto-confirmed via payment.
 	// Set by the application layer to create a chat room, etc.
 	OnBookingConfirmed func(ctx context.Context, booking db.Booking)
+	// OnBookingPaid is called when a payment of a booking succeeds. Set by
+	// the application layer to issue the invoice of the booking.
+	OnBookingPaid func(ctx context.Context, booking db.Booking)
+	// OnRefunded is called for each refund a charge.refunded event records
+	// credit notes for, once the event is committed. Set by the application
+	// layer to issue them right away; the invoice-pipeline job issues those
+	// it fails to.
+	OnRefunded func(ctx context.Context, refund ledger.Refund)
 }
 
-// NewService creates a new payment service and configures the global Stripe API key.
-func NewService(queries *db.Queries) *Service {
+// NewService creates a new payment service on a payment provider. A
+// FakeProvider delivers its webhook events to the service.
+func NewService(pool *pgxpool.Pool, queries *db.Queries, ledgerSvc *ledger.Service, provider PaymentProvider) *Service {
 	s := &Service{
-		queries:           queries,
-		stripeKey:         os.Getenv("STRIPE_SECRET_KEY"),
-		webhookSecret:     os.Getenv("STRIPE_WEBHOOK_SECRET"),
-		connectReturnURL:  os.Getenv("STRIPE_CONNECT_RETURN_URL"),
-		connectRefreshURL: os.Getenv("STRIPE_CONNECT_REFRESH_URL"),
+		pool:               pool,
+		queries:            queries,
+		ledger:             ledgerSvc,
+		provider:           provider,
+		connectReturnURL:   os.Getenv("STRIPE_CONNECT_RETURN_URL"),
+		connectRefreshURL:  os.Getenv("STRIPE_CONNECT_REFRESH_URL"),
+		paymentLinkBaseURL: os.Getenv("PAYMENT_LINK_BASE_URL"),
+	}
+	if s.paymentLinkBaseURL == "" {
+		s.paymentLinkBaseURL = "http://localhost:3000/cont/comenzi"
 	}
 
-	stripe.Key = s.stripeKey
+	if fake, ok := provider.(*FakeProvider); ok {
+		fake.SetWebhookHandler(s.HandleWebhookEvent)
+		log.Println("Payment service initialized with the fake payment provider")
+		return s
+	}
 
 	log.Println("Payment service initialized")
 	return s
@@ -69,7 +89,7 @@ func (s *Service) EnsureStripeCustomer(ctx context.Context, userID pgtype.UUID,
 	}
 	params.AddMetadata("user_id", uuidToString(userID))
 
-	cust, err := customer.New(params)
+	cust, err := s.provider.CreateCustomer(params)
 	if err != nil {
 		return "", fmt.Errorf("payment: failed to create stripe customer: %w", err)
 	}
@@ -102,7 +122,7 @@ func (s *Service) CreateSetupIntent(ctx context.Context, userID pgtype.UUID, ema
 		PaymentMethodTypes: stripe.StringSlice([]string{"card"}),
 	}
 
-	si, err := setupintent.New(params)
+	si, err := s.provider.CreateSetupIntent(params)
 	if err != nil {
 		return "", fmt.Errorf("payment: failed to create setup intent: %w", err)
 	}
@@ -114,7 +134,7 @@ func (s *Service) CreateSetupIntent(ctx context.Context, userID pgtype.UUID, ema
 // AttachPaymentMethod retrieves a Stripe payment method by its ID, reads card
 // details, and stores it in the database linked to the user.
 func (s *Service) AttachPaymentMethod(ctx context.Context, userID pgtype.UUID, stripePaymentMethodID string) (db.ClientPaymentMethod, error) {
-	pm, err := paymentmethod.Get(stripePaymentMethodID, nil)
+	pm, err := s.provider.GetPaymentMethod(stripePaymentMethodID)
 	if err != nil {
 		return db.ClientPaymentMethod{}, fmt.Errorf("payment: failed to retrieve payment method from stripe: %w", err)
 	}
@@ -165,7 +185,7 @@ func (s *Service) AttachPaymentMethod(ctx context.Context, userID pgtype.UUID, s
 
 // DetachPaymentMethod detaches a payment method from Stripe and removes it from the database.
 func (s *Service) DetachPaymentMethod(ctx context.Context, pmID pgtype.UUID, stripePaymentMethodID string) error {
-	_, err := paymentmethod.Detach(stripePaymentMethodID, nil)
+	_, err := s.provider.DetachPaymentMethod(stripePaymentMethodID)
 	if err != nil {
 		return fmt.Errorf("payment: failed to detach payment method from stripe: %w", err)
 	}
@@ -183,7 +203,23 @@ func (s *Service) DetachPaymentMethod(ctx context.Context, pmID pgtype.UUID, str
 // split payments. It calculates the amount from booking totals, applies the platform
 // commission as an application fee, and routes the remainder to the company's Connect
 // account. Returns the client secret, payment intent ID, and the amount in bani.
-func (s *Service) CreatePaymentIntentForBooking(ctx context.Context, booking db.Booking) (string, string, int64, error) {
+//
+// In manual capture mode the PaymentIntent only places an authorization hold,
+// raised by the overage the client agreed to, and saves the card for
+// off-session use so the hold can be renewed; the amount returned is the hold.
+// The payment is captured by CapturePayment when the job is completed.
+func (s *Service) CreatePaymentIntentForBooking(ctx context.Context, booking db.Booking, opts CaptureOptions) (string, string, int64, error) {
+	pi, amountBani, err := s.createBookingPaymentIntent(ctx, booking, opts, "")
+	if err != nil {
+		return "", "", 0, err
+	}
+	return pi.ClientSecret, pi.ID, amountBani, nil
+}
+
+// createBookingPaymentIntent creates the unconfirmed PaymentIntent for a
+// booking and records it on the booking and in payment_transactions. When a
+// saved payment method is given it is attached for an off-session charge.
+func (s *Service) createBookingPaymentIntent(ctx context.Context, booking db.Booking, opts CaptureOptions, paymentMethodID string) (*stripe.PaymentIntent, int64, error) {
 	// Determine the amount in RON from the booking, then convert to bani (cents).
 	amountRON := 0.0
 
@@ -197,43 +233,43 @@ func (s *Service) CreatePaymentIntentForBooking(ctx context.Context, booking db.
 	if amountRON == 0.0 {
 		f64, err := numericToFloat64(booking.EstimatedTotal)
 		if err != nil {
-			return "", "", 0, fmt.Errorf("payment: booking has no valid total amount: %w", err)
+			return nil, 0, fmt.Errorf("payment: booking has no valid total amount: %w", err)
 		}
 		amountRON = f64
 	}
 
 	amountBani := int64(math.Round(amountRON * 100))
 	if amountBani <= 0 {
-		return "", "", 0, fmt.Errorf("payment: booking amount must be positive, got %d bani", amountBani)
+		return nil, 0, fmt.Errorf("payment: booking amount must be positive, got %d bani", amountBani)
 	}
 
 	// Get the company's Stripe Connect account.
 	if !booking.CompanyID.Valid {
-		return "", "", 0, fmt.Errorf("payment: booking has no company assigned")
+		return nil, 0, fmt.Errorf("payment: booking has no company assigned")
 	}
 
 	connectInfo, err := s.queries.GetCompanyStripeConnect(ctx, booking.CompanyID)
 	if err != nil {
-		return "", "", 0, fmt.Errorf("payment: failed to get company stripe connect info: %w", err)
+		return nil, 0, fmt.Errorf("payment: failed to get company stripe connect info: %w", err)
 	}
 
 	if !connectInfo.StripeConnectAccountID.Valid || connectInfo.StripeConnectAccountID.String == "" {
-		return "", "", 0, fmt.Errorf("payment: company does not have a stripe connect account")
+		return nil, 0, fmt.Errorf("payment: company does not have a stripe connect account")
 	}
 
 	// Get user info for Stripe customer creation.
 	if !booking.ClientUserID.Valid {
-		return "", "", 0, fmt.Errorf("payment: booking has no client user")
+		return nil, 0, fmt.Errorf("payment: booking has no client user")
 	}
 
 	user, err := s.queries.GetUserByID(ctx, booking.ClientUserID)
 	if err != nil {
-		return "", "", 0, fmt.Errorf("payment: failed to get client user: %w", err)
+		return nil, 0, fmt.Errorf("payment: failed to get client user: %w", err)
 	}
 
 	customerID, err := s.EnsureStripeCustomer(ctx, booking.ClientUserID, user.Email, user.FullName)
 	if err != nil {
-		return "", "", 0, fmt.Errorf("payment: failed to ensure stripe customer: %w", err)
+		return nil, 0, fmt.Errorf("payment: failed to ensure stripe customer: %w", err)
 	}
 
 	// Calculate platform commission.
@@ -247,22 +283,41 @@ func (s *Service) CreatePaymentIntentForBooking(ctx context.Context, booking db.
 
 	applicationFee := int64(math.Round(float64(amountBani) * commissionPct / 100.0))
 
+	chargeBani := amountBani
+	captureMethod := stripe.PaymentIntentCaptureMethodAutomatic
+	var amountAuthorized pgtype.Int4
+	if opts.Manual {
+		chargeBani = opts.holdAmount(amountBani)
+		captureMethod = stripe.PaymentIntentCaptureMethodManual
+		amountAuthorized = pgtype.Int4{Int32: int32(chargeBani), Valid: true}
+	}
+
 	// Create the PaymentIntent with Connect transfer.
 	params := &stripe.PaymentIntentParams{
-		Amount:               stripe.Int64(amountBani),
+		Amount:               stripe.Int64(chargeBani),
 		Currency:             stripe.String("ron"),
 		Customer:             stripe.String(customerID),
 		ApplicationFeeAmount: stripe.Int64(applicationFee),
 		TransferData: &stripe.PaymentIntentTransferDataParams{
 			Destination: stripe.String(connectInfo.StripeConnectAccountID.String),
 		},
+		CaptureMethod: stripe.String(string(captureMethod)),
+	}
+	if opts.Manual && paymentMethodID == "" {
+		params.SetupFutureUsage = stripe.String(string(stripe.PaymentIntentSetupFutureUsageOffSession))
+	}
+	if paymentMethodID != "" {
+		params.PaymentMethod = stripe.String(paymentMethodID)
 	}
 	params.AddMetadata("booking_id", uuidToString(booking.ID))
 	params.AddMetadata("reference_code", booking.ReferenceCode)
+	if paymentMethodID != "" {
+		params.AddMetadata("off_session", "true")
+	}
 
-	pi, err := paymentintent.New(params)
+	pi, err := s.provider.CreatePaymentIntent(params)
 	if err != nil {
-		return "", "", 0, fmt.Errorf("payment: failed to create payment intent: %w", err)
+		return nil, 0, fmt.Errorf("payment: failed to create payment intent: %w", err)
 	}
 
 	// Store the payment intent ID on the booking.
@@ -278,7 +333,7 @@ func (s *Service) CreatePaymentIntentForBooking(ctx context.Context, booking db.
 		},
 	})
 	if err != nil {
-		return "", "", 0, fmt.Errorf("payment: failed to update booking with payment intent: %w", err)
+		return nil, 0, fmt.Errorf("payment: failed to update booking with payment intent: %w", err)
 	}
 
 	// Create the payment transaction audit record.
@@ -297,48 +352,28 @@ func (s *Service) CreatePaymentIntentForBooking(ctx context.Context, booking db.
 		Currency:              "ron",
 		Status:                db.PaymentTransactionStatusPending,
 		Metadata:              metadata,
+		CaptureMethod:         string(captureMethod),
+		AmountAuthorized:      amountAuthorized,
 	})
 	if err != nil {
-		return "", "", 0, fmt.Errorf("payment: failed to create payment transaction record: %w", err)
-	}
-
-	log.Printf("payment: created PaymentIntent %s for booking %s, amount=%d bani, fee=%d bani",
-		pi.ID, uuidToString(booking.ID), amountBani, applicationFee)
-
-	return pi.ClientSecret, pi.ID, amountBani, nil
-}
-
-// HandleWebhookEvent verifies and processes incoming Stripe webhook events.
-func (s *Service) HandleWebhookEvent(ctx context.Context, payload []byte, sigHeader string) error {
-	event, err := webhook.ConstructEventWithOptions(payload, sigHeader, s.webhookSecret, webhook.ConstructEventOptions{
-		IgnoreAPIVersionMismatch: true,
-	})
-	if err != nil {
-		return fmt.Errorf("payment: webhook signature verification failed: %w", err)
+		return nil, 0, fmt.Errorf("payment: failed to create payment transaction record: %w", err)
 	}
 
-	log.Printf("payment: received webhook event %s (type: %s)", event.ID, event.Type)
+	log.Printf("payment: created PaymentIntent %s for booking %s, amount=%d bani, fee=%d bani, capture=%s",
+		pi.ID, uuidToString(booking.ID), chargeBani, applicationFee, captureMethod)
 
-	switch event.Type {
-	case "payment_intent.succeeded":
-		return s.handlePaymentIntentSucceeded(ctx, event)
-	case "payment_intent.payment_failed":
-		return s.handlePaymentIntentFailed(ctx, event)
-	case "charge.refunded":
-		return s.handleChargeRefunded(ctx, event)
-	case "account.updated":
-		return s.handleAccountUpdated(ctx, event)
-	default:
-		log.Printf("payment: unhandled webhook event type: %s", event.Type)
-		return nil
-	}
+	return pi, chargeBani, nil
 }
 
-// handlePaymentIntentSucceeded processes a successful payment.
-func (s *Service) handlePaymentIntentSucceeded(ctx context.Context, event stripe.Event) error {
+// handlePaymentIntentSucceeded processes a successful payment. It returns the
+// booking as paid when this event first posted the payment, and as confirmed
+// when it moved the booking to confirmed, so the caller can run the
+// callbacks once the event is committed. A replay returns neither.
+// A payment since refunded or cancelled is left as it is.
+func (s *Service) handlePaymentIntentSucceeded(ctx context.Context, q *db.Queries, event stripe.Event) (webhookEffects, error) {
 	var pi stripe.PaymentIntent
 	if err := json.Unmarshal(event.Data.Raw, &pi); err != nil {
-		return fmt.Errorf("payment: failed to unmarshal payment_intent.succeeded: %w", err)
+		return webhookEffects{}, fmt.Errorf("payment: failed to unmarshal payment_intent.succeeded: %w", err)
 	}
 
 	// Extract charge ID from the latest charge.
@@ -348,7 +383,7 @@ func (s *Service) handlePaymentIntentSucceeded(ctx context.Context, event stripe
 	}
 
 	// Update the payment transaction status.
-	txn, err := s.queries.UpdatePaymentTransactionStatus(ctx, db.UpdatePaymentTransactionStatusParams{
+	txn, err := q.UpdatePaymentTransactionStatus(ctx, db.UpdatePaymentTransactionStatusParams{
 		StripePaymentIntentID: pi.ID,
 		Status:                db.PaymentTransactionStatusSucceeded,
 		StripeChargeID: pgtype.Text{
@@ -356,27 +391,60 @@ func (s *Service) handlePaymentIntentSucceeded(ctx context.Context, event stripe
 			Valid:  chargeID != "",
 		},
 	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		log.Printf("payment: payment_intent.succeeded for PI %s skipped, its payment has moved on", pi.ID)
+		return webhookEffects{}, nil
+	}
 	if err != nil {
-		return fmt.Errorf("payment: failed to update transaction for PI %s: %w", pi.ID, err)
+		return webhookEffects{}, fmt.Errorf("payment: failed to update transaction for PI %s: %w", pi.ID, err)
 	}
 
-	// Mark the booking as paid AND auto-confirm if pending/assigned.
-	booking, err := s.queries.MarkBookingPaidAndConfirmed(ctx, txn.BookingID)
+	before, err := q.GetBookingByID(ctx, txn.BookingID)
 	if err != nil {
-		return fmt.Errorf("payment: failed to mark booking paid for PI %s: %w", pi.ID, err)
+		return webhookEffects{}, fmt.Errorf("payment: failed to load booking for PI %s: %w", pi.ID, err)
 	}
 
-	// If booking was auto-confirmed, create chat room via callback.
-	if booking.Status == db.BookingStatusConfirmed && s.OnBookingConfirmed != nil {
-		go s.OnBookingConfirmed(context.Background(), booking)
+	// Mark the booking as paid AND auto-confirm if pending/assigned.
+	booking, err := q.MarkBookingPaidAndConfirmed(ctx, txn.BookingID)
+	if err != nil {
+		return webhookEffects{}, fmt.Errorf("payment: failed to mark booking paid for PI %s: %w", pi.ID, err)
+	}
+
+	gross, fee := pi.AmountReceived, pi.ApplicationFeeAmount
+	if gross == 0 {
+		gross, fee = int64(txn.AmountTotal), int64(txn.AmountPlatformFee)
+	}
+	posted, err := s.ledger.PostWith(ctx, q, ledger.PaymentEntry(ledger.Payment{
+		PaymentIntentID: pi.ID,
+		BookingID:       booking.ID,
+		ClientID:        booking.ClientUserID,
+		CompanyID:       booking.CompanyID,
+		Gross:           gross,
+		PlatformFee:     fee,
+		At:              time.Unix(event.Created, 0),
+	}))
+	if err != nil {
+		return webhookEffects{}, fmt.Errorf("payment: failed to post payment %s to the ledger: %w", pi.ID, err)
 	}
 
 	log.Printf("payment: payment_intent.succeeded processed for PI %s, booking %s, status=%s", pi.ID, uuidToString(txn.BookingID), booking.Status)
-	return nil
+
+	// Only a booking this payment auto-confirmed gets its chat room, and only
+	// the event that posted the payment reports it paid; a replay finds both
+	// done.
+	var effects webhookEffects
+	if posted {
+		effects.paid = &booking
+	}
+	if before.Status != db.BookingStatusConfirmed && booking.Status == db.BookingStatusConfirmed {
+		effects.confirmed = &booking
+	}
+	return effects, nil
 }
 
-// handlePaymentIntentFailed processes a failed payment.
-func (s *Service) handlePaymentIntentFailed(ctx context.Context, event stripe.Event) error {
+// handlePaymentIntentFailed processes a failed payment. A payment that has
+// since been authorized, succeeded or been cancelled is left as it is.
+func (s *Service) handlePaymentIntentFailed(ctx context.Context, q *db.Queries, event stripe.Event) error {
 	var pi stripe.PaymentIntent
 	if err := json.Unmarshal(event.Data.Raw, &pi); err != nil {
 		return fmt.Errorf("payment: failed to unmarshal payment_intent.payment_failed: %w", err)
@@ -387,26 +455,34 @@ func (s *Service) handlePaymentIntentFailed(ctx context.Context, event stripe.Ev
 		failureMessage = pi.LastPaymentError.Msg
 	}
 
-	txn, err := s.queries.UpdatePaymentTransactionFailed(ctx, db.UpdatePaymentTransactionFailedParams{
+	txn, err := q.UpdatePaymentTransactionFailed(ctx, db.UpdatePaymentTransactionFailedParams{
 		StripePaymentIntentID: pi.ID,
 		FailureReason: pgtype.Text{
 			String: failureMessage,
 			Valid:  failureMessage != "",
 		},
 	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		log.Printf("payment: payment_intent.payment_failed for PI %s skipped, its payment has moved on", pi.ID)
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("payment: failed to update transaction failure for PI %s: %w", pi.ID, err)
 	}
 
-	// Auto-cancel the booking since payment failed.
-	if txn.BookingID.Valid {
-		_, cancelErr := s.queries.UpdateBookingStatus(ctx, db.UpdateBookingStatusParams{
-			ID:     txn.BookingID,
-			Status: db.BookingStatusCancelledByAdmin,
+	// Auto-cancel the booking since payment failed, unless it has started or
+	// is now paid with another PaymentIntent. Failed off-session charges
+	// (hold renewals and recurring occurrences) leave the booking to their
+	// caller, which asks the client to pay again.
+	if txn.BookingID.Valid && pi.Metadata["reauthorization_of"] == "" && pi.Metadata["off_session"] == "" {
+		cancelled, cancelErr := q.CancelBookingForFailedPayment(ctx, db.CancelBookingForFailedPaymentParams{
+			ID:                    txn.BookingID,
+			StripePaymentIntentID: pgtype.Text{String: pi.ID, Valid: true},
 		})
 		if cancelErr != nil {
-			log.Printf("payment: warning: failed to cancel booking for failed PI %s: %v", pi.ID, cancelErr)
-		} else {
+			return fmt.Errorf("payment: failed to cancel booking for failed PI %s: %w", pi.ID, cancelErr)
+		}
+		if cancelled > 0 {
 			log.Printf("payment: auto-cancelled booking for failed PI %s", pi.ID)
 		}
 	}
@@ -416,26 +492,30 @@ func (s *Service) handlePaymentIntentFailed(ctx context.Context, event stripe.Ev
 }
 
 // handleChargeRefunded processes a refund event.
-func (s *Service) handleChargeRefunded(ctx context.Context, event stripe.Event) error {
+func (s *Service) handleChargeRefunded(ctx context.Context, q *db.Queries, event stripe.Event) ([]ledger.Refund, error) {
 	var charge stripe.Charge
 	if err := json.Unmarshal(event.Data.Raw, &charge); err != nil {
-		return fmt.Errorf("payment: failed to unmarshal charge.refunded: %w", err)
+		return nil, fmt.Errorf("payment: failed to unmarshal charge.refunded: %w", err)
 	}
 
 	if charge.PaymentIntent == nil {
 		log.Printf("payment: charge.refunded event has no payment intent, skipping")
-		return nil
+		return nil, nil
 	}
 
 	piID := charge.PaymentIntent.ID
 
-	// Determine refund details.
+	// Charges do not embed their refunds since Stripe API 2022-11-15; list
+	// them. The latest one is recorded on the transaction.
+	refunds, err := s.chargeRefunds(charge.ID)
+	if err != nil {
+		return nil, err
+	}
 	var refundID string
 	var refundAmount int64
-	if charge.Refunds != nil && len(charge.Refunds.Data) > 0 {
-		latestRefund := charge.Refunds.Data[0]
-		refundID = latestRefund.ID
-		refundAmount = latestRefund.Amount
+	if len(refunds) > 0 {
+		refundID = refunds[0].ID
+		refundAmount = refunds[0].Amount
 	}
 
 	// Determine whether this is a full or partial refund.
@@ -444,7 +524,7 @@ func (s *Service) handleChargeRefunded(ctx context.Context, event stripe.Event)
 		status = db.PaymentTransactionStatusPartiallyRefunded
 	}
 
-	_, err := s.queries.UpdatePaymentTransactionRefund(ctx, db.UpdatePaymentTransactionRefundParams{
+	txn, err := q.UpdatePaymentTransactionRefund(ctx, db.UpdatePaymentTransactionRefundParams{
 		StripePaymentIntentID: piID,
 		Status:                status,
 		RefundAmount: pgtype.Int4{
@@ -456,16 +536,81 @@ func (s *Service) handleChargeRefunded(ctx context.Context, event stripe.Event)
 			Valid:  refundID != "",
 		},
 	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		// An earlier partial refund replayed after the full one: the
+		// transaction stays refunded, the refunds are still posted once.
+		txn, err = q.GetPaymentTransactionByStripePI(ctx, piID)
+	}
 	if err != nil {
-		return fmt.Errorf("payment: failed to update transaction refund for PI %s: %w", piID, err)
+		return nil, fmt.Errorf("payment: failed to update transaction refund for PI %s: %w", piID, err)
+	}
+
+	booking, err := q.GetBookingByID(ctx, txn.BookingID)
+	if err != nil {
+		return nil, fmt.Errorf("payment: failed to load booking for PI %s: %w", piID, err)
+	}
+	// Each refund is posted once: refunds an earlier event already posted
+	// under their refund:<id> reference are skipped. The credit notes each
+	// refund is owed are recorded in the same transaction, once, for the
+	// invoice-pipeline job to issue.
+	var recorded []ledger.Refund
+	for _, re := range refunds {
+		refund := ledger.Refund{
+			RefundID:        re.ID,
+			PaymentIntentID: piID,
+			BookingID:       booking.ID,
+			ClientID:        booking.ClientUserID,
+			CompanyID:       booking.CompanyID,
+			Amount:          re.Amount,
+			PaymentGross:    charge.Amount,
+			PaymentFee:      charge.ApplicationFeeAmount,
+			At:              time.Unix(re.Created, 0),
+		}
+		if _, err := s.ledger.PostWith(ctx, q, ledger.RefundEntry(refund)); err != nil {
+			return nil, fmt.Errorf("payment: failed to post refund %s to the ledger: %w", re.ID, err)
+		}
+		n, err := q.CreateRefundCredit(ctx, db.CreateRefundCreditParams{
+			RefundID:        refund.RefundID,
+			PaymentIntentID: refund.PaymentIntentID,
+			BookingID:       refund.BookingID,
+			CompanyID:       refund.CompanyID,
+			Amount:          refund.Amount,
+			PaymentGross:    refund.PaymentGross,
+			PaymentFee:      refund.PaymentFee,
+			RefundedAt:      pgtype.Timestamptz{Time: refund.At, Valid: true},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("payment: failed to record credit of refund %s: %w", re.ID, err)
+		}
+		if n > 0 {
+			recorded = append(recorded, refund)
+		}
 	}
 
 	log.Printf("payment: charge.refunded processed for PI %s, refund=%d, status=%s", piID, refundAmount, status)
-	return nil
+	return recorded, nil
+}
+
+// chargeRefunds returns the refunds of a charge that went through or may
+// still, newest first.
+func (s *Service) chargeRefunds(chargeID string) ([]*stripe.Refund, error) {
+	all, err := s.provider.ListRefunds(&stripe.RefundListParams{Charge: stripe.String(chargeID)})
+	if err != nil {
+		return nil, fmt.Errorf("payment: failed to list refunds of charge %s: %w", chargeID, err)
+	}
+	refunds := make([]*stripe.Refund, 0, len(all))
+	for _, re := range all {
+		if re.Status == stripe.RefundStatusFailed || re.Status == stripe.RefundStatusCanceled {
+			continue
+		}
+		refunds = append(refunds, re)
+	}
+	sort.SliceStable(refunds, func(i, j int) bool { return refunds[i].Created > refunds[j].Created })
+	return refunds, nil
 }
 
 // handleAccountUpdated processes a Stripe Connect account update event.
-func (s *Service) handleAccountUpdated(ctx context.Context, event stripe.Event) error {
+func (s *Service) handleAccountUpdated(ctx context.Context, q *db.Queries, event stripe.Event) error {
 	var acct stripe.Account
 	if err := json.Unmarshal(event.Data.Raw, &acct); err != nil {
 		return fmt.Errorf("payment: failed to unmarshal account.updated: %w", err)
@@ -486,7 +631,7 @@ func (s *Service) handleAccountUpdated(ctx context.Context, event stripe.Event)
 	chargesEnabled := acct.ChargesEnabled
 	payoutsEnabled := acct.PayoutsEnabled
 
-	err = s.queries.SetCompanyStripeConnect(ctx, db.SetCompanyStripeConnectParams{
+	err = q.SetCompanyStripeConnect(ctx, db.SetCompanyStripeConnectParams{
 		ID: companyID,
 		StripeConnectAccountID: pgtype.Text{
 			String: acct.ID,
@@ -515,7 +660,8 @@ func (s *Service) handleAccountUpdated(ctx context.Context, event stripe.Event)
 }
 
 // CreateConnectAccount creates a Stripe Express Connect account for a company
-// and saves the account ID to the database.
+// and saves the account ID to the database. The account's payout schedule is
+// manual: the platform pays companies out on its own cadence (ExecutePayout).
 func (s *Service) CreateConnectAccount(ctx context.Context, companyID pgtype.UUID, companyName string, email string) (string, error) {
 	params := &stripe.AccountParams{
 		Type:    stripe.String(string(stripe.AccountTypeExpress)),
@@ -524,6 +670,13 @@ func (s *Service) CreateConnectAccount(ctx context.Context, companyID pgtype.UUI
 		BusinessProfile: &stripe.AccountBusinessProfileParams{
 			Name: stripe.String(companyName),
 		},
+		Settings: &stripe.AccountSettingsParams{
+			Payouts: &stripe.AccountSettingsPayoutsParams{
+				Schedule: &stripe.AccountSettingsPayoutsScheduleParams{
+					Interval: stripe.String("manual"),
+				},
+			},
+		},
 		Capabilities: &stripe.AccountCapabilitiesParams{
 			CardPayments: &stripe.AccountCapabilitiesCardPaymentsParams{
 				Requested: stripe.Bool(true),
@@ -535,7 +688,7 @@ func (s *Service) CreateConnectAccount(ctx context.Context, companyID pgtype.UUI
 	}
 	params.AddMetadata("company_id", uuidToString(companyID))
 
-	acct, err := account.New(params)
+	acct, err := s.provider.CreateAccount(params)
 	if err != nil {
 		return "", fmt.Errorf("payment: failed to create connect account: %w", err)
 	}
@@ -577,7 +730,7 @@ func (s *Service) CreateConnectOnboardingLink(ctx context.Context, accountID str
 		Type:       stripe.String(string(stripe.AccountLinkTypeAccountOnboarding)),
 	}
 
-	link, err := accountlink.New(params)
+	link, err := s.provider.CreateAccountLink(params)
 	if err != nil {
 		return "", fmt.Errorf("payment: failed to create onboarding link: %w", err)
 	}
@@ -602,7 +755,7 @@ func (s *Service) GetConnectAccountStatus(ctx context.Context, companyID pgtype.
 	accountID := row.StripeConnectAccountID.String
 
 	// Fetch fresh status from Stripe API.
-	acct, err := account.GetByID(accountID, nil)
+	acct, err := s.provider.GetAccount(accountID)
 	if err != nil {
 		// If we cannot reach Stripe, return the cached DB values.
 		log.Printf("payment: warning: failed to fetch fresh account status for %s: %v", accountID, err)
@@ -642,14 +795,18 @@ func (s *Service) GetConnectAccountStatus(ctx context.Context, companyID pgtype.
 }
 
 // CreateRefund creates a Stripe refund for a given payment intent.
-// amountBani is the amount to refund in RON cents. Returns the Stripe refund ID.
+// amountBani is the amount to refund in RON cents. The transfer to the company
+// and the platform fee are reversed in proportion, as the ledger books it.
+// Returns the Stripe refund ID.
 func (s *Service) CreateRefund(ctx context.Context, paymentIntentID string, amountBani int64) (string, error) {
 	params := &stripe.RefundParams{
-		PaymentIntent: stripe.String(paymentIntentID),
-		Amount:        stripe.Int64(amountBani),
+		PaymentIntent:        stripe.String(paymentIntentID),
+		Amount:               stripe.Int64(amountBani),
+		ReverseTransfer:      stripe.Bool(true),
+		RefundApplicationFee: stripe.Bool(true),
 	}
 
-	r, err := refund.New(params)
+	r, err := s.provider.CreateRefund(params)
 	if err != nil {
 		return "", fmt.Errorf("payment: failed to create refund for PI %s: %w", paymentIntentID, err)
 	}
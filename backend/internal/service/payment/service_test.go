@@ -0,0 +1,56 @@
+package payment
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stripe/stripe-go/v81"
+)
+
+// TestChargeRefundsWithoutEmbeddedList checks that the refunds of a
+// charge.refunded event are listed from the provider: since Stripe API
+// 2022-11-15 the charge in the event has no refunds field.
+func TestChargeRefundsWithoutEmbeddedList(t *testing.T) {
+	f := NewFakeProvider()
+	pi, err := f.CreatePaymentIntent(&stripe.PaymentIntentParams{
+		Amount:        stripe.Int64(20000),
+		Currency:      stripe.String("ron"),
+		PaymentMethod: stripe.String("pm_card_visa"),
+		Confirm:       stripe.Bool(true),
+	})
+	if err != nil {
+		t.Fatalf("CreatePaymentIntent() error: %v", err)
+	}
+	if _, err := f.CreateRefund(&stripe.RefundParams{PaymentIntent: stripe.String(pi.ID), Amount: stripe.Int64(5000)}); err != nil {
+		t.Fatalf("CreateRefund() error: %v", err)
+	}
+	if _, err := f.CreateRefund(&stripe.RefundParams{PaymentIntent: stripe.String(pi.ID), Amount: stripe.Int64(3000)}); err != nil {
+		t.Fatalf("CreateRefund() error: %v", err)
+	}
+
+	payload := []byte(`{"id":"` + pi.LatestCharge.ID + `","object":"charge","amount":20000,"amount_refunded":8000,` +
+		`"payment_intent":"` + pi.ID + `","refunded":false}`)
+	var charge stripe.Charge
+	if err := json.Unmarshal(payload, &charge); err != nil {
+		t.Fatalf("failed to decode charge: %v", err)
+	}
+	if charge.Refunds != nil {
+		t.Fatalf("test charge embeds refunds: %+v", charge.Refunds)
+	}
+
+	s := &Service{provider: f}
+	refunds, err := s.chargeRefunds(charge.ID)
+	if err != nil {
+		t.Fatalf("chargeRefunds() error: %v", err)
+	}
+	if len(refunds) != 2 {
+		t.Fatalf("chargeRefunds() returned %d refunds, want 2", len(refunds))
+	}
+	var total int64
+	for _, re := range refunds {
+		total += re.Amount
+	}
+	if total != 8000 {
+		t.Errorf("refunds add up to %d, want the 8000 refunded", total)
+	}
+}
@@ -0,0 +1,229 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stripe/stripe-go/v81"
+
+	db "helpmeclean-backend/internal/db/generated"
+	"helpmeclean-backend/internal/service/ledger"
+)
+
+// ErrInvalidSignature is returned by HandleWebhookEvent when the payload does
+// not carry a valid Stripe signature.
+var ErrInvalidSignature = errors.New("payment: webhook signature verification failed")
+
+const (
+	// maxWebhookAttempts is how many times an event is processed before the
+	// retry job gives up on it. It can still be replayed by an admin.
+	maxWebhookAttempts = 10
+	// webhookRetryBatch is how many due events one retry run processes.
+	webhookRetryBatch = 50
+)
+
+// HandleWebhookEvent verifies an incoming Stripe webhook, stores it in the
+// event log and processes it. Once stored, an event is never lost: a
+// processing error is recorded on the event and retried by RetryWebhookEvents,
+// so only verification and storage errors are returned.
+func (s *Service) HandleWebhookEvent(ctx context.Context, payload []byte, sigHeader string) error {
+	event, err := s.provider.ConstructEvent(payload, sigHeader)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+
+	log.Printf("payment: received webhook event %s (type: %s)", event.ID, event.Type)
+
+	stored, err := s.queries.RecordStripeEvent(ctx, db.RecordStripeEventParams{
+		ID:        event.ID,
+		EventType: string(event.Type),
+		Payload:   payload,
+	})
+	if err != nil {
+		return fmt.Errorf("payment: failed to store webhook event %s: %w", event.ID, err)
+	}
+	if stored == 0 {
+		log.Printf("payment: webhook event %s is a redelivery", event.ID)
+	}
+
+	if err := s.ProcessWebhookEvent(ctx, event.ID); err != nil {
+		log.Printf("payment: webhook event %s failed, will be retried: %v", event.ID, err)
+	}
+	return nil
+}
+
+// ProcessWebhookEvent processes a stored event exactly once. The event is
+// claimed first, so concurrent deliveries and retry runs never process it
+// twice, and its effects are committed in the same transaction that marks it
+// processed. Events already processed, or claimed by another worker, are
+// skipped.
+func (s *Service) ProcessWebhookEvent(ctx context.Context, eventID string) error {
+	ev, err := s.queries.ClaimStripeEvent(ctx, eventID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("payment: failed to claim webhook event %s: %w", eventID, err)
+	}
+
+	effects, err := s.applyWebhookEvent(ctx, ev)
+	if err != nil {
+		s.recordWebhookFailure(ctx, ev, err)
+		return err
+	}
+
+	// If the booking was auto-confirmed, create the chat room via callback.
+	if effects.confirmed != nil && s.OnBookingConfirmed != nil {
+		go s.OnBookingConfirmed(context.Background(), *effects.confirmed)
+	}
+	if effects.paid != nil && s.OnBookingPaid != nil {
+		go s.OnBookingPaid(context.Background(), *effects.paid)
+	}
+	if len(effects.refunds) > 0 && s.OnRefunded != nil {
+		go func() {
+			for _, refund := range effects.refunds {
+				s.OnRefunded(context.Background(), refund)
+			}
+		}()
+	}
+	return nil
+}
+
+// webhookEffects are the bookings a committed webhook event moved on and the
+// refunds it recorded credit notes for, for the callbacks run after the
+// commit.
+type webhookEffects struct {
+	confirmed *db.Booking
+	paid      *db.Booking
+	refunds   []ledger.Refund
+}
+
+// applyWebhookEvent runs the handler for the event's type and marks the event
+// processed, in one transaction.
+func (s *Service) applyWebhookEvent(ctx context.Context, ev db.StripeEvent) (webhookEffects, error) {
+	var event stripe.Event
+	if err := json.Unmarshal(ev.Payload, &event); err != nil {
+		return webhookEffects{}, fmt.Errorf("payment: failed to decode webhook event %s: %w", ev.ID, err)
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return webhookEffects{}, fmt.Errorf("payment: failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	qtx := s.queries.WithTx(tx)
+
+	var effects webhookEffects
+	switch event.Type {
+	case "payment_intent.succeeded":
+		effects, err = s.handlePaymentIntentSucceeded(ctx, qtx, event)
+	case "payment_intent.amount_capturable_updated":
+		effects.confirmed, err = s.handlePaymentIntentAuthorized(ctx, qtx, event)
+	case "payment_intent.payment_failed":
+		err = s.handlePaymentIntentFailed(ctx, qtx, event)
+	case "payment_intent.canceled":
+		err = s.handlePaymentIntentCanceled(ctx, qtx, event)
+	case "charge.refunded":
+		effects.refunds, err = s.handleChargeRefunded(ctx, qtx, event)
+	case "account.updated":
+		err = s.handleAccountUpdated(ctx, qtx, event)
+	case "payout.paid", "payout.failed", "payout.canceled":
+		err = s.handlePayoutEvent(ctx, qtx, event)
+	case "charge.dispute.created", "charge.dispute.updated", "charge.dispute.closed",
+		"charge.dispute.funds_withdrawn", "charge.dispute.funds_reinstated":
+		err = s.handleDisputeEvent(ctx, qtx, event)
+	default:
+		log.Printf("payment: unhandled webhook event type: %s", event.Type)
+	}
+	if err != nil {
+		return webhookEffects{}, err
+	}
+
+	if err := qtx.MarkStripeEventProcessed(ctx, ev.ID); err != nil {
+		return webhookEffects{}, fmt.Errorf("payment: failed to mark webhook event %s processed: %w", ev.ID, err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return webhookEffects{}, fmt.Errorf("payment: failed to commit webhook event %s: %w", ev.ID, err)
+	}
+	return effects, nil
+}
+
+// recordWebhookFailure stores the error on the event and schedules the next
+// attempt with exponential backoff (1 minute doubling up to ~8.5 hours).
+// After maxWebhookAttempts the event is left failed with no next attempt.
+func (s *Service) recordWebhookFailure(ctx context.Context, ev db.StripeEvent, cause error) {
+	var next pgtype.Timestamptz
+	if ev.Attempts < maxWebhookAttempts {
+		delay := time.Duration(1<<min(ev.Attempts-1, 9)) * time.Minute
+		next = pgtype.Timestamptz{Time: time.Now().Add(delay), Valid: true}
+	} else {
+		log.Printf("payment: giving up on webhook event %s after %d attempts", ev.ID, ev.Attempts)
+	}
+
+	err := s.queries.MarkStripeEventFailed(ctx, db.MarkStripeEventFailedParams{
+		ID:            ev.ID,
+		LastError:     pgtype.Text{String: cause.Error(), Valid: true},
+		NextAttemptAt: next,
+	})
+	if err != nil {
+		log.Printf("payment: warning: failed to record failure of webhook event %s: %v", ev.ID, err)
+	}
+}
+
+// RetryWebhookEvents processes stored events that are due for another
+// attempt, including claims abandoned by a crashed worker. It is run
+// periodically by the job scheduler.
+func (s *Service) RetryWebhookEvents(ctx context.Context) error {
+	events, err := s.queries.ListDueStripeEvents(ctx, webhookRetryBatch)
+	if err != nil {
+		return fmt.Errorf("payment: failed to list due webhook events: %w", err)
+	}
+
+	failed := 0
+	for _, ev := range events {
+		if err := s.ProcessWebhookEvent(ctx, ev.ID); err != nil {
+			log.Printf("payment: retry of webhook event %s failed: %v", ev.ID, err)
+			failed++
+		}
+	}
+	if len(events) > 0 {
+		log.Printf("payment: retried %d webhook events, %d failed", len(events), failed)
+	}
+	return nil
+}
+
+// ReplayWebhookEvent processes a stored event again, whatever its status,
+// and returns it with the outcome. Handlers only make the status changes
+// that are still allowed, so a replay cannot move a payment or booking back
+// (a succeeded payment to failed, a refunded one to succeeded), and ledger
+// entries and credit notes are recorded once. The callbacks run only for
+// what the replay changed. Events being processed right now cannot be
+// replayed.
+func (s *Service) ReplayWebhookEvent(ctx context.Context, eventID string) (db.StripeEvent, error) {
+	if _, err := s.queries.ResetStripeEventForReplay(ctx, eventID); err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return db.StripeEvent{}, fmt.Errorf("payment: failed to reset webhook event %s: %w", eventID, err)
+		}
+		if _, getErr := s.queries.GetStripeEvent(ctx, eventID); getErr != nil {
+			return db.StripeEvent{}, fmt.Errorf("payment: webhook event %s not found: %w", eventID, getErr)
+		}
+		return db.StripeEvent{}, fmt.Errorf("payment: webhook event %s is being processed", eventID)
+	}
+
+	if err := s.ProcessWebhookEvent(ctx, eventID); err != nil {
+		log.Printf("payment: replay of webhook event %s failed: %v", eventID, err)
+	}
+
+	ev, err := s.queries.GetStripeEvent(ctx, eventID)
+	if err != nil {
+		return db.StripeEvent{}, fmt.Errorf("payment: failed to reload webhook event %s: %w", eventID, err)
+	}
+	log.Printf("payment: replayed webhook event %s, status=%s", eventID, ev.Status)
+	return ev, nil
+}
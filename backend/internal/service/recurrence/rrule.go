@@ -0,0 +1,441 @@
+// Package recurrence expands recurring booking series described by RFC 5545
+// recurrence rules (RRULE) and exception dates (EXDATE).
+//
+// Series are expanded on calendar dates in Europe/Bucharest time: a rule
+// yields dates, and the booking's start time is applied on top of them, so
+// daylight saving changes never move an occurrence to another day.
+package recurrence
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // Europe/Bucharest must resolve without system zoneinfo
+)
+
+// Location is the time zone series are expanded in.
+var Location = mustLoadLocation("Europe/Bucharest")
+
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
+
+// Frequency is the FREQ part of a rule.
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+)
+
+// WeekdayNum is one BYDAY entry: a weekday with an optional ordinal, e.g.
+// 1FR (first Friday) or -1SU (last Sunday). N is 0 when no ordinal is given.
+type WeekdayNum struct {
+	Weekday time.Weekday
+	N       int
+}
+
+// Rule is a parsed RRULE. Only the parts that make sense for cleaning
+// schedules are supported: FREQ (DAILY, WEEKLY, MONTHLY), INTERVAL, COUNT,
+// UNTIL, BYDAY, BYMONTHDAY, BYSETPOS and WKST.
+type Rule struct {
+	Freq       Frequency
+	Interval   int
+	Count      int       // 0 means unbounded
+	Until      time.Time // last allowed date; zero means unbounded
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	BySetPos   []int
+	WeekStart  time.Weekday
+}
+
+var dayCodes = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+var weekdayCodes = [...]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// Parse parses an RRULE value such as "FREQ=WEEKLY;BYDAY=MO,TH". A leading
+// "RRULE:" is accepted.
+func Parse(s string) (*Rule, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	if s == "" {
+		return nil, fmt.Errorf("empty recurrence rule")
+	}
+
+	r := &Rule{Interval: 1, WeekStart: time.Monday}
+	seen := map[string]bool{}
+	for _, part := range strings.Split(s, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		key = strings.ToUpper(strings.TrimSpace(key))
+		value = strings.ToUpper(strings.TrimSpace(value))
+		if !ok || value == "" {
+			return nil, fmt.Errorf("invalid rule part %q", part)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("duplicate rule part %s", key)
+		}
+		seen[key] = true
+
+		switch key {
+		case "FREQ":
+			switch f := Frequency(value); f {
+			case Daily, Weekly, Monthly:
+				r.Freq = f
+			default:
+				return nil, fmt.Errorf("unsupported FREQ %s", value)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid INTERVAL %s", value)
+			}
+			r.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid COUNT %s", value)
+			}
+			r.Count = n
+		case "UNTIL":
+			until, err := parseUntil(value)
+			if err != nil {
+				return nil, err
+			}
+			r.Until = until
+		case "BYDAY":
+			for _, v := range strings.Split(value, ",") {
+				wd, err := parseWeekdayNum(v)
+				if err != nil {
+					return nil, err
+				}
+				r.ByDay = append(r.ByDay, wd)
+			}
+		case "BYMONTHDAY":
+			for _, v := range strings.Split(value, ",") {
+				n, err := strconv.Atoi(v)
+				if err != nil || n == 0 || n < -31 || n > 31 {
+					return nil, fmt.Errorf("invalid BYMONTHDAY %s", v)
+				}
+				r.ByMonthDay = append(r.ByMonthDay, n)
+			}
+		case "BYSETPOS":
+			for _, v := range strings.Split(value, ",") {
+				n, err := strconv.Atoi(v)
+				if err != nil || n == 0 || n < -366 || n > 366 {
+					return nil, fmt.Errorf("invalid BYSETPOS %s", v)
+				}
+				r.BySetPos = append(r.BySetPos, n)
+			}
+		case "WKST":
+			wd, ok := dayCodes[value]
+			if !ok {
+				return nil, fmt.Errorf("invalid WKST %s", value)
+			}
+			r.WeekStart = wd
+		default:
+			return nil, fmt.Errorf("unsupported rule part %s", key)
+		}
+	}
+
+	if r.Freq == "" {
+		return nil, fmt.Errorf("recurrence rule has no FREQ")
+	}
+	if r.Count > 0 && !r.Until.IsZero() {
+		return nil, fmt.Errorf("COUNT and UNTIL cannot both be set")
+	}
+	for _, wd := range r.ByDay {
+		if wd.N != 0 && r.Freq != Monthly {
+			return nil, fmt.Errorf("BYDAY ordinals are only allowed with FREQ=MONTHLY")
+		}
+	}
+	return r, nil
+}
+
+func parseWeekdayNum(s string) (WeekdayNum, error) {
+	if len(s) < 2 {
+		return WeekdayNum{}, fmt.Errorf("invalid BYDAY %s", s)
+	}
+	wd, ok := dayCodes[s[len(s)-2:]]
+	if !ok {
+		return WeekdayNum{}, fmt.Errorf("invalid BYDAY %s", s)
+	}
+	n := 0
+	if prefix := s[:len(s)-2]; prefix != "" {
+		var err error
+		n, err = strconv.Atoi(prefix)
+		if err != nil || n == 0 || n < -5 || n > 5 {
+			return WeekdayNum{}, fmt.Errorf("invalid BYDAY %s", s)
+		}
+	}
+	return WeekdayNum{Weekday: wd, N: n}, nil
+}
+
+// parseUntil accepts a DATE (20260131) or a UTC DATE-TIME (20260131T220000Z);
+// the latter is converted to its Bucharest calendar date.
+func parseUntil(s string) (time.Time, error) {
+	if t, err := time.Parse("20060102", s); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("20060102T150405Z", s); err == nil {
+		return Date(t.In(Location)), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid UNTIL %s", s)
+}
+
+// String formats the rule in canonical RRULE form, without the "RRULE:" prefix.
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, wd := range r.ByDay {
+			days[i] = weekdayCodes[wd.Weekday]
+			if wd.N != 0 {
+				days[i] = strconv.Itoa(wd.N) + days[i]
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		parts = append(parts, "BYMONTHDAY="+joinInts(r.ByMonthDay))
+	}
+	if len(r.BySetPos) > 0 {
+		parts = append(parts, "BYSETPOS="+joinInts(r.BySetPos))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.Format("20060102"))
+	}
+	if r.WeekStart != time.Monday {
+		parts = append(parts, "WKST="+weekdayCodes[r.WeekStart])
+	}
+	return strings.Join(parts, ";")
+}
+
+func joinInts(ns []int) string {
+	s := make([]string, len(ns))
+	for i, n := range ns {
+		s[i] = strconv.Itoa(n)
+	}
+	return strings.Join(s, ",")
+}
+
+// Date returns the calendar date of t (in t's own location) as midnight UTC,
+// the form pgtype.Date uses.
+func Date(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Today returns the current calendar date in Bucharest.
+func Today() time.Time {
+	return Date(time.Now().In(Location))
+}
+
+// StartTime returns the instant an occurrence on date starts, given its
+// local start time in microseconds since midnight.
+func StartTime(date time.Time, startMicros int64) time.Time {
+	d := Date(date)
+	minutes := int(startMicros / 60_000_000)
+	return time.Date(d.Year(), d.Month(), d.Day(), minutes/60, minutes%60, 0, 0, Location)
+}
+
+// maxPeriods bounds the expansion of rules that match rarely or never (e.g.
+// BYMONTHDAY=31 with BYDAY=MO), so a bad rule cannot loop forever.
+const maxPeriods = 5000
+
+// Between returns the dates of the series starting at dtstart that fall
+// within [from, to], ascending. dtstart is the first candidate date; dates
+// in exdates are left out but still count towards COUNT.
+func (r *Rule) Between(dtstart, from, to time.Time, exdates []time.Time) []time.Time {
+	dtstart, from, to = Date(dtstart), Date(from), Date(to)
+	excluded := map[time.Time]bool{}
+	for _, d := range exdates {
+		excluded[Date(d)] = true
+	}
+
+	var dates []time.Time
+	count := 0
+	for p := 0; p < maxPeriods; p++ {
+		for _, d := range r.period(dtstart, p) {
+			if d.Before(dtstart) {
+				continue
+			}
+			if !r.Until.IsZero() && d.After(r.Until) {
+				return dates
+			}
+			if d.After(to) {
+				return dates
+			}
+			count++
+			if !d.Before(from) && !excluded[d] {
+				dates = append(dates, d)
+			}
+			if r.Count > 0 && count >= r.Count {
+				return dates
+			}
+		}
+	}
+	return dates
+}
+
+// period returns the candidate dates of the p-th period (day, week or month,
+// times INTERVAL) after the one containing dtstart, ascending.
+func (r *Rule) period(dtstart time.Time, p int) []time.Time {
+	var candidates []time.Time
+	switch r.Freq {
+	case Daily:
+		d := dtstart.AddDate(0, 0, p*r.Interval)
+		if r.matchesDay(d) && r.matchesMonthDay(d) {
+			candidates = []time.Time{d}
+		}
+
+	case Weekly:
+		offset := (int(dtstart.Weekday()) - int(r.WeekStart) + 7) % 7
+		weekStart := dtstart.AddDate(0, 0, -offset+7*p*r.Interval)
+		byDay := r.ByDay
+		if len(byDay) == 0 && len(r.ByMonthDay) == 0 {
+			byDay = []WeekdayNum{{Weekday: dtstart.Weekday()}}
+		}
+		for i := 0; i < 7; i++ {
+			d := weekStart.AddDate(0, 0, i)
+			if (len(byDay) == 0 || hasWeekday(byDay, d.Weekday())) && r.matchesMonthDay(d) {
+				candidates = append(candidates, d)
+			}
+		}
+
+	case Monthly:
+		first := time.Date(dtstart.Year(), dtstart.Month()+time.Month(p*r.Interval), 1, 0, 0, 0, 0, time.UTC)
+		days := daysIn(first)
+		for i := 0; i < days; i++ {
+			d := first.AddDate(0, 0, i)
+			if r.matchesMonthly(d, dtstart, days) {
+				candidates = append(candidates, d)
+			}
+		}
+	}
+
+	if len(r.BySetPos) == 0 || len(candidates) == 0 {
+		return candidates
+	}
+	var selected []time.Time
+	for _, pos := range r.BySetPos {
+		i := pos - 1
+		if pos < 0 {
+			i = len(candidates) + pos
+		}
+		if i >= 0 && i < len(candidates) {
+			selected = append(selected, candidates[i])
+		}
+	}
+	sort.Slice(selected, func(i, j int) bool { return selected[i].Before(selected[j]) })
+	return dedupe(selected)
+}
+
+// matchesMonthly reports whether d is a candidate in its month. Without
+// BYDAY or BYMONTHDAY the series repeats on dtstart's day of the month;
+// months that do not have that day are skipped, as RFC 5545 requires.
+func (r *Rule) matchesMonthly(d, dtstart time.Time, days int) bool {
+	if len(r.ByDay) == 0 && len(r.ByMonthDay) == 0 {
+		return d.Day() == dtstart.Day()
+	}
+	if len(r.ByMonthDay) > 0 && !r.matchesMonthDay(d) {
+		return false
+	}
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	nth := (d.Day()-1)/7 + 1
+	nthFromEnd := -((days-d.Day())/7 + 1)
+	for _, wd := range r.ByDay {
+		if wd.Weekday != d.Weekday() {
+			continue
+		}
+		if wd.N == 0 || wd.N == nth || wd.N == nthFromEnd {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) matchesDay(d time.Time) bool {
+	return len(r.ByDay) == 0 || hasWeekday(r.ByDay, d.Weekday())
+}
+
+func (r *Rule) matchesMonthDay(d time.Time) bool {
+	if len(r.ByMonthDay) == 0 {
+		return true
+	}
+	days := daysIn(d)
+	for _, md := range r.ByMonthDay {
+		if md == d.Day() || (md < 0 && days+md+1 == d.Day()) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasWeekday(days []WeekdayNum, wd time.Weekday) bool {
+	for _, d := range days {
+		if d.Weekday == wd {
+			return true
+		}
+	}
+	return false
+}
+
+func daysIn(d time.Time) int {
+	return time.Date(d.Year(), d.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+func dedupe(dates []time.Time) []time.Time {
+	out := dates[:0]
+	for i, d := range dates {
+		if i == 0 || !d.Equal(dates[i-1]) {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// FromLegacy returns the rule equivalent to one of the original recurrence
+// types ("weekly", "biweekly", "monthly") for a series starting on anchor.
+// Monthly series anchored after the 28th fall back to the last day of
+// shorter months instead of skipping them.
+func FromLegacy(recurrenceType string, anchor time.Time) (string, error) {
+	day := weekdayCodes[anchor.Weekday()]
+	switch recurrenceType {
+	case "weekly":
+		return "FREQ=WEEKLY;BYDAY=" + day, nil
+	case "biweekly":
+		return "FREQ=WEEKLY;INTERVAL=2;BYDAY=" + day, nil
+	case "monthly":
+		if anchor.Day() <= 28 {
+			return "FREQ=MONTHLY;BYMONTHDAY=" + strconv.Itoa(anchor.Day()), nil
+		}
+		days := make([]int, 0, 4)
+		for d := 28; d <= anchor.Day(); d++ {
+			days = append(days, d)
+		}
+		return "FREQ=MONTHLY;BYMONTHDAY=" + joinInts(days) + ";BYSETPOS=-1", nil
+	default:
+		return "", fmt.Errorf("unknown recurrence type %q", recurrenceType)
+	}
+}
@@ -0,0 +1,249 @@
+package recurrence
+
+import (
+	"testing"
+	"time"
+)
+
+func d(s string) time.Time {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func formatDates(dates []time.Time) []string {
+	out := make([]string, len(dates))
+	for i, t := range dates {
+		out[i] = t.Format("2006-01-02")
+	}
+	return out
+}
+
+func expand(t *testing.T, rule, dtstart, from, to string, exdates ...time.Time) []string {
+	t.Helper()
+	r, err := Parse(rule)
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", rule, err)
+	}
+	return formatDates(r.Between(d(dtstart), d(from), d(to), exdates))
+}
+
+func assertDates(t *testing.T, got []string, want ...string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Parse / String
+// ---------------------------------------------------------------------------
+
+func TestParse_RoundTrip(t *testing.T) {
+	for _, rule := range []string{
+		"FREQ=WEEKLY;BYDAY=MO,TH",
+		"FREQ=WEEKLY;INTERVAL=2;BYDAY=TU",
+		"FREQ=MONTHLY;BYDAY=1FR",
+		"FREQ=MONTHLY;BYDAY=-1SU",
+		"FREQ=MONTHLY;BYMONTHDAY=28,29,30,31;BYSETPOS=-1",
+		"FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR;COUNT=10",
+		"FREQ=WEEKLY;BYDAY=SA;UNTIL=20261231",
+	} {
+		r, err := Parse(rule)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", rule, err)
+			continue
+		}
+		if got := r.String(); got != rule {
+			t.Errorf("String() = %q, want %q", got, rule)
+		}
+	}
+}
+
+func TestParse_AcceptsPrefixAndLowercase(t *testing.T) {
+	r, err := Parse("RRULE:freq=weekly;byday=mo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.String() != "FREQ=WEEKLY;BYDAY=MO" {
+		t.Errorf("String() = %q", r.String())
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	for _, rule := range []string{
+		"",
+		"BYDAY=MO",
+		"FREQ=YEARLY",
+		"FREQ=HOURLY",
+		"FREQ=WEEKLY;INTERVAL=0",
+		"FREQ=WEEKLY;BYDAY=XX",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=WEEKLY;COUNT=3;UNTIL=20261231",
+		"FREQ=WEEKLY;FREQ=DAILY",
+		"FREQ=WEEKLY;BYHOUR=9",
+	} {
+		if _, err := Parse(rule); err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", rule)
+		}
+	}
+}
+
+func TestParse_UntilDateTimeUsesBucharestDate(t *testing.T) {
+	// 22:30 UTC on Jan 31 is already Feb 1 in Bucharest.
+	r, err := Parse("FREQ=DAILY;UNTIL=20260131T223000Z")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := r.Until.Format("2006-01-02"); got != "2026-02-01" {
+		t.Errorf("Until = %s, want 2026-02-01", got)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Between
+// ---------------------------------------------------------------------------
+
+func TestBetween_Weekly(t *testing.T) {
+	// 2026-03-02 is a Monday.
+	got := expand(t, "FREQ=WEEKLY;BYDAY=MO", "2026-03-02", "2026-03-02", "2026-03-23")
+	assertDates(t, got, "2026-03-02", "2026-03-09", "2026-03-16", "2026-03-23")
+}
+
+func TestBetween_MondayAndThursday(t *testing.T) {
+	got := expand(t, "FREQ=WEEKLY;BYDAY=MO,TH", "2026-03-02", "2026-03-01", "2026-03-12")
+	assertDates(t, got, "2026-03-02", "2026-03-05", "2026-03-09", "2026-03-12")
+}
+
+func TestBetween_StartsMidWeek(t *testing.T) {
+	// Starting on Wednesday: the Monday of the same week is before dtstart.
+	got := expand(t, "FREQ=WEEKLY;BYDAY=MO,TH", "2026-03-04", "2026-03-01", "2026-03-10")
+	assertDates(t, got, "2026-03-05", "2026-03-09")
+}
+
+func TestBetween_Biweekly(t *testing.T) {
+	got := expand(t, "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU", "2026-03-03", "2026-03-01", "2026-04-01")
+	assertDates(t, got, "2026-03-03", "2026-03-17", "2026-03-31")
+}
+
+func TestBetween_EveryWeekday(t *testing.T) {
+	got := expand(t, "FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR", "2026-03-05", "2026-03-05", "2026-03-10")
+	assertDates(t, got, "2026-03-05", "2026-03-06", "2026-03-09", "2026-03-10")
+}
+
+func TestBetween_FirstFridayOfMonth(t *testing.T) {
+	got := expand(t, "FREQ=MONTHLY;BYDAY=1FR", "2026-01-01", "2026-01-01", "2026-04-30")
+	assertDates(t, got, "2026-01-02", "2026-02-06", "2026-03-06", "2026-04-03")
+}
+
+func TestBetween_LastSundayOfMonth(t *testing.T) {
+	got := expand(t, "FREQ=MONTHLY;BYDAY=-1SU", "2026-01-01", "2026-01-01", "2026-03-31")
+	assertDates(t, got, "2026-01-25", "2026-02-22", "2026-03-29")
+}
+
+func TestBetween_MonthlyOn31stSkipsShortMonths(t *testing.T) {
+	// Plain RFC 5545 semantics: invalid dates are skipped, never shifted into
+	// the next month.
+	got := expand(t, "FREQ=MONTHLY", "2026-01-31", "2026-01-01", "2026-05-31")
+	assertDates(t, got, "2026-01-31", "2026-03-31", "2026-05-31")
+}
+
+func TestBetween_CountIncludesExdates(t *testing.T) {
+	got := expand(t, "FREQ=WEEKLY;BYDAY=MO;COUNT=3", "2026-03-02", "2026-01-01", "2026-12-31", d("2026-03-09"))
+	assertDates(t, got, "2026-03-02", "2026-03-16")
+}
+
+func TestBetween_Until(t *testing.T) {
+	got := expand(t, "FREQ=WEEKLY;BYDAY=MO;UNTIL=20260316", "2026-03-02", "2026-03-01", "2026-12-31")
+	assertDates(t, got, "2026-03-02", "2026-03-09", "2026-03-16")
+}
+
+func TestBetween_WindowAfterStart(t *testing.T) {
+	got := expand(t, "FREQ=WEEKLY;BYDAY=MO", "2025-01-06", "2026-03-03", "2026-03-20")
+	assertDates(t, got, "2026-03-09", "2026-03-16")
+}
+
+func TestBetween_NeverMatchingRuleTerminates(t *testing.T) {
+	got := expand(t, "FREQ=MONTHLY;BYMONTHDAY=31;BYDAY=1MO", "2026-01-01", "2026-01-01", "2030-01-01")
+	assertDates(t, got)
+}
+
+// ---------------------------------------------------------------------------
+// FromLegacy
+// ---------------------------------------------------------------------------
+
+func TestFromLegacy(t *testing.T) {
+	tests := []struct {
+		recType string
+		anchor  string
+		want    string
+	}{
+		{"weekly", "2026-03-05", "FREQ=WEEKLY;BYDAY=TH"},
+		{"biweekly", "2026-03-02", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO"},
+		{"monthly", "2026-03-15", "FREQ=MONTHLY;BYMONTHDAY=15"},
+		{"monthly", "2026-01-30", "FREQ=MONTHLY;BYMONTHDAY=28,29,30;BYSETPOS=-1"},
+	}
+	for _, tt := range tests {
+		got, err := FromLegacy(tt.recType, d(tt.anchor))
+		if err != nil {
+			t.Errorf("FromLegacy(%s, %s): %v", tt.recType, tt.anchor, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("FromLegacy(%s, %s) = %q, want %q", tt.recType, tt.anchor, got, tt.want)
+		}
+	}
+	if _, err := FromLegacy("yearly", d("2026-01-01")); err == nil {
+		t.Error("FromLegacy(yearly) succeeded, want error")
+	}
+}
+
+func TestFromLegacy_MonthlyJan31(t *testing.T) {
+	// The legacy monthly series on the 31st used to land on March 3rd after
+	// January; the mapped rule keeps it on the last day of each month.
+	rule, err := FromLegacy("monthly", d("2026-01-31"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := expand(t, rule, "2026-01-31", "2026-01-01", "2026-04-30")
+	assertDates(t, got, "2026-01-31", "2026-02-28", "2026-03-31", "2026-04-30")
+}
+
+func TestFromLegacy_MatchesLegacyWeekly(t *testing.T) {
+	anchor := d("2026-03-04")
+	rule, _ := FromLegacy("biweekly", anchor)
+	r, _ := Parse(rule)
+	got := r.Between(anchor, anchor, anchor.AddDate(0, 0, 70), nil)
+	for k, date := range got {
+		if want := anchor.AddDate(0, 0, 14*k); !date.Equal(want) {
+			t.Fatalf("occurrence %d = %s, want %s", k, date.Format("2006-01-02"), want.Format("2006-01-02"))
+		}
+	}
+	if len(got) != 6 {
+		t.Errorf("got %d occurrences, want 6", len(got))
+	}
+}
+
+// ---------------------------------------------------------------------------
+// StartTime
+// ---------------------------------------------------------------------------
+
+func TestStartTime_AcrossDST(t *testing.T) {
+	// 10:00 in Bucharest is 08:00 UTC in winter and 07:00 UTC in summer
+	// (DST starts on 2026-03-29).
+	tenAM := int64(10 * 3_600_000_000)
+	if got := StartTime(d("2026-03-28"), tenAM).UTC().Hour(); got != 8 {
+		t.Errorf("winter start = %02d:00 UTC, want 08:00", got)
+	}
+	if got := StartTime(d("2026-03-30"), tenAM).UTC().Hour(); got != 7 {
+		t.Errorf("summer start = %02d:00 UTC, want 07:00", got)
+	}
+}
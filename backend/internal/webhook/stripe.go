@@ -1,6 +1,7 @@
 package webhook
 
 import (
+	"errors"
 	"io"
 	"log"
 	"net/http"
@@ -26,8 +27,9 @@ func NewStripeHandler(paymentService *payment.Service) *StripeHandler {
 // ServeHTTP handles an incoming Stripe webhook request.
 // It reads the raw body, extracts the Stripe-Signature header, and
 // delegates to the payment service for verification and processing.
-// Stripe expects a 200 response promptly; errors are logged but still
-// return 200 to prevent unnecessary retries for application-level issues.
+// Verified events are stored before processing, so a 200 response means the
+// event is durable even if processing failed (it is retried in the
+// background). Storage failures return 500 so Stripe redelivers the event.
 func (h *StripeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// Only accept POST requests.
 	if r.Method != http.MethodPost {
@@ -57,12 +59,17 @@ func (h *StripeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.paymentService.HandleWebhookEvent(r.Context(), payload, sigHeader); err != nil {
-		// Log the error for observability but still return 200 to Stripe.
-		// Signature verification failures are the exception -- they indicate
-		// a tampered or replayed request, so we reject with 400.
 		log.Printf("stripe webhook: error handling event: %v", err)
 		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
+		// Signature verification failures indicate a tampered or forged
+		// request, so we reject with 400. Anything else means the event was
+		// not stored, so Stripe must retry.
+		if errors.Is(err, payment.ErrInvalidSignature) {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid signature"}`))
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
 		_, _ = w.Write([]byte(`{"error":"webhook processing failed"}`))
 		return
 	}